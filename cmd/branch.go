@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/samzong/gmc/internal/branch"
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+// branchDiffLimit bounds the staged diff gmc branch suggest sends.
+const branchDiffLimit = 8000
+
+// branchTypes are the type prefixes offered for --type completion.
+var branchTypes = []string{"feat", "fix", "docs", "refactor", "test", "chore"}
+
+var (
+	branchCount    int
+	branchType     string
+	branchTicket   string
+	branchInitials bool
+	branchOffline  bool
+	branchAutoYes  bool
+
+	branchCmd = &cobra.Command{
+		Use:   "branch",
+		Short: "Name and create branches",
+		Long:  `Suggest branch names for a piece of work and create the one you pick.`,
+		Example: `  gmc branch suggest add OAuth login
+  gmc branch suggest --type fix --initials`,
+	}
+
+	branchSuggestCmd = &cobra.Command{
+		Use:   "suggest [description...]",
+		Short: "Suggest branch names for a change",
+		Long: `Suggest branch names as <type>/<slug>, such as feat/oauth-login, for the work
+the description names. Without a description, the LLM reads the staged diff to
+infer it.
+
+--type forces the type prefix and --initials puts the initials of the git
+user.name first, as in jd/feat/oauth-login. The names follow the branch_naming
+convention, whose pattern can place --ticket, as in feat/PROJ-12-oauth-login.
+With a description and no API key, or with --offline, the names come from the
+description's words alone.
+
+On a terminal, gmc asks which suggestion to create and switches to it; Enter
+skips. --yes creates the first one without asking.`,
+		Example: `  gmc branch suggest add OAuth login
+  gmc branch suggest --type fix --initials login redirect loops
+  gmc branch suggest -n 5 --ticket PROJ-12
+  gmc branch suggest -y resolve crash on startup`,
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runBranchSuggest(os.Stdin, strings.Join(args, " "))
+		},
+	}
+)
+
+func init() {
+	branchSuggestCmd.Flags().IntVarP(&branchCount, "count", "n", 3, "Number of names to suggest")
+	branchSuggestCmd.Flags().StringVar(&branchType, "type", "",
+		"Use `type` as the prefix of every name, such as feat or fix")
+	_ = branchSuggestCmd.RegisterFlagCompletionFunc("type",
+		cobra.FixedCompletions(branchTypes, cobra.ShellCompDirectiveNoFileComp))
+	branchSuggestCmd.Flags().StringVar(&branchTicket, "ticket", "",
+		"Ticket `id` for the {ticket} placeholder of the branch_naming pattern")
+	branchSuggestCmd.Flags().BoolVar(&branchInitials, "initials", false,
+		"Start the names with the initials of the git user.name")
+	branchSuggestCmd.Flags().BoolVar(&branchOffline, "offline", false,
+		"Build the names from the description's words instead of asking the LLM")
+	branchSuggestCmd.Flags().BoolVarP(&branchAutoYes, "yes", "y", false,
+		"Create and switch to the first suggestion without asking")
+	branchCmd.AddCommand(branchSuggestCmd)
+	rootCmd.AddCommand(branchCmd)
+}
+
+// BranchSuggestJSON is the JSON output of gmc branch suggest.
+type BranchSuggestJSON struct {
+	Suggestions []string `json:"suggestions"`
+	// Created is the branch created with --yes, empty when none was.
+	Created string `json:"created,omitempty"`
+}
+
+func runBranchSuggest(in io.Reader, description string) error {
+	if branchCount < 1 {
+		return errors.New("--count must be at least 1")
+	}
+	if branchOffline && description == "" {
+		return errors.New("--offline needs a description")
+	}
+	gitClient, err := newGitClient()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	opts := branch.Options{Type: branchType, Ticket: branchTicket, Naming: cfg.BranchNaming}
+	if branchInitials {
+		name, err := gitClient.UserName()
+		if err != nil {
+			return err
+		}
+		if opts.Owner = branch.Initials(name); opts.Owner == "" {
+			return errors.New("git user.name is not set; set it or drop --initials")
+		}
+	}
+
+	var change string
+	if description == "" {
+		changes, err := gitClient.GetStagedChanges()
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(changes.Diff) == "" {
+			return fmt.Errorf("%w; describe the work to name the branch after it", workflow.ErrNoChanges)
+		}
+		change = "Staged files:\n" + strings.Join(changes.Files, "\n") + "\n\n" +
+			formatter.TruncateDiff(changes.Diff, branchDiffLimit)
+	}
+
+	names, err := suggestBranchNames(in, cfg, description, change, opts)
+	if err != nil {
+		return err
+	}
+	created := ""
+	if branchAutoYes {
+		created = names[0]
+	}
+	if outputFormat() == "json" {
+		if created != "" {
+			if err := createBranch(gitClient, created, opts.Naming); err != nil {
+				return err
+			}
+		}
+		return printJSON(outWriter(), BranchSuggestJSON{Suggestions: names, Created: created})
+	}
+
+	for i, name := range names {
+		fmt.Fprintf(outWriter(), "%d. %s\n", i+1, name)
+	}
+	if created == "" && isStdinTerminal() {
+		if created, err = pickBranch(in, errWriter(), names); err != nil || created == "" {
+			return err
+		}
+	}
+	if created == "" {
+		return nil
+	}
+	if err := createBranch(gitClient, created, opts.Naming); err != nil {
+		return err
+	}
+	fmt.Fprintf(errWriter(), "Switched to a new branch '%s'\n", created)
+	return nil
+}
+
+// createBranch creates and switches to name once it passes the branch_naming rules.
+func createBranch(gitClient *git.Client, name string, naming config.BranchNaming) error {
+	if err := branch.Check(name, naming); err != nil {
+		return err
+	}
+	return gitClient.CreateAndSwitchBranch(name)
+}
+
+// suggestBranchNames returns up to branchCount normalized names for description, or
+// for change, the staged diff, when there is no description. A description is named
+// locally with --offline, without an API key, or when the LLM cannot be reached.
+func suggestBranchNames(
+	in io.Reader, cfg *config.Config, description, change string, opts branch.Options,
+) ([]string, error) {
+	if description != "" {
+		local := branch.Variants(description, opts)
+		if len(local) == 0 {
+			return nil, fmt.Errorf("no branch name can be made from %q", description)
+		}
+		if branchOffline {
+			return local, nil
+		}
+		if strings.TrimSpace(cfg.APIKey) == "" {
+			fmt.Fprintln(errWriter(), "Naming the branch from the description; run `gmc init` to ask the LLM.")
+			return local, nil
+		}
+		names, err := askBranchNames(cfg, description, opts)
+		if llm.IsUnreachable(err) {
+			fmt.Fprintf(errWriter(), "Warning: %v; naming the branch from the description.\n", err)
+			return local, nil
+		}
+		return names, err
+	}
+
+	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	if err != nil {
+		return nil, err
+	}
+	if !proceed {
+		return nil, errAPIKeyMissing
+	}
+	return askBranchNames(cfg, change, opts)
+}
+
+func askBranchNames(cfg *config.Config, change string, opts branch.Options) ([]string, error) {
+	fmt.Fprintln(errWriter(), "Suggesting branch names...")
+	replies, err := newLLMClient().SuggestBranchNames(change, branchCount, cfg.Model)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(replies))
+	for _, reply := range replies {
+		names = append(names, branch.Normalize(reply, opts))
+	}
+	if names = branch.Dedupe(names); len(names) == 0 {
+		return nil, fmt.Errorf("LLM suggested no usable branch name: %w", llm.ErrLLM)
+	}
+	return names, nil
+}
+
+// pickBranch asks which of names to create and returns it, or "" when the answer is
+// empty.
+func pickBranch(in io.Reader, out io.Writer, names []string) (string, error) {
+	readLine := newTrimmedLineReader(in)
+	for {
+		fmt.Fprintf(out, "Create which branch? [1-%d, Enter to skip]: ", len(names))
+		answer, err := readLine()
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			return "", nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(names) {
+			return names[n-1], nil
+		}
+		fmt.Fprintf(out, "Enter a number from 1 to %d.\n", len(names))
+	}
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickBranch(t *testing.T) {
+	names := []string{"feat/oauth-login", "feat/add-oauth"}
+
+	var out bytes.Buffer
+	picked, err := pickBranch(strings.NewReader("3\nx\n2\n"), &out, names)
+	require.NoError(t, err)
+	assert.Equal(t, "feat/add-oauth", picked)
+	assert.Equal(t, 2, strings.Count(out.String(), "Enter a number from 1 to 2."))
+
+	for _, input := range []string{"\n", ""} {
+		picked, err = pickBranch(strings.NewReader(input), &out, names)
+		require.NoError(t, err)
+		assert.Empty(t, picked)
+	}
+}
@@ -4,17 +4,25 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/samzong/gmc/internal/analyzer"
 	"github.com/samzong/gmc/internal/config"
 	"github.com/samzong/gmc/internal/exitcode"
+	"github.com/samzong/gmc/internal/formatter"
 	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/gmcerrors"
+	"github.com/samzong/gmc/internal/guard"
 	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/review"
 	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVersion(t *testing.T) {
@@ -46,6 +54,55 @@ func TestInitConfig(t *testing.T) {
 	})
 }
 
+func TestResolveWorkDir(t *testing.T) {
+	original, err := os.Getwd()
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		workDir, workDirErr = "", nil
+		config.SetWorkDir("")
+	})
+
+	repo := initCmdTestRepo(t)
+	rel, err := filepath.Rel(original, repo)
+	assert.NoError(t, err)
+	workDir = rel
+	resolveWorkDir()
+	assert.NoError(t, preRun(versionCmd, nil))
+	assert.Equal(t, repo, workDir)
+	assert.Equal(t, filepath.Join(repo, "msg.txt"), workPath("msg.txt"))
+
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.Equal(t, original, cwd, "--cwd must not change the process directory")
+
+	root, err := newWorktreeClient().GetRepoRoot()
+	assert.NoError(t, err)
+	assert.Equal(t, evalPath(t, repo), evalPath(t, root))
+
+	workDir = repo + "/missing"
+	resolveWorkDir()
+	assert.ErrorContains(t, preRun(versionCmd, nil), "cannot use --cwd")
+}
+
+func TestAllowSecretsOnLLMCommands(t *testing.T) {
+	t.Cleanup(func() { allowSecrets = false })
+	for _, args := range [][]string{{"squash"}, {"rewrite"}, {"watch"}, {"serve", "http"}, {"serve", "mcp"},
+		{"explain"}, {"review"}, {"branch", "suggest"}} {
+		cmd, rest, err := rootCmd.Find(args)
+		require.NoError(t, err)
+		allowSecrets = false
+		require.NoError(t, cmd.ParseFlags(append(rest, "--allow-secrets")), args)
+		assert.True(t, allowSecrets, args)
+	}
+}
+
+func evalPath(t *testing.T, path string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(path)
+	assert.NoError(t, err)
+	return resolved
+}
+
 func TestHandleErrors(t *testing.T) {
 	t.Run("returns nil for nil error", func(t *testing.T) {
 		assert.NoError(t, handleErrors(nil, false))
@@ -84,6 +141,27 @@ func TestHandleErrors(t *testing.T) {
 		assert.Equal(t, exitcode.NoStagedChanges, exitErr.Code)
 	})
 
+	t.Run("typed errors return their exit codes", func(t *testing.T) {
+		llmErr := func(kind error) error {
+			return gmcerrors.Mark(fmt.Errorf("failed to call LLM: %w", llm.ErrLLM), kind)
+		}
+		cases := map[int]error{
+			exitcode.ConfigMissing:  gmcerrors.Mark(errors.New("API key not set"), gmcerrors.ErrConfigMissing),
+			exitcode.LLMAuth:        llmErr(gmcerrors.ErrLLMAuth),
+			exitcode.LLMTimeout:     llmErr(gmcerrors.ErrLLMTimeout),
+			exitcode.UserCancelled:  fmt.Errorf("pkg: %w", gmcerrors.ErrUserCancelled),
+			exitcode.SecretsFound:   fmt.Errorf("failed to generate commit message: %w", guard.ErrSecretsFound),
+			exitcode.ReviewFindings: fmt.Errorf("%w: 1 finding(s) at or above high", review.ErrFindings),
+			exitcode.QualityBelowMinScore: fmt.Errorf("%w: the average score of 5 commit(s) is 48.0, below 70",
+				analyzer.ErrBelowMinScore),
+		}
+		for code, cause := range cases {
+			var exitErr *exitcode.Error
+			assert.ErrorAs(t, handleErrors(cause, false), &exitErr)
+			assert.Equal(t, code, exitErr.Code, cause.Error())
+		}
+	})
+
 	t.Run("propagates generic error", func(t *testing.T) {
 		expectedErr := errors.New("boom")
 		err := handleErrors(expectedErr, false)
@@ -92,6 +170,16 @@ func TestHandleErrors(t *testing.T) {
 	})
 }
 
+func TestWrapGitError(t *testing.T) {
+	var exitErr *exitcode.Error
+	err := wrapGitError(fmt.Errorf("failed to read commit history: %w", gmcerrors.ErrNotARepo))
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, exitcode.NotGitRepo, exitErr.Code)
+
+	plain := errors.New("boom")
+	assert.Equal(t, plain, wrapGitError(plain))
+}
+
 func TestGlobalVariables(t *testing.T) {
 	assert.IsType(t, "", cfgFile)
 	assert.IsType(t, false, noVerify)
@@ -143,6 +231,10 @@ func TestCommandFlags(t *testing.T) {
 	assert.NotNil(t, configFlag)
 	assert.Equal(t, "string", configFlag.Value.Type())
 
+	noColorFlag := persistentFlags.Lookup("no-color")
+	assert.NotNil(t, noColorFlag)
+	assert.Equal(t, "bool", noColorFlag.Value.Type())
+
 	noVerifyFlag := flags.Lookup("no-verify")
 	assert.NotNil(t, noVerifyFlag)
 	assert.Equal(t, "bool", noVerifyFlag.Value.Type())
@@ -151,6 +243,15 @@ func TestCommandFlags(t *testing.T) {
 	assert.NotNil(t, noSignoffFlag)
 	assert.Equal(t, "bool", noSignoffFlag.Value.Type())
 
+	signoffFlag := flags.Lookup("signoff")
+	assert.NotNil(t, signoffFlag)
+	assert.Equal(t, "bool", signoffFlag.Value.Type())
+
+	gpgSignFlag := flags.Lookup("gpg-sign")
+	assert.NotNil(t, gpgSignFlag)
+	assert.Equal(t, "string", gpgSignFlag.Value.Type())
+	assert.Equal(t, gpgSignDefaultKey, gpgSignFlag.NoOptDefVal)
+
 	dryRunFlag := flags.Lookup("dry-run")
 	assert.NotNil(t, dryRunFlag)
 	assert.Equal(t, "bool", dryRunFlag.Value.Type())
@@ -159,6 +260,14 @@ func TestCommandFlags(t *testing.T) {
 	assert.NotNil(t, allFlag)
 	assert.Equal(t, "bool", allFlag.Value.Type())
 
+	acknowledgeRiskFlag := flags.Lookup("acknowledge-risk")
+	assert.NotNil(t, acknowledgeRiskFlag)
+	assert.Equal(t, "bool", acknowledgeRiskFlag.Value.Type())
+
+	interactiveFlag := flags.Lookup("interactive")
+	assert.NotNil(t, interactiveFlag)
+	assert.Equal(t, "i", interactiveFlag.Shorthand)
+
 	issueFlag := flags.Lookup("issue")
 	assert.NotNil(t, issueFlag)
 	assert.Equal(t, "string", issueFlag.Value.Type())
@@ -327,7 +436,133 @@ diff --git a/cmd/root.go b/cmd/root.go
 --- a/cmd/root.go
 +++ b/cmd/root.go`
 
-	files := workflow.ExtractFilesFromDiff(diff)
+	files := formatter.ExtractFilesFromDiff(diff)
 	assert.Contains(t, files, "main.go")
 	assert.Contains(t, files, "cmd/root.go")
 }
+
+func TestSignKey(t *testing.T) {
+	original := gpgSign
+	t.Cleanup(func() { gpgSign = original })
+
+	gpgSign = ""
+	assert.Empty(t, signKey())
+	gpgSign = gpgSignDefaultKey
+	assert.Empty(t, signKey(), "bare --gpg-sign uses git's default key")
+	gpgSign = "ABCD1234"
+	assert.Equal(t, "ABCD1234", signKey())
+
+	cfg := &config.Config{}
+	assert.NoError(t, applyFlagOverrides(cfg))
+	assert.True(t, cfg.SignCommits, "--gpg-sign turns on sign_commits")
+}
+
+func TestTemperatureFlag(t *testing.T) {
+	flag := &temperatureValue{}
+	assert.Nil(t, flag.value, "unset leaves the temperature config in effect")
+	assert.Empty(t, flag.String())
+
+	require.NoError(t, flag.Set("0"))
+	require.NotNil(t, flag.value)
+	assert.Zero(t, *flag.value)
+	require.NoError(t, flag.Set("1.5"))
+	assert.Equal(t, "1.5", flag.String())
+
+	assert.Error(t, flag.Set("2.5"))
+	assert.Error(t, flag.Set("warm"))
+	assert.Equal(t, "1.5", flag.String(), "an invalid value keeps the previous one")
+
+	topP := 0.9
+	assert.Equal(t, "<API defaults>", samplingSummary(&config.Config{}))
+	assert.Equal(t, "top_p 0.9, max_tokens 256", samplingSummary(&config.Config{TopP: &topP, MaxTokens: 256}))
+}
+
+func TestApplyFlagDefaults(t *testing.T) {
+	defer func(all, yes, dry, skip, verb bool, timeout, connect int) {
+		addAll, autoYes, dryRun, noVerify, verbose = all, yes, dry, skip, verb
+		timeoutSeconds, connectTimeout = timeout, connect
+	}(addAll, autoYes, dryRun, noVerify, verbose, timeoutSeconds, connectTimeout)
+	addAll, autoYes, dryRun, noVerify, verbose = false, false, false, false, false
+	timeoutSeconds, connectTimeout = 30, 10
+
+	cmd := &cobra.Command{}
+	var unused bool
+	for _, name := range []string{"all", "interactive", "yes", "dry-run", "no-verify", "verbose"} {
+		cmd.Flags().BoolVar(&unused, name, false, "")
+	}
+	cmd.Flags().Int("timeout", 30, "")
+	cmd.Flags().Int("connect-timeout", 10, "")
+	require.NoError(t, cmd.ParseFlags([]string{"--yes=false", "--interactive"}))
+
+	timeout, noLimit := 60, 0
+	applyFlagDefaults(cmd, config.FlagDefaults{
+		AddAll: true, AutoYes: true, DryRun: true, Verbose: true, Timeout: &timeout, ConnectTimeout: &noLimit,
+	})
+	assert.False(t, addAll, "add_all does not apply to --interactive")
+	assert.False(t, autoYes, "a flag on the command line wins")
+	assert.True(t, dryRun)
+	assert.False(t, noVerify)
+	assert.True(t, verbose)
+	assert.Equal(t, 60, timeoutSeconds)
+	assert.Equal(t, 0, connectTimeout, "0 is kept, for no limit")
+
+	assert.Equal(t, "<None>", flagDefaultsSummary(config.FlagDefaults{}))
+	timeout = 90
+	assert.Equal(t, "--all --no-verify --timeout 90",
+		flagDefaultsSummary(config.FlagDefaults{AddAll: true, NoVerify: true, Timeout: &timeout}))
+	assert.Equal(t, llm.NoTimeout, llmTimeout(0))
+	assert.Equal(t, 90*time.Second, llmTimeout(90))
+}
+
+func TestCheckGenerationFlags(t *testing.T) {
+	defer func(out, file string, dry, perPkg bool) {
+		outFile, messageFile, dryRun, perPackage = out, file, dry, perPkg
+	}(outFile, messageFile, dryRun, perPackage)
+
+	outFile, messageFile, dryRun, perPackage = "msg.txt", "", false, false
+	assert.EqualError(t, checkGenerationFlags(), "--out needs --dry-run; without it, gmc commits the message")
+	dryRun = true
+	assert.NoError(t, checkGenerationFlags())
+
+	outFile, messageFile, perPackage = "", "msg.txt", true
+	assert.EqualError(t, checkGenerationFlags(),
+		"--out and --use-message-file cannot be combined with --per-package")
+	perPackage = false
+	assert.NoError(t, checkGenerationFlags())
+
+	defer func(models []string) { compareModels = models }(compareModels)
+	compareModels = []string{"gpt-4o", "o3-mini"}
+	assert.EqualError(t, checkGenerationFlags(),
+		"--models needs the LLM; it cannot be combined with --offline or --use-message-file")
+}
+
+func TestCheckTypeAndScopeFlags(t *testing.T) {
+	defer func(typ, scope, file string, perPkg bool) {
+		typeFlag, scopeFlag, messageFile, perPackage = typ, scope, file, perPkg
+	}(typeFlag, scopeFlag, messageFile, perPackage)
+
+	cfg := &config.Config{CommitTypes: []string{"feat", "fix"}}
+	typ, scope, err := checkTypeAndScopeFlags(cfg, " Fix ", " auth ")
+	require.NoError(t, err)
+	assert.Equal(t, "fix", typ)
+	assert.Equal(t, "auth", scope)
+
+	_, _, err = checkTypeAndScopeFlags(cfg, "chore", "")
+	assert.EqualError(t, err, "--type chore is not in commit_types: feat, fix")
+	_, _, err = checkTypeAndScopeFlags(&config.Config{}, "fix(auth)", "")
+	assert.ErrorContains(t, err, "invalid --type value")
+	_, _, err = checkTypeAndScopeFlags(cfg, "", "auth: x")
+	assert.ErrorContains(t, err, "invalid --scope value")
+
+	typeFlag, scopeFlag = "", "auth"
+	messageFile, perPackage = "msg.txt", false
+	assert.ErrorContains(t, checkGenerationFlags(), "cannot be combined with --use-message-file")
+	messageFile, perPackage = "", true
+	assert.EqualError(t, checkGenerationFlags(),
+		"--scope cannot be combined with --per-package, which scopes each package's commit")
+}
+
+func TestModelList(t *testing.T) {
+	assert.Equal(t, []string{"gpt-4o", "claude-3-haiku"}, modelList([]string{" gpt-4o", "", "claude-3-haiku", "gpt-4o"}))
+	assert.Nil(t, modelList(nil))
+}
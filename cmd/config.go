@@ -4,18 +4,26 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/mattn/go-isatty"
+	"github.com/samzong/gmc/internal/branch"
 	"github.com/samzong/gmc/internal/config"
 	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/version"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
 var (
 	configOutputJSON bool
+	migrateDryRun    bool
+	migrateForce     bool
 
 	configCmd = &cobra.Command{
 		Use:   "config",
@@ -83,6 +91,36 @@ Usage:
 		},
 	}
 
+	configSetFallbackTemplateCmd = &cobra.Command{
+		Use:   "fallback_template [Template Path]",
+		Short: "Set the template used when the prompt fails",
+		Long: `Set the prompt template gmc uses when prompt_template fails to load, parse or
+render. gmc prints the error with the template file and line, then tries this
+template, and the built-in template after it.
+
+Use "default" to fall back to the built-in template directly.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigSetFallbackTemplate(args)
+		},
+	}
+
+	configSetTagTemplateCmd = &cobra.Command{
+		Use:   "tag_template [Template Path]",
+		Short: "Set the annotated tag message template",
+		Long: `Set a Go template file used for annotated tag messages created by 'gmc tag'.
+
+The template receives .Version, .Previous, .Date, .Reason, .Stats (Total, Breaking,
+Features, Fixes, Others) and .GroupedCommits (each with .Title and .Commits, where a
+commit has .Subject, .ShortHash, .Hash, .Author and .Date).
+
+Use "default" to go back to the built-in "Release <version>: <reason>" message.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigSetTagTemplate(args)
+		},
+	}
+
 	configSetEnableEmojiCmd = &cobra.Command{
 		Use:   "enable_emoji [true|false]",
 		Short: "Enable or disable emoji in commit messages",
@@ -92,6 +130,60 @@ Usage:
 		},
 	}
 
+	configSetCommitBodyCmd = &cobra.Command{
+		Use:   "commit_body [true|false]",
+		Short: "Enable or disable bullet-point commit bodies",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigSetCommitBody(args)
+		},
+	}
+
+	configSetTypeHintsCmd = &cobra.Command{
+		Use:   "type_hints <off|soft|strict>",
+		Short: "Set commit type hinting mode",
+		Long: `Set how gmc uses the commit type inferred from staged file categories.
+
+  off     Do not infer a type
+  soft    Suggest the inferred type in the prompt (default)
+  strict  Force the inferred type onto the generated message
+
+A type is inferred only when every changed file is a test, docs, or CI file.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{config.TypeHintsOff, config.TypeHintsSoft, config.TypeHintsStrict},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigSetTypeHints(args)
+		},
+	}
+
+	configSetLanguageCmd = &cobra.Command{
+		Use:   "language <tag>",
+		Short: "Set the commit description language",
+		Long: `Set the language the LLM writes commit descriptions in, such as zh-CN, ja or de.
+The conventional type and scope always stay in English.
+
+Use "en" to go back to English. The --lang flag overrides this setting for a single run.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigSetLanguage(args)
+		},
+	}
+
+	configMigrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Move ~/.gmc.yaml to the XDG config location",
+		Long: `Move the legacy ~/.gmc.yaml to $XDG_CONFIG_HOME/gmc/config.yaml
+(default ~/.config/gmc/config.yaml).
+
+The migration renames deprecated keys, sets the new file's permissions to 0600,
+and replaces ~/.gmc.yaml with a comment-only pointer to the new location.
+Every action taken is reported. Use --dry-run to preview the actions first.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runConfigMigrate()
+		},
+	}
+
 	configGetCmd = &cobra.Command{
 		Use:   "get",
 		Short: "Get Current Configuration",
@@ -103,12 +195,59 @@ Usage:
 
 // configJSONOutput is the JSON structure for config get --json
 type configJSONOutput struct {
-	Role           string `json:"role"`
-	Model          string `json:"model"`
-	APIKeySet      bool   `json:"api_key_set"`
-	APIBase        string `json:"api_base"`
-	PromptTemplate string `json:"prompt_template"`
-	EnableEmoji    bool   `json:"enable_emoji"`
+	Role                 string              `json:"role"`
+	Model                string              `json:"model"`
+	APIKeySet            bool                `json:"api_key_set"`
+	APIBase              string              `json:"api_base"`
+	PromptTemplate       string              `json:"prompt_template"`
+	FallbackTemplate     string              `json:"fallback_template"`
+	ReviewTemplate       string              `json:"review_template"`
+	EnableEmoji          bool                `json:"enable_emoji"`
+	IssueContext         bool                `json:"issue_context"`
+	IssuePattern         string              `json:"issue_pattern"`
+	IssueFormat          string              `json:"issue_format"`
+	JiraURL              string              `json:"jira_url"`
+	JiraEmail            string              `json:"jira_email"`
+	JiraTokenSet         bool                `json:"jira_token_set"`
+	Forge                string              `json:"forge"`
+	GitHubTokenSet       bool                `json:"github_token_set"`
+	GitLabTokenSet       bool                `json:"gitlab_token_set"`
+	GiteaTokenSet        bool                `json:"gitea_token_set"`
+	TypeHints            string              `json:"type_hints"`
+	Language             string              `json:"language"`
+	CommitBody           bool                `json:"commit_body"`
+	TagTemplate          string              `json:"tag_template"`
+	ExecPresets          map[string]string   `json:"exec_presets,omitempty"`
+	Components           map[string]string   `json:"components,omitempty"`
+	CommitTypes          []string            `json:"commit_types,omitempty"`
+	GenerationNotes      bool                `json:"generation_notes"`
+	Attribution          bool                `json:"attribution"`
+	SignCommits          bool                `json:"sign_commits"`
+	HookAutofixRetry     bool                `json:"hook_autofix_retry"`
+	Preflight            bool                `json:"preflight"`
+	Temperature          *float64            `json:"temperature,omitempty"`
+	TopP                 *float64            `json:"top_p,omitempty"`
+	MaxTokens            int                 `json:"max_tokens,omitempty"`
+	SummarizeDiffs       bool                `json:"summarize_diffs"`
+	SummarizeParallelism int                 `json:"summarize_parallelism"`
+	SummarizeTimeout     int                 `json:"summarize_timeout"`
+	SummarizeThreshold   int                 `json:"summarize_threshold"`
+	OutlineNewFiles      bool                `json:"outline_new_files"`
+	GitBackend           string              `json:"git_backend"`
+	PackageGlobs         []string            `json:"package_globs"`
+	ExcludePaths         []string            `json:"exclude_paths"`
+	HTTPProxy            string              `json:"http_proxy"`
+	CACert               string              `json:"ca_cert"`
+	TLSInsecure          bool                `json:"tls_insecure"`
+	ExtraHeaders         []string            `json:"extra_headers,omitempty"`
+	Defaults             config.FlagDefaults `json:"defaults"`
+	Guard                config.Guard        `json:"guard"`
+	BranchNaming         config.BranchNaming `json:"branch_naming"`
+	EmojiMap             map[string]string   `json:"emoji_map,omitempty"`
+	EmojiStyle           string              `json:"emoji_style"`
+	ScopeRules           []config.ScopeRule  `json:"scope_rules,omitempty"`
+	RiskPolicies         []config.RiskPolicy `json:"risk_policies,omitempty"`
+	Trailers             []config.Trailer    `json:"trailers,omitempty"`
 }
 
 func saveConfig() error {
@@ -209,16 +348,61 @@ func runConfigSetPromptTemplate(args []string) error {
 	return nil
 }
 
-func runConfigSetEnableEmoji(args []string) error {
-	value := args[0]
-	var enableEmoji bool
+func runConfigSetFallbackTemplate(args []string) error {
+	templateName := args[0]
+
+	// A fallback must work on its own, so check that it renders, not only that it loads.
+	if err := formatter.CheckPromptTemplate(templateName); err != nil {
+		return fmt.Errorf("invalid fallback template: %w", err)
+	}
+
+	config.SetConfigValue("fallback_template", templateName)
+
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outWriter(), "The fallback template has been set to: %s\n", templateName)
+	return nil
+}
+
+func runConfigSetTagTemplate(args []string) error {
+	templatePath := args[0]
+	if templatePath == "default" {
+		templatePath = ""
+	} else if _, err := version.LoadTagTemplate(templatePath); err != nil {
+		return err
+	}
+
+	config.SetConfigValue("tag_template", templatePath)
+
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	if templatePath == "" {
+		fmt.Fprintln(outWriter(), "The tag template has been reset to the built-in message")
+	} else {
+		fmt.Fprintf(outWriter(), "The tag template has been set to: %s\n", templatePath)
+	}
+	return nil
+}
+
+func parseBoolArg(value string) (bool, error) {
 	switch value {
 	case "true":
-		enableEmoji = true
+		return true, nil
 	case "false":
-		enableEmoji = false
+		return false, nil
 	default:
-		return fmt.Errorf("invalid value: %s (must be 'true' or 'false')", value)
+		return false, fmt.Errorf("invalid value: %s (must be 'true' or 'false')", value)
+	}
+}
+
+func runConfigSetEnableEmoji(args []string) error {
+	enableEmoji, err := parseBoolArg(args[0])
+	if err != nil {
+		return err
 	}
 
 	config.SetConfigValue("enable_emoji", enableEmoji)
@@ -235,6 +419,95 @@ func runConfigSetEnableEmoji(args []string) error {
 	return nil
 }
 
+func runConfigSetCommitBody(args []string) error {
+	commitBody, err := parseBoolArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	config.SetConfigValue("commit_body", commitBody)
+
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	if commitBody {
+		fmt.Fprintln(outWriter(), "Commit body generation has been enabled")
+	} else {
+		fmt.Fprintln(outWriter(), "Commit body generation has been disabled")
+	}
+	return nil
+}
+
+func runConfigSetTypeHints(args []string) error {
+	value := args[0]
+	if !config.IsValidTypeHints(value) {
+		return fmt.Errorf("invalid value: %s (must be 'off', 'soft' or 'strict')", value)
+	}
+
+	config.SetConfigValue("type_hints", value)
+
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outWriter(), "Type hints have been set to: %s\n", value)
+	return nil
+}
+
+func runConfigSetLanguage(args []string) error {
+	value := args[0]
+	if !config.IsValidLanguage(value) {
+		return fmt.Errorf("invalid language: %s (expected a language tag such as zh-CN, ja or de)", value)
+	}
+	if strings.EqualFold(value, "en") {
+		value = ""
+	}
+
+	config.SetConfigValue("language", value)
+
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	if value == "" {
+		fmt.Fprintln(outWriter(), "Commit descriptions will be written in English")
+	} else {
+		fmt.Fprintf(outWriter(), "The commit description language has been set to: %s\n", value)
+	}
+	return nil
+}
+
+func runConfigMigrate() error {
+	result, err := config.MigrateLegacyConfig(config.MigrateOptions{
+		DryRun: migrateDryRun,
+		Force:  migrateForce,
+	})
+	if err != nil {
+		return err
+	}
+
+	if outputFormat() == "json" {
+		encoder := json.NewEncoder(outWriter())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	if result.Skipped != "" {
+		fmt.Fprintf(outWriter(), "Nothing to do: %s\n", result.Skipped)
+		return nil
+	}
+	if result.DryRun {
+		fmt.Fprintln(outWriter(), "Dry run, no files changed. Planned actions:")
+	} else {
+		fmt.Fprintln(outWriter(), "Migrated legacy configuration:")
+	}
+	for _, action := range result.Actions {
+		fmt.Fprintf(outWriter(), "  - %s\n", action)
+	}
+	return nil
+}
+
 func runConfigGet() error {
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -244,12 +517,59 @@ func runConfigGet() error {
 	if configOutputJSON || outputFormat() == "json" {
 		// JSON output to stdout for machine consumption
 		output := configJSONOutput{
-			Role:           cfg.Role,
-			Model:          cfg.Model,
-			APIKeySet:      cfg.APIKey != "",
-			APIBase:        cfg.APIBase,
-			PromptTemplate: cfg.PromptTemplate,
-			EnableEmoji:    cfg.EnableEmoji,
+			Role:                 cfg.Role,
+			Model:                cfg.Model,
+			APIKeySet:            cfg.APIKey != "",
+			APIBase:              cfg.APIBase,
+			PromptTemplate:       cfg.PromptTemplate,
+			FallbackTemplate:     cfg.FallbackTemplate,
+			ReviewTemplate:       cfg.ReviewTemplate,
+			EnableEmoji:          cfg.EnableEmoji,
+			IssueContext:         cfg.IssueContext,
+			IssuePattern:         cfg.IssuePattern,
+			IssueFormat:          cfg.IssueFormat,
+			JiraURL:              cfg.JiraURL,
+			JiraEmail:            cfg.JiraEmail,
+			JiraTokenSet:         cfg.ResolveJiraToken() != "",
+			Forge:                cfg.Forge,
+			GitHubTokenSet:       cfg.ResolveForgeToken("github") != "",
+			GitLabTokenSet:       cfg.ResolveForgeToken("gitlab") != "",
+			GiteaTokenSet:        cfg.ResolveForgeToken("gitea") != "",
+			TypeHints:            cfg.TypeHints,
+			Language:             cfg.Language,
+			CommitBody:           cfg.CommitBody,
+			TagTemplate:          cfg.TagTemplate,
+			ExecPresets:          cfg.ExecPresets,
+			Components:           cfg.Components,
+			CommitTypes:          cfg.AllowedCommitTypes(),
+			GenerationNotes:      cfg.GenerationNotes,
+			Attribution:          cfg.Attribution,
+			SignCommits:          cfg.SignCommits,
+			HookAutofixRetry:     cfg.HookAutofixRetry,
+			Preflight:            cfg.Preflight,
+			Temperature:          cfg.Temperature,
+			TopP:                 cfg.TopP,
+			MaxTokens:            cfg.MaxTokens,
+			SummarizeDiffs:       cfg.SummarizeDiffs,
+			SummarizeParallelism: cfg.SummarizeParallelism,
+			SummarizeTimeout:     cfg.SummarizeTimeout,
+			SummarizeThreshold:   cfg.SummarizeThreshold,
+			OutlineNewFiles:      cfg.OutlineNewFiles,
+			GitBackend:           cfg.GitBackend,
+			PackageGlobs:         cfg.PackageGlobs,
+			ExcludePaths:         cfg.ExcludePaths,
+			HTTPProxy:            redactURL(cfg.HTTPProxy),
+			CACert:               cfg.CACert,
+			TLSInsecure:          cfg.TLSInsecure,
+			ExtraHeaders:         extraHeaderNames(cfg),
+			Defaults:             cfg.Defaults,
+			Guard:                cfg.Guard,
+			BranchNaming:         cfg.BranchNaming,
+			EmojiMap:             cfg.EmojiMap,
+			EmojiStyle:           cfg.EmojiStyle,
+			ScopeRules:           cfg.ScopeRules,
+			RiskPolicies:         cfg.RiskPolicies,
+			Trailers:             cfg.Trailers,
 		}
 		encoder := json.NewEncoder(outWriter())
 		encoder.SetIndent("", "  ")
@@ -260,6 +580,13 @@ func runConfigGet() error {
 	fmt.Fprintln(outWriter(), "Current Configuration:")
 	fmt.Fprintf(outWriter(), "Role: %s\n", cfg.Role)
 	fmt.Fprintf(outWriter(), "Model: %s\n", cfg.Model)
+	fmt.Fprintf(outWriter(), "Sampling: %s\n", samplingSummary(cfg))
+	fmt.Fprintf(outWriter(), "Flag Defaults: %s\n", flagDefaultsSummary(cfg.Defaults))
+	if cfg.Guard.Redact {
+		fmt.Fprintln(outWriter(), "Secret Guard: redact")
+	} else {
+		fmt.Fprintln(outWriter(), "Secret Guard: block")
+	}
 	fmt.Fprintln(outWriter(), "API Key: ********")
 	if cfg.APIBase != "" {
 		fmt.Fprintf(outWriter(), "API Base URL: %s\n", cfg.APIBase)
@@ -267,21 +594,249 @@ func runConfigGet() error {
 		fmt.Fprintln(outWriter(), "API Base URL: <Not Set>")
 	}
 	fmt.Fprintf(outWriter(), "Prompt Template: %s\n", cfg.PromptTemplate)
+	fmt.Fprintf(outWriter(), "Fallback Template: %s\n", cfg.FallbackTemplate)
+	fmt.Fprintf(outWriter(), "Review Template: %s\n", cfg.ReviewTemplate)
+	fmt.Fprintf(outWriter(), "Branch Naming: %s\n", branchNamingSummary(cfg.BranchNaming))
 	fmt.Fprintf(outWriter(), "Enable Emoji: %v\n", cfg.EnableEmoji)
+	fmt.Fprintf(outWriter(), "Emoji Style: %s\n", cfg.EmojiStyle)
+	if len(cfg.EmojiMap) > 0 {
+		fmt.Fprintf(outWriter(), "Emoji Map: %s\n", emojiMapSummary(cfg.EmojiMap))
+	}
+	fmt.Fprintf(outWriter(), "Commit Body: %v\n", cfg.CommitBody)
+	if cfg.TagTemplate != "" {
+		fmt.Fprintf(outWriter(), "Tag Template: %s\n", cfg.TagTemplate)
+	} else {
+		fmt.Fprintln(outWriter(), "Tag Template: default")
+	}
+	fmt.Fprintf(outWriter(), "Issue Context: %v\n", cfg.IssueContext)
+	if cfg.IssuePattern != "" {
+		fmt.Fprintf(outWriter(), "Issue Pattern: %s (%s)\n", cfg.IssuePattern,
+			branch.FormatTicket(cfg.IssueFormat, branch.TicketPlaceholder))
+	} else {
+		fmt.Fprintln(outWriter(), "Issue Pattern: <None>")
+	}
+	if cfg.JiraURL != "" {
+		fmt.Fprintf(outWriter(), "Jira URL: %s\n", cfg.JiraURL)
+	} else {
+		fmt.Fprintln(outWriter(), "Jira URL: <Not Set>")
+	}
+	if cfg.Forge != "" {
+		fmt.Fprintf(outWriter(), "Forge: %s\n", cfg.Forge)
+	} else {
+		fmt.Fprintln(outWriter(), "Forge: <Auto>")
+	}
+	fmt.Fprintf(outWriter(), "Type Hints: %s\n", cfg.TypeHints)
+	if cfg.Language != "" {
+		fmt.Fprintf(outWriter(), "Language: %s\n", cfg.Language)
+	} else {
+		fmt.Fprintln(outWriter(), "Language: en")
+	}
+	if types := cfg.AllowedCommitTypes(); len(types) > 0 {
+		fmt.Fprintf(outWriter(), "Commit Types: %s\n", strings.Join(types, ", "))
+	} else {
+		fmt.Fprintln(outWriter(), "Commit Types: <All>")
+	}
+	fmt.Fprintf(outWriter(), "Generation Notes: %v\n", cfg.GenerationNotes)
+	fmt.Fprintf(outWriter(), "Attribution: %v\n", cfg.Attribution)
+	fmt.Fprintf(outWriter(), "Sign Commits: %v\n", cfg.SignCommits)
+	fmt.Fprintf(outWriter(), "Hook Autofix Retry: %v\n", cfg.HookAutofixRetry)
+	fmt.Fprintf(outWriter(), "Preflight: %v\n", cfg.Preflight)
+	if cfg.SummarizeDiffs {
+		fmt.Fprintf(outWriter(), "Summarize Diffs: true (%d parallel, %ds timeout)\n",
+			cfg.SummarizeParallelism, cfg.SummarizeTimeout)
+	} else {
+		fmt.Fprintln(outWriter(), "Summarize Diffs: false")
+	}
+	if cfg.SummarizeThreshold > 0 {
+		fmt.Fprintf(outWriter(), "Summarize Threshold: %d bytes\n", cfg.SummarizeThreshold)
+	} else {
+		fmt.Fprintln(outWriter(), "Summarize Threshold: <Prompt limit>")
+	}
+	fmt.Fprintf(outWriter(), "Outline New Files: %v\n", cfg.OutlineNewFiles)
+	fmt.Fprintf(outWriter(), "Git Backend: %s\n", cfg.GitBackend)
+	fmt.Fprintf(outWriter(), "Package Globs: %s\n", strings.Join(cfg.PackageGlobs, ", "))
+	if len(cfg.ExcludePaths) > 0 {
+		fmt.Fprintf(outWriter(), "Exclude Paths: %s\n", strings.Join(cfg.ExcludePaths, ", "))
+	} else {
+		fmt.Fprintln(outWriter(), "Exclude Paths: <None>")
+	}
+	if cfg.HTTPProxy != "" {
+		fmt.Fprintf(outWriter(), "HTTP Proxy: %s\n", redactURL(cfg.HTTPProxy))
+	} else {
+		fmt.Fprintln(outWriter(), "HTTP Proxy: <Environment>")
+	}
+	if cfg.CACert != "" {
+		fmt.Fprintf(outWriter(), "CA Cert: %s\n", cfg.CACert)
+	} else {
+		fmt.Fprintln(outWriter(), "CA Cert: <System>")
+	}
+	fmt.Fprintf(outWriter(), "TLS Insecure: %v\n", cfg.TLSInsecure)
+	if names := extraHeaderNames(cfg); len(names) > 0 {
+		fmt.Fprintf(outWriter(), "Extra Headers: %s\n", strings.Join(names, ", "))
+	}
+	if len(cfg.ExecPresets) > 0 {
+		fmt.Fprintln(outWriter(), "Exec Presets:")
+		names := make([]string, 0, len(cfg.ExecPresets))
+		for name := range cfg.ExecPresets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(outWriter(), "  %s: %s\n", name, cfg.ExecPresets[name])
+		}
+	}
+	if len(cfg.Components) > 0 {
+		fmt.Fprintln(outWriter(), "Components:")
+		names := make([]string, 0, len(cfg.Components))
+		for name := range cfg.Components {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(outWriter(), "  %s: %s\n", name, cfg.Components[name])
+		}
+	}
+	if len(cfg.ScopeRules) > 0 {
+		fmt.Fprintln(outWriter(), "Scope Rules:")
+		for _, rule := range cfg.ScopeRules {
+			fmt.Fprintf(outWriter(), "  %s: %s\n", rule.Path, rule.Scope)
+		}
+	}
+	if len(cfg.RiskPolicies) > 0 {
+		fmt.Fprintln(outWriter(), "Risk Policies:")
+		for _, policy := range cfg.RiskPolicies {
+			fmt.Fprintf(outWriter(), "  %s: %s\n", policy.Name, describeRiskPolicy(policy))
+		}
+	}
+	if len(cfg.Trailers) > 0 {
+		fmt.Fprintln(outWriter(), "Trailers:")
+		for _, trailer := range cfg.Trailers {
+			fmt.Fprintf(outWriter(), "  %s\n", trailer)
+		}
+	}
 	return nil
 }
 
+// redactURL hides the password of a URL such as a proxy with credentials.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+func describeRiskPolicy(policy config.RiskPolicy) string {
+	var parts []string
+	if len(policy.Paths) > 0 {
+		parts = append(parts, strings.Join(policy.Paths, ", "))
+	}
+	if policy.MaxLines > 0 {
+		parts = append(parts, fmt.Sprintf("more than %d lines", policy.MaxLines))
+	}
+	return strings.Join(parts, "; ")
+}
+
 func init() {
 	configSetCmd.AddCommand(configSetRoleCmd)
 	configSetCmd.AddCommand(configSetModelCmd)
 	configSetCmd.AddCommand(configSetAPIKeyCmd)
 	configSetCmd.AddCommand(configSetAPIBaseCmd)
 	configSetCmd.AddCommand(configSetPromptTemplateCmd)
+	configSetCmd.AddCommand(configSetFallbackTemplateCmd)
+	configSetCmd.AddCommand(configSetTagTemplateCmd)
 	configSetCmd.AddCommand(configSetEnableEmojiCmd)
+	configSetCmd.AddCommand(configSetCommitBodyCmd)
+	configSetCmd.AddCommand(configSetTypeHintsCmd)
+	configSetCmd.AddCommand(configSetLanguageCmd)
 
 	configGetCmd.Flags().BoolVar(&configOutputJSON, "json", false, "Output in JSON format (deprecated: use -o json)")
 	_ = configGetCmd.Flags().MarkDeprecated("json", "use -o json instead")
 
+	configMigrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false,
+		"Show what would be migrated without changing files")
+	configMigrateCmd.Flags().BoolVar(&migrateForce, "force", false, "Overwrite an existing XDG config file")
+
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configMigrateCmd)
+}
+
+// samplingSummary describes the temperature, top_p and max_tokens config, naming only
+// the keys that are set.
+func samplingSummary(cfg *config.Config) string {
+	var parts []string
+	if cfg.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temperature %g", *cfg.Temperature))
+	}
+	if cfg.TopP != nil {
+		parts = append(parts, fmt.Sprintf("top_p %g", *cfg.TopP))
+	}
+	if cfg.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("max_tokens %d", cfg.MaxTokens))
+	}
+	if len(parts) == 0 {
+		return "<API defaults>"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func flagDefaultsSummary(d config.FlagDefaults) string {
+	var parts []string
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{{"--all", d.AddAll}, {"--yes", d.AutoYes}, {"--dry-run", d.DryRun}, {"--no-verify", d.NoVerify},
+		{"--verbose", d.Verbose}} {
+		if flag.set {
+			parts = append(parts, flag.name)
+		}
+	}
+	if d.Timeout != nil {
+		parts = append(parts, "--timeout "+strconv.Itoa(*d.Timeout))
+	}
+	if d.ConnectTimeout != nil {
+		parts = append(parts, "--connect-timeout "+strconv.Itoa(*d.ConnectTimeout))
+	}
+	if len(parts) == 0 {
+		return "<None>"
+	}
+	return strings.Join(parts, " ")
+}
+
+// emojiMapSummary returns the emoji_map entries sorted by type, as "feat 🚀, fix 🩹".
+func emojiMapSummary(m map[string]string) string {
+	parts := make([]string, 0, len(m))
+	for commitType, value := range m {
+		parts = append(parts, commitType+" "+value)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func branchNamingSummary(n config.BranchNaming) string {
+	parts := []string{n.Pattern}
+	if n.MaxLength > 0 {
+		parts = append(parts, "max "+strconv.Itoa(n.MaxLength))
+	}
+	if n.AllowedChars != "" {
+		parts = append(parts, "chars ["+n.AllowedChars+"]")
+	}
+	if n.Lowercase {
+		parts = append(parts, "lowercase")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// extraHeaderNames returns the sorted names of the extra_headers that are sent.
+// Values are left out because gateways often take credentials in headers.
+func extraHeaderNames(cfg *config.Config) []string {
+	var names []string
+	for name, value := range cfg.ExtraHeaders {
+		if value != "" {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	sort.Strings(names)
+	return names
 }
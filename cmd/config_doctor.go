@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var configDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the API key can use the model",
+	Long: `Check the configured API key against the provider before you need it.
+
+The checks confirm the key is accepted, that it can call the configured model,
+and how much of the current rate limit window is left. Rejected or expired keys,
+missing scopes, organization or project restrictions, and exhausted quota are
+reported with the provider's message instead of a bare 401, 403 or 429.
+
+Providers that do not offer a model list or rate limit headers skip those checks.`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runConfigDoctor()
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configDoctorCmd)
+}
+
+// ConfigDoctorJSON is the JSON output of gmc config doctor.
+type ConfigDoctorJSON struct {
+	APIBase string         `json:"api_base"`
+	Model   string         `json:"model"`
+	OK      bool           `json:"ok"`
+	Checks  []llm.KeyCheck `json:"checks"`
+}
+
+func runConfigDoctor() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	apiBase := cfg.APIBase
+	if apiBase == "" {
+		apiBase = "https://api.openai.com/v1"
+	}
+
+	jsonOutput := outputFormat() == "json"
+	if !jsonOutput {
+		fmt.Fprintf(errWriter(), "Checking API key for %s at %s...\n", cfg.Model, apiBase)
+	}
+
+	checks, err := llm.NewClient(llm.Options{}).DiagnoseKey(cfg.Model)
+	if err != nil {
+		return err
+	}
+
+	ok := !hasFailedKeyCheck(checks)
+	if jsonOutput {
+		if err := printJSON(outWriter(), ConfigDoctorJSON{
+			APIBase: apiBase, Model: cfg.Model, OK: ok, Checks: checks,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printKeyChecks(outWriter(), checks)
+	}
+
+	if !ok {
+		return fmt.Errorf("API key check failed for %s", cfg.Model)
+	}
+	return nil
+}
+
+func hasFailedKeyCheck(checks []llm.KeyCheck) bool {
+	for _, check := range checks {
+		if check.Status == llm.CheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+func printKeyChecks(w io.Writer, checks []llm.KeyCheck) {
+	for _, check := range checks {
+		fmt.Fprintf(w, "[%s] %s: %s\n", check.Status, check.Name, check.Detail)
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintKeyChecks(t *testing.T) {
+	checks := []llm.KeyCheck{
+		{Name: "api key", Status: llm.CheckOK, Detail: "accepted, 2 models visible"},
+		{Name: "model gpt-4o", Status: llm.CheckFail, Detail: "quota exhausted (check billing): no credit"},
+	}
+
+	var out bytes.Buffer
+	printKeyChecks(&out, checks)
+	assert.Equal(t, "[ok] api key: accepted, 2 models visible\n"+
+		"[fail] model gpt-4o: quota exhausted (check billing): no credit\n", out.String())
+	assert.True(t, hasFailedKeyCheck(checks))
+	assert.False(t, hasFailedKeyCheck(checks[:1]))
+}
+
+func TestPrintConfigIssues(t *testing.T) {
+	var out bytes.Buffer
+	printConfigIssues(&out, []string{"config.yaml"}, []config.Issue{
+		{File: "config.yaml", Line: 2, Key: "modle", Severity: config.SeverityWarning, Message: "unknown key, ignored"},
+	})
+	assert.Equal(t, "[warning] config.yaml:2: modle: unknown key, ignored\n", out.String())
+
+	out.Reset()
+	printConfigIssues(&out, []string{"config.yaml", ".gmc.yaml"}, nil)
+	assert.Equal(t, "Configuration is valid (2 file(s) checked)\n", out.String())
+}
+
+func TestPrintConfigLayers(t *testing.T) {
+	var out bytes.Buffer
+	printConfigLayers(&out, []config.Layer{
+		{Name: config.LayerUser, Path: "/home/u/.gmc.yaml", Source: config.SourceLegacy, Exists: true},
+		{Name: config.LayerProject, Path: "/repo/.gmc.yaml", Exists: true},
+	}, []config.EnvOverride{{Variable: "GMC_MODEL", Key: "model"}})
+	assert.Equal(t, "user config:    /home/u/.gmc.yaml (legacy ~/.gmc.yaml)\n"+
+		"project config: /repo/.gmc.yaml (overrides the user config)\n"+
+		"environment:    GMC_MODEL overrides model\n", out.String())
+
+	out.Reset()
+	printConfigLayers(&out, []config.Layer{
+		{Name: config.LayerUser, Path: "/home/u/.config/gmc/config.yaml", Source: config.SourceXDG},
+		{Name: config.LayerProject, Path: "/repo/.gmc.yaml"},
+	}, nil)
+	assert.Equal(t, "user config:    /home/u/.config/gmc/config.yaml (not created yet, defaults apply)\n"+
+		"project config: /repo/.gmc.yaml (not found)\n", out.String())
+
+	out.Reset()
+	printConfigLayers(&out, []config.Layer{
+		{Name: config.LayerUser, Path: "/home/u/.config/gmc/config.yaml", Source: config.SourceXDG, Exists: true},
+		{Name: config.LayerShared, Path: "/proj/.gmc.yaml", Exists: true},
+		{Name: config.LayerProject, Path: "/proj/main/.gmc.yaml"},
+	}, nil)
+	assert.Equal(t, "user config:    /home/u/.config/gmc/config.yaml (XDG config)\n"+
+		"shared config:  /proj/.gmc.yaml (shared by every worktree, overrides the user config)\n"+
+		"project config: /proj/main/.gmc.yaml (not found)\n", out.String())
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var configProject bool
+
+var (
+	configEditCmd = &cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in $EDITOR, then validate it",
+		Long: `Open the user config file in $EDITOR, or the current directory's .gmc.yaml with
+--project. When the editor exits, the file is validated as gmc config validate does.`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runConfigEdit()
+		},
+	}
+
+	configUnsetCmd = &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a key from the config file",
+		Long: `Remove a key from the user config file, or from the current directory's .gmc.yaml
+with --project, so the next layer or the built-in default applies again.
+Unknown keys, such as a misspelled one, can be removed too.`,
+		Example: `  gmc config unset model
+  gmc config unset --project scope_rules`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigUnset(args[0])
+		},
+	}
+
+	configPathCmd = &cobra.Command{
+		Use:   "path",
+		Short: "Show which config files are in effect",
+		Long: `Show the user config file gmc reads and why it was chosen (--config, $GMC_CONFIG,
+the XDG config or the legacy ~/.gmc.yaml), the project .gmc.yaml of the current
+directory, and the GMC_ environment variables that override keys. Later layers
+override earlier ones.`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runConfigPath()
+		},
+	}
+)
+
+func init() {
+	configEditCmd.Flags().BoolVar(&configProject, "project", false,
+		"Edit the project .gmc.yaml, the nearest one up to the worktree root, instead of the user config")
+	configUnsetCmd.Flags().BoolVar(&configProject, "project", false,
+		"Remove the key from the project .gmc.yaml instead of the user config")
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configPathCmd)
+}
+
+// ConfigPathJSON is the JSON output of gmc config path.
+type ConfigPathJSON struct {
+	Layers []config.Layer       `json:"layers"`
+	Env    []config.EnvOverride `json:"env"`
+}
+
+// targetConfigFile returns the config file edit and unset change.
+func targetConfigFile() (string, error) {
+	if !configProject {
+		return config.FilePath(), nil
+	}
+	cwd, err := workingDir()
+	if err != nil {
+		return "", err
+	}
+	project, _ := config.RepoConfigLocations(cwd)
+	return project, nil
+}
+
+func runConfigEdit() error {
+	path, err := targetConfigFile()
+	if err != nil {
+		return err
+	}
+
+	editor := exec.Command(workflow.Editor(), path)
+	editor.Stdin = os.Stdin
+	editor.Stdout = os.Stdout
+	editor.Stderr = os.Stderr
+	if err := editor.Run(); err != nil {
+		return fmt.Errorf("failed to open editor: %w", err)
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	issues := config.ValidateFile(path)
+	printConfigIssues(errWriter(), []string{path}, issues)
+	if config.HasErrors(issues) {
+		command := "gmc config edit"
+		if configProject {
+			command += " --project"
+		}
+		return fmt.Errorf("%s has errors; run %s again to fix them", path, command)
+	}
+	return nil
+}
+
+func runConfigUnset(key string) error {
+	path, err := targetConfigFile()
+	if err != nil {
+		return err
+	}
+	if err := config.UnsetConfigValue(path, key); err != nil {
+		if errors.Is(err, config.ErrKeyNotSet) {
+			return fmt.Errorf("%s is not set in %s", key, path)
+		}
+		return err
+	}
+	fmt.Fprintf(outWriter(), "Removed %s from %s\n", key, path)
+	return nil
+}
+
+func runConfigPath() error {
+	layers := config.Layers()
+	env := config.EnvOverrides()
+	if outputFormat() == "json" {
+		if env == nil {
+			env = []config.EnvOverride{}
+		}
+		return printJSON(outWriter(), ConfigPathJSON{Layers: layers, Env: env})
+	}
+	printConfigLayers(outWriter(), layers, env)
+	return nil
+}
+
+func printConfigLayers(w io.Writer, layers []config.Layer, env []config.EnvOverride) {
+	for _, layer := range layers {
+		var status string
+		switch {
+		case !layer.Exists && layer.Name != config.LayerUser:
+			status = "not found"
+		case !layer.Exists:
+			status = "not created yet, defaults apply"
+		case layer.Name == config.LayerShared:
+			status = "shared by every worktree, overrides the user config"
+		case layer.Name == config.LayerProject:
+			status = "overrides the user config"
+		default:
+			status = layer.Source
+		}
+		fmt.Fprintf(w, "%-16s%s (%s)\n", layer.Name+" config:", layer.Path, status)
+	}
+	for _, override := range env {
+		fmt.Fprintf(w, "%-16s%s overrides %s\n", "environment:", override.Variable, override.Key)
+	}
+}
+
+func completeConfigKeys(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return config.Keys(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config files for invalid keys",
+	Long: `Check the user config file and the repository's .gmc.yaml against the config schema.
+
+Unknown keys, such as a misspelled key, and deprecated keys are warnings: gmc ignores
+them. Values of the wrong type, an api_base that is not an http(s) URL, and values
+outside their allowed set, such as type_hints or git_backend, are errors: every gmc
+command that needs the configuration refuses to run until they are fixed.
+
+Every gmc command checks the files as it loads them, and prints the warnings.`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runConfigValidate()
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
+
+// ConfigValidateJSON is the JSON output of gmc config validate.
+type ConfigValidateJSON struct {
+	Files  []string       `json:"files"`
+	Valid  bool           `json:"valid"`
+	Issues []config.Issue `json:"issues"`
+}
+
+func runConfigValidate() error {
+	files := config.LoadedFiles()
+	issues := []config.Issue{}
+	for _, file := range files {
+		issues = append(issues, config.ValidateFile(file)...)
+	}
+
+	valid := !config.HasErrors(issues)
+	if outputFormat() == "json" {
+		if err := printJSON(outWriter(), ConfigValidateJSON{Files: files, Valid: valid, Issues: issues}); err != nil {
+			return err
+		}
+	} else {
+		printConfigIssues(outWriter(), files, issues)
+	}
+
+	if !valid {
+		return fmt.Errorf("configuration has errors")
+	}
+	return nil
+}
+
+func printConfigIssues(w io.Writer, files []string, issues []config.Issue) {
+	for _, issue := range issues {
+		fmt.Fprintf(w, "[%s] %s\n", issue.Severity, issue)
+	}
+	if len(issues) == 0 {
+		fmt.Fprintf(w, "Configuration is valid (%d file(s) checked)\n", len(files))
+	}
+}
+
+// warnConfigIssues prints the warnings of the config files to stderr, except for
+// commands that report them themselves or whose stderr is not read.
+func warnConfigIssues(cmd *cobra.Command, _ []string) {
+	if cmd == configValidateCmd || cmd.Name() == cobra.ShellCompRequestCmd {
+		return
+	}
+	for _, issue := range config.Warnings() {
+		fmt.Fprintf(errWriter(), "Warning: %s\n", issue)
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/doctor"
+	"github.com/samzong/gmc/internal/gitcmd"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check git, config, API, templates and worktrees",
+	Long: `Diagnose the environment gmc runs in.
+
+Checks:
+  - git is installed and recent enough
+  - the current repository state (branch, detached HEAD, rebase or merge in progress)
+  - the config file exists and is readable only by you
+  - the API key works with the configured model, and the request latency
+  - the prompt and tag templates load
+  - every worktree directory still exists
+
+Each check prints pass, warn or fail. Use --output json to attach the report to
+a support ticket; the API key is never included.`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runDoctor()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// DoctorJSON is the JSON output of gmc doctor.
+type DoctorJSON struct {
+	Version string         `json:"version"`
+	OK      bool           `json:"ok"`
+	Checks  []doctor.Check `json:"checks"`
+}
+
+func runDoctor() error {
+	runner := gitcmd.Runner{Verbose: verbose, Dir: workDir}
+	checks := []doctor.Check{doctor.CheckGit(runner)}
+	repo, inRepo := doctor.CheckRepo(runner)
+	checks = append(checks, repo, doctor.CheckConfigFile(config.FilePath()))
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		checks = append(checks, doctor.Check{Name: "config", Status: doctor.Fail, Detail: err.Error()})
+	} else {
+		if outputFormat() != "json" {
+			fmt.Fprintf(errWriter(), "Checking API access for %s...\n", cfg.Model)
+		}
+		apiChecks, err := doctor.CheckAPI(llm.NewClient(llm.Options{}), cfg.Model)
+		if err != nil {
+			apiChecks = []doctor.Check{{Name: "api", Status: doctor.Fail, Detail: err.Error()}}
+		}
+		checks = append(checks, apiChecks...)
+		checks = append(checks, doctor.CheckTemplates(cfg)...)
+	}
+
+	if inRepo {
+		worktrees, err := newWorktreeClient().List()
+		if err != nil {
+			checks = append(checks, doctor.Check{Name: "worktrees", Status: doctor.Fail, Detail: err.Error()})
+		} else {
+			checks = append(checks, doctor.CheckWorktrees(worktrees))
+		}
+	}
+
+	ok := !doctor.HasFailure(checks)
+	if outputFormat() == "json" {
+		if err := printJSON(outWriter(), DoctorJSON{Version: Version, OK: ok, Checks: checks}); err != nil {
+			return err
+		}
+	} else {
+		printDoctorChecks(outWriter(), checks)
+	}
+
+	if !ok {
+		return errors.New("gmc doctor found problems")
+	}
+	return nil
+}
+
+func printDoctorChecks(w io.Writer, checks []doctor.Check) {
+	for _, check := range checks {
+		fmt.Fprintf(w, "[%s] %s: %s\n", check.Status, check.Name, check.Detail)
+		if check.Hint != "" {
+			fmt.Fprintf(w, "       %s\n", check.Hint)
+		}
+	}
+}
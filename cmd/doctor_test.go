@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/doctor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintDoctorChecks(t *testing.T) {
+	var buf bytes.Buffer
+	printDoctorChecks(&buf, []doctor.Check{
+		{Name: "git", Status: doctor.Pass, Detail: "git version 2.45.1"},
+		{Name: "config file", Status: doctor.Fail, Detail: "readable by other users", Hint: "chmod 600 config.yaml"},
+	})
+	assert.Equal(t, "[pass] git: git version 2.45.1\n"+
+		"[fail] config file: readable by other users\n"+
+		"       chmod 600 config.yaml\n", buf.String())
+}
+
+func TestDoctorArgs(t *testing.T) {
+	assert.Error(t, doctorCmd.Args(doctorCmd, []string{"extra"}))
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/eval"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	evalTemplates []string
+	evalModels    []string
+	evalMessages  bool
+
+	evalCmd = &cobra.Command{
+		Use:   "eval <fixtures-dir>",
+		Short: "Compare templates and models on recorded diffs",
+		Long: `Generate a commit message for every recorded diff in a directory with each
+combination of --template and --model, score the messages with the same rules
+as gmc stats, and print a comparison report.
+
+A fixture is a .diff or .patch file in the directory, in any form gmc - reads:
+a plain diff, or git status --porcelain output followed by the diff and file
+snippets. Record one with git diff --cached > fixtures/name.diff.
+
+--template and --model can be repeated, and default to prompt_template and
+model. Every combination calls the LLM once per fixture, with the rest of the
+config applied, so usage is recorded as for any other generation.`,
+		Example: `  gmc eval testdata/eval
+  gmc eval fixtures --template default --template compact
+  gmc eval fixtures --model gpt-4o-mini --model gpt-4o --messages
+  gmc eval fixtures -o json > report.json`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cobra.FixedCompletions(nil, cobra.ShellCompDirectiveFilterDirs),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runEval(args[0])
+		},
+	}
+)
+
+func init() {
+	evalCmd.Flags().StringArrayVar(&evalTemplates, "template", nil,
+		"Template name or path to evaluate (repeatable, default prompt_template)")
+	evalCmd.Flags().StringArrayVar(&evalModels, "model", nil, "Model to evaluate (repeatable, default model)")
+	evalCmd.Flags().BoolVar(&evalMessages, "messages", false, "Also list each generated subject and its issues")
+	_ = evalCmd.RegisterFlagCompletionFunc("template", completeTemplateNames)
+	rootCmd.AddCommand(evalCmd)
+}
+
+func runEval(dir string) error {
+	fixtures, err := eval.LoadFixtures(dir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(cfg.APIKey) == "" {
+		return errAPIKeyMissing
+	}
+
+	templates, err := evalTemplatePaths()
+	if err != nil {
+		return err
+	}
+	models := evalModels
+	if len(models) == 0 {
+		models = []string{cfg.Model}
+	}
+	var variants []eval.Variant
+	for _, tpl := range templates {
+		for _, model := range models {
+			variants = append(variants, eval.Variant{Template: tpl.name, Model: model})
+		}
+	}
+
+	paths := make(map[string]string, len(templates))
+	for _, tpl := range templates {
+		paths[tpl.name] = tpl.path
+	}
+	llmClient := newLLMClient()
+	generate := func(fixture eval.Fixture, variant eval.Variant) (string, error) {
+		variantCfg := *cfg
+		variantCfg.PromptTemplate = templateRef(paths[variant.Template])
+		variantCfg.Model = variant.Model
+		return generateMessage(llmClient, &variantCfg, fixture.Files, fixture.Diff, messageOptions{})
+	}
+
+	results := eval.Run(fixtures, variants, generate, errWriter())
+	report := eval.Report{Summaries: eval.Summarize(results, variants), Results: results}
+	if outputFormat() == "json" {
+		if err := printJSON(outWriter(), report); err != nil {
+			return err
+		}
+	} else {
+		eval.Render(outWriter(), report, evalMessages)
+	}
+	return report.Check()
+}
+
+type evalTemplate struct {
+	name string
+	path string
+}
+
+// evalTemplatePaths resolves --template, or prompt_template without it, and checks
+// that every template renders, since prompt building would otherwise fall back to
+// fallback_template and hide the template being evaluated.
+func evalTemplatePaths() ([]evalTemplate, error) {
+	refs := [][]string{nil}
+	if len(evalTemplates) > 0 {
+		refs = refs[:0]
+		for _, ref := range evalTemplates {
+			refs = append(refs, []string{ref})
+		}
+	}
+
+	templates := make([]evalTemplate, 0, len(refs))
+	for _, ref := range refs {
+		name, path, err := resolveTemplateArg(ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := formatter.CheckPromptTemplate(templateRef(path)); err != nil {
+			return nil, err
+		}
+		templates = append(templates, evalTemplate{name: name, path: path})
+	}
+	return templates, nil
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// explainDiffLimit bounds the diffs gmc explain sends, shared between the commits.
+	explainDiffLimit = 12000
+	// explainMinDiff is the smallest share of explainDiffLimit worth sending as a diff;
+	// below it, each file of a commit is reduced to a summary line.
+	explainMinDiff = 800
+)
+
+var (
+	explainOutput string
+
+	explainCmd = &cobra.Command{
+		Use:   "explain [<rev>|<range>]",
+		Short: "Explain what a commit or a range changed",
+		Long: `Send the messages and diffs of a commit, HEAD by default, or of a revision range
+such as main..HEAD to the LLM, and print an explanation for a reviewer: the
+motivation, the key changes and the areas at risk. Nothing in the repository
+changes.
+
+The diffs share a budget of about 12 KB. In a long range, each commit gets a
+smaller share, and when the share gets too small its files are listed with
+their line counts and the functions their hunks touch instead.
+
+--output markdown prints the explanation as Markdown, to paste into a review
+comment or a pull request description.`,
+		Example: `  gmc explain
+  gmc explain 3f2a9c1
+  gmc explain main..HEAD --output markdown
+  gmc explain v1.4.0..v1.5.0 -o json`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeExplainRevs,
+		RunE: func(_ *cobra.Command, args []string) error {
+			target := "HEAD"
+			if len(args) == 1 {
+				target = args[0]
+			}
+			return runExplain(os.Stdin, target)
+		},
+	}
+)
+
+func init() {
+	// A local --output shadows the persistent one to add markdown.
+	explainCmd.Flags().StringVarP(&explainOutput, "output", "o", "text", "Output format: text, json or markdown")
+	_ = explainCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		[]string{"text", "json", "markdown"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.AddCommand(explainCmd)
+}
+
+func completeExplainRevs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeBranchNames(cmd, args, toComplete)
+}
+
+// explainedCommit is a commit of the gmc explain JSON output.
+type explainedCommit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+}
+
+// ExplainJSON is the JSON output of gmc explain.
+type ExplainJSON struct {
+	Target  string            `json:"target"`
+	Commits []explainedCommit `json:"commits"`
+	llm.Explanation
+}
+
+func runExplain(in io.Reader, target string) error {
+	if explainOutput != "text" && explainOutput != "json" && explainOutput != "markdown" {
+		return errors.New("--output must be text, json or markdown")
+	}
+
+	gitClient, err := newGitClient()
+	if err != nil {
+		return err
+	}
+	revRange := target
+	if !strings.Contains(target, "..") {
+		// rev^! is the commit alone, without its parents.
+		revRange = target + "^!"
+	}
+	commits, err := gitClient.GetRangeCommits(revRange)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		fmt.Fprintf(errWriter(), "No commits in %s.\n", target)
+		return nil
+	}
+	changes, err := explainChanges(gitClient, commits)
+	if err != nil {
+		return err
+	}
+
+	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		return errAPIKeyMissing
+	}
+	fmt.Fprintf(errWriter(), "Explaining %d commit(s) in %s...\n", len(commits), target)
+	explanation, err := newLLMClient().ExplainChanges(changes, cfg.Model)
+	if err != nil {
+		return err
+	}
+
+	switch explainOutput {
+	case "json":
+		output := ExplainJSON{Target: target, Explanation: explanation}
+		if output.Risks == nil {
+			output.Risks = []string{}
+		}
+		for _, commit := range commits {
+			output.Commits = append(output.Commits, explainedCommit{Hash: commit.Hash, Subject: commit.Subject()})
+		}
+		return printJSON(outWriter(), output)
+	case "markdown":
+		printExplanationMarkdown(outWriter(), target, commits, explanation)
+	default:
+		printExplanation(outWriter(), explanation)
+	}
+	return nil
+}
+
+// explainChanges returns the message and diff of each commit, with the diffs sharing
+// explainDiffLimit.
+func explainChanges(gitClient *git.Client, commits []git.RangeCommit) (string, error) {
+	share := explainDiffLimit / len(commits)
+	var b strings.Builder
+	for _, commit := range commits {
+		diff, err := gitClient.GetCommitDiff(commit.Hash)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "commit %s\n%s\n\n", stringsutil.ShortHash(commit.Hash, 7, ""), commit.Message)
+		if share >= explainMinDiff {
+			b.WriteString(formatter.TruncateDiff(strings.TrimRight(diff, "\n"), share))
+		} else {
+			b.WriteString(strings.Join(formatter.SummarizeFiles(diff), "\n"))
+		}
+		b.WriteString("\n\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+func printExplanation(w io.Writer, explanation llm.Explanation) {
+	fmt.Fprintf(w, "Motivation\n  %s\n", explanation.Motivation)
+	printExplanationList(w, "\nKey changes", "  - ", explanation.Changes)
+	printExplanationList(w, "\nRisk areas", "  - ", explanation.Risks)
+}
+
+func printExplanationMarkdown(w io.Writer, target string, commits []git.RangeCommit, explanation llm.Explanation) {
+	if len(commits) == 1 {
+		fmt.Fprintf(w, "### %s %s\n\n", stringsutil.ShortHash(commits[0].Hash, 7, ""), commits[0].Subject())
+	} else {
+		fmt.Fprintf(w, "### %s (%d commits)\n\n", target, len(commits))
+	}
+	fmt.Fprintf(w, "**Motivation:** %s\n", explanation.Motivation)
+	printExplanationList(w, "\n**Key changes**\n", "- ", explanation.Changes)
+	printExplanationList(w, "\n**Risk areas**\n", "- ", explanation.Risks)
+}
+
+// printExplanationList prints heading and items, or "None" for no items.
+func printExplanationList(w io.Writer, heading, bullet string, items []string) {
+	fmt.Fprintln(w, heading)
+	if len(items) == 0 {
+		items = []string{"None"}
+	}
+	for _, item := range items {
+		fmt.Fprintln(w, bullet+item)
+	}
+}
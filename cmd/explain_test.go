@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintExplanation(t *testing.T) {
+	explanation := llm.Explanation{
+		Motivation: "Commits failed without network.",
+		Changes:    []string{"Add HeuristicMessage", "Add --offline"},
+	}
+
+	var text bytes.Buffer
+	printExplanation(&text, explanation)
+	assert.Equal(t, "Motivation\n  Commits failed without network.\n\n"+
+		"Key changes\n  - Add HeuristicMessage\n  - Add --offline\n\nRisk areas\n  - None\n", text.String())
+
+	var markdown bytes.Buffer
+	commits := []git.RangeCommit{{Hash: "3f2a9c1d8e", Message: "feat: add offline mode\n\nbody"}}
+	printExplanationMarkdown(&markdown, "HEAD", commits, explanation)
+	assert.Equal(t, "### 3f2a9c1 feat: add offline mode\n\n**Motivation:** Commits failed without network.\n\n"+
+		"**Key changes**\n\n- Add HeuristicMessage\n- Add --offline\n\n**Risk areas**\n\n- None\n", markdown.String())
+
+	markdown.Reset()
+	printExplanationMarkdown(&markdown, "main..HEAD", append(commits, commits...), explanation)
+	assert.Contains(t, markdown.String(), "### main..HEAD (2 commits)\n")
+}
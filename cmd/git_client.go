@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/git"
+)
+
+// gitOptions returns the options for the git clients of this run, with the backend
+// from git_backend.
+func gitOptions() (git.Options, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return git.Options{}, err
+	}
+	backend := cfg.GitBackend
+	if backend == "" {
+		backend = config.GitBackendNative
+	}
+	if !config.IsValidGitBackend(backend) {
+		return git.Options{}, fmt.Errorf("invalid git_backend %q: must be %s or %s",
+			backend, config.GitBackendNative, config.GitBackendGoGit)
+	}
+	return git.Options{
+		Verbose:      verbose,
+		Backend:      backend,
+		ExcludePaths: cfg.ExcludePaths,
+		Dir:          workDir,
+	}, nil
+}
+
+func newGitClient() (*git.Client, error) {
+	opts, err := gitOptions()
+	if err != nil {
+		return nil, err
+	}
+	return git.NewClient(opts), nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	guessScopeCount  int
+	guessScopeDryRun bool
+
+	guessScopeCmd = &cobra.Command{
+		Use:   "guess-scope",
+		Short: "Train scope inference on recent commits",
+		Long: `Walk recent commits, compare the scope gmc would infer from the changed files
+with the scope the commit actually used, and offer to save each correction as a
+scope rule.
+
+Scope rules map a path prefix to a scope. When every staged file maps to the
+same scope, gmc adds it to the prompt as a hint. Accepted rules are written to
+scope_rules in the repository's .gmc.yaml, so the whole team shares them.
+
+Without rules, gmc guesses the first directory of each file, looking past
+container directories such as internal/ or pkg/.`,
+		Example: `  gmc guess-scope
+  gmc guess-scope -n 200
+  gmc guess-scope --dry-run -o json`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runGuessScope(os.Stdin)
+		},
+	}
+)
+
+func init() {
+	guessScopeCmd.Flags().IntVarP(&guessScopeCount, "count", "n", 50, "Number of recent commits to walk")
+	guessScopeCmd.Flags().BoolVar(&guessScopeDryRun, "dry-run", false, "Show suggested rules without asking or saving")
+	rootCmd.AddCommand(guessScopeCmd)
+}
+
+// scopeMismatch is a commit whose inferred scope differs from the one it used.
+type scopeMismatch struct {
+	Hash    string           `json:"hash"`
+	Subject string           `json:"subject"`
+	Guessed string           `json:"guessed"`
+	Actual  string           `json:"actual"`
+	Rule    config.ScopeRule `json:"rule"`
+}
+
+// GuessScopeJSON is the JSON output of gmc guess-scope.
+type GuessScopeJSON struct {
+	Commits     int             `json:"commits"`
+	Scoped      int             `json:"scoped"`
+	Matched     int             `json:"matched"`
+	Suggestions []scopeMismatch `json:"suggestions"`
+}
+
+// evaluateScopes counts the scoped commits whose scope rules guesses correctly and
+// suggests a rule for each mismatch, once per path.
+func evaluateScopes(rules []config.ScopeRule, commits []git.CommitFiles) (int, int, []scopeMismatch) {
+	scoped, matched := 0, 0
+	var mismatches []scopeMismatch
+	suggested := map[string]bool{}
+	for _, commit := range commits {
+		actual := formatter.ScopeOf(commit.Subject)
+		if actual == "" || len(commit.Files) == 0 {
+			continue
+		}
+		scoped++
+		guessed := formatter.GuessScope(rules, commit.Files)
+		if guessed == actual {
+			matched++
+			continue
+		}
+		rule := formatter.SuggestScopeRule(commit.Files, actual)
+		if rule.Path == "" || suggested[rule.Path] {
+			continue
+		}
+		suggested[rule.Path] = true
+		mismatches = append(mismatches, scopeMismatch{
+			Hash: commit.Hash, Subject: commit.Subject, Guessed: guessed, Actual: actual, Rule: rule,
+		})
+	}
+	return scoped, matched, mismatches
+}
+
+// withScopeRule returns rules with rule added, replacing any rule for the same path.
+func withScopeRule(rules []config.ScopeRule, rule config.ScopeRule) []config.ScopeRule {
+	updated := make([]config.ScopeRule, 0, len(rules)+1)
+	for _, existing := range rules {
+		if existing.Path != rule.Path {
+			updated = append(updated, existing)
+		}
+	}
+	return append(updated, rule)
+}
+
+func runGuessScope(in io.Reader) error {
+	if guessScopeCount < 1 {
+		return errors.New("--count must be at least 1")
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	gitClient, err := newGitClient()
+	if err != nil {
+		return err
+	}
+	commits, err := gitClient.GetRecentCommitFiles(guessScopeCount)
+	if err != nil {
+		return wrapGitError(err)
+	}
+
+	scoped, matched, mismatches := evaluateScopes(cfg.ScopeRules, commits)
+	if outputFormat() == "json" {
+		if mismatches == nil {
+			mismatches = []scopeMismatch{}
+		}
+		return printJSON(outWriter(), GuessScopeJSON{
+			Commits: len(commits), Scoped: scoped, Matched: matched, Suggestions: mismatches,
+		})
+	}
+
+	out := outWriter()
+	fmt.Fprintf(out, "Walked %d commits; %d use a scope and %d of those match the guessed scope.\n",
+		len(commits), scoped, matched)
+	if scoped == 0 {
+		fmt.Fprintln(out, "No commits use a type(scope): subject, so there is nothing to learn from.")
+		return nil
+	}
+	if len(mismatches) == 0 {
+		fmt.Fprintln(out, "No new scope rules to suggest.")
+		return nil
+	}
+
+	rules := cfg.ScopeRules
+	accepted := 0
+	readLine := newTrimmedLineReader(in)
+	for _, mismatch := range mismatches {
+		fmt.Fprintln(out)
+		printScopeMismatch(out, mismatch)
+		if guessScopeDryRun {
+			continue
+		}
+
+		answer, quit, err := promptScopeRule(out, readLine, mismatch.Rule)
+		if err != nil {
+			return err
+		}
+		if quit {
+			break
+		}
+		if answer {
+			rules = withScopeRule(rules, mismatch.Rule)
+			accepted++
+		}
+	}
+
+	if accepted == 0 {
+		return nil
+	}
+
+	root, err := gitClient.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	path := config.RepoConfigPath(root)
+	if err := config.SetRepoConfigValue(path, "scope_rules", rules); err != nil {
+		return err
+	}
+
+	_, matchedAfter, _ := evaluateScopes(rules, commits)
+	fmt.Fprintf(out, "\nSaved %d scope rule(s) to %s. The rules now guess %d of %d scoped commits.\n",
+		accepted, path, matchedAfter, scoped)
+	return nil
+}
+
+func printScopeMismatch(w io.Writer, mismatch scopeMismatch) {
+	guessed := mismatch.Guessed
+	if guessed == "" {
+		guessed = "(none)"
+	}
+	fmt.Fprintf(w, "%s %s\n", mismatch.Hash, mismatch.Subject)
+	fmt.Fprintf(w, "  guessed: %s, actual: %s\n", guessed, mismatch.Actual)
+	fmt.Fprintf(w, "  suggested rule: %s/ -> %s\n", mismatch.Rule.Path, mismatch.Rule.Scope)
+}
+
+// promptScopeRule asks whether to save rule. It reports quit on "q" or end of input.
+func promptScopeRule(
+	w io.Writer, readLine func() (string, error), rule config.ScopeRule,
+) (accept bool, quit bool, err error) {
+	for {
+		fmt.Fprintf(w, "Save %s/ -> %s? [y/N/q]: ", rule.Path, rule.Scope)
+		answer, err := readLine()
+		if errors.Is(err, io.EOF) {
+			return false, true, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, false, nil
+		case "", "n", "no":
+			return false, false, nil
+		case "q", "quit":
+			return false, true, nil
+		default:
+			fmt.Fprintln(w, "Please enter y, n or q.")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateScopes(t *testing.T) {
+	commits := []git.CommitFiles{
+		{Hash: "a1", Subject: "feat(worktree): add a", Files: []string{"internal/worktree/a.go"}},
+		{Hash: "b2", Subject: "fix(wt): fix b", Files: []string{"internal/worktree/b.go"}},
+		{Hash: "c3", Subject: "feat(wt): fix c", Files: []string{"internal/worktree/c.go"}},
+		{Hash: "d4", Subject: "chore: bump deps", Files: []string{"go.mod"}},
+		{Hash: "e5", Subject: "docs(readme): tweak", Files: []string{"README.md"}},
+	}
+
+	scoped, matched, mismatches := evaluateScopes(nil, commits)
+	assert.Equal(t, 4, scoped)
+	assert.Equal(t, 1, matched)
+	require.Len(t, mismatches, 1, "one suggestion per path; root files cannot be mapped")
+	assert.Equal(t, config.ScopeRule{Path: "internal/worktree", Scope: "wt"}, mismatches[0].Rule)
+	assert.Equal(t, "worktree", mismatches[0].Guessed)
+
+	_, matched, _ = evaluateScopes(withScopeRule(nil, mismatches[0].Rule), commits)
+	assert.Equal(t, 2, matched)
+}
+
+func TestWithScopeRuleReplacesPath(t *testing.T) {
+	rules := []config.ScopeRule{{Path: "cmd", Scope: "cmd"}, {Path: "docs", Scope: "docs"}}
+	got := withScopeRule(rules, config.ScopeRule{Path: "cmd", Scope: "cli"})
+	assert.Equal(t, []config.ScopeRule{{Path: "docs", Scope: "docs"}, {Path: "cmd", Scope: "cli"}}, got)
+}
+
+func TestPromptScopeRule(t *testing.T) {
+	var out bytes.Buffer
+	rule := config.ScopeRule{Path: "cmd", Scope: "cli"}
+	readLine := newTrimmedLineReader(strings.NewReader("maybe\ny\n\nq\n"))
+
+	accept, quit, err := promptScopeRule(&out, readLine, rule)
+	assert.NoError(t, err)
+	assert.True(t, accept)
+	assert.False(t, quit)
+	assert.Contains(t, out.String(), "Please enter y, n or q.")
+
+	accept, quit, _ = promptScopeRule(&out, readLine, rule)
+	assert.False(t, accept || quit, "blank declines")
+
+	_, quit, _ = promptScopeRule(&out, readLine, rule)
+	assert.True(t, quit)
+
+	_, quit, _ = promptScopeRule(&out, readLine, rule)
+	assert.True(t, quit, "end of input stops")
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/history"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyAll   bool
+	historyLimit int
+
+	redoYes       bool
+	redoNoVerify  bool
+	redoNoSignoff bool
+	redoDryRun    bool
+
+	historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "List the commit messages gmc generated",
+		Long: `List the commit messages gmc generated, with what became of each.
+
+gmc records every message it generates in $XDG_STATE_HOME/gmc/history.jsonl
+(default: ~/.local/state/gmc/history.jsonl), with the repository, branch,
+model, and a hash of the staged diff. A message's status is one of:
+
+  committed    committed
+  dry-run      generated with --dry-run
+  cancelled    declined at the prompt
+  failed       accepted, but git commit failed, for example in a hook
+  regenerated  replaced by another message with r at the prompt
+  undone       committed, then taken back with gmc undo --keep-message
+
+The file keeps the 500 most recent messages. Use 'gmc redo' to commit the last
+one that was not committed.`,
+	}
+
+	historyListCmd = &cobra.Command{
+		Use:               "list",
+		Aliases:           []string{"ls"},
+		Short:             "List generated messages, newest first",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Example: `  gmc history list
+  gmc history list --all -n 50
+  gmc history list --output json`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runHistoryList()
+		},
+	}
+
+	historyShowCmd = &cobra.Command{
+		Use:               "show [n]",
+		Short:             "Show a generated message, 1 being the newest",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Example: `  gmc history show
+  gmc history show 3`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runHistoryShow(args)
+		},
+	}
+
+	redoCmd = &cobra.Command{
+		Use:   "redo",
+		Short: "Commit the last uncommitted generated message",
+		Long: `Offer the last message generated in this repository again, and commit the
+staged changes with it, without another LLM call.
+
+This is the message of the last commit attempt: one that was cancelled, run
+with --dry-run, taken back with gmc undo --keep-message, or that failed in git
+commit, for example because a pre-commit hook rejected it. Fix the problem, stage
+the changes, and run gmc redo. If the staged changes differ from the ones the
+message was generated for, gmc warns.`,
+		Example: `  gmc redo
+  gmc redo --yes
+  gmc redo --no-verify`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return handleErrors(runRedo(cmd.InOrStdin()), true)
+		},
+	}
+)
+
+func init() {
+	historyListCmd.Flags().BoolVar(&historyAll, "all", false, "List the messages of every repository")
+	historyListCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of messages to list (0 for all)")
+	historyCmd.AddCommand(historyListCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	rootCmd.AddCommand(historyCmd)
+
+	redoCmd.Flags().BoolVarP(&redoYes, "yes", "y", false, "Commit without asking")
+	redoCmd.Flags().BoolVar(&redoNoVerify, "no-verify", false, "Skip pre-commit hooks")
+	redoCmd.Flags().BoolVar(&redoNoSignoff, "no-signoff", false, "Skip the DCO Signed-off-by trailer")
+	redoCmd.Flags().BoolVar(&redoDryRun, "dry-run", false, "Show the message only, do not commit")
+	rootCmd.AddCommand(redoCmd)
+}
+
+// HistoryJSON is an entry of the JSON output of gmc history list.
+type HistoryJSON struct {
+	N int `json:"n"`
+	history.Entry
+}
+
+// repoHistory returns the history entries, newest first: those of the current
+// repository, or all of them with all.
+func repoHistory(all bool) ([]history.Entry, error) {
+	path, err := history.FilePath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := history.Load(path)
+	if err != nil || all {
+		return entries, err
+	}
+
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return nil, err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return nil, fmt.Errorf("%w; use --all to list the messages of every repository", err)
+	}
+	return history.ForRepo(entries, repo.Root()), nil
+}
+
+func runHistoryList() error {
+	entries, err := repoHistory(historyAll)
+	if err != nil {
+		return err
+	}
+	if historyLimit > 0 && len(entries) > historyLimit {
+		entries = entries[:historyLimit]
+	}
+
+	if outputFormat() == "json" {
+		items := make([]HistoryJSON, len(entries))
+		for i, entry := range entries {
+			items[i] = HistoryJSON{N: i + 1, Entry: entry}
+		}
+		return printJSON(outWriter(), items)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(outWriter(), "No generated messages yet.")
+		return nil
+	}
+	printHistory(outWriter(), entries, historyAll)
+	return nil
+}
+
+func printHistory(w io.Writer, entries []history.Entry, withRepo bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "N\tTIME\tSTATUS\tBRANCH\tSUBJECT"
+	if withRepo {
+		header = "N\tTIME\tSTATUS\tREPO\tBRANCH\tSUBJECT"
+	}
+	fmt.Fprintln(tw, header)
+	for i, entry := range entries {
+		subject, _, _ := strings.Cut(entry.Message, "\n")
+		cols := []string{strconv.Itoa(i + 1), entry.Time.Local().Format("2006-01-02 15:04"), string(entry.Status)}
+		if withRepo {
+			cols = append(cols, entry.Repo)
+		}
+		cols = append(cols, entry.Branch, subject)
+		fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	}
+	_ = tw.Flush()
+}
+
+func runHistoryShow(args []string) error {
+	n := 1
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("invalid message number %q: use a number from gmc history list", args[0])
+		}
+	}
+	entries, err := repoHistory(false)
+	if err != nil {
+		return err
+	}
+	if n > len(entries) {
+		return fmt.Errorf("no message %d: this repository has %d in the history", n, len(entries))
+	}
+	entry := entries[n-1]
+
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), HistoryJSON{N: n, Entry: entry})
+	}
+	w := outWriter()
+	fmt.Fprintf(w, "Time: %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Status: %s\n", entry.Status)
+	fmt.Fprintf(w, "Branch: %s\n", entry.Branch)
+	fmt.Fprintf(w, "Model: %s\n", entry.Model)
+	fmt.Fprintf(w, "Diff hash: %s\n", entry.DiffHash)
+	fmt.Fprintf(w, "\n%s\n", entry.Message)
+	return nil
+}
+
+func runRedo(in io.Reader) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return err
+	}
+	path, err := history.FilePath()
+	if err != nil {
+		return err
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	entry, ok := history.Last(history.ForRepo(entries, repo.Root()))
+	if !ok {
+		return errors.New("no generated message to redo in this repository")
+	}
+
+	flow := workflow.NewCommitFlow(repo, nil, cfg, workflow.CommitOptions{
+		NoVerify:  redoNoVerify,
+		NoSignoff: redoNoSignoff,
+		DryRun:    redoDryRun,
+		AutoYes:   redoYes,
+		Verbose:   verbose,
+		Version:   Version,
+		JSON:      outputFormat() == "json",
+		ErrWriter: errWriter(),
+		OutWriter: outWriter(),
+	})
+	flow.SetPrompter(&workflow.InteractivePrompter{ErrWriter: errWriter(), Stdin: in, Cfg: cfg})
+	flow.SetHistory(history.Recorder{Path: path, Repo: repo.Root()})
+	return flow.Redo(entry)
+}
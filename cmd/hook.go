@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/samzong/gmc/internal/doctor"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/hook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hookCmd = &cobra.Command{
+		Use:   "hook",
+		Short: "Let git commit use gmc through a hook",
+		Long: `Install a prepare-commit-msg hook, so that git commit without -m opens the editor
+with a message gmc generated for the staged changes.
+
+The hook goes where git runs hooks from, which core.hooksPath changes. With husky,
+whose core.hooksPath is .husky/_, it goes in .husky next to the hooks husky runs. A
+hook that is already there, such as one lefthook or husky manages, is kept as
+prepare-commit-msg.gmc-chained and runs first; when it fails, the commit stops.`,
+		Example: `  gmc hook install
+  gmc hook doctor
+  gmc hook uninstall`,
+	}
+
+	hookInstallCmd = &cobra.Command{
+		Use:   "install",
+		Short: "Install the gmc prepare-commit-msg hook",
+		Long: `Install the gmc prepare-commit-msg hook in the current repository.
+
+When git commit is run without a message, the hook runs gmc --dry-run --yes and
+puts the message above git's comments in the file git opens in the editor. Commits
+with -m, -F, a template or --amend, merges, and the commits gmc makes itself are
+left alone. Hook runs are not recorded in gmc history. When gmc fails or is not on
+the PATH, the editor opens as usual.
+
+An existing prepare-commit-msg hook is renamed to prepare-commit-msg.gmc-chained and
+run first. Running install again updates the gmc hook.`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runHookInstall()
+		},
+	}
+
+	hookUninstallCmd = &cobra.Command{
+		Use:               "uninstall",
+		Short:             "Remove the gmc hook and restore the chained one",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runHookUninstall()
+		},
+	}
+
+	hookDoctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the gmc hook, its chain and the gmc binary",
+		Long: `Check the gmc prepare-commit-msg hook of the current repository.
+
+Checks:
+  - where git runs hooks from, with core.hooksPath and the hook manager
+  - the gmc hook is there and executable, and was not replaced, for example by
+    lefthook install
+  - the hook it chains, if any
+  - gmc is on the PATH
+
+Each check prints pass, warn or fail, as gmc doctor does.`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runHookDoctor()
+		},
+	}
+)
+
+func init() {
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookCmd.AddCommand(hookDoctorCmd)
+	rootCmd.AddCommand(hookCmd)
+}
+
+// HookDoctorJSON is the JSON output of gmc hook doctor.
+type HookDoctorJSON struct {
+	OK     bool           `json:"ok"`
+	Target hook.Target    `json:"target"`
+	Checks []doctor.Check `json:"checks"`
+}
+
+// hookTarget returns where the gmc hook of the current repository goes.
+func hookTarget() (hook.Target, error) {
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return hook.Target{}, err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return hook.Target{}, err
+	}
+	dir, hooksPath, err := repo.HooksDir()
+	if err != nil {
+		return hook.Target{}, err
+	}
+	return hook.Resolve(dir, hooksPath), nil
+}
+
+func runHookInstall() error {
+	target, err := hookTarget()
+	if err != nil {
+		return err
+	}
+	result, err := hook.Install(target)
+	if err != nil {
+		return err
+	}
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), result)
+	}
+
+	if result.Updated {
+		fmt.Fprintf(outWriter(), "Updated the gmc %s hook at %s\n", hook.Name, result.Path)
+	} else {
+		fmt.Fprintf(outWriter(), "Installed the gmc %s hook at %s\n", hook.Name, result.Path)
+	}
+	if result.Chained != "" {
+		fmt.Fprintf(errWriter(), "It runs the previous hook, now %s, first.\n", result.Chained)
+	}
+	if target.Manager == hook.ManagerLefthook {
+		fmt.Fprintln(errWriter(), "lefthook install rewrites the hook; run gmc hook install again after it.")
+	}
+	if _, err := exec.LookPath("gmc"); err != nil {
+		fmt.Fprintln(errWriter(), "Warning: gmc is not on the PATH, so the hook cannot run it.")
+	}
+	return nil
+}
+
+func runHookUninstall() error {
+	target, err := hookTarget()
+	if err != nil {
+		return err
+	}
+	restored, err := hook.Uninstall(target)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(outWriter(), "Removed the gmc %s hook from %s\n", hook.Name, target.Path())
+	if restored != "" {
+		fmt.Fprintf(errWriter(), "Restored the hook it chained to %s.\n", restored)
+	}
+	return nil
+}
+
+func runHookDoctor() error {
+	target, err := hookTarget()
+	if err != nil {
+		return err
+	}
+	gmcPath, _ := exec.LookPath("gmc")
+	checks := doctor.CheckHook(target, hook.Inspect(target), gmcPath)
+
+	ok := !doctor.HasFailure(checks)
+	if outputFormat() == "json" {
+		if err := printJSON(outWriter(), HookDoctorJSON{OK: ok, Target: target, Checks: checks}); err != nil {
+			return err
+		}
+	} else {
+		printDoctorChecks(outWriter(), checks)
+	}
+
+	if !ok {
+		return errors.New("gmc hook doctor found problems")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookInstallWithHusky(t *testing.T) {
+	repoDir := initCmdTestRepo(t)
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldCwd) }()
+	require.NoError(t, os.Chdir(repoDir))
+
+	var out bytes.Buffer
+	oldOut, oldErr := outWriterFunc, errWriterFunc
+	outWriterFunc = func() io.Writer { return &out }
+	errWriterFunc = func() io.Writer { return &out }
+	defer func() { outWriterFunc, errWriterFunc = oldOut, oldErr }()
+
+	husky := filepath.Join(repoDir, ".husky")
+	require.NoError(t, os.MkdirAll(filepath.Join(husky, "_"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(husky, "prepare-commit-msg"), []byte("npx commitizen\n"), 0o644))
+	runGitCmd(t, repoDir, "config", "core.hooksPath", ".husky/_")
+
+	require.NoError(t, runHookInstall())
+	husky, err = filepath.EvalSymlinks(husky)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Installed the gmc prepare-commit-msg hook at "+
+		filepath.Join(husky, "prepare-commit-msg"))
+	assert.Contains(t, out.String(), "prepare-commit-msg.gmc-chained, first")
+	chained, err := os.ReadFile(filepath.Join(husky, "prepare-commit-msg.gmc-chained"))
+	require.NoError(t, err)
+	assert.Equal(t, "npx commitizen\n", string(chained))
+
+	out.Reset()
+	require.NoError(t, runHookUninstall())
+	assert.Contains(t, out.String(), "Restored the hook it chained")
+	restored, err := os.ReadFile(filepath.Join(husky, "prepare-commit-msg"))
+	require.NoError(t, err)
+	assert.Equal(t, "npx commitizen\n", string(restored))
+	assert.NoFileExists(t, filepath.Join(repoDir, ".git", "hooks", "prepare-commit-msg"))
+
+	assert.ErrorContains(t, runHookDoctor(), "gmc hook doctor found problems")
+	assert.Contains(t, out.String(), "[fail] hook: "+filepath.Join(husky, "prepare-commit-msg")+" is not the gmc hook")
+}
@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/gmcerrors"
 	"github.com/samzong/gmc/internal/llm"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +22,13 @@ var (
 	initCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize gmc configuration",
+		Long: `Run the interactive setup wizard.
+
+The wizard asks for the LLM provider, API base URL and key, then lists the
+models the provider offers (GET /models) to pick a default. It saves the
+configuration and can run a test generation on a sample diff, reporting the
+latency, so first-run setup is validated end to end.`,
+		Args: cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			cfg, err := config.GetConfig()
 			if err != nil {
@@ -38,12 +49,51 @@ var (
 		return config.SaveConfig()
 	}
 
-	testLLMConnection = func(model string) error {
-		client := llm.NewClient(llm.Options{Timeout: time.Duration(timeoutSeconds) * time.Second})
-		return client.TestConnection(model)
+	listLLMModels = func(apiKey, apiBase string) ([]string, error) {
+		client := llm.NewClient(llm.Options{
+			Timeout:        llmTimeout(timeoutSeconds),
+			ConnectTimeout: llmTimeout(connectTimeout),
+			APIKey:         apiKey,
+			APIBase:        apiBase,
+		})
+		return client.ListModels()
+	}
+
+	testLLMGeneration = func(model string) (string, error) {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return "", err
+		}
+		prompt := formatter.BuildPromptWithConfig(cfg, []string{"greet.go"}, initSampleDiff, "")
+		client := llm.NewClient(llm.Options{
+			Timeout:        llmTimeout(timeoutSeconds),
+			ConnectTimeout: llmTimeout(connectTimeout),
+		})
+		return client.GenerateCommitMessage(prompt, model)
 	}
 )
 
+// initSampleDiff is the staged change gmc init generates a test message for.
+const initSampleDiff = `diff --git a/greet.go b/greet.go
+--- a/greet.go
++++ b/greet.go
+@@ -1,5 +1,9 @@
+ package main
+
+-func Greet() string {
+-	return "Hello"
++// Greet returns a greeting for name, or a generic one when name is empty.
++func Greet(name string) string {
++	if name == "" {
++		return "Hello"
++	}
++	return "Hello, " + name
+ }
+`
+
+// maxListedModels caps the model list gmc init prints; any other name can still be typed.
+const maxListedModels = 30
+
 func runInitWizard(in io.Reader, out io.Writer, current *config.Config) error {
 	cfg, err := initWizardConfig(current)
 	if err != nil {
@@ -57,15 +107,19 @@ func runInitWizard(in io.Reader, out io.Writer, current *config.Config) error {
 	fmt.Fprintln(out, "  optional shell integration (for seamless `gmc wt switch`).")
 	fmt.Fprintln(out)
 
-	apiKey, err := promptAPIKey(out, cfg, readLine)
+	provider, err := promptProvider(out, cfg, readLine)
 	if err != nil {
 		return err
 	}
-	model, err := promptModel(out, cfg, readLine)
+	apiBase, err := promptAPIBase(out, cfg, provider, readLine)
 	if err != nil {
 		return err
 	}
-	apiBase, err := promptAPIBase(out, cfg, readLine)
+	apiKey, err := promptAPIKey(out, cfg, provider, readLine)
+	if err != nil {
+		return err
+	}
+	model, err := promptModel(out, cfg, apiKey, apiBase, readLine)
 	if err != nil {
 		return err
 	}
@@ -74,7 +128,7 @@ func runInitWizard(in io.Reader, out io.Writer, current *config.Config) error {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
-	if err := maybeTestConnection(out, model, readLine); err != nil {
+	if err := maybeTestGeneration(out, model, readLine); err != nil {
 		return err
 	}
 
@@ -102,78 +156,181 @@ func newTrimmedLineReader(in io.Reader) func() (string, error) {
 	}
 }
 
-func promptAPIKey(out io.Writer, cfg *config.Config, readLine func() (string, error)) (string, error) {
+func promptProvider(out io.Writer, cfg *config.Config, readLine func() (string, error)) (llm.Provider, error) {
+	current := llm.ProviderForBase(cfg.APIBase)
+	defaultChoice := 1
+	fmt.Fprintln(out, "LLM provider:")
+	for i, provider := range llm.Providers {
+		location := provider.BaseURL
+		if provider.Name == llm.CustomProvider {
+			location = "any OpenAI-compatible API"
+		}
+		fmt.Fprintf(out, "  %d. %s (%s)\n", i+1, provider.Name, location)
+		if provider.Name == current.Name {
+			defaultChoice = i + 1
+		}
+	}
+
 	for {
-		if cfg.APIKey != "" {
-			fmt.Fprint(out, "OpenAI API Key (leave blank to keep current): ")
+		fmt.Fprintf(out, "Provider (default: %d): ", defaultChoice)
+		line, err := readLine()
+		if err != nil {
+			return llm.Provider{}, err
+		}
+		if line == "" {
+			return llm.Providers[defaultChoice-1], nil
+		}
+		if n, convErr := strconv.Atoi(line); convErr == nil && n >= 1 && n <= len(llm.Providers) {
+			return llm.Providers[n-1], nil
+		}
+		for _, provider := range llm.Providers {
+			if strings.EqualFold(line, provider.Name) {
+				return provider, nil
+			}
+		}
+		fmt.Fprintf(out, "Please enter a number from 1 to %d.\n", len(llm.Providers))
+	}
+}
+
+func promptAPIBase(
+	out io.Writer, cfg *config.Config, provider llm.Provider, readLine func() (string, error),
+) (string, error) {
+	apiBaseDefault := provider.BaseURL
+	if cfg.APIBase != "" && llm.ProviderForBase(cfg.APIBase).Name == provider.Name {
+		apiBaseDefault = cfg.APIBase
+	}
+
+	for {
+		if apiBaseDefault != "" {
+			fmt.Fprintf(out, "API Base URL (default: %s): ", apiBaseDefault)
 		} else {
-			fmt.Fprint(out, "OpenAI API Key (required): ")
+			fmt.Fprint(out, "API Base URL (required): ")
 		}
 
 		line, err := readLine()
 		if err != nil {
 			return "", err
 		}
-		if line == "" {
-			if cfg.APIKey != "" {
-				return cfg.APIKey, nil
-			}
-			fmt.Fprintln(out, "API key is required.")
-			continue
+		if line != "" {
+			return line, nil
 		}
-		return line, nil
+		if apiBaseDefault != "" {
+			return apiBaseDefault, nil
+		}
+		fmt.Fprintln(out, "API base URL is required for a custom provider.")
 	}
 }
 
-func promptModel(out io.Writer, cfg *config.Config, readLine func() (string, error)) (string, error) {
+func promptAPIKey(
+	out io.Writer, cfg *config.Config, provider llm.Provider, readLine func() (string, error),
+) (string, error) {
+	for {
+		switch {
+		case cfg.APIKey != "":
+			fmt.Fprintf(out, "%s API Key (leave blank to keep current): ", provider.Name)
+		case provider.KeyOptional:
+			fmt.Fprintf(out, "%s API Key (optional): ", provider.Name)
+		default:
+			fmt.Fprintf(out, "%s API Key (required): ", provider.Name)
+		}
+
+		line, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		if line != "" {
+			return line, nil
+		}
+		if cfg.APIKey != "" {
+			return cfg.APIKey, nil
+		}
+		if provider.KeyOptional {
+			// The server ignores the key, but gmc treats an empty key as unconfigured.
+			return strings.ToLower(provider.Name), nil
+		}
+		fmt.Fprintln(out, "API key is required.")
+	}
+}
+
+func promptModel(
+	out io.Writer, cfg *config.Config, apiKey, apiBase string, readLine func() (string, error),
+) (string, error) {
 	modelDefault := cfg.Model
 	if modelDefault == "" {
 		modelDefault = config.DefaultModel
 	}
-	fmt.Fprintf(out, "Model (default: %s): ", modelDefault)
 
-	line, err := readLine()
+	fmt.Fprintln(out, "Fetching available models...")
+	models, err := listLLMModels(apiKey, apiBase)
 	if err != nil {
-		return "", err
+		fmt.Fprintf(out, "Could not list models: %v\n", err)
+		fmt.Fprintln(out, "Check the key and base URL, or enter a model name to continue.")
+	} else {
+		printModelChoices(out, models)
 	}
-	if line == "" {
-		return modelDefault, nil
+
+	for {
+		if len(models) > 0 {
+			fmt.Fprintf(out, "Model (number or name, default: %s): ", modelDefault)
+		} else {
+			fmt.Fprintf(out, "Model (default: %s): ", modelDefault)
+		}
+
+		line, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			return modelDefault, nil
+		}
+		if n, convErr := strconv.Atoi(line); convErr == nil && len(models) > 0 {
+			if n >= 1 && n <= min(len(models), maxListedModels) {
+				return models[n-1], nil
+			}
+			fmt.Fprintf(out, "Please enter a number from 1 to %d, or a model name.\n", min(len(models), maxListedModels))
+			continue
+		}
+		if len(models) > 0 && !slices.Contains(models, line) {
+			fmt.Fprintf(out, "Note: %q is not in the provider's model list; using it anyway.\n", line)
+		}
+		return line, nil
 	}
-	return line, nil
 }
 
-func promptAPIBase(out io.Writer, cfg *config.Config, readLine func() (string, error)) (string, error) {
-	apiBaseLabel := cfg.APIBase
-	if apiBaseLabel == "" {
-		apiBaseLabel = "<empty>"
+func printModelChoices(out io.Writer, models []string) {
+	if len(models) == 0 {
+		fmt.Fprintln(out, "The provider returned no models; enter a model name.")
+		return
 	}
-	fmt.Fprintf(out, "API Base URL (default: %s): ", apiBaseLabel)
-
-	line, err := readLine()
-	if err != nil {
-		return "", err
+	fmt.Fprintf(out, "%d models available:\n", len(models))
+	for i, model := range models[:min(len(models), maxListedModels)] {
+		fmt.Fprintf(out, "  %d. %s\n", i+1, model)
 	}
-	if line == "" {
-		return cfg.APIBase, nil
+	if len(models) > maxListedModels {
+		fmt.Fprintf(out, "  ... and %d more; type any model name.\n", len(models)-maxListedModels)
 	}
-	return line, nil
 }
 
-func maybeTestConnection(out io.Writer, model string, readLine func() (string, error)) error {
+func maybeTestGeneration(out io.Writer, model string, readLine func() (string, error)) error {
 	for {
-		fmt.Fprint(out, "Test API connection now? [Y/n]: ")
+		fmt.Fprint(out, "Run a test generation on a sample diff now? [Y/n]: ")
 		answer, err := readLine()
 		if err != nil {
 			return err
 		}
 		switch strings.ToLower(answer) {
 		case "", "y", "yes":
-			fmt.Fprintln(out, "Testing API connection...")
-			if err := testLLMConnection(model); err != nil {
-				fmt.Fprintf(out, "Connection test failed: %v\n", err)
-				fmt.Fprintln(out, "You can re-run `gmc init` or update config with `gmc config set`.")
+			fmt.Fprintf(out, "Generating a commit message with %s...\n", model)
+			start := time.Now()
+			message, err := testLLMGeneration(model)
+			latency := time.Since(start).Round(10 * time.Millisecond)
+			if err != nil {
+				fmt.Fprintf(out, "Test generation failed after %s: %v\n", latency, err)
+				fmt.Fprintln(out, "You can re-run `gmc init`, update config with `gmc config set`, or run `gmc config doctor`.")
 			} else {
-				fmt.Fprintln(out, "Connection test succeeded.")
+				subject, _, _ := strings.Cut(message, "\n")
+				fmt.Fprintf(out, "Test generation succeeded in %s:\n", latency)
+				fmt.Fprintf(out, "    %s\n", subject)
 			}
 			return nil
 		case "n", "no":
@@ -268,6 +425,9 @@ func maybeShellIntegration(out io.Writer, readLine func() (string, error), shell
 	}
 }
 
+// errAPIKeyMissing is returned when a command needs the LLM and no API key is set.
+var errAPIKeyMissing = gmcerrors.Mark(errors.New("API key is not configured: run gmc init"), gmcerrors.ErrConfigMissing)
+
 func ensureLLMConfigured(
 	cfg *config.Config, in io.Reader, out io.Writer,
 	initRunner func(io.Reader, io.Writer, *config.Config) error,
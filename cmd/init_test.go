@@ -13,7 +13,7 @@ import (
 
 func TestRunInitWizard_RequiresAPIKeyAndUsesDefaults(t *testing.T) {
 	t.Setenv("SHELL", "/bin/zsh")
-	input := strings.NewReader("\nkey123\n\nhttps://proxy.example/v1\nn\nn\n")
+	input := strings.NewReader("5\nhttps://proxy.example/v1\n\nkey123\n\nn\nn\n")
 	var output bytes.Buffer
 
 	cfg := &config.Config{
@@ -24,10 +24,12 @@ func TestRunInitWizard_RequiresAPIKeyAndUsesDefaults(t *testing.T) {
 
 	var savedAPIKey, savedModel, savedBase string
 	origSave := saveConfigValues
-	origTest := testLLMConnection
+	origTest := testLLMGeneration
+	origList := listLLMModels
 	defer func() {
 		saveConfigValues = origSave
-		testLLMConnection = origTest
+		testLLMGeneration = origTest
+		listLLMModels = origList
 	}()
 
 	saveConfigValues = func(apiKey, model, apiBase string) error {
@@ -37,10 +39,14 @@ func TestRunInitWizard_RequiresAPIKeyAndUsesDefaults(t *testing.T) {
 		return nil
 	}
 
+	listLLMModels = func(_, _ string) ([]string, error) {
+		return nil, errors.New("404 page not found")
+	}
+
 	var testCalled bool
-	testLLMConnection = func(_ string) error {
+	testLLMGeneration = func(_ string) (string, error) {
 		testCalled = true
-		return nil
+		return "", nil
 	}
 
 	err := runInitWizard(input, &output, cfg)
@@ -50,11 +56,12 @@ func TestRunInitWizard_RequiresAPIKeyAndUsesDefaults(t *testing.T) {
 	assert.Equal(t, "https://proxy.example/v1", savedBase)
 	assert.False(t, testCalled)
 	assert.Contains(t, output.String(), "API key is required")
+	assert.Contains(t, output.String(), "Could not list models: 404 page not found")
 }
 
 func TestRunInitWizard_KeepExistingKeyAndTestConnection(t *testing.T) {
 	t.Setenv("SHELL", "/bin/zsh")
-	input := strings.NewReader("\ngpt-4.2\n\ny\nn\n")
+	input := strings.NewReader("\n\n\n2\ny\nn\n")
 	var output bytes.Buffer
 
 	cfg := &config.Config{
@@ -65,10 +72,12 @@ func TestRunInitWizard_KeepExistingKeyAndTestConnection(t *testing.T) {
 
 	var savedAPIKey, savedModel, savedBase string
 	origSave := saveConfigValues
-	origTest := testLLMConnection
+	origTest := testLLMGeneration
+	origList := listLLMModels
 	defer func() {
 		saveConfigValues = origSave
-		testLLMConnection = origTest
+		testLLMGeneration = origTest
+		listLLMModels = origList
 	}()
 
 	saveConfigValues = func(apiKey, model, apiBase string) error {
@@ -78,10 +87,16 @@ func TestRunInitWizard_KeepExistingKeyAndTestConnection(t *testing.T) {
 		return nil
 	}
 
+	var listedWith string
+	listLLMModels = func(apiKey, apiBase string) ([]string, error) {
+		listedWith = apiKey + " " + apiBase
+		return []string{"gpt-4.1-mini", "gpt-4.2"}, nil
+	}
+
 	var testedModel string
-	testLLMConnection = func(model string) error {
+	testLLMGeneration = func(model string) (string, error) {
 		testedModel = model
-		return nil
+		return "feat(greet): greet by name\n\n- Add a name parameter", nil
 	}
 
 	err := runInitWizard(input, &output, cfg)
@@ -90,6 +105,50 @@ func TestRunInitWizard_KeepExistingKeyAndTestConnection(t *testing.T) {
 	assert.Equal(t, "gpt-4.2", savedModel)
 	assert.Equal(t, "https://proxy.example/v1", savedBase)
 	assert.Equal(t, "gpt-4.2", testedModel)
+	assert.Equal(t, "existing-key https://proxy.example/v1", listedWith)
+	assert.Contains(t, output.String(), "5. Custom (any OpenAI-compatible API)")
+	assert.Contains(t, output.String(), "Provider (default: 5)")
+	assert.Contains(t, output.String(), "  2. gpt-4.2\n")
+	assert.Contains(t, output.String(), "Test generation succeeded in ")
+	assert.Contains(t, output.String(), "    feat(greet): greet by name\n")
+}
+
+func TestRunInitWizard_LocalProviderWithoutKey(t *testing.T) {
+	t.Setenv("SHELL", "/bin/zsh")
+	input := strings.NewReader("ollama\n\n\nqwen2.5-coder\ny\nn\n")
+	var output bytes.Buffer
+
+	var savedAPIKey, savedModel, savedBase string
+	origSave := saveConfigValues
+	origTest := testLLMGeneration
+	origList := listLLMModels
+	defer func() {
+		saveConfigValues = origSave
+		testLLMGeneration = origTest
+		listLLMModels = origList
+	}()
+
+	saveConfigValues = func(apiKey, model, apiBase string) error {
+		savedAPIKey = apiKey
+		savedModel = model
+		savedBase = apiBase
+		return nil
+	}
+	listLLMModels = func(_, _ string) ([]string, error) {
+		return []string{"llama3.2"}, nil
+	}
+	testLLMGeneration = func(_ string) (string, error) {
+		return "", errors.New("connection refused")
+	}
+
+	err := runInitWizard(input, &output, &config.Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ollama", savedAPIKey)
+	assert.Equal(t, "qwen2.5-coder", savedModel)
+	assert.Equal(t, "http://localhost:11434/v1", savedBase)
+	assert.Contains(t, output.String(), `"qwen2.5-coder" is not in the provider's model list`)
+	assert.Contains(t, output.String(), "Test generation failed after ")
+	assert.Contains(t, output.String(), "connection refused")
 }
 
 func TestEnsureLLMConfigured_WithAPIKey(t *testing.T) {
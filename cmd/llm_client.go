@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/samzong/gmc/internal/guard"
+	"github.com/samzong/gmc/internal/llm"
+)
+
+func newLLMClient() *llm.Client {
+	return llm.NewClient(llm.Options{
+		Timeout:        llmTimeout(timeoutSeconds),
+		ConnectTimeout: llmTimeout(connectTimeout),
+		OnUsage:        usageTracker(errWriter()),
+		Temperature:    temperatureFlag.value,
+		AllowSecrets:   allowSecrets,
+		OnRedact:       redactionNotice(errWriter()),
+	})
+}
+
+// llmTimeout converts the seconds of --timeout or --connect-timeout to a timeout of
+// llm.Options, where 0 sets no limit.
+func llmTimeout(seconds int) time.Duration {
+	if seconds == 0 {
+		return llm.NoTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// temperatureValue is the --temperature flag, which overrides the temperature config
+// only when it is given.
+type temperatureValue struct {
+	value *float64
+}
+
+func (f *temperatureValue) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f.value, 'g', -1, 64)
+}
+
+func (f *temperatureValue) Set(s string) error {
+	t, err := strconv.ParseFloat(s, 64)
+	if err != nil || t < 0 || t > 2 {
+		return errors.New("must be a number from 0 to 2")
+	}
+	f.value = &t
+	return nil
+}
+
+func (f *temperatureValue) Type() string { return "float" }
+
+var temperatureFlag = &temperatureValue{}
+
+// usageTracker adds each request's token usage to the usage stats file, and prints it to
+// w in verbose mode. Failing to update the file only warns, once. Concurrent requests,
+// such as diff summaries, are recorded one at a time.
+func usageTracker(w io.Writer) func(llm.Usage) {
+	var mu sync.Mutex
+	warned := false
+	return func(u llm.Usage) {
+		mu.Lock()
+		defer mu.Unlock()
+		if verbose {
+			fmt.Fprintf(w, "Token usage: %s\n", u)
+		}
+		path, err := llm.UsageFilePath()
+		if err == nil {
+			err = llm.RecordUsage(path, u)
+		}
+		if err != nil && !warned {
+			warned = true
+			fmt.Fprintf(w, "Warning: failed to record token usage: %v\n", err)
+		}
+	}
+}
+
+// redactionNotice prints the likely secrets guard.redact replaced in a prompt to w.
+// Concurrent requests, such as diff summaries, print one at a time.
+func redactionNotice(w io.Writer) func([]guard.Finding) {
+	var mu sync.Mutex
+	return func(findings []guard.Finding) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "Redacted %d possible secret(s) before sending the diff:\n", len(findings))
+		for _, finding := range findings {
+			fmt.Fprintf(w, "  - %s\n", finding)
+		}
+	}
+}
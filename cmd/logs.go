@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/debuglog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsShowLast bool
+
+	logsCmd = &cobra.Command{
+		Use:   "logs",
+		Short: "Inspect --debug logs",
+		Long: `Inspect the logs written by --debug.
+
+Each gmc invocation run with --debug writes a JSON-lines log to
+$XDG_STATE_HOME/gmc/logs (default: ~/.local/state/gmc/logs). It records the
+prompt sent, the model, token usage, latency, and the git commands executed.
+API keys and tokens are replaced with [REDACTED]. gmc keeps the 20 most recent
+logs.`,
+	}
+
+	logsListCmd = &cobra.Command{
+		Use:               "list",
+		Short:             "List debug logs, newest first",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runLogsList()
+		},
+	}
+
+	logsShowCmd = &cobra.Command{
+		Use:               "show [name]",
+		Short:             "Show a debug log",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeLogNames,
+		Example: `  gmc logs show --last
+  gmc logs show gmc-20250102-150405-4242.log
+  gmc logs show --last --output json`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runLogsShow(args)
+		},
+	}
+)
+
+func init() {
+	logsShowCmd.Flags().BoolVar(&logsShowLast, "last", false, "Show the most recent log")
+
+	logsCmd.AddCommand(logsListCmd)
+	logsCmd.AddCommand(logsShowCmd)
+	rootCmd.AddCommand(logsCmd)
+}
+
+// startDebugLog opens the --debug log file. Failing to open it only warns, so --debug
+// never blocks a commit.
+func startDebugLog() {
+	dir, err := debuglog.Dir()
+	if err == nil {
+		var path string
+		path, err = debuglog.Start(dir)
+		if err == nil {
+			fmt.Fprintf(errWriter(), "[debug] Logging to %s\n", path)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(errWriter(), "Warning: debug log unavailable: %v\n", err)
+		return
+	}
+
+	if cfg, err := config.GetConfig(); err == nil {
+		for _, secret := range []string{cfg.APIKey, cfg.GitHubToken, cfg.GitLabToken, cfg.GiteaToken, cfg.JiraToken} {
+			debuglog.AddSecret(secret)
+		}
+	}
+	for _, env := range []string{"GITHUB_TOKEN", "GITLAB_TOKEN", "GITEA_TOKEN", "JIRA_TOKEN", "GMC_API_KEY"} {
+		debuglog.AddSecret(os.Getenv(env))
+	}
+	debuglog.Log("start", "args", os.Args[1:], "version", Version)
+}
+
+// LogsShowJSON is the JSON output of gmc logs show.
+type LogsShowJSON struct {
+	Path    string            `json:"path"`
+	Records []debuglog.Record `json:"records"`
+}
+
+func runLogsList() error {
+	dir, err := debuglog.Dir()
+	if err != nil {
+		return err
+	}
+	files, err := debuglog.List(dir)
+	if err != nil {
+		return err
+	}
+	if outputFormat() == "json" {
+		if files == nil {
+			files = []debuglog.File{}
+		}
+		return printJSON(outWriter(), files)
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(errWriter(), "No debug logs in %s; run gmc with --debug to write one.\n", dir)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(outWriter(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tMODIFIED")
+	for _, file := range files {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", file.Name, file.Size, file.ModTime.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+func runLogsShow(args []string) error {
+	if len(args) == 0 && !logsShowLast {
+		return errors.New("specify a log name or --last; run 'gmc logs list' to see available logs")
+	}
+	if len(args) > 0 && logsShowLast {
+		return errors.New("--last cannot be combined with a log name")
+	}
+
+	path, err := resolveLogPath(args)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	records, err := debuglog.Read(f)
+	if err != nil {
+		return err
+	}
+	if outputFormat() == "json" {
+		if records == nil {
+			records = []debuglog.Record{}
+		}
+		return printJSON(outWriter(), LogsShowJSON{Path: path, Records: records})
+	}
+
+	fmt.Fprintf(errWriter(), "# %s\n", path)
+	for _, record := range records {
+		printLogRecord(outWriter(), record)
+	}
+	return nil
+}
+
+// resolveLogPath returns the log for a name or path, or the newest log for --last. The
+// log of the running invocation is skipped, so --debug does not show itself.
+func resolveLogPath(args []string) (string, error) {
+	dir, err := debuglog.Dir()
+	if err != nil {
+		return "", err
+	}
+	if len(args) > 0 {
+		if strings.ContainsRune(args[0], filepath.Separator) {
+			return args[0], nil
+		}
+		return filepath.Join(dir, args[0]), nil
+	}
+
+	files, err := debuglog.List(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if file.Path != debuglog.Current() {
+			return file.Path, nil
+		}
+	}
+	return "", fmt.Errorf("no debug logs in %s; run gmc with --debug to write one", dir)
+}
+
+// printLogRecord prints a one-line summary of a record, followed by its multi-line
+// fields, such as the prompt and response, as indented blocks.
+func printLogRecord(w io.Writer, record debuglog.Record) {
+	keys := make([]string, 0, len(record.Fields))
+	for key := range record.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var summary []string
+	var blocks []string
+	for _, key := range keys {
+		value := formatLogValue(record.Fields[key])
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "\n") || key == "prompt" || key == "response" {
+			blocks = append(blocks, key)
+			continue
+		}
+		summary = append(summary, key+"="+value)
+	}
+
+	fmt.Fprintf(w, "%s %-5s %s\n", record.Time.Format("15:04:05.000"), record.Event, strings.Join(summary, " "))
+	for _, key := range blocks {
+		fmt.Fprintf(w, "  %s:\n", key)
+		for _, line := range strings.Split(strings.TrimRight(formatLogValue(record.Fields[key]), "\n"), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+func formatLogValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, part := range v {
+			parts[i] = fmt.Sprint(part)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func completeLogNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	dir, err := debuglog.Dir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	files, _ := debuglog.List(dir)
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
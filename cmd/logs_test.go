@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samzong/gmc/internal/debuglog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintLogRecord(t *testing.T) {
+	var out bytes.Buffer
+	printLogRecord(&out, debuglog.Record{
+		Time:  time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Event: "llm",
+		Fields: map[string]any{
+			"model":      "gpt-4.1-mini",
+			"latency_ms": float64(812),
+			"prompt":     "first line\nsecond line",
+			"response":   "",
+		},
+	})
+	assert.Equal(t, "15:04:05.000 llm   latency_ms=812 model=gpt-4.1-mini\n"+
+		"  prompt:\n    first line\n    second line\n", out.String())
+
+	out.Reset()
+	printLogRecord(&out, debuglog.Record{
+		Time:   time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Event:  "git",
+		Fields: map[string]any{"args": []any{"diff", "--cached"}},
+	})
+	assert.Equal(t, "15:04:05.000 git   args=[diff --cached]\n", out.String())
+}
+
+func TestResolveLogPathLast(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	dir := filepath.Join(state, "gmc", "logs")
+
+	_, err := resolveLogPath(nil)
+	assert.ErrorContains(t, err, "no debug logs")
+
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	older := filepath.Join(dir, "gmc-20250101-000000-1.log")
+	require.NoError(t, os.WriteFile(older, nil, 0o600))
+
+	current, err := debuglog.Start(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = debuglog.Close() })
+
+	path, err := resolveLogPath(nil)
+	require.NoError(t, err)
+	assert.Equal(t, older, path, "skips the running invocation's log")
+	assert.NotEqual(t, current, path)
+
+	path, err = resolveLogPath([]string{"gmc-x.log"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "gmc-x.log"), path)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/notes"
+	"github.com/spf13/cobra"
+)
+
+var (
+	notesCmd = &cobra.Command{
+		Use:   "notes",
+		Short: "Inspect generation metadata stored as git notes",
+		Long: `Inspect the metadata gmc records for each generated commit.
+
+With generation_notes set to true, gmc stores the model, a hash of the prompt,
+and the candidate messages it generated as a git note under refs/notes/gmc after
+each commit. The commit message is not changed.
+
+Notes stay local until you push them:
+
+  git push origin refs/notes/gmc`,
+	}
+
+	notesShowCmd = &cobra.Command{
+		Use:               "show [commit]",
+		Short:             "Show the generation metadata of a commit",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Example: `  gmc notes show
+  gmc notes show a1b2c3d
+  gmc notes show HEAD~2 --output json`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			commit := "HEAD"
+			if len(args) > 0 {
+				commit = args[0]
+			}
+			return runNotesShow(commit)
+		},
+	}
+)
+
+func init() {
+	notesCmd.AddCommand(notesShowCmd)
+	rootCmd.AddCommand(notesCmd)
+}
+
+// NotesShowJSON is the JSON output of gmc notes show.
+type NotesShowJSON struct {
+	Commit string `json:"commit"`
+	notes.Generation
+}
+
+func runNotesShow(commit string) error {
+	content, err := git.NewClient(git.Options{Verbose: verbose, Dir: workDir}).ShowNote(notes.Ref, commit)
+	if errors.Is(err, git.ErrNoNote) {
+		return fmt.Errorf("%w; gmc records one only for commits it generates while generation_notes is enabled", err)
+	}
+	if err != nil {
+		return wrapGitError(err)
+	}
+
+	gen, err := notes.Decode(content)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), NotesShowJSON{Commit: commit, Generation: gen})
+	}
+	printGeneration(outWriter(), commit, gen)
+	return nil
+}
+
+func printGeneration(w io.Writer, commit string, gen notes.Generation) {
+	fmt.Fprintf(w, "Commit: %s\n", commit)
+	fmt.Fprintf(w, "Model: %s\n", gen.Model)
+	fmt.Fprintf(w, "Prompt hash: %s\n", gen.PromptHash)
+	fmt.Fprintf(w, "Created: %s\n", gen.CreatedAt)
+	fmt.Fprintf(w, "Edited: %t\n", gen.Edited)
+	fmt.Fprintf(w, "Candidates (%d):\n", len(gen.Candidates))
+	for i, candidate := range gen.Candidates {
+		subject, _, _ := strings.Cut(candidate, "\n")
+		fmt.Fprintf(w, "  %d. %s\n", i+1, subject)
+	}
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintGeneration(t *testing.T) {
+	var buf bytes.Buffer
+	printGeneration(&buf, "HEAD", notes.Generation{
+		Model:      "gpt-4.1-mini",
+		PromptHash: "sha256:0123456789abcdef",
+		Candidates: []string{"feat(cli): add notes\n\nBody", "feat: add notes command"},
+		CreatedAt:  "2026-10-15T09:00:00Z",
+	})
+
+	out := buf.String()
+	assert.Contains(t, out, "Model: gpt-4.1-mini\n")
+	assert.Contains(t, out, "Edited: false\n")
+	assert.Contains(t, out, "Candidates (2):\n  1. feat(cli): add notes\n  2. feat: add notes command\n")
+	assert.NotContains(t, out, "Body")
+}
+
+func TestNotesShowArgs(t *testing.T) {
+	assert.NoError(t, notesShowCmd.Args(notesShowCmd, nil))
+	assert.Error(t, notesShowCmd.Args(notesShowCmd, []string{"a", "b"}))
+}
@@ -3,8 +3,11 @@ package cmd
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+
+	"github.com/spf13/cobra"
 )
 
 var (
@@ -31,8 +34,8 @@ type outputFormatFlag struct {
 
 func (f *outputFormatFlag) String() string { return f.value }
 func (f *outputFormatFlag) Set(s string) error {
-	if s != "text" && s != "json" {
-		return errors.New("must be text or json")
+	if s != "text" && s != "json" && s != "csv" {
+		return errors.New("must be text, json or csv")
 	}
 	f.value = s
 	return nil
@@ -45,6 +48,22 @@ func outputFormat() string {
 	return outputFlag.value
 }
 
+// csvOutputAnnotation marks, in a command's Annotations, the commands that support
+// --output csv.
+const csvOutputAnnotation = "gmc/csv-output"
+
+func supportsCSVOutput(cmd *cobra.Command) bool {
+	return cmd.Annotations[csvOutputAnnotation] != ""
+}
+
+// checkOutputFormat rejects --output csv for the commands that do not support it.
+func checkOutputFormat(cmd *cobra.Command) error {
+	if outputFormat() == "csv" && !supportsCSVOutput(cmd) {
+		return fmt.Errorf("--output csv is not supported by %s; use text or json", cmd.CommandPath())
+	}
+	return nil
+}
+
 func printJSON(w io.Writer, v any) error {
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
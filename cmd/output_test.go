@@ -19,7 +19,7 @@ func TestOutputFormatFlag_RejectsInvalid(t *testing.T) {
 	f := &outputFormatFlag{value: "text"}
 	err := f.Set("xml")
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "must be text or json")
+	assert.Contains(t, err.Error(), "must be text, json or csv")
 	assert.Equal(t, "text", f.String())
 }
 
@@ -31,6 +31,12 @@ func TestOutputFormatFlag_AcceptsValid(t *testing.T) {
 	assert.Equal(t, "text", f.String())
 }
 
+func TestCheckOutputFormat_CSVNeedsSupport(t *testing.T) {
+	withOutputFormat(t, "csv")
+	assert.NoError(t, checkOutputFormat(statsCmd))
+	assert.EqualError(t, checkOutputFormat(versionCmd), "--output csv is not supported by gmc version; use text or json")
+}
+
 func TestOutputFormatFlag_Type(t *testing.T) {
 	f := &outputFormatFlag{value: "text"}
 	assert.Equal(t, "string", f.Type())
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/review"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewTemplate string
+	reviewFailOn   string
+
+	reviewCmd = &cobra.Command{
+		Use:   "review",
+		Short: "Review the staged changes with the LLM",
+		Long: `Send the staged diff to the LLM with a code review template and print the
+findings grouped by severity: high, medium and low. The built-in template
+reports bug risks, missing tests and style problems in the changed lines.
+
+--fail-on exits with code 19 when a finding is at or above the severity, so CI
+and pre-commit hooks can stop on it. Nothing is committed.
+
+Review templates live in the review directory of the template directories,
+.gmc/templates/review and the user's templates/review, and are picked with
+--template or review_template. A default.yaml there replaces the built-in one,
+which gmc template export-builtin writes out to start from.`,
+		Example: `  gmc review
+  gmc review --fail-on high
+  gmc review --template security -o json`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runReview(os.Stdin)
+		},
+	}
+)
+
+func init() {
+	reviewCmd.Flags().StringVar(&reviewTemplate, "template", "",
+		"Review template `name` or file, overriding review_template")
+	reviewCmd.Flags().StringVar(&reviewFailOn, "fail-on", "",
+		"Exit with code 19 when a finding is at or above `severity`: high, medium or low")
+	_ = reviewCmd.RegisterFlagCompletionFunc("fail-on", cobra.FixedCompletions(
+		[]string{string(review.High), string(review.Medium), string(review.Low)}, cobra.ShellCompDirectiveNoFileComp))
+	_ = reviewCmd.RegisterFlagCompletionFunc("template", completeReviewTemplates)
+	rootCmd.AddCommand(reviewCmd)
+}
+
+func completeReviewTemplates(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	templates, err := formatter.ListTemplates(formatter.ReviewTemplateDirs(templateDirs()))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(templates))
+	for _, tpl := range templates {
+		if !tpl.Shadowed {
+			names = append(names, tpl.Name)
+		}
+	}
+	return completeStrings(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// ReviewJSON is the JSON output of gmc review.
+type ReviewJSON struct {
+	Findings []review.Finding `json:"findings"`
+	FailOn   review.Severity  `json:"fail_on,omitempty"`
+	Failed   bool             `json:"failed"`
+}
+
+func runReview(in io.Reader) error {
+	var failOn review.Severity
+	if reviewFailOn != "" {
+		var err error
+		if failOn, err = review.ParseSeverity(reviewFailOn); err != nil {
+			return fmt.Errorf("invalid --fail-on value: %w", err)
+		}
+	}
+
+	gitClient, err := newGitClient()
+	if err != nil {
+		return err
+	}
+	changes, err := gitClient.GetStagedChanges()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(changes.Diff) == "" {
+		return workflow.ErrNoChanges
+	}
+
+	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		return errAPIKeyMissing
+	}
+	ref := reviewTemplate
+	if ref == "" {
+		ref = cfg.ReviewTemplate
+	}
+	if ref == "" {
+		ref = config.DefaultPromptTemplate
+	}
+	path, err := formatter.FindTemplate(formatter.ReviewTemplateDirs(templateDirs()), ref)
+	if err != nil {
+		return err
+	}
+	diff := changes.Diff + "\n" + formatter.DiffStatsSeparator + "\n" + changes.Stats
+	prompt, err := formatter.BuildReviewPrompt(cfg, templateRef(path), changes.Files, diff)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(errWriter(), "Reviewing %d staged file(s)...\n", len(changes.Files))
+	reply, err := newLLMClient().ReviewChanges(prompt, cfg.Model)
+	if err != nil {
+		return err
+	}
+	findings, err := review.Parse(reply)
+	if err != nil {
+		return err
+	}
+
+	failing := 0
+	if failOn != "" {
+		failing = review.Count(findings, failOn)
+	}
+	if outputFormat() == "json" {
+		if findings == nil {
+			findings = []review.Finding{}
+		}
+		if err := printJSON(outWriter(), ReviewJSON{Findings: findings, FailOn: failOn, Failed: failing > 0}); err != nil {
+			return err
+		}
+	} else {
+		printReview(outWriter(), findings)
+	}
+	if failing > 0 {
+		return fmt.Errorf("%w: %d finding(s) at or above %s", review.ErrFindings, failing, failOn)
+	}
+	return nil
+}
+
+// printReview prints findings under a heading per severity, as
+// "bug-risk  internal/cache/cache.go:42  The map is written without the lock.".
+func printReview(w io.Writer, findings []review.Finding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No findings.")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, severity := range review.Severities {
+		var group []review.Finding
+		for _, finding := range findings {
+			if finding.Severity == severity {
+				group = append(group, finding)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%s (%d)\n", strings.ToUpper(string(severity[:1]))+string(severity[1:]), len(group))
+		for _, finding := range group {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", finding.Category, finding.Location(), finding.Message)
+		}
+	}
+	_ = tw.Flush()
+}
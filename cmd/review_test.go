@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/review"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintReview(t *testing.T) {
+	var out bytes.Buffer
+	printReview(&out, []review.Finding{
+		{Severity: review.High, Category: review.BugRisk, File: "internal/cache/cache.go", Line: 42,
+			Message: "The map is written without the lock."},
+		{Severity: review.Low, Category: review.Style, File: "cmd/review.go", Message: "Rename the variable."},
+		{Severity: review.Low, Category: review.MissingTests, Message: "Nothing covers --fail-on."},
+	})
+	assert.Equal(t, "High (1)\n"+
+		"  bug-risk  internal/cache/cache.go:42  The map is written without the lock.\n"+
+		"Low (2)\n"+
+		"  style          cmd/review.go  Rename the variable.\n"+
+		"  missing-tests                 Nothing covers --fail-on.\n", out.String())
+
+	out.Reset()
+	printReview(&out, nil)
+	assert.Equal(t, "No findings.\n", out.String())
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/samzong/gmc/internal/analyzer"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rewriteRange    string
+	rewriteMinScore int
+	rewriteForce    bool
+	rewriteDryRun   bool
+	rewriteAutoYes  bool
+
+	rewriteCmd = &cobra.Command{
+		Use:   "rewrite --range <rev-range>",
+		Short: "Regenerate poor commit messages in a range",
+		Long: `Score the commit messages of a range with the same rules as gmc stats, generate
+a new message from the diff of each commit that scores below --min-score, show
+the old and new subjects, and rewrite the branch once confirmed.
+
+The range must end at the checked out branch, for example main..HEAD or
+HEAD~10.. , and must not contain merge commits. Only messages change: trees,
+authors and dates are kept, and the index and worktree are not touched.
+Commits after the first rewritten one get new hashes, and signatures are dropped.
+
+gmc refuses to run with uncommitted changes to tracked files, and refuses to
+rewrite commits a remote-tracking branch already contains unless --force is set.
+The previous tip stays in the branch reflog.`,
+		Example: `  gmc rewrite --range main..HEAD --dry-run
+  gmc rewrite --range HEAD~5..
+  gmc rewrite --range origin/main.. --min-score 80 --yes`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runRewrite(os.Stdin)
+		},
+	}
+)
+
+func init() {
+	rewriteCmd.Flags().StringVar(&rewriteRange, "range", "", "Revision range to rewrite, ending at HEAD")
+	rewriteCmd.Flags().IntVar(&rewriteMinScore, "min-score", 60, "Rewrite messages that score below this (0-100)")
+	rewriteCmd.Flags().BoolVar(&rewriteForce, "force", false, "Rewrite commits that are already pushed")
+	rewriteCmd.Flags().BoolVar(&rewriteDryRun, "dry-run", false, "Show the new messages without rewriting")
+	rewriteCmd.Flags().BoolVarP(&rewriteAutoYes, "yes", "y", false, "Rewrite without asking for confirmation")
+	_ = rewriteCmd.MarkFlagRequired("range")
+	rootCmd.AddCommand(rewriteCmd)
+}
+
+// rewriteEntry is a commit gmc rewrite generates a new message for.
+type rewriteEntry struct {
+	Hash       string   `json:"hash"`
+	Score      int      `json:"score"`
+	Issues     []string `json:"issues,omitempty"`
+	OldSubject string   `json:"old_subject"`
+	NewMessage string   `json:"new_message"`
+}
+
+// RewriteJSON is the JSON output of gmc rewrite.
+type RewriteJSON struct {
+	Branch   string         `json:"branch"`
+	Commits  int            `json:"commits"`
+	Rewrites []rewriteEntry `json:"rewrites"`
+	Applied  bool           `json:"applied"`
+	NewHead  string         `json:"new_head,omitempty"`
+}
+
+func runRewrite(in io.Reader) error {
+	if rewriteMinScore < 0 || rewriteMinScore > 100 {
+		return errors.New("--min-score must be between 0 and 100")
+	}
+
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return wrapGitError(err)
+	}
+	branch, err := repo.CurrentBranch()
+	if err != nil {
+		return err
+	}
+	if branch == "" {
+		return errors.New("HEAD is detached: check out the branch to rewrite first")
+	}
+
+	commits, err := repo.GetRangeCommits(rewriteRange)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		fmt.Fprintf(errWriter(), "No commits in %s.\n", rewriteRange)
+		return nil
+	}
+	if err := checkRewritable(repo, commits); err != nil {
+		return err
+	}
+
+	candidates := poorCommits(commits, rewriteMinScore)
+	if len(candidates) == 0 {
+		if outputFormat() == "json" {
+			return printJSON(outWriter(), RewriteJSON{Branch: branch, Commits: len(commits), Rewrites: []rewriteEntry{}})
+		}
+		fmt.Fprintf(outWriter(), "All %d commits in %s score at least %d; nothing to rewrite.\n",
+			len(commits), rewriteRange, rewriteMinScore)
+		return nil
+	}
+
+	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	if err != nil || !proceed {
+		return err
+	}
+	llmClient := newLLMClient()
+
+	originals := make(map[string]string, len(commits))
+	for _, commit := range commits {
+		originals[commit.Hash] = commit.Message
+	}
+	messages := make(map[string]string, len(candidates))
+	for i, commit := range candidates {
+		fmt.Fprintf(errWriter(), "[%d/%d] %s %s\n",
+			i+1, len(candidates), stringsutil.ShortHash(commit.Hash, 7, ""), commit.OldSubject)
+		diff, err := repo.GetCommitDiff(commit.Hash)
+		if err != nil {
+			return err
+		}
+		message, err := generateStdinMessage(llmClient, cfg, formatter.ExtractFilesFromDiff(diff), diff, "", "")
+		if err != nil {
+			return fmt.Errorf("commit %s: %w", stringsutil.ShortHash(commit.Hash, 7, ""), err)
+		}
+		candidates[i].NewMessage = keepOriginalBody(message, originals[commit.Hash])
+		messages[commit.Hash] = candidates[i].NewMessage
+	}
+
+	output := RewriteJSON{Branch: branch, Commits: len(commits), Rewrites: candidates}
+	if outputFormat() != "json" {
+		printRewritePreview(outWriter(), candidates)
+	}
+
+	if !rewriteDryRun {
+		confirmed, err := confirmRewrite(in, len(candidates), branch)
+		if err != nil {
+			return err
+		}
+		if confirmed {
+			newHead, err := repo.RewriteMessages(branch, commits, messages)
+			if err != nil {
+				return err
+			}
+			output.Applied, output.NewHead = true, newHead
+			oldHead := commits[len(commits)-1].Hash
+			fmt.Fprintf(errWriter(), "Rewrote %d commit message(s) on %s. To undo: git reset --hard %s\n",
+				len(candidates), branch, stringsutil.ShortHash(oldHead, 7, ""))
+		}
+	}
+
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), output)
+	}
+	return nil
+}
+
+// checkRewritable enforces what gmc rewrite needs before it calls the LLM: a linear
+// range ending at the branch tip and, unless this is a dry run, no uncommitted changes
+// and no pushed commits without --force.
+func checkRewritable(repo *git.Repo, commits []git.RangeCommit) error {
+	for _, commit := range commits {
+		if len(commit.Parents) > 1 {
+			return fmt.Errorf("%s contains merge commit %s; gmc rewrite only rewrites linear history",
+				rewriteRange, stringsutil.ShortHash(commit.Hash, 7, ""))
+		}
+	}
+	head, err := repo.GetRangeCommits("HEAD^!")
+	if err != nil {
+		return err
+	}
+	if len(head) != 1 || head[0].Hash != commits[len(commits)-1].Hash {
+		return fmt.Errorf("%s must end at HEAD, for example main..HEAD or HEAD~5..", rewriteRange)
+	}
+	if rewriteDryRun {
+		return nil
+	}
+
+	dirty, err := repo.HasUncommittedChanges()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return errors.New("the worktree has uncommitted changes: commit or stash them before rewriting history")
+	}
+	pushed, err := repo.PushedCommits(rewriteRange)
+	if err != nil {
+		return err
+	}
+	if len(pushed) > 0 && !rewriteForce {
+		return fmt.Errorf("%d commit(s) in %s are already on a remote branch, such as %s; "+
+			"use --force to rewrite them anyway", len(pushed), rewriteRange, stringsutil.ShortHash(pushed[0], 7, ""))
+	}
+	return nil
+}
+
+// poorCommits scores each commit subject and returns those below minScore, oldest first.
+func poorCommits(commits []git.RangeCommit, minScore int) []rewriteEntry {
+	var entries []rewriteEntry
+	for _, commit := range commits {
+		score := analyzer.ScoreCommit(git.CommitInfo{Hash: commit.Hash, Message: commit.Subject()})
+		if score.Score < minScore {
+			entries = append(entries, rewriteEntry{
+				Hash: commit.Hash, Score: score.Score, Issues: score.Issues, OldSubject: commit.Subject(),
+			})
+		}
+	}
+	return entries
+}
+
+// keepOriginalBody adds the body of the original message, such as its trailers, to a
+// generated message that has none.
+func keepOriginalBody(generated, original string) string {
+	subject, body := formatter.SplitCommitMessage(generated)
+	if strings.TrimSpace(body) != "" {
+		return generated
+	}
+	_, originalBody := formatter.SplitCommitMessage(original)
+	return formatter.JoinCommitMessage(subject, originalBody)
+}
+
+func printRewritePreview(w io.Writer, entries []rewriteEntry) {
+	for _, entry := range entries {
+		subject, _ := formatter.SplitCommitMessage(entry.NewMessage)
+		fmt.Fprintf(w, "\n%s (score %d: %s)\n",
+			stringsutil.ShortHash(entry.Hash, 7, ""), entry.Score, strings.Join(entry.Issues, ", "))
+		fmt.Fprintf(w, "  - %s\n", entry.OldSubject)
+		fmt.Fprintf(w, "  + %s\n", subject)
+	}
+}
+
+func confirmRewrite(in io.Reader, count int, branch string) (bool, error) {
+	if rewriteAutoYes {
+		return true, nil
+	}
+	if !isStdinTerminal() {
+		return false, errors.New("stdin is not a terminal, use --yes to skip interactive confirmation")
+	}
+
+	fmt.Fprintf(errWriter(), "\nRewrite %d commit message(s) on %s? [y/N]: ", count, branch)
+	answer, err := newTrimmedLineReader(in)()
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoorCommits(t *testing.T) {
+	commits := []git.RangeCommit{
+		{Hash: "a1", Message: "feat(cli): add the rewrite command"},
+		{Hash: "b2", Message: "wip\n\nmore later"},
+		{Hash: "c3", Message: "Fix stuff"},
+	}
+
+	entries := poorCommits(commits, 60)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b2", entries[0].Hash)
+	assert.Equal(t, "wip", entries[0].OldSubject)
+	assert.Contains(t, entries[0].Issues, "not a Conventional Commit")
+	assert.Equal(t, "c3", entries[1].Hash)
+
+	assert.Len(t, poorCommits(commits, 0), 0)
+	assert.Len(t, poorCommits(commits, 100), 2, "a perfect score is not below 100")
+}
+
+func TestKeepOriginalBody(t *testing.T) {
+	original := "wip\n\nSigned-off-by: A <a@example.com>"
+	assert.Equal(t, "fix: handle empty input\n\nSigned-off-by: A <a@example.com>",
+		keepOriginalBody("fix: handle empty input", original))
+	assert.Equal(t, "fix: handle empty input\n\n- check the length",
+		keepOriginalBody("fix: handle empty input\n\n- check the length", original))
+	assert.Equal(t, "fix: handle empty input", keepOriginalBody("fix: handle empty input", "wip"))
+}
@@ -5,35 +5,73 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/mattn/go-isatty"
+	"github.com/samzong/gmc/internal/analyzer"
+	"github.com/samzong/gmc/internal/builtin"
 	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/debuglog"
+	"github.com/samzong/gmc/internal/emoji"
 	"github.com/samzong/gmc/internal/exitcode"
+	"github.com/samzong/gmc/internal/forge"
+	"github.com/samzong/gmc/internal/forge/jira"
 	"github.com/samzong/gmc/internal/formatter"
 	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/gmcerrors"
+	"github.com/samzong/gmc/internal/guard"
+	"github.com/samzong/gmc/internal/history"
+	"github.com/samzong/gmc/internal/hunks"
 	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/review"
 	"github.com/samzong/gmc/internal/ui"
 	"github.com/samzong/gmc/internal/workflow"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile        string
-	noVerify       bool
-	noSignoff      bool
-	dryRun         bool
-	addAll         bool
-	issueNum       string
-	autoYes        bool
-	configErr      error
-	verbose        bool
-	branchDesc     string
-	userPrompt     string
-	timeoutSeconds int
-	debug          bool
-	rootCmd        = &cobra.Command{
+	cfgFile         string
+	noVerify        bool
+	noSignoff       bool
+	signoff         bool
+	gpgSign         string
+	dryRun          bool
+	addAll          bool
+	interactive     bool
+	perPackage      bool
+	acknowledgeRisk bool
+	issueNum        string
+	autoYes         bool
+	configErr       error
+	verbose         bool
+	branchDesc      string
+	userPrompt      string
+	typeFlag        string
+	scopeFlag       string
+	timeoutSeconds  int
+	connectTimeout  int
+	langFlag        string
+	bodyFlag        bool
+	strictContext   bool
+	allowSecrets    bool
+	offline         bool
+	debug           bool
+	noColor         bool
+	authorFlag      string
+	dateFlag        string
+	trailerFlags    []string
+	jiraTransition  string
+	jiraComment     bool
+	outFile         string
+	messageFile     string
+	compareModels   []string
+	noHistory       bool
+	workDir         string
+	workDirErr      error
+	rootCmd         = &cobra.Command{
 		Use:   "gmc",
 		Short: "Parallel git worktrees for AI agents, plus AI commit messages.",
 		Long: `gmc runs parallel git worktrees for parallel AI coding agents, built on the ` +
@@ -55,11 +93,20 @@ func RootCmd() *cobra.Command {
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	defer func() { _ = debuglog.Close() }()
+	err := rootCmd.Execute()
+	var exitErr *exitcode.Error
+	if err != nil && !errors.As(err, &exitErr) {
+		if coded := classifyError(err); coded != nil {
+			return coded
+		}
+	}
+	return err
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(resolveWorkDir, initConfig)
+	rootCmd.PersistentPreRunE = preRun
 
 	rootCmd.AddGroup(
 		&cobra.Group{ID: "worktree", Title: "Worktree (parallel AI development):"},
@@ -68,38 +115,215 @@ func init() {
 
 	wtCmd.GroupID = "worktree"
 	tagCmd.GroupID = "other"
+	statsCmd.GroupID = "other"
 	configCmd.GroupID = "other"
 	initCmd.GroupID = "other"
 	versionCmd.GroupID = "other"
 	completionCmd.GroupID = "other"
+	templateCmd.GroupID = "other"
+	doctorCmd.GroupID = "other"
+	guessScopeCmd.GroupID = "other"
+	logsCmd.GroupID = "other"
+	notesCmd.GroupID = "other"
+	evalCmd.GroupID = "other"
+	historyCmd.GroupID = "other"
+	redoCmd.GroupID = "other"
+	rewriteCmd.GroupID = "other"
+	undoCmd.GroupID = "other"
+	watchCmd.GroupID = "other"
+	squashCmd.GroupID = "other"
+	hookCmd.GroupID = "other"
+	explainCmd.GroupID = "other"
+	reviewCmd.GroupID = "other"
+	branchCmd.GroupID = "other"
+	serveCmd.GroupID = "other"
 	rootCmd.SetHelpCommandGroupID("other")
 
 	rootCmd.PersistentFlags().StringVar(
 		&cfgFile, "config", "", "Config file (default: $XDG_CONFIG_HOME/gmc/config.yaml)")
-	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug output")
-	rootCmd.PersistentFlags().VarP(outputFlag, "output", "o", "Output format: text or json")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false,
+		"Enable debug output and write a log to $XDG_STATE_HOME/gmc/logs")
+	rootCmd.PersistentFlags().VarP(outputFlag, "output", "o", "Output format: text or json, or csv where supported")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false,
+		"Disable colors, hyperlinks and the spinner (also set by NO_COLOR)")
+	rootCmd.PersistentFlags().StringVarP(&workDir, "cwd", "C", "",
+		"Run as if gmc was started in `dir`, like git -C")
+	// Every command that sends a diff to the LLM goes through the secret guard.
+	rootCmd.PersistentFlags().BoolVar(&allowSecrets, "allow-secrets", false,
+		"Send the diff even when it contains possible secrets such as API keys or private keys")
 	_ = rootCmd.RegisterFlagCompletionFunc("output", completeOutputFormat)
+	_ = rootCmd.RegisterFlagCompletionFunc("cwd", cobra.FixedCompletions(nil, cobra.ShellCompDirectiveFilterDirs))
 
 	rootCmd.Flags().BoolP("version", "V", false, "version for gmc")
 	rootCmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip pre-commit hooks")
-	rootCmd.Flags().BoolVar(&noSignoff, "no-signoff", false, "Skip signing the commit (DCO signoff)")
+	rootCmd.Flags().BoolVar(&signoff, "signoff", false, "Add a DCO Signed-off-by trailer (the default)")
+	rootCmd.Flags().BoolVar(&noSignoff, "no-signoff", false, "Skip the DCO Signed-off-by trailer")
+	rootCmd.MarkFlagsMutuallyExclusive("signoff", "no-signoff")
+	rootCmd.Flags().StringVar(&gpgSign, "gpg-sign", "",
+		"Sign the commit with GPG or SSH, optionally with `keyid` (overrides the sign_commits config)")
+	rootCmd.Flags().Lookup("gpg-sign").NoOptDefVal = gpgSignDefaultKey
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Generate message only, do not commit")
+	rootCmd.Flags().StringVar(&outFile, "out", "",
+		"With --dry-run, write the accepted message to `file`, as git commit -F reads it")
+	rootCmd.Flags().StringVar(&messageFile, "use-message-file", "",
+		"Commit with the message saved in `file`, such as by --out, instead of generating one")
+	// The prepare-commit-msg hook passes --no-history, so that every git commit does
+	// not add a dry-run entry to the history.
+	rootCmd.Flags().BoolVar(&noHistory, "no-history", false, "Do not record the generated message in the history")
+	_ = rootCmd.Flags().MarkHidden("no-history")
 	rootCmd.Flags().BoolVarP(&addAll, "all", "a", false,
 		"Stage files before committing (all files if none specified, or only specified files)")
+	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false,
+		"Pick the staged and unstaged hunks to commit before generating")
+	rootCmd.MarkFlagsMutuallyExclusive("interactive", "all")
+	rootCmd.Flags().BoolVar(&perPackage, "per-package", false,
+		"Commit the staged changes of each monorepo package separately, scoped by package (see package_globs)")
+	rootCmd.Flags().BoolVar(&acknowledgeRisk, "acknowledge-risk", false,
+		"Commit without the typed confirmation that risk_policies require")
 	rootCmd.Flags().StringVar(&issueNum, "issue", "", "Optional issue number")
 	rootCmd.Flags().BoolVarP(&autoYes, "yes", "y", false, "Automatically confirm the commit message")
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed git command output")
 	rootCmd.Flags().StringVarP(&branchDesc, "branch", "b", "", "Create and switch to a new branch with generated name")
 	rootCmd.Flags().StringVarP(&userPrompt, "prompt", "p", "",
 		"Additional context or instructions for commit message generation")
-	rootCmd.Flags().IntVar(&timeoutSeconds, "timeout", 30, "LLM request timeout in seconds")
+	rootCmd.Flags().IntVar(&timeoutSeconds, "timeout", 30,
+		"LLM request timeout in seconds, from connecting to the end of the reply; 0 for no limit")
+	rootCmd.Flags().IntVar(&connectTimeout, "connect-timeout", 10,
+		"Timeout in seconds for connecting to the LLM endpoint; 0 for no limit")
+	rootCmd.Flags().StringVar(&typeFlag, "type", "",
+		"Require this Conventional Commits `type` in the message, e.g. fix (must be in commit_types when set)")
+	rootCmd.Flags().StringVar(&scopeFlag, "scope", "", "Require this `scope` in the message, e.g. auth")
+	rootCmd.Flags().BoolVar(&bodyFlag, "body", false,
+		"Generate a bullet-point commit body in addition to the subject (overrides the commit_body config)")
+	rootCmd.Flags().StringVar(&langFlag, "lang", "",
+		"Language for the commit description, e.g. zh-CN, ja, de (overrides the language config)")
+	rootCmd.Flags().Var(temperatureFlag, "temperature",
+		"Sampling temperature for this run, 0 to 2 (overrides the temperature config)")
+	rootCmd.Flags().BoolVar(&strictContext, "strict-context", false,
+		"Fail instead of generating from a truncated diff when the changes are too large for the prompt")
+	rootCmd.Flags().StringSliceVar(&compareModels, "models", nil,
+		"Generate with each of these comma-separated `models` at once and pick among the messages")
+	rootCmd.Flags().BoolVar(&offline, "offline", false,
+		"Write the message from the diff with local rules instead of the LLM (no API key needed)")
+	rootCmd.Flags().StringVar(&authorFlag, "author", "",
+		"Override the commit author, as 'Name <email>' (GIT_AUTHOR_NAME/EMAIL are also respected)")
+	rootCmd.Flags().StringVar(&dateFlag, "date", "",
+		"Override the author date, e.g. 2024-01-02T15:04:05+0100 (GIT_AUTHOR_DATE is also respected)")
+	rootCmd.Flags().StringArrayVar(&trailerFlags, "trailer", nil,
+		"Add a trailer to the commit message as `key=value`, e.g. Refs=PROJ-123 (repeatable)")
+	rootCmd.Flags().StringVar(&jiraTransition, "jira-transition", "",
+		"Move the Jira ticket through the transition, or to the status, `name` after committing (needs jira_url)")
+	rootCmd.Flags().BoolVar(&jiraComment, "jira-comment", false,
+		"Comment the commit message on the Jira ticket after committing (needs jira_url)")
 
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(initCmd)
 }
 
+// resolveWorkDir makes --cwd absolute before the config is loaded. The process keeps
+// its working directory: git, the worktree client and repository config lookups get
+// workDir instead, and paths given on the command line are resolved by workPath.
+func resolveWorkDir() {
+	if workDir == "" {
+		return
+	}
+	dir, err := filepath.Abs(workDir)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = os.Stat(dir); err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", workDir)
+		}
+	}
+	if err != nil {
+		workDirErr = fmt.Errorf("cannot use --cwd: %w", err)
+		return
+	}
+	workDir = dir
+	config.SetWorkDir(dir)
+}
+
+// workingDir returns the directory gmc runs in: --cwd, or the process working directory.
+func workingDir() (string, error) {
+	if workDir != "" {
+		return workDir, nil
+	}
+	return os.Getwd()
+}
+
+// workPath resolves a path given on the command line against --cwd, as git -C does.
+func workPath(path string) string {
+	if path == "" || workDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workDir, path)
+}
+
+func preRun(cmd *cobra.Command, args []string) error {
+	if workDirErr != nil {
+		return workDirErr
+	}
+	if err := checkOutputFormat(cmd); err != nil {
+		return err
+	}
+	warnConfigIssues(cmd, args)
+	if cmd == rootCmd && configErr == nil {
+		if cfg, err := config.GetConfig(); err == nil {
+			applyFlagDefaults(cmd, cfg.Defaults)
+		}
+	}
+	return nil
+}
+
+// applyFlagDefaults seeds the flags of gmc itself from the defaults section of the
+// config. Flags given on the command line win, including --all=false and the like,
+// and add_all is skipped for --interactive, which picks hunks instead.
+func applyFlagDefaults(cmd *cobra.Command, defaults config.FlagDefaults) {
+	flags := cmd.Flags()
+	seed := func(name string, value bool, target *bool) {
+		if value && !flags.Changed(name) {
+			*target = true
+		}
+	}
+	if !flags.Changed("interactive") {
+		seed("all", defaults.AddAll, &addAll)
+	}
+	seed("yes", defaults.AutoYes, &autoYes)
+	seed("dry-run", defaults.DryRun, &dryRun)
+	seed("no-verify", defaults.NoVerify, &noVerify)
+	seed("verbose", defaults.Verbose, &verbose)
+	if defaults.Timeout != nil && !flags.Changed("timeout") {
+		timeoutSeconds = *defaults.Timeout
+	}
+	if defaults.ConnectTimeout != nil && !flags.Changed("connect-timeout") {
+		connectTimeout = *defaults.ConnectTimeout
+	}
+}
+
 func initConfig() {
 	configErr = config.InitConfig(cfgFile)
+	builtin.SetOverrideDirs(func() []string { return builtinOverrideDirs("") })
+	if configErr == nil {
+		applyEmojiMap()
+	}
+	if noColor {
+		ui.SetNoColor(true)
+		// Prompts drawn by huh follow NO_COLOR.
+		os.Setenv("NO_COLOR", "1")
+	}
+	if debug {
+		startDebugLog()
+	}
+}
+
+// applyEmojiMap puts the emoji_map emoji in front of their commit types.
+func applyEmojiMap() {
+	cfg, err := config.GetConfig()
+	if err != nil || len(cfg.EmojiMap) == 0 {
+		return
+	}
+	for _, commitType := range emoji.SetTypeEmojis(cfg.EmojiMap) {
+		fmt.Fprintf(os.Stderr, "Warning: emoji_map: %s is not a commit type, ignored\n", commitType)
+	}
 }
 
 func runRoot(cmd *cobra.Command, args []string) error {
@@ -129,16 +353,43 @@ func handleErrors(err error, addAllFlag bool) error {
 	return userFacingError{msg: fmt.Sprintf("gmc: %v", err), err: err}
 }
 
+// errorCodes maps the errors scripts can branch on to their exit codes. The first
+// match wins, so the specific LLM failures come before ErrLLM.
+var errorCodes = []struct {
+	target error
+	code   int
+}{
+	{gmcerrors.ErrNotARepo, exitcode.NotGitRepo},
+	{gmcerrors.ErrNoChanges, exitcode.NoStagedChanges},
+	{gmcerrors.ErrConfigMissing, exitcode.ConfigMissing},
+	{gmcerrors.ErrLLMAuth, exitcode.LLMAuth},
+	{gmcerrors.ErrLLMTimeout, exitcode.LLMTimeout},
+	{llm.ErrLLM, exitcode.LLMError},
+	{gmcerrors.ErrUserCancelled, exitcode.UserCancelled},
+	{workflow.ErrRiskNotAcknowledged, exitcode.RiskNotAcknowledged},
+	{guard.ErrSecretsFound, exitcode.SecretsFound},
+	{review.ErrFindings, exitcode.ReviewFindings},
+	{analyzer.ErrBelowMinScore, exitcode.QualityBelowMinScore},
+}
+
 func classifyError(err error) *exitcode.Error {
-	if errors.Is(err, git.ErrNotGitRepo) {
-		return exitcode.New(exitcode.NotGitRepo, err.Error(), err)
-	}
-	if errors.Is(err, llm.ErrLLM) {
-		return exitcode.New(exitcode.LLMError, err.Error(), err)
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.target) {
+			return exitcode.New(entry.code, err.Error(), err)
+		}
 	}
 	return nil
 }
 
+// wrapGitError returns err with the exit code errorCodes assigns to it, such as
+// NotGitRepo, or err itself when it has none.
+func wrapGitError(err error) error {
+	if coded := classifyError(err); coded != nil {
+		return coded
+	}
+	return err
+}
+
 type userFacingError struct {
 	msg string
 	err error
@@ -153,45 +404,216 @@ func (e userFacingError) Unwrap() error {
 }
 
 func generateAndCommit(in io.Reader, fileArgs []string) error {
-	gitClient := git.NewClient(git.Options{Verbose: verbose})
-	llmClient := llm.NewClient(llm.Options{Timeout: time.Duration(timeoutSeconds) * time.Second})
+	if timeoutSeconds < 0 || connectTimeout < 0 {
+		return errors.New("--timeout and --connect-timeout must be 0 or a number of seconds")
+	}
+	llmClient := newLLMClient()
 
 	if len(fileArgs) == 1 && fileArgs[0] == "-" {
+		if interactive {
+			return errors.New("--interactive cannot be combined with stdin mode")
+		}
+		if perPackage {
+			return errors.New("--per-package cannot be combined with stdin mode")
+		}
+		if outFile != "" || messageFile != "" {
+			return errors.New("--out and --use-message-file cannot be combined with stdin mode")
+		}
+		if len(compareModels) > 0 {
+			return errors.New("--models cannot be combined with stdin mode")
+		}
 		return handleStdinDiff(in, llmClient)
 	}
+	if err := checkGenerationFlags(); err != nil {
+		return err
+	}
+	var message string
+	if messageFile != "" {
+		var err error
+		if message, err = workflow.ReadMessageFile(workPath(messageFile)); err != nil {
+			return err
+		}
+	}
 
-	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	cfg, offlineRun, err := configForGeneration(in)
 	if err != nil {
 		return err
 	}
-	if !proceed {
-		return nil
+	if err := applyFlagOverrides(cfg); err != nil {
+		return err
+	}
+	commitType, scope, err := checkTypeAndScopeFlags(cfg, typeFlag, scopeFlag)
+	if err != nil {
+		return err
+	}
+	trailers, err := parseTrailerFlags(trailerFlags)
+	if err != nil {
+		return err
+	}
+	if (jiraTransition != "" || jiraComment) && cfg.JiraURL == "" {
+		return errors.New("--jira-transition and --jira-comment need jira_url; set it with gmc config set jira_url")
 	}
 
 	opts := workflow.CommitOptions{
-		AddAll:     addAll,
-		NoVerify:   noVerify,
-		NoSignoff:  noSignoff,
-		DryRun:     dryRun,
-		IssueNum:   issueNum,
-		AutoYes:    autoYes,
-		Verbose:    verbose,
-		BranchDesc: branchDesc,
-		UserPrompt: userPrompt,
-		ErrWriter:  errWriter(),
-		OutWriter:  outWriter(),
-	}
-
-	flow := workflow.NewCommitFlow(gitClient, llmClient, cfg, opts)
+		AddAll:          addAll,
+		NoVerify:        noVerify,
+		NoSignoff:       noSignoff,
+		DryRun:          dryRun,
+		IssueNum:        issueNum,
+		AutoYes:         autoYes,
+		Verbose:         verbose,
+		BranchDesc:      branchDesc,
+		UserPrompt:      userPrompt,
+		Type:            commitType,
+		Scope:           scope,
+		StrictContext:   strictContext,
+		Offline:         offlineRun,
+		Interactive:     interactive,
+		PerPackage:      perPackage,
+		AcknowledgeRisk: acknowledgeRisk,
+		JSON:            outputFormat() == "json",
+		Author:          authorFlag,
+		Date:            dateFlag,
+		SignKey:         signKey(),
+		Trailers:        trailers,
+		IssueComment:    jiraComment,
+		IssueTransition: jiraTransition,
+		Message:         message,
+		Models:          modelList(compareModels),
+		OutFile:         workPath(outFile),
+		Version:         Version,
+		ErrWriter:       errWriter(),
+		OutWriter:       outWriter(),
+	}
+
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return err
+	}
+	builtin.SetOverrideDirs(func() []string { return builtinOverrideDirs(repo.Root()) })
+
+	flow := workflow.NewCommitFlow(repo, llmClient, cfg, opts)
+	if path, err := history.FilePath(); err == nil && !noHistory {
+		flow.SetHistory(history.Recorder{Path: path, Repo: repo.Root()})
+	}
 	flow.SetPrompter(&workflow.InteractivePrompter{
 		ErrWriter: errWriter(),
 		Stdin:     in,
 		Cfg:       cfg,
 	})
+	if cfg.SummarizeDiffs && !offlineRun {
+		flow.SetSummarizer(llmClient)
+	}
+	if interactive && isatty.IsTerminal(os.Stdin.Fd()) && ui.Detect(errWriter()).Interactive {
+		flow.SetHunkPicker(hunks.Picker{Out: errWriter()})
+	}
+	// --issue names a forge issue; Jira only handles the ticket of the branch.
+	if cfg.JiraURL != "" && issueNum == "" {
+		client := jira.New(jira.Options{URL: cfg.JiraURL, Email: cfg.JiraEmail, Token: cfg.ResolveJiraToken()})
+		if cfg.IssueContext {
+			flow.SetIssueFetcher(client)
+		}
+		flow.SetIssueUpdater(client)
+	} else if cfg.IssueContext && issueNum != "" {
+		fetcher, err := newIssueFetcher(repo, cfg)
+		if err != nil {
+			fmt.Fprintf(errWriter(), "Warning: issue context unavailable: %v\n", err)
+		} else {
+			flow.SetIssueFetcher(fetcher)
+		}
+	}
 
 	return flow.Run(fileArgs)
 }
 
+func newIssueFetcher(gitRepo *git.Repo, cfg *config.Config) (workflow.IssueFetcher, error) {
+	remoteURL, err := gitRepo.GetRemoteURL("origin")
+	if err != nil {
+		return nil, err
+	}
+	repo, kind, err := forge.Resolve(remoteURL, cfg.Forge)
+	if err != nil {
+		return nil, err
+	}
+	return forge.NewIssueClientFor(repo, kind, forge.Options{Token: cfg.ResolveForgeToken(kind)})
+}
+
+// applyFlagOverrides lets --lang and --body override their config keys for this run.
+func applyFlagOverrides(cfg *config.Config) error {
+	if bodyFlag {
+		cfg.CommitBody = true
+	}
+	if gpgSign != "" {
+		cfg.SignCommits = true
+	}
+	if langFlag == "" {
+		return nil
+	}
+	if !config.IsValidLanguage(langFlag) {
+		return fmt.Errorf("invalid --lang value: %s (expected a language tag such as zh-CN, ja or de)", langFlag)
+	}
+	cfg.Language = langFlag
+	return nil
+}
+
+// typeFlagPattern and scopeFlagPattern are the --type and --scope values a
+// "type(scope): " prefix can hold.
+var (
+	typeFlagPattern  = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	scopeFlagPattern = regexp.MustCompile(`^[\w.,/-]+$`)
+)
+
+// checkTypeAndScopeFlags returns the --type and --scope values trimmed, with the
+// type in lower case. It rejects values that do not fit the subject prefix, or a
+// type outside commit_types.
+func checkTypeAndScopeFlags(cfg *config.Config, typ, scope string) (string, string, error) {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	scope = strings.TrimSpace(scope)
+	if typ != "" {
+		if !typeFlagPattern.MatchString(typ) {
+			return "", "", fmt.Errorf("invalid --type value: %s (expected a word such as fix or feat)", typ)
+		}
+		if !cfg.AllowsCommitType(typ) {
+			return "", "", fmt.Errorf("--type %s is not in commit_types: %s", typ,
+				strings.Join(cfg.AllowedCommitTypes(), ", "))
+		}
+	}
+	if scope != "" && !scopeFlagPattern.MatchString(scope) {
+		return "", "", fmt.Errorf("invalid --scope value: %s (expected a word such as auth, without spaces, "+
+			"parentheses or colons)", scope)
+	}
+	return typ, scope, nil
+}
+
+// parseTrailerFlags parses the --trailer values.
+func parseTrailerFlags(values []string) ([]config.Trailer, error) {
+	trailers := make([]config.Trailer, 0, len(values))
+	for _, value := range values {
+		trailer, err := config.ParseTrailer(value)
+		if err != nil {
+			return nil, err
+		}
+		trailers = append(trailers, trailer)
+	}
+	return trailers, nil
+}
+
+// gpgSignDefaultKey is the --gpg-sign value without =<keyid>, which signs with git's
+// default key.
+const gpgSignDefaultKey = "default"
+
+// signKey returns the key passed as --gpg-sign=<keyid>, or "" for git's default key.
+func signKey() string {
+	if gpgSign == gpgSignDefaultKey {
+		return ""
+	}
+	return gpgSign
+}
+
 func handleStdinDiff(in io.Reader, llmClient *llm.Client) error {
 	if f, ok := in.(*os.File); ok {
 		if isatty.IsTerminal(f.Fd()) {
@@ -208,29 +630,57 @@ func handleStdinDiff(in io.Reader, llmClient *llm.Client) error {
 		return fmt.Errorf("failed to read stdin: %w", err)
 	}
 
-	diff := strings.TrimSpace(string(data))
-	if diff == "" {
+	if strings.TrimSpace(string(data)) == "" {
 		return errors.New("empty diff received from stdin")
 	}
 
+	payload := formatter.ParseStdinPayload(string(data))
+	diff := payload.PromptDiff()
+	if diff == "" {
+		return errors.New("stdin has git status lines but no diff or file content")
+	}
+
 	if debug {
-		fmt.Fprintf(errWriter(), "[debug] Read %d bytes from stdin\n", len(diff))
+		fmt.Fprintf(errWriter(), "[debug] Read %d bytes from stdin\n", len(data))
+		if payload.Combined() {
+			fmt.Fprintf(errWriter(), "[debug] Combined payload: %d status entries, %d file snippets\n",
+				len(payload.Status), len(payload.Snippets))
+		}
 	}
 
-	changedFiles := workflow.ExtractFilesFromDiff(diff)
+	changedFiles := payload.ChangedFiles()
 
-	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	cfg, offlineRun, err := configForGeneration(in)
 	if err != nil {
 		return err
 	}
-	if !proceed {
-		return nil
+	if err := applyFlagOverrides(cfg); err != nil {
+		return err
 	}
-
-	message, err := generateStdinMessage(llmClient, cfg, changedFiles, diff)
+	commitType, scope, err := checkTypeAndScopeFlags(cfg, typeFlag, scopeFlag)
 	if err != nil {
 		return err
 	}
+	if strictContext {
+		if err := formatter.CheckPromptContext(cfg, diff); err != nil {
+			return err
+		}
+	}
+
+	var message string
+	if !offlineRun {
+		message, err = generateStdinMessage(llmClient, cfg, changedFiles, diff, commitType, scope)
+		if llm.IsUnreachable(err) {
+			fmt.Fprintln(errWriter(), "Warning: the LLM is unreachable, so the message was written offline from the diff.")
+			offlineRun = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if offlineRun {
+		message = formatter.FormatCommitMessageWithConfig(cfg, formatter.HeuristicMessage(cfg, changedFiles, diff, scope))
+		message = formatter.ConstrainMessage(message, commitType, scope)
+	}
 
 	fmt.Fprintln(errWriter(), "\n[stdin mode: message only, no commit]")
 	fmt.Fprintln(outWriter(), message)
@@ -238,9 +688,39 @@ func handleStdinDiff(in io.Reader, llmClient *llm.Client) error {
 }
 
 func generateStdinMessage(
-	llmClient *llm.Client, cfg *config.Config, changedFiles []string, diff string,
+	llmClient *llm.Client, cfg *config.Config, changedFiles []string, diff, commitType, scope string,
+) (string, error) {
+	return generateMessage(llmClient, cfg, changedFiles, diff, messageOptions{
+		Prompt: userPrompt, Issue: issueNum, Type: commitType, Scope: scope,
+	})
+}
+
+// messageOptions are the inputs of generateMessage that the root command takes from
+// --prompt, --issue, --type and --scope.
+type messageOptions struct {
+	Prompt string
+	Issue  string
+	Type   string
+	Scope  string
+}
+
+// generateMessage generates a message for diff without a commit flow: no confirmation,
+// breaking-change or risk checks.
+func generateMessage(
+	llmClient *llm.Client, cfg *config.Config, changedFiles []string, diff string, opts messageOptions,
 ) (string, error) {
-	prompt := formatter.BuildPromptWithConfig(cfg, changedFiles, diff, userPrompt)
+	typeHint := ""
+	if opts.Type == "" {
+		typeHint = formatter.TypeHintForConfig(cfg, changedFiles)
+	}
+	prompt := formatter.BuildPromptWithContext(cfg, changedFiles, diff, formatter.PromptContext{
+		UserPrompt: opts.Prompt,
+		TypeHint:   typeHint,
+		ScopeHint:  formatter.ScopeHintForConfig(cfg, changedFiles),
+		Type:       opts.Type,
+		Scope:      opts.Scope,
+		Warnings:   errWriter(),
+	})
 
 	sp := ui.NewSpinner("Generating commit message...")
 	sp.Start()
@@ -248,21 +728,108 @@ func generateStdinMessage(
 	sp.Stop()
 
 	if err != nil {
-		return "", fmt.Errorf("failed to generate commit message: %w", err)
+		var partial *llm.PartialResponseError
+		if !errors.As(err, &partial) || formatter.SalvageSubject(partial.Content) == "" {
+			return "", fmt.Errorf("failed to generate commit message: %w", err)
+		}
+		fmt.Fprintf(errWriter(), "Warning: %v\n", partial.Err)
+		fmt.Fprintln(errWriter(), "Warning: using the partial subject line received before the response stalled")
+		message = formatter.SalvageSubject(partial.Content)
 	}
 
 	formattedMessage := formatter.FormatCommitMessageWithConfig(cfg, message)
-	if issueNum != "" {
-		formattedMessage = fmt.Sprintf("%s (#%s)", formattedMessage, issueNum)
+	formattedMessage = formatter.EnforceTypeHint(cfg, formattedMessage, typeHint)
+	constrained := formatter.ConstrainMessage(formattedMessage, opts.Type, opts.Scope)
+	if constrained != formattedMessage {
+		fmt.Fprintln(errWriter(), "Warning: the reply ignored the required type or scope; corrected it")
+		formattedMessage = constrained
+	}
+	if formatter.CheckCommitType(cfg, formattedMessage) != nil {
+		rejected := formatter.CommitTypeOf(formattedMessage)
+		fmt.Fprintf(errWriter(), "Type %q is not in commit_types, asking again...\n", rejected)
+		message, err = llmClient.GenerateCommitMessage(formatter.CommitTypeRetryPrompt(cfg, prompt, rejected), cfg.Model)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate commit message: %w", err)
+		}
+		formattedMessage = formatter.FormatCommitMessageWithConfig(cfg, message)
+		formattedMessage = formatter.EnforceTypeHint(cfg, formattedMessage, typeHint)
+		formattedMessage = formatter.ConstrainMessage(formattedMessage, opts.Type, opts.Scope)
+		if err := formatter.CheckCommitType(cfg, formattedMessage); err != nil {
+			return "", err
+		}
+	}
+	if opts.Issue != "" {
+		subject, body := formatter.SplitCommitMessage(formattedMessage)
+		formattedMessage = formatter.JoinCommitMessage(fmt.Sprintf("%s (#%s)", subject, opts.Issue), body)
 	}
 
 	return formattedMessage, nil
 }
 
-func completeOutputFormat(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+func completeOutputFormat(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if supportsCSVOutput(cmd) {
+		return []string{"text", "json", "csv"}, cobra.ShellCompDirectiveNoFileComp
+	}
 	return []string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp
 }
 
+// configForGeneration returns the config for a run that generates a message and whether
+// the message is written offline: with --offline, or when no API key is set and gmc init
+// is skipped. An unattended run (--yes) without an API key still fails, so that a
+// heuristic message is never committed unreviewed.
+func configForGeneration(in io.Reader) (*config.Config, bool, error) {
+	// A message file needs no LLM, so no API key either.
+	if offline || messageFile != "" {
+		cfg, err := config.GetConfig()
+		return cfg, true, err
+	}
+	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	if err != nil {
+		return nil, false, err
+	}
+	if proceed {
+		return cfg, false, nil
+	}
+	if autoYes || len(compareModels) > 0 {
+		return nil, false, errAPIKeyMissing
+	}
+	fmt.Fprintln(errWriter(), "Writing the message offline from the diff; run `gmc init` to use the LLM.")
+	cfg, err = config.GetConfig()
+	return cfg, true, err
+}
+
+// modelList returns the --models values without blanks and repeats.
+func modelList(values []string) []string {
+	var models []string
+	for _, value := range values {
+		if model := strings.TrimSpace(value); model != "" && !slices.Contains(models, model) {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// checkGenerationFlags rejects --out, --use-message-file, --models, --type and --scope
+// where they do not apply.
+func checkGenerationFlags() error {
+	if messageFile != "" && (typeFlag != "" || scopeFlag != "") {
+		return errors.New("--type and --scope steer the generated message; they cannot be combined with --use-message-file")
+	}
+	if perPackage && scopeFlag != "" {
+		return errors.New("--scope cannot be combined with --per-package, which scopes each package's commit")
+	}
+	if outFile != "" && !dryRun {
+		return errors.New("--out needs --dry-run; without it, gmc commits the message")
+	}
+	if perPackage && (outFile != "" || messageFile != "") {
+		return errors.New("--out and --use-message-file cannot be combined with --per-package")
+	}
+	if len(compareModels) > 0 && (offline || messageFile != "") {
+		return errors.New("--models needs the LLM; it cannot be combined with --offline or --use-message-file")
+	}
+	return nil
+}
+
 func ensureConfiguredAndGetConfig(
 	cfg *config.Config,
 	in io.Reader,
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/spf13/cobra"
+)
+
+var errEmptyDiff = errors.New("diff is empty")
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve gmc to editors and agents",
+	Long: `Run gmc as a long-lived server so editors, IDE agents and scripts can call it
+without starting a new process for each request.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
+
+// generateForDiff generates a commit message for diff, which can be a combined stdin
+// payload as gmc - reads it.
+func generateForDiff(diff, prompt string) (string, error) {
+	if strings.TrimSpace(diff) == "" {
+		return "", errEmptyDiff
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(cfg.APIKey) == "" {
+		return "", errAPIKeyMissing
+	}
+
+	payload := formatter.ParseStdinPayload(diff)
+	promptDiff := payload.PromptDiff()
+	if promptDiff == "" {
+		return "", fmt.Errorf("%w: it has git status lines but no diff or file content", errEmptyDiff)
+	}
+	return generateMessage(newLLMClient(), cfg, payload.ChangedFiles(), promptDiff, messageOptions{Prompt: prompt})
+}
+
+// decodeStrictJSON unmarshals data into v, rejecting unknown fields.
+func decodeStrictJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
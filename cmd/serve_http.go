@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/samzong/gmc/internal/analyzer"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/httpapi"
+	"github.com/spf13/cobra"
+)
+
+const serveTokenEnv = "GMC_SERVE_TOKEN"
+
+var (
+	serveListen string
+	serveToken  string
+)
+
+var serveHTTPCmd = &cobra.Command{
+	Use:   "http",
+	Short: "Serve gmc endpoints over a local HTTP API",
+	Long: `Serve gmc over HTTP, so editor plugins and scripts reuse one process and its loaded
+configuration instead of starting gmc for every call:
+
+  POST /generate         {"diff": "...", "prompt": "..."} -> {"message": "..."}
+  POST /lint             {"message": "..."} -> {"score": 85, "type": "feat", "issues": [...]}
+  GET  /version-suggest  -> {"current": "v1.2.3", "suggested": "v1.3.0", ...}
+  GET  /healthz          -> {"status": "ok"}, without a token
+
+Every endpoint but /healthz requires "Authorization: Bearer <token>". The token is
+--token, or $GMC_SERVE_TOKEN; without either, gmc generates one and prints it to stderr.
+The repository endpoints work on the repository of the directory the server starts in.`,
+	Example: `  gmc serve http
+  GMC_SERVE_TOKEN=secret gmc serve http --listen 127.0.0.1:9000
+
+  git diff --cached | jq -Rs '{diff: .}' | \
+    curl -s -H "Authorization: Bearer secret" -d @- http://127.0.0.1:9000/generate`,
+	Args:              cobra.NoArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if configErr != nil {
+			return fmt.Errorf("configuration error: %w", configErr)
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return runServeHTTP(ctx)
+	},
+}
+
+func init() {
+	serveHTTPCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8765", "Address to listen on")
+	serveHTTPCmd.Flags().StringVar(&serveToken, "token", "",
+		"Bearer token clients must send (default $"+serveTokenEnv+", or a generated one)")
+	serveCmd.AddCommand(serveHTTPCmd)
+}
+
+func runServeHTTP(ctx context.Context) error {
+	token := serveToken
+	if token == "" {
+		token = os.Getenv(serveTokenEnv)
+	}
+	if token == "" {
+		generated, err := httpapi.NewToken()
+		if err != nil {
+			return err
+		}
+		token = generated
+		fmt.Fprintf(errWriter(), "Bearer token: %s\n", token)
+	}
+
+	listener, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveListen, err)
+	}
+	server := &http.Server{
+		Handler:           httpapi.NewHandler(token, httpEndpoints()...),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(errWriter(), "Serving gmc on http://%s\n", listener.Addr())
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// LintJSON is the /lint response: the gmc stats score of a commit subject.
+type LintJSON struct {
+	Score  int      `json:"score"`
+	Type   string   `json:"type,omitempty"`
+	Issues []string `json:"issues,omitempty"`
+}
+
+func httpEndpoints() []httpapi.Endpoint {
+	return []httpapi.Endpoint{
+		{Method: http.MethodPost, Path: "/generate", Handler: httpGenerate},
+		{Method: http.MethodPost, Path: "/lint", Handler: httpLint},
+		{
+			Method: http.MethodGet,
+			Path:   "/version-suggest",
+			Handler: func(context.Context, json.RawMessage) (any, error) {
+				return suggestNextVersion(newLLMClient())
+			},
+		},
+	}
+}
+
+func httpGenerate(_ context.Context, body json.RawMessage) (any, error) {
+	var req struct {
+		Diff   string `json:"diff"`
+		Prompt string `json:"prompt"`
+	}
+	if err := decodeStrictJSON(body, &req); err != nil {
+		return nil, httpapi.BadRequest(err)
+	}
+	message, err := generateForDiff(req.Diff, req.Prompt)
+	if errors.Is(err, errEmptyDiff) {
+		return nil, httpapi.BadRequest(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"message": message}, nil
+}
+
+func httpLint(_ context.Context, body json.RawMessage) (any, error) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := decodeStrictJSON(body, &req); err != nil {
+		return nil, httpapi.BadRequest(err)
+	}
+	subject, _, _ := strings.Cut(strings.TrimSpace(req.Message), "\n")
+	if subject == "" {
+		return nil, httpapi.BadRequest(errors.New("message is empty"))
+	}
+	score := analyzer.ScoreCommit(git.CommitInfo{Message: subject})
+	return LintJSON{Score: score.Score, Type: score.Type, Issues: score.Issues}, nil
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPLintScoresSubject(t *testing.T) {
+	result, err := httpLint(context.Background(), json.RawMessage(`{"message": "feat(api): add user search\n\nBody."}`))
+	require.NoError(t, err)
+	assert.Equal(t, LintJSON{Score: 100, Type: "feat"}, result)
+
+	result, err = httpLint(context.Background(), json.RawMessage(`{"message": "update"}`))
+	require.NoError(t, err)
+	assert.Equal(t, LintJSON{
+		Score:  35,
+		Issues: []string{"not a Conventional Commit", "vague description"},
+	}, result)
+
+	_, err = httpLint(context.Background(), json.RawMessage(`{"message": " "}`))
+	assert.EqualError(t, err, "message is empty")
+	_, err = httpGenerate(context.Background(), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "diff is empty")
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/samzong/gmc/internal/analyzer"
+	"github.com/samzong/gmc/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+var serveMCPCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve gmc tools over MCP on stdio",
+	Long: `Serve gmc as a Model Context Protocol (MCP) server on stdin and stdout, so IDE
+agents such as Claude Desktop or Cursor can call it as tools:
+
+  generate_commit_message  Generate a commit message from a diff
+  analyze_commit_quality   Score recent commit messages, as gmc stats does
+  suggest_version          Suggest the next semantic version, as gmc tag does
+  list_worktrees           List the worktrees of the repository, as gmc wt list does
+
+The repository tools work on the repository of the directory the server starts in.
+Messages go to stdout; warnings and logs go to stderr.`,
+	Example: `  gmc serve mcp
+
+  # Claude Desktop, claude_desktop_config.json
+  {"mcpServers": {"gmc": {"command": "gmc", "args": ["serve", "mcp"]}}}`,
+	Args:              cobra.NoArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if configErr != nil {
+			return fmt.Errorf("configuration error: %w", configErr)
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return mcp.NewServer("gmc", Version, mcpTools()...).Serve(ctx, cmd.InOrStdin(), outWriter())
+	},
+}
+
+func init() {
+	serveCmd.AddCommand(serveMCPCmd)
+}
+
+func mcpTools() []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name: "generate_commit_message",
+			Description: "Generate a Conventional Commits message for a unified diff with the configured LLM. " +
+				"The diff can be a stdin payload of gmc -: git status --porcelain, the diff and file snippets.",
+			InputSchema: mcp.ObjectSchema(map[string]mcp.Property{
+				"diff":   {Type: "string", Description: "Unified diff, such as the output of git diff --cached"},
+				"prompt": {Type: "string", Description: "Extra instruction for the LLM"},
+			}, "diff"),
+			Handler: mcpGenerateCommitMessage,
+		},
+		{
+			Name:        "analyze_commit_quality",
+			Description: "Score recent commit messages against Conventional Commits and report types and issues.",
+			InputSchema: mcp.ObjectSchema(map[string]mcp.Property{
+				"limit": {Type: "integer", Description: "Number of recent commits to analyze (default 100)"},
+				"team":  {Type: "boolean", Description: "Analyze commits from all authors, not just the user's"},
+			}),
+			Handler: mcpAnalyzeCommitQuality,
+		},
+		{
+			Name:        "suggest_version",
+			Description: "Suggest the next semantic version from the commits since the latest tag, without tagging.",
+			InputSchema: mcp.ObjectSchema(nil),
+			Handler: func(context.Context, json.RawMessage) (string, error) {
+				suggestion, err := suggestNextVersion(newLLMClient())
+				if err != nil {
+					return "", err
+				}
+				return toolJSON(suggestion)
+			},
+		},
+		{
+			Name:        "list_worktrees",
+			Description: "List the worktrees of the repository with their branch, status and changes.",
+			InputSchema: mcp.ObjectSchema(nil),
+			Handler: func(context.Context, json.RawMessage) (string, error) {
+				wtClient := newWorktreeClient()
+				worktrees, err := wtClient.List()
+				if err != nil {
+					return "", err
+				}
+				filtered := filterBareWorktrees(worktrees)
+				diffStats, err := loadWorktreeDiffStats(wtClient, filtered)
+				if err != nil {
+					return "", err
+				}
+				return toolJSON(buildWorktreeJSON(wtClient, filtered, nil, diffStats))
+			},
+		},
+	}
+}
+
+func mcpGenerateCommitMessage(_ context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		Diff   string `json:"diff"`
+		Prompt string `json:"prompt"`
+	}
+	if err := decodeStrictJSON(args, &params); err != nil {
+		return "", err
+	}
+	return generateForDiff(params.Diff, params.Prompt)
+}
+
+func mcpAnalyzeCommitQuality(_ context.Context, args json.RawMessage) (string, error) {
+	params := struct {
+		Limit int  `json:"limit"`
+		Team  bool `json:"team"`
+	}{Limit: 100}
+	if err := decodeStrictJSON(args, &params); err != nil {
+		return "", err
+	}
+	if params.Limit <= 0 {
+		return "", errors.New("limit must be a positive number")
+	}
+
+	commits, scores, err := scoreCommitHistory(params.Limit, params.Team)
+	if err != nil {
+		return "", err
+	}
+	return toolJSON(analyzer.BuildReport(commits, scores))
+}
+
+func toolJSON(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
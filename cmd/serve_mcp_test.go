@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPToolsHaveObjectSchemas(t *testing.T) {
+	names := []string{}
+	for _, tool := range mcpTools() {
+		names = append(names, tool.Name)
+		assert.Equal(t, "object", tool.InputSchema.Type, tool.Name)
+		assert.NotEmpty(t, tool.Description, tool.Name)
+		require.NotNil(t, tool.Handler, tool.Name)
+	}
+	assert.Equal(t, []string{
+		"generate_commit_message", "analyze_commit_quality", "suggest_version", "list_worktrees",
+	}, names)
+}
+
+func TestDecodeStrictJSONRejectsUnknownFields(t *testing.T) {
+	var params struct {
+		Limit int `json:"limit"`
+	}
+	require.NoError(t, decodeStrictJSON(json.RawMessage(`{"limit": 5}`), &params))
+	assert.Equal(t, 5, params.Limit)
+
+	err := decodeStrictJSON(json.RawMessage(`{"limt": 5}`), &params)
+	assert.ErrorContains(t, err, `invalid arguments: json: unknown field "limt"`)
+}
+
+func TestMCPToolsValidateArguments(t *testing.T) {
+	_, err := mcpGenerateCommitMessage(context.Background(), json.RawMessage(`{"diff": "  "}`))
+	assert.EqualError(t, err, "diff is empty")
+
+	_, err = mcpAnalyzeCommitQuality(context.Background(), json.RawMessage(`{"limit": 0}`))
+	assert.EqualError(t, err, "limit must be a positive number")
+}
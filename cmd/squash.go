@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/history"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var (
+	squashSince   string
+	squashAutoYes bool
+
+	squashCmd = &cobra.Command{
+		Use:   "squash [N]",
+		Short: "Squash commits under a regenerated message",
+		Long: `Squash the last N commits, or the commits since --since, into one commit with a
+message generated for their combined changes.
+
+gmc runs git reset --soft to the commit before them, so their changes are staged
+together, and generates the message with the messages of the squashed commits as
+context. The message is confirmed, edited or regenerated as with gmc, or accepted
+with --yes. When no commit is made, for example because you cancel, gmc resets
+back and the commits are left as they were.
+
+gmc refuses to squash commits a remote-tracking branch already contains, a range
+with a merge commit, or the first commit of the repository. Nothing may be staged
+when the squash starts, so that only the commits go into it.`,
+		Example: `  gmc squash 3
+  gmc squash --since origin/main
+  gmc squash 5 --yes`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSquash(cmd.InOrStdin(), args)
+		},
+	}
+)
+
+func init() {
+	squashCmd.Flags().StringVar(&squashSince, "since", "", "Squash the commits after this `ref`, such as origin/main")
+	squashCmd.Flags().BoolVarP(&squashAutoYes, "yes", "y", false, "Accept the generated message without asking")
+	_ = squashCmd.RegisterFlagCompletionFunc("since", completeBranchNames)
+	rootCmd.AddCommand(squashCmd)
+}
+
+func runSquash(in io.Reader, args []string) error {
+	base, err := squashBaseRev(args)
+	if err != nil {
+		return err
+	}
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return err
+	}
+	baseHash, err := repo.ResolveCommit(base)
+	if err != nil {
+		if len(args) == 1 {
+			return fmt.Errorf("cannot squash %s commits: the branch does not have a commit before them; "+
+				"gmc squash cannot include the first commit", args[0])
+		}
+		return err
+	}
+	commits, err := squashableCommits(repo, baseHash)
+	if err != nil {
+		return err
+	}
+	staged, _, err := repo.StagedPaths()
+	if err != nil {
+		return fmt.Errorf("failed to list staged files: %w", err)
+	}
+	if len(staged) > 0 {
+		return errors.New("there are staged changes; commit or unstage them first, so they are not squashed in")
+	}
+
+	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		return errAPIKeyMissing
+	}
+
+	fmt.Fprintf(errWriter(), "Squashing %d commits onto %s:\n", len(commits), stringsutil.ShortHash(baseHash, 7, ""))
+	for _, commit := range commits {
+		fmt.Fprintf(errWriter(), "  %s %s\n", stringsutil.ShortHash(commit.Hash, 7, ""), commit.Subject())
+	}
+	return squashOnto(repo, newLLMClient(), cfg, in, baseHash, commits, squashAutoYes)
+}
+
+// squashBaseRev returns the revision the squashed commits sit on: HEAD~N for the N
+// argument, or --since.
+func squashBaseRev(args []string) (string, error) {
+	if len(args) == 1 && squashSince != "" {
+		return "", errors.New("give either N or --since, not both")
+	}
+	if squashSince != "" {
+		return squashSince, nil
+	}
+	if len(args) == 0 {
+		return "", errors.New("give the number of commits to squash, such as gmc squash 3, or --since <ref>")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 2 {
+		return "", fmt.Errorf("invalid number of commits: %s (expected 2 or more)", args[0])
+	}
+	return fmt.Sprintf("HEAD~%d", n), nil
+}
+
+// squashableCommits returns the commits from base to HEAD, oldest first, after
+// checking that they form a linear, unpushed history on top of base.
+func squashableCommits(repo *git.Repo, base string) ([]git.RangeCommit, error) {
+	commits, err := repo.GetRangeCommits(base + "..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) < 2 {
+		return nil, fmt.Errorf("nothing to squash: there are %d commit(s) after %s",
+			len(commits), stringsutil.ShortHash(base, 7, ""))
+	}
+	parent := base
+	for _, commit := range commits {
+		if len(commit.Parents) > 1 {
+			return nil, fmt.Errorf("%s is a merge commit; gmc squash only squashes a linear history",
+				stringsutil.ShortHash(commit.Hash, 7, ""))
+		}
+		if len(commit.Parents) == 0 || commit.Parents[0] != parent {
+			return nil, fmt.Errorf("HEAD does not descend from %s in a straight line; "+
+				"gmc squash only squashes a linear history", stringsutil.ShortHash(base, 7, ""))
+		}
+		parent = commit.Hash
+	}
+	pushed, err := repo.PushedCommits(base + "..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if len(pushed) > 0 {
+		return nil, fmt.Errorf("%s is already on a remote branch; squashing would rewrite published history",
+			stringsutil.ShortHash(pushed[0], 7, ""))
+	}
+	return commits, nil
+}
+
+// squashOnto soft-resets to base and commits what is then staged as one commit, with
+// the messages of commits as context for the generated message. When no commit is
+// made, it resets back to the last of commits.
+func squashOnto(
+	repo *git.Repo, llmClient *llm.Client, cfg *config.Config, in io.Reader,
+	base string, commits []git.RangeCommit, autoYes bool,
+) error {
+	head := commits[len(commits)-1].Hash
+	messages := make([]string, len(commits))
+	for i, commit := range commits {
+		messages[i] = commit.Message
+	}
+
+	if err := repo.ResetHead(base, false); err != nil {
+		return err
+	}
+	err := runCommitFlow(repo, llmClient, cfg, in, workflow.CommitOptions{
+		AutoYes:  autoYes,
+		Squashed: messages,
+		JSON:     outputFormat() == "json",
+	})
+	if err == nil {
+		return nil
+	}
+	if resetErr := repo.ResetHead(head, false); resetErr != nil {
+		return fmt.Errorf("%w; restoring the commits also failed: %w (run git reset --soft %s)",
+			err, resetErr, stringsutil.ShortHash(head, 7, ""))
+	}
+	fmt.Fprintf(errWriter(), "No commit was made; the %d commits are back as they were.\n", len(commits))
+	return err
+}
+
+// runCommitFlow commits the staged changes through the commit flow of the root
+// command, with opts on top of what every run sets.
+func runCommitFlow(
+	repo *git.Repo, llmClient *llm.Client, cfg *config.Config, in io.Reader, opts workflow.CommitOptions,
+) error {
+	opts.Version = Version
+	opts.ErrWriter = errWriter()
+	opts.OutWriter = outWriter()
+	flow := workflow.NewCommitFlow(repo, llmClient, cfg, opts)
+	if path, err := history.FilePath(); err == nil {
+		flow.SetHistory(history.Recorder{Path: path, Repo: repo.Root()})
+	}
+	flow.SetPrompter(&workflow.InteractivePrompter{ErrWriter: errWriter(), Stdin: in, Cfg: cfg})
+	return flow.Run(nil)
+}
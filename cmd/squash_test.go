@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquashBaseRev(t *testing.T) {
+	defer func(since string) { squashSince = since }(squashSince)
+
+	squashSince = ""
+	base, err := squashBaseRev([]string{"3"})
+	require.NoError(t, err)
+	assert.Equal(t, "HEAD~3", base)
+	_, err = squashBaseRev([]string{"1"})
+	assert.EqualError(t, err, "invalid number of commits: 1 (expected 2 or more)")
+	_, err = squashBaseRev(nil)
+	assert.ErrorContains(t, err, "give the number of commits to squash")
+
+	squashSince = "origin/main"
+	base, err = squashBaseRev(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "origin/main", base)
+	_, err = squashBaseRev([]string{"3"})
+	assert.EqualError(t, err, "give either N or --since, not both")
+}
+
+func TestSquashableCommits(t *testing.T) {
+	repoDir := initCmdTestRepo(t)
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldCwd) }()
+	require.NoError(t, os.Chdir(repoDir))
+
+	commit := func(name string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(name), 0o644))
+		runGitCmd(t, repoDir, "add", name)
+		runGitCmd(t, repoDir, "commit", "-q", "-m", "feat: add "+name)
+	}
+	base := strings.TrimSpace(runGitCmd(t, repoDir, "rev-parse", "HEAD"))
+	commit("a.txt")
+	commit("b.txt")
+
+	repo, err := git.OpenRepo(git.Options{})
+	require.NoError(t, err)
+	commits, err := squashableCommits(repo, base)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "feat: add a.txt", commits[0].Subject())
+
+	head := strings.TrimSpace(runGitCmd(t, repoDir, "rev-parse", "HEAD"))
+	_, err = squashableCommits(repo, head+"~1")
+	assert.ErrorContains(t, err, "nothing to squash: there are 1 commit(s)")
+
+	runGitCmd(t, repoDir, "update-ref", "refs/remotes/origin/main", "HEAD~1")
+	_, err = squashableCommits(repo, base)
+	assert.ErrorContains(t, err, "already on a remote branch")
+	runGitCmd(t, repoDir, "update-ref", "-d", "refs/remotes/origin/main")
+
+	runGitCmd(t, repoDir, "checkout", "-q", "-b", "side", base)
+	commit("c.txt")
+	runGitCmd(t, repoDir, "checkout", "-q", "main")
+	runGitCmd(t, repoDir, "merge", "-q", "--no-edit", "side")
+	_, err = squashableCommits(repo, base)
+	assert.ErrorContains(t, err, "gmc squash only squashes a linear history")
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/analyzer"
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsLimit     int
+	statsTeam      bool
+	statsSuggest   bool
+	statsTrend     bool
+	statsPeriod    string
+	statsUsage     bool
+	statsMinScore  int
+	statsByEmail   bool
+	statsScopes    bool
+	statsSaveScope bool
+
+	statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Analyze commit message quality",
+		Long: `Score recent commit messages against Conventional Commits and show type
+distribution, common issues, and author stats as ASCII charts.
+
+Only your own commits are analyzed unless --team is set. Scores are cached by
+commit hash under .git/gmc/cache, so repeated runs only score new commits.
+
+Authors are grouped by name after .mailmap is applied, or by email with
+--by-email. The author_aliases config maps other names and emails of a person
+to the one to report.
+
+With --trend, the quality score is shown per week or month instead, so you can
+see whether commit hygiene improves over time.
+
+With --scopes, the scopes of the commits are shown instead, with the scopes that
+look like variants of one another, such as work-tree and worktree, and the
+one to use for each. --save-scopes writes the suggested scopes to scopes in the
+repository's .gmc.yaml, which the prompt lists so that new commits reuse them.
+
+With --usage, the LLM token usage and estimated cost that gmc has accumulated
+across invocations are shown instead, per model.
+
+For scheduled CI jobs, --min-score fails the command with exit code 20 when the
+average score of the analyzed commits is below the threshold, after printing the
+report. --output json includes the per-author stats, the type distribution and
+every commit scoring below 60 with its hash; --output csv prints one row per
+commit, or per period with --trend.`,
+		Example: `  gmc stats                 # Your last 100 commits
+  gmc stats --team --limit 1000
+  gmc stats --suggest       # Ask the LLM for improvement suggestions
+  gmc stats --team --trend --period month
+  gmc stats --team --scopes --limit 1000
+  gmc stats --team --scopes --save-scopes
+  gmc stats --usage         # Tokens and estimated cost so far
+  gmc stats -o json
+  gmc stats --team --output csv > quality.csv
+  gmc stats --team --min-score 70 -o json   # Fail CI below an average of 70`,
+		Annotations:       map[string]string{csvOutputAnnotation: "true"},
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runStatsCommand()
+		},
+	}
+)
+
+func init() {
+	statsCmd.Flags().IntVarP(&statsLimit, "limit", "n", 100, "Number of recent commits to analyze")
+	statsCmd.Flags().BoolVar(&statsTeam, "team", false, "Analyze commits from all authors, not just yours")
+	statsCmd.Flags().BoolVar(&statsByEmail, "by-email", false, "Group the author stats by email instead of name")
+	statsCmd.Flags().BoolVar(&statsSuggest, "suggest", false, "Ask the LLM for suggestions to improve commit messages")
+	statsCmd.Flags().BoolVar(&statsTrend, "trend", false, "Show the quality score over time instead of the report")
+	statsCmd.Flags().StringVar(&statsPeriod, "period", string(analyzer.TrendWeek), "Trend bucket size: week or month")
+	_ = statsCmd.RegisterFlagCompletionFunc("period", cobra.FixedCompletions(
+		[]string{string(analyzer.TrendWeek), string(analyzer.TrendMonth)}, cobra.ShellCompDirectiveNoFileComp))
+	statsCmd.Flags().BoolVar(&statsScopes, "scopes", false,
+		"Show scope usage and inconsistent scopes instead of the report")
+	statsCmd.Flags().BoolVar(&statsSaveScope, "save-scopes", false,
+		"With --scopes, write the suggested scopes to scopes in the repository's .gmc.yaml")
+	statsCmd.Flags().BoolVar(&statsUsage, "usage", false, "Show accumulated LLM token usage and estimated cost")
+	statsCmd.Flags().IntVar(&statsMinScore, "min-score", 0,
+		"Exit with code 20 when the average score is below this (0-100), for CI")
+	statsCmd.MarkFlagsMutuallyExclusive("trend", "suggest")
+	statsCmd.MarkFlagsMutuallyExclusive("usage", "trend")
+	statsCmd.MarkFlagsMutuallyExclusive("usage", "suggest")
+	statsCmd.MarkFlagsMutuallyExclusive("min-score", "trend")
+	statsCmd.MarkFlagsMutuallyExclusive("min-score", "usage")
+	statsCmd.MarkFlagsMutuallyExclusive("scopes", "trend")
+	statsCmd.MarkFlagsMutuallyExclusive("scopes", "usage")
+	statsCmd.MarkFlagsMutuallyExclusive("scopes", "suggest")
+	statsCmd.MarkFlagsMutuallyExclusive("scopes", "min-score")
+	rootCmd.AddCommand(statsCmd)
+}
+
+type StatsJSON struct {
+	analyzer.Report
+	Suggestions string `json:"suggestions,omitempty"`
+	// MinScore is --min-score, and BelowMinScore whether the average score is below it.
+	MinScore      int  `json:"min_score,omitempty"`
+	BelowMinScore bool `json:"below_min_score,omitempty"`
+}
+
+func runStatsCommand() error {
+	if statsUsage {
+		return runUsageStats()
+	}
+	if statsLimit <= 0 {
+		return errors.New("--limit must be a positive number")
+	}
+	if statsMinScore < 0 || statsMinScore > 100 {
+		return fmt.Errorf("invalid --min-score %d: must be between 0 and 100", statsMinScore)
+	}
+	if !analyzer.IsValidTrendPeriod(statsPeriod) {
+		return fmt.Errorf("invalid --period %q: must be week or month", statsPeriod)
+	}
+	if statsSaveScope && !statsScopes {
+		return errors.New("--save-scopes requires --scopes")
+	}
+	if statsScopes {
+		return runScopeStats()
+	}
+
+	commits, scores, err := scoreCommitHistory(statsLimit, statsTeam)
+	if err != nil {
+		return err
+	}
+	var aliases map[string]string
+	if cfg, err := config.GetConfig(); err == nil {
+		aliases = cfg.AuthorAliases
+	}
+	commits = analyzer.NormalizeAuthors(commits, aliases, statsByEmail)
+
+	if statsTrend {
+		trend := analyzer.BuildTrend(commits, scores, analyzer.TrendPeriod(statsPeriod))
+		switch outputFormat() {
+		case "json":
+			return printJSON(outWriter(), trend)
+		case "csv":
+			return analyzer.WriteTrendCSV(outWriter(), trend)
+		}
+		analyzer.RenderTrend(outWriter(), trend)
+		return nil
+	}
+
+	report := analyzer.BuildReport(commits, scores)
+	suggestions := ""
+	if statsSuggest && report.Total > 0 {
+		suggestions = suggestCommitImprovements(report)
+	}
+	below := statsMinScore > 0 && report.Total > 0 && report.AverageScore < float64(statsMinScore)
+
+	switch outputFormat() {
+	case "json":
+		err = printJSON(outWriter(), StatsJSON{
+			Report: report, Suggestions: suggestions, MinScore: statsMinScore, BelowMinScore: below,
+		})
+	case "csv":
+		err = analyzer.WriteCSV(outWriter(), commits, scores)
+	default:
+		analyzer.RenderReport(outWriter(), report)
+		if suggestions != "" {
+			fmt.Fprintf(outWriter(), "\nSuggestions\n%s\n", suggestions)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if below {
+		return fmt.Errorf("%w: the average score of %d commit(s) is %.1f, below %d",
+			analyzer.ErrBelowMinScore, report.Total, report.AverageScore, statsMinScore)
+	}
+	return nil
+}
+
+// runScopeStats reports the scopes of the commit history and, with --save-scopes,
+// writes the canonical ones to the repository config.
+func runScopeStats() error {
+	gitClient, err := newGitClient()
+	if err != nil {
+		return err
+	}
+	commits, err := gitClient.GetCommitHistory(statsLimit, statsTeam)
+	if err != nil {
+		return wrapGitError(fmt.Errorf("failed to read commit history: %w", err))
+	}
+	report := analyzer.BuildScopeReport(commits)
+
+	switch outputFormat() {
+	case "json":
+		err = printJSON(outWriter(), report)
+	case "csv":
+		err = analyzer.WriteScopeCSV(outWriter(), report)
+	default:
+		analyzer.RenderScopeReport(outWriter(), report)
+	}
+	if err != nil || !statsSaveScope {
+		return err
+	}
+
+	scopes := report.CanonicalScopes()
+	if len(scopes) == 0 {
+		return errors.New("no scoped commits to save scopes from")
+	}
+	root, err := gitClient.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	path := config.RepoConfigPath(root)
+	if err := config.SetRepoConfigValue(path, "scopes", scopes); err != nil {
+		return err
+	}
+	fmt.Fprintf(errWriter(), "Saved %d scope(s) to %s\n", len(scopes), path)
+	return nil
+}
+
+// scoreCommitHistory scores the last limit commits, only yours unless team is set,
+// through the quality cache.
+func scoreCommitHistory(limit int, team bool) ([]git.CommitInfo, []analyzer.QualityScore, error) {
+	gitClient, err := newGitClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	commits, err := gitClient.GetCommitHistory(limit, team)
+	if err != nil {
+		return nil, nil, wrapGitError(fmt.Errorf("failed to read commit history: %w", err))
+	}
+
+	var cache *analyzer.QualityCache
+	if gitDir, err := gitClient.GetGitCommonDir(); err == nil {
+		cache = analyzer.LoadQualityCache(gitDir)
+	}
+	scores := analyzer.ScoreCommits(commits, cache)
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(errWriter(), "Warning: %v\n", err)
+	}
+	return commits, scores, nil
+}
+
+// suggestCommitImprovements returns LLM advice for the report, or "" after warning
+// when the LLM is not configured or the request fails.
+func suggestCommitImprovements(report analyzer.Report) string {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		fmt.Fprintf(errWriter(), "Warning: %v\n", err)
+		return ""
+	}
+	if cfg.APIKey == "" {
+		fmt.Fprintln(errWriter(), "Warning: api_key is not set; skipping suggestions")
+		return ""
+	}
+
+	llmClient := newLLMClient()
+	suggestions, err := llmClient.SuggestCommitImprovements(report.Summary(), cfg.Model)
+	if err != nil {
+		fmt.Fprintf(errWriter(), "Warning: LLM suggestions failed: %v\n", err)
+		return ""
+	}
+	return suggestions
+}
+
+func runUsageStats() error {
+	path, err := llm.UsageFilePath()
+	if err != nil {
+		return err
+	}
+	totals, err := llm.LoadUsageTotals(path)
+	if err != nil {
+		return err
+	}
+	switch outputFormat() {
+	case "json":
+		return printJSON(outWriter(), UsageStatsJSON{UsageTotals: totals, Total: totals.Total()})
+	case "csv":
+		return errors.New("--output csv is not supported with --usage; use json")
+	}
+	if len(totals.Models) == 0 {
+		fmt.Fprintln(errWriter(), "No LLM usage recorded yet.")
+		return nil
+	}
+	printUsageTotals(outWriter(), totals)
+	return nil
+}
+
+// UsageStatsJSON is the JSON output of gmc stats --usage.
+type UsageStatsJSON struct {
+	llm.UsageTotals
+	Total llm.ModelUsage `json:"total"`
+}
+
+// printUsageTotals prints one row per model and a total. Models without a known price
+// show "-" for the cost.
+func printUsageTotals(w io.Writer, totals llm.UsageTotals) {
+	fmt.Fprintf(w, "LLM usage since %s\n\n", totals.Since.Local().Format("2006-01-02"))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tREQUESTS\tPROMPT TOK\tCOMPLETION TOK\tCOST")
+	for _, name := range totals.ModelNames() {
+		model := totals.Models[name]
+		cost := "-"
+		if _, ok := llm.PriceFor(name); ok {
+			cost = fmt.Sprintf("~$%.4f", model.Cost)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", name, model.Requests, model.PromptTokens, model.CompletionTokens, cost)
+	}
+	total := totals.Total()
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\t~$%.4f\n", total.Requests, total.PromptTokens, total.CompletionTokens, total.Cost)
+	_ = tw.Flush()
+}
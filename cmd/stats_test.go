@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/analyzer"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatsCommandRejectsInvalidLimit(t *testing.T) {
+	original := statsLimit
+	defer func() { statsLimit = original }()
+
+	statsLimit = 0
+	err := runStatsCommand()
+	assert.EqualError(t, err, "--limit must be a positive number")
+}
+
+func TestRunStatsCommandRejectsInvalidPeriod(t *testing.T) {
+	original := statsPeriod
+	defer func() { statsPeriod = original }()
+
+	statsPeriod = "day"
+	err := runStatsCommand()
+	assert.EqualError(t, err, `invalid --period "day": must be week or month`)
+}
+
+func TestStatsCommandRejectsArgs(t *testing.T) {
+	assert.Error(t, statsCmd.Args(statsCmd, []string{"extra"}))
+}
+
+func TestUsageTrackerRecordsAndPrints(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	original := verbose
+	defer func() { verbose = original }()
+
+	var out bytes.Buffer
+	track := usageTracker(&out)
+	verbose = true
+	track(llm.Usage{Model: "gpt-4.1-mini", PromptTokens: 812, CompletionTokens: 24})
+	verbose = false
+	track(llm.Usage{Model: "gpt-4.1-mini", PromptTokens: 100, CompletionTokens: 10})
+	assert.Equal(t, "Token usage: prompt 812 tok, completion 24 tok, ~$0.0004\n", out.String())
+
+	path, err := llm.UsageFilePath()
+	require.NoError(t, err)
+	totals, err := llm.LoadUsageTotals(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, totals.Models["gpt-4.1-mini"].Requests)
+	assert.Equal(t, 912, totals.Models["gpt-4.1-mini"].PromptTokens)
+}
+
+func TestPrintUsageTotals(t *testing.T) {
+	var totals llm.UsageTotals
+	totals.Add(llm.Usage{Model: "gpt-4.1-mini", PromptTokens: 1000, CompletionTokens: 30})
+	totals.Add(llm.Usage{Model: "llama3.1:8b", PromptTokens: 100, CompletionTokens: 5})
+
+	var out bytes.Buffer
+	printUsageTotals(&out, totals)
+	assert.Contains(t, out.String(), "MODEL         REQUESTS  PROMPT TOK  COMPLETION TOK  COST")
+	assert.Contains(t, out.String(), "gpt-4.1-mini  1         1000        30              ~$0.0004")
+	assert.Contains(t, out.String(), "llama3.1:8b   1         100         5               -")
+	assert.Contains(t, out.String(), "TOTAL         2         1100        35              ~$0.0004")
+}
+
+func TestRunStatsCommandMinScore(t *testing.T) {
+	repoDir := initCmdTestRepo(t)
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldCwd) }()
+	require.NoError(t, os.Chdir(repoDir))
+	runGitCmd(t, repoDir, "commit", "-q", "--allow-empty", "-m", "feat: add the quality gate")
+
+	var out bytes.Buffer
+	oldOut := outWriterFunc
+	outWriterFunc = func() io.Writer { return &out }
+	defer func(minScore int) {
+		outWriterFunc = oldOut
+		statsMinScore = minScore
+	}(statsMinScore)
+
+	// "init" scores 45 and the feat commit 100: an average of 72.5.
+	withOutputFormat(t, "json")
+	statsMinScore = 70
+	require.NoError(t, runStatsCommand())
+	var report StatsJSON
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, 72.5, report.AverageScore)
+	assert.False(t, report.BelowMinScore)
+	require.Len(t, report.Poor, 1)
+	assert.Equal(t, "init", report.Poor[0].Subject)
+	assert.NotEmpty(t, report.Poor[0].Hash)
+
+	out.Reset()
+	withOutputFormat(t, "csv")
+	statsMinScore = 80
+	err = runStatsCommand()
+	assert.ErrorIs(t, err, analyzer.ErrBelowMinScore)
+	assert.EqualError(t, err, "commit quality is below the minimum score: "+
+		"the average score of 2 commit(s) is 72.5, below 80")
+	assert.True(t, strings.HasPrefix(out.String(), "hash,author,date,type,score,issues\n"))
+	assert.Contains(t, out.String(), ",Test User,")
+}
+
+func TestRunStatsCommandGroupsAuthors(t *testing.T) {
+	repoDir := initCmdTestRepo(t)
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldCwd) }()
+	require.NoError(t, os.Chdir(repoDir))
+	runGitCmd(t, repoDir, "commit", "-q", "--allow-empty", "-m", "feat: add aliases", "--author", "tu <tu@old.example>")
+
+	var out bytes.Buffer
+	oldOut := outWriterFunc
+	outWriterFunc = func() io.Writer { return &out }
+	defer func(team, byEmail bool) {
+		outWriterFunc = oldOut
+		statsTeam, statsByEmail = team, byEmail
+	}(statsTeam, statsByEmail)
+	withOutputFormat(t, "json")
+	statsTeam = true
+
+	authors := func() []string {
+		out.Reset()
+		require.NoError(t, runStatsCommand())
+		var report StatsJSON
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		var names []string
+		for _, author := range report.Authors {
+			names = append(names, author.Author)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Test User", "tu"}, authors())
+
+	statsByEmail = true
+	assert.Equal(t, []string{"test@example.com", "tu@old.example"}, authors())
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".mailmap"),
+		[]byte("Test User <test@example.com> tu <tu@old.example>\n"), 0o644))
+	assert.Equal(t, []string{"test@example.com"}, authors())
+}
+
+func TestRunStatsCommandScopes(t *testing.T) {
+	repoDir := initCmdTestRepo(t)
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldCwd) }()
+	require.NoError(t, os.Chdir(repoDir))
+	for _, subject := range []string{"feat(auth): add login", "fix(auth): expire tokens", "fix(authn): keep sessions"} {
+		runGitCmd(t, repoDir, "commit", "-q", "--allow-empty", "-m", subject)
+	}
+
+	var out, errOut bytes.Buffer
+	oldOut, oldErr := outWriterFunc, errWriterFunc
+	outWriterFunc = func() io.Writer { return &out }
+	errWriterFunc = func() io.Writer { return &errOut }
+	defer func(scopes, save bool) {
+		outWriterFunc, errWriterFunc = oldOut, oldErr
+		statsScopes, statsSaveScope = scopes, save
+	}(statsScopes, statsSaveScope)
+
+	withOutputFormat(t, "text")
+	statsSaveScope = true
+	require.EqualError(t, runStatsCommand(), "--save-scopes requires --scopes")
+
+	statsScopes = true
+	require.NoError(t, runStatsCommand())
+	assert.Contains(t, out.String(), "3 scoped commit(s), 1 scope(s)")
+	assert.Contains(t, out.String(), "use auth             instead of authn (1)")
+	assert.Contains(t, errOut.String(), "Saved 1 scope(s) to ")
+
+	data, err := os.ReadFile(filepath.Join(repoDir, ".gmc.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "scopes:\n  - auth\n")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,7 +19,13 @@ import (
 )
 
 var (
-	tagAutoYes bool
+	tagAutoYes    bool
+	tagPushRemote string
+	tagNotes      bool
+	tagNotesFile  string
+	tagPre        string
+	tagFinalize   bool
+	tagComponent  string
 
 	// isStdinTerminal is a function to check if stdin is a terminal.
 	// It can be overridden in tests.
@@ -33,8 +40,16 @@ var (
 			`and optionally create the tag when confirmed.
 
 Examples:
-  gmc tag          # Analyze commits and interactively create a tag
-  gmc tag --yes    # Auto-confirm tag creation with the suggested version`,
+  gmc tag                         # Analyze commits and interactively create a tag
+  gmc tag --yes                   # Auto-confirm tag creation with the suggested version
+  gmc tag --push                  # Create the tag and push it to origin
+  gmc tag --push=upstream         # Create the tag and push it to upstream
+  gmc tag --notes                 # Also print release notes for a GitHub release
+  gmc tag --notes-file NOTES.md   # Write the release notes to a file
+  gmc tag --pre rc                # Start or continue a release candidate train, e.g. v1.3.0-rc.2
+  gmc tag --finalize              # Release the latest pre-release, e.g. v1.3.0-rc.2 as v1.3.0
+  gmc tag --component api         # Tag the api component of a monorepo, e.g. api/v1.4.0`,
+		Args: cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return runTagCommand()
 		},
@@ -49,9 +64,63 @@ func init() {
 		false,
 		"Automatically confirm tag creation with the suggested version",
 	)
+	tagCmd.Flags().StringVar(&tagPushRemote, "push", "",
+		"Push the tag to `remote` after creating it (origin when given without a value)")
+	tagCmd.Flags().Lookup("push").NoOptDefVal = "origin"
+	tagCmd.Flags().BoolVar(&tagNotes, "notes", false,
+		"Print release notes grouped by commit type after creating the tag")
+	tagCmd.Flags().StringVar(&tagNotesFile, "notes-file", "", "Write the release notes to `file` instead of stdout")
+	tagCmd.Flags().StringVar(&tagPre, "pre", "",
+		"Tag a pre-release of the suggested version with the identifier `id`, e.g. rc, continuing its train")
+	tagCmd.Flags().BoolVar(&tagFinalize, "finalize", false, "Tag the release of the latest pre-release")
+	tagCmd.MarkFlagsMutuallyExclusive("pre", "finalize")
+	tagCmd.Flags().StringVar(&tagComponent, "component", "",
+		"Tag the component `name` from the components config, e.g. api/v1.4.0, from its commits only")
+	_ = tagCmd.RegisterFlagCompletionFunc("component", completeComponents)
 	rootCmd.AddCommand(tagCmd)
 }
 
+func completeComponents(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(cfg.Components))
+	for name := range cfg.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return completeStrings(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// tagScope is what gmc tag releases: the whole repository, or a monorepo component
+// whose tags are namespaced by prefix and whose commits are those under dir.
+type tagScope struct {
+	prefix string
+	dir    string
+}
+
+// name returns the tag name of v in the scope.
+func (s tagScope) name(v version.SemVer) string {
+	return s.prefix + v.String()
+}
+
+// resolveTagScope returns the scope of --component, or the whole repository.
+func resolveTagScope() (tagScope, error) {
+	if tagComponent == "" {
+		return tagScope{}, nil
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return tagScope{}, err
+	}
+	dir, ok := cfg.Component(tagComponent)
+	if !ok {
+		return tagScope{}, fmt.Errorf("unknown component %q; add it under components in .gmc.yaml", tagComponent)
+	}
+	return tagScope{prefix: strings.ToLower(tagComponent) + "/", dir: dir}, nil
+}
+
 type TagJSON struct {
 	Current   string   `json:"current"`
 	Suggested string   `json:"suggested"`
@@ -59,14 +128,26 @@ type TagJSON struct {
 }
 
 func runTagCommand() error {
-	gitClient := git.NewClient(git.Options{Verbose: verbose})
-	llmClient := llm.NewClient(llm.Options{Timeout: time.Duration(timeoutSeconds) * time.Second})
+	if tagPre != "" {
+		if err := version.ValidatePrerelease(tagPre); err != nil {
+			return fmt.Errorf("invalid --pre value: %w", err)
+		}
+	}
+	scope, err := resolveTagScope()
+	if err != nil {
+		return err
+	}
+	gitClient, err := newGitClient()
+	if err != nil {
+		return err
+	}
+	llmClient := newLLMClient()
 
-	lastTag, commits, err := collectTagContext(gitClient)
+	lastTag, commits, err := collectTagContext(gitClient, scope)
 	if err != nil {
 		return wrapTagError(err)
 	}
-	if len(commits) == 0 {
+	if len(commits) == 0 && !tagFinalize {
 		if outputFormat() == "json" {
 			return printJSON(outWriter(), TagJSON{Current: lastTag})
 		}
@@ -74,19 +155,20 @@ func runTagCommand() error {
 		return nil
 	}
 
-	baseVersion, displayTag, err := resolveBaseVersion(lastTag)
+	baseVersion, displayTag, err := resolveBaseVersion(lastTag, scope)
 	if err != nil {
 		return wrapTagError(err)
 	}
 
-	if outputFormat() != "json" {
+	if outputFormat() != "json" && len(commits) > 0 {
 		printCommitSummary(displayTag, commits)
 	}
 
-	finalVersion, finalReason, source, err := pickTagSuggestion(baseVersion, commits, llmClient)
+	finalVersion, finalReason, source, err := pickReleaseVersion(baseVersion, commits, llmClient)
 	if err != nil {
 		return wrapTagError(err)
 	}
+	tagName := scope.name(finalVersion)
 
 	if outputFormat() == "json" {
 		commitMsgs := make([]string, len(commits))
@@ -95,12 +177,12 @@ func runTagCommand() error {
 		}
 		return printJSON(outWriter(), TagJSON{
 			Current:   lastTag,
-			Suggested: finalVersion.String(),
+			Suggested: tagName,
 			Commits:   commitMsgs,
 		})
 	}
 
-	fmt.Fprintf(outWriter(), "Suggested version (%s): %s\n", source, finalVersion.String())
+	fmt.Fprintf(outWriter(), "Suggested version (%s): %s\n", source, tagName)
 	if strings.TrimSpace(finalReason) != "" {
 		fmt.Fprintf(outWriter(), "Reason: %s\n", finalReason)
 	}
@@ -111,7 +193,12 @@ func runTagCommand() error {
 		return nil
 	}
 
-	confirmed, err := confirmTagCreation(finalVersion.String())
+	tagMessage, err := buildTagMessage(tagName, lastTag, finalReason, commits)
+	if err != nil {
+		return wrapTagError(err)
+	}
+
+	confirmed, err := confirmTagCreation(tagName)
 	if err != nil {
 		return wrapTagError(fmt.Errorf("failed to read confirmation: %w", err))
 	}
@@ -121,31 +208,97 @@ func runTagCommand() error {
 		return nil
 	}
 
-	tagMessage := "Release " + finalVersion.String()
-	if strings.TrimSpace(finalReason) != "" {
-		tagMessage = fmt.Sprintf("%s: %s", tagMessage, finalReason)
+	if err := gitClient.CreateAnnotatedTag(tagName, tagMessage); err != nil {
+		return wrapTagError(fmt.Errorf("failed to create tag: %w", err))
 	}
 
-	if err := gitClient.CreateAnnotatedTag(finalVersion.String(), tagMessage); err != nil {
-		return wrapTagError(fmt.Errorf("failed to create tag: %w", err))
+	fmt.Fprintf(outWriter(), "Tag %s created successfully.\n", tagName)
+	if tagPushRemote == "" {
+		fmt.Fprintf(outWriter(), "Hint: run `git push origin %s` to share the tag.\n", tagName)
+	} else {
+		if err := gitClient.PushTag(tagPushRemote, tagName); err != nil {
+			return wrapTagError(err)
+		}
+		fmt.Fprintf(outWriter(), "Tag %s pushed to %s.\n", tagName, tagPushRemote)
 	}
 
-	fmt.Fprintf(outWriter(), "Tag %s created successfully.\n", finalVersion.String())
-	fmt.Fprintf(outWriter(), "Hint: run `git push origin %s` to share the tag.\n", finalVersion.String())
+	if tagNotes || tagNotesFile != "" {
+		data := version.NewTagMessageData(tagName, lastTag, finalReason, commits, time.Now())
+		return writeReleaseNotes(version.RenderReleaseNotes(data), llmClient)
+	}
+	return nil
+}
+
+// writeReleaseNotes polishes notes with the LLM when an API key is configured, and
+// prints them, or writes them to --notes-file. A failed polish keeps the notes as
+// grouped from the commits.
+func writeReleaseNotes(notes string, llmClient *llm.Client) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.APIKey != "" {
+		polished, err := llmClient.PolishReleaseNotes(notes, cfg.Model)
+		if err != nil {
+			fmt.Fprintf(errWriter(), "Warning: release notes polishing failed: %v\n", err)
+		} else {
+			notes = polished
+		}
+	}
+
+	if tagNotesFile != "" {
+		if err := os.WriteFile(workPath(tagNotesFile), []byte(notes), 0o644); err != nil {
+			return fmt.Errorf("failed to write release notes: %w", err)
+		}
+		fmt.Fprintf(errWriter(), "Release notes written to %s\n", tagNotesFile)
+		return nil
+	}
+	fmt.Fprintln(outWriter())
+	fmt.Fprint(outWriter(), notes)
 	return nil
 }
 
-func collectTagContext(gitClient *git.Client) (string, []git.CommitInfo, error) {
+// buildTagMessage renders the tag_template when configured, otherwise the default
+// "Release <version>: <reason>" message. Templated messages are previewed before confirmation.
+func buildTagMessage(next, previous, reason string, commits []git.CommitInfo) (string, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(cfg.TagTemplate) == "" {
+		message := "Release " + next
+		if strings.TrimSpace(reason) != "" {
+			message = fmt.Sprintf("%s: %s", message, reason)
+		}
+		return message, nil
+	}
+
+	content, err := version.LoadTagTemplate(cfg.TagTemplate)
+	if err != nil {
+		return "", err
+	}
+	data := version.NewTagMessageData(next, previous, reason, commits, time.Now())
+	message, err := version.RenderTagMessage(content, data)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(outWriter(), "Tag message (from %s):\n%s\n\n", cfg.TagTemplate, message)
+	return message, nil
+}
+
+func collectTagContext(gitClient *git.Client, scope tagScope) (string, []git.CommitInfo, error) {
 	if err := gitClient.CheckGitRepository(); err != nil {
 		return "", nil, fmt.Errorf("tagging failed: %w", err)
 	}
 
-	lastTag, err := gitClient.GetLatestTag()
+	lastTag, err := gitClient.GetLatestTagWithPrefix(scope.prefix)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to determine latest tag: %w", err)
 	}
 
-	commits, err := gitClient.GetCommitsSinceTag(lastTag)
+	commits, err := gitClient.GetCommitsSinceTagInPath(lastTag, scope.dir)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to collect commits: %w", err)
 	}
@@ -161,8 +314,8 @@ func printNoCommitsSinceLastTag(lastTag string) {
 	fmt.Fprintf(outWriter(), "No new commits since %s; no tag created.\n", lastTag)
 }
 
-func resolveBaseVersion(lastTag string) (version.SemVer, string, error) {
-	baseTag := lastTag
+func resolveBaseVersion(lastTag string, scope tagScope) (version.SemVer, string, error) {
+	baseTag := strings.TrimPrefix(lastTag, scope.prefix)
 	if baseTag == "" {
 		baseTag = "v0.0.0"
 	}
@@ -172,7 +325,7 @@ func resolveBaseVersion(lastTag string) (version.SemVer, string, error) {
 		return version.SemVer{}, "", fmt.Errorf("failed to parse base version %s: %w", baseTag, err)
 	}
 
-	displayTag := baseTag
+	displayTag := lastTag
 	if lastTag == "" {
 		displayTag = "initial commit"
 	}
@@ -187,6 +340,31 @@ func printCommitSummary(displayTag string, commits []git.CommitInfo) {
 	fmt.Fprintln(outWriter())
 }
 
+// pickReleaseVersion is pickTagSuggestion, turned into the next pre-release of the
+// --pre train, or the release of the latest pre-release for --finalize.
+func pickReleaseVersion(
+	baseVersion version.SemVer, commits []git.CommitInfo, llmClient *llm.Client,
+) (version.SemVer, string, string, error) {
+	if tagFinalize {
+		if !baseVersion.IsPrerelease() {
+			return version.SemVer{}, "", "", fmt.Errorf("latest tag %s is not a pre-release; nothing to finalize",
+				baseVersion.String())
+		}
+		return baseVersion.Finalize(), "Finalize pre-release " + baseVersion.String(), "pre-release", nil
+	}
+
+	next, reason, source, err := pickTagSuggestion(baseVersion, commits, llmClient)
+	if err != nil || tagPre == "" {
+		return next, reason, source, err
+	}
+	pre := version.NextPrerelease(baseVersion, next, tagPre)
+	if !pre.GreaterThan(baseVersion) {
+		return version.SemVer{}, "", "", fmt.Errorf("pre-release %s would not be above the latest tag %s",
+			pre.String(), baseVersion.String())
+	}
+	return pre, reason, source, nil
+}
+
 func pickTagSuggestion(
 	baseVersion version.SemVer, commits []git.CommitInfo, llmClient *llm.Client,
 ) (version.SemVer, string, string, error) {
@@ -249,6 +427,47 @@ func selectVersionSuggestion(
 	}
 }
 
+// VersionSuggestionJSON is the next version gmc suggests, without creating the tag.
+type VersionSuggestionJSON struct {
+	Current   string   `json:"current"`
+	Suggested string   `json:"suggested,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	Commits   []string `json:"commits"`
+}
+
+// suggestNextVersion runs the analysis of gmc tag and returns its suggestion. Suggested
+// is empty when there are no commits since the latest tag.
+func suggestNextVersion(llmClient *llm.Client) (VersionSuggestionJSON, error) {
+	gitClient, err := newGitClient()
+	if err != nil {
+		return VersionSuggestionJSON{}, err
+	}
+	lastTag, commits, err := collectTagContext(gitClient, tagScope{})
+	if err != nil {
+		return VersionSuggestionJSON{}, wrapTagError(err)
+	}
+
+	suggestion := VersionSuggestionJSON{Current: lastTag, Commits: make([]string, len(commits))}
+	for i, commit := range commits {
+		suggestion.Commits[i] = commit.Message
+	}
+	if len(commits) == 0 {
+		return suggestion, nil
+	}
+
+	baseVersion, _, err := resolveBaseVersion(lastTag, tagScope{})
+	if err != nil {
+		return VersionSuggestionJSON{}, wrapTagError(err)
+	}
+	next, reason, source, err := pickTagSuggestion(baseVersion, commits, llmClient)
+	if err != nil {
+		return VersionSuggestionJSON{}, wrapTagError(err)
+	}
+	suggestion.Suggested, suggestion.Reason, suggestion.Source = next.String(), reason, source
+	return suggestion, nil
+}
+
 func buildCommitSummaries(commits []git.CommitInfo) []string {
 	commitSummaries := make([]string, 0, len(commits))
 	for _, commit := range commits {
@@ -4,6 +4,9 @@ import (
 	"os"
 	"testing"
 
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/version"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -73,3 +76,56 @@ func TestConfirmTagCreationDecline(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, confirmed)
 }
+
+func TestPickReleaseVersion(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	defer func() { tagPre, tagFinalize = "", false }()
+	commits := []git.CommitInfo{{Message: "feat: add login"}}
+	rc, _ := version.ParseSemVer("v1.3.0-rc.1")
+
+	tagPre = "rc"
+	next, _, source, err := pickReleaseVersion(rc, commits, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0-rc.2", next.String())
+	assert.Equal(t, "rule engine", source)
+
+	tagPre = "alpha"
+	_, _, _, err = pickReleaseVersion(rc, commits, nil)
+	assert.EqualError(t, err, "pre-release v1.3.0-alpha.1 would not be above the latest tag v1.3.0-rc.1")
+
+	tagPre, tagFinalize = "", true
+	next, reason, _, err := pickReleaseVersion(rc, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", next.String())
+	assert.Equal(t, "Finalize pre-release v1.3.0-rc.1", reason)
+
+	_, _, _, err = pickReleaseVersion(next, nil, nil)
+	assert.EqualError(t, err, "latest tag v1.3.0 is not a pre-release; nothing to finalize")
+}
+
+func TestResolveTagScope(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	defer func() { tagComponent = "" }()
+	viper.Set("components", map[string]string{"api": "services/api"})
+
+	scope, err := resolveTagScope()
+	assert.NoError(t, err)
+	assert.Equal(t, tagScope{}, scope)
+
+	tagComponent = "API"
+	scope, err = resolveTagScope()
+	assert.NoError(t, err)
+	assert.Equal(t, tagScope{prefix: "api/", dir: "services/api"}, scope)
+
+	base, display, err := resolveBaseVersion("api/v1.4.0", scope)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.0", base.String())
+	assert.Equal(t, "api/v1.4.0", display)
+	assert.Equal(t, "api/v1.5.0", scope.name(base.NextMinor()))
+
+	tagComponent = "web"
+	_, err = resolveTagScope()
+	assert.EqualError(t, err, `unknown component "web"; add it under components in .gmc.yaml`)
+}
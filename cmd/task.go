@@ -162,7 +162,7 @@ func runTaskAdd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	source := strings.TrimSpace(taskAddFile)
+	source := workPath(strings.TrimSpace(taskAddFile))
 	if source == "" {
 		source = args[0]
 	}
@@ -34,7 +34,7 @@ func startTaskWebUI(opts taskweb.Options) error {
 	if err != nil {
 		return err
 	}
-	repoPath, err := os.Getwd()
+	repoPath, err := workingDir()
 	if err != nil {
 		return err
 	}
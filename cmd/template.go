@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/builtin"
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateNewHome        bool
+	templateNewDescription string
+	templateExportHome     bool
+	templateExportForce    bool
+
+	templateCmd = &cobra.Command{
+		Use:   "template",
+		Short: "Manage prompt templates",
+		Long: `List, inspect, create, edit and test the prompt templates used to generate
+commit messages.
+
+Named templates are YAML files looked up in this order:
+
+  1. .gmc/templates/<name>.yaml in the repository
+  2. ~/.config/gmc/templates/<name>.yaml
+
+"default" is the built-in template; a default.yaml in either directory replaces
+it. Commands that take a template also accept a file path, and use the
+prompt_template config when no template is given.`,
+		Example: `  gmc template list
+  gmc template new terse
+  gmc template edit terse
+  gmc template test terse
+  gmc template export-builtin
+  gmc config set prompt_template .gmc/templates/terse.yaml`,
+	}
+
+	templateListCmd = &cobra.Command{
+		Use:               "list",
+		Short:             "List built-in, repository and user templates",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runTemplateList()
+		},
+	}
+
+	templateShowCmd = &cobra.Command{
+		Use:               "show [name|path]",
+		Short:             "Print the resolved content of a template",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeTemplateNames,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runTemplateShow(args)
+		},
+	}
+
+	templateNewCmd = &cobra.Command{
+		Use:   "new <name>",
+		Short: "Scaffold a new template from the built-in one",
+		Long: `Create <name>.yaml in the repository's .gmc/templates directory, or in
+~/.config/gmc/templates with --home. The new template starts as a copy of the
+built-in template.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runTemplateNew(args[0])
+		},
+	}
+
+	templateEditCmd = &cobra.Command{
+		Use:               "edit [name|path]",
+		Short:             "Open a template in $EDITOR",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeTemplateNames,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runTemplateEdit(args)
+		},
+	}
+
+	templateTestCmd = &cobra.Command{
+		Use:   "test [name|path]",
+		Short: "Render a template against the staged changes",
+		Long: `Render the full prompt that gmc would send for the staged changes, using
+the given template. Nothing is sent to the LLM, so you can iterate on a
+template quickly.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeTemplateNames,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runTemplateTest(args)
+		},
+	}
+
+	templateExportBuiltinCmd = &cobra.Command{
+		Use:   "export-builtin",
+		Short: "Copy the built-in templates for customization",
+		Long: `Write the files compiled into gmc to the repository's .gmc directory, or to
+~/.config/gmc with --home:
+
+  templates/default.yaml  the default prompt template
+  locales/<code>.yaml     the wording used for each language setting
+  emoji.yaml              the emoji added to each commit type
+
+gmc reads each of these files from .gmc in the repository, then from
+~/.config/gmc, and only then uses its built-in copy. Existing files are kept
+unless --force is given. Delete the copies you do not change, so they keep
+following gmc updates.`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runTemplateExportBuiltin()
+		},
+	}
+)
+
+func init() {
+	templateNewCmd.Flags().BoolVar(&templateNewHome, "home", false,
+		"Create the template in ~/.config/gmc/templates instead of the repository")
+	templateNewCmd.Flags().StringVar(&templateNewDescription, "description", "", "Description stored in the template")
+	templateExportBuiltinCmd.Flags().BoolVar(&templateExportHome, "home", false,
+		"Write the files to ~/.config/gmc instead of the repository")
+	templateExportBuiltinCmd.Flags().BoolVar(&templateExportForce, "force", false, "Overwrite existing files")
+
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateShowCmd)
+	templateCmd.AddCommand(templateNewCmd)
+	templateCmd.AddCommand(templateEditCmd)
+	templateCmd.AddCommand(templateTestCmd)
+	templateCmd.AddCommand(templateExportBuiltinCmd)
+	rootCmd.AddCommand(templateCmd)
+}
+
+// TemplateShowJSON is the JSON output of gmc template show.
+type TemplateShowJSON struct {
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Content string `json:"content"`
+}
+
+// templateDirs returns the template lookup directories. The repository directory is
+// left out when not inside a git repository.
+func templateDirs() []formatter.TemplateDir {
+	var dirs []formatter.TemplateDir
+	if root, err := git.NewClient(git.Options{Dir: workDir}).GetRepoRoot(); err == nil {
+		dirs = append(dirs, formatter.TemplateDir{
+			Source: formatter.TemplateSourceRepo,
+			Path:   filepath.Join(root, formatter.RepoTemplatesDir),
+		})
+	}
+	if dir, err := config.UserTemplatesDir(); err == nil {
+		dirs = append(dirs, formatter.TemplateDir{Source: formatter.TemplateSourceHome, Path: dir})
+	}
+	return dirs
+}
+
+// builtinOverrideDirs returns the directories whose files replace the built-in
+// template, locales and emoji map, in lookup order. The repository is looked up
+// unless root, its top-level directory, is given.
+func builtinOverrideDirs(root string) []string {
+	if root == "" {
+		root, _ = git.NewClient(git.Options{Dir: workDir}).GetRepoRoot()
+	}
+
+	var dirs []string
+	if root != "" {
+		dirs = append(dirs, filepath.Join(root, builtin.RepoDir))
+	}
+	if dir, err := config.UserDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// resolveTemplateArg returns the template name and file path for args, falling back to
+// the prompt_template config. The path is "" for the built-in template.
+func resolveTemplateArg(args []string) (string, string, error) {
+	ref := ""
+	if len(args) > 0 {
+		ref = args[0]
+	} else {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return "", "", err
+		}
+		ref = cfg.PromptTemplate
+	}
+	if ref == "" {
+		ref = config.DefaultPromptTemplate
+	}
+
+	path, err := formatter.FindTemplate(templateDirs(), ref)
+	return ref, path, err
+}
+
+func runTemplateList() error {
+	templates, err := formatter.ListTemplates(templateDirs())
+	if err != nil {
+		return err
+	}
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), templates)
+	}
+
+	w := tabwriter.NewWriter(outWriter(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tDESCRIPTION\tPATH")
+	for _, tpl := range templates {
+		source := tpl.Source
+		if tpl.Shadowed {
+			source += " (shadowed)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tpl.Name, source, tpl.Description, tpl.Path)
+	}
+	return w.Flush()
+}
+
+func runTemplateShow(args []string) error {
+	name, path, err := resolveTemplateArg(args)
+	if err != nil {
+		return err
+	}
+
+	content, err := formatter.GetPromptTemplate(templateRef(path))
+	if err != nil {
+		return err
+	}
+
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), TemplateShowJSON{Name: name, Path: path, Content: content})
+	}
+	if path != "" {
+		fmt.Fprintf(errWriter(), "# %s\n", path)
+	}
+	fmt.Fprintln(outWriter(), content)
+	return nil
+}
+
+func runTemplateNew(name string) error {
+	var dir string
+	if templateNewHome {
+		userDir, err := config.UserTemplatesDir()
+		if err != nil {
+			return err
+		}
+		dir = userDir
+	} else {
+		root, err := git.NewClient(git.Options{Dir: workDir}).GetRepoRoot()
+		if err != nil {
+			return fmt.Errorf("%w (use --home to create a user template)", err)
+		}
+		dir = filepath.Join(root, formatter.RepoTemplatesDir)
+	}
+
+	path, err := formatter.NewTemplateFile(dir, name, templateNewDescription)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(outWriter(), path)
+	fmt.Fprintf(errWriter(), "Created template %q. Try it with: gmc template test %s\n", name, name)
+	fmt.Fprintf(errWriter(), "Use it with: gmc config set prompt_template %s\n", path)
+	return nil
+}
+
+func runTemplateExportBuiltin() error {
+	var dir string
+	if templateExportHome {
+		userDir, err := config.UserDir()
+		if err != nil {
+			return err
+		}
+		dir = userDir
+	} else {
+		root, err := git.NewClient(git.Options{Dir: workDir}).GetRepoRoot()
+		if err != nil {
+			return fmt.Errorf("%w (use --home to export to the user config directory)", err)
+		}
+		dir = filepath.Join(root, builtin.RepoDir)
+	}
+
+	written, skipped, err := builtin.Export(dir, templateExportForce)
+	for _, path := range written {
+		fmt.Fprintln(outWriter(), path)
+	}
+	if err != nil {
+		return err
+	}
+	for _, path := range skipped {
+		fmt.Fprintf(errWriter(), "Skipped %s: it already exists (use --force to overwrite)\n", path)
+	}
+	if len(written) > 0 {
+		fmt.Fprintf(errWriter(), "Exported %d built-in files to %s. Edit them, and delete the ones you keep as is.\n",
+			len(written), dir)
+	}
+	return nil
+}
+
+func runTemplateEdit(args []string) error {
+	_, path, err := resolveTemplateArg(args)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return errors.New("the built-in template cannot be edited; run 'gmc template new <name>' to start " +
+			"from a copy, or 'gmc template export-builtin' to override it")
+	}
+
+	editor := exec.Command(workflow.Editor(), path)
+	editor.Stdin = os.Stdin
+	editor.Stdout = os.Stdout
+	editor.Stderr = os.Stderr
+	if err := editor.Run(); err != nil {
+		return fmt.Errorf("failed to open editor: %w", err)
+	}
+	return nil
+}
+
+func runTemplateTest(args []string) error {
+	_, path, err := resolveTemplateArg(args)
+	if err != nil {
+		return err
+	}
+
+	// Surface template errors here; prompt building would fall back to fallback_template.
+	if err := formatter.CheckPromptTemplate(templateRef(path)); err != nil {
+		return err
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	if fallback := cfg.FallbackTemplate; fallback != "" && fallback != config.DefaultPromptTemplate {
+		if err := formatter.CheckPromptTemplate(fallback); err != nil {
+			fmt.Fprintf(errWriter(), "Warning: fallback_template is broken, gmc would use the built-in template: %v\n", err)
+		}
+	}
+
+	gitClient, err := newGitClient()
+	if err != nil {
+		return err
+	}
+	changes, err := gitClient.GetStagedChanges()
+	if err != nil {
+		return wrapGitError(err)
+	}
+	if changes.Diff == "" {
+		return errors.New("no staged changes to render the template against; stage some changes first")
+	}
+	files := changes.Files
+
+	cfg.PromptTemplate = templateRef(path)
+
+	prompt := formatter.BuildPromptWithContext(cfg, files,
+		changes.Diff+"\n"+formatter.DiffStatsSeparator+"\n"+changes.Stats,
+		formatter.PromptContext{
+			TypeHint:  formatter.TypeHintForConfig(cfg, files),
+			ScopeHint: formatter.ScopeHintForConfig(cfg, files),
+			Warnings:  errWriter(),
+		})
+	fmt.Fprintln(outWriter(), prompt)
+	return nil
+}
+
+// templateRef maps a resolved template path to the value GetPromptTemplate expects.
+func templateRef(path string) string {
+	if path == "" {
+		return config.DefaultPromptTemplate
+	}
+	return path
+}
+
+func completeTemplateNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	templates, err := formatter.ListTemplates(templateDirs())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	names := make([]string, 0, len(templates))
+	for _, tpl := range templates {
+		if !tpl.Shadowed {
+			names = append(names, tpl.Name+"\t"+tpl.Source)
+		}
+	}
+	return names, cobra.ShellCompDirectiveDefault
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateEditRejectsBuiltin(t *testing.T) {
+	err := runTemplateEdit([]string{"default"})
+	assert.ErrorContains(t, err, "built-in template cannot be edited")
+}
+
+func TestTemplateRef(t *testing.T) {
+	assert.Equal(t, "default", templateRef(""))
+	assert.Equal(t, "/tmp/terse.yaml", templateRef("/tmp/terse.yaml"))
+}
+
+func TestTemplateCommandArgs(t *testing.T) {
+	assert.Error(t, templateListCmd.Args(templateListCmd, []string{"extra"}))
+	assert.Error(t, templateNewCmd.Args(templateNewCmd, nil))
+	assert.Error(t, templateTestCmd.Args(templateTestCmd, []string{"a", "b"}))
+	assert.Error(t, templateExportBuiltinCmd.Args(templateExportBuiltinCmd, []string{"default"}))
+	assert.NotNil(t, templateExportBuiltinCmd.Flags().Lookup("home"))
+	assert.NotNil(t, templateExportBuiltinCmd.Flags().Lookup("force"))
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/history"
+	"github.com/samzong/gmc/internal/notes"
+	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var (
+	undoHard        bool
+	undoKeepMessage bool
+	undoAutoYes     bool
+
+	undoCmd = &cobra.Command{
+		Use:   "undo",
+		Short: "Undo the last commit gmc made",
+		Long: `Undo the last commit when gmc made it, keeping its changes staged.
+
+gmc checks that HEAD is its own commit: the message has a Generated-by: gmc
+trailer, the commit has a gmc generation note, or the gmc history recorded it as
+committed in this repository. It then runs git reset --soft HEAD~1, so the
+changes of the commit are staged again, ready for another gmc run.
+
+--hard runs git reset --hard HEAD~1 instead, discarding the changes of the
+commit and any uncommitted changes to tracked files; gmc asks first unless --yes
+is set. --keep-message records the message in the gmc history, so gmc redo
+commits it again.
+
+gmc refuses to undo a commit a remote-tracking branch already contains, a merge
+commit, or the first commit of the repository. The undone commit stays in the
+reflog as ORIG_HEAD.`,
+		Example: `  gmc undo
+  gmc undo --keep-message
+  gmc undo --hard --yes`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runUndo(cmd.InOrStdin())
+		},
+	}
+)
+
+func init() {
+	undoCmd.Flags().BoolVar(&undoHard, "hard", false, "Discard the changes of the commit instead of staging them")
+	undoCmd.Flags().BoolVar(&undoKeepMessage, "keep-message", false, "Keep the message for gmc redo")
+	undoCmd.Flags().BoolVarP(&undoAutoYes, "yes", "y", false, "Discard changes with --hard without asking")
+	rootCmd.AddCommand(undoCmd)
+}
+
+// UndoJSON is the JSON output of gmc undo.
+type UndoJSON struct {
+	Commit      string `json:"commit"`
+	Subject     string `json:"subject"`
+	Hard        bool   `json:"hard"`
+	KeptMessage bool   `json:"kept_message"`
+	NewHead     string `json:"new_head"`
+}
+
+func runUndo(in io.Reader) error {
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return wrapGitError(err)
+	}
+	heads, err := repo.GetRangeCommits("HEAD^!")
+	if err != nil || len(heads) != 1 {
+		return errors.New("nothing to undo: the current branch has no commits")
+	}
+	head := heads[0]
+	switch len(head.Parents) {
+	case 0:
+		return fmt.Errorf("%s is the first commit of the repository; gmc undo cannot remove it",
+			stringsutil.ShortHash(head.Hash, 7, ""))
+	case 1:
+	default:
+		return fmt.Errorf("%s is a merge commit; gmc undo only undoes commits gmc made",
+			stringsutil.ShortHash(head.Hash, 7, ""))
+	}
+
+	path, err := history.FilePath()
+	if err != nil {
+		return err
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	model, ok := gmcCommitModel(repo, head, history.ForRepo(entries, repo.Root()))
+	if !ok {
+		return fmt.Errorf("%s %q was not made by gmc: it has no Generated-by: gmc trailer, gmc note or "+
+			"history entry; use git reset --soft HEAD~1 to undo it anyway",
+			stringsutil.ShortHash(head.Hash, 7, ""), head.Subject())
+	}
+	pushed, err := repo.PushedCommits("HEAD^!")
+	if err != nil {
+		return err
+	}
+	if len(pushed) > 0 {
+		return fmt.Errorf("%s is already on a remote branch; undoing it would rewrite published history, "+
+			"use git revert instead", stringsutil.ShortHash(head.Hash, 7, ""))
+	}
+
+	if undoHard {
+		confirmed, err := confirmHardUndo(in, head)
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+	if err := repo.ResetHead(head.Parents[0], undoHard); err != nil {
+		return err
+	}
+	if undoKeepMessage {
+		if err := keepUndoneMessage(repo, path, head, model); err != nil {
+			return err
+		}
+	}
+
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), UndoJSON{
+			Commit: head.Hash, Subject: head.Subject(), Hard: undoHard,
+			KeptMessage: undoKeepMessage, NewHead: head.Parents[0],
+		})
+	}
+	fmt.Fprintf(outWriter(), "Undid %s %s\n", stringsutil.ShortHash(head.Hash, 7, ""), head.Subject())
+	if undoHard {
+		fmt.Fprintf(errWriter(), "Its changes were discarded. To restore the commit: git reset --hard %s\n",
+			stringsutil.ShortHash(head.Hash, 7, ""))
+	} else {
+		fmt.Fprintln(errWriter(), "Its changes are staged.")
+	}
+	if undoKeepMessage {
+		fmt.Fprintln(errWriter(), "Run gmc redo to commit the message again.")
+	}
+	return nil
+}
+
+// gmcCommitModel reports whether gmc made head, and the model that generated its
+// message when that is known. entries are the history of this repository.
+func gmcCommitModel(repo *git.Repo, head git.RangeCommit, entries []history.Entry) (string, bool) {
+	model, found := "", workflow.HasGeneratedByTrailer(head.Message)
+	if content, err := repo.ShowNote(notes.Ref, head.Hash); err == nil {
+		found = true
+		if gen, err := notes.Decode(content); err == nil {
+			model = gen.Model
+		}
+	}
+	for _, entry := range entries {
+		if entry.Status == history.StatusCommitted && entry.Message != "" &&
+			strings.HasPrefix(head.Message, strings.TrimSpace(entry.Message)) {
+			if model == "" {
+				model = entry.Model
+			}
+			return model, true
+		}
+	}
+	return model, found
+}
+
+// keepUndoneMessage records the message of head as undone, so gmc redo offers it
+// again for the staged changes.
+func keepUndoneMessage(repo *git.Repo, path string, head git.RangeCommit, model string) error {
+	entry := history.Entry{
+		Time:    time.Now().UTC().Truncate(time.Second),
+		Model:   model,
+		Message: head.Message,
+		Status:  history.StatusUndone,
+	}
+	entry.Branch, _ = repo.CurrentBranch()
+	if !undoHard {
+		diff, err := repo.GetStagedDiff()
+		if err != nil {
+			return err
+		}
+		entry.DiffHash = history.HashDiff(diff)
+		entry.Files = formatter.ExtractFilesFromDiff(diff)
+	}
+	return history.Recorder{Path: path, Repo: repo.Root()}.Record(entry)
+}
+
+func confirmHardUndo(in io.Reader, head git.RangeCommit) (bool, error) {
+	if undoAutoYes {
+		return true, nil
+	}
+	if !isStdinTerminal() {
+		return false, errors.New("stdin is not a terminal, use --yes to discard the changes with --hard")
+	}
+
+	fmt.Fprintf(errWriter(), "Discard the changes of %s %s and any uncommitted changes? [y/N]: ",
+		stringsutil.ShortHash(head.Hash, 7, ""), head.Subject())
+	answer, err := newTrimmedLineReader(in)()
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
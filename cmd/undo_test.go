@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUndo(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repoDir := initCmdTestRepo(t)
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldCwd) }()
+	require.NoError(t, os.Chdir(repoDir))
+
+	var out bytes.Buffer
+	oldOut, oldErr := outWriterFunc, errWriterFunc
+	outWriterFunc = func() io.Writer { return &out }
+	errWriterFunc = func() io.Writer { return &out }
+	defer func() {
+		outWriterFunc, errWriterFunc = oldOut, oldErr
+		undoKeepMessage = false
+	}()
+
+	commit := func(name, message string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(name), 0o644))
+		runGitCmd(t, repoDir, "add", name)
+		runGitCmd(t, repoDir, "commit", "-q", "-m", message)
+	}
+
+	commit("manual.txt", "feat: add manual")
+	err = runUndo(strings.NewReader(""))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "was not made by gmc")
+
+	commit("a.txt", "feat: add a\n\nGenerated-by: gmc v1.0.0 (gpt-4.1)")
+	runGitCmd(t, repoDir, "update-ref", "refs/remotes/origin/main", "HEAD")
+	err = runUndo(strings.NewReader(""))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already on a remote branch")
+	runGitCmd(t, repoDir, "update-ref", "-d", "refs/remotes/origin/main")
+
+	undoKeepMessage = true
+	require.NoError(t, runUndo(strings.NewReader("")))
+	assert.Contains(t, out.String(), "feat: add a")
+	assert.Equal(t, "a.txt\n", runGitCmd(t, repoDir, "diff", "--cached", "--name-only"))
+	assert.Equal(t, "feat: add manual\n", runGitCmd(t, repoDir, "log", "-1", "--format=%s"))
+
+	path, err := history.FilePath()
+	require.NoError(t, err)
+	entries, err := history.Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, history.StatusUndone, entries[0].Status)
+	assert.Equal(t, []string{"a.txt"}, entries[0].Files)
+	assert.True(t, entries[0].Redoable())
+}
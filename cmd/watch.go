@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/samzong/gmc/internal/watch"
+	"github.com/samzong/gmc/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchInterval     int
+	watchPaths        []string
+	watchSquashOnExit bool
+
+	watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Commit checkpoints as the working tree changes",
+		Long: `Watch the current directory and commit a checkpoint each time the changes settle,
+with a generated message, for sessions such as an AI agent's that make many small edits.
+
+Once no file has changed for --interval seconds, gmc stages the files --paths
+match, as git add --all does, and commits what is staged like gmc --yes. Without
+--paths, only what is already staged is committed, so a session that stages its
+own changes decides what goes in. Directories git ignores when the watch starts,
+such as node_modules, and .git are not watched.
+
+A checkpoint that fails, for example because the LLM cannot be reached, is
+reported and retried after the next change. Stop watching with Ctrl-C.
+--squash-on-exit then squashes every commit made since the watch started into one
+commit, with a message generated for the combined changes.`,
+		Example: `  gmc watch --paths .
+  gmc watch --paths 'src/**' --paths ':!src/gen' --interval 30
+  gmc watch --paths . --squash-on-exit`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runWatch(ctx, cmd.InOrStdin())
+		},
+	}
+)
+
+func init() {
+	watchCmd.Flags().IntVar(&watchInterval, "interval", 10,
+		"Seconds without a change before the changes are committed")
+	watchCmd.Flags().StringArrayVar(&watchPaths, "paths", nil,
+		"Stage the files this `pathspec` matches before each checkpoint (repeatable; globs and :! excludes work)")
+	watchCmd.Flags().BoolVar(&watchSquashOnExit, "squash-on-exit", false,
+		"Squash the checkpoint commits into one with a regenerated message when the watch stops")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watchSession commits the checkpoints of one gmc watch.
+type watchSession struct {
+	repo    *git.Repo
+	llm     *llm.Client
+	cfg     *config.Config
+	in      io.Reader
+	commits int
+}
+
+func runWatch(ctx context.Context, in io.Reader) error {
+	if watchInterval <= 0 {
+		return errors.New("--interval must be a positive number of seconds")
+	}
+	gitOpts, err := gitOptions()
+	if err != nil {
+		return err
+	}
+	repo, err := git.OpenRepo(gitOpts)
+	if err != nil {
+		return err
+	}
+	cfg, proceed, err := ensureConfiguredAndGetConfig(nil, in, errWriter(), runInitWizard)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		return errAPIKeyMissing
+	}
+
+	start := ""
+	if watchSquashOnExit {
+		if start, err = repo.ResolveCommit("HEAD"); err != nil {
+			return errors.New("--squash-on-exit needs a commit to squash onto; make the first commit without it")
+		}
+	}
+	ignored, err := repo.IgnoredDirs()
+	if err != nil {
+		return err
+	}
+	skip := make(map[string]bool, len(ignored))
+	for _, dir := range ignored {
+		skip[dir] = true
+	}
+
+	session := &watchSession{repo: repo, llm: newLLMClient(), cfg: cfg, in: in}
+	fmt.Fprintf(errWriter(), "Watching for changes; committing %d second(s) after they settle. Press Ctrl-C to stop.\n",
+		watchInterval)
+	err = watch.Run(ctx, watch.Options{
+		Root:     ".",
+		Interval: time.Duration(watchInterval) * time.Second,
+		Skip:     func(dir string) bool { return skip[dir] },
+		OnSettle: session.checkpoint,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(errWriter(), "\nStopped watching after %d checkpoint commit(s).\n", session.commits)
+	if watchSquashOnExit && session.commits > 0 {
+		return session.squash(start)
+	}
+	return nil
+}
+
+// checkpoint stages the --paths files and commits what is staged, reporting a failure
+// instead of stopping the watch.
+func (s *watchSession) checkpoint() {
+	if err := s.stage(); err != nil {
+		fmt.Fprintf(errWriter(), "Warning: %v\n", err)
+		return
+	}
+	staged, _, err := s.repo.StagedPaths()
+	if err != nil {
+		fmt.Fprintf(errWriter(), "Warning: failed to list staged files: %v\n", err)
+		return
+	}
+	if len(staged) == 0 {
+		return
+	}
+
+	fmt.Fprintf(errWriter(), "\n[%s] Committing checkpoint %d (%d file(s))\n",
+		time.Now().Format(time.TimeOnly), s.commits+1, len(staged))
+	if err := runCommitFlow(s.repo, s.llm, s.cfg, s.in, workflow.CommitOptions{AutoYes: true}); err != nil {
+		fmt.Fprintf(errWriter(), "Warning: checkpoint failed: %v\n", err)
+		return
+	}
+	s.commits++
+}
+
+// squash commits the changes of every commit since start, the HEAD the watch started
+// at, as one, as gmc squash --since <start> --yes does.
+func (s *watchSession) squash(start string) error {
+	if err := s.stage(); err != nil {
+		fmt.Fprintf(errWriter(), "Warning: %v\n", err)
+	}
+	commits, err := s.repo.GetRangeCommits(start + "..HEAD")
+	if err != nil || len(commits) == 0 {
+		return err
+	}
+	fmt.Fprintf(errWriter(), "Squashing the %d commits since %s into one...\n",
+		len(commits), stringsutil.ShortHash(start, 7, ""))
+	return squashOnto(s.repo, s.llm, s.cfg, s.in, start, commits, true)
+}
+
+func (s *watchSession) stage() error {
+	if len(watchPaths) == 0 {
+		return nil
+	}
+	return s.repo.StagePaths(watchPaths)
+}
@@ -9,8 +9,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/mattn/go-isatty"
 	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/samzong/gmc/internal/ui"
 	"github.com/samzong/gmc/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -28,6 +28,10 @@ var (
 	wtAddPR        int
 	wtShowPR       bool
 	wtDiffBase     string
+
+	wtCloneDepth        int
+	wtCloneFilter       string
+	wtCloneSingleBranch bool
 )
 
 var wtCmd = &cobra.Command{
@@ -62,22 +66,22 @@ Examples:
   gmc wt add feat-a feat-b feat-c             # Create multiple worktrees
   gmc wt add feature-login -b main            # Create based on main branch
   gmc wt add feature-login --sync             # Sync base branch before add
-  gmc wt add --pr 1065                        # Create a worktree from a pull request
+  gmc wt add --from-pr 1065                   # Create a worktree from a pull request
   gmc wt add hotfix-bug123 -b release
   gmc wt add -b feat/existing-branch          # Name derived from -b`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if addPRMode(cmd) {
 			if wtAddPR <= 0 {
-				return errors.New("--pr must be greater than 0")
+				return errors.New("--from-pr must be greater than 0")
 			}
 			if len(args) > 0 {
-				return errors.New("--pr is mutually exclusive with worktree names")
+				return errors.New("--from-pr is mutually exclusive with worktree names")
 			}
 			if strings.TrimSpace(wtBaseBranch) != "" {
-				return errors.New("--pr is mutually exclusive with -b/--base")
+				return errors.New("--from-pr is mutually exclusive with -b/--base")
 			}
 			if wtAddSync {
-				return errors.New("--pr is mutually exclusive with --sync")
+				return errors.New("--from-pr is mutually exclusive with --sync")
 			}
 			return nil
 		}
@@ -146,7 +150,13 @@ agents: clone once, then 'gmc wt dup' to fan out.
 
 Creates a .bare directory containing the bare repository and a worktree
 for the default branch. For fork workflows, use --upstream to register
-the original upstream repo alongside your fork.
+the original upstream repo alongside your fork: the default branch then
+tracks upstream/<default>, and 'gmc wt sync' updates it from upstream.
+
+For huge repositories, --depth, --filter and --single-branch make a shallow
+or partial clone. 'gmc wt add -b' fetches a base branch the clone does not
+have yet at the clone depth, and the history is deepened when a merge base
+is past it.
 
 Examples:
   # Basic clone into bare + worktree layout
@@ -160,6 +170,10 @@ Examples:
     --upstream https://github.com/org/repo.git \
     --name upstream-repo
 
+  # Huge monorepo: recent history of the default branch, blobs on demand
+  gmc wt clone https://github.com/org/monorepo.git \
+    --depth 50 --filter blob:none --single-branch
+
   # Typical next step: fan out worktrees for parallel AI agents
   cd upstream-repo && gmc wt dup 3`,
 	Args: cobra.ExactArgs(1),
@@ -241,10 +255,12 @@ Examples:
 
 var wtPrReviewCmd = &cobra.Command{
 	Use:   "pr-review <PR_NUMBER>",
-	Short: "Create a worktree from a GitHub Pull Request",
-	Long: `Create a worktree from a GitHub Pull Request for code review.
+	Short: "Create a worktree from a PR or merge request",
+	Long: `Create a worktree from a pull request (GitLab: merge request) for code review.
 
-Automatically detects remote (upstream > origin > single remote).
+Automatically detects remote (upstream > origin > single remote). The forge of the
+remote, or the forge config key, decides the ref: pull/<n>/head on GitHub and Gitea,
+merge-requests/<n>/head on GitLab.
 
 Examples:
   gmc wt pr-review 1065`,
@@ -275,7 +291,11 @@ func init() {
 
 	// Flags for add command
 	wtAddCmd.Flags().StringVarP(&wtBaseBranch, "base", "b", "", "Base branch to create from")
+	wtAddCmd.Flags().IntVar(&wtAddPR, "from-pr", 0,
+		"Create a worktree from pull request `number`, on the branch pr/<number>")
 	wtAddCmd.Flags().IntVar(&wtAddPR, "pr", 0, "Create a worktree from a pull request")
+	_ = wtAddCmd.Flags().MarkHidden("pr")
+	wtAddCmd.MarkFlagsMutuallyExclusive("from-pr", "pr")
 
 	// Flags for remove command
 	wtRemoveCmd.Flags().BoolVarP(&wtForce, "force", "f", false, "Force removal even if worktree is dirty")
@@ -286,6 +306,12 @@ func init() {
 	// Flags for clone command
 	wtCloneCmd.Flags().StringVar(&wtUpstream, "upstream", "", "Upstream repository URL (for fork workflow)")
 	wtCloneCmd.Flags().StringVar(&wtProjectName, "name", "", "Custom project directory name")
+	wtCloneCmd.Flags().IntVar(&wtCloneDepth, "depth", 0, "Fetch only the last `n` commits of each branch")
+	wtCloneCmd.Flags().StringVar(&wtCloneFilter, "filter", "",
+		"Partial clone filter, such as blob:none, to fetch objects on demand")
+	wtCloneCmd.Flags().BoolVar(&wtCloneSingleBranch, "single-branch", false, "Fetch only the default branch")
+	_ = wtCloneCmd.RegisterFlagCompletionFunc("filter",
+		cobra.FixedCompletions([]string{"blob:none", "tree:0", "blob:limit=1m"}, cobra.ShellCompDirectiveNoFileComp))
 
 	// Flags for dup command
 	wtDupCmd.Flags().StringVarP(&wtDupBase, "base", "b", "", "Base branch to create from")
@@ -331,6 +357,9 @@ type WorktreeJSON struct {
 	Branch         string `json:"branch"`
 	Commit         string `json:"commit"`
 	Status         string `json:"status"`
+	Locked         bool   `json:"locked,omitempty"`
+	LockReason     string `json:"lock_reason,omitempty"`
+	Note           string `json:"note,omitempty"`
 	DiffBase       string `json:"diff_base,omitempty"`
 	ChangedFiles   *int   `json:"changed_files,omitempty"`
 	Insertions     *int   `json:"insertions,omitempty"`
@@ -365,7 +394,7 @@ func runWorktreeDefault(wtClient *worktree.Client, _ *cobra.Command) error {
 	fmt.Fprintln(outWriter(), "Current Worktrees:")
 	printWorktreeTable(wtClient, filtered, reviews.Reviews, diffStats)
 
-	cwd, err := os.Getwd()
+	cwd, err := workingDir()
 	if err == nil {
 		for _, wt := range filtered {
 			if strings.HasPrefix(cwd, wt.Path) {
@@ -440,7 +469,7 @@ func addPRMode(cmd *cobra.Command) bool {
 	if wtAddPR > 0 {
 		return true
 	}
-	return cmd != nil && cmd.Flags().Changed("pr")
+	return cmd != nil && (cmd.Flags().Changed("from-pr") || cmd.Flags().Changed("pr"))
 }
 
 func runWorktreeAddPR(wtClient *worktree.Client, prNumber int) error {
@@ -542,8 +571,14 @@ func resolveAllRemovableWorktrees(wtClient *worktree.Client) ([]string, error) {
 
 func runWorktreeClone(wtClient *worktree.Client, url string) error {
 	opts := worktree.CloneOptions{
-		Name:     wtProjectName,
-		Upstream: wtUpstream,
+		Name:         wtProjectName,
+		Upstream:     wtUpstream,
+		Depth:        wtCloneDepth,
+		Filter:       wtCloneFilter,
+		SingleBranch: wtCloneSingleBranch,
+	}
+	if opts.Depth < 0 {
+		return errors.New("--depth must be a positive number of commits")
 	}
 	report, err := wtClient.Clone(url, opts)
 	printWorktreeReport(report)
@@ -717,11 +752,7 @@ func formatWorktreeReviewDisplay(reviews map[string]worktree.ReviewInfo, branch
 }
 
 func terminalLinksEnabled(w io.Writer) bool {
-	file, ok := w.(*os.File)
-	if !ok || os.Getenv("TERM") == "dumb" {
-		return false
-	}
-	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+	return ui.Detect(w).ANSI
 }
 
 func padVisibleRight(text string, visibleLen int, width int) string {
@@ -748,7 +779,22 @@ func printWorktreeTable(
 	maxName := len("Name")
 	maxBranch := len("Branch")
 	maxPR := len("PR")
-	for _, wt := range worktrees {
+	statuses := make([]string, len(worktrees))
+	notes := make([]string, len(worktrees))
+	maxStatus := len("STATUS")
+	hasNotes := false
+	for i, wt := range worktrees {
+		stat, hasStat := diffStats.Stats[wt.Path]
+		statuses[i] = formatWorktreeStatus(resolveWorktreeStatus(wtClient, root, wt), stat, hasStat)
+		if lock := formatWorktreeLock(wt); lock != "" {
+			statuses[i] += ", " + lock
+		}
+		maxStatus = max(maxStatus, len(statuses[i]))
+		if !wt.IsBare {
+			notes[i] = wtClient.Note(wt.Path)
+			hasNotes = hasNotes || notes[i] != ""
+		}
+
 		name := displayWorktreeName(root, wt.Path)
 		if len(name) > maxName {
 			maxName = len(name)
@@ -766,6 +812,14 @@ func printWorktreeTable(
 	maxBranch += 2
 	maxPR += 2
 
+	// The NOTE column is shown only when a worktree has a note.
+	statusCell := func(status, note string) string {
+		if !hasNotes {
+			return status
+		}
+		return strings.TrimRight(fmt.Sprintf("%-*s %s", maxStatus+2, status, note), " ")
+	}
+
 	if reviews != nil {
 		fmt.Fprintf(
 			writer,
@@ -774,17 +828,17 @@ func printWorktreeTable(
 			maxBranch, "BRANCH",
 			"COMMIT",
 			maxPR, "PR",
-			"STATUS",
+			statusCell("STATUS", "NOTE"),
 		)
 	} else {
-		fmt.Fprintf(writer, "%-*s %-*s %-8s %s\n", maxName, "NAME", maxBranch, "BRANCH", "COMMIT", "STATUS")
+		fmt.Fprintf(writer, "%-*s %-*s %-8s %s\n", maxName, "NAME", maxBranch, "BRANCH", "COMMIT",
+			statusCell("STATUS", "NOTE"))
 	}
 
-	for _, wt := range worktrees {
+	for i, wt := range worktrees {
 		name := displayWorktreeName(root, wt.Path)
 		shortCommit := stringsutil.ShortHash(wt.Commit, 7, "")
-		stat, hasStat := diffStats.Stats[wt.Path]
-		status := formatWorktreeStatus(resolveWorktreeStatus(wtClient, root, wt), stat, hasStat)
+		status := statusCell(statuses[i], notes[i])
 		if reviews != nil {
 			prText := formatWorktreeReview(reviews, wt.Branch)
 			prDisplay := formatWorktreeReviewDisplay(reviews, wt.Branch, links)
@@ -814,11 +868,16 @@ func buildWorktreeJSON(
 	for _, wt := range worktrees {
 		stat, hasStat := diffStats.Stats[wt.Path]
 		item := WorktreeJSON{
-			Name:   displayWorktreeName(root, wt.Path),
-			Path:   wt.Path,
-			Branch: wt.Branch,
-			Commit: wt.Commit,
-			Status: resolveWorktreeStatus(wtClient, root, wt),
+			Name:       displayWorktreeName(root, wt.Path),
+			Path:       wt.Path,
+			Branch:     wt.Branch,
+			Commit:     wt.Commit,
+			Status:     resolveWorktreeStatus(wtClient, root, wt),
+			Locked:     wt.IsLocked,
+			LockReason: wt.LockReason,
+		}
+		if !wt.IsBare {
+			item.Note = wtClient.Note(wt.Path)
 		}
 		if hasStat && stat.HasChanges() {
 			changedFiles := stat.Files
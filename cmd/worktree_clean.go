@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wtCleanBase   string
+	wtCleanForce  bool
+	wtCleanDryRun bool
+	wtCleanYes    bool
+)
+
+var wtCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove merged worktrees and their branches",
+	Long: `Find the worktrees that are done with, show them, and remove each worktree and
+its local branch after one confirmation.
+
+A worktree is a candidate when its branch is merged into the base branch, or when
+the branch's upstream is gone: forges delete the branch of a merged pull request,
+and git fetch --prune then marks the upstream as gone. The second check catches
+squash and rebase merges, whose commits are not in the base branch as they are.
+Such a branch is kept when it has commits that are on no remote branch and whose
+changes are not in the base branch, unless --force is set.
+Run 'gmc wt fetch --prune' first so the upstreams are up to date.
+
+Protected, locked and detached worktrees and the base branch are kept, as with
+'gmc wt prune'. Worktrees with uncommitted changes are kept unless --force is set.`,
+	Example: `  gmc wt fetch --prune && gmc wt clean
+  gmc wt clean --dry-run
+  gmc wt clean --yes -o json`,
+	Args:              cobra.NoArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runWorktreeClean(newWorktreeClient(), os.Stdin)
+	},
+}
+
+func init() {
+	wtCmd.AddCommand(wtCleanCmd)
+	wtCleanCmd.Flags().StringVarP(&wtCleanBase, "base", "b", "", "Base branch to check merge status against")
+	wtCleanCmd.Flags().BoolVarP(&wtCleanForce, "force", "f", false,
+		"Also remove worktrees with uncommitted changes or unpushed commits")
+	wtCleanCmd.Flags().BoolVar(&wtCleanDryRun, "dry-run", false, "Show the candidates without removing anything")
+	wtCleanCmd.Flags().BoolVarP(&wtCleanYes, "yes", "y", false, "Remove the candidates without asking")
+	wtCleanCmd.MarkFlagsMutuallyExclusive("dry-run", "yes")
+	_ = wtCleanCmd.RegisterFlagCompletionFunc("base", completeBranchNames)
+}
+
+// WorktreeCleanJSON is the JSON output of gmc wt clean.
+type WorktreeCleanJSON struct {
+	Base       string                    `json:"base"`
+	DryRun     bool                      `json:"dry_run"`
+	Candidates []worktree.CleanCandidate `json:"candidates"`
+	Removed    []worktree.CleanCandidate `json:"removed"`
+	// Failed maps the name of each candidate that could not be removed to the error.
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+func runWorktreeClean(wtClient *worktree.Client, in io.Reader) error {
+	plan, err := wtClient.PlanClean(worktree.CleanOptions{BaseBranch: wtCleanBase, Force: wtCleanForce})
+	if err != nil {
+		return err
+	}
+	printWorktreeReport(plan.Report)
+
+	output := WorktreeCleanJSON{
+		Base:       plan.Base,
+		DryRun:     wtCleanDryRun,
+		Candidates: plan.Candidates,
+		Removed:    []worktree.CleanCandidate{},
+	}
+	if output.Candidates == nil {
+		output.Candidates = []worktree.CleanCandidate{}
+	}
+	jsonOutput := outputFormat() == "json"
+
+	if len(plan.Candidates) == 0 {
+		if jsonOutput {
+			return printJSON(outWriter(), output)
+		}
+		fmt.Fprintf(outWriter(), "No merged worktrees to clean (base: %s).\n", plan.Base)
+		return nil
+	}
+
+	if !jsonOutput {
+		printCleanCandidates(outWriter(), plan.Candidates)
+	}
+	if wtCleanDryRun {
+		if jsonOutput {
+			return printJSON(outWriter(), output)
+		}
+		fmt.Fprintf(errWriter(), "Dry run: %d worktree(s) and branch(es) would be removed.\n", len(plan.Candidates))
+		return nil
+	}
+
+	confirmed, err := confirmWorktreeClean(in, len(plan.Candidates))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Fprintln(errWriter(), "Clean cancelled.")
+		if jsonOutput {
+			return printJSON(outWriter(), output)
+		}
+		return nil
+	}
+
+	result := wtClient.Clean(plan.Candidates, wtCleanForce)
+	printWorktreeReport(result.Report)
+	if result.Removed != nil {
+		output.Removed = result.Removed
+	}
+
+	var failed []string
+	for name, err := range result.Failed {
+		if output.Failed == nil {
+			output.Failed = make(map[string]string)
+		}
+		output.Failed[name] = err.Error()
+		failed = append(failed, name)
+	}
+	sort.Strings(failed)
+	if jsonOutput {
+		if err := printJSON(outWriter(), output); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range failed {
+			fmt.Fprintf(errWriter(), "Error removing '%s': %v\n", name, result.Failed[name])
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to remove worktrees: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func printCleanCandidates(w io.Writer, candidates []worktree.CleanCandidate) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tBRANCH\tREASON\tSTATUS")
+	for _, cand := range candidates {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", cand.Name, cand.Branch, cand.Reason, cand.Status)
+	}
+	_ = tw.Flush()
+}
+
+// confirmWorktreeClean asks once before count worktrees and their branches are
+// removed. Without a terminal on stdin it fails unless --yes is set.
+func confirmWorktreeClean(in io.Reader, count int) (bool, error) {
+	if wtCleanYes {
+		return true, nil
+	}
+	if !isStdinTerminal() {
+		return false, errors.New("stdin is not a terminal, use --yes to remove the worktrees")
+	}
+
+	fmt.Fprintf(errWriter(), "Remove %d worktree(s) and delete their branches? [y/N]: ", count)
+	answer, err := newTrimmedLineReader(in)()
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
@@ -1,7 +1,84 @@
 package cmd
 
-import "github.com/samzong/gmc/internal/worktree"
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/ui"
+	"github.com/samzong/gmc/internal/worktree"
+)
+
+var (
+	// wtShareParallel is set by gmc wt share sync --parallel.
+	wtShareParallel int
+	// wtShareForce is set by gmc wt share sync --force.
+	wtShareForce bool
+)
 
 func newWorktreeClient() *worktree.Client {
-	return worktree.NewClient(worktree.Options{Verbose: verbose})
+	opts := worktree.Options{
+		Verbose:  verbose,
+		Dir:      workDir,
+		Parallel: wtShareParallel,
+		OnCopy:   copyProgressPrinter(errWriter()),
+
+		Force:            wtShareForce,
+		ConfirmOverwrite: overwritePrompter(os.Stdin, errWriter()),
+	}
+	if cfg, err := config.GetConfig(); err == nil {
+		opts.Forge = cfg.Forge
+	}
+	return worktree.NewClient(opts)
+}
+
+// overwritePrompter asks on w whether to replace a shared resource whose policy is
+// overwrite: prompt. Without a terminal on stdin the resource is kept.
+func overwritePrompter(in io.Reader, w io.Writer) func(worktree.ShareEntry) bool {
+	var readLine func() (string, error)
+	return func(entry worktree.ShareEntry) bool {
+		if !isStdinTerminal() {
+			fmt.Fprintf(w, "Kept %s in %s: it differs from the source, but stdin is not a terminal\n",
+				entry.Path, entry.Worktree)
+			return false
+		}
+		if readLine == nil {
+			readLine = newTrimmedLineReader(in)
+		}
+		fmt.Fprintf(w, "%s in %s differs from the source (%s). Overwrite it? [y/N]: ",
+			entry.Path, entry.Worktree, entry.Detail)
+		answer, err := readLine()
+		if err != nil {
+			return false
+		}
+		answer = strings.ToLower(answer)
+		return answer == "y" || answer == "yes"
+	}
+}
+
+// copyProgressPrinter rewrites a single progress line on w while shared directories are
+// copied. It returns nil when w is not a terminal that allows ANSI; the sync report still
+// prints a summary.
+func copyProgressPrinter(w io.Writer) func(worktree.CopyProgress) {
+	if !ui.Detect(w).ANSI {
+		return nil
+	}
+	return func(p worktree.CopyProgress) {
+		fmt.Fprintf(w, "\r\x1b[K%s", truncateProgress(p.String(), 100))
+		if p.Files == p.TotalFiles {
+			fmt.Fprint(w, "\r\x1b[K")
+		}
+	}
+}
+
+// truncateProgress keeps the end of line, which holds the file name, so the line does
+// not wrap and break the carriage-return redraw.
+func truncateProgress(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	return "..." + string(runes[len(runes)-width+3:])
 }
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wtExecParallel int
+	wtExecOnly     []string
+	wtExecExclude  []string
+)
+
+var wtExecCmd = &cobra.Command{
+	Use:   "exec <preset|command> [args...]",
+	Short: "Run a command or preset in every worktree",
+	Long: `Run a shell command in every worktree and summarize the results.
+
+A single argument that names a preset from the exec_presets config runs that
+preset's command. Anything else runs as a shell command. Put "--" before
+commands that have their own flags.
+
+Presets live in the global config or the repository's .gmc.yaml:
+
+  exec_presets:
+    test: go test ./...
+    lint: golangci-lint run
+
+The command exits non-zero when it fails in any worktree.`,
+	Example: `  gmc wt exec test --parallel 4
+  gmc wt exec lint --only 'feat-*'
+  gmc wt exec --exclude main -- go test -race ./...
+  gmc wt exec test -o json`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeExecPresets,
+	RunE: func(_ *cobra.Command, args []string) error {
+		wtClient := newWorktreeClient()
+		return runWorktreeExec(wtClient, args)
+	},
+}
+
+func init() {
+	wtCmd.AddCommand(wtExecCmd)
+	wtExecCmd.Flags().IntVarP(&wtExecParallel, "parallel", "j", 1, "Number of worktrees to run in parallel")
+	wtExecCmd.Flags().StringSliceVar(&wtExecOnly, "only", nil, "Only run in worktrees whose name matches a glob")
+	wtExecCmd.Flags().StringSliceVar(&wtExecExclude, "exclude", nil, "Skip worktrees whose name matches a glob")
+}
+
+// WorktreeExecJSON is the JSON output of gmc wt exec.
+type WorktreeExecJSON struct {
+	Command string                `json:"command"`
+	Results []worktree.ExecResult `json:"results"`
+	Summary WorktreeExecSummary   `json:"summary"`
+}
+
+type WorktreeExecSummary struct {
+	Total  int `json:"total"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+func runWorktreeExec(wtClient *worktree.Client, args []string) error {
+	if wtExecParallel < 1 {
+		return errors.New("--parallel must be at least 1")
+	}
+
+	command, err := resolveExecCommand(args)
+	if err != nil {
+		return err
+	}
+
+	jsonOutput := outputFormat() == "json"
+	var onDone func(worktree.ExecResult)
+	if !jsonOutput {
+		fmt.Fprintf(errWriter(), "Running %q...\n", command)
+		onDone = printExecResult
+	}
+
+	results, err := wtClient.Exec(worktree.ExecOptions{
+		Command:  command,
+		Parallel: wtExecParallel,
+		Only:     wtExecOnly,
+		Exclude:  wtExecExclude,
+	}, onDone)
+	if err != nil {
+		return err
+	}
+
+	summary := WorktreeExecSummary{Total: len(results)}
+	var failed []string
+	for _, result := range results {
+		if result.Passed() {
+			summary.Passed++
+			continue
+		}
+		summary.Failed++
+		failed = append(failed, result.Worktree)
+	}
+
+	if jsonOutput {
+		if err := printJSON(outWriter(), WorktreeExecJSON{Command: command, Results: results, Summary: summary}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(outWriter(), "\nSummary: %d passed, %d failed\n", summary.Passed, summary.Failed)
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("command failed in %d of %d worktrees: %s",
+			summary.Failed, summary.Total, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// resolveExecCommand expands a single preset name, or joins args into a shell command.
+// A single non-preset argument is used verbatim so quoted pipelines keep working.
+func resolveExecCommand(args []string) (string, error) {
+	if len(args) == 1 {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return "", err
+		}
+		if command, ok := cfg.ExecPreset(args[0]); ok {
+			return command, nil
+		}
+		return args[0], nil
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteShellArg(arg)
+	}
+	return strings.Join(quoted, " "), nil
+}
+
+var shellSafeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func quoteShellArg(arg string) string {
+	if shellSafeArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func printExecResult(result worktree.ExecResult) {
+	status := "ok"
+	switch {
+	case result.Error != "":
+		status = "error: " + result.Error
+	case result.ExitCode != 0:
+		status = fmt.Sprintf("exit %d", result.ExitCode)
+	}
+
+	fmt.Fprintf(outWriter(), "\n==> %s (%s) %s in %.1fs\n", result.Worktree, result.Branch, status,
+		float64(result.DurationMS)/1000)
+	if output := strings.TrimRight(result.Output, "\n"); output != "" {
+		fmt.Fprintln(outWriter(), output)
+	}
+}
+
+func completeExecPresets(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	presets := make([]string, 0, len(cfg.ExecPresets))
+	for name, command := range cfg.ExecPresets {
+		presets = append(presets, name+"\t"+command)
+	}
+	sort.Strings(presets)
+	return presets, cobra.ShellCompDirectiveNoFileComp
+}
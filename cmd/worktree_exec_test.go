@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveExecCommand(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("exec_presets", map[string]string{"test": "go test ./..."})
+
+	command, err := resolveExecCommand([]string{"test"})
+	require.NoError(t, err)
+	assert.Equal(t, "go test ./...", command)
+
+	command, err = resolveExecCommand([]string{"make lint && make test"})
+	require.NoError(t, err)
+	assert.Equal(t, "make lint && make test", command, "a single non-preset argument runs verbatim")
+
+	command, err = resolveExecCommand([]string{"go", "test", "-run", "Test Foo", "./..."})
+	require.NoError(t, err)
+	assert.Equal(t, "go test -run 'Test Foo' ./...", command)
+}
+
+func TestQuoteShellArg(t *testing.T) {
+	assert.Equal(t, "./...", quoteShellArg("./..."))
+	assert.Equal(t, "'a b'", quoteShellArg("a b"))
+	assert.Equal(t, `'it'\''s'`, quoteShellArg("it's"))
+	assert.Equal(t, "''", quoteShellArg(""))
+}
+
+func TestRunWorktreeExecRejectsInvalidParallel(t *testing.T) {
+	original := wtExecParallel
+	defer func() { wtExecParallel = original }()
+
+	wtExecParallel = 0
+	assert.EqualError(t, runWorktreeExec(nil, []string{"test"}), "--parallel must be at least 1")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wtFetchRemote string
+	wtFetchPrune  bool
+)
+
+var wtFetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Fetch remotes into the shared repository",
+	Long: `Fetch remotes into the repository shared by all worktrees.
+
+The fetch runs against the bare repository (or the main repository), so it
+updates remote-tracking refs for every worktree no matter which one you run it
+from. git's progress is shown on stderr, followed by a summary of the refs that
+were created, updated or deleted.`,
+	Example: `  gmc wt fetch
+  gmc wt fetch --remote upstream
+  gmc wt fetch --prune -o json`,
+	Args:              cobra.NoArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runWorktreeFetch(newWorktreeClient())
+	},
+}
+
+func init() {
+	wtCmd.AddCommand(wtFetchCmd)
+	wtFetchCmd.Flags().StringVar(&wtFetchRemote, "remote", worktree.FetchAllRemotes,
+		"Remote to fetch: a remote name such as origin or upstream, or all")
+	wtFetchCmd.Flags().BoolVar(&wtFetchPrune, "prune", false,
+		"Remove remote-tracking refs that no longer exist on the remote")
+	_ = wtFetchCmd.RegisterFlagCompletionFunc("remote", cobra.FixedCompletions(
+		[]string{"origin", "upstream", worktree.FetchAllRemotes}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runWorktreeFetch(wtClient *worktree.Client) error {
+	result, err := wtClient.Fetch(worktree.FetchOptions{
+		Remote:   wtFetchRemote,
+		Prune:    wtFetchPrune,
+		Progress: errWriter(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if outputFormat() == "json" {
+		return printJSON(outWriter(), result)
+	}
+	printFetchResult(outWriter(), result)
+	return nil
+}
+
+func printFetchResult(w io.Writer, result worktree.FetchResult) {
+	fmt.Fprintf(w, "Fetched %s\n", strings.Join(result.Remotes, ", "))
+	if len(result.Updated) == 0 {
+		fmt.Fprintln(w, "Already up to date.")
+		return
+	}
+	for _, update := range result.Updated {
+		name := strings.TrimPrefix(strings.TrimPrefix(update.Ref, "refs/remotes/"), "refs/")
+		oldHash := stringsutil.ShortHash(update.Old, 7, "")
+		newHash := stringsutil.ShortHash(update.New, 7, "")
+		switch update.Status {
+		case worktree.RefNew:
+			fmt.Fprintf(w, "  %-8s %s (%s)\n", update.Status, name, newHash)
+		case worktree.RefDeleted:
+			fmt.Fprintf(w, "  %-8s %s (was %s)\n", update.Status, name, oldHash)
+		default:
+			fmt.Fprintf(w, "  %-8s %s (%s..%s)\n", update.Status, name, oldHash, newHash)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wtLockReason string
+	wtNoteClear  bool
+)
+
+var wtLockCmd = &cobra.Command{
+	Use:   "lock <name>",
+	Short: "Lock a worktree so it is not removed or pruned",
+	Long: `Lock a worktree with git worktree lock.
+
+A locked worktree is kept by 'gmc wt rm', 'gmc wt prune' and git worktree
+prune until it is unlocked. 'gmc wt ls' shows the lock and its reason.`,
+	Example: `  gmc wt lock .dup-1
+  gmc wt lock .dup-2 --reason "agent still running"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	RunE: func(_ *cobra.Command, args []string) error {
+		report, err := newWorktreeClient().Lock(args[0], wtLockReason)
+		printWorktreeReport(report)
+		return err
+	},
+}
+
+var wtUnlockCmd = &cobra.Command{
+	Use:               "unlock <name>",
+	Short:             "Unlock a locked worktree",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	RunE: func(_ *cobra.Command, args []string) error {
+		report, err := newWorktreeClient().Unlock(args[0])
+		printWorktreeReport(report)
+		return err
+	},
+}
+
+var wtNoteCmd = &cobra.Command{
+	Use:   "note <name> [text...]",
+	Short: "Show or set a note on a worktree",
+	Long: `Show or set a free-form note on a worktree, such as what a .dup-N
+candidate is experimenting with. 'gmc wt ls' shows the notes.
+
+The note is kept in the worktree's git directory, so it is removed with
+the worktree.`,
+	Example: `  gmc wt note .dup-1 "trying a redis cache"
+  gmc wt note .dup-1
+  gmc wt note .dup-1 --clear`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeNames(cmd, args, toComplete)
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		return runWorktreeNote(newWorktreeClient(), args[0], strings.Join(args[1:], " "))
+	},
+}
+
+func init() {
+	wtCmd.AddCommand(wtLockCmd)
+	wtCmd.AddCommand(wtUnlockCmd)
+	wtCmd.AddCommand(wtNoteCmd)
+	wtLockCmd.Flags().StringVar(&wtLockReason, "reason", "", "Why the worktree is locked, shown by gmc wt ls")
+	wtNoteCmd.Flags().BoolVar(&wtNoteClear, "clear", false, "Remove the note")
+}
+
+func runWorktreeNote(wtClient *worktree.Client, name, text string) error {
+	if wtNoteClear && text != "" {
+		return errors.New("--clear cannot be combined with a note")
+	}
+	if text == "" && !wtNoteClear {
+		note, err := wtClient.NoteFor(name)
+		if err != nil {
+			return err
+		}
+		if note == "" {
+			fmt.Fprintf(errWriter(), "No note on '%s'.\n", name)
+			return nil
+		}
+		fmt.Fprintln(outWriter(), note)
+		return nil
+	}
+
+	report, err := wtClient.SetNote(name, text)
+	printWorktreeReport(report)
+	return err
+}
+
+// formatWorktreeLock returns the lock shown in the status column of gmc wt ls.
+func formatWorktreeLock(wt worktree.Info) string {
+	if !wt.IsLocked {
+		return ""
+	}
+	if wt.LockReason == "" {
+		return "locked"
+	}
+	return fmt.Sprintf("locked (%s)", wt.LockReason)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var wtMergeCheckBase string
+
+var wtMergeCheckCmd = &cobra.Command{
+	Use:   "mergecheck",
+	Short: "Predict conflicts between worktree branches",
+	Long: `Predict which worktree branches would conflict when merged, with
+git merge-tree, before merging any of them. Every branch is checked against
+the base branch and against every other branch, so parallel candidates such
+as .dup-N branches can be compared before picking which ones to merge.
+
+The matrix shows "ok" for pairs that merge cleanly and the number of
+conflicting files otherwise; the files are listed below it. No worktree is
+changed. Requires git 2.38 or later.`,
+	Example: `  gmc wt mergecheck
+  gmc wt mergecheck -b release
+  gmc wt mergecheck -o json`,
+	Args:              cobra.NoArgs,
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		check, err := newWorktreeClient().MergeCheck(worktree.MergeCheckOptions{BaseBranch: wtMergeCheckBase})
+		if err != nil {
+			return err
+		}
+		if outputFormat() == "json" {
+			return printJSON(outWriter(), check)
+		}
+		printMergeCheck(outWriter(), check)
+		return nil
+	},
+}
+
+func init() {
+	wtCmd.AddCommand(wtMergeCheckCmd)
+	wtMergeCheckCmd.Flags().StringVarP(&wtMergeCheckBase, "base", "b", "",
+		"Base branch to check against (default: the synced base branch)")
+	_ = wtMergeCheckCmd.RegisterFlagCompletionFunc("base", completeBranchNames)
+}
+
+func printMergeCheck(w io.Writer, check worktree.MergeCheck) {
+	names := append([]string{check.Base}, check.Branches...)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\t"+strings.Join(names, "\t"))
+	for _, a := range names {
+		row := []string{a}
+		for _, b := range names {
+			row = append(row, mergeCheckCell(check, a, b))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	_ = tw.Flush()
+
+	var conflicting []worktree.MergePair
+	for _, pair := range check.Pairs {
+		if !pair.Clean() {
+			conflicting = append(conflicting, pair)
+		}
+	}
+	fmt.Fprintln(w)
+	if len(conflicting) == 0 {
+		fmt.Fprintf(w, "All %d branch(es) merge cleanly with %s and with each other.\n", len(check.Branches), check.Base)
+		return
+	}
+	fmt.Fprintln(w, "Conflicts:")
+	for _, pair := range conflicting {
+		fmt.Fprintf(w, "  %s <> %s: %s\n", pair.A, pair.B, strings.Join(pair.Conflicts, ", "))
+	}
+}
+
+func mergeCheckCell(check worktree.MergeCheck, a, b string) string {
+	if a == b {
+		return "-"
+	}
+	pair, ok := check.Pair(a, b)
+	if !ok {
+		return "?"
+	}
+	if pair.Clean() {
+		return "ok"
+	}
+	return strconv.Itoa(len(pair.Conflicts))
+}
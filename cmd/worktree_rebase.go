@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wtRebaseBase      string
+	wtRebaseAutostash bool
+)
+
+var wtRebaseCmd = &cobra.Command{
+	Use:   "rebase <worktree>",
+	Short: "Rebase a worktree's branch onto the base branch",
+	Long: `Rebase the branch of a worktree onto the base branch, the one 'gmc wt sync'
+updates, so updating main and rebasing a feature on it takes two commands:
+
+  gmc wt sync
+  gmc wt rebase feature-x
+
+A worktree with uncommitted changes is refused; use --autostash to stash
+them before the rebase and restore them after. When the rebase stops on
+conflicts, the conflicting files are listed and the rebase is left in
+progress in the worktree, to continue or abort there.`,
+	Example: `  gmc wt rebase feature-x
+  gmc wt rebase .dup-2 --autostash
+  gmc wt rebase feature-x -b release`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeNames(cmd, args, toComplete)
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		report, err := newWorktreeClient().Rebase(args[0], worktree.RebaseOptions{
+			BaseBranch: wtRebaseBase,
+			Autostash:  wtRebaseAutostash,
+		})
+		printWorktreeReport(report)
+		return err
+	},
+}
+
+func init() {
+	wtCmd.AddCommand(wtRebaseCmd)
+	wtRebaseCmd.Flags().StringVarP(&wtRebaseBase, "base", "b", "",
+		"Branch to rebase onto (default: the synced base branch)")
+	wtRebaseCmd.Flags().BoolVar(&wtRebaseAutostash, "autostash", false,
+		"Stash uncommitted changes before the rebase and restore them after")
+	_ = wtRebaseCmd.RegisterFlagCompletionFunc("base", completeBranchNames)
+}
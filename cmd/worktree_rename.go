@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var wtRenameWithBranch bool
+
+var wtRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a worktree and optionally its branch",
+	Long: `Rename a worktree with git worktree move, so that git's pointers between
+the worktree and the repository stay valid. Renaming the directory by hand
+breaks them.
+
+The new directory is the one 'gmc wt add <new>' would create. Shared
+resources are synced again into it. Use --with-branch to rename the
+worktree's branch to <new> as well.`,
+	Example: `  gmc wt rename .dup-1 redis-cache
+  gmc wt rename feature-x feature-login --with-branch`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeNames(cmd, args, toComplete)
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		report, err := newWorktreeClient().Rename(args[0], args[1], worktree.RenameOptions{
+			WithBranch: wtRenameWithBranch,
+		})
+		printWorktreeReport(report)
+		return err
+	},
+}
+
+func init() {
+	wtCmd.AddCommand(wtRenameCmd)
+	wtRenameCmd.Flags().BoolVar(&wtRenameWithBranch, "with-branch", false, "Also rename the branch to the new name")
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +15,7 @@ import (
 var (
 	shareStrategy string
 	discoverAuto  bool
+	shareFix      bool
 )
 
 var wtShareCmd = &cobra.Command{
@@ -76,8 +78,11 @@ var wtShareRemoveCmd = &cobra.Command{
 }
 
 type ShareJSON struct {
-	Path     string `json:"path"`
-	Strategy string `json:"strategy"`
+	Path      string `json:"path"`
+	Strategy  string `json:"strategy"`
+	MaxSize   string `json:"max_size,omitempty"`
+	Render    bool   `json:"render,omitempty"`
+	Overwrite string `json:"overwrite,omitempty"`
 }
 
 var wtShareListCmd = &cobra.Command{
@@ -94,7 +99,10 @@ var wtShareListCmd = &cobra.Command{
 		if outputFormat() == "json" {
 			items := make([]ShareJSON, len(cfg.Resources))
 			for i, res := range cfg.Resources {
-				items[i] = ShareJSON{Path: res.Path, Strategy: string(res.Strategy)}
+				items[i] = ShareJSON{
+					Path: res.Path, Strategy: string(res.Strategy), MaxSize: res.MaxSize, Render: res.Render,
+					Overwrite: string(res.Overwrite),
+				}
 			}
 			return printJSON(outWriter(), items)
 		}
@@ -106,7 +114,20 @@ var wtShareListCmd = &cobra.Command{
 
 		fmt.Println("Shared Resources:")
 		for _, res := range cfg.Resources {
-			fmt.Printf("  - %s (%s)\n", res.Path, res.Strategy)
+			details := string(res.Strategy)
+			if res.Render {
+				details += ", render"
+			}
+			if res.MaxSize != "" {
+				details += ", max_size " + res.MaxSize
+			}
+			if res.Overwrite != "" {
+				details += ", overwrite " + string(res.Overwrite)
+			}
+			fmt.Printf("  - %s (%s)\n", res.Path, details)
+		}
+		if len(cfg.Ignore) > 0 {
+			fmt.Printf("Ignore: %s\n", strings.Join(cfg.Ignore, ", "))
 		}
 		return nil
 	},
@@ -183,6 +204,22 @@ Use --auto to actually add discovered files and sync them.`,
 var wtShareSyncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Manually sync shared resources to all worktrees",
+	Long: `Sync shared resources to all worktrees.
+
+Copied directories skip entries matching the global 'ignore:' glob patterns, and
+files larger than a resource's 'max_size' are skipped. Skipped items are listed
+after the sync.
+
+On a terminal, each copied file is shown with its size and the running total.
+Directories are copied to <name>.gmc-partial and renamed when complete, so an
+interrupted sync is resumed by running it again. Use --parallel to copy several
+files at once.
+
+A resource that already exists in a worktree is kept, unless its 'overwrite'
+policy is 'always', or 'prompt' and you confirm. --force overwrites every
+resource that differs from its source. A replaced file or directory is moved
+to <name>.bak first.`,
+	Args: cobra.NoArgs,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		wtClient := newWorktreeClient()
 		report, err := wtClient.SyncAllSharedResources()
@@ -191,6 +228,31 @@ var wtShareSyncCmd = &cobra.Command{
 	},
 }
 
+var wtShareStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Compare shared resources with their sources",
+	Long: `Check each worktree's copy or link of every shared resource against its source.
+
+Copies are compared by content, and rendered files with their template rendered
+for the worktree. Links must point to the source. Each entry is:
+
+  ok       up to date
+  missing  not in the worktree
+  stale    differs from the source, or is a copy where a link is configured
+           (or the reverse)
+  broken   a link that is dangling or points elsewhere
+
+Use --fix to repair every entry that is not ok. A stale file is moved to
+<name>.bak before it is replaced.`,
+	Example: `  gmc wt share status
+  gmc wt share status --fix
+  gmc wt share status -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runWorktreeShareStatus(newWorktreeClient())
+	},
+}
+
 func init() {
 	wtCmd.AddCommand(wtShareCmd)
 	wtShareCmd.AddCommand(wtShareAddCmd)
@@ -198,14 +260,73 @@ func init() {
 	wtShareCmd.AddCommand(wtShareListCmd)
 	wtShareCmd.AddCommand(wtShareSyncCmd)
 	wtShareCmd.AddCommand(wtShareDiscoverCmd)
+	wtShareCmd.AddCommand(wtShareStatusCmd)
 
 	wtShareAddCmd.Flags().StringVarP(&shareStrategy, "strategy", "s", "copy", "Sync strategy: copy or link")
 	_ = wtShareAddCmd.RegisterFlagCompletionFunc("strategy", completeStrategies)
 
+	wtShareSyncCmd.Flags().IntVar(&wtShareParallel, "parallel", 1, "Number of files to copy at once")
+	wtShareSyncCmd.Flags().BoolVar(&wtShareForce, "force", false,
+		"Overwrite resources that differ from the source, keeping a .bak backup")
+	wtShareStatusCmd.Flags().BoolVar(&shareFix, "fix", false, "Repair missing, stale and broken entries")
+
 	wtShareDiscoverCmd.Flags().BoolVar(&discoverAuto, "auto", false, "Actually add discovered items and sync")
 	wtShareDiscoverCmd.Flags().Bool("dry-run", true, "Preview mode (default behavior)")
 }
 
+func runWorktreeShareStatus(wtClient *worktree.Client) error {
+	entries, err := wtClient.SharedStatus()
+	if err != nil {
+		return err
+	}
+	if shareFix {
+		report, err := wtClient.FixShared(entries)
+		printWorktreeReport(report)
+		if err != nil {
+			return err
+		}
+		if entries, err = wtClient.SharedStatus(); err != nil {
+			return err
+		}
+	}
+
+	if outputFormat() == "json" {
+		if entries == nil {
+			entries = []worktree.ShareEntry{}
+		}
+		return printJSON(outWriter(), entries)
+	}
+	printShareStatus(outWriter(), entries)
+	return nil
+}
+
+func printShareStatus(w io.Writer, entries []worktree.ShareEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No shared resources to check.")
+		return
+	}
+
+	maxWorktree, maxPath := len("WORKTREE"), len("PATH")
+	for _, entry := range entries {
+		maxWorktree = max(maxWorktree, len(entry.Worktree))
+		maxPath = max(maxPath, len(entry.Path))
+	}
+	fmt.Fprintf(w, "%-*s  %-*s  %-8s %s\n", maxWorktree, "WORKTREE", maxPath, "PATH", "STATE", "DETAIL")
+	problems := 0
+	for _, entry := range entries {
+		if entry.State != worktree.ShareOK {
+			problems++
+		}
+		line := fmt.Sprintf("%-*s  %-*s  %-8s %s", maxWorktree, entry.Worktree, maxPath, entry.Path,
+			entry.State, entry.Detail)
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	if problems > 0 {
+		fmt.Fprintf(w, "\n%d of %d entries need attention; run 'gmc wt share status --fix' to repair them.\n",
+			problems, len(entries))
+	}
+}
+
 func runWorktreeShareInteractive(c *worktree.Client) error {
 	reader := bufio.NewReader(os.Stdin)
 
@@ -263,7 +384,7 @@ func promptAddResource(c *worktree.Client, reader *bufio.Reader) {
 	root, _ := c.GetWorktreeRoot()
 
 	// Detect current worktree
-	cwd, _ := os.Getwd()
+	cwd, _ := workingDir()
 	currentWorktree := ""
 	if strings.HasPrefix(cwd, root) {
 		rel, _ := filepath.Rel(root, cwd)
@@ -1,6 +1,12 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samzong/gmc/internal/stringsutil"
 	"github.com/samzong/gmc/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -9,6 +15,7 @@ var (
 	wtAddSync    bool
 	wtSyncBase   string
 	wtSyncDryRun bool
+	wtSyncAll    bool
 )
 
 var wtSyncCmd = &cobra.Command{
@@ -17,7 +24,15 @@ var wtSyncCmd = &cobra.Command{
 	Long: `Sync the base branch used for worktrees.
 
 This updates the base branch using fast-forward only and optionally
-updates the base worktree when it's clean.`,
+updates the base worktree when it's clean.
+
+With --all, every remote is fetched and each worktree's branch is
+fast-forwarded to its upstream. Worktrees with uncommitted changes, without
+an upstream, or whose branch has diverged are left alone, and a table shows
+what happened to each one.`,
+	Example: `  gmc wt sync
+  gmc wt sync --all
+  gmc wt sync --all --dry-run -o json`,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		wtClient := newWorktreeClient()
 		return runWorktreeSync(wtClient)
@@ -29,6 +44,8 @@ func init() {
 	wtAddCmd.Flags().BoolVar(&wtAddSync, "sync", false, "Sync base branch before creating worktree")
 	wtSyncCmd.Flags().StringVarP(&wtSyncBase, "base", "b", "", "Base branch to sync")
 	wtSyncCmd.Flags().BoolVar(&wtSyncDryRun, "dry-run", false, "Preview what would be updated without making changes")
+	wtSyncCmd.Flags().BoolVarP(&wtSyncAll, "all", "a", false, "Fast-forward the branch of every worktree to its upstream")
+	wtSyncCmd.MarkFlagsMutuallyExclusive("all", "base")
 
 	_ = wtSyncCmd.RegisterFlagCompletionFunc("base", completeBranchNames)
 }
@@ -38,7 +55,60 @@ func runWorktreeSync(wtClient *worktree.Client) error {
 		BaseBranch: wtSyncBase,
 		DryRun:     wtSyncDryRun,
 	}
+	if wtSyncAll {
+		return runWorktreeSyncAll(wtClient, opts)
+	}
 	report, err := wtClient.Sync(opts)
 	printWorktreeReport(report)
 	return err
 }
+
+func runWorktreeSyncAll(wtClient *worktree.Client, opts worktree.SyncOptions) error {
+	if !opts.DryRun {
+		fmt.Fprintln(errWriter(), "Fetching all remotes...")
+	}
+	results, err := wtClient.SyncAll(opts)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat() == "json" {
+		if err := printJSON(outWriter(), results); err != nil {
+			return err
+		}
+	} else {
+		printSyncResults(outWriter(), results, getDisplayRoot(wtClient))
+	}
+
+	var failed []string
+	for _, result := range results {
+		if result.Status == worktree.SyncFailed {
+			failed = append(failed, result.Worktree)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to sync %d of %d worktrees: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func printSyncResults(w io.Writer, results []worktree.SyncResult, displayRoot string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tBRANCH\tUPSTREAM\tSTATUS\tDETAIL")
+	for _, r := range results {
+		detail := r.Error
+		if r.New != "" {
+			detail = stringsutil.ShortHash(r.Old, 7, "none") + ".." + stringsutil.ShortHash(r.New, 7, "")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", displayWorktreeName(displayRoot, r.Path),
+			dashIfEmpty(r.Branch), dashIfEmpty(r.Upstream), r.Status, detail)
+	}
+	_ = tw.Flush()
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -272,3 +273,109 @@ func initCmdPRRemote(t *testing.T, prNumber int) string {
 	runGitCmd(t, remoteDir, "update-ref", fmt.Sprintf("refs/pull/%d/head", prNumber), "HEAD")
 	return remoteDir
 }
+
+func TestTruncateProgress(t *testing.T) {
+	assert.Equal(t, "[1/2] short", truncateProgress("[1/2] short", 20))
+	assert.Equal(t, "...c/d/file.go (1 B)", truncateProgress("[1/2] 1 B / 2 B  a/b/c/d/file.go (1 B)", 20))
+	assert.Nil(t, copyProgressPrinter(&bytes.Buffer{}), "no redraw outside a terminal")
+}
+
+func TestPrintFetchResult(t *testing.T) {
+	var out bytes.Buffer
+	printFetchResult(&out, worktree.FetchResult{
+		Remotes: []string{"origin", "upstream"},
+		Updated: []worktree.RefUpdate{
+			{Ref: "refs/remotes/origin/feature", Status: worktree.RefNew, New: "1111111aaaa"},
+			{Ref: "refs/remotes/upstream/main", Status: worktree.RefUpdated, Old: "2222222bbbb", New: "3333333cccc"},
+			{Ref: "refs/tags/v1.2.0", Status: worktree.RefDeleted, Old: "4444444dddd"},
+		},
+	})
+	assert.Equal(t, "Fetched origin, upstream\n"+
+		"  new      origin/feature (1111111)\n"+
+		"  updated  upstream/main (2222222..3333333)\n"+
+		"  deleted  tags/v1.2.0 (was 4444444)\n", out.String())
+
+	out.Reset()
+	printFetchResult(&out, worktree.FetchResult{Remotes: []string{"origin"}})
+	assert.Equal(t, "Fetched origin\nAlready up to date.\n", out.String())
+}
+
+func TestPrintMergeCheck(t *testing.T) {
+	var out bytes.Buffer
+	printMergeCheck(&out, worktree.MergeCheck{
+		Base:     "main",
+		Branches: []string{".dup-1", ".dup-2"},
+		Pairs: []worktree.MergePair{
+			{A: "main", B: ".dup-1", Conflicts: []string{}},
+			{A: "main", B: ".dup-2", Conflicts: []string{}},
+			{A: ".dup-1", B: ".dup-2", Conflicts: []string{"go.sum", "api.go"}},
+		},
+	})
+	assert.Equal(t, "        main  .dup-1  .dup-2\n"+
+		"main    -     ok      ok\n"+
+		".dup-1  ok    -       2\n"+
+		".dup-2  ok    2       -\n"+
+		"\n"+
+		"Conflicts:\n"+
+		"  .dup-1 <> .dup-2: go.sum, api.go\n", out.String())
+}
+
+func TestFormatWorktreeLock(t *testing.T) {
+	assert.Empty(t, formatWorktreeLock(worktree.Info{}))
+	assert.Equal(t, "locked", formatWorktreeLock(worktree.Info{IsLocked: true}))
+	assert.Equal(t, "locked (agent running)",
+		formatWorktreeLock(worktree.Info{IsLocked: true, LockReason: "agent running"}))
+}
+
+func TestRunWorktreeNoteRejectsClearWithText(t *testing.T) {
+	wtNoteClear = true
+	defer func() { wtNoteClear = false }()
+
+	err := runWorktreeNote(worktree.NewClient(worktree.Options{}), ".dup-1", "trying redis")
+	assert.EqualError(t, err, "--clear cannot be combined with a note")
+}
+
+func TestRunWorktreeClean(t *testing.T) {
+	repoDir := initCmdTestRepo(t)
+	merged := filepath.Join(repoDir, "merged")
+	runGitCmd(t, repoDir, "worktree", "add", "-b", "merged", merged, "main")
+
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(oldCwd) }()
+	require.NoError(t, os.Chdir(repoDir))
+
+	var out, errOut bytes.Buffer
+	withWriters(t, &out, &errOut)
+	withOutputFormat(t, "json")
+	oldIsStdinTerminal := isStdinTerminal
+	defer func(dryRun, yes bool) {
+		isStdinTerminal = oldIsStdinTerminal
+		wtCleanDryRun, wtCleanYes = dryRun, yes
+	}(wtCleanDryRun, wtCleanYes)
+
+	wtCleanDryRun = true
+	client := worktree.NewClient(worktree.Options{})
+	require.NoError(t, runWorktreeClean(client, bytes.NewBufferString("")))
+	var dryRun WorktreeCleanJSON
+	require.NoError(t, json.Unmarshal(out.Bytes(), &dryRun))
+	require.Len(t, dryRun.Candidates, 1)
+	assert.Equal(t, "merged", dryRun.Candidates[0].Branch)
+	assert.Equal(t, worktree.CleanMerged, dryRun.Candidates[0].Reason)
+	assert.Empty(t, dryRun.Removed)
+
+	wtCleanDryRun = false
+	isStdinTerminal = func() bool { return false }
+	require.EqualError(t, runWorktreeClean(client, bytes.NewBufferString("")),
+		"stdin is not a terminal, use --yes to remove the worktrees")
+
+	out.Reset()
+	isStdinTerminal = func() bool { return true }
+	require.NoError(t, runWorktreeClean(client, bytes.NewBufferString("y\n")))
+	var cleaned WorktreeCleanJSON
+	require.NoError(t, json.Unmarshal(out.Bytes(), &cleaned))
+	require.Len(t, cleaned.Removed, 1)
+	_, err = os.Stat(merged)
+	assert.True(t, os.IsNotExist(err), "the merged worktree should be removed")
+	assert.Contains(t, errOut.String(), "Remove 1 worktree(s) and delete their branches? [y/N]: ")
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/samzong/gmc/internal/git"
+)
+
+// NormalizeAuthors returns commits with Author set to the identity the report groups
+// them by: the author name, or the email when byEmail is set. When aliases maps the
+// email, or the name unless byEmail is set, matched case-insensitively, the mapped
+// value is used instead, so one person's identities add up to one author.
+func NormalizeAuthors(commits []git.CommitInfo, aliases map[string]string, byEmail bool) []git.CommitInfo {
+	lookup := make(map[string]string, len(aliases))
+	for alias, canonical := range aliases {
+		if canonical = strings.TrimSpace(canonical); canonical != "" {
+			lookup[strings.ToLower(strings.TrimSpace(alias))] = canonical
+		}
+	}
+
+	normalized := make([]git.CommitInfo, len(commits))
+	for i, commit := range commits {
+		author := commit.Author
+		identities := []string{commit.Email, commit.Author}
+		if byEmail && commit.Email != "" {
+			author = commit.Email
+			identities = identities[:1]
+		}
+		for _, identity := range identities {
+			if canonical, ok := lookup[strings.ToLower(identity)]; ok && identity != "" {
+				author = canonical
+				break
+			}
+		}
+		commit.Author = author
+		normalized[i] = commit
+	}
+	return normalized
+}
@@ -0,0 +1,36 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAuthors(t *testing.T) {
+	commits := []git.CommitInfo{
+		{Hash: "a1", Author: "Jane Doe", Email: "jane@example.com", Message: "feat: add login"},
+		{Hash: "b2", Author: "jd", Email: "JD@old.example", Message: "fix: handle timeouts"},
+		{Hash: "c3", Author: "Jane", Email: "jane@example.com", Message: "docs: explain login"},
+		{Hash: "d4", Author: "Bob", Email: "bob@example.com", Message: "chore: bump deps"},
+	}
+	// Viper lowers the keys of config maps.
+	aliases := map[string]string{"jd@old.example": "Jane Doe", "jane": "Jane Doe", "bob": " "}
+
+	authors := func(commits []git.CommitInfo) []string {
+		names := make([]string, len(commits))
+		for i, commit := range commits {
+			names[i] = commit.Author
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Jane Doe", "Jane Doe", "Jane Doe", "Bob"},
+		authors(NormalizeAuthors(commits, aliases, false)))
+	assert.Equal(t, []string{"jane@example.com", "Jane Doe", "jane@example.com", "bob@example.com"},
+		authors(NormalizeAuthors(commits, aliases, true)))
+	assert.Equal(t, "jd", commits[1].Author, "the input is left alone")
+
+	report := BuildReport(NormalizeAuthors(commits, aliases, false), ScoreCommits(commits, nil))
+	assert.Equal(t, "Jane Doe", report.Authors[0].Author)
+	assert.Equal(t, 3, report.Authors[0].Commits)
+}
@@ -0,0 +1,151 @@
+// Package analyzer inspects diffs and commit history for signals gmc surfaces to users.
+package analyzer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// BreakingKind identifies the kind of API break found in a diff.
+type BreakingKind string
+
+const (
+	BreakingRemovedFunc      BreakingKind = "removed-func"
+	BreakingRemovedConfigKey BreakingKind = "removed-config-key"
+)
+
+// BreakingChange is a removal in a diff that likely breaks callers or users.
+type BreakingChange struct {
+	Kind BreakingKind
+	File string
+	Name string
+}
+
+func (c BreakingChange) String() string {
+	switch c.Kind {
+	case BreakingRemovedFunc:
+		return fmt.Sprintf("removed exported func %s (%s)", c.Name, c.File)
+	case BreakingRemovedConfigKey:
+		return fmt.Sprintf("removed config key %s (%s)", c.Name, c.File)
+	default:
+		return fmt.Sprintf("%s %s (%s)", c.Kind, c.Name, c.File)
+	}
+}
+
+var (
+	diffHeaderPattern = regexp.MustCompile(`^diff --git a/(\S+) b/(\S+)`)
+	goFuncPattern     = regexp.MustCompile(`^func\s+(?:\(\s*(?:\w+\s+)?\*?\s*(\w+)[^)]*\)\s*)?(\w+)\s*[\[(]`)
+	configKeyPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`mapstructure:"([^",]+)`),
+		regexp.MustCompile(`viper\.SetDefault\(\s*"([^"]+)"`),
+	}
+)
+
+type diffLine struct {
+	file string
+	text string
+}
+
+// DetectBreakingChanges reports exported Go functions and config keys that a unified
+// diff removes without adding back. Functions are matched per package directory, so
+// moving a function between files of one package is not reported. Test files are ignored.
+func DetectBreakingChanges(diff string) []BreakingChange {
+	removed, added := splitGoChanges(diff)
+
+	addedFuncs := make(map[string]bool)
+	addedKeys := make(map[string]bool)
+	for _, line := range added {
+		if name := exportedFunc(line.text); name != "" {
+			addedFuncs[path.Dir(line.file)+":"+name] = true
+		}
+		for _, key := range configKeys(line.text) {
+			addedKeys[key] = true
+		}
+	}
+
+	var changes []BreakingChange
+	seen := make(map[string]bool)
+	report := func(change BreakingChange) {
+		id := string(change.Kind) + ":" + change.Name
+		if !seen[id] {
+			seen[id] = true
+			changes = append(changes, change)
+		}
+	}
+	for _, line := range removed {
+		if name := exportedFunc(line.text); name != "" && !addedFuncs[path.Dir(line.file)+":"+name] {
+			report(BreakingChange{Kind: BreakingRemovedFunc, File: line.file, Name: name})
+		}
+		for _, key := range configKeys(line.text) {
+			if !addedKeys[key] {
+				report(BreakingChange{Kind: BreakingRemovedConfigKey, File: line.file, Name: key})
+			}
+		}
+	}
+	return changes
+}
+
+// splitGoChanges collects removed and added lines of non-test Go files.
+func splitGoChanges(diff string) (removed, added []diffLine) {
+	file := ""
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		if m := diffHeaderPattern.FindStringSubmatch(line); m != nil {
+			file = m[2]
+			if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+				file = ""
+			}
+			inHunk = false
+			continue
+		}
+		if strings.HasPrefix(line, "@@") {
+			inHunk = true
+			continue
+		}
+		if file == "" || !inHunk || line == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '-':
+			removed = append(removed, diffLine{file: file, text: strings.TrimSpace(line[1:])})
+		case '+':
+			added = append(added, diffLine{file: file, text: strings.TrimSpace(line[1:])})
+		}
+	}
+	return removed, added
+}
+
+// exportedFunc returns "Name" or "Type.Name" for exported functions and methods on
+// exported types, and "" for anything else.
+func exportedFunc(line string) string {
+	m := goFuncPattern.FindStringSubmatch(line)
+	if m == nil || !isExported(m[2]) {
+		return ""
+	}
+	if m[1] == "" {
+		return m[2]
+	}
+	if !isExported(m[1]) {
+		return ""
+	}
+	return m[1] + "." + m[2]
+}
+
+func configKeys(line string) []string {
+	var keys []string
+	for _, pattern := range configKeyPatterns {
+		for _, m := range pattern.FindAllStringSubmatch(line, -1) {
+			if key := strings.TrimSpace(m[1]); key != "" && key != "-" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func isExported(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
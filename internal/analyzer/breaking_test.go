@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectBreakingChanges(t *testing.T) {
+	diff := `diff --git a/internal/config/config.go b/internal/config/config.go
+index 1111111..2222222 100644
+--- a/internal/config/config.go
++++ b/internal/config/config.go
+@@ -10,12 +10,10 @@
+ type Config struct {
+-	Role  string ` + "`mapstructure:\"role\"`" + `
+-	Model string ` + "`mapstructure:\"model\"`" + `
++	Model string ` + "`mapstructure:\"model\" yaml:\"model\"`" + `
+ }
+-func GetConfig() *Config {
+-func (c *Config) Validate() error {
+-func (c *cache) Flush() {
+-func helper() {
+ func Keep() {
+diff --git a/internal/config/load.go b/internal/config/load.go
+--- a/internal/config/load.go
++++ b/internal/config/load.go
+@@ -1,3 +1,3 @@
+-	viper.SetDefault("enable_emoji", false)
++func GetConfig() *Config {
+diff --git a/internal/config/config_test.go b/internal/config/config_test.go
+--- a/internal/config/config_test.go
++++ b/internal/config/config_test.go
+@@ -1,2 +1,1 @@
+-func TestRemoved(t *testing.T) {
+diff --git a/docs/api.md b/docs/api.md
+--- a/docs/api.md
++++ b/docs/api.md
+@@ -1,2 +1,1 @@
+-func Documented() {
+`
+
+	changes := DetectBreakingChanges(diff)
+	assert.Equal(t, []BreakingChange{
+		{Kind: BreakingRemovedConfigKey, File: "internal/config/config.go", Name: "role"},
+		{Kind: BreakingRemovedFunc, File: "internal/config/config.go", Name: "Config.Validate"},
+		{Kind: BreakingRemovedConfigKey, File: "internal/config/load.go", Name: "enable_emoji"},
+	}, changes)
+	assert.Equal(t, "removed exported func Config.Validate (internal/config/config.go)", changes[1].String())
+	assert.Equal(t, "removed config key role (internal/config/config.go)", changes[0].String())
+}
+
+func TestDetectBreakingChanges_MovedAcrossPackages(t *testing.T) {
+	diff := `diff --git a/a/x.go b/a/x.go
+--- a/a/x.go
++++ b/a/x.go
+@@ -1,1 +0,0 @@
+-func Parse[T any](v T) error {
+diff --git a/b/x.go b/b/x.go
+--- a/b/x.go
++++ b/b/x.go
+@@ -0,0 +1,1 @@
++func Parse[T any](v T) error {
+`
+
+	assert.Equal(t, []BreakingChange{
+		{Kind: BreakingRemovedFunc, File: "a/x.go", Name: "Parse"},
+	}, DetectBreakingChanges(diff))
+}
+
+func TestDetectBreakingChanges_None(t *testing.T) {
+	assert.Empty(t, DetectBreakingChanges(""))
+	assert.Empty(t, DetectBreakingChanges("diff --git a/x.go b/x.go\n@@ -1 +1 @@\n+func New() {\n"))
+}
@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/samzong/gmc/internal/git"
+)
+
+// QualityCache stores commit scores by hash under <git-common-dir>/gmc/cache, so
+// repeated analyses only score commits they have not seen before.
+type QualityCache struct {
+	path   string
+	scores map[string]QualityScore
+	dirty  bool
+}
+
+type qualityCacheFile struct {
+	Version int                     `json:"version"`
+	Scores  map[string]QualityScore `json:"scores"`
+}
+
+// QualityCachePath returns the cache file location for a git common directory.
+func QualityCachePath(gitDir string) string {
+	return filepath.Join(gitDir, "gmc", "cache", "quality.json")
+}
+
+// LoadQualityCache reads the cache for gitDir. A missing, unreadable, or outdated
+// cache file yields an empty cache: it is only an optimization.
+func LoadQualityCache(gitDir string) *QualityCache {
+	cache := &QualityCache{path: QualityCachePath(gitDir), scores: make(map[string]QualityScore)}
+
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+	var file qualityCacheFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != scoringVersion || file.Scores == nil {
+		return cache
+	}
+	cache.scores = file.Scores
+	return cache
+}
+
+// Save writes the cache when new scores were added.
+func (c *QualityCache) Save() error {
+	if c == nil || !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(qualityCacheFile{Version: scoringVersion, Scores: c.scores})
+	if err != nil {
+		return fmt.Errorf("failed to encode quality cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write quality cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write quality cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// ScoreCommits scores commits in order, reusing cached scores. cache may be nil.
+func ScoreCommits(commits []git.CommitInfo, cache *QualityCache) []QualityScore {
+	scores := make([]QualityScore, 0, len(commits))
+	for _, commit := range commits {
+		if cache != nil {
+			if cached, ok := cache.scores[commit.Hash]; ok && commit.Hash != "" {
+				scores = append(scores, cached)
+				continue
+			}
+		}
+
+		score := ScoreCommit(commit)
+		if cache != nil && commit.Hash != "" {
+			cache.scores[commit.Hash] = score
+			cache.dirty = true
+		}
+		scores = append(scores, score)
+	}
+	return scores
+}
@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/samzong/gmc/internal/git"
+)
+
+// ErrBelowMinScore is returned when the average score of the analyzed commits is below
+// the gmc stats --min-score threshold.
+var ErrBelowMinScore = errors.New("commit quality is below the minimum score")
+
+// WriteCSV writes one row per commit, in the order given: hash, author, date, type,
+// score and the issues separated by "; ". commits and scores must be in the same order.
+func WriteCSV(w io.Writer, commits []git.CommitInfo, scores []QualityScore) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"hash", "author", "date", "type", "score", "issues"})
+	for i, commit := range commits {
+		if i >= len(scores) {
+			break
+		}
+		score := scores[i]
+		_ = writer.Write([]string{
+			commit.Hash, commit.Author, commit.Date, score.Type,
+			strconv.Itoa(score.Score), strings.Join(score.Issues, "; "),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteTrendCSV writes one row per period of the trend, oldest first.
+func WriteTrendCSV(w io.Writer, trend Trend) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"period", "start", "commits", "average_score", "conventional_rate"})
+	for _, point := range trend.Points {
+		_ = writer.Write([]string{
+			point.Period, point.Start, strconv.Itoa(point.Commits),
+			strconv.FormatFloat(point.AverageScore, 'f', 1, 64),
+			strconv.FormatFloat(point.ConventionalRate, 'f', 1, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteScopeCSV writes one row per scope with the canonical scope it belongs to, each
+// canonical scope first and its variants after it.
+func WriteScopeCSV(w io.Writer, r ScopeReport) error {
+	variants := make(map[string][]ScopeCount, len(r.Inconsistent))
+	for _, cluster := range r.Inconsistent {
+		variants[cluster.Canonical] = cluster.Variants
+	}
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"scope", "count", "canonical"})
+	for _, scope := range r.Scopes {
+		own := scope.Count
+		for _, variant := range variants[scope.Scope] {
+			own -= variant.Count
+		}
+		_ = writer.Write([]string{scope.Scope, strconv.Itoa(own), scope.Scope})
+		for _, variant := range variants[scope.Scope] {
+			_ = writer.Write([]string{variant.Scope, strconv.Itoa(variant.Count), scope.Scope})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	commits := sampleCommits()[:2]
+	commits[0].Date = "2026-02-03"
+
+	var out bytes.Buffer
+	require.NoError(t, WriteCSV(&out, commits, ScoreCommits(commits, nil)))
+	assert.Equal(t, "hash,author,date,type,score,issues\n"+
+		"a1,alice,2026-02-03,feat,100,\n"+
+		"b2,bob,,,35,not a Conventional Commit; vague description\n", out.String())
+}
+
+func TestWriteTrendCSV(t *testing.T) {
+	trend := Trend{Period: TrendMonth, Points: []TrendPoint{
+		{Period: "2026-01", Start: "2026-01-01", Commits: 3, AverageScore: 81.7, ConventionalRate: 66.7},
+	}}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteTrendCSV(&out, trend))
+	assert.Equal(t, "period,start,commits,average_score,conventional_rate\n"+
+		"2026-01,2026-01-01,3,81.7,66.7\n", out.String())
+}
+
+func TestWriteScopeCSV(t *testing.T) {
+	report := ScopeReport{
+		Scoped: 9,
+		Scopes: []ScopeCount{{Scope: "auth", Count: 7}, {Scope: "cli", Count: 2}},
+		Inconsistent: []ScopeCluster{{Canonical: "auth", Variants: []ScopeCount{
+			{Scope: "authentication", Count: 2}, {Scope: "authn", Count: 1},
+		}, Commits: 7}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteScopeCSV(&out, report))
+	assert.Equal(t, "scope,count,canonical\n"+
+		"auth,4,auth\nauthentication,2,auth\nauthn,1,auth\ncli,2,cli\n", out.String())
+}
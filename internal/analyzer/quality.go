@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samzong/gmc/internal/emoji"
+	"github.com/samzong/gmc/internal/git"
+)
+
+// scoringVersion must be bumped whenever ScoreCommit changes, so cached scores are discarded.
+const scoringVersion = 1
+
+const (
+	maxSubjectLength     = 72
+	minDescriptionLength = 10
+)
+
+// QualityScore is the hygiene score (0-100) of one commit message.
+type QualityScore struct {
+	Hash   string   `json:"hash"`
+	Score  int      `json:"score"`
+	Type   string   `json:"type,omitempty"`
+	Issues []string `json:"issues,omitempty"`
+}
+
+var (
+	subjectPattern   *regexp.Regexp
+	vagueDescription = map[string]bool{
+		"update": true, "updates": true, "fix": true, "fixes": true, "wip": true,
+		"changes": true, "misc": true, "minor changes": true, "cleanup": true, "stuff": true,
+	}
+)
+
+func init() {
+	subjectPattern = regexp.MustCompile(`(?i)^(?:\S+\s)?(` + emoji.GetCommitTypesRegexPattern() + `)(\([^)]+\))?!?: (.+)$`)
+}
+
+// ScoreCommit rates a commit subject against Conventional Commits and common hygiene rules.
+func ScoreCommit(commit git.CommitInfo) QualityScore {
+	subject := strings.TrimSpace(commit.Message)
+	score := QualityScore{Hash: commit.Hash, Score: 100}
+	penalize := func(points int, issue string) {
+		score.Score -= points
+		score.Issues = append(score.Issues, issue)
+	}
+
+	description := subject
+	if m := subjectPattern.FindStringSubmatch(subject); m != nil {
+		score.Type = strings.ToLower(m[1])
+		description = strings.TrimSpace(m[3])
+	} else {
+		penalize(40, "not a Conventional Commit")
+	}
+
+	if utf8.RuneCountInString(subject) > maxSubjectLength {
+		penalize(15, "subject longer than 72 characters")
+	}
+	if vagueDescription[strings.ToLower(strings.TrimRight(description, "."))] {
+		penalize(25, "vague description")
+	} else if utf8.RuneCountInString(description) < minDescriptionLength {
+		penalize(15, "description too short")
+	}
+	if strings.HasSuffix(description, ".") {
+		penalize(5, "subject ends with a period")
+	}
+
+	score.Score = max(score.Score, 0)
+	return score
+}
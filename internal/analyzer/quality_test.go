@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreCommit(t *testing.T) {
+	cases := []struct {
+		message string
+		score   int
+		typ     string
+	}{
+		{message: "feat(api): add retry with backoff", score: 100, typ: "feat"},
+		{message: "✨ feat!: drop the v1 client", score: 100, typ: "feat"},
+		{message: "fix: update", score: 75, typ: "fix"},
+		{message: "fix: typo", score: 85, typ: "fix"},
+		{message: "docs: describe the cache layout.", score: 95, typ: "docs"},
+		{message: "Added a bunch of things to the parser", score: 60},
+		{message: "wip", score: 35},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.message, func(t *testing.T) {
+			got := ScoreCommit(git.CommitInfo{Hash: "abc1234", Message: tc.message})
+			assert.Equal(t, tc.score, got.Score, got.Issues)
+			assert.Equal(t, tc.typ, got.Type)
+			assert.Equal(t, "abc1234", got.Hash)
+		})
+	}
+}
+
+func TestScoreCommitsUsesCache(t *testing.T) {
+	gitDir := t.TempDir()
+	commits := []git.CommitInfo{
+		{Hash: "aaa1111", Message: "feat: add cache"},
+		{Hash: "bbb2222", Message: "wip"},
+	}
+
+	cache := LoadQualityCache(gitDir)
+	scores := ScoreCommits(commits, cache)
+	require.Len(t, scores, 2)
+	require.NoError(t, cache.Save())
+
+	// A cached score wins over re-scoring, which proves the second run skips scoring.
+	cache = LoadQualityCache(gitDir)
+	cache.scores["aaa1111"] = QualityScore{Hash: "aaa1111", Score: 1}
+	rescored := ScoreCommits(append(commits, git.CommitInfo{Hash: "ccc3333", Message: "fix: handle nil"}), cache)
+	assert.Equal(t, 1, rescored[0].Score)
+	assert.Equal(t, scores[1], rescored[1])
+	assert.Equal(t, 100, rescored[2].Score)
+	assert.True(t, cache.dirty)
+}
+
+func TestLoadQualityCacheIgnoresStaleFiles(t *testing.T) {
+	gitDir := t.TempDir()
+	path := QualityCachePath(gitDir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":0,"scores":{"a":{"hash":"a","score":1}}}`), 0o644))
+	assert.Empty(t, LoadQualityCache(gitDir).scores)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+	assert.Empty(t, LoadQualityCache(gitDir).scores)
+}
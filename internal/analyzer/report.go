@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samzong/gmc/internal/git"
+)
+
+const (
+	lowestLimit = 5
+	noType      = "(none)"
+)
+
+// PoorScore is the score below which a commit is listed in Report.Poor, the default
+// threshold of gmc rewrite.
+const PoorScore = 60
+
+// TypeCount is the number of commits of one Conventional Commits type.
+type TypeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// AuthorStats summarizes the commits of one author.
+type AuthorStats struct {
+	Author       string  `json:"author"`
+	Commits      int     `json:"commits"`
+	AverageScore float64 `json:"average_score"`
+}
+
+// IssueCount is how often a quality issue occurs.
+type IssueCount struct {
+	Issue string `json:"issue"`
+	Count int    `json:"count"`
+}
+
+// ScoredCommit is a commit with its quality score.
+type ScoredCommit struct {
+	Hash    string   `json:"hash"`
+	Author  string   `json:"author"`
+	Subject string   `json:"subject"`
+	Score   int      `json:"score"`
+	Issues  []string `json:"issues,omitempty"`
+}
+
+// Report is the aggregated commit quality analysis shown by gmc stats.
+type Report struct {
+	Total        int     `json:"total"`
+	AverageScore float64 `json:"average_score"`
+	// ConventionalRate is the percentage of commits that follow Conventional Commits.
+	ConventionalRate float64        `json:"conventional_rate"`
+	Types            []TypeCount    `json:"types"`
+	Authors          []AuthorStats  `json:"authors"`
+	Issues           []IssueCount   `json:"issues"`
+	Lowest           []ScoredCommit `json:"lowest"`
+	// Poor lists every commit scoring below PoorScore, lowest first.
+	Poor []ScoredCommit `json:"poor"`
+}
+
+// BuildReport aggregates commits and their scores, which must be in the same order.
+func BuildReport(commits []git.CommitInfo, scores []QualityScore) Report {
+	report := Report{
+		Types:   []TypeCount{},
+		Authors: []AuthorStats{},
+		Issues:  []IssueCount{},
+		Lowest:  []ScoredCommit{},
+		Poor:    []ScoredCommit{},
+	}
+	if len(commits) == 0 || len(commits) != len(scores) {
+		return report
+	}
+
+	types := make(map[string]int)
+	issues := make(map[string]int)
+	authorTotals := make(map[string]int)
+	authorCommits := make(map[string]int)
+	scored := make([]ScoredCommit, 0, len(commits))
+	total := 0
+	conventional := 0
+	for i, commit := range commits {
+		score := scores[i]
+		total += score.Score
+
+		commitType := score.Type
+		if commitType == "" {
+			commitType = noType
+		} else {
+			conventional++
+		}
+		types[commitType]++
+		for _, issue := range score.Issues {
+			issues[issue]++
+		}
+		authorTotals[commit.Author] += score.Score
+		authorCommits[commit.Author]++
+
+		scored = append(scored, ScoredCommit{
+			Hash:    commit.Hash,
+			Author:  commit.Author,
+			Subject: strings.TrimSpace(commit.Message),
+			Score:   score.Score,
+			Issues:  score.Issues,
+		})
+	}
+
+	report.Total = len(commits)
+	report.AverageScore = roundTenth(float64(total) / float64(len(commits)))
+	report.ConventionalRate = roundTenth(float64(conventional) * 100 / float64(len(commits)))
+
+	for commitType, count := range types {
+		report.Types = append(report.Types, TypeCount{Type: commitType, Count: count})
+	}
+	sort.Slice(report.Types, func(i, j int) bool {
+		return byCountThenName(report.Types[i].Count, report.Types[j].Count, report.Types[i].Type, report.Types[j].Type)
+	})
+
+	for author, count := range authorCommits {
+		report.Authors = append(report.Authors, AuthorStats{
+			Author:       author,
+			Commits:      count,
+			AverageScore: roundTenth(float64(authorTotals[author]) / float64(count)),
+		})
+	}
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		return byCountThenName(a.Commits, b.Commits, a.Author, b.Author)
+	})
+
+	for issue, count := range issues {
+		report.Issues = append(report.Issues, IssueCount{Issue: issue, Count: count})
+	}
+	sort.Slice(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		return byCountThenName(a.Count, b.Count, a.Issue, b.Issue)
+	})
+
+	// Stable sort keeps the newest commit first among equal scores.
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score < scored[j].Score })
+	for _, commit := range scored {
+		if len(report.Lowest) == lowestLimit || commit.Score == 100 {
+			break
+		}
+		report.Lowest = append(report.Lowest, commit)
+	}
+	for _, commit := range scored {
+		if commit.Score >= PoorScore {
+			break
+		}
+		report.Poor = append(report.Poor, commit)
+	}
+	return report
+}
+
+// Summary is a compact plain-text version of the report used as LLM input.
+func (r Report) Summary() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Commits analyzed: %d\n", r.Total)
+	fmt.Fprintf(&builder, "Average quality score: %.1f/100\n", r.AverageScore)
+	fmt.Fprintf(&builder, "Conventional Commits: %.1f%%\n", r.ConventionalRate)
+
+	types := make([]string, 0, len(r.Types))
+	for _, t := range r.Types {
+		types = append(types, fmt.Sprintf("%s=%d", t.Type, t.Count))
+	}
+	fmt.Fprintf(&builder, "Types: %s\n", strings.Join(types, ", "))
+
+	if len(r.Issues) > 0 {
+		builder.WriteString("Common issues:\n")
+		for _, issue := range r.Issues {
+			fmt.Fprintf(&builder, "- %s (%d)\n", issue.Issue, issue.Count)
+		}
+	}
+	if len(r.Lowest) > 0 {
+		builder.WriteString("Lowest scoring subjects:\n")
+		for _, commit := range r.Lowest {
+			fmt.Fprintf(&builder, "- %q (%d)\n", commit.Subject, commit.Score)
+		}
+	}
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func byCountThenName(countA, countB int, nameA, nameB string) bool {
+	if countA != countB {
+		return countA > countB
+	}
+	return nameA < nameB
+}
+
+func roundTenth(value float64) float64 {
+	return float64(int(value*10+0.5)) / 10
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleCommits() []git.CommitInfo {
+	return []git.CommitInfo{
+		{Hash: "a1", Author: "alice", Message: "feat(api): add retry with backoff"},
+		{Hash: "b2", Author: "bob", Message: "wip"},
+		{Hash: "c3", Author: "alice", Message: "fix: handle empty diff output"},
+		{Hash: "d4", Author: "alice", Message: "feat: add the stats command"},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	commits := sampleCommits()
+	report := BuildReport(commits, ScoreCommits(commits, nil))
+
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 83.8, report.AverageScore)
+	assert.Equal(t, 75.0, report.ConventionalRate)
+	assert.Equal(t, []TypeCount{{Type: "feat", Count: 2}, {Type: "(none)", Count: 1}, {Type: "fix", Count: 1}},
+		report.Types)
+	assert.Equal(t, []AuthorStats{
+		{Author: "alice", Commits: 3, AverageScore: 100},
+		{Author: "bob", Commits: 1, AverageScore: 35},
+	}, report.Authors)
+	assert.Equal(t, []IssueCount{{Issue: "not a Conventional Commit", Count: 1}, {Issue: "vague description", Count: 1}},
+		report.Issues)
+	if assert.Len(t, report.Lowest, 1) {
+		assert.Equal(t, "wip", report.Lowest[0].Subject)
+	}
+	assert.Equal(t, []ScoredCommit{{Hash: "b2", Author: "bob", Subject: "wip", Score: 35,
+		Issues: []string{"not a Conventional Commit", "vague description"}}}, report.Poor)
+
+	summary := report.Summary()
+	assert.Contains(t, summary, "Commits analyzed: 4")
+	assert.Contains(t, summary, "Types: feat=2, (none)=1, fix=1")
+	assert.Contains(t, summary, `- "wip" (35)`)
+}
+
+func TestBuildReportEmpty(t *testing.T) {
+	report := BuildReport(nil, nil)
+	assert.Zero(t, report.Total)
+	assert.NotNil(t, report.Types, "empty slices keep JSON output as [] rather than null")
+	assert.NotNil(t, report.Poor)
+}
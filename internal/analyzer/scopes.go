@@ -0,0 +1,232 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+)
+
+// minPrefixLength is the length a scope needs before a longer scope it starts is
+// taken as a variant of it, so that auth joins authentication but ui does not join
+// uikit. minTypoLength is the length scopes need before a typo joins them, so that
+// lint and list stay apart. Up to shortStemLength, a different last letter is not
+// taken as a typo, so that authn and authz stay apart.
+const (
+	minPrefixLength = 4
+	minTypoLength   = 5
+	shortStemLength = 6
+)
+
+// ScopeCount is how many commits used one scope.
+type ScopeCount struct {
+	Scope string `json:"scope"`
+	Count int    `json:"count"`
+}
+
+// ScopeCluster is a group of scopes that name the same thing, such as worktree,
+// work-tree and worktrees.
+type ScopeCluster struct {
+	// Canonical is the suggested scope: the most used one, the shortest on a tie.
+	Canonical string `json:"canonical"`
+	// Variants are the other scopes of the cluster, most used first.
+	Variants []ScopeCount `json:"variants"`
+	// Commits is the number of commits that used any scope of the cluster.
+	Commits int `json:"commits"`
+}
+
+// ScopeReport is the scope usage of a range of commits.
+type ScopeReport struct {
+	// Scoped is the number of commits with a scope.
+	Scoped int `json:"scoped"`
+	// Scopes are the canonical scopes, with the commits of their variants counted in,
+	// most used first.
+	Scopes []ScopeCount `json:"scopes"`
+	// Inconsistent are the clusters with more than one scope, most commits first.
+	Inconsistent []ScopeCluster `json:"inconsistent"`
+}
+
+// BuildScopeReport extracts the scopes of commits and groups the near-duplicates: scopes
+// that differ only in case and separators, a plural, a single typo in scopes of
+// minTypoLength or more, or, when the match is unambiguous, one that starts another and
+// is at least minPrefixLength long.
+func BuildScopeReport(commits []git.CommitInfo) ScopeReport {
+	report := ScopeReport{Scopes: []ScopeCount{}, Inconsistent: []ScopeCluster{}}
+	counts := make(map[string]int)
+	for _, commit := range commits {
+		if scope := formatter.ScopeOf(commit.Message); scope != "" {
+			counts[scope]++
+			report.Scoped++
+		}
+	}
+
+	scopes := make([]string, 0, len(counts))
+	for scope := range counts {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	parent := make(map[string]string, len(scopes))
+	var find func(string) string
+	find = func(scope string) string {
+		if parent[scope] == "" || parent[scope] == scope {
+			return scope
+		}
+		root := find(parent[scope])
+		parent[scope] = root
+		return root
+	}
+	for i, a := range scopes {
+		for _, b := range scopes[i+1:] {
+			if similarScopes(a, b) {
+				parent[find(b)] = find(a)
+			}
+		}
+	}
+	for extension, base := range prefixVariants(scopes) {
+		parent[find(extension)] = find(base)
+	}
+
+	clusters := make(map[string][]ScopeCount)
+	for _, scope := range scopes {
+		root := find(scope)
+		clusters[root] = append(clusters[root], ScopeCount{Scope: scope, Count: counts[scope]})
+	}
+	for _, members := range clusters {
+		sort.Slice(members, func(i, j int) bool {
+			a, b := members[i], members[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			if len(a.Scope) != len(b.Scope) {
+				return len(a.Scope) < len(b.Scope)
+			}
+			return a.Scope < b.Scope
+		})
+		total := 0
+		for _, member := range members {
+			total += member.Count
+		}
+		report.Scopes = append(report.Scopes, ScopeCount{Scope: members[0].Scope, Count: total})
+		if len(members) > 1 {
+			report.Inconsistent = append(report.Inconsistent, ScopeCluster{
+				Canonical: members[0].Scope, Variants: members[1:], Commits: total,
+			})
+		}
+	}
+
+	sort.Slice(report.Scopes, func(i, j int) bool {
+		a, b := report.Scopes[i], report.Scopes[j]
+		return byCountThenName(a.Count, b.Count, a.Scope, b.Scope)
+	})
+	sort.Slice(report.Inconsistent, func(i, j int) bool {
+		a, b := report.Inconsistent[i], report.Inconsistent[j]
+		return byCountThenName(a.Commits, b.Commits, a.Canonical, b.Canonical)
+	})
+	return report
+}
+
+// CanonicalScopes returns the canonical scopes of the report, most used first.
+func (r ScopeReport) CanonicalScopes() []string {
+	scopes := make([]string, len(r.Scopes))
+	for i, scope := range r.Scopes {
+		scopes[i] = scope.Scope
+	}
+	return scopes
+}
+
+// RenderScopeReport writes the scope usage and the inconsistent clusters as text.
+func RenderScopeReport(w io.Writer, r ScopeReport) {
+	if r.Scoped == 0 {
+		fmt.Fprintln(w, "No commits with a scope found.")
+		return
+	}
+	fmt.Fprintf(w, "%d scoped commit(s), %d scope(s)\n", r.Scoped, len(r.Scopes))
+	if len(r.Inconsistent) == 0 {
+		fmt.Fprintln(w, "\nNo inconsistent scopes found.")
+		return
+	}
+	fmt.Fprintln(w, "\nInconsistent scopes")
+	for _, cluster := range r.Inconsistent {
+		variants := make([]string, len(cluster.Variants))
+		for i, variant := range cluster.Variants {
+			variants[i] = fmt.Sprintf("%s (%d)", variant.Scope, variant.Count)
+		}
+		fmt.Fprintf(w, "  use %-16s instead of %s\n", cluster.Canonical, strings.Join(variants, ", "))
+	}
+}
+
+// similarScopes reports whether a and b look like names for the same scope.
+func similarScopes(a, b string) bool {
+	a, b = normalizeScope(a), normalizeScope(b)
+	if a == b {
+		return true
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if b == a+"s" || b == a+"es" {
+		return true
+	}
+	if len(a) < minTypoLength || len(a) != len(b) || !editDistanceOne(a, b) {
+		return false
+	}
+	return len(a) > shortStemLength || a[:len(a)-1] != b[:len(b)-1]
+}
+
+// prefixVariants maps each scope that extends a shorter one, as authentication extends
+// auth, to the shorter scope. Only unambiguous pairs count: the shorter scope has no
+// other extension, and neither scope is part of another pair, so that auth does not
+// gather both authn and authz, and auth, author and authorization do not chain.
+func prefixVariants(scopes []string) map[string]string {
+	extensions := make(map[string][]string)
+	extended := make(map[string]bool)
+	for _, a := range scopes {
+		for _, b := range scopes {
+			na, nb := normalizeScope(a), normalizeScope(b)
+			if len(na) >= minPrefixLength && len(nb) > len(na) && strings.HasPrefix(nb, na) &&
+				!similarScopes(a, b) {
+				extensions[a] = append(extensions[a], b)
+				extended[b] = true
+			}
+		}
+	}
+
+	variants := make(map[string]string)
+	for base, exts := range extensions {
+		if len(exts) == 1 && !extended[base] && len(extensions[exts[0]]) == 0 {
+			variants[exts[0]] = base
+		}
+	}
+	return variants
+}
+
+// normalizeScope lowers scope and drops its separators, so that work-tree, work_tree
+// and WorkTree compare equal.
+func normalizeScope(scope string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(scope))
+}
+
+// editDistanceOne reports whether a and b, of the same length, differ in one
+// character or in two adjacent characters swapped.
+func editDistanceOne(a, b string) bool {
+	diff := -1
+	for i := range len(a) {
+		if a[i] == b[i] {
+			continue
+		}
+		if diff >= 0 {
+			return i == diff+1 && a[diff] == b[i] && a[i] == b[diff] && a[i+1:] == b[i+1:]
+		}
+		diff = i
+	}
+	return diff >= 0
+}
@@ -0,0 +1,81 @@
+package analyzer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildScopeReport(t *testing.T) {
+	var commits []git.CommitInfo
+	add := func(subject string, n int) {
+		for range n {
+			commits = append(commits, git.CommitInfo{Message: subject})
+		}
+	}
+	add("feat(auth): add login", 4)
+	add("fix(Auth): expire sessions", 1)
+	add("feat(authentication): add SSO", 2)
+	add("fix(work-tree): prune", 1)
+	add("feat(worktree): add clean", 3)
+	add("test(tests): cover parser", 1)
+	add("test(test): cover lexer", 1)
+	add("fix(confg): typo", 1)
+	add("fix(lint): tweak", 1)
+	add("fix(list): sort", 1)
+	add("fix(ui): spacing", 1)
+	add("feat(uikit): add button", 1)
+	add("chore: no scope", 2)
+
+	report := BuildScopeReport(commits)
+	assert.Equal(t, 18, report.Scoped)
+	assert.Equal(t, []ScopeCluster{
+		{Canonical: "auth", Variants: []ScopeCount{{Scope: "authentication", Count: 2}, {Scope: "Auth", Count: 1}},
+			Commits: 7},
+		{Canonical: "worktree", Variants: []ScopeCount{{Scope: "work-tree", Count: 1}}, Commits: 4},
+		{Canonical: "test", Variants: []ScopeCount{{Scope: "tests", Count: 1}}, Commits: 2},
+	}, report.Inconsistent)
+	assert.Equal(t, []string{"auth", "worktree", "test", "confg", "lint", "list", "ui", "uikit"},
+		report.CanonicalScopes())
+
+	var out bytes.Buffer
+	RenderScopeReport(&out, report)
+	assert.Contains(t, out.String(), "18 scoped commit(s), 8 scope(s)")
+	assert.Contains(t, out.String(), "  use auth             instead of authentication (2), Auth (1)\n")
+}
+
+func TestBuildScopeReportKeepsAmbiguousPrefixesApart(t *testing.T) {
+	var commits []git.CommitInfo
+	for _, scope := range []string{"auth", "authn", "authz", "author", "authorization"} {
+		commits = append(commits, git.CommitInfo{Message: "fix(" + scope + "): tweak"})
+	}
+
+	report := BuildScopeReport(commits)
+	assert.Empty(t, report.Inconsistent)
+	assert.Len(t, report.Scopes, 5)
+}
+
+func TestSimilarScopes(t *testing.T) {
+	assert.True(t, similarScopes("config", "cofnig"))
+	assert.True(t, similarScopes("parser", "parsre"))
+	assert.True(t, similarScopes("Auth", "auth"))
+	assert.True(t, similarScopes("class", "classes"))
+	assert.False(t, similarScopes("parser", "poster"), "two changes apart")
+	assert.False(t, similarScopes("doc", "docker"))
+	assert.False(t, similarScopes("core", "cord"))
+	assert.False(t, similarScopes("authn", "authz"), "a short stem with another last letter")
+	assert.True(t, similarScopes("deployment", "deploymant"))
+	assert.False(t, similarScopes("auth", "authentication"), "prefixes are matched by prefixVariants")
+}
+
+func TestBuildScopeReportEmpty(t *testing.T) {
+	report := BuildScopeReport(nil)
+	assert.NotNil(t, report.Scopes)
+	assert.NotNil(t, report.Inconsistent)
+
+	var out bytes.Buffer
+	RenderScopeReport(&out, report)
+	assert.Equal(t, "No commits with a scope found.\n", out.String())
+}
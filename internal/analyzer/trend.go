@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samzong/gmc/internal/git"
+)
+
+// TrendPeriod is the bucket size of a quality trend.
+type TrendPeriod string
+
+const (
+	TrendWeek  TrendPeriod = "week"
+	TrendMonth TrendPeriod = "month"
+)
+
+// IsValidTrendPeriod reports whether period is a supported bucket size.
+func IsValidTrendPeriod(period string) bool {
+	return period == string(TrendWeek) || period == string(TrendMonth)
+}
+
+// TrendPoint is the commit quality of one week or month.
+type TrendPoint struct {
+	// Period is an ISO week ("2026-W07") or a month ("2026-02").
+	Period           string  `json:"period"`
+	Start            string  `json:"start"`
+	Commits          int     `json:"commits"`
+	AverageScore     float64 `json:"average_score"`
+	ConventionalRate float64 `json:"conventional_rate"`
+}
+
+// Trend is a quality time series, oldest period first. Periods without commits are omitted.
+type Trend struct {
+	Period TrendPeriod  `json:"period"`
+	Points []TrendPoint `json:"points"`
+}
+
+type trendBucket struct {
+	start        time.Time
+	commits      int
+	total        int
+	conventional int
+}
+
+// BuildTrend buckets commits by the week or month of their date (YYYY-MM-DD).
+// Commits with unparseable dates are skipped.
+func BuildTrend(commits []git.CommitInfo, scores []QualityScore, period TrendPeriod) Trend {
+	trend := Trend{Period: period, Points: []TrendPoint{}}
+	if len(commits) != len(scores) {
+		return trend
+	}
+
+	buckets := make(map[string]*trendBucket)
+	for i, commit := range commits {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(commit.Date))
+		if err != nil {
+			continue
+		}
+
+		label, start := bucketFor(date, period)
+		bucket, ok := buckets[label]
+		if !ok {
+			bucket = &trendBucket{start: start}
+			buckets[label] = bucket
+		}
+		bucket.commits++
+		bucket.total += scores[i].Score
+		if scores[i].Type != "" {
+			bucket.conventional++
+		}
+	}
+
+	for label, bucket := range buckets {
+		trend.Points = append(trend.Points, TrendPoint{
+			Period:           label,
+			Start:            bucket.start.Format("2006-01-02"),
+			Commits:          bucket.commits,
+			AverageScore:     roundTenth(float64(bucket.total) / float64(bucket.commits)),
+			ConventionalRate: roundTenth(float64(bucket.conventional) * 100 / float64(bucket.commits)),
+		})
+	}
+	sort.Slice(trend.Points, func(i, j int) bool { return trend.Points[i].Start < trend.Points[j].Start })
+	return trend
+}
+
+func bucketFor(date time.Time, period TrendPeriod) (string, time.Time) {
+	if period == TrendMonth {
+		return date.Format("2006-01"), time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	year, week := date.ISOWeek()
+	offset := (int(date.Weekday()) + 6) % 7
+	return fmt.Sprintf("%d-W%02d", year, week), date.AddDate(0, 0, -offset)
+}
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline maps scores (0-100) to block characters, one per point.
+func Sparkline(points []TrendPoint) string {
+	var builder strings.Builder
+	for _, point := range points {
+		level := int(point.AverageScore) * (len(sparkLevels) - 1) / 100
+		builder.WriteRune(sparkLevels[min(max(level, 0), len(sparkLevels)-1)])
+	}
+	return builder.String()
+}
+
+// RenderTrend writes a sparkline and one bar per period scaled to the 0-100 score range.
+func RenderTrend(w io.Writer, trend Trend) {
+	if len(trend.Points) == 0 {
+		fmt.Fprintln(w, "No commits to analyze.")
+		return
+	}
+
+	first, last := trend.Points[0], trend.Points[len(trend.Points)-1]
+	fmt.Fprintf(w, "Quality trend by %s: %s  %.1f -> %.1f\n\n", trend.Period, Sparkline(trend.Points),
+		first.AverageScore, last.AverageScore)
+	for _, point := range trend.Points {
+		bar := int(point.AverageScore) * chartWidth / 100
+		fmt.Fprintf(w, "  %-8s  %-*s  %5.1f  %3.0f%% conventional, %d commits\n", point.Period, chartWidth,
+			strings.Repeat("#", bar), point.AverageScore, point.ConventionalRate, point.Commits)
+	}
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func trendCommits() []git.CommitInfo {
+	// git log order: newest first.
+	return []git.CommitInfo{
+		{Hash: "d4", Date: "2026-02-10", Message: "feat(stats): add trend mode"},
+		{Hash: "c3", Date: "2026-02-09", Message: "fix: handle empty history"},
+		{Hash: "b2", Date: "2026-01-28", Message: "wip"},
+		{Hash: "a1", Date: "2026-01-27", Message: "feat: add stats"},
+		{Hash: "x0", Date: "not a date", Message: "feat: skipped"},
+	}
+}
+
+func TestBuildTrendByWeek(t *testing.T) {
+	commits := trendCommits()
+	trend := BuildTrend(commits, ScoreCommits(commits, nil), TrendWeek)
+
+	assert.Equal(t, TrendWeek, trend.Period)
+	assert.Equal(t, []TrendPoint{
+		{Period: "2026-W05", Start: "2026-01-26", Commits: 2, AverageScore: 60, ConventionalRate: 50},
+		{Period: "2026-W07", Start: "2026-02-09", Commits: 2, AverageScore: 100, ConventionalRate: 100},
+	}, trend.Points)
+}
+
+func TestBuildTrendByMonth(t *testing.T) {
+	commits := trendCommits()
+	trend := BuildTrend(commits, ScoreCommits(commits, nil), TrendMonth)
+
+	if assert.Len(t, trend.Points, 2) {
+		assert.Equal(t, "2026-01", trend.Points[0].Period)
+		assert.Equal(t, "2026-01-01", trend.Points[0].Start)
+		assert.Equal(t, "2026-02", trend.Points[1].Period)
+	}
+}
+
+func TestRenderTrend(t *testing.T) {
+	commits := trendCommits()
+	var out bytes.Buffer
+	RenderTrend(&out, BuildTrend(commits, ScoreCommits(commits, nil), TrendWeek))
+
+	assert.Contains(t, out.String(), "Quality trend by week: ▅█  60.0 -> 100.0")
+	assert.Contains(t, out.String(), "2026-W07")
+	assert.Contains(t, out.String(), "100% conventional, 2 commits")
+
+	out.Reset()
+	RenderTrend(&out, Trend{Period: TrendMonth})
+	assert.Equal(t, "No commits to analyze.\n", out.String())
+}
+
+func TestIsValidTrendPeriod(t *testing.T) {
+	assert.True(t, IsValidTrendPeriod("week"))
+	assert.True(t, IsValidTrendPeriod("month"))
+	assert.False(t, IsValidTrendPeriod("day"))
+}
@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const chartWidth = 30
+
+type chartRow struct {
+	label string
+	value int
+	note  string
+}
+
+// RenderReport writes the report as text with ASCII bar charts. The authors chart is
+// only shown when more than one author is present.
+func RenderReport(w io.Writer, r Report) {
+	if r.Total == 0 {
+		fmt.Fprintln(w, "No commits to analyze.")
+		return
+	}
+
+	fmt.Fprintf(w, "Commits analyzed: %d\n", r.Total)
+	fmt.Fprintf(w, "Average quality:  %.1f/100\n", r.AverageScore)
+	fmt.Fprintf(w, "Conventional:     %.1f%%\n", r.ConventionalRate)
+
+	rows := make([]chartRow, 0, len(r.Types))
+	for _, t := range r.Types {
+		rows = append(rows, chartRow{label: t.Type, value: t.Count,
+			note: fmt.Sprintf("%d (%.0f%%)", t.Count, float64(t.Count)*100/float64(r.Total))})
+	}
+	renderChart(w, "Commit types", rows)
+
+	if len(r.Authors) > 1 {
+		rows = rows[:0]
+		for _, a := range r.Authors {
+			rows = append(rows, chartRow{label: a.Author, value: a.Commits,
+				note: fmt.Sprintf("%d commits, avg %.1f", a.Commits, a.AverageScore)})
+		}
+		renderChart(w, "Authors", rows)
+	}
+
+	if len(r.Issues) > 0 {
+		rows = rows[:0]
+		for _, issue := range r.Issues {
+			rows = append(rows, chartRow{label: issue.Issue, value: issue.Count, note: fmt.Sprint(issue.Count)})
+		}
+		renderChart(w, "Common issues", rows)
+	}
+
+	if len(r.Lowest) > 0 {
+		fmt.Fprintln(w, "\nLowest scoring commits")
+		for _, commit := range r.Lowest {
+			fmt.Fprintf(w, "  %3d  %s  %s\n", commit.Score, commit.Hash, commit.Subject)
+			if len(commit.Issues) > 0 {
+				fmt.Fprintf(w, "            %s\n", strings.Join(commit.Issues, ", "))
+			}
+		}
+	}
+}
+
+// renderChart draws one horizontal bar per row, scaled to the largest value.
+func renderChart(w io.Writer, title string, rows []chartRow) {
+	fmt.Fprintf(w, "\n%s\n", title)
+
+	labelWidth, maxValue := 0, 0
+	for _, row := range rows {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(row.label))
+		maxValue = max(maxValue, row.value)
+	}
+
+	for _, row := range rows {
+		bar := 0
+		if maxValue > 0 {
+			bar = row.value * chartWidth / maxValue
+		}
+		if bar == 0 && row.value > 0 {
+			bar = 1
+		}
+		padding := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(row.label))
+		fmt.Fprintf(w, "  %s%s  %-*s  %s\n", row.label, padding, chartWidth, strings.Repeat("#", bar), row.note)
+	}
+}
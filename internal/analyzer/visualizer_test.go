@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderReport(t *testing.T) {
+	commits := sampleCommits()
+	var out bytes.Buffer
+	RenderReport(&out, BuildReport(commits, ScoreCommits(commits, nil)))
+
+	text := out.String()
+	assert.Contains(t, text, "Average quality:  83.8/100")
+	assert.Contains(t, text, "  feat    "+strings.Repeat("#", chartWidth)+"  2 (50%)")
+	assert.Contains(t, text, "  fix     "+strings.Repeat("#", chartWidth/2))
+	assert.Contains(t, text, "Authors\n  alice")
+	assert.Contains(t, text, "   35  b2  wip\n")
+}
+
+func TestRenderReportSingleAuthorAndEmpty(t *testing.T) {
+	commits := sampleCommits()[:1]
+	var out bytes.Buffer
+	RenderReport(&out, BuildReport(commits, ScoreCommits(commits, nil)))
+	assert.NotContains(t, out.String(), "Authors")
+	assert.NotContains(t, out.String(), "Lowest scoring")
+
+	out.Reset()
+	RenderReport(&out, Report{})
+	assert.Equal(t, "No commits to analyze.\n", out.String())
+}
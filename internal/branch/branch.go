@@ -1,7 +1,6 @@
 package branch
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 )
@@ -19,18 +18,19 @@ var prefixKeywords = map[string][]string{
 }
 
 func GenerateName(description string) string {
+	return GenerateNameWithOptions(description, Options{})
+}
+
+// GenerateNameWithOptions names a branch after description with the type its words
+// suggest, unless opts sets one, laid out by the branch_naming convention in opts.
+func GenerateNameWithOptions(description string, opts Options) string {
 	if description == "" {
 		return ""
 	}
-
-	prefix := detectPrefix(description)
-	cleaned := sanitizeDescription(description)
-	if cleaned == "" {
-		return ""
+	if opts.Type == "" {
+		opts.Type = detectPrefix(description)
 	}
-	cleaned = limitLength(cleaned, 45)
-
-	return fmt.Sprintf("%s/%s", prefix, cleaned)
+	return render(sanitizeDescription(description), opts)
 }
 
 func detectPrefix(description string) string {
@@ -63,6 +63,9 @@ func sanitizeDescription(description string) string {
 }
 
 func limitLength(text string, maxLength int) string {
+	if maxLength <= 0 {
+		return ""
+	}
 	if len(text) <= maxLength {
 		return text
 	}
@@ -0,0 +1,170 @@
+package branch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/samzong/gmc/internal/config"
+)
+
+// maxSlugLength bounds the description part of a name when branch_naming has no
+// max_length.
+const maxSlugLength = 45
+
+// Without a ticket, {ticket} is dropped with the separator after it, or else the one
+// before it.
+var (
+	ticketThenSeparator = regexp.MustCompile(`\{ticket\}[-_./]`)
+	separatorThenTicket = regexp.MustCompile(`[-_./]?\{ticket\}`)
+)
+
+// stopWords are dropped from the compact variant of a description.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "of": true, "for": true, "in": true,
+	"on": true, "and": true, "or": true, "with": true, "from": true, "when": true, "by": true,
+}
+
+// Options shape the names GenerateNameWithOptions, Normalize and Variants return.
+type Options struct {
+	// Type replaces the type prefix, such as "feat" or "fix", of every name.
+	Type string
+	// Owner, such as the user's initials, is put before the type: "jd/feat/...".
+	Owner string
+	// Ticket fills the {ticket} placeholder of Naming's pattern.
+	Ticket string
+	// Naming is the branch_naming convention; its zero value is "{type}/{slug}".
+	Naming config.BranchNaming
+}
+
+// Normalize cleans a suggested name such as "Feat/Add OAuth login!" into
+// "feat/add-oauth-login", applying opts. A name without a type gets the one its
+// words suggest. It returns "" when nothing is left of the name.
+func Normalize(name string, opts Options) string {
+	name = strings.Trim(strings.TrimSpace(name), "`\"'")
+	typ, slug, ok := strings.Cut(name, "/")
+	if !ok {
+		typ, slug = "", name
+	}
+	slug = sanitizeDescription(strings.ReplaceAll(slug, "/", " "))
+	if opts.Type == "" {
+		opts.Type = typ
+	}
+	if sanitizeDescription(opts.Type) == "" {
+		opts.Type = detectPrefix(slug)
+	}
+	return render(slug, opts)
+}
+
+// render lays out slug, the type, ticket and owner of opts by the pattern of
+// opts.Naming, in the characters it allows, and cuts the slug to fit its max_length.
+func render(slug string, opts Options) string {
+	naming := opts.Naming
+	pattern := naming.Pattern
+	if naming.CheckPattern() != nil {
+		pattern = config.DefaultBranchPattern
+	}
+	typ, owner := sanitizeDescription(opts.Type), sanitizeDescription(opts.Owner)
+	ticket := strings.Join(strings.Fields(opts.Ticket), "-")
+	if naming.Lowercase {
+		ticket = strings.ToLower(ticket)
+	}
+	if disallowed, err := naming.Disallowed(); err == nil && disallowed != nil {
+		clean := func(part string) string {
+			return strings.Trim(disallowed.ReplaceAllString(strings.ReplaceAll(part, "/", "-"), ""), "-")
+		}
+		typ, ticket, owner, slug = clean(typ), clean(ticket), clean(owner), clean(slug)
+	}
+	if ticket == "" {
+		pattern = ticketThenSeparator.ReplaceAllString(pattern, "")
+		pattern = separatorThenTicket.ReplaceAllString(pattern, "")
+	}
+
+	name := strings.NewReplacer("{type}", typ, "{ticket}", ticket).Replace(pattern)
+	if owner != "" {
+		name = owner + "/" + name
+	}
+	limit := maxSlugLength
+	if naming.MaxLength > 0 {
+		limit = naming.MaxLength - (len(name) - len("{slug}"))
+	}
+	if slug = limitLength(slug, limit); slug == "" {
+		return ""
+	}
+	return strings.Replace(name, "{slug}", slug, 1)
+}
+
+// Check reports how name breaks the branch_naming convention: its max_length,
+// lowercase and allowed_chars rules.
+func Check(name string, naming config.BranchNaming) error {
+	if naming.MaxLength > 0 && len(name) > naming.MaxLength {
+		return fmt.Errorf("branch name %s is longer than the %d characters branch_naming allows", name, naming.MaxLength)
+	}
+	if naming.Lowercase && name != strings.ToLower(name) {
+		return fmt.Errorf("branch name %s has upper case, which branch_naming does not allow", name)
+	}
+	disallowed, err := naming.Disallowed()
+	if err != nil {
+		return fmt.Errorf("invalid branch_naming.allowed_chars: %w", err)
+	}
+	if disallowed != nil {
+		if chars := disallowed.FindString(name); chars != "" {
+			return fmt.Errorf("branch name %s has %q, outside the characters branch_naming allows", name, chars)
+		}
+	}
+	return nil
+}
+
+// Variants returns names for description without an LLM: the whole description,
+// then a compact one without filler words when that differs.
+func Variants(description string, opts Options) []string {
+	if opts.Type == "" {
+		// The type comes from the whole description, even for the compact slug.
+		opts.Type = detectPrefix(description)
+	}
+	var compact []string
+	for _, word := range strings.Fields(description) {
+		if !stopWords[strings.ToLower(word)] {
+			compact = append(compact, word)
+		}
+	}
+	return Dedupe([]string{
+		GenerateNameWithOptions(description, opts),
+		GenerateNameWithOptions(strings.Join(compact, " "), opts),
+	})
+}
+
+// Dedupe returns names without repeats or empty names, in order.
+func Dedupe(names []string) []string {
+	var unique []string
+	for _, name := range names {
+		if name != "" {
+			unique = appendUnique(unique, name)
+		}
+	}
+	return unique
+}
+
+func appendUnique(names []string, name string) []string {
+	for _, existing := range names {
+		if existing == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
+// Initials returns the lowercase initials of a user name, "jd" for "Jane Doe".
+func Initials(userName string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(userName) {
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				b.WriteRune(unicode.ToLower(r))
+				break
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package branch
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts Options
+		want string
+	}{
+		{name: "clean", in: "feat/add-oauth-login", want: "feat/add-oauth-login"},
+		{name: "messy", in: "`Feat/Add OAuth login!`", want: "feat/add-oauth-login"},
+		{name: "no type", in: "fix the login redirect", want: "fix/fix-the-login-redirect"},
+		{name: "forced type", in: "feat/login-redirect", opts: Options{Type: "fix"}, want: "fix/login-redirect"},
+		{name: "owner", in: "feat/login", opts: Options{Owner: "jd"}, want: "jd/feat/login"},
+		{name: "nested slug", in: "feat/api/v2 routes", want: "feat/api-v2-routes"},
+		{name: "empty slug", in: "feat/!!!", want: ""},
+		{
+			name: "long slug",
+			in:   "chore/this is a very long description that goes beyond the limit",
+			want: "chore/this-is-a-very-long-description-that-goes-bey",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.in, tt.opts))
+		})
+	}
+}
+
+func TestVariants(t *testing.T) {
+	assert.Equal(t, []string{"fix/resolve-the-crash-on-startup", "fix/resolve-crash-startup"},
+		Variants("resolve the crash on startup", Options{}))
+	assert.Equal(t, []string{"jd/feat/oauth-login"}, Variants("OAuth login", Options{Type: "feat", Owner: "jd"}))
+	assert.Empty(t, Variants("", Options{}))
+}
+
+func TestInitials(t *testing.T) {
+	assert.Equal(t, "jd", Initials("Jane Doe"))
+	assert.Equal(t, "jmr", Initials("  jane m. (Roe)"))
+	assert.Equal(t, "", Initials(""))
+}
+
+func TestGenerateNameWithOptions(t *testing.T) {
+	naming := config.BranchNaming{Pattern: "{type}/{ticket}-{slug}", MaxLength: 30, Lowercase: true}
+	tests := []struct {
+		name        string
+		description string
+		opts        Options
+		want        string
+	}{
+		{name: "ticket", description: "fix login redirect", opts: Options{Ticket: "PROJ-12", Naming: naming},
+			want: "fix/proj-12-fix-login-redirect"},
+		{name: "no ticket", description: "fix login redirect", opts: Options{Naming: naming},
+			want: "fix/fix-login-redirect"},
+		{name: "max length", description: "fix the login redirect loop on expired sessions",
+			opts: Options{Ticket: "PROJ-12", Naming: naming}, want: "fix/proj-12-fix-the-login-redi"},
+		{name: "ticket last", description: "fix login",
+			opts: Options{Naming: config.BranchNaming{Pattern: "{type}/{slug}-{ticket}"}}, want: "fix/fix-login"},
+		{name: "ticket case kept", description: "fix login",
+			opts: Options{Ticket: "PROJ-12", Naming: config.BranchNaming{Pattern: "{ticket}/{slug}"}},
+			want: "PROJ-12/fix-login"},
+		{name: "allowed chars", description: "fix login", opts: Options{Type: "hot_fix", Owner: "j.d",
+			Naming: config.BranchNaming{AllowedChars: "a-z-"}}, want: "jd/hotfix/fix-login"},
+		{name: "invalid pattern", description: "fix login",
+			opts: Options{Naming: config.BranchNaming{Pattern: "{type}"}}, want: "fix/fix-login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := GenerateNameWithOptions(tt.description, tt.opts)
+			assert.Equal(t, tt.want, name)
+			assert.NoError(t, Check(name, tt.opts.Naming))
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	naming := config.BranchNaming{MaxLength: 20, AllowedChars: "a-z0-9-", Lowercase: true}
+	assert.NoError(t, Check("feat/add-login", naming))
+	assert.EqualError(t, Check("feat/add-login-with-oauth", naming),
+		"branch name feat/add-login-with-oauth is longer than the 20 characters branch_naming allows")
+	assert.EqualError(t, Check("feat/Add-login", naming),
+		"branch name feat/Add-login has upper case, which branch_naming does not allow")
+	naming.Lowercase = false
+	assert.EqualError(t, Check("feat/add_login", naming),
+		`branch name feat/add_login has "_", outside the characters branch_naming allows`)
+	assert.NoError(t, Check("Feat/add_login", config.BranchNaming{}))
+}
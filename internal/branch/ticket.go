@@ -0,0 +1,51 @@
+package branch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TicketPlaceholder is replaced with the ticket ID in an issue_format.
+const TicketPlaceholder = "{id}"
+
+// DefaultTicketFormat appends the ticket ID to the subject in brackets.
+const DefaultTicketFormat = "[" + TicketPlaceholder + "]"
+
+// trailerFormatPattern matches an issue_format that is a trailer, such as "Refs: {id}".
+var trailerFormatPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: `)
+
+// ExtractTicket returns the ticket ID that pattern finds in the branch name, such as
+// PROJ-1234 in PROJ-1234-fix-login, or "" when it finds none. When pattern has a
+// capture group, the ID is the first group's match.
+func ExtractTicket(branch, pattern string) (string, error) {
+	if pattern == "" || branch == "" {
+		return "", nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid issue_pattern %q: %w", pattern, err)
+	}
+	match := re.FindStringSubmatch(branch)
+	if match == nil {
+		return "", nil
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
+
+// FormatTicket renders the ticket ID with format, DefaultTicketFormat when empty.
+func FormatTicket(format, id string) string {
+	if format == "" {
+		format = DefaultTicketFormat
+	}
+	return strings.ReplaceAll(format, TicketPlaceholder, id)
+}
+
+// IsTrailerFormat reports whether format adds the ticket as a trailer, "Key: value",
+// rather than to the subject.
+func IsTrailerFormat(format string) bool {
+	return trailerFormatPattern.MatchString(format)
+}
@@ -0,0 +1,40 @@
+package branch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractTicket(t *testing.T) {
+	jira := `[A-Z][A-Z0-9]+-[0-9]+`
+	tests := []struct {
+		branch, pattern, want string
+	}{
+		{"PROJ-1234-fix-login", jira, "PROJ-1234"},
+		{"feature/AB2-7_cache", jira, "AB2-7"},
+		{"fix/login", jira, ""},
+		{"issue-42-crash", `issue-([0-9]+)`, "42"},
+		{"PROJ-1234-fix-login", "", ""},
+		{"", jira, ""},
+	}
+	for _, tt := range tests {
+		got, err := ExtractTicket(tt.branch, tt.pattern)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.branch)
+	}
+
+	_, err := ExtractTicket("PROJ-1", "[A-Z")
+	assert.ErrorContains(t, err, "invalid issue_pattern")
+}
+
+func TestFormatTicket(t *testing.T) {
+	assert.Equal(t, "[PROJ-1]", FormatTicket("", "PROJ-1"))
+	assert.Equal(t, "(PROJ-1)", FormatTicket("({id})", "PROJ-1"))
+	assert.Equal(t, "Refs: PROJ-1", FormatTicket("Refs: {id}", "PROJ-1"))
+
+	assert.True(t, IsTrailerFormat("Refs: {id}"))
+	assert.True(t, IsTrailerFormat("Jira-Issue: {id}"))
+	assert.False(t, IsTrailerFormat("[{id}]"))
+	assert.False(t, IsTrailerFormat("ticket {id}"))
+}
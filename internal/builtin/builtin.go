@@ -0,0 +1,160 @@
+// Package builtin holds the prompt templates, locale bundles and emoji map compiled
+// into gmc, and finds the copies users keep to override them.
+package builtin
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the embedded files, relative to an override directory.
+const (
+	DefaultTemplate = "templates/default.yaml"
+	ReviewTemplate  = "templates/review/default.yaml"
+	EmojiMap        = "emoji.yaml"
+	LocalesDir      = "locales"
+)
+
+// RepoDir is the directory of a repository that overrides the embedded files.
+const RepoDir = ".gmc"
+
+//go:embed files
+var embedded embed.FS
+
+var (
+	overrideMu    sync.Mutex
+	overrideFunc  func() []string
+	overrideDirs  []string
+	overrideReady bool
+)
+
+// SetOverrideDirs sets the function that returns the directories whose files replace
+// the embedded ones, in lookup order. It runs once, on the first lookup, so commands
+// that never read a built-in file do not pay for it.
+func SetOverrideDirs(dirs func() []string) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	overrideFunc = dirs
+	overrideDirs = nil
+	overrideReady = false
+}
+
+func lookupDirs() []string {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	if !overrideReady {
+		if overrideFunc != nil {
+			overrideDirs = overrideFunc()
+		}
+		overrideReady = true
+	}
+	return overrideDirs
+}
+
+// Embedded returns the copy of name compiled into gmc.
+func Embedded(name string) ([]byte, error) {
+	content, err := embedded.ReadFile(path.Join("files", name))
+	if err != nil {
+		return nil, fmt.Errorf("no built-in file %s", name)
+	}
+	return content, nil
+}
+
+// Read returns name from the first override directory that has it, or the embedded
+// copy. The returned path is "" for the embedded copy.
+func Read(name string) ([]byte, string, error) {
+	for _, dir := range lookupDirs() {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		content, err := os.ReadFile(file)
+		if err == nil {
+			return content, file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+	}
+
+	content, err := Embedded(name)
+	return content, "", err
+}
+
+// List returns the names of the YAML files in dir, such as "locales/ja.yaml", that are
+// embedded or found in an override directory, sorted.
+func List(dir string) ([]string, error) {
+	seen := make(map[string]bool)
+	entries, err := embedded.ReadDir(path.Join("files", dir))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		seen[entry.Name()] = true
+	}
+
+	for _, override := range lookupDirs() {
+		entries, err := os.ReadDir(filepath.Join(override, filepath.FromSlash(dir)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(override, dir), err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".yaml") {
+				seen[entry.Name()] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, path.Join(dir, name))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Names returns every embedded file, sorted.
+func Names() []string {
+	var names []string
+	_ = fs.WalkDir(embedded, "files", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			names = append(names, strings.TrimPrefix(name, "files/"))
+		}
+		return err
+	})
+	sort.Strings(names)
+	return names
+}
+
+// Export writes the embedded files into dir with the layout gmc looks them up in. A
+// file that already exists is left alone and reported as skipped, unless force is set.
+func Export(dir string, force bool) (written []string, skipped []string, err error) {
+	for _, name := range Names() {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(file); err == nil && !force {
+			skipped = append(skipped, file)
+			continue
+		}
+
+		content, err := Embedded(name)
+		if err != nil {
+			return written, skipped, err
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return written, skipped, fmt.Errorf("failed to create %s: %w", filepath.Dir(file), err)
+		}
+		if err := os.WriteFile(file, content, 0o644); err != nil {
+			return written, skipped, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+	return written, skipped, nil
+}
@@ -0,0 +1,92 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	return file
+}
+
+func useOverrideDirs(t *testing.T, dirs ...string) {
+	t.Helper()
+	SetOverrideDirs(func() []string { return dirs })
+	t.Cleanup(func() { SetOverrideDirs(nil) })
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	assert.Contains(t, names, DefaultTemplate)
+	assert.Contains(t, names, EmojiMap)
+	assert.Contains(t, names, "locales/ja.yaml")
+}
+
+func TestReadLookupOrder(t *testing.T) {
+	repoDir := t.TempDir()
+	homeDir := t.TempDir()
+	useOverrideDirs(t, repoDir, homeDir)
+
+	content, file, err := Read(EmojiMap)
+	require.NoError(t, err)
+	assert.Empty(t, file, "the embedded copy has no path")
+	assert.Contains(t, string(content), "feat: sparkles")
+
+	homeEmoji := writeFile(t, homeDir, EmojiMap, "types: {}\n")
+	_, file, err = Read(EmojiMap)
+	require.NoError(t, err)
+	assert.Equal(t, homeEmoji, file)
+
+	repoEmoji := writeFile(t, repoDir, EmojiMap, "types: {}\n")
+	_, file, err = Read(EmojiMap)
+	require.NoError(t, err)
+	assert.Equal(t, repoEmoji, file, "the repository comes before the user directory")
+
+	_, _, err = Read("missing.yaml")
+	assert.ErrorContains(t, err, "no built-in file missing.yaml")
+}
+
+func TestList(t *testing.T) {
+	homeDir := t.TempDir()
+	useOverrideDirs(t, filepath.Join(t.TempDir(), "missing"), homeDir)
+	writeFile(t, homeDir, "locales/nl.yaml", "name: Dutch\n")
+	writeFile(t, homeDir, "locales/notes.txt", "ignored")
+
+	names, err := List(LocalesDir)
+	require.NoError(t, err)
+	assert.Contains(t, names, "locales/nl.yaml")
+	assert.Contains(t, names, "locales/zh-CN.yaml")
+	assert.NotContains(t, names, "locales/notes.txt")
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	kept := writeFile(t, dir, EmojiMap, "types: {}\n")
+
+	written, skipped, err := Export(dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{kept}, skipped)
+	assert.Contains(t, written, filepath.Join(dir, "templates", "default.yaml"))
+	assert.Len(t, written, len(Names())-1)
+	content, err := os.ReadFile(kept)
+	require.NoError(t, err)
+	assert.Equal(t, "types: {}\n", string(content), "existing files are kept")
+
+	written, skipped, err = Export(dir, true)
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+	assert.Len(t, written, len(Names()))
+	content, err = os.ReadFile(kept)
+	require.NoError(t, err)
+	embedded, err := Embedded(EmojiMap)
+	require.NoError(t, err)
+	assert.Equal(t, embedded, content)
+}
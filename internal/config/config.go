@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 
@@ -17,7 +18,227 @@ type Config struct {
 	APIKey         string `mapstructure:"api_key"`
 	APIBase        string `mapstructure:"api_base"`
 	PromptTemplate string `mapstructure:"prompt_template"`
-	EnableEmoji    bool   `mapstructure:"enable_emoji"`
+	// FallbackTemplate is used when prompt_template fails to load, parse or render.
+	FallbackTemplate string `mapstructure:"fallback_template"`
+	EnableEmoji      bool   `mapstructure:"enable_emoji"`
+	IssueContext     bool   `mapstructure:"issue_context"`
+	GitHubToken      string `mapstructure:"github_token"`
+	GitLabToken      string `mapstructure:"gitlab_token"`
+	GiteaToken       string `mapstructure:"gitea_token"`
+	Forge            string `mapstructure:"forge"`
+	TypeHints        string `mapstructure:"type_hints"`
+	Language         string `mapstructure:"language"`
+	CommitBody       bool   `mapstructure:"commit_body"`
+	TagTemplate      string `mapstructure:"tag_template"`
+	// IssuePattern is a regular expression that finds a ticket ID, such as PROJ-1234, in
+	// the branch name. Without --issue, the ID is named in the prompt and added to the
+	// message as IssueFormat renders it, "[{id}]" when empty.
+	IssuePattern string `mapstructure:"issue_pattern"`
+	IssueFormat  string `mapstructure:"issue_format"`
+	// JiraURL is the Jira site that tickets live on. With it, issue_context reads the
+	// ticket's summary and description from Jira, authenticated by JiraEmail and
+	// JiraToken, and --jira-comment and --jira-transition update it after the commit.
+	JiraURL   string `mapstructure:"jira_url"`
+	JiraEmail string `mapstructure:"jira_email"`
+	JiraToken string `mapstructure:"jira_token"`
+	// GenerationNotes stores generation metadata as a git note on refs/notes/gmc.
+	GenerationNotes bool `mapstructure:"generation_notes"`
+	// Attribution adds a "Generated-by: gmc <version> (<model>)" trailer to the messages
+	// gmc generates, which gmc undo uses to recognize its commits.
+	Attribution bool `mapstructure:"attribution"`
+	// Components maps monorepo component names to their directories, such as
+	// api: services/api. gmc tag --component api tags them as api/v1.4.0 from the
+	// commits under the directory.
+	Components map[string]string `mapstructure:"components"`
+	// ExecPresets maps preset names to shell commands run by gmc wt exec.
+	ExecPresets map[string]string `mapstructure:"exec_presets"`
+	// CommitTypes restricts generated commit types; empty allows every type.
+	CommitTypes []string `mapstructure:"commit_types"`
+	// SignCommits signs every commit gmc creates, as git commit -S does.
+	SignCommits bool `mapstructure:"sign_commits"`
+	// Temperature, TopP and MaxTokens tune commit message requests. Unset values, and
+	// a MaxTokens of 0, keep the API's defaults.
+	Temperature *float64 `mapstructure:"temperature"`
+	TopP        *float64 `mapstructure:"top_p"`
+	MaxTokens   int      `mapstructure:"max_tokens"`
+	// HookAutofixRetry restages the files a failing pre-commit hook rewrote, such as
+	// formatter output, and retries the commit once with the same message.
+	HookAutofixRetry bool `mapstructure:"hook_autofix_retry"`
+	// Preflight prints a summary of the staged changes, with the estimated prompt size
+	// and cost, and asks to go on before the message is generated.
+	Preflight bool `mapstructure:"preflight"`
+	// SummarizeDiffs summarizes diffs too large for the prompt file by file instead of
+	// truncating them, with up to SummarizeParallelism requests in flight, each limited
+	// to SummarizeTimeout seconds.
+	SummarizeDiffs       bool `mapstructure:"summarize_diffs"`
+	SummarizeParallelism int  `mapstructure:"summarize_parallelism"`
+	SummarizeTimeout     int  `mapstructure:"summarize_timeout"`
+	// SummarizeThreshold is the diff size, in bytes, above which the diff is summarized
+	// file by file before prompting: by the LLM with SummarizeDiffs, from hunk headers
+	// and line counts otherwise. 0 summarizes only diffs too large for the prompt, and
+	// only with SummarizeDiffs.
+	SummarizeThreshold int `mapstructure:"summarize_threshold"`
+	// OutlineNewFiles sends large new Go and JavaScript/TypeScript files to the LLM as
+	// an outline of their declarations instead of every added line.
+	OutlineNewFiles bool `mapstructure:"outline_new_files"`
+	// ScopeRules map path prefixes to commit scopes for the prompt's scope hint.
+	ScopeRules []ScopeRule `mapstructure:"scope_rules"`
+	// Scopes are the commit scopes the repository uses, which the prompt lists. gmc
+	// stats --scopes --save-scopes writes them from the history.
+	Scopes []string `mapstructure:"scopes"`
+	// RiskPolicies flag commits that need a second confirmation.
+	RiskPolicies []RiskPolicy `mapstructure:"risk_policies"`
+	// Trailers are added to every commit message gmc commits, such as Co-authored-by
+	// or Refs, through git interpret-trailers.
+	Trailers []Trailer `mapstructure:"trailers"`
+	// GitBackend picks what reads diffs, status and history: "native" runs git and
+	// "go-git" reads the repository in-process.
+	GitBackend string `mapstructure:"git_backend"`
+	// PackageGlobs match the package directories of a monorepo, such as "packages/*",
+	// which gmc --per-package commits one at a time.
+	PackageGlobs []string `mapstructure:"package_globs"`
+	// ExcludePaths are pathspec patterns, such as "*.pb.go" or "vendor", whose changes
+	// are left out of the diff sent to the LLM.
+	ExcludePaths []string `mapstructure:"exclude_paths"`
+	// HTTPProxy routes LLM requests through a proxy; empty honors HTTPS_PROXY, HTTP_PROXY
+	// and NO_PROXY. CACert is a PEM bundle trusted in addition to the system roots, and
+	// TLSInsecure skips certificate verification.
+	HTTPProxy   string `mapstructure:"http_proxy"`
+	CACert      string `mapstructure:"ca_cert"`
+	TLSInsecure bool   `mapstructure:"tls_insecure"`
+	// ExtraHeaders are HTTP headers added to every LLM request, such as the
+	// organization or route headers an LLM gateway expects. An empty value drops a
+	// header set by a lower config layer.
+	ExtraHeaders map[string]string `mapstructure:"extra_headers"`
+	// Defaults seed the flags of gmc itself when they are not given on the command line.
+	Defaults FlagDefaults `mapstructure:"defaults"`
+	// Guard configures the check for likely secrets in the changes sent to the LLM.
+	Guard Guard `mapstructure:"guard"`
+	// ReviewTemplate is the gmc review template, a name from the review template
+	// directories or a file path.
+	ReviewTemplate string `mapstructure:"review_template"`
+	// BranchNaming is the convention the branch names gmc generates follow.
+	BranchNaming BranchNaming `mapstructure:"branch_naming"`
+	// EmojiMap sets the emoji of commit types, such as feat: 🚀, over the emoji map's
+	// when EnableEmoji is on. Values are emoji or gitmoji names or codes.
+	EmojiMap map[string]string `mapstructure:"emoji_map"`
+	// EmojiStyle is how EnableEmoji writes the emoji: "conventional" as the emoji
+	// itself, or "gitmoji" as a gitmoji code such as :sparkles:.
+	EmojiStyle string `mapstructure:"emoji_style"`
+	// AuthorAliases maps the author names and emails, matched case-insensitively, that
+	// gmc stats reports as another author, on top of .mailmap.
+	AuthorAliases map[string]string `mapstructure:"author_aliases"`
+}
+
+// FlagDefaults are the defaults section: flag values a team prefers, so they do not
+// need shell aliases. Zero values leave the built-in flag defaults alone.
+type FlagDefaults struct {
+	AddAll   bool `mapstructure:"add_all" json:"add_all"`     // --all
+	AutoYes  bool `mapstructure:"auto_yes" json:"auto_yes"`   // --yes
+	DryRun   bool `mapstructure:"dry_run" json:"dry_run"`     // --dry-run
+	NoVerify bool `mapstructure:"no_verify" json:"no_verify"` // --no-verify
+	Verbose  bool `mapstructure:"verbose" json:"verbose"`     // --verbose
+	// Timeout and ConnectTimeout are --timeout and --connect-timeout, in seconds, where
+	// 0 sets no limit. Unset, the flags keep their defaults.
+	Timeout        *int `mapstructure:"timeout" json:"timeout,omitempty"`
+	ConnectTimeout *int `mapstructure:"connect_timeout" json:"connect_timeout,omitempty"`
+}
+
+// Guard is the guard section. Redact sends a diff with likely secrets replaced,
+// instead of refusing to send it.
+type Guard struct {
+	Redact bool `mapstructure:"redact" json:"redact"`
+}
+
+// BranchNaming is the branch_naming section. Pattern lays a name out from {type},
+// {ticket} and {slug}, dropping {ticket} and a separator next to it without a ticket.
+// MaxLength bounds the whole name; 0 keeps slugs to 45 characters. AllowedChars is a
+// regular expression character class, such as a-z0-9-, of the characters allowed
+// besides "/". Lowercase rejects upper case, in tickets too.
+type BranchNaming struct {
+	Pattern      string `mapstructure:"pattern" json:"pattern"`
+	MaxLength    int    `mapstructure:"max_length" json:"max_length"`
+	AllowedChars string `mapstructure:"allowed_chars" json:"allowed_chars"`
+	Lowercase    bool   `mapstructure:"lowercase" json:"lowercase"`
+}
+
+// branchPlaceholderPattern matches the placeholders of a branch_naming pattern.
+var branchPlaceholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// CheckPattern reports why pattern is not a branch_naming pattern: it needs {slug} and
+// knows only {type}, {ticket} and {slug}.
+func (n BranchNaming) CheckPattern() error {
+	if !strings.Contains(n.Pattern, "{slug}") {
+		return fmt.Errorf("must contain {slug}, such as %s, got %q", DefaultBranchPattern, n.Pattern)
+	}
+	for _, placeholder := range branchPlaceholderPattern.FindAllString(n.Pattern, -1) {
+		if placeholder != "{type}" && placeholder != "{ticket}" && placeholder != "{slug}" {
+			return fmt.Errorf("unknown placeholder %s: use {type}, {ticket} and {slug}", placeholder)
+		}
+	}
+	return nil
+}
+
+// Disallowed returns a regular expression matching the runs of characters outside
+// AllowedChars and "/", or nil when AllowedChars is empty.
+func (n BranchNaming) Disallowed() (*regexp.Regexp, error) {
+	if n.AllowedChars == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("[^/" + n.AllowedChars + "]+")
+	if err != nil {
+		return nil, fmt.Errorf("must be a character class such as a-z0-9-, got %q", n.AllowedChars)
+	}
+	return re, nil
+}
+
+// ScopeRule maps the files under Path to a Conventional Commits scope.
+type ScopeRule struct {
+	Path  string `mapstructure:"path" yaml:"path" json:"path"`
+	Scope string `mapstructure:"scope" yaml:"scope" json:"scope"`
+}
+
+// RiskPolicy flags a commit as risky when it touches a file matching Paths, or when it
+// changes more than MaxLines lines. A zero MaxLines does not limit the size.
+type RiskPolicy struct {
+	Name     string   `mapstructure:"name" yaml:"name" json:"name"`
+	Paths    []string `mapstructure:"paths" yaml:"paths,omitempty" json:"paths,omitempty"`
+	MaxLines int      `mapstructure:"max_lines" yaml:"max_lines,omitempty" json:"max_lines,omitempty"`
+}
+
+// Trailer is a "Key: value" line of a commit message's trailer block.
+type Trailer struct {
+	Key   string `mapstructure:"key" yaml:"key" json:"key"`
+	Value string `mapstructure:"value" yaml:"value" json:"value"`
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// trailerKeyPattern matches the trailer keys git interpret-trailers accepts as given.
+var trailerKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// IsValidTrailerKey reports whether key is a trailer key, such as Co-authored-by.
+func IsValidTrailerKey(key string) bool {
+	return trailerKeyPattern.MatchString(key)
+}
+
+// ParseTrailer parses a --trailer value, "key=value" or "key: value".
+func ParseTrailer(s string) (Trailer, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if colonKey, colonValue, found := strings.Cut(s, ":"); found && (!ok || len(colonKey) < len(key)) {
+		key, value, ok = colonKey, colonValue, true
+	}
+	trailer := Trailer{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)}
+	if !ok || trailer.Value == "" {
+		return Trailer{}, fmt.Errorf("invalid trailer %q: want key=value, such as Reviewed-by=Jane Doe <jane@example.com>", s)
+	}
+	if !IsValidTrailerKey(trailer.Key) {
+		return Trailer{}, fmt.Errorf("invalid trailer key %q: use letters, digits and dashes, such as Co-authored-by",
+			trailer.Key)
+	}
+	return trailer, nil
 }
 
 const (
@@ -30,7 +251,58 @@ const (
 	EnvPrefix             = "GMC"
 )
 
-var configFilePath string
+// DefaultBranchPattern is the branch_naming pattern default, as in feature/add-login.
+const DefaultBranchPattern = "{type}/{slug}"
+
+// DefaultPackageGlob is the package_globs default.
+const DefaultPackageGlob = "packages/*"
+
+// Defaults for summarize_parallelism and summarize_timeout, in seconds.
+const (
+	DefaultSummarizeParallelism = 8
+	DefaultSummarizeTimeout     = 20
+)
+
+// type_hints values: "soft" adds the inferred type to the prompt, "strict" also
+// forces it onto the generated message, and "off" disables inference.
+const (
+	TypeHintsOff    = "off"
+	TypeHintsSoft   = "soft"
+	TypeHintsStrict = "strict"
+)
+
+// emoji_style values.
+const (
+	EmojiStyleConventional = "conventional"
+	EmojiStyleGitmoji      = "gitmoji"
+)
+
+// git_backend values.
+const (
+	GitBackendNative = "native"
+	GitBackendGoGit  = "go-git"
+)
+
+var configFilePath, configSource string
+
+// workDir is the directory project config is looked up from, see SetWorkDir.
+var workDir string
+
+// SetWorkDir makes the project config lookups start at dir instead of the process
+// working directory, for gmc -C.
+func SetWorkDir(dir string) {
+	workDir = dir
+}
+
+// currentDir returns the directory set by SetWorkDir, or the process working directory.
+func currentDir() (string, error) {
+	if workDir != "" {
+		return workDir, nil
+	}
+	return os.Getwd()
+}
+
+var languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
 
 var suggestedRoles = []string{
 	"Developer",
@@ -47,57 +319,75 @@ var suggestedModels = []string{
 	"gpt-4-turbo",
 }
 
-// getConfigPath returns the config path following priority:
+// Sources of the user config file, as Layers reports them.
+const (
+	SourceFlag   = "--config flag"
+	SourceEnv    = "GMC_CONFIG"
+	SourceXDG    = "XDG config"
+	SourceLegacy = "legacy ~/.gmc.yaml"
+)
+
+// getConfigPath returns the config path and its source, following priority:
 // 1. Explicit --config flag
 // 2. GMC_CONFIG env var
 // 3. $XDG_CONFIG_HOME/gmc/config.yaml
 // 4. ~/.config/gmc/config.yaml (XDG default)
 // 5. ~/.gmc.yaml (legacy fallback)
-func getConfigPath(cfgFile string) (string, error) {
+func getConfigPath(cfgFile string) (string, string, error) {
 	// 1. Explicit config file
 	if cfgFile != "" {
-		return cfgFile, nil
+		return cfgFile, SourceFlag, nil
 	}
 
 	// 2. GMC_CONFIG env var
 	if envConfig := os.Getenv("GMC_CONFIG"); envConfig != "" {
-		return envConfig, nil
+		return envConfig, SourceEnv, nil
 	}
 
-	home, err := os.UserHomeDir()
+	// 3/4. XDG_CONFIG_HOME, defaulting to ~/.config
+	xdgConfigPath, legacyPath, err := userConfigPaths()
 	if err != nil {
-		return "", fmt.Errorf("failed to find home directory: %w", err)
-	}
-
-	// 3. XDG_CONFIG_HOME
-	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
-	if xdgConfigHome == "" {
-		xdgConfigHome = filepath.Join(home, ".config")
+		return "", "", err
 	}
 
-	xdgConfigPath := filepath.Join(xdgConfigHome, DefaultConfigDir, DefaultConfigName+".yaml")
-
 	// Check if XDG config exists
 	if _, err := os.Stat(xdgConfigPath); err == nil {
-		return xdgConfigPath, nil
+		return xdgConfigPath, SourceXDG, nil
 	}
 
-	// 4. Check legacy path
-	legacyPath := filepath.Join(home, LegacyConfigName+".yaml")
+	// 5. Check legacy path
 	if _, err := os.Stat(legacyPath); err == nil {
-		return legacyPath, nil
+		return legacyPath, SourceLegacy, nil
+	}
+
+	// Default to XDG path for new installations
+	return xdgConfigPath, SourceXDG, nil
+}
+
+// userConfigPaths returns the XDG config path and the legacy ~/.gmc.yaml path.
+func userConfigPaths() (xdgPath string, legacyPath string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find home directory: %w", err)
 	}
 
-	// 5. Default to XDG path for new installations
-	return xdgConfigPath, nil
+	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfigHome == "" {
+		xdgConfigHome = filepath.Join(home, ".config")
+	}
+
+	xdgPath = filepath.Join(xdgConfigHome, DefaultConfigDir, DefaultConfigName+".yaml")
+	legacyPath = filepath.Join(home, LegacyConfigName+".yaml")
+	return xdgPath, legacyPath, nil
 }
 
 func InitConfig(cfgFile string) error {
-	configPath, err := getConfigPath(cfgFile)
+	configPath, source, err := getConfigPath(cfgFile)
 	if err != nil {
 		return err
 	}
 	configFilePath = configPath
+	configSource = source
 
 	viper.SetConfigFile(configPath)
 	viper.SetConfigType("yaml")
@@ -108,7 +398,50 @@ func InitConfig(cfgFile string) error {
 	viper.SetDefault("api_key", "")
 	viper.SetDefault("api_base", "")
 	viper.SetDefault("prompt_template", DefaultPromptTemplate)
+	viper.SetDefault("fallback_template", DefaultPromptTemplate)
+	viper.SetDefault("review_template", DefaultPromptTemplate)
 	viper.SetDefault("enable_emoji", false)
+	viper.SetDefault("emoji_style", EmojiStyleConventional)
+	viper.SetDefault("issue_context", false)
+	viper.SetDefault("issue_pattern", "")
+	viper.SetDefault("issue_format", "")
+	viper.SetDefault("jira_url", "")
+	viper.SetDefault("jira_email", "")
+	viper.SetDefault("jira_token", "")
+	viper.SetDefault("github_token", "")
+	viper.SetDefault("gitlab_token", "")
+	viper.SetDefault("gitea_token", "")
+	viper.SetDefault("forge", "")
+	viper.SetDefault("type_hints", TypeHintsSoft)
+	viper.SetDefault("language", "")
+	viper.SetDefault("commit_body", false)
+	viper.SetDefault("tag_template", "")
+	viper.SetDefault("generation_notes", false)
+	viper.SetDefault("attribution", true)
+	viper.SetDefault("sign_commits", false)
+	viper.SetDefault("hook_autofix_retry", true)
+	viper.SetDefault("preflight", false)
+	viper.SetDefault("max_tokens", 0)
+	viper.SetDefault("summarize_diffs", false)
+	viper.SetDefault("summarize_parallelism", DefaultSummarizeParallelism)
+	viper.SetDefault("summarize_timeout", DefaultSummarizeTimeout)
+	viper.SetDefault("summarize_threshold", 0)
+	viper.SetDefault("outline_new_files", false)
+	viper.SetDefault("git_backend", GitBackendNative)
+	viper.SetDefault("package_globs", []string{DefaultPackageGlob})
+	viper.SetDefault("http_proxy", "")
+	viper.SetDefault("ca_cert", "")
+	viper.SetDefault("tls_insecure", false)
+	viper.SetDefault("defaults.add_all", false)
+	viper.SetDefault("defaults.auto_yes", false)
+	viper.SetDefault("defaults.dry_run", false)
+	viper.SetDefault("defaults.no_verify", false)
+	viper.SetDefault("defaults.verbose", false)
+	viper.SetDefault("guard.redact", false)
+	viper.SetDefault("branch_naming.pattern", DefaultBranchPattern)
+	viper.SetDefault("branch_naming.max_length", 0)
+	viper.SetDefault("branch_naming.allowed_chars", "")
+	viper.SetDefault("branch_naming.lowercase", false)
 
 	// Enable GMC_ prefixed environment variables
 	// GMC_MODEL, GMC_API_KEY, GMC_API_BASE, etc.
@@ -139,8 +472,8 @@ func InitConfig(cfgFile string) error {
 		}
 	}
 
-	// Merge repo-level config if exists (higher priority than user config)
-	if repoConfig := findRepoConfig(); repoConfig != "" {
+	// Merge repo-level configs if they exist (higher priority than user config)
+	for _, repoConfig := range findRepoConfigs() {
 		repoViper := viper.New()
 		repoViper.SetConfigFile(repoConfig)
 		if err := repoViper.ReadInConfig(); err == nil {
@@ -150,27 +483,73 @@ func InitConfig(cfgFile string) error {
 		}
 	}
 
-	return nil
+	return validateLoaded()
+}
+
+// FilePath returns the user config file resolved by InitConfig.
+func FilePath() string {
+	return configFilePath
+}
+
+// StateDir returns $XDG_STATE_HOME/gmc, defaulting to ~/.local/state/gmc, where gmc keeps
+// logs and usage totals.
+func StateDir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, DefaultConfigDir), nil
 }
 
-// findRepoConfig searches for .gmc.yaml in the current working directory.
-func findRepoConfig() string {
-	cwd, err := os.Getwd()
+// UserDir returns $XDG_CONFIG_HOME/gmc, which holds the config file and the user's
+// copies of built-in files.
+func UserDir() (string, error) {
+	xdgPath, _, err := userConfigPaths()
 	if err != nil {
-		return ""
+		return "", err
+	}
+	return filepath.Dir(xdgPath), nil
+}
+
+// UserTemplatesDir returns $XDG_CONFIG_HOME/gmc/templates, where personal prompt templates live.
+func UserTemplatesDir() (string, error) {
+	dir, err := UserDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// findRepoConfigs returns the project config files of the current directory that
+// exist, in the order they are merged: the bare project root's, then the worktree's
+// (see RepoConfigLocations).
+func findRepoConfigs() []string {
+	cwd, err := currentDir()
+	if err != nil {
+		return nil
 	}
-	repoConfigPath := filepath.Join(cwd, LegacyConfigName+".yaml")
-	if _, err := os.Stat(repoConfigPath); err == nil {
-		return repoConfigPath
+	project, projectRoot := RepoConfigLocations(cwd)
+	var files []string
+	for _, path := range []string{projectRoot, project} {
+		if fileExists(path) {
+			files = append(files, path)
+		}
 	}
-	return ""
+	return files
 }
 
+// GetConfig returns the loaded configuration, with the ${VAR} references of its values
+// expanded from the environment.
 func GetConfig() (*Config, error) {
 	cfg := defaultConfig()
 	if err := viper.Unmarshal(cfg); err != nil {
 		return cfg, fmt.Errorf("failed to parse configuration: %w", err)
 	}
+	expandConfigEnv(cfg)
 	return cfg, nil
 }
 
@@ -184,13 +563,102 @@ func MustGetConfig() *Config {
 
 func defaultConfig() *Config {
 	return &Config{
-		Role:           DefaultRole,
-		Model:          DefaultModel,
-		APIKey:         "",
-		APIBase:        "",
-		PromptTemplate: DefaultPromptTemplate,
-		EnableEmoji:    false,
+		Role:                 DefaultRole,
+		Model:                DefaultModel,
+		APIKey:               "",
+		APIBase:              "",
+		PromptTemplate:       DefaultPromptTemplate,
+		FallbackTemplate:     DefaultPromptTemplate,
+		ReviewTemplate:       DefaultPromptTemplate,
+		EnableEmoji:          false,
+		IssueContext:         false,
+		IssuePattern:         "",
+		IssueFormat:          "",
+		JiraURL:              "",
+		JiraEmail:            "",
+		JiraToken:            "",
+		GitHubToken:          "",
+		GitLabToken:          "",
+		GiteaToken:           "",
+		Forge:                "",
+		TypeHints:            TypeHintsSoft,
+		Language:             "",
+		CommitBody:           false,
+		TagTemplate:          "",
+		GenerationNotes:      false,
+		Attribution:          true,
+		SignCommits:          false,
+		HookAutofixRetry:     true,
+		Preflight:            false,
+		SummarizeDiffs:       false,
+		SummarizeParallelism: DefaultSummarizeParallelism,
+		SummarizeTimeout:     DefaultSummarizeTimeout,
+		SummarizeThreshold:   0,
+		OutlineNewFiles:      false,
+		GitBackend:           GitBackendNative,
+		PackageGlobs:         []string{DefaultPackageGlob},
+		HTTPProxy:            "",
+		CACert:               "",
+		TLSInsecure:          false,
+		BranchNaming:         BranchNaming{Pattern: DefaultBranchPattern},
+		EmojiStyle:           EmojiStyleConventional,
+	}
+}
+
+// ExecPreset returns the command of the named exec preset. Names are case-insensitive
+// because viper lower-cases map keys.
+func (c *Config) ExecPreset(name string) (string, bool) {
+	command, ok := c.ExecPresets[strings.ToLower(name)]
+	return command, ok && strings.TrimSpace(command) != ""
+}
+
+var componentNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// headerNamePattern matches the token characters of RFC 9110 field names.
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// IsValidHeaderName reports whether name is an HTTP header name, such as X-Org-Id.
+func IsValidHeaderName(name string) bool {
+	return headerNamePattern.MatchString(name)
+}
+
+// IsValidComponentName reports whether name can prefix a component tag, as api does
+// in api/v1.4.0.
+func IsValidComponentName(name string) bool {
+	return componentNamePattern.MatchString(name)
+}
+
+// Component returns the directory of the named component. Names are case-insensitive
+// because viper lower-cases map keys.
+func (c *Config) Component(name string) (string, bool) {
+	dir, ok := c.Components[strings.ToLower(name)]
+	return dir, ok && strings.TrimSpace(dir) != ""
+}
+
+// AllowedCommitTypes returns the commit_types allowlist in lower case, or nil when
+// every type is allowed.
+func (c *Config) AllowedCommitTypes() []string {
+	var types []string
+	for _, commitType := range c.CommitTypes {
+		if commitType = strings.ToLower(strings.TrimSpace(commitType)); commitType != "" {
+			types = append(types, commitType)
+		}
 	}
+	return types
+}
+
+// AllowsCommitType reports whether commitType passes the commit_types allowlist.
+func (c *Config) AllowsCommitType(commitType string) bool {
+	allowed := c.AllowedCommitTypes()
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, t := range allowed {
+		if strings.EqualFold(t, commitType) {
+			return true
+		}
+	}
+	return false
 }
 
 func SaveConfig() error {
@@ -204,6 +672,65 @@ func SetConfigValue(key string, value any) {
 	viper.Set(key, value)
 }
 
+// ResolveForgeToken returns the configured token for a forge kind ("github", "gitlab"
+// or "gitea"), falling back to the conventional GITHUB_TOKEN/GITLAB_TOKEN/GITEA_TOKEN.
+func (c *Config) ResolveForgeToken(kind string) string {
+	var configured, envName string
+	switch kind {
+	case "github":
+		envName = "GITHUB_TOKEN"
+		if c != nil {
+			configured = c.GitHubToken
+		}
+	case "gitlab":
+		envName = "GITLAB_TOKEN"
+		if c != nil {
+			configured = c.GitLabToken
+		}
+	case "gitea":
+		envName = "GITEA_TOKEN"
+		if c != nil {
+			configured = c.GiteaToken
+		}
+	default:
+		return ""
+	}
+
+	if configured != "" {
+		return configured
+	}
+	return os.Getenv(envName)
+}
+
+// ResolveJiraToken returns the configured jira_token, falling back to JIRA_TOKEN.
+func (c *Config) ResolveJiraToken() string {
+	if c != nil && c.JiraToken != "" {
+		return c.JiraToken
+	}
+	return os.Getenv("JIRA_TOKEN")
+}
+
+// IsValidTypeHints reports whether value is a supported type_hints mode.
+func IsValidTypeHints(value string) bool {
+	switch value {
+	case TypeHintsOff, TypeHintsSoft, TypeHintsStrict:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsValidGitBackend reports whether value is a supported git_backend.
+func IsValidGitBackend(value string) bool {
+	return value == GitBackendNative || value == GitBackendGoGit
+}
+
+// IsValidLanguage reports whether value looks like a language tag such as "ja" or "zh-CN".
+// An empty value is valid and means English.
+func IsValidLanguage(value string) bool {
+	return value == "" || languagePattern.MatchString(value)
+}
+
 func IsValidRole(role string) bool {
 	return role != ""
 }
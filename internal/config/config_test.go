@@ -401,3 +401,94 @@ func TestInitConfig_HomeDirectoryError(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to find home directory")
 	}
 }
+
+func TestResolveForgeToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "env-gh")
+	t.Setenv("GITLAB_TOKEN", "env-gl")
+	t.Setenv("GITEA_TOKEN", "")
+
+	cfg := &Config{GitHubToken: "cfg-gh"}
+	assert.Equal(t, "cfg-gh", cfg.ResolveForgeToken("github"))
+	assert.Equal(t, "env-gl", cfg.ResolveForgeToken("gitlab"))
+	assert.Empty(t, cfg.ResolveForgeToken("gitea"))
+	assert.Empty(t, cfg.ResolveForgeToken("bitbucket"))
+}
+
+func TestExecPreset(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader("exec_presets:\n  Test: go test ./...\n  lint: \"\"\n")))
+
+	cfg, err := GetConfig()
+	require.NoError(t, err)
+
+	command, ok := cfg.ExecPreset("test")
+	assert.True(t, ok)
+	assert.Equal(t, "go test ./...", command)
+	_, ok = cfg.ExecPreset("TEST")
+	assert.True(t, ok)
+	_, ok = cfg.ExecPreset("lint")
+	assert.False(t, ok, "empty presets are ignored")
+	_, ok = cfg.ExecPreset("build")
+	assert.False(t, ok)
+}
+
+func TestUserTemplatesDir(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	dir, err := UserTemplatesDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(xdg, "gmc", "templates"), dir)
+}
+
+func TestAllowsCommitType(t *testing.T) {
+	cfg := &Config{}
+	assert.Nil(t, cfg.AllowedCommitTypes())
+	assert.True(t, cfg.AllowsCommitType("chore"), "no allowlist allows every type")
+
+	cfg.CommitTypes = []string{"feat", " Fix ", "infra", ""}
+	assert.Equal(t, []string{"feat", "fix", "infra"}, cfg.AllowedCommitTypes())
+	assert.True(t, cfg.AllowsCommitType("fix"))
+	assert.True(t, cfg.AllowsCommitType("INFRA"))
+	assert.False(t, cfg.AllowsCommitType("chore"))
+}
+
+func TestParseTrailer(t *testing.T) {
+	for input, want := range map[string]Trailer{
+		"Reviewed-by=Jane Doe <jane@example.com>": {Key: "Reviewed-by", Value: "Jane Doe <jane@example.com>"},
+		"Refs: PROJ-12":                  {Key: "Refs", Value: "PROJ-12"},
+		"Link=https://example.com/a":     {Key: "Link", Value: "https://example.com/a"},
+		"Link: https://example.com/?a=b": {Key: "Link", Value: "https://example.com/?a=b"},
+	} {
+		got, err := ParseTrailer(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"Refs", "Refs=", "Bad Key=x", "=x"} {
+		_, err := ParseTrailer(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestInitConfig_RepoConfigPrecedence(t *testing.T) {
+	proj := t.TempDir()
+	main := filepath.Join(proj, "main")
+	require.NoError(t, os.Mkdir(filepath.Join(proj, ".bare"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(main, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(main, ".git"), []byte("gitdir: ../.bare/worktrees/main\n"), 0o644))
+	require.NoError(t, os.WriteFile(RepoConfigPath(proj), []byte("model: shared-model\nrole: Tester\n"), 0o644))
+	require.NoError(t, os.WriteFile(RepoConfigPath(main), []byte("model: worktree-model\n"), 0o644))
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("model: user-model\nrole: Developer\nlanguage: fr\n"), 0o600))
+
+	t.Chdir(filepath.Join(main, "sub"))
+	viper.Reset()
+	require.NoError(t, InitConfig(configFile))
+
+	assert.Equal(t, "worktree-model", viper.GetString("model"))
+	assert.Equal(t, "Tester", viper.GetString("role"))
+	assert.Equal(t, "fr", viper.GetString("language"))
+	assert.Equal(t, []string{RepoConfigPath(proj), RepoConfigPath(main)}, findRepoConfigs())
+}
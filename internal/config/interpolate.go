@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+)
+
+// envRefPattern matches ${VAR} and ${VAR:-default} references in config values, and
+// $${ which escapes a literal ${.
+var envRefPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// ExpandEnv replaces ${VAR} references in value with the environment variable VAR, or
+// with default for ${VAR:-default} when VAR is unset or empty. Unset variables without
+// a default expand to "".
+func ExpandEnv(value string) string {
+	return envRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		m := envRefPattern.FindStringSubmatch(ref)
+		if v := os.Getenv(m[1]); v != "" {
+			return v
+		}
+		return m[2]
+	})
+}
+
+// unsetEnvRefs returns the variables value references that are unset and have no default.
+func unsetEnvRefs(value string) []string {
+	var names []string
+	for _, m := range envRefPattern.FindAllStringSubmatch(value, -1) {
+		if m[0] == "$${" || os.Getenv(m[1]) != "" || len(m[0]) > len("${"+m[1]+"}") {
+			continue
+		}
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// expandConfigEnv expands the environment references of every string in cfg, including
+// those in lists, maps and nested rules.
+func expandConfigEnv(cfg *Config) {
+	expandValue(reflect.ValueOf(cfg).Elem())
+}
+
+func expandValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(ExpandEnv(v.String()))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			expandValue(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandValue(v.Index(i))
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			v.SetMapIndex(key, reflect.ValueOf(ExpandEnv(v.MapIndex(key).String())))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("GMC_TEST_KEY", "sk-123")
+	t.Setenv("GMC_TEST_EMPTY", "")
+
+	assert.Equal(t, "sk-123", ExpandEnv("${GMC_TEST_KEY}"))
+	assert.Equal(t, "Bearer sk-123!", ExpandEnv("Bearer ${GMC_TEST_KEY}!"))
+	assert.Equal(t, "", ExpandEnv("${GMC_TEST_UNSET}"))
+	assert.Equal(t, "gpt-4o", ExpandEnv("${GMC_TEST_EMPTY:-gpt-4o}"))
+	assert.Equal(t, "sk-123", ExpandEnv("${GMC_TEST_KEY:-fallback}"))
+	assert.Equal(t, "${GMC_TEST_KEY} and $HOME", ExpandEnv("$${GMC_TEST_KEY} and $HOME"))
+
+	assert.Equal(t, []string{"GMC_TEST_UNSET"},
+		unsetEnvRefs("${GMC_TEST_KEY}${GMC_TEST_UNSET}${GMC_TEST_OTHER:-x}$${GMC_TEST_ESCAPED}"))
+}
+
+func TestGetConfigExpandsEnv(t *testing.T) {
+	t.Setenv("GMC_TEST_KEY", "sk-123")
+	t.Setenv("GMC_TEST_TOOL", "golangci-lint")
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`api_key: ${GMC_TEST_KEY}
+api_base: ${GMC_TEST_BASE:-https://llm.internal/v1}
+exec_presets:
+  lint: ${GMC_TEST_TOOL} run
+scope_rules:
+  - path: ${GMC_TEST_UNSET}api/
+    scope: api
+`), 0o600))
+
+	viper.Reset()
+	require.NoError(t, InitConfig(configFile))
+	assert.Equal(t, "scope_rules[0].path", Warnings()[0].Key)
+	assert.Equal(t, `references ${GMC_TEST_UNSET}, which is not set; it expands to ""`, Warnings()[0].Message)
+
+	cfg, err := GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "sk-123", cfg.APIKey)
+	assert.Equal(t, "https://llm.internal/v1", cfg.APIBase)
+	assert.Equal(t, "golangci-lint run", cfg.ExecPresets["lint"])
+	assert.Equal(t, "api/", cfg.ScopeRules[0].Path)
+	assert.Equal(t, "${GMC_TEST_KEY}", viper.GetString("api_key"), "the file keeps the reference")
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Layer names, in the order gmc applies them.
+const (
+	LayerUser    = "user"
+	LayerShared  = "shared"
+	LayerProject = "project"
+)
+
+// Layer is a config file gmc reads. Later layers override earlier ones.
+type Layer struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Source string `json:"source,omitempty"`
+	Exists bool   `json:"exists"`
+}
+
+// EnvOverride is a GMC_ environment variable that overrides a config key.
+type EnvOverride struct {
+	Variable string `json:"variable"`
+	Key      string `json:"key"`
+}
+
+// Layers returns the user config file InitConfig resolved and the project .gmc.yaml
+// files of the current directory, whether or not they exist. The shared layer, the
+// .gmc.yaml above .bare, is only listed in a bare + worktree project.
+func Layers() []Layer {
+	userPath := configFilePath
+	if abs, err := filepath.Abs(userPath); err == nil && userPath != "" {
+		userPath = abs
+	}
+	layers := []Layer{{Name: LayerUser, Path: userPath, Source: configSource, Exists: fileExists(userPath)}}
+	if cwd, err := currentDir(); err == nil {
+		project, projectRoot := RepoConfigLocations(cwd)
+		if projectRoot != "" {
+			layers = append(layers, Layer{Name: LayerShared, Path: projectRoot, Exists: fileExists(projectRoot)})
+		}
+		layers = append(layers, Layer{Name: LayerProject, Path: project, Exists: fileExists(project)})
+	}
+	return layers
+}
+
+// EnvOverrides returns the GMC_ environment variables that override config keys,
+// sorted by key.
+func EnvOverrides() []EnvOverride {
+	var overrides []EnvOverride
+	for key := range schemaFields(reflect.TypeOf(Config{})) {
+		variable := EnvPrefix + "_" + strings.ToUpper(key)
+		if os.Getenv(variable) != "" {
+			overrides = append(overrides, EnvOverride{Variable: variable, Key: key})
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Key < overrides[j].Key })
+	return overrides
+}
+
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Keys returns the config keys, sorted.
+func Keys() []string {
+	fields := schemaFields(reflect.TypeOf(Config{}))
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayers(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	viper.Reset()
+	require.NoError(t, InitConfig(configFile))
+
+	layers := Layers()
+	require.Len(t, layers, 2)
+	assert.Equal(t, Layer{Name: LayerUser, Path: configFile, Source: SourceFlag, Exists: true}, layers[0])
+	assert.Equal(t, LayerProject, layers[1].Name)
+	assert.Equal(t, RepoConfigFile, filepath.Base(layers[1].Path))
+	assert.False(t, layers[1].Exists)
+}
+
+func TestEnvOverrides(t *testing.T) {
+	for _, key := range Keys() {
+		variable := EnvPrefix + "_" + strings.ToUpper(key)
+		t.Setenv(variable, "")
+		os.Unsetenv(variable)
+	}
+	t.Setenv("GMC_MODEL", "gpt-4")
+	t.Setenv("GMC_API_BASE", "https://example.com/v1")
+	t.Setenv("GMC_NOT_A_KEY", "1")
+
+	assert.Equal(t, []EnvOverride{
+		{Variable: "GMC_API_BASE", Key: "api_base"},
+		{Variable: "GMC_MODEL", Key: "model"},
+	}, EnvOverrides())
+	assert.Contains(t, Keys(), "package_globs")
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// legacyPointerHeader starts the pointer file left at ~/.gmc.yaml after migration.
+const legacyPointerHeader = "# gmc configuration moved to "
+
+// renamedKeys maps deprecated or misspelled keys to their current names.
+// apikey/apibase match the `gmc config set` subcommand names and are a common hand-edit.
+var renamedKeys = map[string]string{
+	"apikey":             "api_key",
+	"apibase":            "api_base",
+	"custom_prompts_dir": "prompts_dir",
+}
+
+// MigrateOptions controls MigrateLegacyConfig.
+type MigrateOptions struct {
+	// DryRun reports the planned actions without touching the filesystem.
+	DryRun bool
+	// Force overwrites an existing XDG config file.
+	Force bool
+}
+
+// MigrateResult reports what MigrateLegacyConfig did, in order. Skipped explains why
+// nothing was migrated, if so.
+type MigrateResult struct {
+	LegacyPath string   `json:"legacy_path"`
+	TargetPath string   `json:"target_path"`
+	Migrated   bool     `json:"migrated"`
+	DryRun     bool     `json:"dry_run"`
+	Skipped    string   `json:"skipped,omitempty"`
+	Actions    []string `json:"actions"`
+}
+
+func (r *MigrateResult) add(format string, args ...any) {
+	r.Actions = append(r.Actions, fmt.Sprintf(format, args...))
+}
+
+// MigrateLegacyConfig moves ~/.gmc.yaml to $XDG_CONFIG_HOME/gmc/config.yaml, rewriting
+// deprecated keys, securing the new file to 0600, and leaving a pointer file behind.
+func MigrateLegacyConfig(opts MigrateOptions) (*MigrateResult, error) {
+	targetPath, legacyPath, err := userConfigPaths()
+	if err != nil {
+		return nil, err
+	}
+	result := &MigrateResult{LegacyPath: legacyPath, TargetPath: targetPath, DryRun: opts.DryRun}
+
+	content, err := os.ReadFile(legacyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			result.Skipped = fmt.Sprintf("no legacy config found at %s; nothing to migrate", legacyPath)
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to read legacy configuration: %w", err)
+	}
+	if bytes.HasPrefix(content, []byte(legacyPointerHeader)) {
+		result.Skipped = fmt.Sprintf("%s already points to the migrated config; nothing to migrate", legacyPath)
+		return result, nil
+	}
+
+	if _, err := os.Stat(targetPath); err == nil {
+		if !opts.Force {
+			return nil, fmt.Errorf(
+				"%s already exists and takes precedence over %s; use --force to overwrite it",
+				targetPath, legacyPath)
+		}
+		result.add("overwrite existing %s", targetPath)
+	}
+
+	migrated, err := rewriteDeprecatedKeys(content, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse legacy configuration %s: %w", legacyPath, err)
+	}
+
+	result.add("write %s", targetPath)
+	result.add("set permissions on %s to 0600", targetPath)
+	result.add("replace %s with a pointer to %s", legacyPath, targetPath)
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create configuration directory: %w", err)
+	}
+	if err := os.WriteFile(targetPath, migrated, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write configuration file: %w", err)
+	}
+	if err := enforceConfigFilePermissions(targetPath); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(legacyPath, []byte(legacyPointer(targetPath)), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write pointer file %s: %w", legacyPath, err)
+	}
+
+	result.Migrated = true
+	return result, nil
+}
+
+func legacyPointer(targetPath string) string {
+	return legacyPointerHeader + targetPath + " by `gmc config migrate`.\n" +
+		"# Edit that file instead; this one is kept only as a pointer.\n"
+}
+
+// rewriteDeprecatedKeys renames deprecated top-level keys while preserving comments and order.
+func rewriteDeprecatedKeys(content []byte, result *MigrateResult) ([]byte, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return content, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping of configuration keys")
+	}
+	root := doc.Content[0]
+
+	changed := false
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		key := root.Content[i]
+		newName, ok := renamedKeys[key.Value]
+		if !ok {
+			continue
+		}
+		if mappingValue(root, newName) != nil {
+			result.add("drop deprecated key %s (%s is already set)", key.Value, newName)
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			i -= 2
+		} else {
+			result.add("rename key %s to %s", key.Value, newName)
+			key.Value = newName
+		}
+		changed = true
+	}
+
+	if resolvePromptsDir(root, result) {
+		changed = true
+	}
+
+	if !changed {
+		return content, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resolvePromptsDir folds the removed prompts_dir key into prompt_template, which now
+// takes a file path instead of a template name looked up in prompts_dir.
+func resolvePromptsDir(root *yaml.Node, result *MigrateResult) bool {
+	dirIndex := mappingIndex(root, "prompts_dir")
+	if dirIndex < 0 {
+		return false
+	}
+	dir := root.Content[dirIndex+1].Value
+
+	if template := mappingValue(root, "prompt_template"); template != nil {
+		name := template.Value
+		if name != "" && name != DefaultPromptTemplate && !strings.ContainsAny(name, `/\`) {
+			if path := findPromptFile(dir, name); path != "" {
+				result.add("rewrite prompt_template %s to %s", name, path)
+				template.Value = path
+			} else {
+				result.add("leave prompt_template %s unchanged (not found in %s)", name, dir)
+			}
+		}
+	}
+
+	result.add("drop removed key prompts_dir")
+	root.Content = append(root.Content[:dirIndex], root.Content[dirIndex+2:]...)
+	return true
+}
+
+func findPromptFile(dir, name string) string {
+	if strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+	candidates := []string{name}
+	if filepath.Ext(name) == "" {
+		candidates = append(candidates, name+".yaml", name+".yml")
+	}
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(node, key); i >= 0 {
+		return node.Content[i+1]
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMigrateHome(t *testing.T) (home string, legacyPath string, targetPath string) {
+	t.Helper()
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	return home, filepath.Join(home, ".gmc.yaml"), filepath.Join(home, ".config", "gmc", "config.yaml")
+}
+
+func TestMigrateLegacyConfig_NoLegacyFile(t *testing.T) {
+	setupMigrateHome(t)
+
+	result, err := MigrateLegacyConfig(MigrateOptions{})
+	require.NoError(t, err)
+	assert.False(t, result.Migrated)
+	assert.Contains(t, result.Skipped, "no legacy config found")
+}
+
+func TestMigrateLegacyConfig_MovesAndRewritesKeys(t *testing.T) {
+	home, legacyPath, targetPath := setupMigrateHome(t)
+
+	promptsDir := filepath.Join(home, "prompts")
+	require.NoError(t, os.MkdirAll(promptsDir, 0o755))
+	templatePath := filepath.Join(promptsDir, "short.yaml")
+	require.NoError(t, os.WriteFile(templatePath, []byte("template: x"), 0o600))
+
+	legacy := "# my settings\n" +
+		"model: gpt-4\n" +
+		"apikey: sk-test\n" +
+		"apibase: https://proxy.example\n" +
+		"api_base: https://kept.example\n" +
+		"custom_prompts_dir: " + promptsDir + "\n" +
+		"prompt_template: short\n"
+	require.NoError(t, os.WriteFile(legacyPath, []byte(legacy), 0o644))
+
+	result, err := MigrateLegacyConfig(MigrateOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.Migrated)
+	assert.Contains(t, result.Actions, "rename key apikey to api_key")
+	assert.Contains(t, result.Actions, "drop deprecated key apibase (api_base is already set)")
+	assert.Contains(t, result.Actions, "rewrite prompt_template short to "+templatePath)
+	assert.Contains(t, result.Actions, "drop removed key prompts_dir")
+
+	migrated, err := os.ReadFile(targetPath)
+	require.NoError(t, err)
+	content := string(migrated)
+	assert.Contains(t, content, "# my settings")
+	assert.Contains(t, content, "api_key: sk-test")
+	assert.Contains(t, content, "api_base: https://kept.example")
+	assert.Contains(t, content, "prompt_template: "+templatePath)
+	assert.NotContains(t, content, "apibase")
+	assert.NotContains(t, content, "prompts_dir")
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(targetPath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	pointer, err := os.ReadFile(legacyPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(pointer), "moved to "+targetPath)
+	assert.NotContains(t, string(pointer), "sk-test")
+
+	again, err := MigrateLegacyConfig(MigrateOptions{})
+	require.NoError(t, err)
+	assert.False(t, again.Migrated)
+	assert.Contains(t, again.Skipped, "already points to the migrated config")
+}
+
+func TestMigrateLegacyConfig_DryRun(t *testing.T) {
+	_, legacyPath, targetPath := setupMigrateHome(t)
+	require.NoError(t, os.WriteFile(legacyPath, []byte("model: gpt-4\n"), 0o600))
+
+	result, err := MigrateLegacyConfig(MigrateOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.False(t, result.Migrated)
+	assert.Contains(t, result.Actions, "write "+targetPath)
+
+	_, err = os.Stat(targetPath)
+	assert.True(t, os.IsNotExist(err))
+	content, err := os.ReadFile(legacyPath)
+	require.NoError(t, err)
+	assert.Equal(t, "model: gpt-4\n", string(content))
+}
+
+func TestMigrateLegacyConfig_ExistingTarget(t *testing.T) {
+	_, legacyPath, targetPath := setupMigrateHome(t)
+	require.NoError(t, os.WriteFile(legacyPath, []byte("model: gpt-4\n"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Dir(targetPath), 0o755))
+	require.NoError(t, os.WriteFile(targetPath, []byte("model: gpt-4o\n"), 0o600))
+
+	_, err := MigrateLegacyConfig(MigrateOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--force")
+
+	result, err := MigrateLegacyConfig(MigrateOptions{Force: true})
+	require.NoError(t, err)
+	assert.True(t, result.Migrated)
+	assert.Contains(t, result.Actions, "overwrite existing "+targetPath)
+
+	content, err := os.ReadFile(targetPath)
+	require.NoError(t, err)
+	assert.Equal(t, "model: gpt-4\n", string(content))
+}
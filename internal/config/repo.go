@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrKeyNotSet is returned by UnsetConfigValue for a key the file does not set.
+var ErrKeyNotSet = errors.New("key is not set")
+
+// RepoConfigFile is the name of the per-repository config file.
+const RepoConfigFile = LegacyConfigName + ".yaml"
+
+// RepoConfigPath returns the repository config file in dir.
+func RepoConfigPath(dir string) string {
+	return filepath.Join(dir, RepoConfigFile)
+}
+
+// RepoConfigLocations returns where the project config files for dir are. project is
+// the nearest .gmc.yaml from dir up to the top of its git worktree, or that top when
+// there is none; outside a worktree it is the .gmc.yaml of dir. projectRoot is the
+// .gmc.yaml above .bare when the worktree belongs to a bare + worktree project, so it
+// applies to every worktree, or "" otherwise. Neither file has to exist.
+func RepoConfigLocations(dir string) (project string, projectRoot string) {
+	var dirs []string
+	top := ""
+	for current := dir; ; {
+		dirs = append(dirs, current)
+		if _, err := os.Lstat(filepath.Join(current, ".git")); err == nil {
+			top = current
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+	if top == "" {
+		dirs = []string{dir}
+	}
+
+	project = RepoConfigPath(dirs[len(dirs)-1])
+	for _, candidate := range dirs {
+		if fileExists(RepoConfigPath(candidate)) {
+			project = RepoConfigPath(candidate)
+			break
+		}
+	}
+
+	if root := bareProjectRoot(dirs[len(dirs)-1]); root != "" && RepoConfigPath(root) != project {
+		projectRoot = RepoConfigPath(root)
+	}
+	return project, projectRoot
+}
+
+// bareProjectRoot returns the directory holding .bare at or above dir, the root of a
+// bare + worktree project, or "" when there is none.
+func bareProjectRoot(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".bare")); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// SetRepoConfigValue sets key in the repository config file at path, creating the file
+// when needed. Other keys and comments are kept.
+func SetRepoConfigValue(path string, key string, value any) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", path)
+	}
+
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1] = &valueNode
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+	}
+
+	return writeYAMLDocument(path, &doc, 0o644)
+}
+
+// UnsetConfigValue removes key from the config file at path, so the next layer or the
+// default applies. Keys match case-insensitively, as viper reads them. Other keys and
+// comments are kept.
+func UnsetConfigValue(path string, key string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s does not exist", ErrKeyNotSet, path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%w in %s", ErrKeyNotSet, path)
+	}
+
+	root := doc.Content[0]
+	removed := false
+	for i := 0; i+1 < len(root.Content); {
+		if strings.EqualFold(root.Content[i].Value, key) {
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			removed = true
+			continue
+		}
+		i += 2
+	}
+	if !removed {
+		return fmt.Errorf("%w in %s", ErrKeyNotSet, path)
+	}
+	return writeYAMLDocument(path, &doc, info.Mode().Perm())
+}
+
+func writeYAMLDocument(path string, doc *yaml.Node, perm os.FileMode) error {
+	var out bytes.Buffer
+	if len(doc.Content[0].Content) > 0 {
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, out.Bytes(), perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
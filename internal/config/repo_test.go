@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRepoConfigValue(t *testing.T) {
+	path := RepoConfigPath(t.TempDir())
+	rules := []ScopeRule{{Path: "internal/worktree", Scope: "wt"}}
+	require.NoError(t, SetRepoConfigValue(path, "scope_rules", rules))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "scope_rules:\n  - path: internal/worktree\n    scope: wt\n", string(data))
+
+	require.NoError(t, os.WriteFile(path, []byte("# team settings\ncommit_types: [feat, fix]\nscope_rules: []\n"), 0o644))
+	require.NoError(t, SetRepoConfigValue(path, "scope_rules", rules))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# team settings")
+	assert.Contains(t, string(data), "commit_types: [feat, fix]")
+	assert.Contains(t, string(data), "scope_rules:\n  - path: internal/worktree")
+	assert.Equal(t, ".gmc.yaml", filepath.Base(path))
+}
+
+func TestSetRepoConfigValueRejectsNonMapping(t *testing.T) {
+	path := RepoConfigPath(t.TempDir())
+	require.NoError(t, os.WriteFile(path, []byte("- a\n- b\n"), 0o644))
+	assert.ErrorContains(t, SetRepoConfigValue(path, "scope_rules", nil), "not a YAML mapping")
+}
+
+func TestUnsetConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("# personal settings\nmodel: gpt-4 # pinned\nRole: Dev\n"), 0o600))
+
+	require.NoError(t, UnsetConfigValue(path, "role"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# personal settings\nmodel: gpt-4 # pinned\n", string(data))
+
+	assert.ErrorIs(t, UnsetConfigValue(path, "role"), ErrKeyNotSet)
+	assert.ErrorIs(t, UnsetConfigValue(filepath.Join(t.TempDir(), "missing.yaml"), "role"), ErrKeyNotSet)
+
+	require.NoError(t, UnsetConfigValue(path, "model"))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestRepoConfigLocations(t *testing.T) {
+	proj := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(proj, ".bare"), 0o755))
+	main := filepath.Join(proj, "main")
+	sub := filepath.Join(main, "internal", "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(main, ".git"), []byte("gitdir: ../.bare/worktrees/main\n"), 0o644))
+
+	project, projectRoot := RepoConfigLocations(sub)
+	assert.Equal(t, RepoConfigPath(main), project)
+	assert.Equal(t, RepoConfigPath(proj), projectRoot)
+
+	require.NoError(t, os.WriteFile(RepoConfigPath(filepath.Join(main, "internal")), nil, 0o644))
+	project, _ = RepoConfigLocations(sub)
+	assert.Equal(t, RepoConfigPath(filepath.Join(main, "internal")), project)
+
+	project, projectRoot = RepoConfigLocations(proj)
+	assert.Equal(t, RepoConfigPath(proj), project)
+	assert.Empty(t, projectRoot)
+}
+
+func TestRepoConfigLocationsPlainRepo(t *testing.T) {
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "cmd")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.Mkdir(sub, 0o755))
+
+	project, projectRoot := RepoConfigLocations(sub)
+	assert.Equal(t, RepoConfigPath(repo), project)
+	assert.Empty(t, projectRoot)
+
+	outside := t.TempDir()
+	project, projectRoot = RepoConfigLocations(outside)
+	assert.Equal(t, RepoConfigPath(outside), project)
+	assert.Empty(t, projectRoot)
+}
@@ -0,0 +1,525 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue severities. Errors make InitConfig fail; warnings are only reported.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem with one key of a config file. Line is 0 when the problem is not
+// tied to a line, such as a file that cannot be parsed.
+type Issue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location += ":" + strconv.Itoa(i.Line)
+	}
+	if i.Key == "" {
+		return location + ": " + i.Message
+	}
+	return location + ": " + i.Key + ": " + i.Message
+}
+
+// ValidationError is returned by InitConfig when a config file has errors.
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		lines = append(lines, issue.String())
+	}
+	return "invalid configuration:\n  " + strings.Join(lines, "\n  ")
+}
+
+// warnings holds the warnings of the files loaded by the last InitConfig.
+var warnings []Issue
+
+// Warnings returns the warnings, such as unknown keys, of the files InitConfig loaded.
+func Warnings() []Issue {
+	return warnings
+}
+
+// LoadedFiles returns the config files InitConfig read, user config first, that exist.
+func LoadedFiles() []string {
+	var files []string
+	for _, file := range append([]string{configFilePath}, findRepoConfigs()...) {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// HasErrors reports whether issues contain an error.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLoaded validates the files InitConfig read, keeps their warnings and returns
+// their errors as a *ValidationError.
+func validateLoaded() error {
+	warnings = nil
+	var errs []Issue
+	for _, file := range LoadedFiles() {
+		for _, issue := range ValidateFile(file) {
+			if issue.Severity == SeverityError {
+				errs = append(errs, issue)
+			} else {
+				warnings = append(warnings, issue)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Issues: errs}
+	}
+	return nil
+}
+
+// ValidateFile checks a config file against the Config schema: unknown and renamed keys
+// are warnings, values of the wrong type or outside their allowed set are errors.
+func ValidateFile(file string) []Issue {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return []Issue{{File: file, Severity: SeverityError, Message: err.Error()}}
+	}
+	return validateYAML(file, data)
+}
+
+func validateYAML(file string, data []byte) []Issue {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Issue{{File: file, Severity: SeverityError, Message: "not valid YAML: " + err.Error()}}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []Issue{{File: file, Line: root.Line, Severity: SeverityError, Message: "must be a mapping of keys"}}
+	}
+
+	v := &validator{file: file}
+	v.checkMapping(root, reflect.TypeOf(Config{}), "")
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		v.checkValue(root.Content[i].Value, root.Content[i+1])
+	}
+	sort.SliceStable(v.issues, func(i, j int) bool { return v.issues[i].Line < v.issues[j].Line })
+	return v.issues
+}
+
+type validator struct {
+	file   string
+	issues []Issue
+}
+
+func (v *validator) add(node *yaml.Node, key, severity, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		File:     v.file,
+		Line:     node.Line,
+		Key:      key,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkMapping checks the keys of node against the mapstructure fields of structType,
+// and the type of each known value.
+func (v *validator) checkMapping(node *yaml.Node, structType reflect.Type, prefix string) {
+	fields := schemaFields(structType)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := strings.ToLower(keyNode.Value)
+		fullKey := prefix + keyNode.Value
+
+		fieldType, ok := fields[key]
+		if !ok {
+			if newName, renamed := renamedKeys[key]; renamed && prefix == "" {
+				v.add(keyNode, fullKey, SeverityWarning,
+					"deprecated key, renamed to %s; run gmc config migrate or rename it", newName)
+				continue
+			}
+			message := "unknown key, ignored"
+			if suggestion := closestKey(key, fields); suggestion != "" {
+				message += fmt.Sprintf("; did you mean %s?", prefix+suggestion)
+			}
+			v.add(keyNode, fullKey, SeverityWarning, "%s", message)
+			continue
+		}
+		v.checkType(valueNode, fieldType, fullKey)
+	}
+}
+
+func (v *validator) checkType(node *yaml.Node, t reflect.Type, key string) {
+	if isNull(node) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			v.add(node, key, SeverityError, "must be a string")
+			return
+		}
+		for _, name := range unsetEnvRefs(node.Value) {
+			v.add(node, key, SeverityWarning, "references ${%s}, which is not set; it expands to \"\"", name)
+		}
+	case reflect.Bool:
+		if _, err := strconv.ParseBool(node.Value); node.Kind != yaml.ScalarNode || err != nil {
+			v.add(node, key, SeverityError, "must be true or false, got %s", describeNode(node))
+		}
+	case reflect.Int:
+		if _, err := strconv.Atoi(node.Value); node.Kind != yaml.ScalarNode || err != nil {
+			v.add(node, key, SeverityError, "must be a whole number, got %s", describeNode(node))
+		}
+	case reflect.Float64:
+		if _, err := strconv.ParseFloat(node.Value, 64); node.Kind != yaml.ScalarNode || err != nil {
+			v.add(node, key, SeverityError, "must be a number, got %s", describeNode(node))
+		}
+	case reflect.Pointer:
+		v.checkType(node, t.Elem(), key)
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.add(node, key, SeverityError, "must be a list")
+			return
+		}
+		for i, item := range node.Content {
+			v.checkType(item, t.Elem(), fmt.Sprintf("%s[%d]", key, i))
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.add(node, key, SeverityError, "must be a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkType(node.Content[i+1], t.Elem(), key+"."+node.Content[i].Value)
+		}
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.add(node, key, SeverityError, "must be a mapping")
+			return
+		}
+		v.checkMapping(node, t, key+".")
+	}
+}
+
+// checkValue checks the value of a top-level key of the right type against its
+// allowed values.
+func (v *validator) checkValue(key string, node *yaml.Node) {
+	key = strings.ToLower(key)
+	if (key == "package_globs" || key == "exclude_paths") && node.Kind == yaml.SequenceNode {
+		for i, item := range node.Content {
+			if _, err := path.Match(item.Value, ""); err != nil {
+				v.add(item, fmt.Sprintf("%s[%d]", key, i), SeverityError, "invalid glob %q", item.Value)
+			}
+		}
+		return
+	}
+	if key == "components" && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkComponent(node.Content[i], node.Content[i+1])
+		}
+		return
+	}
+	if key == "extra_headers" && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkHeader(node.Content[i], node.Content[i+1])
+		}
+		return
+	}
+	if key == "defaults" && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i], node.Content[i+1]
+			isTimeout := name.Value == "timeout" || name.Value == "connect_timeout"
+			if n, err := strconv.Atoi(value.Value); isTimeout && err == nil && n < 0 {
+				v.add(value, "defaults."+name.Value, SeverityError, "must be 0 or a number of seconds, got %d", n)
+			}
+		}
+		return
+	}
+	if key == "emoji_map" && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && strings.TrimSpace(value.Value) == "" {
+				v.add(value, "emoji_map."+name.Value, SeverityError, "must be an emoji or a gitmoji name such as rocket")
+			}
+		}
+		return
+	}
+	if key == "author_aliases" && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && strings.TrimSpace(value.Value) == "" {
+				v.add(value, "author_aliases."+name.Value, SeverityError, "must be the name or email to report instead")
+			}
+		}
+		return
+	}
+	if key == "branch_naming" && node.Kind == yaml.MappingNode {
+		v.checkBranchNaming(node)
+		return
+	}
+	if key == "trailers" && node.Kind == yaml.SequenceNode {
+		for i, item := range node.Content {
+			v.checkTrailer(item, fmt.Sprintf("%s[%d]", key, i))
+		}
+		return
+	}
+	if node.Kind != yaml.ScalarNode || isNull(node) {
+		return
+	}
+
+	value := ExpandEnv(node.Value)
+	if value == "" && envRefPattern.MatchString(node.Value) {
+		// checkType already warns about the unset variable.
+		return
+	}
+	switch key {
+	case "role", "model":
+		if strings.TrimSpace(value) == "" {
+			v.add(node, key, SeverityError, "must not be empty")
+		}
+	case "api_base":
+		if value != "" && !isHTTPURL(value) {
+			v.add(node, key, SeverityError,
+				"must be an http(s) URL such as https://api.openai.com/v1, got %q", value)
+		}
+	case "type_hints":
+		if value != "" && !IsValidTypeHints(value) {
+			v.add(node, key, SeverityError, "must be off, soft or strict, got %q", value)
+		}
+	case "emoji_style":
+		if value != EmojiStyleConventional && value != EmojiStyleGitmoji {
+			v.add(node, key, SeverityError, "must be %s or %s, got %q", EmojiStyleConventional, EmojiStyleGitmoji, value)
+		}
+	case "git_backend":
+		if value != "" && !IsValidGitBackend(value) {
+			v.add(node, key, SeverityError, "must be %s or %s, got %q", GitBackendNative, GitBackendGoGit, value)
+		}
+	case "forge":
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "", "github", "gitlab", "gitea":
+		default:
+			v.add(node, key, SeverityError, "must be github, gitlab or gitea, got %q", value)
+		}
+	case "language":
+		if !IsValidLanguage(value) {
+			v.add(node, key, SeverityError, "must be a language tag such as ja or zh-CN, got %q", value)
+		}
+	case "issue_pattern":
+		if _, err := regexp.Compile(value); err != nil {
+			v.add(node, key, SeverityError, "must be a regular expression: %v", err)
+		}
+	case "issue_format":
+		if value != "" && !strings.Contains(value, "{id}") {
+			v.add(node, key, SeverityError, "must contain {id}, such as [{id}] or Refs: {id}, got %q", value)
+		}
+	case "jira_url":
+		if value != "" && !isHTTPURL(value) {
+			v.add(node, key, SeverityError, "must be an http(s) URL such as https://example.atlassian.net, got %q", value)
+		}
+	case "http_proxy":
+		if u, err := url.Parse(value); value != "" && (err != nil || u.Host == "") {
+			v.add(node, key, SeverityError, "must be a URL such as http://proxy.example.com:8080, got %q", value)
+		}
+	case "ca_cert":
+		if value != "" && !strings.HasPrefix(value, "~/") && !fileExists(value) {
+			v.add(node, key, SeverityError, "file %s does not exist", value)
+		}
+	case "tls_insecure":
+		if insecure, _ := strconv.ParseBool(value); insecure {
+			v.add(node, key, SeverityWarning, "TLS certificate verification is disabled for LLM requests")
+		}
+	case "summarize_parallelism", "summarize_timeout":
+		if n, err := strconv.Atoi(value); err == nil && n <= 0 {
+			v.add(node, key, SeverityError, "must be a positive number, got %d", n)
+		}
+	case "temperature":
+		if f, err := strconv.ParseFloat(value, 64); err == nil && (f < 0 || f > 2) {
+			v.add(node, key, SeverityError, "must be between 0 and 2, got %s", value)
+		}
+	case "top_p":
+		if f, err := strconv.ParseFloat(value, 64); err == nil && (f <= 0 || f > 1) {
+			v.add(node, key, SeverityError, "must be greater than 0 and at most 1, got %s", value)
+		}
+	case "max_tokens":
+		if n, err := strconv.Atoi(value); err == nil && n < 0 {
+			v.add(node, key, SeverityError, "must be 0 or a number of tokens, got %d", n)
+		}
+	case "summarize_threshold":
+		if n, err := strconv.Atoi(value); err == nil && n < 0 {
+			v.add(node, key, SeverityError, "must be 0 or a number of bytes, got %d", n)
+		}
+	}
+}
+
+// checkBranchNaming checks the pattern, max_length and allowed_chars of the
+// branch_naming section.
+func (v *validator) checkBranchNaming(node *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := strings.ToLower(node.Content[i].Value), node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			continue
+		}
+		naming := BranchNaming{Pattern: value.Value, AllowedChars: value.Value}
+		switch name {
+		case "pattern":
+			if err := naming.CheckPattern(); err != nil {
+				v.add(value, "branch_naming.pattern", SeverityError, "%v", err)
+			}
+		case "max_length":
+			if n, err := strconv.Atoi(value.Value); err == nil && n < 0 {
+				v.add(value, "branch_naming.max_length", SeverityError, "must be 0 or a number of characters, got %d", n)
+			}
+		case "allowed_chars":
+			if _, err := naming.Disallowed(); err != nil {
+				v.add(value, "branch_naming.allowed_chars", SeverityError, "%v", err)
+			}
+		}
+	}
+}
+
+// checkTrailer checks that a trailers entry has a valid key and a value.
+func (v *validator) checkTrailer(node *yaml.Node, key string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	var trailer Trailer
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch strings.ToLower(node.Content[i].Value) {
+		case "key":
+			trailer.Key = node.Content[i+1].Value
+		case "value":
+			trailer.Value = node.Content[i+1].Value
+		}
+	}
+	if !IsValidTrailerKey(trailer.Key) {
+		v.add(node, key+".key", SeverityError,
+			"must be a trailer key of letters, digits and dashes, such as Co-authored-by, got %q", trailer.Key)
+	}
+	if strings.TrimSpace(trailer.Value) == "" {
+		v.add(node, key+".value", SeverityError, "must not be empty")
+	}
+}
+
+// checkComponent checks that a components entry names a tag prefix and a directory
+// inside the repository.
+func (v *validator) checkComponent(name, dir *yaml.Node) {
+	key := "components." + name.Value
+	if !IsValidComponentName(name.Value) {
+		v.add(name, key, SeverityError,
+			"must be a name of letters, digits, dots, dashes and underscores, such as api, got %q", name.Value)
+	}
+	clean := path.Clean(dir.Value)
+	if dir.Kind == yaml.ScalarNode && (dir.Value == "" || path.IsAbs(clean) || clean == ".." ||
+		strings.HasPrefix(clean, "../")) {
+		v.add(dir, key, SeverityError, "must be a directory inside the repository, such as services/api, got %q",
+			dir.Value)
+	}
+}
+
+// checkHeader checks that an extra_headers entry is a header name with a value
+// that fits on one line.
+func (v *validator) checkHeader(name, value *yaml.Node) {
+	key := "extra_headers." + name.Value
+	if !IsValidHeaderName(name.Value) {
+		v.add(name, key, SeverityError, "must be an HTTP header name such as X-Org-Id, got %q", name.Value)
+	}
+	if value.Kind == yaml.ScalarNode && strings.ContainsAny(value.Value, "\r\n") {
+		v.add(value, key, SeverityError, "must be a single line")
+	}
+}
+
+// schemaFields maps the mapstructure keys of structType to their field types.
+func schemaFields(structType reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if name := field.Tag.Get("mapstructure"); name != "" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// closestKey returns the known key within two edits of key, if there is one.
+func closestKey(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if distance := editDistance(key, name); distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func describeNode(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return strconv.Quote(node.Value)
+	}
+	return "a " + map[yaml.Kind]string{yaml.SequenceNode: "list", yaml.MappingNode: "mapping"}[node.Kind]
+}
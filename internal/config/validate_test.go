@@ -0,0 +1,239 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateYAML(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte(`role: Developer
+modle: gpt-4
+apikey: sk-1
+api_base: api.openai.com
+type_hints: loose
+git_backend: ""
+enable_emoji: maybe
+summarize_timeout: 0
+summarize_parallelism: [4]
+forge: GitLab
+language: zh-CN
+package_globs: ["packages/*", "apps/[a-"]
+exec_presets:
+  test: go test ./...
+scope_rules:
+  - path: api/
+    scop: api
+risk_policies: none
+summarize_threshold: -1
+trailers:
+  - key: Co-authored-by
+    value: Jane Doe <jane@example.com>
+  - key: Reviewed by
+    value: ""
+issue_pattern: "[A-Z+-"
+issue_format: "[ticket]"
+jira_url: example.atlassian.net
+components:
+  api: services/api
+  web app: ../web
+`))
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, "["+issue.Severity+"] "+issue.String())
+	}
+	assert.Equal(t, []string{
+		"[warning] config.yaml:2: modle: unknown key, ignored; did you mean model?",
+		"[warning] config.yaml:3: apikey: deprecated key, renamed to api_key; run gmc config migrate or rename it",
+		`[error] config.yaml:4: api_base: must be an http(s) URL such as https://api.openai.com/v1, got "api.openai.com"`,
+		`[error] config.yaml:5: type_hints: must be off, soft or strict, got "loose"`,
+		`[error] config.yaml:7: enable_emoji: must be true or false, got "maybe"`,
+		"[error] config.yaml:8: summarize_timeout: must be a positive number, got 0",
+		"[error] config.yaml:9: summarize_parallelism: must be a whole number, got a list",
+		`[error] config.yaml:12: package_globs[1]: invalid glob "apps/[a-"`,
+		"[warning] config.yaml:17: scope_rules[0].scop: unknown key, ignored; did you mean scope_rules[0].scope?",
+		"[error] config.yaml:18: risk_policies: must be a list",
+		"[error] config.yaml:19: summarize_threshold: must be 0 or a number of bytes, got -1",
+		`[error] config.yaml:23: trailers[1].key: must be a trailer key of letters, digits and dashes, ` +
+			`such as Co-authored-by, got "Reviewed by"`,
+		"[error] config.yaml:23: trailers[1].value: must not be empty",
+		"[error] config.yaml:25: issue_pattern: must be a regular expression: " +
+			"error parsing regexp: missing closing ]: `[A-Z+-`",
+		`[error] config.yaml:26: issue_format: must contain {id}, such as [{id}] or Refs: {id}, got "[ticket]"`,
+		`[error] config.yaml:27: jira_url: must be an http(s) URL such as https://example.atlassian.net, ` +
+			`got "example.atlassian.net"`,
+		`[error] config.yaml:30: components.web app: must be a name of letters, digits, dots, dashes and ` +
+			`underscores, such as api, got "web app"`,
+		`[error] config.yaml:30: components.web app: must be a directory inside the repository, ` +
+			`such as services/api, got "../web"`,
+	}, got)
+}
+
+func TestValidateYAMLAcceptsEmptyAndDefaultConfig(t *testing.T) {
+	assert.Empty(t, validateYAML("config.yaml", nil))
+	assert.Empty(t, validateYAML("config.yaml", []byte("# only a comment\n")))
+
+	issues := validateYAML("config.yaml", []byte("- role\n"))
+	require.Len(t, issues, 1)
+	assert.Equal(t, "config.yaml:1: must be a mapping of keys", issues[0].String())
+
+	issues = validateYAML("config.yaml", []byte("role: [\n"))
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, "not valid YAML")
+}
+
+func TestInitConfigValidatesFile(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("modle: gpt-4\ngit_backend: jgit\n"), 0o600))
+
+	viper.Reset()
+	err := InitConfig(configFile)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.EqualError(t, err, "invalid configuration:\n  "+configFile+
+		`:2: git_backend: must be native or go-git, got "jgit"`)
+	require.Len(t, Warnings(), 1)
+	assert.Equal(t, "modle", Warnings()[0].Key)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("model: gpt-4\n"), 0o600))
+	viper.Reset()
+	require.NoError(t, InitConfig(configFile))
+	assert.Empty(t, Warnings())
+	assert.Equal(t, "gpt-4", viper.GetString("model"))
+}
+
+func TestValidateYAMLNetworkKeys(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte(`http_proxy: proxy.example.com:8080
+ca_cert: /nonexistent/corp-root.pem
+tls_insecure: true
+`))
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, "["+issue.Severity+"] "+issue.String())
+	}
+	assert.Equal(t, []string{
+		`[error] config.yaml:1: http_proxy: must be a URL such as http://proxy.example.com:8080, ` +
+			`got "proxy.example.com:8080"`,
+		"[error] config.yaml:2: ca_cert: file /nonexistent/corp-root.pem does not exist",
+		"[warning] config.yaml:3: tls_insecure: TLS certificate verification is disabled for LLM requests",
+	}, got)
+	assert.Empty(t, validateYAML("config.yaml", []byte("http_proxy: http://user:pw@proxy:3128\ntls_insecure: false\n")))
+}
+
+func TestValidateYAMLSamplingKeys(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte(`temperature: 3
+top_p: 0
+max_tokens: -5
+`))
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	assert.Equal(t, []string{
+		"config.yaml:1: temperature: must be between 0 and 2, got 3",
+		"config.yaml:2: top_p: must be greater than 0 and at most 1, got 0",
+		"config.yaml:3: max_tokens: must be 0 or a number of tokens, got -5",
+	}, got)
+
+	issues = validateYAML("config.yaml", []byte("temperature: warm\n"))
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, "must be a number")
+	assert.Empty(t, validateYAML("config.yaml", []byte("temperature: 0\ntop_p: 0.9\nmax_tokens: 200\n")))
+}
+
+func TestValidateYAMLExtraHeaders(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte(`extra_headers:
+  X-Org-Id: acme
+  X-Route: ""
+  X Team: platform
+  X-Tags: [a, b]
+  X-Note: "first\nsecond"
+`))
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	assert.Equal(t, []string{
+		`config.yaml:4: extra_headers.X Team: must be an HTTP header name such as X-Org-Id, got "X Team"`,
+		"config.yaml:5: extra_headers.X-Tags: must be a string",
+		"config.yaml:6: extra_headers.X-Note: must be a single line",
+	}, got)
+}
+
+func TestValidateYAMLDefaults(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte(`defaults:
+  add_all: true
+  auto_yse: true
+  timeout: -5
+  connect_timeout: -1
+`))
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	assert.Equal(t, []string{
+		"config.yaml:3: defaults.auto_yse: unknown key, ignored; did you mean defaults.auto_yes?",
+		"config.yaml:4: defaults.timeout: must be 0 or a number of seconds, got -5",
+		"config.yaml:5: defaults.connect_timeout: must be 0 or a number of seconds, got -1",
+	}, got)
+}
+
+func TestValidateYAMLBranchNaming(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte(`branch_naming:
+  pattern: "{type}/{ticket}-{slug}"
+  max_length: 60
+  allowed_chars: a-z0-9-
+`))
+	assert.Empty(t, issues)
+
+	issues = validateYAML("config.yaml", []byte(`branch_naming:
+  pattern: "{type}/{user}-{slug}"
+  max_length: -1
+  allowed_chars: "a-z]["
+`))
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	assert.Equal(t, []string{
+		"config.yaml:2: branch_naming.pattern: unknown placeholder {user}: use {type}, {ticket} and {slug}",
+		"config.yaml:3: branch_naming.max_length: must be 0 or a number of characters, got -1",
+		`config.yaml:4: branch_naming.allowed_chars: must be a character class such as a-z0-9-, got "a-z]["`,
+	}, got)
+
+	issues = validateYAML("config.yaml", []byte("branch_naming:\n  pattern: \"{type}/{ticket}\"\n"))
+	require.Len(t, issues, 1)
+	assert.Equal(t,
+		`config.yaml:2: branch_naming.pattern: must contain {slug}, such as {type}/{slug}, got "{type}/{ticket}"`,
+		issues[0].String())
+}
+
+func TestValidateYAMLEmojiMap(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte("emoji_map:\n  feat: \"🚀\"\n  fix: \"\"\n"))
+	require.Len(t, issues, 1)
+	assert.Equal(t, "config.yaml:3: emoji_map.fix: must be an emoji or a gitmoji name such as rocket", issues[0].String())
+}
+
+func TestValidateYAMLAuthorAliases(t *testing.T) {
+	issues := validateYAML("config.yaml", []byte("author_aliases:\n  jd@old.example: Jane Doe\n  jd: \"\"\n"))
+	require.Len(t, issues, 1)
+	assert.Equal(t, "config.yaml:3: author_aliases.jd: must be the name or email to report instead", issues[0].String())
+}
+
+func TestValidateYAMLEmojiStyle(t *testing.T) {
+	assert.Empty(t, validateYAML("config.yaml", []byte("emoji_style: gitmoji\n")))
+	issues := validateYAML("config.yaml", []byte("emoji_style: shortcodes\n"))
+	require.Len(t, issues, 1)
+	assert.Equal(t, `config.yaml:1: emoji_style: must be conventional or gitmoji, got "shortcodes"`, issues[0].String())
+}
@@ -0,0 +1,254 @@
+// Package debuglog writes --debug sessions to JSON-lines files under
+// $XDG_STATE_HOME/gmc/logs, redacting API keys and tokens before they reach disk.
+package debuglog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+)
+
+// MaxFiles is how many log files Start keeps; older ones are removed.
+const MaxFiles = 20
+
+const (
+	filePrefix = "gmc-"
+	fileSuffix = ".log"
+	redacted   = "[REDACTED]"
+)
+
+// secretPatterns match credentials that should never be logged, even when they are not
+// a configured secret, such as a key pasted into a prompt.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]{8,}`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`github_pat_[A-Za-z0-9_]{20,}`),
+	regexp.MustCompile(`glpat-[A-Za-z0-9_-]{16,}`),
+}
+
+var (
+	mu     sync.Mutex
+	file   *os.File
+	logger *slog.Logger
+
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// Dir returns $XDG_STATE_HOME/gmc/logs, defaulting to ~/.local/state/gmc/logs.
+func Dir() (string, error) {
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "logs"), nil
+}
+
+// Start opens a new log file in dir and enables logging until Close. It returns the
+// file's path.
+func Start(dir string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		return file.Name(), nil
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+	name := fmt.Sprintf("%s%s-%d%s", filePrefix, time.Now().Format("20060102-150405"), os.Getpid(), fileSuffix)
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create log file: %w", err)
+	}
+	file = f
+	logger = slog.New(slog.NewJSONHandler(f, &slog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactAttr,
+	}))
+	prune(dir, MaxFiles)
+	return f.Name(), nil
+}
+
+// Close stops logging and closes the current file.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file, logger = nil, nil
+	return err
+}
+
+// Current returns the path of the open log file, or "" when logging is off.
+func Current() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		return ""
+	}
+	return file.Name()
+}
+
+// Enabled reports whether a log file is open.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return logger != nil
+}
+
+// AddSecret registers a value, such as the configured API key, that is replaced with
+// [REDACTED] wherever it appears in a log record.
+func AddSecret(secret string) {
+	if len(strings.TrimSpace(secret)) < 4 {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, secret)
+}
+
+// Log writes one record with the given event name and key/value pairs. It is a no-op
+// unless Start was called.
+func Log(event string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if logger == nil {
+		return
+	}
+	logger.Debug(event, args...)
+}
+
+// Redact replaces registered secrets and anything that looks like an API key or token.
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if sub := pattern.FindStringSubmatch(match); len(sub) > 1 {
+				return sub[1] + redacted
+			}
+			return redacted
+		})
+	}
+	return s
+}
+
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(attr.Value.String()))
+	case slog.KindAny:
+		if args, ok := attr.Value.Any().([]string); ok {
+			clean := make([]string, len(args))
+			for i, arg := range args {
+				clean[i] = Redact(arg)
+			}
+			return slog.Any(attr.Key, clean)
+		}
+	}
+	return attr
+}
+
+// File describes a log file in the log directory.
+type File struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// List returns the log files in dir, newest first. A missing directory has no files.
+func List(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var files []File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, File{
+			Name:    name,
+			Path:    filepath.Join(dir, name),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	// Names embed the start time, so they sort chronologically.
+	sort.Slice(files, func(i, j int) bool { return files[i].Name > files[j].Name })
+	return files, nil
+}
+
+func prune(dir string, keep int) {
+	files, err := List(dir)
+	if err != nil {
+		return
+	}
+	for i := keep; i < len(files); i++ {
+		_ = os.Remove(files[i].Path)
+	}
+}
+
+// Record is one decoded log line. Fields holds every attribute except time, level and msg.
+type Record struct {
+	Time   time.Time      `json:"time"`
+	Event  string         `json:"event"`
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// Read decodes the records in a log file. Lines that are not JSON objects are skipped.
+func Read(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var fields map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			continue
+		}
+		record := Record{Fields: fields}
+		if msg, ok := fields[slog.MessageKey].(string); ok {
+			record.Event = msg
+		}
+		if ts, ok := fields[slog.TimeKey].(string); ok {
+			record.Time, _ = time.Parse(time.RFC3339Nano, ts)
+		}
+		delete(fields, slog.MessageKey)
+		delete(fields, slog.TimeKey)
+		delete(fields, slog.LevelKey)
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,293 @@
+// Package doctor implements the environment checks behind gmc doctor.
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/gitcmd"
+	"github.com/samzong/gmc/internal/hook"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/version"
+	"github.com/samzong/gmc/internal/worktree"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Check is one line of the gmc doctor report.
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail"`
+	// Hint suggests a fix for warnings and failures.
+	Hint string `json:"hint,omitempty"`
+}
+
+// MinGitVersion is the oldest git whose worktree list reports prunable worktrees,
+// which gmc wt relies on.
+const MinGitVersion = "2.31"
+
+// slowLatency is the API round trip above which the latency check warns.
+const slowLatency = 5 * time.Second
+
+var gitVersionPattern = regexp.MustCompile(`git version (\d+)\.(\d+)`)
+
+// HasFailure reports whether any check failed.
+func HasFailure(checks []Check) bool {
+	for _, check := range checks {
+		if check.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckGit reports the installed git version.
+func CheckGit(runner gitcmd.Runner) Check {
+	result, err := runner.Run("--version")
+	if err != nil {
+		return Check{Name: "git", Status: Fail, Detail: "git is not installed or not on PATH",
+			Hint: "install git " + MinGitVersion + " or later"}
+	}
+	return gitVersionCheck(result.StdoutString(true))
+}
+
+func gitVersionCheck(output string) Check {
+	matches := gitVersionPattern.FindStringSubmatch(output)
+	if matches == nil {
+		return Check{Name: "git", Status: Warn, Detail: "unrecognized version: " + output}
+	}
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+	minMajor, minMinor, _ := strings.Cut(MinGitVersion, ".")
+	wantMajor, _ := strconv.Atoi(minMajor)
+	wantMinor, _ := strconv.Atoi(minMinor)
+	if major < wantMajor || (major == wantMajor && minor < wantMinor) {
+		return Check{Name: "git", Status: Warn, Detail: output,
+			Hint: "gmc wt needs git " + MinGitVersion + " or later"}
+	}
+	return Check{Name: "git", Status: Pass, Detail: output}
+}
+
+// CheckRepo reports whether the working directory is a repository gmc can commit in.
+// The second result is false outside a repository.
+func CheckRepo(runner gitcmd.Runner) (Check, bool) {
+	result, err := runner.Run("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return Check{Name: "repository", Status: Warn, Detail: "not inside a git repository",
+			Hint: "run gmc doctor from a repository to check it"}, false
+	}
+	gitDir := result.StdoutString(true)
+
+	if bare, err := runner.Run("rev-parse", "--is-bare-repository"); err == nil && bare.StdoutString(true) == "true" {
+		return Check{Name: "repository", Status: Pass, Detail: "bare repository at " + gitDir}, true
+	}
+
+	for _, op := range []struct{ file, name string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+	} {
+		if _, err := os.Stat(filepath.Join(gitDir, op.file)); err == nil {
+			return Check{Name: "repository", Status: Warn, Detail: "a " + op.name + " is in progress",
+				Hint: "finish or abort it before generating commits"}, true
+		}
+	}
+
+	if _, err := runner.Run("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return Check{Name: "repository", Status: Pass, Detail: "no commits yet"}, true
+	}
+	branch, err := runner.Run("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return Check{Name: "repository", Status: Warn, Detail: "detached HEAD",
+			Hint: "create a branch before committing, for example with gmc --branch"}, true
+	}
+	return Check{Name: "repository", Status: Pass, Detail: "on branch " + branch.StdoutString(true)}, true
+}
+
+// CheckConfigFile reports whether the config file exists and is private to the user,
+// since it holds the API key.
+func CheckConfigFile(path string) Check {
+	if path == "" {
+		return Check{Name: "config file", Status: Warn, Detail: "no config file in use"}
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return Check{Name: "config file", Status: Warn, Detail: path + " does not exist", Hint: "run gmc init"}
+	}
+	if err != nil {
+		return Check{Name: "config file", Status: Fail, Detail: err.Error()}
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return Check{Name: "config file", Status: Fail,
+			Detail: fmt.Sprintf("%s is readable by other users (mode %04o)", path, info.Mode().Perm()),
+			Hint:   "chmod 600 " + path}
+	}
+	return Check{Name: "config file", Status: Pass, Detail: fmt.Sprintf("%s (mode %04o)", path, info.Mode().Perm())}
+}
+
+// CheckAPI runs the API key diagnosis against the configured provider and adds the
+// round-trip latency.
+func CheckAPI(client *llm.Client, model string) ([]Check, error) {
+	start := time.Now()
+	keyChecks, err := client.DiagnoseKey(model)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(start).Round(time.Millisecond)
+
+	checks := make([]Check, 0, len(keyChecks)+1)
+	failed := false
+	for _, keyCheck := range keyChecks {
+		check := Check{Name: keyCheck.Name, Detail: keyCheck.Detail}
+		switch keyCheck.Status {
+		case llm.CheckOK:
+			check.Status = Pass
+		case llm.CheckWarn:
+			check.Status = Warn
+		default:
+			check.Status = Fail
+			check.Hint = "run gmc config doctor for details"
+			failed = true
+		}
+		checks = append(checks, check)
+	}
+	if failed {
+		return checks, nil
+	}
+
+	latencyCheck := Check{Name: "api latency", Status: Pass, Detail: latency.String()}
+	if latency > slowLatency {
+		latencyCheck.Status = Warn
+		latencyCheck.Hint = "generation may hit the timeout; raise it with gmc --timeout <seconds>"
+	}
+	return append(checks, latencyCheck), nil
+}
+
+// CheckTemplates reports whether the prompt, fallback and tag templates load and parse.
+func CheckTemplates(cfg *config.Config) []Check {
+	prompt := Check{Name: "prompt template", Status: Pass, Detail: cfg.PromptTemplate}
+	if err := formatter.CheckPromptTemplate(cfg.PromptTemplate); err != nil {
+		prompt.Status = Fail
+		prompt.Detail = err.Error()
+		prompt.Hint = "fix it with gmc template edit, or reset it with gmc config set prompt_template default"
+	}
+	checks := []Check{prompt}
+
+	if cfg.FallbackTemplate != "" && cfg.FallbackTemplate != config.DefaultPromptTemplate {
+		fallback := Check{Name: "fallback template", Status: Pass, Detail: cfg.FallbackTemplate}
+		if err := formatter.CheckPromptTemplate(cfg.FallbackTemplate); err != nil {
+			fallback.Status = Warn
+			fallback.Detail = err.Error()
+			fallback.Hint = "gmc falls back to the built-in template; reset it with gmc config set fallback_template default"
+		}
+		checks = append(checks, fallback)
+	}
+
+	if cfg.TagTemplate != "" {
+		tag := Check{Name: "tag template", Status: Pass, Detail: cfg.TagTemplate}
+		if _, err := version.LoadTagTemplate(cfg.TagTemplate); err != nil {
+			tag.Status = Fail
+			tag.Detail = err.Error()
+			tag.Hint = "fix the file or clear tag_template"
+		}
+		checks = append(checks, tag)
+	}
+	return checks
+}
+
+// CheckWorktrees reports worktrees whose directories are gone or that git marks
+// as prunable.
+func CheckWorktrees(worktrees []worktree.Info) Check {
+	var broken []string
+	count := 0
+	for _, wt := range worktrees {
+		if wt.IsBare {
+			continue
+		}
+		count++
+		if wt.IsPrunable {
+			broken = append(broken, wt.Path)
+			continue
+		}
+		if _, err := os.Stat(wt.Path); err != nil {
+			broken = append(broken, wt.Path)
+		}
+	}
+
+	if len(broken) > 0 {
+		return Check{Name: "worktrees", Status: Warn,
+			Detail: fmt.Sprintf("%d of %d worktree(s) are missing: %s", len(broken), count, strings.Join(broken, ", ")),
+			Hint:   "run git worktree prune"}
+	}
+	return Check{Name: "worktrees", Status: Pass, Detail: fmt.Sprintf("%d worktree(s), all present", count)}
+}
+
+// CheckHook reports whether the gmc hook at target is installed and runnable, the hook
+// it chains, and gmcPath, where the hook finds gmc, "" when gmc is not on the PATH.
+func CheckHook(target hook.Target, state hook.State, gmcPath string) []Check {
+	dir := Check{Name: "hooks dir", Status: Pass, Detail: target.Dir}
+	if target.HooksPath != "" {
+		dir.Detail += " (core.hooksPath " + target.HooksPath + ")"
+	}
+	if target.Manager != "" {
+		dir.Detail += ", managed by " + target.Manager
+	}
+
+	installed := Check{Name: "hook", Status: Pass, Detail: "gmc " + hook.Name + " hook at " + target.Path()}
+	switch {
+	case !state.Exists && state.Chained:
+		installed.Status = Fail
+		installed.Detail = target.Path() + " is missing, so " + target.ChainedPath() + " no longer runs"
+		installed.Hint = "run gmc hook install"
+	case !state.Exists:
+		installed.Status = Fail
+		installed.Detail = "no " + hook.Name + " hook at " + target.Path()
+		installed.Hint = "run gmc hook install"
+	case !state.Installed:
+		installed.Status = Fail
+		installed.Detail = target.Path() + " is not the gmc hook"
+		if state.Chained {
+			installed.Detail += "; it was replaced after gmc hook install, so " + target.ChainedPath() + " no longer runs"
+		}
+		installed.Hint = "run gmc hook install to chain it"
+	case !state.Executable && target.Manager != hook.ManagerHusky:
+		installed.Status = Fail
+		installed.Detail = target.Path() + " is not executable, so git skips it"
+		installed.Hint = "run chmod +x " + target.Path()
+	}
+
+	chain := Check{Name: "hook chain", Status: Pass, Detail: "no previous hook to run"}
+	if state.Chained {
+		chain.Detail = "runs " + target.ChainedPath() + " first"
+	}
+	if target.Manager == hook.ManagerLefthook {
+		chain.Status = Warn
+		chain.Hint = "lefthook install rewrites " + target.Path() + "; run gmc hook install again after it"
+	}
+
+	binary := Check{Name: "gmc on PATH", Status: Pass, Detail: gmcPath}
+	if gmcPath == "" {
+		binary.Status = Fail
+		binary.Detail = "gmc is not on the PATH, so the hook leaves the message empty"
+		binary.Hint = "add the directory of gmc to the PATH git commit runs with"
+	}
+	return []Check{dir, installed, chain, binary}
+}
@@ -0,0 +1,141 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/gitcmd"
+	"github.com/samzong/gmc/internal/hook"
+	"github.com/samzong/gmc/internal/worktree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitVersionCheck(t *testing.T) {
+	assert.Equal(t, Pass, gitVersionCheck("git version 2.45.1").Status)
+	assert.Equal(t, Pass, gitVersionCheck("git version 2.31.0.windows.1").Status)
+	assert.Equal(t, Pass, gitVersionCheck("git version 3.0.0").Status)
+
+	old := gitVersionCheck("git version 2.25.1")
+	assert.Equal(t, Warn, old.Status)
+	assert.Contains(t, old.Hint, MinGitVersion)
+
+	assert.Equal(t, Warn, gitVersionCheck("not git").Status)
+}
+
+func TestCheckRepo(t *testing.T) {
+	dir := t.TempDir()
+	runner := gitcmd.Runner{Dir: dir}
+	check, inRepo := CheckRepo(runner)
+	assert.False(t, inRepo)
+	assert.Equal(t, Warn, check.Status)
+
+	gitRun := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	gitRun("init", "-q", "-b", "main")
+	check, inRepo = CheckRepo(runner)
+	assert.True(t, inRepo)
+	assert.Equal(t, Check{Name: "repository", Status: Pass, Detail: "no commits yet"}, check)
+
+	gitRun("-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init")
+	check, _ = CheckRepo(runner)
+	assert.Equal(t, "on branch main", check.Detail)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "MERGE_HEAD"), []byte("x"), 0o644))
+	check, _ = CheckRepo(runner)
+	assert.Equal(t, Warn, check.Status)
+	assert.Equal(t, "a merge is in progress", check.Detail)
+	require.NoError(t, os.Remove(filepath.Join(dir, ".git", "MERGE_HEAD")))
+
+	gitRun("checkout", "-q", "--detach")
+	check, _ = CheckRepo(runner)
+	assert.Equal(t, Warn, check.Status)
+	assert.Equal(t, "detached HEAD", check.Detail)
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Equal(t, Warn, CheckConfigFile(path).Status, "missing file")
+
+	require.NoError(t, os.WriteFile(path, []byte("model: x\n"), 0o600))
+	assert.Equal(t, Pass, CheckConfigFile(path).Status)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	require.NoError(t, os.Chmod(path, 0o644))
+	check := CheckConfigFile(path)
+	assert.Equal(t, Fail, check.Status)
+	assert.Equal(t, "chmod 600 "+path, check.Hint)
+}
+
+func TestCheckTemplates(t *testing.T) {
+	checks := CheckTemplates(&config.Config{PromptTemplate: config.DefaultPromptTemplate})
+	require.Len(t, checks, 1)
+	assert.Equal(t, Pass, checks[0].Status)
+
+	broken := filepath.Join(t.TempDir(), "broken.yaml")
+	require.NoError(t, os.WriteFile(broken, []byte("template: \"{{.Diff\"\n"), 0o644))
+	checks = CheckTemplates(&config.Config{PromptTemplate: broken, TagTemplate: "/missing/tag.tmpl"})
+	require.Len(t, checks, 2)
+	assert.Equal(t, Fail, checks[0].Status)
+	assert.Equal(t, Fail, checks[1].Status)
+	assert.True(t, HasFailure(checks))
+	assert.Contains(t, checks[0].Detail, broken+":1:")
+
+	checks = CheckTemplates(&config.Config{PromptTemplate: config.DefaultPromptTemplate, FallbackTemplate: broken})
+	require.Len(t, checks, 2)
+	assert.Equal(t, "fallback template", checks[1].Name)
+	assert.Equal(t, Warn, checks[1].Status)
+	assert.False(t, HasFailure(checks))
+}
+
+func TestCheckWorktrees(t *testing.T) {
+	present := t.TempDir()
+	check := CheckWorktrees([]worktree.Info{
+		{Path: "/repo/.bare", IsBare: true},
+		{Path: present},
+	})
+	assert.Equal(t, Check{Name: "worktrees", Status: Pass, Detail: "1 worktree(s), all present"}, check)
+
+	check = CheckWorktrees([]worktree.Info{
+		{Path: present},
+		{Path: filepath.Join(present, "gone")},
+		{Path: present + "-stale", IsPrunable: true},
+	})
+	assert.Equal(t, Warn, check.Status)
+	assert.Contains(t, check.Detail, "2 of 3 worktree(s) are missing")
+	assert.False(t, HasFailure([]Check{check}))
+}
+
+func TestCheckHook(t *testing.T) {
+	target := hook.Target{Dir: "/repo/.husky", HooksPath: ".husky/_", Manager: hook.ManagerHusky}
+	checks := CheckHook(target, hook.State{Exists: true, Installed: true, Chained: true}, "/usr/local/bin/gmc")
+	require.Len(t, checks, 4)
+	assert.False(t, HasFailure(checks), "husky runs hooks that are not executable")
+	assert.Equal(t, "/repo/.husky (core.hooksPath .husky/_), managed by husky", checks[0].Detail)
+	assert.Equal(t, "runs /repo/.husky/prepare-commit-msg.gmc-chained first", checks[2].Detail)
+
+	target = hook.Target{Dir: "/repo/.git/hooks", Manager: hook.ManagerLefthook}
+	checks = CheckHook(target, hook.State{Exists: true, Executable: true, Chained: true}, "")
+	assert.Equal(t, Fail, checks[1].Status)
+	assert.Contains(t, checks[1].Detail, "no longer runs")
+	assert.Equal(t, Warn, checks[2].Status)
+	assert.Equal(t, Fail, checks[3].Status)
+
+	checks = CheckHook(hook.Target{Dir: "/repo/.git/hooks"}, hook.State{}, "/usr/local/bin/gmc")
+	assert.Equal(t, Fail, checks[1].Status)
+	assert.Equal(t, "run gmc hook install", checks[1].Hint)
+
+	checks = CheckHook(hook.Target{Dir: "/repo/.git/hooks"}, hook.State{Exists: true, Installed: true}, "/bin/gmc")
+	assert.Equal(t, Fail, checks[1].Status)
+	assert.Contains(t, checks[1].Hint, "chmod +x")
+}
@@ -1,143 +1,107 @@
 package emoji
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"unicode/utf8"
+
+	"github.com/samzong/gmc/internal/builtin"
+	"gopkg.in/yaml.v3"
 )
 
 // Gitmoji represents a single gitmoji entry from gitmoji.dev specification
 type Gitmoji struct {
-	Emoji       string
-	Code        string
-	Description string
-	Name        string
-	Semver      string // "major", "minor", "patch", or ""
-}
-
-// gitmojis contains the complete gitmoji.dev specification
-// Source: https://github.com/carloscuesta/gitmoji
-//
-//nolint:lll // data table from upstream spec, line length is unavoidable
-var gitmojis = []Gitmoji{
-	{Emoji: "🎨", Code: ":art:", Description: "Improve structure / format of the code", Name: "art", Semver: ""},
-	{Emoji: "⚡️", Code: ":zap:", Description: "Improve performance", Name: "zap", Semver: "patch"},
-	{Emoji: "🔥", Code: ":fire:", Description: "Remove code or files", Name: "fire", Semver: ""},
-	{Emoji: "🐛", Code: ":bug:", Description: "Fix a bug", Name: "bug", Semver: "patch"},
-	{Emoji: "🚑️", Code: ":ambulance:", Description: "Critical hotfix", Name: "ambulance", Semver: "patch"},
-	{Emoji: "✨", Code: ":sparkles:", Description: "Introduce new features", Name: "sparkles", Semver: "minor"},
-	{Emoji: "📝", Code: ":memo:", Description: "Add or update documentation", Name: "memo", Semver: ""},
-	{Emoji: "🚀", Code: ":rocket:", Description: "Deploy stuff", Name: "rocket", Semver: ""},
-	{Emoji: "💄", Code: ":lipstick:", Description: "Add or update the UI and style files", Name: "lipstick", Semver: "patch"},
-	{Emoji: "🎉", Code: ":tada:", Description: "Begin a project", Name: "tada", Semver: ""},
-	{Emoji: "✅", Code: ":white_check_mark:", Description: "Add, update, or pass tests", Name: "white-check-mark", Semver: ""},
-	{Emoji: "🔒️", Code: ":lock:", Description: "Fix security or privacy issues", Name: "lock", Semver: "patch"},
-	{Emoji: "🔐", Code: ":closed_lock_with_key:", Description: "Add or update secrets", Name: "closed-lock-with-key", Semver: ""},
-	{Emoji: "🔖", Code: ":bookmark:", Description: "Release / Version tags", Name: "bookmark", Semver: ""},
-	{Emoji: "🚨", Code: ":rotating_light:", Description: "Fix compiler / linter warnings", Name: "rotating-light", Semver: ""},
-	{Emoji: "🚧", Code: ":construction:", Description: "Work in progress", Name: "construction", Semver: ""},
-	{Emoji: "💚", Code: ":green_heart:", Description: "Fix CI Build", Name: "green-heart", Semver: ""},
-	{Emoji: "⬇️", Code: ":arrow_down:", Description: "Downgrade dependencies", Name: "arrow-down", Semver: "patch"},
-	{Emoji: "⬆️", Code: ":arrow_up:", Description: "Upgrade dependencies", Name: "arrow-up", Semver: "patch"},
-	{Emoji: "📌", Code: ":pushpin:", Description: "Pin dependencies to specific versions", Name: "pushpin", Semver: "patch"},
-	{Emoji: "👷", Code: ":construction_worker:", Description: "Add or update CI build system", Name: "construction-worker", Semver: ""},
-	{Emoji: "📈", Code: ":chart_with_upwards_trend:", Description: "Add or update analytics or track code", Name: "chart-with-upwards-trend", Semver: "patch"},
-	{Emoji: "♻️", Code: ":recycle:", Description: "Refactor code", Name: "recycle", Semver: ""},
-	{Emoji: "➕", Code: ":heavy_plus_sign:", Description: "Add a dependency", Name: "heavy-plus-sign", Semver: "patch"},
-	{Emoji: "➖", Code: ":heavy_minus_sign:", Description: "Remove a dependency", Name: "heavy-minus-sign", Semver: "patch"},
-	{Emoji: "🔧", Code: ":wrench:", Description: "Add or update configuration files", Name: "wrench", Semver: "patch"},
-	{Emoji: "🔨", Code: ":hammer:", Description: "Add or update development scripts", Name: "hammer", Semver: ""},
-	{Emoji: "🌐", Code: ":globe_with_meridians:", Description: "Internationalization and localization", Name: "globe-with-meridians", Semver: "patch"},
-	{Emoji: "✏️", Code: ":pencil2:", Description: "Fix typos", Name: "pencil2", Semver: "patch"},
-	{Emoji: "💩", Code: ":poop:", Description: "Write bad code that needs to be improved", Name: "poop", Semver: ""},
-	{Emoji: "⏪️", Code: ":rewind:", Description: "Revert changes", Name: "rewind", Semver: "patch"},
-	{Emoji: "🔀", Code: ":twisted_rightwards_arrows:", Description: "Merge branches", Name: "twisted-rightwards-arrows", Semver: ""},
-	{Emoji: "📦️", Code: ":package:", Description: "Add or update compiled files or packages", Name: "package", Semver: "patch"},
-	{Emoji: "👽️", Code: ":alien:", Description: "Update code due to external API changes", Name: "alien", Semver: "patch"},
-	{Emoji: "🚚", Code: ":truck:", Description: "Move or rename resources (e.g.: files, paths, routes)", Name: "truck", Semver: ""},
-	{Emoji: "📄", Code: ":page_facing_up:", Description: "Add or update license", Name: "page-facing-up", Semver: ""},
-	{Emoji: "💥", Code: ":boom:", Description: "Introduce breaking changes", Name: "boom", Semver: "major"},
-	{Emoji: "🍱", Code: ":bento:", Description: "Add or update assets", Name: "bento", Semver: "patch"},
-	{Emoji: "♿️", Code: ":wheelchair:", Description: "Improve accessibility", Name: "wheelchair", Semver: "patch"},
-	{Emoji: "💡", Code: ":bulb:", Description: "Add or update comments in source code", Name: "bulb", Semver: ""},
-	{Emoji: "🍻", Code: ":beers:", Description: "Write code drunkenly", Name: "beers", Semver: ""},
-	{Emoji: "💬", Code: ":speech_balloon:", Description: "Add or update text and literals", Name: "speech-balloon", Semver: "patch"},
-	{Emoji: "🗃️", Code: ":card_file_box:", Description: "Perform database related changes", Name: "card-file-box", Semver: "patch"},
-	{Emoji: "🔊", Code: ":loud_sound:", Description: "Add or update logs", Name: "loud-sound", Semver: ""},
-	{Emoji: "🔇", Code: ":mute:", Description: "Remove logs", Name: "mute", Semver: ""},
-	{Emoji: "👥", Code: ":busts_in_silhouette:", Description: "Add or update contributor(s)", Name: "busts-in-silhouette", Semver: ""},
-	{Emoji: "🚸", Code: ":children_crossing:", Description: "Improve user experience / usability", Name: "children-crossing", Semver: "patch"},
-	{Emoji: "🏗️", Code: ":building_construction:", Description: "Make architectural changes", Name: "building-construction", Semver: ""},
-	{Emoji: "📱", Code: ":iphone:", Description: "Work on responsive design", Name: "iphone", Semver: "patch"},
-	{Emoji: "🤡", Code: ":clown_face:", Description: "Mock things", Name: "clown-face", Semver: ""},
-	{Emoji: "🥚", Code: ":egg:", Description: "Add or update an easter egg", Name: "egg", Semver: "patch"},
-	{Emoji: "🙈", Code: ":see_no_evil:", Description: "Add or update a .gitignore file", Name: "see-no-evil", Semver: ""},
-	{Emoji: "📸", Code: ":camera_flash:", Description: "Add or update snapshots", Name: "camera-flash", Semver: ""},
-	{Emoji: "⚗️", Code: ":alembic:", Description: "Perform experiments", Name: "alembic", Semver: "patch"},
-	{Emoji: "🔍️", Code: ":mag:", Description: "Improve SEO", Name: "mag", Semver: "patch"},
-	{Emoji: "🏷️", Code: ":label:", Description: "Add or update types", Name: "label", Semver: "patch"},
-	{Emoji: "🌱", Code: ":seedling:", Description: "Add or update seed files", Name: "seedling", Semver: ""},
-	{Emoji: "🚩", Code: ":triangular_flag_on_post:", Description: "Add, update, or remove feature flags", Name: "triangular-flag-on-post", Semver: "patch"},
-	{Emoji: "🥅", Code: ":goal_net:", Description: "Catch errors", Name: "goal-net", Semver: "patch"},
-	{Emoji: "💫", Code: ":dizzy:", Description: "Add or update animations and transitions", Name: "dizzy", Semver: "patch"},
-	{Emoji: "🗑️", Code: ":wastebasket:", Description: "Deprecate code that needs to be cleaned up", Name: "wastebasket", Semver: "patch"},
-	{Emoji: "🛂", Code: ":passport_control:", Description: "Work on code related to authorization, roles and permissions", Name: "passport-control", Semver: "patch"},
-	{Emoji: "🩹", Code: ":adhesive_bandage:", Description: "Simple fix for a non-critical issue", Name: "adhesive-bandage", Semver: "patch"},
-	{Emoji: "🧐", Code: ":monocle_face:", Description: "Data exploration/inspection", Name: "monocle-face", Semver: ""},
-	{Emoji: "⚰️", Code: ":coffin:", Description: "Remove dead code", Name: "coffin", Semver: ""},
-	{Emoji: "🧪", Code: ":test_tube:", Description: "Add a failing test", Name: "test-tube", Semver: ""},
-	{Emoji: "👔", Code: ":necktie:", Description: "Add or update business logic", Name: "necktie", Semver: "patch"},
-	{Emoji: "🩺", Code: ":stethoscope:", Description: "Add or update healthcheck", Name: "stethoscope", Semver: ""},
-	{Emoji: "🧱", Code: ":bricks:", Description: "Infrastructure related changes", Name: "bricks", Semver: ""},
-	{Emoji: "🧑‍💻", Code: ":technologist:", Description: "Improve developer experience", Name: "technologist", Semver: ""},
-	{Emoji: "💸", Code: ":money_with_wings:", Description: "Add sponsorships or money related infrastructure", Name: "money-with-wings", Semver: ""},
-	{Emoji: "🧵", Code: ":thread:", Description: "Add or update code related to multithreading or concurrency", Name: "thread", Semver: ""},
-	{Emoji: "🦺", Code: ":safety_vest:", Description: "Add or update code related to validation", Name: "safety-vest", Semver: ""},
+	Emoji       string `yaml:"emoji"`
+	Code        string `yaml:"code"`
+	Description string `yaml:"description"`
+	Name        string `yaml:"name"`
+	Semver      string `yaml:"semver"` // "major", "minor", "patch", or ""
 }
 
-// conventionalToGitmoji maps conventional commit types to gitmoji names
-var conventionalToGitmoji = map[string]string{
-	"feat":      "sparkles",
-	"fix":       "bug",
-	"docs":      "memo",
-	"style":     "lipstick",
-	"refactor":  "recycle",
-	"perf":      "zap",
-	"test":      "white-check-mark",
-	"build":     "construction-worker",
-	"ci":        "green-heart",
-	"chore":     "wrench",
-	"revert":    "rewind",
-	"deps":      "arrow-up",
-	"security":  "lock",
-	"hotfix":    "ambulance",
-	"release":   "rocket",
-	"wip":       "construction",
-	"init":      "tada",
-	"breaking":  "boom",
-	"config":    "wrench",
-	"i18n":      "globe-with-meridians",
-	"typo":      "pencil2",
-	"merge":     "twisted-rightwards-arrows",
-	"move":      "truck",
-	"remove":    "fire",
-	"add":       "heavy-plus-sign",
-	"upgrade":   "arrow-up",
-	"downgrade": "arrow-down",
-	"other":     "wrench",
+// emojiMap is the layout of builtin.EmojiMap: the gitmoji table and the gitmoji name
+// of each Conventional Commits type.
+type emojiMap struct {
+	Types    map[string]string `yaml:"types"`
+	Gitmojis []Gitmoji         `yaml:"gitmojis"`
 }
 
 var (
-	gitmojiByName   map[string]*Gitmoji
-	gitmojiByEmoji  map[string]*Gitmoji
-	emojiPrefixes   []string
-	commitTypeRegex *regexp.Regexp
-	initOnce        sync.Once
+	// gitmojis and conventionalToGitmoji come from the emoji map, which users can
+	// override; commitTypes always comes from the embedded one.
+	gitmojis              []Gitmoji
+	conventionalToGitmoji map[string]string
+	commitTypes           []string
+	gitmojiByName         map[string]*Gitmoji
+	gitmojiByEmoji        map[string]*Gitmoji
+	emojiPrefixes         []string
+	commitTypeRegex       *regexp.Regexp
+	shortcodePattern      = regexp.MustCompile(`^:[a-z0-9_+-]+:`)
+	variationSelector     = "\uFE0F"
+	initOnce              sync.Once
+	typesOnce             sync.Once
+
+	// typeEmojis are the emoji_map config overrides, by commit type, and
+	// typeEmojiPrefixes their emoji from the longest.
+	typeEmojis        map[string]string
+	typeEmojiPrefixes []string
 )
 
+func parseEmojiMap(content []byte) (emojiMap, error) {
+	var m emojiMap
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return emojiMap{}, err
+	}
+	if len(m.Gitmojis) == 0 || len(m.Types) == 0 {
+		return emojiMap{}, errors.New("the emoji map needs both types and gitmojis")
+	}
+	return m, nil
+}
+
+func embeddedEmojiMap() emojiMap {
+	content, err := builtin.Embedded(builtin.EmojiMap)
+	if err == nil {
+		var m emojiMap
+		if m, err = parseEmojiMap(content); err == nil {
+			return m
+		}
+	}
+	panic(fmt.Sprintf("invalid built-in emoji map: %v", err))
+}
+
+// loadCommitTypes reads the commit types from the embedded emoji map. An override
+// cannot add types, because the patterns that parse subjects are built from this list
+// when gmc starts, before overrides can be found.
+func loadCommitTypes() {
+	typesOnce.Do(func() {
+		for t := range embeddedEmojiMap().Types {
+			commitTypes = append(commitTypes, t)
+		}
+		sort.Strings(commitTypes)
+	})
+}
+
 func initMaps() {
 	initOnce.Do(func() {
+		m := embeddedEmojiMap()
+		content, file, err := builtin.Read(builtin.EmojiMap)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Warning: %v; using the built-in emoji map\n", err)
+		case file != "":
+			if override, err := parseEmojiMap(content); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: ignoring %s: %v\n", file, err)
+			} else {
+				m = override
+			}
+		}
+		gitmojis = m.Gitmojis
+		conventionalToGitmoji = m.Types
+
 		gitmojiByName = make(map[string]*Gitmoji, len(gitmojis))
 		gitmojiByEmoji = make(map[string]*Gitmoji, len(gitmojis))
 		emojiPrefixes = make([]string, 0, len(gitmojis))
@@ -153,11 +117,50 @@ func initMaps() {
 			return len(emojiPrefixes[i]) > len(emojiPrefixes[j])
 		})
 
-		commitTypeRegex = regexp.MustCompile(`^([a-zA-Z]+)(?:\([^)]+\))?:`)
+		commitTypeRegex = regexp.MustCompile(`^([a-zA-Z]+)(?:\([^)]+\))?!?:`)
 	})
 }
 
+// SetTypeEmojis puts the emoji of overrides, the emoji_map config, in front of their
+// commit types instead of the emoji map's. A value is an emoji, or a gitmoji name or
+// code such as rocket or :rocket:. It returns the keys that are not commit types,
+// which are ignored; nil overrides clear the previous ones.
+func SetTypeEmojis(overrides map[string]string) []string {
+	initMaps()
+	loadCommitTypes()
+	typeEmojis = make(map[string]string, len(overrides))
+	typeEmojiPrefixes = nil
+	var unknown []string
+	for commitType, value := range overrides {
+		commitType = strings.ToLower(strings.TrimSpace(commitType))
+		value = strings.TrimSpace(value)
+		if _, found := sort.Find(len(commitTypes), func(i int) int {
+			return strings.Compare(commitType, commitTypes[i])
+		}); !found {
+			unknown = append(unknown, commitType)
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		for _, g := range gitmojis {
+			if value == g.Name || value == g.Code {
+				value = g.Emoji
+				break
+			}
+		}
+		typeEmojis[commitType] = value
+		typeEmojiPrefixes = append(typeEmojiPrefixes, value)
+	}
+	sort.Slice(typeEmojiPrefixes, func(i, j int) bool {
+		return len(typeEmojiPrefixes[i]) > len(typeEmojiPrefixes[j])
+	})
+	sort.Strings(unknown)
+	return unknown
+}
+
 func GetAllGitmojis() []Gitmoji {
+	initMaps()
 	return gitmojis
 }
 
@@ -174,6 +177,9 @@ func GetGitmojiByEmoji(emoji string) *Gitmoji {
 func GetEmojiForType(commitType string) string {
 	initMaps()
 	commitType = strings.ToLower(commitType)
+	if emoji, ok := typeEmojis[commitType]; ok {
+		return emoji
+	}
 
 	if name, ok := conventionalToGitmoji[commitType]; ok {
 		if g := gitmojiByName[name]; g != nil {
@@ -184,12 +190,8 @@ func GetEmojiForType(commitType string) string {
 }
 
 func GetAllCommitTypes() []string {
-	types := make([]string, 0, len(conventionalToGitmoji))
-	for t := range conventionalToGitmoji {
-		types = append(types, t)
-	}
-	sort.Strings(types)
-	return types
+	loadCommitTypes()
+	return append([]string(nil), commitTypes...)
 }
 
 func GetCommitTypesRegexPattern() string {
@@ -209,6 +211,19 @@ func GetEmojiDescription() string {
 	return strings.Join(parts, ", ")
 }
 
+// GetShortcodeDescription is GetEmojiDescription with gitmoji codes, as in
+// ":sparkles: for feat", for emoji_style gitmoji.
+func GetShortcodeDescription() string {
+	types := GetAllCommitTypes()
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		if emoji := GetEmojiForType(t); emoji != "" {
+			parts = append(parts, Shortcode(emoji)+" for "+t)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 func InferTypeFromEmojiPrefix(message string) (string, string) {
 	initMaps()
 	message = strings.TrimSpace(message)
@@ -216,22 +231,96 @@ func InferTypeFromEmojiPrefix(message string) (string, string) {
 		return "", ""
 	}
 
-	for _, emoji := range emojiPrefixes {
+	for _, emoji := range typeEmojiPrefixes {
 		if after, found := strings.CutPrefix(message, emoji); found {
-			rest := strings.TrimSpace(after)
-			if g := gitmojiByEmoji[emoji]; g != nil {
-				for convType, gitmojiName := range conventionalToGitmoji {
-					if gitmojiName == g.Name {
-						return convType, rest
-					}
+			for _, commitType := range commitTypes {
+				if typeEmojis[commitType] == emoji {
+					return commitType, strings.TrimSpace(after)
 				}
 			}
-			return "", rest
 		}
 	}
+	if g, rest, found := CutGitmoji(message); found {
+		for convType, gitmojiName := range conventionalToGitmoji {
+			if gitmojiName == g.Name {
+				return convType, rest
+			}
+		}
+		return "", rest
+	}
 	return "", ""
 }
 
+// CutGitmoji returns the gitmoji message starts with, as an emoji or a code such as
+// :sparkles:, and the rest of message. found is false when message does not start with
+// one of the gitmoji list.
+func CutGitmoji(message string) (g *Gitmoji, rest string, found bool) {
+	initMaps()
+	message = strings.TrimSpace(message)
+	for _, emoji := range emojiPrefixes {
+		after, ok := strings.CutPrefix(message, emoji)
+		if !ok {
+			// LLMs often leave out the variation selector, as in 🔒 for 🔒️.
+			after, ok = strings.CutPrefix(message, strings.TrimSuffix(emoji, variationSelector))
+		}
+		if ok {
+			return gitmojiByEmoji[emoji], strings.TrimSpace(strings.TrimPrefix(after, variationSelector)), true
+		}
+	}
+	if code := shortcodePattern.FindString(message); code != "" {
+		for i := range gitmojis {
+			if gitmojis[i].Code == code {
+				return &gitmojis[i], strings.TrimSpace(message[len(code):]), true
+			}
+		}
+	}
+	return nil, message, false
+}
+
+// StripEmoji removes the emoji and :shortcode: prefixes of message, whether or not they
+// are gitmoji.
+func StripEmoji(message string) string {
+	message = strings.TrimSpace(message)
+	for message != "" {
+		if code := shortcodePattern.FindString(message); code != "" {
+			message = strings.TrimSpace(message[len(code):])
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(message)
+		if !isEmoji(r) {
+			break
+		}
+		message = strings.TrimSpace(message[size:])
+	}
+	return message
+}
+
+// Shortcode returns the gitmoji code of emoji, such as :sparkles: for ✨, or emoji
+// itself when it is not a gitmoji.
+func Shortcode(emoji string) string {
+	if g := GetGitmojiByEmoji(emoji); g != nil {
+		return g.Code
+	}
+	return emoji
+}
+
+// AddShortcodeToMessage is AddEmojiToMessage with the gitmoji code of the type, as in
+// ":sparkles: feat: add login", for emoji_style gitmoji.
+func AddShortcodeToMessage(message string) string {
+	message = strings.TrimSpace(message)
+	if _, _, found := CutGitmoji(message); found || message == "" {
+		return message
+	}
+	commitType := extractCommitType(message)
+	if commitType == "" {
+		return message
+	}
+	if emoji := GetEmojiForType(commitType); emoji != "" {
+		return Shortcode(emoji) + " " + message
+	}
+	return message
+}
+
 func AddEmojiToMessage(message string) string {
 	initMaps()
 	message = strings.TrimSpace(message)
@@ -276,8 +365,9 @@ func isEmoji(r rune) bool {
 }
 
 func GetGitmojiPromptList() string {
+	initMaps()
 	var sb strings.Builder
-	for _, g := range gitmojis[:20] {
+	for _, g := range gitmojis[:min(20, len(gitmojis))] {
 		sb.WriteString(g.Emoji)
 		sb.WriteString(" ")
 		sb.WriteString(g.Description)
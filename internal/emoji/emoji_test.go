@@ -1,9 +1,14 @@
 package emoji
 
 import (
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/samzong/gmc/internal/builtin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetEmojiForType(t *testing.T) {
@@ -108,6 +113,11 @@ func TestAddEmojiToMessage(t *testing.T) {
 			input:    "feat(auth): add user authentication",
 			expected: "✨ feat(auth): add user authentication",
 		},
+		{
+			name:     "breaking marker",
+			input:    "feat(api)!: drop v1 endpoints",
+			expected: "✨ feat(api)!: drop v1 endpoints",
+		},
 		{
 			name:     "fix without scope",
 			input:    "fix: resolve parsing error",
@@ -388,3 +398,80 @@ func TestGetGitmojiPromptList(t *testing.T) {
 	assert.Contains(t, list, "🐛")
 	assert.Contains(t, list, "Improve")
 }
+
+func TestEmojiMapOverride(t *testing.T) {
+	dir := t.TempDir()
+	override := "types:\n  feat: rocket\n  ticket: memo\ngitmojis:\n" +
+		"  - {emoji: \"🚀\", code: \":rocket:\", description: Deploy stuff, name: rocket}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, builtin.EmojiMap), []byte(override), 0o644))
+
+	builtin.SetOverrideDirs(func() []string { return []string{dir} })
+	initOnce = sync.Once{}
+	t.Cleanup(func() {
+		builtin.SetOverrideDirs(nil)
+		initOnce = sync.Once{}
+	})
+
+	assert.Equal(t, "🚀", GetEmojiForType("feat"))
+	assert.Empty(t, GetEmojiForType("fix"), "the override replaces the whole map")
+	assert.Len(t, GetAllGitmojis(), 1)
+	assert.NotContains(t, GetAllCommitTypes(), "ticket", "an override cannot add commit types")
+	assert.Contains(t, GetAllCommitTypes(), "fix")
+}
+
+func TestSetTypeEmojis(t *testing.T) {
+	t.Cleanup(func() { SetTypeEmojis(nil) })
+
+	unknown := SetTypeEmojis(map[string]string{
+		"feat": "🚀", "Fix": ":adhesive_bandage:", "docs": "books", "ticket": "📌",
+	})
+	assert.Equal(t, []string{"ticket"}, unknown)
+	assert.Equal(t, "🚀", GetEmojiForType("feat"))
+	assert.Equal(t, "🩹", GetEmojiForType("fix"))
+	assert.Equal(t, "books", GetEmojiForType("docs"), "a value that is not a gitmoji is used as is")
+	assert.Equal(t, "✅", GetEmojiForType("test"), "types without an override keep the emoji map's")
+	assert.Contains(t, GetEmojiDescription(), "🚀 for feat")
+	assert.Equal(t, "🚀 feat: add login", AddEmojiToMessage("feat: add login"))
+
+	commitType, rest := InferTypeFromEmojiPrefix("🚀 add login")
+	assert.Equal(t, "feat", commitType)
+	assert.Equal(t, "add login", rest)
+
+	SetTypeEmojis(nil)
+	assert.Equal(t, "✨", GetEmojiForType("feat"))
+}
+
+func TestCutGitmoji(t *testing.T) {
+	g, rest, found := CutGitmoji("✨ feat: add login")
+	require.True(t, found)
+	assert.Equal(t, "sparkles", g.Name)
+	assert.Equal(t, "feat: add login", rest)
+
+	g, rest, found = CutGitmoji(":bug: fix crash")
+	require.True(t, found)
+	assert.Equal(t, "🐛", g.Emoji)
+	assert.Equal(t, "fix crash", rest)
+
+	_, rest, found = CutGitmoji(":not_a_gitmoji: feat: x")
+	assert.False(t, found)
+	assert.Equal(t, ":not_a_gitmoji: feat: x", rest)
+
+	commitType, rest := InferTypeFromEmojiPrefix(":sparkles: add login")
+	assert.Equal(t, "feat", commitType)
+	assert.Equal(t, "add login", rest)
+}
+
+func TestStripEmoji(t *testing.T) {
+	assert.Equal(t, "feat: add login", StripEmoji("✨ feat: add login"))
+	assert.Equal(t, "feat: add login", StripEmoji(":sparkles: :tada: feat: add login"))
+	assert.Equal(t, "feat: add login", StripEmoji("🤖feat: add login"))
+	assert.Equal(t, "feat: add login", StripEmoji("feat: add login"))
+}
+
+func TestAddShortcodeToMessage(t *testing.T) {
+	assert.Equal(t, ":sparkles: feat: add login", AddShortcodeToMessage("feat: add login"))
+	assert.Equal(t, ":bug: fix(api): handle nil", AddShortcodeToMessage("fix(api): handle nil"))
+	assert.Equal(t, ":lock: fix: escape input", AddShortcodeToMessage(":lock: fix: escape input"))
+	assert.Equal(t, "add login", AddShortcodeToMessage("add login"))
+	assert.Contains(t, GetShortcodeDescription(), ":sparkles: for feat")
+}
@@ -0,0 +1,255 @@
+// Package eval runs recorded diffs through prompt templates and models, and scores
+// the generated messages with the analyzer's quality rules, so templates and models
+// can be compared before changing the default.
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/samzong/gmc/internal/analyzer"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+)
+
+// fixtureExts are the file extensions LoadFixtures reads.
+var fixtureExts = []string{".diff", ".patch"}
+
+// Fixture is one recorded diff.
+type Fixture struct {
+	Name  string
+	Diff  string
+	Files []string
+}
+
+// Variant is one template and model combination to evaluate.
+type Variant struct {
+	Template string `json:"template"`
+	Model    string `json:"model"`
+}
+
+// String returns the label of v in reports.
+func (v Variant) String() string {
+	return v.Template + " / " + v.Model
+}
+
+// Result is the message one variant generated for one fixture, with its score.
+type Result struct {
+	Fixture  string        `json:"fixture"`
+	Variant  Variant       `json:"variant"`
+	Message  string        `json:"message,omitempty"`
+	Score    int           `json:"score"`
+	Type     string        `json:"type,omitempty"`
+	Issues   []string      `json:"issues,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+
+	err error
+}
+
+// Summary aggregates the results of one variant. Failed generations count in Failed
+// only, not in the scores.
+type Summary struct {
+	Variant      Variant       `json:"variant"`
+	Runs         int           `json:"runs"`
+	Failed       int           `json:"failed"`
+	AverageScore float64       `json:"average_score"`
+	MinScore     int           `json:"min_score"`
+	AverageTime  time.Duration `json:"average_time_ns"`
+}
+
+// Report is the outcome of an evaluation.
+type Report struct {
+	Summaries []Summary `json:"summaries"`
+	Results   []Result  `json:"results"`
+}
+
+// GenerateFunc generates a commit message for fixture with variant.
+type GenerateFunc func(fixture Fixture, variant Variant) (string, error)
+
+// LoadFixtures reads the .diff and .patch files of dir, sorted by name. A fixture
+// holds what gmc - reads: a plain diff, or git status --porcelain output, a diff and
+// file snippets.
+func LoadFixtures(dir string) ([]Fixture, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var fixtures []Fixture
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || !slices.Contains(fixtureExts, ext) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		payload := formatter.ParseStdinPayload(string(data))
+		diff := payload.PromptDiff()
+		if strings.TrimSpace(diff) == "" {
+			return nil, fmt.Errorf("fixture %s has no diff", entry.Name())
+		}
+		fixtures = append(fixtures, Fixture{
+			Name:  strings.TrimSuffix(entry.Name(), ext),
+			Diff:  diff,
+			Files: payload.ChangedFiles(),
+		})
+	}
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("no .diff or .patch fixtures in %s", dir)
+	}
+	return fixtures, nil
+}
+
+// Run generates a message for every fixture with every variant and scores it.
+// Progress goes to progress, one line per generation.
+func Run(fixtures []Fixture, variants []Variant, generate GenerateFunc, progress io.Writer) []Result {
+	results := make([]Result, 0, len(fixtures)*len(variants))
+	total := len(fixtures) * len(variants)
+	for _, variant := range variants {
+		for _, fixture := range fixtures {
+			fmt.Fprintf(progress, "[%d/%d] %s: %s\n", len(results)+1, total, variant, fixture.Name)
+			start := time.Now()
+			message, err := generate(fixture, variant)
+			result := Result{Fixture: fixture.Name, Variant: variant, Duration: time.Since(start)}
+			if err != nil {
+				result.Error, result.err = err.Error(), err
+			} else {
+				result.Message = message
+				subject, _ := formatter.SplitCommitMessage(message)
+				score := analyzer.ScoreCommit(git.CommitInfo{Message: subject})
+				result.Score, result.Type, result.Issues = score.Score, score.Type, score.Issues
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Summarize aggregates results per variant, in the order of variants.
+func Summarize(results []Result, variants []Variant) []Summary {
+	summaries := make([]Summary, 0, len(variants))
+	for _, variant := range variants {
+		summary := Summary{Variant: variant}
+		var scored, scoreSum int
+		var elapsed time.Duration
+		for _, result := range results {
+			if result.Variant != variant {
+				continue
+			}
+			summary.Runs++
+			elapsed += result.Duration
+			if result.Error != "" {
+				summary.Failed++
+				continue
+			}
+			if scored == 0 || result.Score < summary.MinScore {
+				summary.MinScore = result.Score
+			}
+			scored++
+			scoreSum += result.Score
+		}
+		if scored > 0 {
+			summary.AverageScore = math.Round(float64(scoreSum)/float64(scored)*10) / 10
+		}
+		if summary.Runs > 0 {
+			summary.AverageTime = elapsed / time.Duration(summary.Runs)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// ErrAllFailed is returned by Check when no generation succeeded.
+var ErrAllFailed = errors.New("every generation failed")
+
+// Check returns ErrAllFailed, with the first error, when no result has a message.
+func (r Report) Check() error {
+	if len(r.Results) == 0 {
+		return nil
+	}
+	for _, result := range r.Results {
+		if result.Error == "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrAllFailed, r.Results[0].err)
+}
+
+// Render writes the summary table and the score of every fixture per variant. With
+// messages, it also lists each generated subject and its issues.
+func Render(w io.Writer, report Report, messages bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIANT\tAVG SCORE\tMIN\tFAILED\tAVG TIME")
+	for _, s := range report.Summaries {
+		fmt.Fprintf(tw, "%s\t%.1f\t%d\t%d/%d\t%s\n", s.Variant, s.AverageScore, s.MinScore, s.Failed, s.Runs,
+			s.AverageTime.Round(100*time.Millisecond))
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"FIXTURE"}
+	for _, s := range report.Summaries {
+		header = append(header, s.Variant.String())
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, fixture := range fixtureNames(report.Results) {
+		row := []string{fixture}
+		for _, s := range report.Summaries {
+			row = append(row, cell(report.Results, fixture, s.Variant))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	_ = tw.Flush()
+
+	if !messages {
+		return
+	}
+	for _, result := range report.Results {
+		fmt.Fprintf(w, "\n%s (%s)\n", result.Fixture, result.Variant)
+		if result.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", result.Error)
+			continue
+		}
+		subject, _ := formatter.SplitCommitMessage(result.Message)
+		fmt.Fprintf(w, "  %d  %s\n", result.Score, subject)
+		for _, issue := range result.Issues {
+			fmt.Fprintf(w, "      - %s\n", issue)
+		}
+	}
+}
+
+func fixtureNames(results []Result) []string {
+	var names []string
+	for _, result := range results {
+		if !slices.Contains(names, result.Fixture) {
+			names = append(names, result.Fixture)
+		}
+	}
+	return names
+}
+
+func cell(results []Result, fixture string, variant Variant) string {
+	for _, result := range results {
+		if result.Fixture != fixture || result.Variant != variant {
+			continue
+		}
+		if result.Error != "" {
+			return "error"
+		}
+		return strconv.Itoa(result.Score)
+	}
+	return "-"
+}
@@ -0,0 +1,111 @@
+package eval
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const loginDiff = `diff --git a/auth/login.go b/auth/login.go
+--- a/auth/login.go
++++ b/auth/login.go
+@@ -1 +1,2 @@
+ package auth
++func Login() {}
+`
+
+func writeFixture(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "b-login.diff", loginDiff)
+	writeFixture(t, dir, "a-status.patch", " M docs/guide.md\n==> docs/guide.md <==\n# Guide\n")
+	writeFixture(t, dir, "README.md", "not a fixture")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.diff"), 0o755))
+
+	fixtures, err := LoadFixtures(dir)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 2)
+	assert.Equal(t, "a-status", fixtures[0].Name)
+	assert.Equal(t, []string{"docs/guide.md"}, fixtures[0].Files)
+	assert.Contains(t, fixtures[0].Diff, "new file mode")
+	assert.Equal(t, "b-login", fixtures[1].Name)
+	assert.Equal(t, []string{"auth/login.go"}, fixtures[1].Files)
+}
+
+func TestLoadFixturesErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadFixtures(dir)
+	assert.ErrorContains(t, err, "no .diff or .patch fixtures")
+
+	writeFixture(t, dir, "empty.diff", "\n")
+	_, err = LoadFixtures(dir)
+	assert.ErrorContains(t, err, "fixture empty.diff has no diff")
+
+	_, err = LoadFixtures(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "failed to read fixtures")
+}
+
+func TestRunSummarizeAndRender(t *testing.T) {
+	fixtures := []Fixture{{Name: "login"}, {Name: "docs"}}
+	good := Variant{Template: "default", Model: "gpt-4o"}
+	poor := Variant{Template: "compact", Model: "gpt-4o-mini"}
+	replies := map[Variant]map[string]string{
+		good: {"login": "feat(auth): add login handler", "docs": "docs: describe the setup guide"},
+		poor: {"login": "update"},
+	}
+	errTimeout := errors.New("request timed out")
+	generate := func(fixture Fixture, variant Variant) (string, error) {
+		if message, ok := replies[variant][fixture.Name]; ok {
+			return message, nil
+		}
+		return "", errTimeout
+	}
+
+	var progress bytes.Buffer
+	results := Run(fixtures, []Variant{good, poor}, generate, &progress)
+	require.Len(t, results, 4)
+	assert.Contains(t, progress.String(), "[4/4] compact / gpt-4o-mini: docs")
+	assert.Equal(t, 100, results[0].Score)
+	assert.Equal(t, "feat", results[0].Type)
+	assert.Equal(t, 35, results[2].Score)
+	assert.Contains(t, results[2].Issues, "vague description")
+	assert.Equal(t, "request timed out", results[3].Error)
+
+	summaries := Summarize(results, []Variant{good, poor})
+	require.Len(t, summaries, 2)
+	assert.InDelta(t, 100.0, summaries[0].AverageScore, 0.001)
+	assert.Equal(t, 0, summaries[0].Failed)
+	assert.InDelta(t, 35.0, summaries[1].AverageScore, 0.001)
+	assert.Equal(t, 35, summaries[1].MinScore)
+	assert.Equal(t, 1, summaries[1].Failed)
+	assert.Equal(t, 2, summaries[1].Runs)
+
+	report := Report{Summaries: summaries, Results: results}
+	require.NoError(t, report.Check())
+
+	var out bytes.Buffer
+	Render(&out, report, true)
+	assert.Contains(t, out.String(), "default / gpt-4o")
+	assert.Regexp(t, `docs\s+100\s+error`, out.String())
+	assert.Contains(t, out.String(), "  35  update\n      - not a Conventional Commit\n")
+	assert.Contains(t, out.String(), "  error: request timed out")
+}
+
+func TestReportCheckAllFailed(t *testing.T) {
+	errAuth := errors.New("unauthorized")
+	results := Run([]Fixture{{Name: "login"}}, []Variant{{Template: "default", Model: "m"}},
+		func(Fixture, Variant) (string, error) { return "", errAuth }, &bytes.Buffer{})
+
+	err := Report{Results: results}.Check()
+	require.ErrorIs(t, err, ErrAllFailed)
+	assert.ErrorIs(t, err, errAuth)
+}
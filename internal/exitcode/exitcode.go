@@ -7,6 +7,22 @@ const (
 	NoStagedChanges = 10
 	NotGitRepo      = 11
 	LLMError        = 12
+	// RiskNotAcknowledged is a commit flagged by risk_policies that was not confirmed.
+	RiskNotAcknowledged = 13
+	// ConfigMissing is a required setting, such as the API key, that is not set.
+	ConfigMissing = 14
+	// LLMAuth is an API key the LLM endpoint rejected.
+	LLMAuth = 15
+	// LLMTimeout is an LLM request that did not finish in time.
+	LLMTimeout = 16
+	// UserCancelled is a prompt the user declined or a picker the user quit.
+	UserCancelled = 17
+	// SecretsFound is a diff with possible secrets that was not sent to the LLM.
+	SecretsFound = 18
+	// ReviewFindings is a gmc review with findings at or above --fail-on.
+	ReviewFindings = 19
+	// QualityBelowMinScore is a gmc stats whose average score is below --min-score.
+	QualityBelowMinScore = 20
 )
 
 type Error struct {
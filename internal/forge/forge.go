@@ -0,0 +1,159 @@
+// Package forge talks to code-hosting services (issues, pull requests) over their REST APIs.
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrUnsupportedRemote is returned when a remote URL does not map to a known forge.
+var ErrUnsupportedRemote = errors.New("unsupported forge remote")
+
+// Supported forge kinds, also accepted by the `forge` config key.
+const (
+	KindGitHub = "github"
+	KindGitLab = "gitlab"
+	KindGitea  = "gitea"
+)
+
+// Issue is the subset of issue metadata used to enrich commit prompts.
+type Issue struct {
+	Number string `json:"number"`
+	Title  string `json:"title"`
+	// Description is the issue's text, set by trackers such as Jira.
+	Description string   `json:"description,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	URL         string   `json:"url,omitempty"`
+}
+
+// IssueClient fetches issue metadata for a single repository.
+type IssueClient interface {
+	FetchIssue(ctx context.Context, number string) (*Issue, error)
+}
+
+// Repo identifies a repository on a forge host.
+type Repo struct {
+	Host  string
+	Owner string
+	Name  string
+}
+
+// FullName returns "owner/name".
+func (r Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// Options configures forge clients.
+type Options struct {
+	// Kind forces a forge kind instead of detecting it from the remote host.
+	Kind string
+	// Token authenticates API requests. Empty means anonymous access.
+	Token string
+	// BaseURL overrides the API endpoint (used for enterprise hosts and tests).
+	BaseURL string
+}
+
+// NewIssueClient returns an issue client for the forge hosting remoteURL.
+func NewIssueClient(remoteURL string, opts Options) (IssueClient, error) {
+	repo, kind, err := Resolve(remoteURL, opts.Kind)
+	if err != nil {
+		return nil, err
+	}
+	return NewIssueClientFor(repo, kind, opts)
+}
+
+// NewIssueClientFor returns an issue client of the given kind for repo.
+func NewIssueClientFor(repo Repo, kind string, opts Options) (IssueClient, error) {
+	switch kind {
+	case KindGitHub:
+		return NewGitHub(repo, opts), nil
+	case KindGitLab:
+		return NewGitLab(repo, opts), nil
+	case KindGitea:
+		return NewGitea(repo, opts), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedRemote, repo.Host)
+	}
+}
+
+// Resolve parses remoteURL and determines the forge kind, honoring override when set.
+func Resolve(remoteURL string, override string) (Repo, string, error) {
+	repo, err := ParseRemoteURL(remoteURL)
+	if err != nil {
+		return Repo{}, "", err
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(override))
+	if kind == "" {
+		kind = DetectKind(repo.Host)
+	}
+	if !IsValidKind(kind) {
+		if override != "" {
+			return Repo{}, "", fmt.Errorf("unknown forge %q (must be github, gitlab or gitea)", override)
+		}
+		return Repo{}, "", fmt.Errorf("%w: %s (set the forge config key to override)", ErrUnsupportedRemote, repo.Host)
+	}
+	return repo, kind, nil
+}
+
+// DetectKind guesses the forge kind from a remote host name, or returns "".
+func DetectKind(host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case host == "github.com" || strings.HasSuffix(host, ".github.com"):
+		return KindGitHub
+	case strings.Contains(host, "gitlab"):
+		return KindGitLab
+	case strings.Contains(host, "gitea") || host == "codeberg.org":
+		return KindGitea
+	default:
+		return ""
+	}
+}
+
+// IsValidKind reports whether kind names a supported forge.
+func IsValidKind(kind string) bool {
+	switch kind {
+	case KindGitHub, KindGitLab, KindGitea:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseRemoteURL extracts host, owner, and repository name from a git remote URL.
+// Both URL-style (https://host/owner/repo.git) and scp-style (git@host:owner/repo.git)
+// remotes are accepted.
+func ParseRemoteURL(remoteURL string) (Repo, error) {
+	trimmed := strings.TrimSpace(remoteURL)
+	if trimmed == "" {
+		return Repo{}, errors.New("remote URL is empty")
+	}
+
+	var host, path string
+	if parsed, err := url.Parse(trimmed); err == nil && parsed.Host != "" {
+		host = parsed.Hostname()
+		path = parsed.Path
+	} else if at := strings.Index(trimmed, "@"); at >= 0 {
+		rest := trimmed[at+1:]
+		colon := strings.Index(rest, ":")
+		if colon < 0 {
+			return Repo{}, fmt.Errorf("cannot parse remote URL: %s", remoteURL)
+		}
+		host = rest[:colon]
+		path = rest[colon+1:]
+	} else {
+		return Repo{}, fmt.Errorf("cannot parse remote URL: %s", remoteURL)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	slash := strings.LastIndex(path, "/")
+	if host == "" || slash <= 0 || slash == len(path)-1 {
+		return Repo{}, fmt.Errorf("cannot parse owner/repository from remote URL: %s", remoteURL)
+	}
+
+	return Repo{Host: host, Owner: path[:slash], Name: path[slash+1:]}, nil
+}
@@ -0,0 +1,141 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	gmcRepo := Repo{Host: "github.com", Owner: "samzong", Name: "gmc"}
+	tests := []struct {
+		name    string
+		remote  string
+		want    Repo
+		wantErr bool
+	}{
+		{name: "https", remote: "https://github.com/samzong/gmc.git", want: gmcRepo},
+		{name: "scp", remote: "git@github.com:samzong/gmc.git", want: gmcRepo},
+		{name: "no suffix", remote: "https://github.com/samzong/gmc", want: gmcRepo},
+		{
+			name:   "ssh url with port",
+			remote: "ssh://git@gitlab.example.com:2222/group/sub/repo.git",
+			want:   Repo{Host: "gitlab.example.com", Owner: "group/sub", Name: "repo"},
+		},
+		{name: "empty", remote: "", wantErr: true},
+		{name: "local path", remote: "/srv/git/repo.git", wantErr: true},
+		{name: "missing owner", remote: "https://github.com/gmc.git", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRemoteURL(tt.remote)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewIssueClientRejectsUnknownHost(t *testing.T) {
+	_, err := NewIssueClient("https://example.com/owner/repo.git", Options{})
+	assert.True(t, errors.Is(err, ErrUnsupportedRemote))
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		remote   string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{name: "github", remote: "git@github.com:o/r.git", want: KindGitHub},
+		{name: "gitlab", remote: "https://gitlab.com/group/sub/r.git", want: KindGitLab},
+		{name: "self-hosted gitlab", remote: "git@gitlab.corp.example:o/r.git", want: KindGitLab},
+		{name: "codeberg", remote: "https://codeberg.org/o/r.git", want: KindGitea},
+		{name: "override", remote: "https://git.example.com/o/r.git", override: "Gitea", want: KindGitea},
+		{name: "unknown host", remote: "https://git.example.com/o/r.git", wantErr: true},
+		{name: "bad override", remote: "https://github.com/o/r.git", override: "bitbucket", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, kind, err := Resolve(tt.remote, tt.override)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, kind)
+		})
+	}
+}
+
+func TestGitHubFetchIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/samzong/gmc/issues/42", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"number":42,"title":" Crash on empty diff ",` +
+			`"html_url":"https://github.com/samzong/gmc/issues/42",` +
+			`"labels":[{"name":"bug"},{"name":"cli"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewGitHub(Repo{Host: "github.com", Owner: "samzong", Name: "gmc"},
+		Options{Token: "secret", BaseURL: server.URL})
+	issue, err := client.FetchIssue(context.Background(), "#42")
+	require.NoError(t, err)
+	assert.Equal(t, "42", issue.Number)
+	assert.Equal(t, "Crash on empty diff", issue.Title)
+	assert.Equal(t, []string{"bug", "cli"}, issue.Labels)
+}
+
+func TestGitHubFetchIssueNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewGitHub(Repo{Host: "github.com", Owner: "o", Name: "r"}, Options{BaseURL: server.URL})
+	_, err := client.FetchIssue(context.Background(), "7")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestGitLabFetchIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/group%2Fsub%2Frepo/issues/5", r.URL.EscapedPath())
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		_, _ = w.Write([]byte(`{"iid":5,"title":"Slow sync","labels":["perf"],"web_url":"https://gitlab.com/x"}`))
+	}))
+	defer server.Close()
+
+	client := NewGitLab(Repo{Host: "gitlab.com", Owner: "group/sub", Name: "repo"},
+		Options{Token: "secret", BaseURL: server.URL})
+	issue, err := client.FetchIssue(context.Background(), "5")
+	require.NoError(t, err)
+	assert.Equal(t, &Issue{Number: "5", Title: "Slow sync", Labels: []string{"perf"}, URL: "https://gitlab.com/x"}, issue)
+}
+
+func TestGiteaFetchIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/o/r/issues/9", r.URL.Path)
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"number":9,"title":"Docs typo","labels":[{"name":"docs"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewGitea(Repo{Host: "codeberg.org", Owner: "o", Name: "r"}, Options{Token: "secret", BaseURL: server.URL})
+	issue, err := client.FetchIssue(context.Background(), "9")
+	require.NoError(t, err)
+	assert.Equal(t, "Docs typo", issue.Title)
+	assert.Equal(t, []string{"docs"}, issue.Labels)
+}
@@ -0,0 +1,53 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Gitea is a minimal Gitea (and Forgejo) REST client bound to one repository.
+type Gitea struct {
+	repo       Repo
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGitea creates a Gitea client for repo. The API is served from the remote host.
+func NewGitea(repo Repo, opts Options) *Gitea {
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://" + repo.Host + "/api/v1"
+	}
+	return &Gitea{
+		repo:       repo,
+		token:      opts.Token,
+		baseURL:    baseURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// FetchIssue returns the title and labels of an issue by index.
+func (g *Gitea) FetchIssue(ctx context.Context, number string) (*Issue, error) {
+	number, err := normalizeIssueNumber(number)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%s",
+		g.baseURL, url.PathEscape(g.repo.Owner), url.PathEscape(g.repo.Name), url.PathEscape(number))
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("Authorization", "token "+g.token)
+	}
+
+	// Gitea mirrors GitHub's issue payload shape.
+	var raw githubIssue
+	if err := getJSON(ctx, g.httpClient, endpoint, header, "Gitea issue #"+number, &raw); err != nil {
+		return nil, err
+	}
+	return raw.toIssue(), nil
+}
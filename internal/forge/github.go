@@ -0,0 +1,83 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const githubAPIBase = "https://api.github.com"
+
+// GitHub is a minimal GitHub REST client bound to one repository.
+type GitHub struct {
+	repo       Repo
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGitHub creates a GitHub client for repo.
+func NewGitHub(repo Repo, opts Options) *GitHub {
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = githubAPIBase
+		if host := strings.ToLower(repo.Host); host != "" && host != "github.com" {
+			// GitHub Enterprise serves the REST API under /api/v3.
+			baseURL = "https://" + repo.Host + "/api/v3"
+		}
+	}
+	return &GitHub{
+		repo:       repo,
+		token:      opts.Token,
+		baseURL:    baseURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	Labels  []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+// FetchIssue returns the title and labels of an issue (or pull request) by number.
+func (g *GitHub) FetchIssue(ctx context.Context, number string) (*Issue, error) {
+	number, err := normalizeIssueNumber(number)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%s",
+		g.baseURL, url.PathEscape(g.repo.Owner), url.PathEscape(g.repo.Name), url.PathEscape(number))
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	if g.token != "" {
+		header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	var raw githubIssue
+	if err := getJSON(ctx, g.httpClient, endpoint, header, "GitHub issue #"+number, &raw); err != nil {
+		return nil, err
+	}
+	return raw.toIssue(), nil
+}
+
+func (raw githubIssue) toIssue() *Issue {
+	issue := &Issue{
+		Number: strconv.Itoa(raw.Number),
+		Title:  strings.TrimSpace(raw.Title),
+		URL:    raw.HTMLURL,
+	}
+	for _, label := range raw.Labels {
+		if label.Name != "" {
+			issue.Labels = append(issue.Labels, label.Name)
+		}
+	}
+	return issue
+}
@@ -0,0 +1,66 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GitLab is a minimal GitLab REST (v4) client bound to one project.
+type GitLab struct {
+	repo       Repo
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGitLab creates a GitLab client for repo. The API is served from the remote host.
+func NewGitLab(repo Repo, opts Options) *GitLab {
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://" + repo.Host + "/api/v4"
+	}
+	return &GitLab{
+		repo:       repo,
+		token:      opts.Token,
+		baseURL:    baseURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+type gitlabIssue struct {
+	IID    int      `json:"iid"`
+	Title  string   `json:"title"`
+	Labels []string `json:"labels"`
+	WebURL string   `json:"web_url"`
+}
+
+// FetchIssue returns the title and labels of a project issue by its IID.
+func (g *GitLab) FetchIssue(ctx context.Context, number string) (*Issue, error) {
+	number, err := normalizeIssueNumber(number)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/issues/%s",
+		g.baseURL, url.PathEscape(g.repo.FullName()), url.PathEscape(number))
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	var raw gitlabIssue
+	if err := getJSON(ctx, g.httpClient, endpoint, header, "GitLab issue #"+number, &raw); err != nil {
+		return nil, err
+	}
+
+	return &Issue{
+		Number: strconv.Itoa(raw.IID),
+		Title:  strings.TrimSpace(raw.Title),
+		Labels: raw.Labels,
+		URL:    raw.WebURL,
+	}, nil
+}
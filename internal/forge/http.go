@@ -0,0 +1,56 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxResponseBytes = 1 << 20
+
+// getJSON performs an authenticated GET and decodes a JSON response into target.
+// what names the resource in error messages, e.g. "GitHub issue #12".
+func getJSON(
+	ctx context.Context, client *http.Client, endpoint string, header http.Header, what string, target any,
+) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request for %s: %w", what, err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response for %s: %w", what, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s lookup failed: %s", what, resp.Status)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", what, err)
+	}
+	return nil
+}
+
+func normalizeIssueNumber(number string) (string, error) {
+	number = strings.TrimPrefix(strings.TrimSpace(number), "#")
+	if number == "" {
+		return "", errors.New("issue number is empty")
+	}
+	return number, nil
+}
@@ -0,0 +1,204 @@
+// Package jira is a minimal Jira REST client: it reads issues to enrich commit prompts,
+// and comments on and transitions them after a commit.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/samzong/gmc/internal/forge"
+)
+
+const maxResponseBytes = 1 << 20
+
+// keyPattern matches a Jira issue key, such as PROJ-1234.
+var keyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*-[0-9]+$`)
+
+// Options configures a Client.
+type Options struct {
+	// URL is the Jira site, such as https://example.atlassian.net.
+	URL string
+	// Email and Token authenticate with basic auth, as Jira Cloud API tokens do. Without
+	// Email, Token is sent as a bearer personal access token, as Jira Data Center expects.
+	Email string
+	Token string
+}
+
+// Client talks to one Jira site over REST API v2.
+type Client struct {
+	baseURL    string
+	email      string
+	token      string
+	httpClient *http.Client
+}
+
+// New returns a client for the site opts.URL.
+func New(opts Options) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(opts.URL, "/"),
+		email:      opts.Email,
+		token:      opts.Token,
+		httpClient: http.DefaultClient,
+	}
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string   `json:"summary"`
+		Description string   `json:"description"`
+		Labels      []string `json:"labels"`
+	} `json:"fields"`
+}
+
+// FetchIssue returns the summary, description and labels of the issue key.
+func (c *Client) FetchIssue(ctx context.Context, key string) (*forge.Issue, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw jiraIssue
+	endpoint := c.issueURL(key) + "?fields=summary,description,labels"
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, "Jira issue "+key, &raw); err != nil {
+		return nil, err
+	}
+	return &forge.Issue{
+		Number:      raw.Key,
+		Title:       strings.TrimSpace(raw.Fields.Summary),
+		Description: strings.TrimSpace(raw.Fields.Description),
+		Labels:      raw.Fields.Labels,
+		URL:         c.baseURL + "/browse/" + url.PathEscape(raw.Key),
+	}, nil
+}
+
+// AddComment adds a plain-text comment to the issue key.
+func (c *Client) AddComment(ctx context.Context, key, body string) error {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return err
+	}
+	payload := map[string]string{"body": body}
+	return c.do(ctx, http.MethodPost, c.issueURL(key)+"/comment", payload, "comment on Jira issue "+key, nil)
+}
+
+type transitions struct {
+	Transitions []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		To   struct {
+			Name string `json:"name"`
+		} `json:"to"`
+	} `json:"transitions"`
+}
+
+// Transition moves the issue key through the workflow transition named name, or the
+// one that leads to the status named name, ignoring case.
+func (c *Client) Transition(ctx context.Context, key, name string) error {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return err
+	}
+
+	var available transitions
+	what := "transitions of Jira issue " + key
+	if err := c.do(ctx, http.MethodGet, c.issueURL(key)+"/transitions", nil, what, &available); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(available.Transitions))
+	for _, transition := range available.Transitions {
+		if strings.EqualFold(transition.Name, name) || strings.EqualFold(transition.To.Name, name) {
+			payload := map[string]any{"transition": map[string]string{"id": transition.ID}}
+			return c.do(ctx, http.MethodPost, c.issueURL(key)+"/transitions", payload,
+				fmt.Sprintf("transition %q of Jira issue %s", transition.Name, key), nil)
+		}
+		names = append(names, transition.Name)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no transitions are available for Jira issue %s", key)
+	}
+	return fmt.Errorf("no transition %q for Jira issue %s; available: %s", name, key, strings.Join(names, ", "))
+}
+
+func (c *Client) issueURL(key string) string {
+	return c.baseURL + "/rest/api/2/issue/" + url.PathEscape(key)
+}
+
+// do sends a request with an optional JSON payload and decodes a JSON response into
+// target, when target is not nil. what names the resource in error messages.
+func (c *Client) do(ctx context.Context, method, endpoint string, payload any, what string, target any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request for %s: %w", what, err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to build request for %s: %w", what, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.email != "":
+		req.SetBasicAuth(c.email, c.token)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Jira for %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response for %s: %w", what, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s failed: %s%s", what, resp.Status, errorDetail(data))
+	}
+	if target == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", what, err)
+	}
+	return nil
+}
+
+// errorDetail returns the errorMessages of a Jira error response, as ": message".
+func errorDetail(data []byte) string {
+	var payload struct {
+		ErrorMessages []string `json:"errorMessages"`
+	}
+	if json.Unmarshal(data, &payload) != nil || len(payload.ErrorMessages) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(payload.ErrorMessages, "; ")
+}
+
+func normalizeKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("issue key is empty")
+	}
+	if !keyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid Jira issue key %q: want a key such as PROJ-1234", key)
+	}
+	return strings.ToUpper(key), nil
+}
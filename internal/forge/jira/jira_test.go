@@ -0,0 +1,92 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/issue/PROJ-12", r.URL.Path)
+		assert.Equal(t, "summary,description,labels", r.URL.Query().Get("fields"))
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "dev@example.com", user)
+		assert.Equal(t, "tok", pass)
+		_, _ = w.Write([]byte(`{"key":"PROJ-12","fields":{"summary":" Login times out ",` +
+			`"description":"Users on slow links are logged out.","labels":["auth"]}}`))
+	}))
+	defer server.Close()
+
+	client := New(Options{URL: server.URL + "/", Email: "dev@example.com", Token: "tok"})
+	issue, err := client.FetchIssue(context.Background(), "proj-12")
+	require.NoError(t, err)
+	assert.Equal(t, "PROJ-12", issue.Number)
+	assert.Equal(t, "Login times out", issue.Title)
+	assert.Equal(t, "Users on slow links are logged out.", issue.Description)
+	assert.Equal(t, []string{"auth"}, issue.Labels)
+	assert.Equal(t, server.URL+"/browse/PROJ-12", issue.URL)
+
+	_, err = client.FetchIssue(context.Background(), "12")
+	assert.ErrorContains(t, err, `invalid Jira issue key "12"`)
+}
+
+func TestFetchIssueError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer pat", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`))
+	}))
+	defer server.Close()
+
+	_, err := New(Options{URL: server.URL, Token: "pat"}).FetchIssue(context.Background(), "PROJ-9")
+	assert.EqualError(t, err, "Jira issue PROJ-9 failed: 404 Not Found: "+
+		"Issue does not exist or you do not have permission to see it.")
+}
+
+func TestCommentAndTransition(t *testing.T) {
+	var requests []string
+	var comment, transition map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/api/2/issue/PROJ-12/comment":
+			require.NoError(t, json.Unmarshal(body, &comment))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"1"}`))
+		case "GET /rest/api/2/issue/PROJ-12/transitions":
+			_, _ = w.Write([]byte(`{"transitions":[{"id":"21","name":"Start progress","to":{"name":"In Progress"}},` +
+				`{"id":"31","name":"Review","to":{"name":"In Review"}}]}`))
+		case "POST /rest/api/2/issue/PROJ-12/transitions":
+			require.NoError(t, json.Unmarshal(body, &transition))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := New(Options{URL: server.URL, Token: "pat"})
+
+	require.NoError(t, client.AddComment(context.Background(), "PROJ-12", "feat: add login retry"))
+	assert.Equal(t, map[string]any{"body": "feat: add login retry"}, comment)
+
+	require.NoError(t, client.Transition(context.Background(), "PROJ-12", "in review"))
+	assert.Equal(t, map[string]any{"transition": map[string]any{"id": "31"}}, transition)
+
+	err := client.Transition(context.Background(), "PROJ-12", "Done")
+	assert.EqualError(t, err, `no transition "Done" for Jira issue PROJ-12; available: Start progress, Review`)
+	assert.Equal(t, []string{
+		"POST /rest/api/2/issue/PROJ-12/comment",
+		"GET /rest/api/2/issue/PROJ-12/transitions",
+		"POST /rest/api/2/issue/PROJ-12/transitions",
+		"GET /rest/api/2/issue/PROJ-12/transitions",
+	}, requests)
+}
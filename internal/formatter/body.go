@@ -0,0 +1,118 @@
+package formatter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BodyWrapWidth is the conventional git limit for commit body lines.
+const BodyWrapWidth = 72
+
+var (
+	bulletPrefixPattern = regexp.MustCompile(`^(\s*)([-*•]|\d+[.)])\s+`)
+	fencePattern        = regexp.MustCompile("^\\s*```")
+)
+
+const bodyPromptSection = `Commit Body:
+After the subject, add a blank line and a body of 2-5 bullets starting with "- ".
+Explain what changed and why, not how, and do not repeat the subject.
+Wrap body lines at 72 characters.
+If the change breaks compatibility, end with a "BREAKING CHANGE: <what breaks and how to migrate>" footer.`
+
+// MarkBreaking adds the "!" marker to a Conventional Commits subject and appends a
+// "BREAKING CHANGE:" footer with description unless the body already has one.
+func MarkBreaking(message, description string) string {
+	subject, body := SplitCommitMessage(message)
+	if m := breakingSubject.FindStringSubmatchIndex(subject); m != nil && m[4] == m[5] {
+		subject = subject[:m[3]] + "!" + subject[m[3]:]
+	}
+
+	if strings.Contains(body, "BREAKING CHANGE:") || strings.Contains(body, "BREAKING-CHANGE:") {
+		return JoinCommitMessage(subject, body)
+	}
+	footer := strings.Join(wrapBodyLine("BREAKING CHANGE: "+description, BodyWrapWidth), "\n")
+	if strings.TrimSpace(body) == "" {
+		return JoinCommitMessage(subject, footer)
+	}
+	return JoinCommitMessage(subject, body+"\n\n"+footer)
+}
+
+func stripCodeFences(message string) string {
+	lines := strings.Split(message, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !fencePattern.MatchString(line) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// SplitCommitMessage splits a message into its subject line and body.
+func SplitCommitMessage(message string) (subject string, body string) {
+	subject, body, _ = strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(subject), strings.Trim(body, "\n")
+}
+
+// JoinCommitMessage joins a subject and optional body with a blank line.
+func JoinCommitMessage(subject, body string) string {
+	if strings.TrimSpace(body) == "" {
+		return subject
+	}
+	return subject + "\n\n" + body
+}
+
+// FormatBody normalizes an LLM-generated commit body: it uses "- " bullets, collapses
+// repeated blank lines, and wraps lines at BodyWrapWidth.
+func FormatBody(body string) string {
+	var lines []string
+	blank := true
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+
+		if m := bulletPrefixPattern.FindStringSubmatch(line); m != nil && !isNumbered(m[2]) {
+			line = m[1] + "- " + line[len(m[0]):]
+		}
+		lines = append(lines, wrapBodyLine(line, BodyWrapWidth)...)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func isNumbered(marker string) bool {
+	return marker != "" && marker[0] >= '0' && marker[0] <= '9'
+}
+
+// wrapBodyLine wraps a single line, indenting bullet continuations under the bullet text.
+// Words longer than the width (such as URLs) are kept whole on their own line.
+func wrapBodyLine(line string, width int) []string {
+	if len([]rune(line)) <= width {
+		return []string{line}
+	}
+
+	prefix := line[:len(line)-len(strings.TrimLeft(line, " "))]
+	indent := prefix
+	if m := bulletPrefixPattern.FindString(line); m != "" {
+		indent = strings.Repeat(" ", len([]rune(m)))
+	}
+
+	words := strings.Fields(line)
+	var wrapped []string
+	current := prefix + words[0]
+	for _, word := range words[1:] {
+		if len([]rune(current))+1+len([]rune(word)) > width {
+			wrapped = append(wrapped, current)
+			current = indent + word
+			continue
+		}
+		current += " " + word
+	}
+	return append(wrapped, current)
+}
@@ -0,0 +1,78 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBody(t *testing.T) {
+	body := "* Add retry with backoff to the forge client so transient 502 responses " +
+		"from self-hosted instances no longer fail the commit\n" +
+		"\n\n" +
+		"• Keep the timeout unchanged   \n" +
+		"BREAKING CHANGE: the forge config key now rejects unknown values " +
+		"instead of silently falling back to detection"
+
+	got := FormatBody(body)
+	assert.Equal(t, strings.Join([]string{
+		"- Add retry with backoff to the forge client so transient 502 responses",
+		"  from self-hosted instances no longer fail the commit",
+		"",
+		"- Keep the timeout unchanged",
+		"BREAKING CHANGE: the forge config key now rejects unknown values instead",
+		"of silently falling back to detection",
+	}, "\n"), got)
+
+	for _, line := range strings.Split(got, "\n") {
+		assert.LessOrEqual(t, len([]rune(line)), BodyWrapWidth)
+	}
+}
+
+func TestFormatBodyKeepsLongWords(t *testing.T) {
+	url := "https://example.com/" + strings.Repeat("a", 80)
+	assert.Equal(t, "- See\n  "+url, FormatBody("- See "+url))
+}
+
+func TestFormatCommitMessageWithBody(t *testing.T) {
+	cfg := &config.Config{CommitBody: true}
+	message := "```\nFeat(api): add retry (#12)\n\n- Retry transient failures\n```"
+	assert.Equal(t, "feat(api): add retry\n\n- Retry transient failures", FormatCommitMessageWithConfig(cfg, message))
+
+	cfg.CommitBody = false
+	assert.Equal(t, "feat(api): add retry", FormatCommitMessageWithConfig(cfg, "feat(api): add retry\n\n- body"))
+}
+
+func TestSplitAndJoinCommitMessage(t *testing.T) {
+	subject, body := SplitCommitMessage("fix: x\n\n- a\n- b\n")
+	assert.Equal(t, "fix: x", subject)
+	assert.Equal(t, "- a\n- b", body)
+	assert.Equal(t, "fix: x\n\n- a\n- b", JoinCommitMessage(subject, body))
+	assert.Equal(t, "fix: x", JoinCommitMessage("fix: x", " "))
+}
+
+func TestMarkBreaking(t *testing.T) {
+	assert.Equal(t, "feat(api)!: drop v1\n\nBREAKING CHANGE: removed exported func Client.V1",
+		MarkBreaking("feat(api): drop v1", "removed exported func Client.V1"))
+	assert.Equal(t, "✨ feat!: drop v1\n\n- Remove the v1 client\n\nBREAKING CHANGE: v1 is gone",
+		MarkBreaking("✨ feat: drop v1\n\n- Remove the v1 client", "v1 is gone"))
+	assert.Equal(t, "feat!: drop v1\n\nBREAKING CHANGE: already explained",
+		MarkBreaking("feat!: drop v1\n\nBREAKING CHANGE: already explained", "v1 is gone"))
+	assert.Equal(t, "Drop v1\n\nBREAKING CHANGE: v1 is gone", MarkBreaking("Drop v1", "v1 is gone"))
+}
+
+func TestBuildPromptWithBody(t *testing.T) {
+	cfg := &config.Config{Role: "Developer", PromptTemplate: config.DefaultPromptTemplate, CommitBody: true}
+	prompt := BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", "")
+	assert.Contains(t, prompt, "Reply with a subject line, a blank line, and a body.")
+	assert.Contains(t, prompt, "Commit Body:")
+	assert.Contains(t, prompt, "BREAKING CHANGE:")
+	assert.NotContains(t, prompt, "Reply with one line.")
+
+	cfg.CommitBody = false
+	prompt = BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", "")
+	assert.Contains(t, prompt, "Reply with one line.")
+	assert.NotContains(t, prompt, "Commit Body:")
+}
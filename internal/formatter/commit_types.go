@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/emoji"
+)
+
+// ErrCommitTypeNotAllowed reports a generated type outside the commit_types allowlist.
+var ErrCommitTypeNotAllowed = errors.New("commit type not allowed")
+
+// subjectTypePattern matches any "type(scope)!: " prefix, including custom types that
+// the built-in type list does not know, after an optional emoji. The groups are the
+// type, the scope in parentheses and the breaking marker.
+var subjectTypePattern = regexp.MustCompile(`^(?:[^\x00-\x7F]+\s*)?([A-Za-z][\w-]*)(\([^)]*\))?(!)?: `)
+
+// CommitTypeOf returns the type of message's subject in lower case, or "" when the
+// subject has no type prefix.
+func CommitTypeOf(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	matches := subjectTypePattern.FindStringSubmatch(subject)
+	if len(matches) < 2 {
+		return ""
+	}
+	return strings.ToLower(matches[1])
+}
+
+// CheckCommitType returns an error wrapping ErrCommitTypeNotAllowed when commit_types is
+// set and message's type is missing or not in it.
+func CheckCommitType(cfg *config.Config, message string) error {
+	if cfg == nil || len(cfg.AllowedCommitTypes()) == 0 {
+		return nil
+	}
+	commitType := CommitTypeOf(message)
+	if commitType != "" && cfg.AllowsCommitType(commitType) {
+		return nil
+	}
+	if commitType == "" {
+		commitType = "(none)"
+	}
+	return fmt.Errorf("%w: %s (commit_types allows %s)",
+		ErrCommitTypeNotAllowed, commitType, strings.Join(cfg.AllowedCommitTypes(), ", "))
+}
+
+// CommitTypeRetryPrompt extends prompt for a second request after the reply used a
+// type outside the allowlist.
+func CommitTypeRetryPrompt(cfg *config.Config, prompt string, rejected string) string {
+	if rejected == "" {
+		rejected = "no type"
+	}
+	return fmt.Sprintf("%s\n\nThe previous reply used %q, which this repository does not allow. "+
+		"Reply again using exactly one of these types: %s.",
+		prompt, rejected, strings.Join(cfg.AllowedCommitTypes(), ", "))
+}
+
+// commitTypeList returns the types the prompt offers: the allowlist when set, otherwise
+// every known type.
+func commitTypeList(cfg *config.Config) string {
+	if cfg != nil {
+		if allowed := cfg.AllowedCommitTypes(); len(allowed) > 0 {
+			return strings.Join(allowed, ", ")
+		}
+	}
+	return strings.Join(emoji.GetAllCommitTypes(), ", ")
+}
+
+func formatAllowedTypes(cfg *config.Config) string {
+	if cfg == nil || len(cfg.AllowedCommitTypes()) == 0 {
+		return ""
+	}
+	return "Allowed Types:\nThis repository only allows these commit types: " +
+		strings.Join(cfg.AllowedCommitTypes(), ", ") + ". Do not use any other type."
+}
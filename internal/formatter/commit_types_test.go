@@ -0,0 +1,52 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitTypeOf(t *testing.T) {
+	tests := map[string]string{
+		"feat(api): add retry":         "feat",
+		"Infra: move runners":          "infra",
+		"✨ feat: add stats":            "feat",
+		"fix!: drop legacy flag\n\nx":  "fix",
+		"update readme":                "",
+		"feat: note: colons are fine":  "feat",
+		"release notes for v1.2: done": "",
+	}
+	for message, want := range tests {
+		assert.Equal(t, want, CommitTypeOf(message), message)
+	}
+}
+
+func TestCheckCommitType(t *testing.T) {
+	assert.NoError(t, CheckCommitType(&config.Config{}, "chore: anything goes"))
+
+	cfg := &config.Config{CommitTypes: []string{"feat", "fix", "infra"}}
+	assert.NoError(t, CheckCommitType(cfg, "infra(ci): move runners"))
+
+	err := CheckCommitType(cfg, "chore: bump deps")
+	assert.ErrorIs(t, err, ErrCommitTypeNotAllowed)
+	assert.EqualError(t, err, "commit type not allowed: chore (commit_types allows feat, fix, infra)")
+
+	assert.ErrorIs(t, CheckCommitType(cfg, "bump deps"), ErrCommitTypeNotAllowed)
+}
+
+func TestPromptListsAllowedTypes(t *testing.T) {
+	cfg := &config.Config{CommitTypes: []string{"feat", "fix", "infra"}}
+	prompt := BuildPromptWithContext(cfg, []string{"main.tf"}, "diff", PromptContext{})
+	assert.Contains(t, prompt, "Allowed Types:\nThis repository only allows these commit types: feat, fix, infra.")
+
+	assert.NotContains(t, BuildPromptWithContext(&config.Config{}, nil, "diff", PromptContext{}), "Allowed Types:")
+}
+
+func TestTypeHintRespectsAllowlist(t *testing.T) {
+	files := []string{"README.md"}
+	assert.Equal(t, "docs", TypeHintForConfig(&config.Config{TypeHints: config.TypeHintsSoft}, files))
+
+	cfg := &config.Config{TypeHints: config.TypeHintsStrict, CommitTypes: []string{"feat", "fix"}}
+	assert.Empty(t, TypeHintForConfig(cfg, files), "a hint outside commit_types is dropped")
+}
@@ -0,0 +1,145 @@
+package formatter
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+)
+
+// outlineMinLines is the size, in added lines, from which a new file is outlined.
+// Smaller files cost little more in full than as an outline.
+const outlineMinLines = 40
+
+// outlineLineLimit bounds one outline line.
+const outlineLineLimit = 120
+
+// outliners return the declarations of a source file, one per line, by file extension.
+var outliners = map[string]func(lines []string) []string{
+	".go":  outlineGo,
+	".js":  outlineJS,
+	".jsx": outlineJS,
+	".mjs": outlineJS,
+	".cjs": outlineJS,
+	".ts":  outlineJS,
+	".tsx": outlineJS,
+	".mts": outlineJS,
+	".cts": outlineJS,
+}
+
+// OutlineNewFilesForConfig returns OutlineNewFiles(diff) when cfg enables
+// outline_new_files, and diff unchanged otherwise.
+func OutlineNewFilesForConfig(cfg *config.Config, diff string) string {
+	if cfg == nil || !cfg.OutlineNewFiles {
+		return diff
+	}
+	return OutlineNewFiles(diff)
+}
+
+// OutlineNewFiles replaces the hunks of each new Go or JavaScript/TypeScript file of
+// diff that adds at least outlineMinLines lines with an outline of its declarations:
+// function and type signatures for Go, exports for JavaScript and TypeScript. Other
+// files, any DiffStatsSeparator block, and diffs without such a file are kept as is.
+func OutlineNewFiles(diff string) string {
+	body, stats, hasStats := strings.Cut(diff, DiffStatsSeparator)
+	files := parseDiff(strings.TrimRight(body, "\n"))
+	changed := false
+	for i, file := range files {
+		if hunks, ok := outlineNewFile(file); ok {
+			files[i].Hunks = hunks
+			changed = true
+		}
+	}
+	if !changed {
+		return diff
+	}
+
+	var builder strings.Builder
+	for _, file := range files {
+		builder.WriteString(file.Header)
+		builder.WriteString(strings.Join(file.Hunks, ""))
+	}
+	if hasStats {
+		builder.WriteString(DiffStatsSeparator + stats)
+		return builder.String()
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func outlineNewFile(file DiffFile) ([]string, bool) {
+	outline := outliners[strings.ToLower(filepath.Ext(file.Path))]
+	if outline == nil || file.IsBinary || !strings.Contains(file.Header, "\nnew file mode ") || len(file.Hunks) != 1 {
+		return nil, false
+	}
+
+	var content []string
+	for _, line := range strings.Split(strings.TrimSuffix(file.Hunks[0], "\n"), "\n")[1:] {
+		if text, ok := strings.CutPrefix(line, "+"); ok {
+			content = append(content, text)
+		}
+	}
+	if len(content) < outlineMinLines {
+		return nil, false
+	}
+	declarations := outline(content)
+	if len(declarations) == 0 {
+		return nil, false
+	}
+
+	var hunk strings.Builder
+	hunk.WriteString("@@ -0,0 +1," + strconv.Itoa(len(content)) + " @@\n")
+	hunk.WriteString("... (new file of " + strconv.Itoa(len(content)) + " lines, outline of its declarations)\n")
+	for _, declaration := range declarations {
+		hunk.WriteString("+" + clipOutlineLine(declaration) + "\n")
+	}
+	return []string{hunk.String()}, true
+}
+
+// outlineGo returns the package clause and the top-level func, type, var and const
+// declarations of a Go file, without bodies.
+func outlineGo(lines []string) []string {
+	var outline []string
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "package "), strings.HasPrefix(line, "func "):
+			outline = append(outline, strings.TrimSuffix(strings.TrimSpace(line), " {"))
+		case strings.HasPrefix(line, "type "), strings.HasPrefix(line, "var "), strings.HasPrefix(line, "const "):
+			declaration := strings.TrimSuffix(strings.TrimSpace(line), " {")
+			if before, _, ok := strings.Cut(declaration, " = "); ok {
+				declaration = before
+			}
+			if !strings.HasSuffix(declaration, "(") {
+				outline = append(outline, declaration)
+			}
+		}
+	}
+	return outline
+}
+
+// outlineJS returns the export statements of a JavaScript or TypeScript file, cut
+// before function bodies and initializers, and any CommonJS module.exports.
+func outlineJS(lines []string) []string {
+	var outline []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "export ") && !strings.HasPrefix(line, "module.exports") {
+			continue
+		}
+		declaration := strings.TrimSpace(line)
+		if !strings.HasPrefix(declaration, "export {") && !strings.HasPrefix(declaration, "export *") {
+			if before, _, ok := strings.Cut(declaration, " = "); ok {
+				declaration = before
+			}
+			declaration = strings.TrimSuffix(strings.TrimSpace(strings.TrimSuffix(declaration, "{")), " =>")
+		}
+		outline = append(outline, strings.TrimSuffix(declaration, ";"))
+	}
+	return outline
+}
+
+func clipOutlineLine(line string) string {
+	if len(line) <= outlineLineLimit {
+		return line
+	}
+	return truncateToValidUTF8(line, outlineLineLimit) + "..."
+}
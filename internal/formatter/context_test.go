@@ -0,0 +1,127 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFileDiffOf(path string, lines []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%[1]s b/%[1]s\nnew file mode 100644\nindex 0000000..1111111\n"+
+		"--- /dev/null\n+++ b/%[1]s\n@@ -0,0 +1,%[2]d @@\n", path, len(lines))
+	for _, line := range lines {
+		b.WriteString("+" + line + "\n")
+	}
+	return b.String()
+}
+
+func padded(lines ...string) []string {
+	for len(lines) < outlineMinLines {
+		lines = append(lines, "\t// filler")
+	}
+	return lines
+}
+
+func TestOutlineNewFilesGo(t *testing.T) {
+	source := padded(
+		"package cache",
+		"",
+		"import \"sync\"",
+		"",
+		"const DefaultSize = 128",
+		"",
+		"var (",
+		"\tmu sync.Mutex",
+		")",
+		"",
+		"type Cache struct {",
+		"\titems map[string]string",
+		"}",
+		"",
+		"type Option func(*Cache)",
+		"",
+		"func New(opts ...Option) *Cache {",
+		"\treturn &Cache{}",
+		"}",
+		"",
+		"func (c *Cache) Get(key string) (string, bool) {",
+		"\tv, ok := c.items[key]",
+		"\treturn v, ok",
+		"}",
+	)
+	diff := fileDiff("main.go", 1) + newFileDiffOf("cache/cache.go", source)
+
+	got := OutlineNewFiles(diff)
+	assert.Equal(t, fileDiff("main.go", 1)+"diff --git a/cache/cache.go b/cache/cache.go\n"+
+		"new file mode 100644\nindex 0000000..1111111\n--- /dev/null\n+++ b/cache/cache.go\n"+
+		"@@ -0,0 +1,40 @@\n"+
+		"... (new file of 40 lines, outline of its declarations)\n"+
+		"+package cache\n"+
+		"+const DefaultSize\n"+
+		"+type Cache struct\n"+
+		"+type Option func(*Cache)\n"+
+		"+func New(opts ...Option) *Cache\n"+
+		"+func (c *Cache) Get(key string) (string, bool)", got)
+	files := ParseDiff(got)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "cache/cache.go", files[1].Path)
+}
+
+func TestOutlineNewFilesJS(t *testing.T) {
+	source := padded(
+		"import { api } from './api';",
+		"export const fetchUser = async (id) => {",
+		"  return api.get(`/users/${id}`);",
+		"};",
+		"export default function UserCard({ user }) {",
+		"  return null;",
+		"}",
+		"export interface User {",
+		"  id: string;",
+		"}",
+		"export { api };",
+		"module.exports = { fetchUser };",
+	)
+
+	got := OutlineNewFiles(newFileDiffOf("src/user.ts", source))
+	assert.True(t, strings.HasSuffix(got, "\n+export const fetchUser\n"+
+		"+export default function UserCard({ user })\n"+
+		"+export interface User\n"+
+		"+export { api }\n"+
+		"+module.exports"), got)
+}
+
+func TestOutlineNewFilesKeepsOtherFiles(t *testing.T) {
+	small := newFileDiffOf("small.go", []string{"package small", "func A() {}"})
+	assert.Equal(t, small, OutlineNewFiles(small), "small files are kept in full")
+
+	markdown := newFileDiffOf("README.md", padded("# Title"))
+	assert.Equal(t, markdown, OutlineNewFiles(markdown), "no outliner for markdown")
+
+	modified := strings.Replace(newFileDiffOf("a.go", padded("package a", "func A() {")),
+		"new file mode 100644\n", "", 1)
+	assert.Equal(t, modified, OutlineNewFiles(modified), "only new files are outlined")
+
+	withStats := newFileDiffOf("a.go", padded("package a", "func A() {")) + DiffStatsSeparator + "\n40\t0\ta.go\n"
+	got := OutlineNewFiles(withStats)
+	assert.True(t, strings.HasSuffix(got, "+func A()\n"+DiffStatsSeparator+"\n40\t0\ta.go\n"), got)
+}
+
+func TestBuildPromptOutlinesNewFiles(t *testing.T) {
+	diff := newFileDiffOf("a.go", padded("package a", "func Handle(w Writer) error {"))
+
+	prompt := BuildPromptWithConfig(&config.Config{OutlineNewFiles: true}, []string{"a.go"}, diff, "")
+	assert.Contains(t, prompt, "+func Handle(w Writer) error\n")
+	assert.NotContains(t, prompt, "// filler")
+
+	prompt = BuildPromptWithConfig(&config.Config{}, []string{"a.go"}, diff, "")
+	assert.Contains(t, prompt, "// filler")
+
+	prompt = BuildPromptWithContext(&config.Config{OutlineNewFiles: true}, []string{"a.go"}, diff,
+		PromptContext{Outlined: true})
+	assert.Contains(t, prompt, "// filler", "an outlined diff is not outlined again")
+}
@@ -0,0 +1,44 @@
+package formatter
+
+import "strings"
+
+// DiffChunk is a run of consecutive file diffs that fits one summarization request.
+type DiffChunk struct {
+	Files []string
+	Diff  string
+}
+
+// ChunkDiff splits diff into chunks of whole file diffs, each at most limit bytes, in
+// diff order. A file larger than limit gets a chunk of its own, cut to fit. Any
+// DiffStatsSeparator block is dropped.
+func ChunkDiff(diff string, limit int) []DiffChunk {
+	diff, _, _ = strings.Cut(diff, DiffStatsSeparator)
+
+	var chunks []DiffChunk
+	var current DiffChunk
+	var builder strings.Builder
+	flush := func() {
+		if builder.Len() > 0 {
+			current.Diff = builder.String()
+			chunks = append(chunks, current)
+		}
+		current = DiffChunk{}
+		builder.Reset()
+	}
+
+	const marker = "... (truncated)\n"
+	for _, file := range parseDiff(strings.TrimRight(diff, "\n")) {
+		text := file.Header + strings.Join(file.Hunks, "")
+		if len(text) > limit {
+			text = truncateToValidUTF8(text, max(limit-len(marker), 0))
+			text = text[:strings.LastIndex(text, "\n")+1] + marker
+		}
+		if builder.Len() > 0 && builder.Len()+len(text) > limit {
+			flush()
+		}
+		current.Files = append(current.Files, file.Path)
+		builder.WriteString(text)
+	}
+	flush()
+	return chunks
+}
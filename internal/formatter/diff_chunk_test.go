@@ -0,0 +1,40 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fileDiff(path string, lines int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1,0 +1,%d @@\n", path, path, path, path, lines)
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "+line %d of %s\n", i, path)
+	}
+	return b.String()
+}
+
+func TestChunkDiff(t *testing.T) {
+	diff := fileDiff("a.go", 2) + fileDiff("b.go", 2) + fileDiff("c.go", 40) + fileDiff("d.go", 1) +
+		DiffStatsSeparator + "\n2\t0\ta.go\n"
+
+	chunks := ChunkDiff(diff, 400)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, []string{"a.go", "b.go"}, chunks[0].Files)
+	assert.Equal(t, []string{"c.go"}, chunks[1].Files)
+	assert.Equal(t, []string{"d.go"}, chunks[2].Files)
+
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk.Diff), 400)
+		assert.NotContains(t, chunk.Diff, DiffStatsSeparator)
+	}
+	assert.Contains(t, chunks[1].Diff, "... (truncated)", "an oversized file is cut to fit")
+	assert.True(t, strings.HasPrefix(chunks[0].Diff, "diff --git a/a.go b/a.go\n"))
+	assert.Contains(t, chunks[0].Diff, "+line 1 of b.go\n")
+
+	assert.Empty(t, ChunkDiff("", 400))
+}
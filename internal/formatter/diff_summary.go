@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxSummarySymbols bounds the hunk contexts listed for one file by SummarizeFiles.
+const maxSummarySymbols = 3
+
+// NeedsSummary reports whether diff is larger than threshold bytes and should be
+// summarized file by file before prompting. A threshold of 0 or less is the prompt
+// limit, so only diffs that would be truncated are summarized. Any DiffStatsSeparator
+// block is not counted.
+func NeedsSummary(diff string, threshold int) bool {
+	diff, _, _ = strings.Cut(diff, DiffStatsSeparator)
+	if threshold <= 0 {
+		threshold = diffPromptLimit
+	}
+	return len(strings.TrimRight(diff, "\n")) > threshold
+}
+
+// SummarizeFiles returns a one-line summary of each file of diff, in diff order, without
+// an LLM: the path, its added and deleted lines, and the functions or sections its
+// hunks touch, as git names them in hunk headers.
+func SummarizeFiles(diff string) []string {
+	stats := ""
+	if parts := strings.SplitN(diff, DiffStatsSeparator, 2); len(parts) == 2 {
+		diff = strings.TrimRight(parts[0], "\n")
+		stats = strings.TrimSpace(parts[1])
+	}
+
+	files := prepareDiffFiles(diff, stats)
+	lines := make([]string, 0, len(files))
+	for _, file := range files {
+		line := summarizeFile(file)
+		if symbols := hunkSymbols(file.Hunks); len(symbols) > 0 {
+			line += ": " + describeSymbols(symbols)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// hunkSymbols returns the distinct section headings of hunks, such as
+// "func (c *Client) Do(req *Request) error", in order.
+func hunkSymbols(hunks []string) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, hunk := range hunks {
+		header, _, _ := strings.Cut(hunk, "\n")
+		if !isHunkHeader(header) {
+			continue
+		}
+		marker := header[:strings.Index(header, " ")]
+		_, heading, ok := strings.Cut(header[len(marker):], marker)
+		heading = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(heading), "{"))
+		if !ok || heading == "" || seen[heading] {
+			continue
+		}
+		seen[heading] = true
+		symbols = append(symbols, heading)
+	}
+	return symbols
+}
+
+func describeSymbols(symbols []string) string {
+	if len(symbols) <= maxSummarySymbols {
+		return strings.Join(symbols, "; ")
+	}
+	return strings.Join(symbols[:maxSummarySymbols], "; ") +
+		" and " + strconv.Itoa(len(symbols)-maxSummarySymbols) + " more"
+}
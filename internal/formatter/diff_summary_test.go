@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeedsSummary(t *testing.T) {
+	diff := fileDiff("a.go", 20)
+
+	assert.True(t, NeedsSummary(diff, 100))
+	assert.False(t, NeedsSummary(diff, len(diff)))
+	assert.False(t, NeedsSummary(diff, 0), "0 is the prompt limit")
+	assert.True(t, NeedsSummary(diff+strings.Repeat("+x\n", 2000), 0))
+	assert.False(t, NeedsSummary(diff+DiffStatsSeparator+strings.Repeat("1\t0\ta.go\n", 500), len(diff)),
+		"the stats block is not counted")
+}
+
+func TestSummarizeFiles(t *testing.T) {
+	diff := `diff --git a/client.go b/client.go
+--- a/client.go
++++ b/client.go
+@@ -10,3 +10,4 @@ func (c *Client) Do(req *Request) error {
++	c.retries++
+@@ -40,3 +41,3 @@ func (c *Client) Close() error {
+-	return nil
++	return c.conn.Close()
+@@ -60,2 +61,2 @@ func (c *Client) Close() error {
+-	x
++	y
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,2 +1,2 @@
+-old
++new
+diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+`
+
+	assert.Equal(t, []string{
+		"client.go (+3/-2): func (c *Client) Do(req *Request) error; func (c *Client) Close() error",
+		"README.md (+1/-1)",
+		"old.go -> new.go (renamed, 90% similar)",
+	}, SummarizeFiles(diff))
+
+	withStats := diff + "\n" + DiffStatsSeparator + "\n30\t4\tclient.go\n"
+	assert.Equal(t, "client.go (+30/-4): func (c *Client) Do(req *Request) error; func (c *Client) Close() error",
+		SummarizeFiles(withStats)[0])
+}
+
+func TestDescribeSymbols(t *testing.T) {
+	assert.Equal(t, "a; b; c", describeSymbols([]string{"a", "b", "c"}))
+	assert.Equal(t, "a; b; c and 2 more", describeSymbols([]string{"a", "b", "c", "d", "e"}))
+}
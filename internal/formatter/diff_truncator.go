@@ -1,18 +1,26 @@
 package formatter
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/gitutil"
 )
 
 type DiffFile struct {
-	Path          string
-	OldPath       string
-	Header        string
-	Hunks         []string
-	IsBinary      bool
-	IsRename      bool
+	Path     string
+	OldPath  string
+	Header   string
+	Hunks    []string
+	IsBinary bool
+	IsRename bool
+	// Similarity is git's similarity index for renames and copies, 0 when not reported.
+	Similarity    int
 	Priority      int
 	Added         int
 	Deleted       int
@@ -41,11 +49,20 @@ func truncateDiffWithStats(diff string, stats string, limit int) string {
 		return diff
 	}
 
-	files := parseDiff(diff)
+	files := prepareDiffFiles(diff, stats)
 	if len(files) == 0 {
 		return truncateToValidUTF8(diff, limit) + "...(content is too long, truncated)"
 	}
 
+	result := truncateDiff(files, limit)
+	if result == "" {
+		return truncateToValidUTF8(diff, limit) + "...(content is too long, truncated)"
+	}
+	return result
+}
+
+func prepareDiffFiles(diff string, stats string) []DiffFile {
+	files := parseDiff(diff)
 	statMap := parseNumstat(stats)
 	for i := range files {
 		files[i].Priority = classifyFile(files[i].Path)
@@ -54,12 +71,93 @@ func truncateDiffWithStats(diff string, stats string, limit int) string {
 			files[i].Added, files[i].Deleted = countHunkChanges(files[i].Hunks)
 		}
 	}
+	return files
+}
 
-	result := truncateDiff(files, limit)
-	if result == "" {
-		return truncateToValidUTF8(diff, limit) + "...(content is too long, truncated)"
+// excludeDiffPaths drops the files matching the exclude_paths patterns from the diff,
+// its stats and the changed files, for diffs git did not filter, such as gmc - input.
+// When the patterns match every file, everything is kept, as git diff does in gmc.
+func excludeDiffPaths(diff, stats string, files, patterns []string) (string, string, []string) {
+	if len(patterns) == 0 || diff == "" {
+		return diff, stats, files
 	}
-	return result
+	excluded := func(file string) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			return gitutil.MatchPathspec(pattern, file)
+		})
+	}
+
+	var kept strings.Builder
+	skip, dropped := false, false
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if isDiffHeader(line) {
+			newPath, oldPath := parseDiffHeaderPaths(strings.TrimRight(line, "\n"))
+			skip = excluded(newPath) || (oldPath != "" && excluded(oldPath))
+			dropped = dropped || skip
+		}
+		if !skip {
+			kept.WriteString(line)
+		}
+	}
+	if !dropped || !strings.Contains(kept.String(), "diff --") {
+		return diff, stats, files
+	}
+
+	var keptStats []string
+	for _, line := range strings.Split(stats, "\n") {
+		// numstat lines end with a tab and the path; --summary lines with the path.
+		fields := strings.Fields(line)
+		if parts := strings.SplitN(line, "\t", 3); len(parts) == 3 {
+			fields = []string{parts[2]}
+		}
+		if len(fields) == 0 || !excluded(fields[len(fields)-1]) {
+			keptStats = append(keptStats, line)
+		}
+	}
+	keptFiles := slices.DeleteFunc(slices.Clone(files), excluded)
+	return strings.TrimRight(kept.String(), "\n"), strings.Join(keptStats, "\n"), keptFiles
+}
+
+// TruncateDiff fits diff into limit bytes the way commit prompts do, keeping source files
+// ahead of lock and generated files, which are reduced to a summary first.
+func TruncateDiff(diff string, limit int) string {
+	return truncateDiffWithStats(diff, "", limit)
+}
+
+// ErrContextTruncated reports that the diff does not fit the prompt in full.
+var ErrContextTruncated = errors.New("diff does not fit in the prompt")
+
+// CheckPromptContext returns an error wrapping ErrContextTruncated when BuildPromptWithContext
+// would truncate the diff or reduce files to summaries. The message names the affected files.
+// Files the exclude_paths config of cfg leaves out of the prompt are left out here too.
+func CheckPromptContext(cfg *config.Config, diff string) error {
+	stats := ""
+	if parts := strings.SplitN(diff, DiffStatsSeparator, 2); len(parts) == 2 {
+		diff = strings.TrimRight(parts[0], "\n")
+		stats = strings.TrimSpace(parts[1])
+	}
+	if cfg != nil {
+		diff, stats, _ = excludeDiffPaths(diff, stats, nil, cfg.ExcludePaths)
+	}
+	if len(diff) <= diffPromptLimit {
+		return nil
+	}
+
+	detail := ""
+	if files := prepareDiffFiles(diff, stats); stats != "" && len(files) > 0 {
+		if _, reduced := truncateDiffFiles(files, diffPromptLimit); len(reduced) > 0 {
+			detail = "; not included in full: " + strings.Join(reduced, ", ")
+		}
+	}
+
+	return fmt.Errorf("%w (%d bytes, limit %d)%s\n"+
+		"Split the commit: stage fewer files, or commit paths separately with gmc <paths>",
+		ErrContextTruncated, len(diff), diffPromptLimit, detail)
+}
+
+// ParseDiff splits a unified diff into files, each with its header and hunks.
+func ParseDiff(raw string) []DiffFile {
+	return parseDiff(raw)
 }
 
 func parseDiff(raw string) []DiffFile {
@@ -168,6 +266,10 @@ func applyHeaderLine(file *DiffFile, line string) {
 		file.Path = strings.Trim(file.Path, "\"")
 		return
 	}
+	if strings.HasPrefix(line, "similarity index ") {
+		file.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+		return
+	}
 	if strings.HasPrefix(line, "old mode ") ||
 		strings.HasPrefix(line, "new mode ") ||
 		strings.HasPrefix(line, "new file mode ") ||
@@ -327,6 +429,9 @@ func summarizeFile(file DiffFile) string {
 		return file.Path + " (binary)"
 	}
 	if file.IsRename && file.OldPath != "" && file.Path != "" {
+		if file.Similarity > 0 {
+			return file.OldPath + " -> " + file.Path + " (renamed, " + strconv.Itoa(file.Similarity) + "% similar)"
+		}
 		return file.OldPath + " -> " + file.Path + " (renamed)"
 	}
 	if file.HasModeChange {
@@ -336,6 +441,13 @@ func summarizeFile(file DiffFile) string {
 }
 
 func truncateDiff(files []DiffFile, limit int) string {
+	result, _ := truncateDiffFiles(files, limit)
+	return result
+}
+
+// truncateDiffFiles fits files into limit, highest priority first, and also returns the
+// paths that were summarized, cut, or left out instead of included in full.
+func truncateDiffFiles(files []DiffFile, limit int) (string, []string) {
 	var high []DiffFile
 	var mid []DiffFile
 	var low []DiffFile
@@ -351,31 +463,22 @@ func truncateDiff(files []DiffFile, limit int) string {
 	}
 
 	var result strings.Builder
-	for _, file := range high {
-		if !appendFile(&result, file, limit, false) {
-			if !appendFile(&result, file, limit, true) {
-				return truncateToValidUTF8(result.String(), limit)
-			}
+	var reduced []string
+	ordered := append(append(high, mid...), low...)
+	for i, file := range ordered {
+		if file.Priority < 2 && appendFile(&result, file, limit, false) {
+			continue
 		}
-	}
-
-	for _, file := range mid {
-		if !appendFile(&result, file, limit, false) {
-			if !appendFile(&result, file, limit, true) {
-				return truncateToValidUTF8(result.String(), limit)
+		reduced = append(reduced, file.Path)
+		if !appendFile(&result, file, limit, true) {
+			for _, rest := range ordered[i+1:] {
+				reduced = append(reduced, rest.Path)
 			}
+			break
 		}
 	}
 
-	for _, file := range low {
-		summary := summarizeFile(file) + "\n"
-		if result.Len()+len(summary) > limit {
-			return truncateToValidUTF8(result.String(), limit)
-		}
-		result.WriteString(summary)
-	}
-
-	return truncateToValidUTF8(result.String(), limit)
+	return truncateToValidUTF8(result.String(), limit), reduced
 }
 
 func appendFile(builder *strings.Builder, file DiffFile, limit int, summaryOnly bool) bool {
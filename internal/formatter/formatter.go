@@ -2,7 +2,11 @@ package formatter
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -12,15 +16,40 @@ import (
 
 const diffPromptLimit = 4000
 
+// summaryPromptLimit bounds a diff summary, which replaces a diff too large for the
+// prompt, so it may be larger than diffPromptLimit.
+const summaryPromptLimit = 4 * diffPromptLimit
+
 // DiffStatsSeparator separates diff content from optional stats block.
 const DiffStatsSeparator = "-- gmc diff stats --"
 
+// SystemPromptSeparator separates the system message of a prompt, rendered from a
+// template's system key, from the user message.
+const SystemPromptSeparator = "-- gmc user message --"
+
+// JoinSystemPrompt joins a system message and a user message into one prompt.
+func JoinSystemPrompt(system, user string) string {
+	return strings.TrimRight(system, "\n") + "\n\n" + SystemPromptSeparator + "\n\n" + user
+}
+
+// SplitSystemPrompt splits a prompt into its system and user messages. system is ""
+// when the template has no system key.
+func SplitSystemPrompt(prompt string) (system, user string) {
+	system, user, found := strings.Cut(prompt, "\n\n"+SystemPromptSeparator+"\n\n")
+	if !found {
+		return "", prompt
+	}
+	return system, user
+}
+
 var (
 	// Pre-compiled regex patterns for performance
 	issuePattern        *regexp.Regexp
 	conventionalPattern *regexp.Regexp
 	prefixPattern       *regexp.Regexp
 	typePrefixPattern   *regexp.Regexp
+	fullWidthColon      *regexp.Regexp
+	breakingSubject     *regexp.Regexp
 )
 
 func init() {
@@ -30,7 +59,10 @@ func init() {
 	typePattern := emoji.GetCommitTypesRegexPattern()
 	conventionalPattern = regexp.MustCompile(`(?i)^(?:[^\s]*\s)?(` + typePattern + `)(\([^\)]+\))?: (.+)`)
 	prefixPattern = regexp.MustCompile(`(?i)^(` + typePattern + `):\s*(.+)`)
-	typePrefixPattern = regexp.MustCompile(`(?i)^(` + typePattern + `)(\([^\)]+\))?:`)
+	typePrefixPattern = regexp.MustCompile(`(?i)^(` + typePattern + `)(\([^\)]+\))?(!)?:`)
+	// Localized replies (e.g. zh-CN, ja) often use a full-width colon after the type.
+	fullWidthColon = regexp.MustCompile(`(?i)^((?:[^\s]*\s)?(?:` + typePattern + `)(?:\([^\)]+\))?)\s*：\s*`)
+	breakingSubject = regexp.MustCompile(`(?i)^((?:[^\s]*\s)?(?:` + typePattern + `)(?:\([^\)]+\))?)(!?): `)
 }
 
 func BuildPrompt(role string, changedFiles []string, diff string, userPrompt string) string {
@@ -43,14 +75,75 @@ func BuildPrompt(role string, changedFiles []string, diff string, userPrompt str
 	return BuildPromptWithConfig(cfg, changedFiles, diff, userPrompt)
 }
 
+// IssueContext describes the issue a commit refers to.
+type IssueContext struct {
+	Number string
+	Title  string
+	Labels []string
+	// Description is the issue body, as Jira tickets provide it. It is clipped to
+	// issueDescriptionLimit bytes in the prompt.
+	Description string
+}
+
+// issueDescriptionLimit bounds the issue description in the prompt.
+const issueDescriptionLimit = 500
+
+// squashedMessagesLimit bounds the messages of squashed commits in the prompt.
+const squashedMessagesLimit = 2000
+
+// PromptContext carries optional context appended to the rendered prompt.
+type PromptContext struct {
+	UserPrompt string
+	Issue      *IssueContext
+	// TypeHint is the commit type inferred from file categories (see InferTypeHint).
+	TypeHint string
+	// ScopeHint is the scope the scope_rules config, or the package gmc --per-package
+	// commits, assigns to the changed files.
+	ScopeHint string
+	// Type and Scope are the type and scope the user requires. They replace TypeHint and
+	// ScopeHint.
+	Type  string
+	Scope string
+	// Warnings receives template fallback warnings; nil means os.Stderr.
+	Warnings io.Writer
+	// Ticket is the ticket ID issue_pattern found in the branch name. gmc adds it to
+	// the message itself.
+	Ticket string
+	// Squashed are the messages of the commits this one replaces, oldest first, as
+	// gmc squash combines them.
+	Squashed []string
+	// Summarized marks the diff as per-file summaries of a diff too large for the
+	// prompt, as produced by the summarize package.
+	Summarized bool
+	// Outlined marks the diff as already passed through OutlineNewFilesForConfig, so
+	// that it is not outlined again.
+	Outlined bool
+}
+
 func BuildPromptWithConfig(cfg *config.Config, changedFiles []string, diff string, userPrompt string) string {
+	return BuildPromptWithContext(cfg, changedFiles, diff, PromptContext{UserPrompt: userPrompt})
+}
+
+// BuildPromptWithContext renders the prompt template and appends any optional context sections.
+func BuildPromptWithContext(cfg *config.Config, changedFiles []string, diff string, pctx PromptContext) string {
 	stats := ""
 	if parts := strings.SplitN(diff, DiffStatsSeparator, 2); len(parts) == 2 {
 		diff = strings.TrimRight(parts[0], "\n")
 		stats = strings.TrimSpace(parts[1])
 	}
+	if cfg != nil {
+		diff, stats, changedFiles = excludeDiffPaths(diff, stats, changedFiles, cfg.ExcludePaths)
+	}
+	renames := formatRenames(diff)
+	if !pctx.Summarized && !pctx.Outlined {
+		diff = OutlineNewFilesForConfig(cfg, diff)
+	}
 
-	if len(diff) > diffPromptLimit {
+	if pctx.Summarized {
+		if len(diff) > summaryPromptLimit {
+			diff = truncateToValidUTF8(diff, summaryPromptLimit) + "...(content is too long, truncated)"
+		}
+	} else if len(diff) > diffPromptLimit {
 		if stats == "" {
 			diff = truncateToValidUTF8(diff, diffPromptLimit) + "...(content is too long, truncated)"
 		} else {
@@ -61,51 +154,206 @@ func BuildPromptWithConfig(cfg *config.Config, changedFiles []string, diff strin
 	changedFilesStr := strings.Join(changedFiles, "\n")
 
 	role := ""
-	templateName := "default"
+	langCode := ""
 	if cfg != nil {
 		role = cfg.Role
-		if cfg.PromptTemplate != "" {
-			templateName = cfg.PromptTemplate
-		}
+		langCode = cfg.Language
 	}
 
 	data := TemplateData{
 		Role:  role,
 		Files: changedFilesStr,
 		Diff:  diff,
+		Body:  cfg != nil && cfg.CommitBody,
+		Types: commitTypeList(cfg),
 	}
-
-	templateContent, err := GetPromptTemplate(templateName)
-	if err != nil {
-		fmt.Printf("Warning: %v, using default template\n", err)
-		templateContent = buildDefaultTemplateContent()
+	if cfg != nil && cfg.EnableEmoji {
+		data.Emoji = emojiDescription(cfg)
+	}
+	if lang, ok := ResolveLanguage(langCode); ok {
+		data.Language = lang.Name
+		data.LanguageInstruction = languageInstruction(lang)
 	}
 
-	prompt, err := RenderTemplate(templateContent, data)
-	if err != nil {
-		fmt.Printf("Warning: %v, using simple format\n", err)
+	warnings := pctx.Warnings
+	if warnings == nil {
+		warnings = os.Stderr
+	}
+	prompt, templateContent := "", ""
+	chain := promptTemplateChain(cfg)
+	for i, ref := range chain {
+		var err error
+		prompt, templateContent, err = renderPromptTemplate(ref, data)
+		if err == nil {
+			break
+		}
+		if i+1 < len(chain) {
+			fmt.Fprintf(warnings, "Warning: %v; falling back to %s\n", err, templateLabel(chain[i+1]))
+			continue
+		}
+		fmt.Fprintf(warnings, "Warning: %v; using simple format\n", err)
 		prompt = buildSimplePromptWithConfig(cfg, role, changedFilesStr, diff)
+		templateContent = ""
+	}
+
+	// Custom templates that don't reference the language still get the instruction.
+	if data.LanguageInstruction != "" && !strings.Contains(templateContent, ".Language") {
+		prompt += "\n\nLanguage:\n" + data.LanguageInstruction
+	}
+
+	if data.Body {
+		prompt += "\n\n" + bodyPromptSection
+	}
+
+	switch {
+	case pctx.Type != "":
+		prompt += fmt.Sprintf("\n\nRequired Type:\nThe user has classified this change; use the %q type.", pctx.Type)
+	case pctx.TypeHint != "":
+		prompt += fmt.Sprintf("\n\nType Hint:\nAll changed files are %s-related; prefer the %q type "+
+			"unless the diff clearly calls for another.", pctx.TypeHint, pctx.TypeHint)
+	}
+
+	switch {
+	case pctx.Scope != "":
+		prompt += fmt.Sprintf("\n\nRequired Scope:\nThe user has classified this change; use the %q scope.", pctx.Scope)
+	case pctx.ScopeHint != "":
+		prompt += fmt.Sprintf("\n\nScope Hint:\nThis repository uses the %q scope for these files; use it "+
+			"unless the diff clearly calls for another.", pctx.ScopeHint)
+	default:
+		if section := formatKnownScopes(cfg); section != "" {
+			prompt += "\n\n" + section
+		}
+	}
+
+	if section := formatAllowedTypes(cfg); section != "" {
+		prompt += "\n\n" + section
 	}
 
-	if userPrompt != "" {
-		prompt += "\n\nAdditional Context:\n" + userPrompt
+	if renames != "" {
+		prompt += "\n\n" + renames
+	}
+
+	if section := formatIssueContext(pctx.Issue); section != "" {
+		prompt += "\n\n" + section
+	}
+
+	if section := formatSquashed(pctx.Squashed); section != "" {
+		prompt += "\n\n" + section
+	}
+
+	if pctx.Ticket != "" {
+		prompt += fmt.Sprintf("\n\nTicket:\nThis change is for ticket %s. Use it to understand intent, but do not "+
+			"write the ticket ID in the message; it is added automatically.", pctx.Ticket)
+	}
+
+	if pctx.UserPrompt != "" {
+		prompt += "\n\nAdditional Context:\n" + pctx.UserPrompt
 	}
 
 	return prompt
 }
 
+// promptTemplateChain returns the templates tried in order: prompt_template, then
+// fallback_template, then the built-in template.
+func promptTemplateChain(cfg *config.Config) []string {
+	var refs []string
+	if cfg != nil {
+		refs = append(refs, cfg.PromptTemplate, cfg.FallbackTemplate)
+	}
+	refs = append(refs, config.DefaultPromptTemplate)
+
+	chain := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref == "" {
+			ref = config.DefaultPromptTemplate
+		}
+		if !slices.Contains(chain, ref) {
+			chain = append(chain, ref)
+		}
+	}
+	return chain
+}
+
+func templateLabel(ref string) string {
+	if ref == config.DefaultPromptTemplate {
+		return "the built-in template"
+	}
+	return ref
+}
+
+func formatIssueContext(issue *IssueContext) string {
+	if issue == nil || strings.TrimSpace(issue.Title) == "" {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Related Issue:\n")
+	number := issue.Number
+	if _, err := strconv.Atoi(number); err == nil {
+		number = "#" + number
+	}
+	fmt.Fprintf(&builder, "%s: %s", number, strings.TrimSpace(issue.Title))
+	if len(issue.Labels) > 0 {
+		fmt.Fprintf(&builder, "\nLabels: %s", strings.Join(issue.Labels, ", "))
+	}
+	if description := strings.Join(strings.Fields(issue.Description), " "); description != "" {
+		if len(description) > issueDescriptionLimit {
+			description = truncateToValidUTF8(description, issueDescriptionLimit) + "..."
+		}
+		fmt.Fprintf(&builder, "\nDescription: %s", description)
+	}
+	builder.WriteString("\nUse the issue to understand intent, but describe what the diff actually changes.")
+	return builder.String()
+}
+
+// formatSquashed lists the squashed messages, each with its body indented under its
+// subject, up to squashedMessagesLimit.
+func formatSquashed(messages []string) string {
+	if len(messages) == 0 {
+		return ""
+	}
+
+	var list strings.Builder
+	for _, message := range messages {
+		lines := strings.Split(strings.TrimSpace(message), "\n")
+		fmt.Fprintf(&list, "\n- %s", lines[0])
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&list, "\n  %s", line)
+			}
+		}
+	}
+	listed := list.String()
+	if len(listed) > squashedMessagesLimit {
+		listed = truncateToValidUTF8(listed, squashedMessagesLimit) + "\n...(more messages truncated)"
+	}
+	return "Squashed Commits:\nThis commit replaces the commits below, oldest first. Use their messages to " +
+		"understand intent, but write one message for the combined diff rather than listing them." + listed
+}
+
 func FormatCommitMessage(message string) string {
 	return FormatCommitMessageWithConfig(config.MustGetConfig(), message)
 }
 
 func FormatCommitMessageWithConfig(cfg *config.Config, message string) string {
 	message = strings.TrimSpace(message)
+	if cfg != nil && cfg.CommitBody {
+		message = stripCodeFences(message)
+		subject, body := SplitCommitMessage(message)
+		return JoinCommitMessage(formatSubject(cfg, subject), FormatBody(body))
+	}
+	return formatSubject(cfg, message)
+}
 
+// formatSubject normalizes the first line of message into a Conventional Commits subject.
+func formatSubject(cfg *config.Config, message string) string {
 	lines := strings.Split(message, "\n")
 	if len(lines) > 0 {
 		firstLine := lines[0]
+		lead, _, _ := emoji.CutGitmoji(firstLine)
 
 		firstLine = issuePattern.ReplaceAllString(firstLine, "")
+		firstLine = fullWidthColon.ReplaceAllString(firstLine, "$1: ")
 		firstLine = normalizeEmojiMissingType(firstLine)
 
 		matches := conventionalPattern.FindStringSubmatch(firstLine)
@@ -118,17 +366,53 @@ func FormatCommitMessageWithConfig(cfg *config.Config, message string) string {
 			firstLine = normalizeTypePrefix(firstLine)
 		}
 
-		if cfg != nil && cfg.EnableEmoji {
-			firstLine = emoji.AddEmojiToMessage(firstLine)
-		}
+		return addEmoji(cfg, firstLine, lead)
+	}
+
+	return addEmoji(cfg, message, nil)
+}
+
+// addEmoji puts the emoji of the subject's type in front of it, as emoji_style says,
+// when enable_emoji is on, and strips any emoji the LLM added when it is off. In the
+// gitmoji style, lead, a gitmoji the reply started with, is kept over the type's.
+func addEmoji(cfg *config.Config, subject string, lead *emoji.Gitmoji) string {
+	if cfg == nil || !cfg.EnableEmoji {
+		return emoji.StripEmoji(subject)
+	}
+	if cfg.EmojiStyle != config.EmojiStyleGitmoji {
+		return emoji.AddEmojiToMessage(subject)
+	}
+	subject = emoji.StripEmoji(subject)
+	if lead != nil {
+		return lead.Code + " " + subject
+	}
+	return emoji.AddShortcodeToMessage(subject)
+}
 
-		return firstLine
+// emojiDescription lists the emoji of each type for the prompt, in the emoji_style of
+// cfg.
+func emojiDescription(cfg *config.Config) string {
+	if cfg.EmojiStyle == config.EmojiStyleGitmoji {
+		return emoji.GetShortcodeDescription()
 	}
+	return emoji.GetEmojiDescription()
+}
 
-	if cfg != nil && cfg.EnableEmoji {
-		return emoji.AddEmojiToMessage(message)
+// SalvageSubject returns the first line of a partial LLM reply when it already forms a
+// Conventional Commits subject, or "" when nothing usable was received. The line must
+// be followed by a newline: without one, the reply may have been cut off mid-word.
+func SalvageSubject(partial string) string {
+	subject, _, complete := strings.Cut(strings.TrimLeft(partial, " \t\r\n"), "\n")
+	if !complete {
+		return ""
 	}
-	return message
+	subject = strings.TrimSpace(fullWidthColon.ReplaceAllString(strings.TrimSpace(subject), "$1: "))
+
+	matches := conventionalPattern.FindStringSubmatch(subject)
+	if len(matches) < 4 || strings.TrimSpace(matches[3]) == "" {
+		return ""
+	}
+	return subject
 }
 
 func normalizeEmojiMissingType(message string) string {
@@ -198,9 +482,9 @@ func buildSimplePromptWithConfig(cfg *config.Config, role, changedFilesStr, diff
 	fmt.Fprintf(&builder, "Files:\n%s\n\n", changedFilesStr)
 	fmt.Fprintf(&builder, "Diff:\n%s\n\n", diff)
 	fmt.Fprintf(&builder, "%s and pick the most relevant type from: %s.\n",
-		typeInstruction, strings.Join(emoji.GetAllCommitTypes(), ", "))
+		typeInstruction, commitTypeList(cfg))
 	if enableEmoji {
-		fmt.Fprintf(&builder, "Start with an emoji that matches the type (%s).\n", emoji.GetEmojiDescription())
+		fmt.Fprintf(&builder, "Start with an emoji that matches the type (%s).\n", emojiDescription(cfg))
 	}
 	builder.WriteString("Keep it under 150 characters and skip issue references; gmc adds them automatically.")
 
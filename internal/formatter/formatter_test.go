@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/emoji"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -296,6 +298,33 @@ func TestFormatCommitMessage(t *testing.T) {
 }
 
 // Test with custom configuration
+func TestFormatCommitMessageWithEmojiMap(t *testing.T) {
+	emoji.SetTypeEmojis(map[string]string{"feat": "🚀"})
+	t.Cleanup(func() { emoji.SetTypeEmojis(nil) })
+
+	cfg := &config.Config{Role: "Developer", EnableEmoji: true}
+	assert.Equal(t, "🚀 feat: add login", FormatCommitMessageWithConfig(cfg, "feat: add login"))
+	assert.Equal(t, "🚀 feat: add login", FormatCommitMessageWithConfig(cfg, "🚀 add login"))
+	assert.Equal(t, "🐛 fix: handle nil", FormatCommitMessageWithConfig(cfg, "fix: handle nil"))
+	assert.Contains(t, BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", ""), "🚀 for feat")
+}
+
+func TestFormatCommitMessageEmojiStyle(t *testing.T) {
+	gitmoji := &config.Config{Role: "Developer", EnableEmoji: true, EmojiStyle: config.EmojiStyleGitmoji}
+	assert.Equal(t, ":sparkles: feat: add login", FormatCommitMessageWithConfig(gitmoji, "feat: add login"))
+	assert.Equal(t, ":sparkles: feat: add login", FormatCommitMessageWithConfig(gitmoji, "✨ feat: add login"))
+	assert.Equal(t, ":lock: fix: escape input", FormatCommitMessageWithConfig(gitmoji, "🔒 fix: escape input"),
+		"a gitmoji from the reply is kept")
+	assert.Equal(t, ":bug: fix: handle nil", FormatCommitMessageWithConfig(gitmoji, "🤖 fix: handle nil"),
+		"an emoji outside the gitmoji list is replaced")
+	assert.Contains(t, BuildPromptWithConfig(gitmoji, []string{"main.go"}, "diff", ""), ":sparkles: for feat")
+
+	plain := &config.Config{Role: "Developer"}
+	assert.Equal(t, "feat: add login", FormatCommitMessageWithConfig(plain, ":sparkles: feat: add login"))
+	assert.Equal(t, "feat!: drop v1", FormatCommitMessageWithConfig(plain, "💥 feat!: drop v1"))
+	assert.Equal(t, "feat: add login", FormatCommitMessageWithConfig(plain, "✨ add login"))
+}
+
 func TestBuildPromptWithCustomTemplate(t *testing.T) {
 	// Create a temporary directory for test
 	tempDir, err := os.MkdirTemp("", "gmc_test")
@@ -328,6 +357,34 @@ template: |
 	assert.Contains(t, result, "diff content")
 }
 
+func TestBuildPromptWithSystemTemplate(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "split.yaml")
+	require.NoError(t, os.WriteFile(templateFile, []byte(`name: split
+system: |
+  You are {{.Role}}. Reply with one line.
+template: |
+  Files: {{.Files}}
+  Diff: {{.Diff}}
+`), 0o644))
+
+	cfg := &config.Config{Role: "a release engineer", PromptTemplate: templateFile}
+	prompt := BuildPromptWithContext(cfg, []string{"main.go"}, "+func main() {}",
+		PromptContext{UserPrompt: "hotfix for the outage"})
+
+	system, user := SplitSystemPrompt(prompt)
+	assert.Equal(t, "You are a release engineer. Reply with one line.", system)
+	assert.True(t, strings.HasPrefix(user, "Files: main.go\nDiff: +func main() {}"))
+	assert.Contains(t, user, "Additional Context:\nhotfix for the outage")
+
+	content, err := GetPromptTemplate(templateFile)
+	require.NoError(t, err)
+	assert.Contains(t, content, "Reply with one line.\n\n"+SystemPromptSeparator+"\n\nFiles: {{.Files}}")
+
+	system, user = SplitSystemPrompt("no system part")
+	assert.Empty(t, system)
+	assert.Equal(t, "no system part", user)
+}
+
 func TestBuildPromptFallbackToBuiltinOnError(t *testing.T) {
 	// Test with non-existent template should fall back to builtin
 	role := "Senior Go Developer"
@@ -343,6 +400,34 @@ func TestBuildPromptFallbackToBuiltinOnError(t *testing.T) {
 	assert.Contains(t, result, "Conventional Commits")
 }
 
+func TestBuildPromptFallbackChain(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.yaml")
+	require.NoError(t, os.WriteFile(broken, []byte("template: |\n  {{.Role}\n"), 0o644))
+	fallback := filepath.Join(dir, "fallback.yaml")
+	require.NoError(t, os.WriteFile(fallback, []byte("template: |\n  Fallback for {{.Role}}.\n"), 0o644))
+
+	var warnings strings.Builder
+	cfg := &config.Config{Role: "Tester", PromptTemplate: broken, FallbackTemplate: fallback}
+	prompt := BuildPromptWithContext(cfg, []string{"a.go"}, "diff", PromptContext{Warnings: &warnings})
+	assert.True(t, strings.HasPrefix(prompt, "Fallback for Tester."))
+	assert.Contains(t, warnings.String(), "Warning: "+broken+":2: ")
+	assert.Contains(t, warnings.String(), "; falling back to "+fallback)
+
+	warnings.Reset()
+	cfg.FallbackTemplate = broken
+	prompt = BuildPromptWithContext(cfg, []string{"a.go"}, "diff", PromptContext{Warnings: &warnings})
+	assert.Contains(t, prompt, "Conventional Commits")
+	assert.Equal(t, 1, strings.Count(warnings.String(), "Warning:"), "a broken template is tried once")
+	assert.Contains(t, warnings.String(), "falling back to the built-in template")
+
+	assert.Equal(t, []string{config.DefaultPromptTemplate}, promptTemplateChain(nil))
+	assert.Equal(t, []string{broken, fallback, config.DefaultPromptTemplate},
+		promptTemplateChain(&config.Config{PromptTemplate: broken, FallbackTemplate: fallback}))
+	assert.Equal(t, []string{broken, config.DefaultPromptTemplate},
+		promptTemplateChain(&config.Config{PromptTemplate: broken}))
+}
+
 func TestBuildPromptWithUserPrompt(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -415,3 +500,118 @@ func TestBuildPromptWithUserPrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildPromptWithIssueContext(t *testing.T) {
+	cfg := &config.Config{Role: "Developer", PromptTemplate: "default"}
+
+	result := BuildPromptWithContext(cfg, []string{"file.go"}, "some diff", PromptContext{
+		UserPrompt: "extra",
+		Issue:      &IssueContext{Number: "42", Title: "Crash on empty diff", Labels: []string{"bug", "cli"}},
+	})
+
+	assert.Contains(t, result, "Related Issue:\n#42: Crash on empty diff")
+	assert.Contains(t, result, "Labels: bug, cli")
+	assert.Less(t, strings.Index(result, "Related Issue:"), strings.Index(result, "Additional Context:"))
+
+	withoutTitle := BuildPromptWithContext(cfg, []string{"file.go"}, "some diff", PromptContext{
+		Issue: &IssueContext{Number: "42"},
+	})
+	assert.NotContains(t, withoutTitle, "Related Issue:")
+
+	jira := BuildPromptWithContext(cfg, []string{"file.go"}, "some diff", PromptContext{
+		Issue: &IssueContext{Number: "PROJ-7", Title: "Login times out",
+			Description: "Users on slow\n\nlinks are " + strings.Repeat("x", 600)},
+	})
+	assert.Contains(t, jira, "Related Issue:\nPROJ-7: Login times out\nDescription: Users on slow links are xxx")
+	assert.Contains(t, jira, "xxx...\n")
+	assert.NotContains(t, jira, strings.Repeat("x", 500))
+}
+
+func TestSalvageSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial string
+		want    string
+	}{
+		{name: "complete subject with cut body", partial: "feat(api): add retry\n\nThe bo", want: "feat(api): add retry"},
+		{name: "subject only", partial: "fix: handle nil config\n", want: "fix: handle nil config"},
+		{name: "leading blank line", partial: "\nfix: handle nil config\n", want: "fix: handle nil config"},
+		{name: "subject cut mid-word", partial: "fix: handle nil con", want: ""},
+		{name: "emoji prefix", partial: "🐛 fix: handle nil config\n", want: "🐛 fix: handle nil config"},
+		{name: "full-width colon", partial: "docs：更新说明\n", want: "docs: 更新说明"},
+		{name: "type only", partial: "feat(api): \n", want: ""},
+		{name: "cut inside type", partial: "fea", want: ""},
+		{name: "not conventional", partial: "Added retry logic", want: ""},
+		{name: "empty", partial: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SalvageSubject(tt.partial))
+		})
+	}
+}
+
+func TestCheckPromptContext(t *testing.T) {
+	assert.NoError(t, CheckPromptContext(nil, "diff --git a/a.go b/a.go\n+small change\n"))
+
+	err := CheckPromptContext(nil, strings.Repeat("x", diffPromptLimit+1))
+	assert.ErrorIs(t, err, ErrContextTruncated)
+	assert.Contains(t, err.Error(), "Split the commit")
+
+	small := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n+func main() {}\n"
+	lock := "diff --git a/go.sum b/go.sum\n--- a/go.sum\n+++ b/go.sum\n@@ -1 +1 @@\n+" +
+		strings.Repeat("h", diffPromptLimit) + "\n"
+	diff := small + lock + "\n" + DiffStatsSeparator + "\n1\t0\tmain.go\n1\t0\tgo.sum"
+
+	err = CheckPromptContext(nil, diff)
+	assert.ErrorIs(t, err, ErrContextTruncated)
+	assert.Contains(t, err.Error(), "not included in full: go.sum")
+	assert.NotContains(t, err.Error(), "main.go")
+
+	assert.NoError(t, CheckPromptContext(&config.Config{ExcludePaths: []string{"go.sum"}}, diff),
+		"exclude_paths leaves go.sum out of the prompt")
+}
+
+func TestBuildPromptWithTicket(t *testing.T) {
+	prompt := BuildPromptWithContext(nil, []string{"login.go"}, "some diff", PromptContext{Ticket: "PROJ-1234"})
+	assert.Contains(t, prompt, "Ticket:\nThis change is for ticket PROJ-1234.")
+	assert.Contains(t, prompt, "do not write the ticket ID in the message")
+
+	prompt = BuildPromptWithContext(nil, []string{"login.go"}, "some diff", PromptContext{})
+	assert.NotContains(t, prompt, "Ticket:")
+}
+
+func TestBuildPromptExcludePaths(t *testing.T) {
+	main := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n+func main() {}\n"
+	generated := "diff --git a/api/v1/user.pb.go b/api/v1/user.pb.go\n--- a/api/v1/user.pb.go\n" +
+		"+++ b/api/v1/user.pb.go\n@@ -1 +1 @@\n+GENERATED\n"
+	vendored := "diff --git a/vendor/lib/lib.go b/vendor/lib/lib.go\n@@ -1 +1 @@\n+VENDORED\n"
+	diff := main + generated + vendored + "\n" + DiffStatsSeparator + "\n" +
+		"1\t0\tmain.go\n1\t0\tapi/v1/user.pb.go\n1\t0\tvendor/lib/lib.go"
+	files := []string{"main.go", "api/v1/user.pb.go", "vendor/lib/lib.go"}
+
+	cfg := &config.Config{ExcludePaths: []string{"*.pb.go", "vendor/"}}
+	prompt := BuildPromptWithContext(cfg, files, diff, PromptContext{})
+	assert.Contains(t, prompt, "func main() {}")
+	assert.NotContains(t, prompt, "GENERATED")
+	assert.NotContains(t, prompt, "VENDORED")
+	assert.NotContains(t, prompt, "user.pb.go")
+
+	// A diff of excluded files alone is kept, as git diff does.
+	cfg.ExcludePaths = []string{"api/**", "vendor"}
+	prompt = BuildPromptWithContext(cfg, files[1:], generated+vendored, PromptContext{})
+	assert.Contains(t, prompt, "GENERATED")
+	assert.Contains(t, prompt, "VENDORED")
+}
+
+func TestBuildPromptWithSquashedMessages(t *testing.T) {
+	prompt := BuildPromptWithContext(nil, []string{"a.go"}, "diff --git a/a.go b/a.go\n", PromptContext{
+		Squashed: []string{"feat: add login", "fix: handle empty password\n\nReturn an error.\n"},
+	})
+	assert.Contains(t, prompt, "Squashed Commits:\nThis commit replaces the commits below, oldest first.")
+	assert.Contains(t, prompt, "\n- feat: add login\n- fix: handle empty password\n  Return an error.")
+
+	prompt = BuildPromptWithContext(nil, []string{"a.go"}, "diff --git a/a.go b/a.go\n", PromptContext{})
+	assert.NotContains(t, prompt, "Squashed Commits:")
+}
@@ -0,0 +1,195 @@
+package formatter
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+)
+
+// maxHeuristicNames is the length up to which HeuristicMessage lists file names
+// instead of counting the files.
+const maxHeuristicNames = 50
+
+var (
+	buildFiles = []string{
+		"go.mod", "go.sum", "package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+		"Cargo.toml", "Cargo.lock", "pyproject.toml", "requirements.txt", "Gemfile", "Gemfile.lock",
+		"Makefile", "Dockerfile", "build.gradle", "pom.xml",
+	}
+	// fixWordPattern finds the words that mark a fix in the lines a diff adds.
+	fixWordPattern = regexp.MustCompile(`(?i)\b(fix(es|ed)?|bug|crash|panic|regression|workaround)\b`)
+	// declarationPattern finds the lines that declare a function or type.
+	declarationPattern = regexp.MustCompile(`^\+\s*(export\s+)?(async\s+)?(pub\s+)?` +
+		`(func|type|class|def|function|fn|interface|struct)\b`)
+	// symbolPattern takes the name of a function or type from a hunk heading.
+	symbolPattern = regexp.MustCompile(`\b(?:func\s*(?:\([^)]*\)\s*)?|def\s+|class\s+|function\s+|fn\s+|type\s+)` +
+		`([A-Za-z_]\w*)`)
+)
+
+// HeuristicMessage writes a Conventional Commits subject for diff without an LLM, for
+// gmc --offline and for when the LLM cannot be reached. The type comes from the file
+// categories and the lines the diff changes, the scope from the directories of the
+// files unless scope is set, and the description from the file names and the functions
+// the hunks touch.
+func HeuristicMessage(cfg *config.Config, changedFiles []string, diff string, scope string) string {
+	stats := ""
+	if parts := strings.SplitN(diff, DiffStatsSeparator, 2); len(parts) == 2 {
+		diff = strings.TrimRight(parts[0], "\n")
+		stats = strings.TrimSpace(parts[1])
+	}
+	files := prepareDiffFiles(diff, stats)
+	if len(changedFiles) == 0 {
+		for _, file := range files {
+			changedFiles = append(changedFiles, file.Path)
+		}
+	}
+
+	var rules []config.ScopeRule
+	if cfg != nil {
+		rules = cfg.ScopeRules
+	}
+	subject := heuristicType(cfg, changedFiles, files)
+	if scope == "" {
+		scope = GuessScope(rules, changedFiles)
+	}
+	if scope != "" && scope != subject {
+		subject += "(" + scope + ")"
+	}
+	return subject + ": " + heuristicDescription(changedFiles, files)
+}
+
+// heuristicType picks the type of the commit: the category every file falls in, build
+// for dependency and build files, fix when the added lines mention one, feat for new
+// files or declarations, and refactor otherwise. A type commit_types does not allow
+// gives way to chore, or to the first allowed type.
+func heuristicType(cfg *config.Config, changedFiles []string, files []DiffFile) string {
+	commitType := InferTypeHint(changedFiles)
+	switch {
+	case commitType != "":
+	case len(changedFiles) > 0 && allMatch(changedFiles, isBuildFile):
+		commitType = "build"
+	case addedLinesMatch(files, fixWordPattern):
+		commitType = "fix"
+	case addsCode(files):
+		commitType = "feat"
+	default:
+		commitType = "refactor"
+	}
+
+	if cfg == nil || cfg.AllowsCommitType(commitType) {
+		return commitType
+	}
+	if cfg.AllowsCommitType("chore") {
+		return "chore"
+	}
+	return cfg.AllowedCommitTypes()[0]
+}
+
+func isBuildFile(file string) bool {
+	base := path.Base(file)
+	for _, name := range buildFiles {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+func addedLinesMatch(files []DiffFile, pattern *regexp.Regexp) bool {
+	for _, file := range files {
+		for _, hunk := range file.Hunks {
+			for _, line := range strings.Split(hunk, "\n") {
+				if strings.HasPrefix(line, "+") && pattern.MatchString(line) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// addsCode reports whether the diff adds a source file, or declares functions or types
+// while adding more lines than it deletes.
+func addsCode(files []DiffFile) bool {
+	for _, file := range files {
+		if isNewFile(file) && !isTestFile(file.Path) && !isDocFile(file.Path) {
+			return true
+		}
+	}
+	added, deleted := 0, 0
+	for _, file := range files {
+		added += file.Added
+		deleted += file.Deleted
+	}
+	return added > deleted && addedLinesMatch(files, declarationPattern)
+}
+
+// heuristicDescription describes the change by what happened to the files and their
+// names, as "add heuristic.go", "update Run in commit.go" or "update 7 files".
+func heuristicDescription(changedFiles []string, files []DiffFile) string {
+	if len(files) == 1 && files[0].IsRename && files[0].OldPath != "" {
+		return "rename " + path.Base(files[0].OldPath) + " to " + path.Base(files[0].Path)
+	}
+
+	verb := "update"
+	switch {
+	case len(files) > 0 && allFiles(files, isNewFile):
+		verb = "add"
+	case len(files) > 0 && allFiles(files, isDeletedFile):
+		verb = "remove"
+	case len(files) > 0 && allFiles(files, func(f DiffFile) bool { return f.IsRename }):
+		verb = "move"
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, file := range changedFiles {
+		name := path.Base(cleanFilePath(file))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	switch {
+	case len(names) == 0:
+		return verb + " files"
+	case len(names) == 1 && len(files) == 1 && verb == "update":
+		if symbols := hunkSymbols(files[0].Hunks); len(symbols) == 1 {
+			if match := symbolPattern.FindStringSubmatch(symbols[0]); match != nil {
+				return "update " + match[1] + " in " + names[0]
+			}
+		}
+	}
+	if listed := joinNames(names); len(listed) <= maxHeuristicNames {
+		return verb + " " + listed
+	}
+	return verb + " " + strconv.Itoa(len(changedFiles)) + " files"
+}
+
+func joinNames(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+func allFiles(files []DiffFile, match func(DiffFile) bool) bool {
+	for _, file := range files {
+		if !match(file) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNewFile(file DiffFile) bool {
+	return strings.Contains(file.Header, "\nnew file mode ")
+}
+
+func isDeletedFile(file DiffFile) bool {
+	return strings.Contains(file.Header, "\ndeleted file mode ")
+}
@@ -0,0 +1,58 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeuristicMessage(t *testing.T) {
+	newFile := "diff --git a/internal/formatter/heuristic.go b/internal/formatter/heuristic.go\n" +
+		"new file mode 100644\n--- /dev/null\n+++ b/internal/formatter/heuristic.go\n" +
+		"@@ -0,0 +1,2 @@\n+package formatter\n+func HeuristicMessage() {}\n"
+	fixHunk := "diff --git a/internal/llm/llm.go b/internal/llm/llm.go\n--- a/internal/llm/llm.go\n" +
+		"+++ b/internal/llm/llm.go\n@@ -10,2 +10,3 @@ func (c *Client) GenerateCommitMessage(prompt string) error {\n" +
+		"-\treturn nil\n+\t// Fix the panic on an empty reply.\n+\treturn errEmpty\n"
+	edit := "diff --git a/cmd/root.go b/cmd/root.go\n--- a/cmd/root.go\n+++ b/cmd/root.go\n" +
+		"@@ -5,2 +5,2 @@ func init() {\n-\ta := 1\n+\tb := 1\n"
+	rename := "diff --git a/old.go b/new.go\nsimilarity index 100%\nrename from old.go\nrename to new.go\n"
+	deleted := "diff --git a/docs/old.md b/docs/old.md\ndeleted file mode 100644\n--- a/docs/old.md\n" +
+		"+++ /dev/null\n@@ -1 +0,0 @@\n-old\n"
+
+	tests := []struct {
+		name  string
+		cfg   *config.Config
+		files []string
+		diff  string
+		want  string
+	}{
+		{"new source file", nil, nil, newFile, "feat(formatter): add heuristic.go"},
+		{"fix words", nil, nil, fixHunk, "fix(llm): update GenerateCommitMessage in llm.go"},
+		{"plain edit", nil, nil, edit, "refactor(cmd): update init in root.go"},
+		{"rename", nil, nil, rename, "refactor: rename old.go to new.go"},
+		{"deleted doc", nil, nil, deleted, "docs: remove old.md"},
+		{"several files", nil, nil, newFile + fixHunk + edit, "fix: update heuristic.go, llm.go and root.go"},
+		{"dependencies", nil, []string{"go.mod", "go.sum"}, "", "build: update go.mod and go.sum"},
+		{
+			"many files", nil,
+			[]string{"a/one.go", "a/two.go", "a/three.go", "a/four.go", "a/five.go", "a/six.go", "a/seven.go"},
+			"", "refactor(a): update 7 files",
+		},
+		{
+			"scope rules and allowed types",
+			&config.Config{
+				ScopeRules:  []config.ScopeRule{{Path: "cmd/", Scope: "cli"}},
+				CommitTypes: []string{"feat", "fix", "chore"},
+			},
+			nil, edit, "chore(cli): update init in root.go",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HeuristicMessage(tt.cfg, tt.files, tt.diff, ""))
+		})
+	}
+
+	assert.Equal(t, "refactor(ui): update init in root.go", HeuristicMessage(nil, nil, edit, "ui"))
+}
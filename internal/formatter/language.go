@@ -0,0 +1,89 @@
+package formatter
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/samzong/gmc/internal/builtin"
+	"gopkg.in/yaml.v3"
+)
+
+// Language describes a commit message language for the builtin prompt templates.
+type Language struct {
+	Code string `yaml:"-"`
+	Name string `yaml:"name"`
+	// Example is a localized description used to show the expected message shape.
+	Example string `yaml:"example"`
+}
+
+// ResolveLanguage looks up a language tag such as "ja" or "zh_cn". Unknown tags are
+// passed through by name so the LLM can still honor them. English and empty tags
+// return false, meaning no language instruction is needed.
+func ResolveLanguage(code string) (Language, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), "_", "-")
+	if code == "" || strings.EqualFold(code, "en") || strings.HasPrefix(strings.ToLower(code), "en-") {
+		return Language{}, false
+	}
+
+	languages := loadLanguages()
+	for _, lang := range languages {
+		if strings.EqualFold(lang.Code, code) {
+			return lang, true
+		}
+	}
+	// "zh" alone defaults to Simplified Chinese; other bare tags match their regional entry.
+	for _, lang := range languages {
+		if base, _, _ := strings.Cut(lang.Code, "-"); strings.EqualFold(base, code) {
+			return lang, true
+		}
+	}
+	return Language{Code: code, Name: code}, true
+}
+
+// loadLanguages reads the locale bundles, locales/<code>.yaml, in code order. A bundle
+// in .gmc or the user config directory replaces the built-in one or adds a language;
+// one that cannot be read is skipped with a warning.
+func loadLanguages() []Language {
+	names, err := builtin.List(builtin.LocalesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var languages []Language
+	for _, name := range names {
+		content, file, err := builtin.Read(name)
+		var lang Language
+		if err == nil {
+			err = yaml.Unmarshal(content, &lang)
+		}
+		if err == nil && lang.Name == "" {
+			err = errors.New("name is empty")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring locale %s: %v\n", cmp.Or(file, name), err)
+			continue
+		}
+		lang.Code = strings.TrimSuffix(path.Base(name), ".yaml")
+		languages = append(languages, lang)
+	}
+	return languages
+}
+
+// languageInstruction returns the prompt sentence asking for a localized description.
+func languageInstruction(lang Language) string {
+	var builder strings.Builder
+	builder.WriteString("Write the description in " + lang.Name)
+	if lang.Name != lang.Code {
+		builder.WriteString(" (" + lang.Code + ")")
+	}
+	builder.WriteString(", but keep the type and scope in English")
+	if lang.Example != "" {
+		builder.WriteString(`, e.g. "feat(auth): ` + lang.Example + `"`)
+	}
+	builder.WriteString(".")
+	return builder.String()
+}
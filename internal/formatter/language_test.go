@@ -0,0 +1,99 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samzong/gmc/internal/builtin"
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		wantOK   bool
+		wantCode string
+		wantName string
+	}{
+		{name: "empty", code: "", wantOK: false},
+		{name: "english", code: "en", wantOK: false},
+		{name: "english region", code: "en-US", wantOK: false},
+		{name: "exact", code: "zh-CN", wantOK: true, wantCode: "zh-CN", wantName: "Simplified Chinese"},
+		{name: "underscore and case", code: "zh_tw", wantOK: true, wantCode: "zh-TW", wantName: "Traditional Chinese"},
+		{name: "bare tag", code: "zh", wantOK: true, wantCode: "zh-CN", wantName: "Simplified Chinese"},
+		{name: "regional fallback", code: "pt", wantOK: true, wantCode: "pt-BR", wantName: "Brazilian Portuguese"},
+		{name: "unknown passes through", code: "nl", wantOK: true, wantCode: "nl", wantName: "nl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, ok := ResolveLanguage(tt.code)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantCode, lang.Code)
+			assert.Equal(t, tt.wantName, lang.Name)
+		})
+	}
+}
+
+func TestBuildPromptWithLanguage(t *testing.T) {
+	cfg := &config.Config{Role: "Developer", PromptTemplate: config.DefaultPromptTemplate, Language: "ja"}
+
+	prompt := BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", "")
+	assert.Contains(t, prompt, "Write the description in Japanese (ja), but keep the type and scope in English")
+	assert.Contains(t, prompt, "ログイントークンの自動更新を追加")
+	assert.NotContains(t, prompt, "Language:\n")
+
+	english := &config.Config{Role: "Developer", PromptTemplate: config.DefaultPromptTemplate}
+	assert.NotContains(t, BuildPromptWithConfig(english, []string{"main.go"}, "diff", ""), "Write the description in")
+}
+
+func TestBuildPromptWithLanguageCustomTemplate(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.txt")
+	require.NoError(t, os.WriteFile(plain, []byte("Summarize {{.Files}}"), 0o600))
+	aware := filepath.Join(dir, "aware.txt")
+	require.NoError(t, os.WriteFile(aware, []byte("Summarize {{.Files}} in {{.Language}}"), 0o600))
+
+	cfg := &config.Config{Role: "Developer", PromptTemplate: plain, Language: "de"}
+	prompt := BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", "")
+	assert.Contains(t, prompt, "Language:\nWrite the description in German (de)")
+
+	cfg.PromptTemplate = aware
+	prompt = BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", "")
+	assert.Equal(t, "Summarize main.go in German", prompt)
+}
+
+func TestFormatCommitMessageFullWidthColon(t *testing.T) {
+	cfg := &config.Config{}
+	assert.Equal(t, "feat(auth): 添加登录令牌自动刷新",
+		FormatCommitMessageWithConfig(cfg, "feat(auth)：添加登录令牌自动刷新"))
+	assert.Equal(t, "fix: 修复空指针", FormatCommitMessageWithConfig(cfg, "fix ： 修复空指针"))
+}
+
+func TestResolveLanguageOverride(t *testing.T) {
+	dir := t.TempDir()
+	builtin.SetOverrideDirs(func() []string { return []string{dir} })
+	t.Cleanup(func() { builtin.SetOverrideDirs(nil) })
+
+	locales := filepath.Join(dir, builtin.LocalesDir)
+	require.NoError(t, os.MkdirAll(locales, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(locales, "nl.yaml"),
+		[]byte("name: Dutch\nexample: automatisch vernieuwen van het inlogtoken toevoegen\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(locales, "ja.yaml"), []byte("name: 日本語\n"), 0o644))
+
+	lang, ok := ResolveLanguage("nl")
+	assert.True(t, ok)
+	assert.Equal(t, "Dutch", lang.Name)
+	assert.Equal(t, "automatisch vernieuwen van het inlogtoken toevoegen", lang.Example)
+
+	lang, _ = ResolveLanguage("ja")
+	assert.Equal(t, "日本語", lang.Name, "an override replaces the built-in bundle")
+	assert.Empty(t, lang.Example)
+
+	lang, _ = ResolveLanguage("zh")
+	assert.Equal(t, "zh-CN", lang.Code, "built-in bundles are still found")
+}
@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxPromptRenames caps the rename list so mass moves don't crowd out the diff.
+const maxPromptRenames = 20
+
+// formatRenames lists renamed files with git's similarity index, so the model describes
+// a move as a rename (plus any edits, such as adjusted imports) rather than as a file
+// deleted and another added.
+func formatRenames(diff string) string {
+	var lines []string
+	for _, file := range parseDiff(diff) {
+		if !file.IsRename || file.OldPath == "" || file.Path == "" {
+			continue
+		}
+		line := fmt.Sprintf("- %s -> %s", file.OldPath, file.Path)
+		switch {
+		case file.Similarity == 100:
+			line += " (content unchanged)"
+		case file.Similarity > 0:
+			line += fmt.Sprintf(" (%d%% similar, the diff shows only the edits)", file.Similarity)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	if len(lines) > maxPromptRenames {
+		more := len(lines) - maxPromptRenames
+		lines = append(lines[:maxPromptRenames], fmt.Sprintf("- ...and %d more", more))
+	}
+	return "Renamed Files:\n" + strings.Join(lines, "\n") +
+		"\nDescribe these as renames or moves, not as deleted and added files."
+}
@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const renameDiff = `diff --git a/internal/old/util.go b/internal/helpers/util.go
+similarity index 100%
+rename from internal/old/util.go
+rename to internal/helpers/util.go
+diff --git a/cmd/run.go b/cmd/exec.go
+similarity index 92%
+rename from cmd/run.go
+rename to cmd/exec.go
+index 1111111..2222222 100644
+--- a/cmd/run.go
++++ b/cmd/exec.go
+@@ -3 +3 @@
+-import "example.com/internal/old"
++import "example.com/internal/helpers"
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old
++new
+`
+
+func TestFormatRenames(t *testing.T) {
+	assert.Equal(t, "Renamed Files:\n"+
+		"- internal/old/util.go -> internal/helpers/util.go (content unchanged)\n"+
+		"- cmd/run.go -> cmd/exec.go (92% similar, the diff shows only the edits)\n"+
+		"Describe these as renames or moves, not as deleted and added files.", formatRenames(renameDiff))
+
+	assert.Empty(t, formatRenames("diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n+x\n"))
+}
+
+func TestFormatRenamesCapsList(t *testing.T) {
+	var diff strings.Builder
+	for i := range maxPromptRenames + 3 {
+		fmt.Fprintf(&diff, "diff --git a/old%d.go b/new%d.go\nsimilarity index 100%%\n"+
+			"rename from old%d.go\nrename to new%d.go\n", i, i, i, i)
+	}
+
+	section := formatRenames(diff.String())
+	assert.Equal(t, maxPromptRenames+1, strings.Count(section, "\n- "))
+	assert.Contains(t, section, "- ...and 3 more")
+}
+
+func TestSummarizeRenamedFile(t *testing.T) {
+	files := parseDiff(renameDiff)
+	assert.Equal(t, "cmd/run.go -> cmd/exec.go (renamed, 92% similar)", summarizeFile(files[1]))
+}
+
+func TestBuildPromptIncludesRenames(t *testing.T) {
+	prompt := BuildPromptWithContext(&config.Config{}, []string{"cmd/exec.go"}, renameDiff, PromptContext{})
+	assert.Contains(t, prompt, "Renamed Files:\n- internal/old/util.go -> internal/helpers/util.go")
+}
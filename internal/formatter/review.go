@@ -0,0 +1,51 @@
+package formatter
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/samzong/gmc/internal/builtin"
+	"github.com/samzong/gmc/internal/config"
+)
+
+// ReviewTemplatesSubdir is the directory, under each template directory, that holds
+// the templates of gmc review. A default.yaml there replaces the built-in one.
+const ReviewTemplatesSubdir = "review"
+
+// reviewDiffLimit bounds the diff in a review prompt. A review needs more of the diff
+// than a commit message, which gets diffPromptLimit.
+const reviewDiffLimit = 16000
+
+// ReviewTemplateDirs returns the review template directories of dirs, in the same
+// lookup order.
+func ReviewTemplateDirs(dirs []TemplateDir) []TemplateDir {
+	review := make([]TemplateDir, len(dirs))
+	for i, dir := range dirs {
+		review[i] = TemplateDir{Source: dir.Source, Path: filepath.Join(dir.Path, ReviewTemplatesSubdir)}
+	}
+	return review
+}
+
+// BuildReviewPrompt renders the review template ref, a file path or "default" for the
+// built-in one, for the staged changes of changedFiles. Parse and render failures are
+// *TemplateError.
+func BuildReviewPrompt(cfg *config.Config, ref string, changedFiles []string, diff string) (string, error) {
+	stats := ""
+	if parts := strings.SplitN(diff, DiffStatsSeparator, 2); len(parts) == 2 {
+		diff = strings.TrimRight(parts[0], "\n")
+		stats = strings.TrimSpace(parts[1])
+	}
+	role := ""
+	if cfg != nil {
+		diff, stats, changedFiles = excludeDiffPaths(diff, stats, changedFiles, cfg.ExcludePaths)
+		role = cfg.Role
+	}
+
+	data := TemplateData{
+		Role:  role,
+		Files: strings.Join(changedFiles, "\n"),
+		Diff:  truncateDiffWithStats(diff, stats, reviewDiffLimit),
+	}
+	prompt, _, err := renderFamilyTemplate(builtin.ReviewTemplate, ref, data)
+	return prompt, err
+}
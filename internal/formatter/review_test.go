@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReviewPrompt(t *testing.T) {
+	cfg := &config.Config{Role: "Senior Go Developer", ExcludePaths: []string{"*.pb.go"}}
+	diff := "diff --git a/cache.go b/cache.go\n--- a/cache.go\n+++ b/cache.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/api.pb.go b/api.pb.go\n--- a/api.pb.go\n+++ b/api.pb.go\n@@ -1 +1 @@\n-c\n+d\n"
+
+	prompt, err := BuildReviewPrompt(cfg, config.DefaultPromptTemplate, []string{"cache.go", "api.pb.go"}, diff)
+	require.NoError(t, err)
+	system, user := SplitSystemPrompt(prompt)
+	assert.Contains(t, system, "Senior Go Developer, review the staged changes")
+	assert.Contains(t, system, `"severity | category | file:line | finding"`)
+	assert.Contains(t, user, "Files touched:\ncache.go\n")
+	assert.Contains(t, user, "+b")
+	assert.NotContains(t, user, "api.pb.go")
+
+	custom := filepath.Join(t.TempDir(), "security.yaml")
+	require.NoError(t, os.WriteFile(custom, []byte("template: \"Check {{.Files}} for {{.Bogus}}\"\n"), 0o644))
+	_, err = BuildReviewPrompt(cfg, custom, []string{"cache.go"}, diff)
+	var templateErr *TemplateError
+	require.ErrorAs(t, err, &templateErr)
+	assert.Equal(t, custom, templateErr.Path)
+}
+
+func TestReviewTemplateDirs(t *testing.T) {
+	repo := filepath.Join("repo", RepoTemplatesDir)
+	dirs := ReviewTemplateDirs([]TemplateDir{{Source: TemplateSourceRepo, Path: repo}})
+	assert.Equal(t, []TemplateDir{{Source: TemplateSourceRepo, Path: filepath.Join(repo, "review")}}, dirs)
+}
@@ -0,0 +1,186 @@
+package formatter
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+)
+
+// subjectScopePattern captures the scope of a "type(scope): " subject after an optional emoji.
+var subjectScopePattern = regexp.MustCompile(`^(?:[^\x00-\x7F]+\s*)?[A-Za-z][\w-]*\(([^)]+)\)!?: `)
+
+// subjectPrefixPattern splits a "type(scope)!: " subject prefix, after an optional emoji
+// or emoji shortcode, into the type, the parenthesized scope and the rest.
+var subjectPrefixPattern = regexp.MustCompile(`^((?:[^\x00-\x7F]+\s*|:[\w+-]+:\s*)?[A-Za-z][\w-]*)(\([^)]*\))?(!?: )`)
+
+// containerDirs hold modules rather than name them, so GuessScope looks one level deeper.
+var containerDirs = map[string]bool{
+	"internal": true, "pkg": true, "src": true, "lib": true, "packages": true, "apps": true,
+}
+
+// ScopeOf returns the scope of message's subject, or "" when it has none.
+func ScopeOf(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	matches := subjectScopePattern.FindStringSubmatch(subject)
+	if matches == nil {
+		return ""
+	}
+	return strings.TrimSpace(matches[1])
+}
+
+// ForceCommitScope sets the scope of a Conventional Commits subject, keeping the type,
+// breaking marker and description. A subject without a type prefix is left alone.
+func ForceCommitScope(message string, scope string) string {
+	message = strings.TrimSpace(message)
+	if message == "" || scope == "" {
+		return message
+	}
+
+	subject, rest, hasBody := strings.Cut(message, "\n")
+	matches := subjectPrefixPattern.FindStringSubmatch(subject)
+	if matches == nil {
+		return message
+	}
+	subject = matches[1] + "(" + scope + ")" + matches[3] + strings.TrimPrefix(subject, matches[0])
+	if hasBody {
+		return subject + "\n" + rest
+	}
+	return subject
+}
+
+// ConstrainMessage forces commitType and scope onto message's subject where it has
+// others. An empty commitType or scope leaves that part as the reply wrote it.
+func ConstrainMessage(message string, commitType string, scope string) string {
+	if commitType != "" && CommitTypeOf(message) != commitType {
+		message = ForceCommitType(message, commitType)
+	}
+	if scope != "" && ScopeOf(message) != scope {
+		message = ForceCommitScope(message, scope)
+	}
+	return message
+}
+
+// MatchScopeRules returns the scope every changed file maps to through rules, or ""
+// when a file matches no rule or the files map to different scopes. The longest
+// matching rule path wins.
+func MatchScopeRules(rules []config.ScopeRule, changedFiles []string) string {
+	scope := ""
+	for _, file := range changedFiles {
+		fileScope := ruleScope(rules, cleanFilePath(file))
+		if fileScope == "" || (scope != "" && fileScope != scope) {
+			return ""
+		}
+		scope = fileScope
+	}
+	return scope
+}
+
+// GuessScope is MatchScopeRules with a fallback for files no rule covers: the first
+// directory under the repository root, or under a container directory such as internal/.
+func GuessScope(rules []config.ScopeRule, changedFiles []string) string {
+	scope := ""
+	for _, file := range changedFiles {
+		file = cleanFilePath(file)
+		fileScope := ruleScope(rules, file)
+		if fileScope == "" {
+			fileScope = directoryScope(file)
+		}
+		if fileScope == "" || (scope != "" && fileScope != scope) {
+			return ""
+		}
+		scope = fileScope
+	}
+	return scope
+}
+
+// SuggestScopeRule proposes a rule mapping changedFiles to scope: their deepest common
+// directory, or the directory holding most of them when they share none.
+func SuggestScopeRule(changedFiles []string, scope string) config.ScopeRule {
+	if len(changedFiles) == 0 || scope == "" {
+		return config.ScopeRule{}
+	}
+
+	dirs := make([]string, 0, len(changedFiles))
+	for _, file := range changedFiles {
+		dirs = append(dirs, path.Dir(cleanFilePath(file)))
+	}
+
+	common := dirs[0]
+	for _, dir := range dirs[1:] {
+		for common != "." && dir != common && !strings.HasPrefix(dir, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	if common == "." {
+		counts := map[string]int{}
+		for _, dir := range dirs {
+			counts[dir]++
+			if counts[dir] > counts[common] || (counts[dir] == counts[common] && dir < common) {
+				common = dir
+			}
+		}
+	}
+	if common == "." {
+		return config.ScopeRule{}
+	}
+	return config.ScopeRule{Path: common, Scope: scope}
+}
+
+// ScopeHintForConfig returns the scope the scope_rules config assigns to changedFiles.
+func ScopeHintForConfig(cfg *config.Config, changedFiles []string) string {
+	if cfg == nil {
+		return ""
+	}
+	return MatchScopeRules(cfg.ScopeRules, changedFiles)
+}
+
+// formatKnownScopes lists the scopes config, so that the model reuses them instead of
+// inventing variants.
+func formatKnownScopes(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	scopes := make([]string, 0, len(cfg.Scopes))
+	for _, scope := range cfg.Scopes {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return ""
+	}
+	return "Known Scopes:\nThis repository uses these scopes: " + strings.Join(scopes, ", ") +
+		". Use one of them when it fits the change; do not invent a variant of one, such as a plural " +
+		"or an abbreviation."
+}
+
+func ruleScope(rules []config.ScopeRule, file string) string {
+	best, bestLen := "", -1
+	for _, rule := range rules {
+		prefix := strings.TrimSuffix(cleanFilePath(rule.Path), "/")
+		if rule.Scope == "" || prefix == "" {
+			continue
+		}
+		if (file == prefix || strings.HasPrefix(file, prefix+"/")) && len(prefix) > bestLen {
+			best, bestLen = rule.Scope, len(prefix)
+		}
+	}
+	return best
+}
+
+func directoryScope(file string) string {
+	parts := strings.Split(file, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	if containerDirs[parts[0]] && len(parts) > 2 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+func cleanFilePath(file string) string {
+	return strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, "\\", "/")), "./")
+}
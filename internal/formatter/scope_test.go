@@ -0,0 +1,101 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeOf(t *testing.T) {
+	assert.Equal(t, "worktree", ScopeOf("feat(worktree): add share progress"))
+	assert.Equal(t, "api", ScopeOf("fix(api)!: drop v1\n\nbody"))
+	assert.Equal(t, "cli", ScopeOf("✨ feat(cli): add doctor"))
+	assert.Equal(t, "", ScopeOf("feat: no scope"))
+	assert.Equal(t, "", ScopeOf("Merge branch 'main'"))
+}
+
+func TestMatchScopeRules(t *testing.T) {
+	rules := []config.ScopeRule{
+		{Path: "internal", Scope: "core"},
+		{Path: "internal/worktree/", Scope: "wt"},
+		{Path: "cmd", Scope: "cli"},
+	}
+
+	assert.Equal(t, "wt", MatchScopeRules(rules, []string{"internal/worktree/a.go", "./internal/worktree/b.go"}),
+		"longest prefix wins")
+	assert.Equal(t, "core", MatchScopeRules(rules, []string{"internal/llm/llm.go"}))
+	assert.Equal(t, "", MatchScopeRules(rules, []string{"internal/worktree/a.go", "cmd/root.go"}), "mixed scopes")
+	assert.Equal(t, "", MatchScopeRules(rules, []string{"cmd/root.go", "README.md"}), "uncovered file")
+	assert.Equal(t, "", MatchScopeRules(rules, []string{"cmdline/x.go"}), "prefix stops at a path segment")
+	assert.Equal(t, "", ScopeHintForConfig(nil, []string{"cmd/root.go"}))
+}
+
+func TestGuessScope(t *testing.T) {
+	assert.Equal(t, "worktree", GuessScope(nil, []string{"internal/worktree/resource.go"}))
+	assert.Equal(t, "cmd", GuessScope(nil, []string{"cmd/doctor.go", "cmd/doctor_test.go"}))
+	assert.Equal(t, "", GuessScope(nil, []string{"README.md"}))
+	assert.Equal(t, "", GuessScope(nil, []string{"cmd/doctor.go", "internal/doctor/doctor.go"}))
+	assert.Equal(t, "doctor", GuessScope([]config.ScopeRule{{Path: "cmd/doctor.go", Scope: "doctor"}},
+		[]string{"cmd/doctor.go", "internal/doctor/doctor.go"}))
+}
+
+func TestSuggestScopeRule(t *testing.T) {
+	assert.Equal(t, config.ScopeRule{Path: "internal/worktree", Scope: "wt"},
+		SuggestScopeRule([]string{"internal/worktree/a.go", "internal/worktree/sub/b.go"}, "wt"))
+	assert.Equal(t, config.ScopeRule{Path: "cmd", Scope: "doctor"},
+		SuggestScopeRule([]string{"cmd/doctor.go", "cmd/doctor_test.go", "internal/doctor/doctor.go"}, "doctor"),
+		"no common directory: the one with most files")
+	assert.Equal(t, config.ScopeRule{}, SuggestScopeRule([]string{"README.md"}, "docs"))
+}
+
+func TestBuildPromptWithScopeHint(t *testing.T) {
+	prompt := BuildPromptWithContext(nil, []string{"cmd/root.go"}, "diff --git a/cmd/root.go b/cmd/root.go\n",
+		PromptContext{ScopeHint: "cli"})
+	assert.Contains(t, prompt, "Scope Hint:\nThis repository uses the \"cli\" scope for these files")
+
+	prompt = BuildPromptWithContext(nil, []string{"cmd/root.go"}, "diff --git a/cmd/root.go b/cmd/root.go\n",
+		PromptContext{})
+	assert.NotContains(t, prompt, "Scope Hint:")
+}
+
+func TestBuildPromptWithKnownScopes(t *testing.T) {
+	cfg := &config.Config{Scopes: []string{"auth", " ", "cli"}}
+	diff := "diff --git a/cmd/root.go b/cmd/root.go\n"
+	prompt := BuildPromptWithContext(cfg, []string{"cmd/root.go"}, diff, PromptContext{})
+	assert.Contains(t, prompt, "Known Scopes:\nThis repository uses these scopes: auth, cli.")
+
+	prompt = BuildPromptWithContext(cfg, []string{"cmd/root.go"}, diff, PromptContext{ScopeHint: "cli"})
+	assert.NotContains(t, prompt, "Known Scopes:", "the scope hint is more specific")
+
+	prompt = BuildPromptWithContext(&config.Config{}, []string{"cmd/root.go"}, diff, PromptContext{})
+	assert.NotContains(t, prompt, "Known Scopes:")
+}
+
+func TestForceCommitScope(t *testing.T) {
+	assert.Equal(t, "feat(auth): add login", ForceCommitScope("feat: add login", "auth"))
+	assert.Equal(t, "fix(auth)!: drop v1\n\nbody", ForceCommitScope("fix(api)!: drop v1\n\nbody", "auth"))
+	assert.Equal(t, "✨ feat(auth): add login", ForceCommitScope("✨ feat(cli): add login", "auth"))
+	assert.Equal(t, ":bug: fix(auth): x", ForceCommitScope(":bug: fix: x", "auth"))
+	assert.Equal(t, "add login", ForceCommitScope("add login", "auth"), "no type to scope")
+}
+
+func TestConstrainMessage(t *testing.T) {
+	assert.Equal(t, "fix(auth): handle expired tokens",
+		ConstrainMessage("feat(api): handle expired tokens", "fix", "auth"))
+	assert.Equal(t, "fix(api): handle expired tokens",
+		ConstrainMessage("feat(api): handle expired tokens", "fix", ""), "no --scope keeps the reply's")
+	assert.Equal(t, "fix(auth): handle expired tokens",
+		ConstrainMessage("fix(auth): handle expired tokens", "fix", "auth"))
+	assert.Equal(t, "fix(auth): handle expired tokens",
+		ConstrainMessage("handle expired tokens", "fix", "auth"), "a bare subject gets both")
+}
+
+func TestBuildPromptWithRequiredTypeAndScope(t *testing.T) {
+	prompt := BuildPromptWithContext(nil, []string{"docs/a.md"}, "diff --git a/docs/a.md b/docs/a.md\n",
+		PromptContext{TypeHint: "docs", ScopeHint: "cli", Type: "fix", Scope: "auth"})
+	assert.Contains(t, prompt, "Required Type:\nThe user has classified this change; use the \"fix\" type.")
+	assert.Contains(t, prompt, "Required Scope:\nThe user has classified this change; use the \"auth\" scope.")
+	assert.NotContains(t, prompt, "Type Hint:", "the required type replaces the hint")
+	assert.NotContains(t, prompt, "Scope Hint:")
+}
@@ -0,0 +1,156 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/samzong/gmc/internal/stringsutil"
+)
+
+// statusLinePattern matches a git status --porcelain entry, "XY path", and the
+// "## branch" line of --branch.
+var statusLinePattern = regexp.MustCompile(`^(?:[ MTADRCU?!]{2} \S|## \S)`)
+
+// snippetHeaderPattern matches the "==> path <==" line head -v prints before each file.
+var snippetHeaderPattern = regexp.MustCompile(`^==> (.+) <==$`)
+
+// StdinPayload is what gmc - reads. It is either a unified diff, or a combined payload:
+// git status --porcelain output, then a unified diff, then file snippets, each after a
+// "==> path <==" line as head -v prints them. Every part of a combined payload but the
+// status is optional.
+type StdinPayload struct {
+	Status   []StatusEntry
+	Diff     string
+	Snippets []FileSnippet
+}
+
+// StatusEntry is one line of git status --porcelain. Path is the new path of a rename.
+type StatusEntry struct {
+	Code string
+	Path string
+}
+
+// FileSnippet is the content, possibly cut short, of a file the diff does not cover,
+// such as an untracked file.
+type FileSnippet struct {
+	Path    string
+	Content string
+}
+
+// Combined reports whether the payload started with git status output.
+func (p StdinPayload) Combined() bool {
+	return len(p.Status) > 0
+}
+
+// ParseStdinPayload splits input into its status, diff and file snippets. Input that
+// does not start with a git status line is a plain diff.
+func ParseStdinPayload(input string) StdinPayload {
+	// Only newlines are trimmed: a status line can start with a space.
+	lines := strings.Split(strings.Trim(strings.ReplaceAll(input, "\r\n", "\n"), "\n"), "\n")
+	if !statusLinePattern.MatchString(lines[0]) {
+		return StdinPayload{Diff: strings.TrimSpace(input)}
+	}
+
+	var payload StdinPayload
+	i := 0
+	for ; i < len(lines) && statusLinePattern.MatchString(lines[i]); i++ {
+		if entry, ok := parseStatusLine(lines[i]); ok {
+			payload.Status = append(payload.Status, entry)
+		}
+	}
+
+	start := i
+	for i < len(lines) && !snippetHeaderPattern.MatchString(lines[i]) {
+		i++
+	}
+	payload.Diff = strings.TrimSpace(strings.Join(lines[start:i], "\n"))
+
+	for i < len(lines) {
+		end := i + 1
+		for end < len(lines) && !snippetHeaderPattern.MatchString(lines[end]) {
+			end++
+		}
+		// head -v separates files with a blank line.
+		payload.Snippets = append(payload.Snippets, FileSnippet{
+			Path:    snippetHeaderPattern.FindStringSubmatch(lines[i])[1],
+			Content: strings.TrimSuffix(strings.Join(lines[i+1:end], "\n"), "\n"),
+		})
+		i = end
+	}
+	return payload
+}
+
+func parseStatusLine(line string) (StatusEntry, bool) {
+	if strings.HasPrefix(line, "## ") {
+		return StatusEntry{}, false
+	}
+	path := line[3:]
+	if _, to, ok := strings.Cut(path, " -> "); ok && (line[0] == 'R' || line[0] == 'C') {
+		path = to
+	}
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		path = unquoted
+	}
+	return StatusEntry{Code: line[:2], Path: path}, true
+}
+
+// PromptDiff returns the diff to generate from: the payload's diff, plus each snippet of
+// a file the diff does not cover as the diff of a new file.
+func (p StdinPayload) PromptDiff() string {
+	covered := make(map[string]bool)
+	for _, file := range ExtractFilesFromDiff(p.Diff) {
+		covered[file] = true
+	}
+
+	parts := []string{}
+	if p.Diff != "" {
+		parts = append(parts, p.Diff)
+	}
+	for _, snippet := range p.Snippets {
+		if !covered[snippet.Path] {
+			parts = append(parts, newFileDiff(snippet))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ChangedFiles returns the files of the diff, the snippets and the status, in that order.
+func (p StdinPayload) ChangedFiles() []string {
+	files := ExtractFilesFromDiff(p.PromptDiff())
+	for _, entry := range p.Status {
+		files = append(files, entry.Path)
+	}
+	return stringsutil.UniqueStrings(files)
+}
+
+func newFileDiff(snippet FileSnippet) string {
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "diff --git a/%[1]s b/%[1]s\nnew file mode 100644\n", snippet.Path)
+	if snippet.Content == "" {
+		return strings.TrimSuffix(diff.String(), "\n")
+	}
+
+	lines := strings.Split(snippet.Content, "\n")
+	fmt.Fprintf(&diff, "--- /dev/null\n+++ b/%s\n@@ -0,0 +1,%d @@", snippet.Path, len(lines))
+	for _, line := range lines {
+		diff.WriteString("\n+" + line)
+	}
+	return diff.String()
+}
+
+// ExtractFilesFromDiff returns the files a unified diff changes.
+func ExtractFilesFromDiff(diff string) []string {
+	var files []string
+
+	for _, line := range strings.Split(diff, "\n") {
+		if after, found := strings.CutPrefix(line, "+++ b/"); found {
+			files = append(files, after)
+		} else if after, found := strings.CutPrefix(line, "--- a/"); found {
+			files = append(files, after)
+		}
+	}
+
+	return stringsutil.UniqueStrings(files)
+}
@@ -0,0 +1,64 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStdinPayloadPlainDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b"
+	payload := ParseStdinPayload("\n" + diff + "\n\n")
+
+	assert.False(t, payload.Combined())
+	assert.Equal(t, StdinPayload{Diff: diff}, payload)
+	assert.Equal(t, diff, payload.PromptDiff())
+	assert.Equal(t, []string{"a.go"}, payload.ChangedFiles())
+}
+
+func TestParseStdinPayloadCombined(t *testing.T) {
+	input := "## main...origin/main\n" +
+		" M a.go\n" +
+		"R  old.go -> new.go\n" +
+		"D  gone.go\n" +
+		"?? \"notes/to do.md\"\n" +
+		"?? empty.txt\n" +
+		"diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"==> a.go <==\npackage a\n\n" +
+		"==> notes/to do.md <==\n# Todo\n- ship\n\n" +
+		"==> empty.txt <==\n"
+	payload := ParseStdinPayload(input)
+
+	assert.True(t, payload.Combined())
+	assert.Equal(t, []StatusEntry{
+		{Code: " M", Path: "a.go"},
+		{Code: "R ", Path: "new.go"},
+		{Code: "D ", Path: "gone.go"},
+		{Code: "??", Path: "notes/to do.md"},
+		{Code: "??", Path: "empty.txt"},
+	}, payload.Status)
+	assert.Equal(t, "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b", payload.Diff)
+	assert.Equal(t, []FileSnippet{
+		{Path: "a.go", Content: "package a"},
+		{Path: "notes/to do.md", Content: "# Todo\n- ship"},
+		{Path: "empty.txt", Content: ""},
+	}, payload.Snippets)
+
+	assert.Equal(t, payload.Diff+"\n"+
+		"diff --git a/notes/to do.md b/notes/to do.md\nnew file mode 100644\n"+
+		"--- /dev/null\n+++ b/notes/to do.md\n@@ -0,0 +1,2 @@\n+# Todo\n+- ship\n"+
+		"diff --git a/empty.txt b/empty.txt\nnew file mode 100644",
+		payload.PromptDiff(), "a snippet of a file the diff covers is left out")
+	assert.Equal(t, []string{"a.go", "notes/to do.md", "new.go", "gone.go", "empty.txt"}, payload.ChangedFiles())
+}
+
+func TestParseStdinPayloadStatusOnly(t *testing.T) {
+	payload := ParseStdinPayload(" M a.go\n?? b.txt\n")
+	assert.True(t, payload.Combined())
+	assert.Empty(t, payload.PromptDiff())
+
+	payload = ParseStdinPayload("?? b.txt\n==> b.txt <==\nhello\n")
+	assert.Empty(t, payload.Diff)
+	assert.Equal(t, "diff --git a/b.txt b/b.txt\nnew file mode 100644\n"+
+		"--- /dev/null\n+++ b/b.txt\n@@ -0,0 +1,1 @@\n+hello", payload.PromptDiff())
+}
@@ -2,118 +2,189 @@ package formatter
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/samzong/gmc/internal/builtin"
 	"github.com/samzong/gmc/internal/config"
-	"github.com/samzong/gmc/internal/emoji"
 	"gopkg.in/yaml.v3"
 )
 
 type PromptTemplate struct {
 	Name        string `yaml:"name"`
 	Description string `yaml:"description"`
-	Template    string `yaml:"template"`
+	// System is the optional system message template. With it, Template renders the
+	// user message, usually just the files and the diff.
+	System   string `yaml:"system,omitempty"`
+	Template string `yaml:"template"`
 }
 
 type TemplateData struct {
 	Role  string
 	Files string
 	Diff  string
+	// Language is the configured description language name, empty for English.
+	Language string
+	// LanguageInstruction asks for a localized description while keeping type/scope in English.
+	LanguageInstruction string
+	// Body is true when commit_body asks for a subject plus a bullet-point body.
+	Body bool
+	// Types lists the commit types to choose from, comma separated.
+	Types string
+	// Emoji lists the emoji of each commit type when enable_emoji is on, and is empty
+	// otherwise.
+	Emoji string
 }
 
-// Common template parts that are shared between templates
-var templateParts = struct {
-	Header   string
-	Files    string
-	Content  string
-	Format   string
-	NoIssues string
-	Emoji    string
-}{
-	Header:   "{{.Role}}, craft a Conventional Commits-style summary for the changes below.",
-	Files:    "Files touched:\n{{.Files}}",
-	Content:  "Diff excerpt:\n{{.Diff}}",
-	Format:   "Use the \"type(scope): description\" syntax",
-	NoIssues: "Skip issue references; gmc appends them automatically.",
-	Emoji:    "", // Will be initialized by initTemplateParts()
-}
-
-// initTemplateParts initializes template parts with dynamic content from emoji module
-func initTemplateParts() {
-	templateParts.Emoji = fmt.Sprintf(
-		"Lead with an emoji that matches the commit type (%s).",
-		emoji.GetEmojiDescription(),
-	)
-}
-
-func init() {
-	initTemplateParts()
+// builtinTemplateContent returns the embedded default template, which new templates
+// start from.
+func builtinTemplateContent() string {
+	content, err := builtin.Embedded(builtin.DefaultTemplate)
+	if err != nil {
+		panic(err)
+	}
+	text, _ := parseTemplateContent(content)
+	return text
 }
 
-// buildDefaultTemplateContent builds the default template content based on enable_emoji configuration
-func buildDefaultTemplateContent() string {
-	cfg := config.MustGetConfig()
-	enableEmoji := cfg.EnableEmoji
-
-	formatMsg := templateParts.Format
-	emojiInstruction := ""
-	if enableEmoji {
-		formatMsg = `Use the "emoji type(scope): description" syntax`
-		emojiInstruction = templateParts.Emoji + "\n"
+// builtinSystemContent returns the system message template of the embedded default
+// template.
+func builtinSystemContent() string {
+	content, err := builtin.Embedded(builtin.DefaultTemplate)
+	if err != nil {
+		panic(err)
 	}
-
-	return fmt.Sprintf(
-		`%s
-
-%s
-
-%s
-
-Reply with one line. %s.
-Select the most fitting type from: %s.
-%sKeep the description under 150 characters and describe the behavior change.
-%s`,
-		templateParts.Header,
-		templateParts.Files,
-		templateParts.Content,
-		formatMsg,
-		strings.Join(emoji.GetAllCommitTypes(), ", "),
-		emojiInstruction,
-		templateParts.NoIssues,
-	)
+	text, _ := parseSystemTemplate(content)
+	return text
 }
 
 // readTemplateFile reads and parses a template file.
 // Returns the template content if successful, or an error if the file cannot be read.
 // If YAML parsing fails, returns the raw content as plain text.
 func readTemplateFile(filePath string) (string, error) {
+	content, _, err := readTemplateFileWithLine(filePath)
+	return content, err
+}
+
+// readTemplateFileWithLine is readTemplateFile that also returns the file line the
+// template content starts on, so template errors can point into the file.
+func readTemplateFileWithLine(filePath string) (string, int, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("unable to read template file %s: %w", filePath, err)
+		return "", 0, fmt.Errorf("unable to read template file %s: %w", filePath, err)
 	}
 
+	text, line := parseTemplateContent(content)
+	return text, line, nil
+}
+
+// parseTemplateContent returns the template key of a YAML template file and the line
+// it starts on. Content that is not YAML is a plain text template.
+func parseTemplateContent(content []byte) (string, int) {
 	var tpl PromptTemplate
 	if err := yaml.Unmarshal(content, &tpl); err != nil {
-		// If YAML parsing fails, treat as plain text template
-		return string(content), nil //nolint:nilerr // Intentional fallback to plain text
+		return string(content), 1
 	}
+	return tpl.Template, templateStartLine(content, "template")
+}
 
-	return tpl.Template, nil
+// parseSystemTemplate returns the system key of a YAML template file and the line it
+// starts on. Plain text templates have none.
+func parseSystemTemplate(content []byte) (string, int) {
+	var tpl PromptTemplate
+	if err := yaml.Unmarshal(content, &tpl); err != nil || tpl.System == "" {
+		return "", 0
+	}
+	return tpl.System, templateStartLine(content, "system")
 }
 
+// templateStartLine returns the line of a YAML template file that key's content
+// starts on, or 1 when it cannot be found.
+func templateStartLine(content []byte, key string) int {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return 1
+	}
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		value := mapping.Content[i+1]
+		if value.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			// Block scalars start on the line after the | or > indicator.
+			return value.Line + 1
+		}
+		return value.Line
+	}
+	return 1
+}
+
+// GetPromptTemplate returns the content of templateName. A system template comes
+// first, separated from the user message template by SystemPromptSeparator.
 func GetPromptTemplate(templateName string) (string, error) {
+	content, _, err := loadPromptTemplate(builtin.DefaultTemplate, templateName)
+	if err != nil {
+		return "", err
+	}
+	system, _, err := loadSystemTemplate(builtin.DefaultTemplate, templateName)
+	if err != nil || system == "" {
+		return content, err
+	}
+	return JoinSystemPrompt(system, content), nil
+}
+
+// loadPromptTemplate is GetPromptTemplate that also returns the template's file and
+// the line its content starts on. The default template of a family is the built-in
+// file builtinName, such as builtin.DefaultTemplate; its path is "" for the embedded
+// copy, which the same file in .gmc or the user config directory replaces.
+func loadPromptTemplate(builtinName, templateName string) (string, templatePos, error) {
+	if templateName == "" || templateName == config.DefaultPromptTemplate {
+		content, file, err := builtin.Read(builtinName)
+		if err != nil {
+			return "", templatePos{}, err
+		}
+		text, line := parseTemplateContent(content)
+		return text, templatePos{Path: file, Line: line}, nil
+	}
+
+	path, err := resolveTemplatePath(templateName)
+	if err != nil {
+		return "", templatePos{}, err
+	}
+	content, line, err := readTemplateFileWithLine(path)
+	return content, templatePos{Path: path, Line: line}, err
+}
+
+// loadSystemTemplate is loadPromptTemplate for the template's system key. It returns
+// "" when the template has none.
+func loadSystemTemplate(builtinName, templateName string) (string, templatePos, error) {
+	var content []byte
+	var path string
+	var err error
 	if templateName == "" || templateName == config.DefaultPromptTemplate {
-		content := buildDefaultTemplateContent()
-		if content != "" {
-			return content, nil
+		content, path, err = builtin.Read(builtinName)
+	} else if path, err = resolveTemplatePath(templateName); err == nil {
+		content, err = os.ReadFile(path)
+		if err != nil {
+			err = fmt.Errorf("unable to read template file %s: %w", path, err)
 		}
 	}
+	if err != nil {
+		return "", templatePos{}, err
+	}
+	text, line := parseSystemTemplate(content)
+	return text, templatePos{Path: path, Line: line}, nil
+}
 
-	// Expand ~ to home directory
+// resolveTemplatePath expands ~ in a template file path and checks that it is a file.
+func resolveTemplatePath(templateName string) (string, error) {
 	if strings.HasPrefix(templateName, "~/") {
 		home, err := os.UserHomeDir()
 		if err == nil {
@@ -131,8 +202,104 @@ func GetPromptTemplate(templateName string) (string, error) {
 	if info.IsDir() {
 		return "", fmt.Errorf("prompt template path is a directory: %s", templateName)
 	}
+	return templateName, nil
+}
+
+// templatePos locates a template's content: its file, "" for the built-in template,
+// and the file line the content starts on.
+type templatePos struct {
+	Path string
+	Line int
+}
+
+// TemplateError is a prompt template that failed to parse or render, located in the
+// template file.
+type TemplateError struct {
+	// Path is the template file, or "" for the built-in template.
+	Path string
+	// Line is the line in Path, or 0 when text/template did not report one.
+	Line int
+	Err  error
+}
+
+func (e *TemplateError) Error() string {
+	name := e.Path
+	if name == "" {
+		name = "built-in template"
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", name, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", name, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// templateErrorPattern matches text/template's "template: prompt:3:5: message" errors.
+	templateErrorPattern = regexp.MustCompile(`^template: [^:]*:(\d+)(?::\d+)?: (.*)$`)
+	// templateLinePattern matches further positions in the message, such as "started at prompt:2".
+	templateLinePattern = regexp.MustCompile(`\bprompt:(\d+)`)
+)
+
+// newTemplateError converts a text/template error into a TemplateError whose line
+// counts from the start of the template file.
+func newTemplateError(pos templatePos, err error) *TemplateError {
+	// Drop RenderTemplate's "template parsing error" wrapping.
+	if inner := errors.Unwrap(err); inner != nil {
+		err = inner
+	}
+	matches := templateErrorPattern.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return &TemplateError{Path: pos.Path, Err: err}
+	}
+	line, _ := strconv.Atoi(matches[1])
+	message := templateLinePattern.ReplaceAllStringFunc(matches[2], func(match string) string {
+		n, _ := strconv.Atoi(strings.TrimPrefix(match, "prompt:"))
+		return fmt.Sprintf("line %d", pos.Line+n-1)
+	})
+	return &TemplateError{Path: pos.Path, Line: pos.Line + line - 1, Err: errors.New(message)}
+}
+
+// renderPromptTemplate loads and renders the prompt template ref. A system template
+// is rendered in front of the prompt, separated by SystemPromptSeparator. Parse and
+// render failures are returned as *TemplateError. It also returns the template
+// content, both parts of it.
+func renderPromptTemplate(ref string, data TemplateData) (string, string, error) {
+	return renderFamilyTemplate(builtin.DefaultTemplate, ref, data)
+}
+
+// renderFamilyTemplate is renderPromptTemplate for the template family whose default
+// template is the built-in file builtinName.
+func renderFamilyTemplate(builtinName, ref string, data TemplateData) (string, string, error) {
+	content, pos, err := loadPromptTemplate(builtinName, ref)
+	if err != nil {
+		return "", "", err
+	}
+	prompt, err := RenderTemplate(content, data)
+	if err != nil {
+		return "", "", newTemplateError(pos, err)
+	}
+
+	system, systemPos, err := loadSystemTemplate(builtinName, ref)
+	if err != nil || system == "" {
+		return prompt, content, err
+	}
+	systemPrompt, err := RenderTemplate(system, data)
+	if err != nil {
+		return "", "", newTemplateError(systemPos, err)
+	}
+	return JoinSystemPrompt(systemPrompt, prompt), system + "\n" + content, nil
+}
 
-	return readTemplateFile(templateName)
+// CheckPromptTemplate loads and renders the prompt template ref with empty data, so a
+// broken template is reported before it is used. Parse and render failures are
+// *TemplateError.
+func CheckPromptTemplate(ref string) error {
+	_, _, err := renderPromptTemplate(ref, TemplateData{})
+	return err
 }
 
 func RenderTemplate(templateContent string, data TemplateData) (string, error) {
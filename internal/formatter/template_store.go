@@ -0,0 +1,169 @@
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Template sources reported by ListTemplates.
+const (
+	TemplateSourceBuiltin = "builtin"
+	TemplateSourceRepo    = "repo"
+	TemplateSourceHome    = "home"
+)
+
+// RepoTemplatesDir is where a repository keeps its named prompt templates.
+const RepoTemplatesDir = ".gmc/templates"
+
+// TemplateDir is a directory of named YAML prompt templates.
+type TemplateDir struct {
+	Source string
+	Path   string
+}
+
+// TemplateInfo describes a prompt template found by ListTemplates.
+type TemplateInfo struct {
+	Name        string `json:"name"`
+	Source      string `json:"source"`
+	Path        string `json:"path,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Shadowed is true when a directory earlier in the lookup order has a template with the same name.
+	Shadowed bool `json:"shadowed,omitempty"`
+}
+
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ListTemplates returns the built-in template followed by the templates in dirs,
+// in lookup order. Missing directories are skipped. A default.yaml in dirs overrides
+// the built-in template, which is then marked shadowed.
+func ListTemplates(dirs []TemplateDir) ([]TemplateInfo, error) {
+	templates := []TemplateInfo{{
+		Name:        config.DefaultPromptTemplate,
+		Source:      TemplateSourceBuiltin,
+		Description: "Built-in Conventional Commits template",
+	}}
+	seen := make(map[string]bool)
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template directory %s: %w", dir.Path, err)
+		}
+
+		var found []TemplateInfo
+		for _, entry := range entries {
+			name, ok := templateFileName(entry.Name())
+			if !ok || entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir.Path, entry.Name())
+			found = append(found, TemplateInfo{
+				Name:        name,
+				Source:      dir.Source,
+				Path:        path,
+				Description: readTemplateDescription(path),
+				Shadowed:    seen[name],
+			})
+		}
+		sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+		for _, tpl := range found {
+			seen[tpl.Name] = true
+		}
+		templates = append(templates, found...)
+	}
+	templates[0].Shadowed = seen[config.DefaultPromptTemplate]
+	return templates, nil
+}
+
+// FindTemplate resolves a template name from dirs, or returns ref unchanged when it
+// is a file path. It returns "" for the built-in template, unless dirs override it.
+func FindTemplate(dirs []TemplateDir, ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	if strings.ContainsAny(ref, `/\`) || strings.HasPrefix(ref, "~") {
+		return ref, nil
+	}
+	if _, ok := templateFileName(ref); ok {
+		if _, err := os.Stat(ref); err == nil {
+			return ref, nil
+		}
+	}
+
+	for _, dir := range dirs {
+		for _, ext := range []string{".yaml", ".yml"} {
+			path := filepath.Join(dir.Path, ref+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+	if ref == config.DefaultPromptTemplate {
+		return "", nil
+	}
+	return "", fmt.Errorf("prompt template %q not found; run 'gmc template list' to see available templates", ref)
+}
+
+// NewTemplateFile scaffolds dir/name.yaml from the built-in template and returns its path.
+func NewTemplateFile(dir, name, description string) (string, error) {
+	if !templateNamePattern.MatchString(name) || name == config.DefaultPromptTemplate {
+		return "", fmt.Errorf("invalid template name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	if description == "" {
+		description = "Custom commit message template"
+	}
+
+	path := filepath.Join(dir, name+".yaml")
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("template already exists: %s", path)
+	}
+
+	content, err := yaml.Marshal(PromptTemplate{
+		Name:        name,
+		Description: description,
+		System:      builtinSystemContent(),
+		Template:    builtinTemplateContent(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode template: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create template directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write template: %w", err)
+	}
+	return path, nil
+}
+
+func templateFileName(file string) (string, bool) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		if name, ok := strings.CutSuffix(file, ext); ok && name != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func readTemplateDescription(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var tpl PromptTemplate
+	if err := yaml.Unmarshal(content, &tpl); err != nil {
+		return ""
+	}
+	return tpl.Description
+}
@@ -0,0 +1,109 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, dir, file, content string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, file)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestListTemplates(t *testing.T) {
+	repoDir := filepath.Join(t.TempDir(), "repo")
+	homeDir := filepath.Join(t.TempDir(), "home")
+	terse := writeTemplate(t, repoDir, "terse.yaml", "name: terse\ndescription: Short subjects\ntemplate: x\n")
+	writeTemplate(t, repoDir, "notes.txt", "ignored")
+	writeTemplate(t, homeDir, "terse.yml", "template: y\n")
+	verbose := writeTemplate(t, homeDir, "verbose.yaml", "template: z\n")
+
+	templates, err := ListTemplates([]TemplateDir{
+		{Source: TemplateSourceRepo, Path: repoDir},
+		{Source: TemplateSourceHome, Path: homeDir},
+		{Source: TemplateSourceHome, Path: filepath.Join(homeDir, "missing")},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, templates, 4)
+	assert.Equal(t, TemplateInfo{Name: "default", Source: TemplateSourceBuiltin,
+		Description: "Built-in Conventional Commits template"}, templates[0])
+	assert.Equal(t, TemplateInfo{Name: "terse", Source: TemplateSourceRepo, Path: terse,
+		Description: "Short subjects"}, templates[1])
+	assert.Equal(t, "terse", templates[2].Name)
+	assert.True(t, templates[2].Shadowed, "home template is shadowed by the repo one")
+	assert.Equal(t, TemplateInfo{Name: "verbose", Source: TemplateSourceHome, Path: verbose}, templates[3])
+}
+
+func TestFindTemplate(t *testing.T) {
+	repoDir := t.TempDir()
+	homeDir := t.TempDir()
+	repoTerse := writeTemplate(t, repoDir, "terse.yaml", "template: x\n")
+	writeTemplate(t, homeDir, "terse.yaml", "template: y\n")
+	homeOnly := writeTemplate(t, homeDir, "home-only.yml", "template: z\n")
+	dirs := []TemplateDir{{Source: TemplateSourceRepo, Path: repoDir}, {Source: TemplateSourceHome, Path: homeDir}}
+
+	path, err := FindTemplate(dirs, "default")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	path, err = FindTemplate(dirs, "terse")
+	require.NoError(t, err)
+	assert.Equal(t, repoTerse, path)
+
+	path, err = FindTemplate(dirs, "home-only")
+	require.NoError(t, err)
+	assert.Equal(t, homeOnly, path)
+
+	path, err = FindTemplate(dirs, "./custom/prompt.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "./custom/prompt.yaml", path)
+
+	_, err = FindTemplate(dirs, "missing")
+	assert.ErrorContains(t, err, `prompt template "missing" not found`)
+}
+
+func TestNewTemplateFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".gmc", "templates")
+
+	path, err := NewTemplateFile(dir, "terse", "Short subjects")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "terse.yaml"), path)
+
+	content, err := GetPromptTemplate(path)
+	require.NoError(t, err)
+	assert.Contains(t, content, "{{.Diff}}")
+	assert.Equal(t, "Short subjects", readTemplateDescription(path))
+
+	_, err = NewTemplateFile(dir, "terse", "")
+	assert.ErrorContains(t, err, "template already exists")
+
+	for _, name := range []string{"default", "../escape", "with space", ""} {
+		_, err = NewTemplateFile(dir, name, "")
+		assert.ErrorContains(t, err, "invalid template name", name)
+	}
+}
+
+func TestDefaultTemplateOverrideIsListed(t *testing.T) {
+	repoDir := t.TempDir()
+	override := writeTemplate(t, repoDir, "default.yaml", "description: Team default\ntemplate: x\n")
+	dirs := []TemplateDir{{Source: TemplateSourceRepo, Path: repoDir}}
+
+	templates, err := ListTemplates(dirs)
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.True(t, templates[0].Shadowed, "the built-in template is shadowed by default.yaml")
+	assert.Equal(t, TemplateInfo{Name: "default", Source: TemplateSourceRepo, Path: override,
+		Description: "Team default"}, templates[1])
+
+	path, err := FindTemplate(dirs, "default")
+	require.NoError(t, err)
+	assert.Equal(t, override, path)
+}
@@ -1,10 +1,13 @@
 package formatter
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/samzong/gmc/internal/builtin"
+	"github.com/samzong/gmc/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -275,3 +278,84 @@ template: |
 	assert.Contains(t, renderedResult, "internal/formatter/template.go")
 	assert.Contains(t, renderedResult, "+func NewFunction() {}")
 }
+
+func TestCheckPromptTemplateReportsFileLine(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		message string
+	}{
+		{
+			name:    "parse error in block scalar",
+			content: "name: broken\ndescription: Broken\ntemplate: |\n  {{.Role}}\n  {{.Files}\n",
+			line:    5,
+			message: "bad character",
+		},
+		{
+			name:    "render error in block scalar",
+			content: "name: broken\ntemplate: |\n  {{.Role}}\n\n  {{.Author}}\n",
+			line:    5,
+			message: "can't evaluate field Author",
+		},
+		{
+			name:    "parse error in system block",
+			content: "name: broken\nsystem: |\n  {{.Role}}\n  {{.Types}\ntemplate: |\n  {{.Diff}}\n",
+			line:    4,
+			message: "bad character",
+		},
+		{
+			name:    "plain text template",
+			content: "{{.Role}}\n{{.Files\n",
+			line:    3,
+			message: "unclosed action started at line 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "broken.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			err := CheckPromptTemplate(path)
+			var templateErr *TemplateError
+			require.ErrorAs(t, err, &templateErr)
+			assert.Equal(t, path, templateErr.Path)
+			assert.Equal(t, tt.line, templateErr.Line)
+			assert.Contains(t, err.Error(), fmt.Sprintf("%s:%d: ", path, tt.line))
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+
+	assert.NoError(t, CheckPromptTemplate(config.DefaultPromptTemplate))
+	assert.ErrorContains(t, CheckPromptTemplate(filepath.Join(dir, "missing.yaml")), "prompt template file not found")
+}
+
+func TestDefaultTemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	builtin.SetOverrideDirs(func() []string { return []string{dir} })
+	t.Cleanup(func() { builtin.SetOverrideDirs(nil) })
+
+	cfg := &config.Config{Role: "Developer", EnableEmoji: true, CommitTypes: []string{"feat", "fix"}}
+	prompt := BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", "")
+	assert.Contains(t, prompt, `Use the "emoji type(scope): description" syntax.`)
+	assert.Contains(t, prompt, "Select the most fitting type from: feat, fix.")
+	assert.Contains(t, prompt, "✨ for feat")
+
+	path := filepath.Join(dir, "templates", "default.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("name: default\ntemplate: |\n  Types: {{.Types}}\n  {{.Nope}}\n"), 0o644))
+
+	err := CheckPromptTemplate(config.DefaultPromptTemplate)
+	var templateErr *TemplateError
+	require.ErrorAs(t, err, &templateErr)
+	assert.Equal(t, path, templateErr.Path, "errors point into the override")
+	assert.Equal(t, 4, templateErr.Line)
+
+	require.NoError(t, os.WriteFile(path, []byte("name: default\ntemplate: \"Types: {{.Types}}\"\n"), 0o644))
+	content, err := GetPromptTemplate("")
+	require.NoError(t, err)
+	assert.Equal(t, "Types: {{.Types}}", content)
+	assert.Contains(t, BuildPromptWithConfig(cfg, []string{"main.go"}, "diff", ""), "Types: feat, fix")
+}
@@ -0,0 +1,138 @@
+package formatter
+
+import (
+	"path"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/emoji"
+)
+
+var (
+	ciFiles = []string{".gitlab-ci.yml", ".travis.yml", "Jenkinsfile", "azure-pipelines.yml", ".drone.yml"}
+	ciDirs  = []string{".github/workflows/", ".circleci/", ".buildkite/"}
+	docExts = []string{".md", ".mdx", ".rst", ".adoc"}
+)
+
+// InferTypeHint returns a Conventional Commits type when every changed file falls into
+// a single category (tests, docs, or CI), or "" when the files are mixed.
+func InferTypeHint(changedFiles []string) string {
+	if len(changedFiles) == 0 {
+		return ""
+	}
+
+	for _, category := range []struct {
+		commitType string
+		match      func(string) bool
+	}{
+		{"test", isTestFile},
+		{"docs", isDocFile},
+		{"ci", isCIFile},
+	} {
+		if allMatch(changedFiles, category.match) {
+			return category.commitType
+		}
+	}
+	return ""
+}
+
+func allMatch(files []string, match func(string) bool) bool {
+	for _, file := range files {
+		if !match(strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, "\\", "/")), "./")) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTestFile(file string) bool {
+	base := path.Base(file)
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") ||
+		strings.Contains(base, ".spec.") ||
+		(strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py"))
+}
+
+func isDocFile(file string) bool {
+	if strings.HasPrefix(file, "docs/") || strings.Contains(file, "/docs/") {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(file))
+	for _, docExt := range docExts {
+		if ext == docExt {
+			return true
+		}
+	}
+	return false
+}
+
+func isCIFile(file string) bool {
+	for _, dir := range ciDirs {
+		if strings.HasPrefix(file, dir) {
+			return true
+		}
+	}
+	for _, name := range ciFiles {
+		if file == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ForceCommitType rewrites the type of a Conventional Commits subject, keeping scope and
+// description. A leading emoji is replaced with the one matching the new type.
+func ForceCommitType(message string, commitType string) string {
+	message = strings.TrimSpace(message)
+	if message == "" || commitType == "" {
+		return message
+	}
+
+	subject, rest, hasBody := strings.Cut(message, "\n")
+
+	hadEmoji, shortcode := false, strings.HasPrefix(subject, ":")
+	if _, stripped := emoji.InferTypeFromEmojiPrefix(subject); stripped != "" {
+		subject = stripped
+		hadEmoji = true
+	}
+
+	if matches := subjectTypePattern.FindStringSubmatch(subject); len(matches) >= 1 {
+		subject = commitType + matches[2] + matches[3] + ": " + strings.TrimPrefix(subject, matches[0])
+	} else {
+		subject = commitType + ": " + subject
+	}
+
+	switch {
+	case hadEmoji && shortcode:
+		subject = emoji.AddShortcodeToMessage(subject)
+	case hadEmoji:
+		subject = emoji.AddEmojiToMessage(subject)
+	}
+	if hasBody {
+		return subject + "\n" + rest
+	}
+	return subject
+}
+
+// TypeHintForConfig returns the inferred type hint unless type_hints is "off" or the
+// commit_types allowlist excludes it.
+func TypeHintForConfig(cfg *config.Config, changedFiles []string) string {
+	if cfg == nil {
+		return InferTypeHint(changedFiles)
+	}
+	if cfg.TypeHints == config.TypeHintsOff {
+		return ""
+	}
+	if hint := InferTypeHint(changedFiles); cfg.AllowsCommitType(hint) {
+		return hint
+	}
+	return ""
+}
+
+// EnforceTypeHint forces the hinted type onto message when type_hints is "strict".
+func EnforceTypeHint(cfg *config.Config, message string, hint string) string {
+	if cfg == nil || cfg.TypeHints != config.TypeHintsStrict || hint == "" {
+		return message
+	}
+	return ForceCommitType(message, hint)
+}
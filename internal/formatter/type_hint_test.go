@@ -0,0 +1,72 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferTypeHint(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "only go tests", files: []string{"cmd/root_test.go", "internal/git/git_test.go"}, want: "test"},
+		{name: "js spec", files: []string{"web/app.spec.ts"}, want: "test"},
+		{name: "docs dir", files: []string{"docs/guide.txt", "README.md"}, want: "docs"},
+		{name: "nested docs", files: []string{"website/docs/intro.mdx"}, want: "docs"},
+		{name: "ci files", files: []string{".github/workflows/release.yml", ".gitlab-ci.yml"}, want: "ci"},
+		{name: "mixed", files: []string{"cmd/root.go", "cmd/root_test.go"}, want: ""},
+		{name: "docs and tests", files: []string{"README.md", "cmd/root_test.go"}, want: ""},
+		{name: "empty", files: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, InferTypeHint(tt.files))
+		})
+	}
+}
+
+func TestForceCommitType(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "replaces type", message: "feat: add parser tests", want: "test: add parser tests"},
+		{name: "keeps scope", message: "fix(parser): cover edge case", want: "test(parser): cover edge case"},
+		{name: "adds missing type", message: "cover edge case", want: "test: cover edge case"},
+		{name: "keeps body", message: "feat: add tests\n\nbody", want: "test: add tests\n\nbody"},
+		{name: "swaps emoji", message: "✨ feat: add tests", want: "✅ test: add tests"},
+		{name: "swaps shortcode", message: ":sparkles: feat: add tests", want: ":white_check_mark: test: add tests"},
+		{name: "keeps breaking marker", message: "feat(api)!: drop v1 tests", want: "test(api)!: drop v1 tests"},
+		{name: "replaces custom type", message: "infra(x): cover the module", want: "test(x): cover the module"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ForceCommitType(tt.message, "test"))
+		})
+	}
+}
+
+func TestTypeHintPolicy(t *testing.T) {
+	files := []string{"cmd/root_test.go"}
+
+	off := &config.Config{TypeHints: config.TypeHintsOff}
+	assert.Empty(t, TypeHintForConfig(off, files))
+
+	soft := &config.Config{TypeHints: config.TypeHintsSoft}
+	assert.Equal(t, "test", TypeHintForConfig(soft, files))
+	assert.Equal(t, "feat: x", EnforceTypeHint(soft, "feat: x", "test"))
+
+	strict := &config.Config{TypeHints: config.TypeHintsStrict}
+	assert.Equal(t, "test: x", EnforceTypeHint(strict, "feat: x", "test"))
+
+	prompt := BuildPromptWithContext(soft, files, "diff", PromptContext{TypeHint: "test"})
+	assert.Contains(t, prompt, "Type Hint:")
+	assert.Contains(t, prompt, `prefer the "test" type`)
+}
@@ -7,27 +7,72 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/samzong/gmc/internal/gitcmd"
 	"github.com/samzong/gmc/internal/gitutil"
+	"github.com/samzong/gmc/internal/gmcerrors"
 	"github.com/samzong/gmc/internal/stringsutil"
 )
 
 type Options struct {
 	Verbose bool
+	// Backend is BackendNative (the default) or BackendGoGit.
+	Backend string
+	// ExcludePaths are pathspec patterns left out of the staged diffs gmc reads.
+	ExcludePaths []string
+	// Dir is the directory git runs in and relative paths resolve against.
+	// When empty, the process working directory is used.
+	Dir string
 }
 
 type Client struct {
 	runner  gitcmd.Runner
+	dir     string
 	verbose bool
+	// excludePaths are left out of the staged diffs, see diffPathspecs.
+	excludePaths []string
+	// location is set by OpenRepo, which has checked the worktree already.
+	location *location
+	// goGit is set when the client answers read-only queries with go-git.
+	goGit *goGitRepo
+}
+
+// location is where a worktree is: its root, and the current directory relative to it.
+type location struct {
+	root   string
+	prefix string
 }
 
 func NewClient(opts Options) *Client {
-	return &Client{
-		runner:  gitcmd.Runner{Verbose: opts.Verbose},
-		verbose: opts.Verbose,
+	client := &Client{
+		runner:       gitcmd.Runner{Verbose: opts.Verbose, Dir: opts.Dir},
+		dir:          opts.Dir,
+		verbose:      opts.Verbose,
+		excludePaths: opts.ExcludePaths,
+	}
+	if opts.Backend == BackendGoGit {
+		client.goGit = &goGitRepo{}
+	}
+	return client
+}
+
+// workingDir returns the directory the client runs git in.
+func (c *Client) workingDir() (string, error) {
+	if c.dir != "" {
+		return c.dir, nil
 	}
+	return os.Getwd()
+}
+
+// absPath resolves path against the directory the client runs git in.
+func (c *Client) absPath(path string) (string, error) {
+	if filepath.IsAbs(path) || c.dir == "" {
+		return filepath.Abs(path)
+	}
+	return filepath.Join(c.dir, path), nil
 }
 
 func (c *Client) logVerboseOutput(label string, data []byte) {
@@ -39,20 +84,40 @@ func (c *Client) logVerboseOutput(label string, data []byte) {
 
 // CommitInfo represents information about a single commit
 type CommitInfo struct {
-	Hash    string `json:"hash"`
-	Author  string `json:"author"`
+	Hash   string `json:"hash"`
+	Author string `json:"author"`
+	// Email is the author email, set by GetCommitHistory.
+	Email   string `json:"email,omitempty"`
 	Date    string `json:"date"`
 	Message string `json:"message"`
 	Body    string `json:"body"`
 }
 
+// CommitFiles is a commit subject with the files the commit changed.
+type CommitFiles struct {
+	Hash    string   `json:"hash"`
+	Subject string   `json:"subject"`
+	Files   []string `json:"files"`
+}
+
 // IsGitRepository checks if the current directory is a git repository
 func (c *Client) IsGitRepository() bool {
+	if c.location != nil {
+		return true
+	}
+	if c.goGit != nil {
+		_, err := c.goGitLocation()
+		return err == nil
+	}
 	_, err := c.runner.Run("rev-parse", "--is-inside-work-tree")
 	return err == nil
 }
 
-var ErrNotGitRepo = errors.New("not a git repository")
+// ErrNotGitRepo is returned outside a git repository; it is gmcerrors.ErrNotARepo.
+var ErrNotGitRepo = gmcerrors.ErrNotARepo
+
+// ErrNoNote is returned by ShowNote when the object has no note under the ref.
+var ErrNoNote = errors.New("no note found")
 
 func (c *Client) CheckGitRepository() error {
 	if !c.IsGitRepository() {
@@ -61,6 +126,75 @@ func (c *Client) CheckGitRepository() error {
 	return nil
 }
 
+// GetGitCommonDir returns the absolute git directory shared by all worktrees of the repository.
+func (c *Client) GetGitCommonDir() (string, error) {
+	result, err := c.runner.Run("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	dir := result.StdoutString(true)
+	if dir == "" {
+		return "", errors.New("failed to determine git common directory")
+	}
+	return c.absPath(dir)
+}
+
+// HooksDir returns the absolute directory git runs the hooks of the repository from,
+// and core.hooksPath, which moves it, or "" when that is not set.
+func (c *Client) HooksDir() (dir string, hooksPath string, err error) {
+	result, err := c.runner.Run("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", "", fmt.Errorf("not in a git repository: %w", err)
+	}
+	if dir, err = c.absPath(result.StdoutString(true)); err != nil {
+		return "", "", err
+	}
+	// git config exits 1 when the key is not set.
+	if result, err := c.runner.Run("config", "--get", "core.hooksPath"); err == nil {
+		hooksPath = result.StdoutString(true)
+	}
+	return dir, hooksPath, nil
+}
+
+// GetRepoRoot returns the top-level directory of the current worktree.
+func (c *Client) GetRepoRoot() (string, error) {
+	if c.location != nil {
+		return c.location.root, nil
+	}
+	if c.goGit != nil {
+		loc, err := c.goGitLocation()
+		if err != nil {
+			return "", fmt.Errorf("not in a git repository: %w", err)
+		}
+		return loc.root, nil
+	}
+	result, err := c.runner.Run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	root := result.StdoutString(true)
+	if root == "" {
+		return "", errors.New("failed to determine repository root")
+	}
+	return root, nil
+}
+
+// GetRemoteURL returns the configured URL of the named remote.
+func (c *Client) GetRemoteURL(name string) (string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return "", err
+	}
+
+	result, err := c.runner.Run("remote", "get-url", name)
+	if err != nil {
+		return "", gitutil.WrapGitError(fmt.Sprintf("failed to get URL of remote '%s'", name), result, err)
+	}
+
+	return result.StdoutString(true), nil
+}
+
 func (c *Client) GetDiff() (string, error) {
 	if err := c.CheckGitRepository(); err != nil {
 		return "", err
@@ -89,8 +223,12 @@ func (c *Client) GetStagedDiff() (string, error) {
 	if err := c.CheckGitRepository(); err != nil {
 		return "", err
 	}
+	if c.goGit != nil {
+		changes, err := c.goGitStagedChanges()
+		return changes.Diff, err
+	}
 
-	result, err := c.runner.RunLogged("diff", "--cached", "-U1")
+	result, err := c.runStagedDiff([]string{"diff", "--cached", "-M", "-U1"}, nil)
 	if err != nil {
 		c.logVerboseOutput("Git stderr:", result.Stderr)
 		return "", fmt.Errorf("failed to run git diff --cached: %w", err)
@@ -99,16 +237,130 @@ func (c *Client) GetStagedDiff() (string, error) {
 	return string(result.Stdout), nil
 }
 
+// runStagedDiff runs the git diff args on paths, or the whole tree without paths,
+// leaving out excludePaths. When they leave out every change, it runs the diff again
+// without them, so a commit of excluded files alone still gets a message.
+func (c *Client) runStagedDiff(args []string, paths []string) (gitcmd.Result, error) {
+	if len(c.excludePaths) > 0 {
+		specs := gitutil.ExcludePathspecs(c.excludePaths)
+		if len(paths) > 0 {
+			specs = append(slices.Clone(paths), specs[1:]...)
+		}
+		result, err := c.runner.RunLogged(append(append(slices.Clone(args), "--"), specs...)...)
+		if err != nil || len(bytes.TrimSpace(result.Stdout)) > 0 {
+			return result, err
+		}
+	}
+	if len(paths) > 0 {
+		args = append(append(slices.Clone(args), "--"), paths...)
+	}
+	return c.runner.RunLogged(args...)
+}
+
+// StagedChanges is what gmc reads about the staged changes to write a message.
+type StagedChanges struct {
+	// Diff is the patch, as GetStagedDiff returns it.
+	Diff string
+	// Stats is the --numstat --summary block, as GetStagedDiffStats returns it.
+	Stats string
+	// Files are the changed paths, as ParseStagedFiles returns them.
+	Files []string
+}
+
+// GetStagedChanges reads the staged patch, its stats and the changed files with a
+// single git diff, in place of GetStagedDiff, GetStagedDiffStats and ParseStagedFiles.
+func (c *Client) GetStagedChanges() (StagedChanges, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return StagedChanges{}, err
+	}
+	if c.goGit != nil {
+		return c.goGitStagedChanges()
+	}
+
+	result, err := c.runStagedDiff([]string{"diff", "--cached", "-M", "-U1", "--numstat", "--summary", "--patch"}, nil)
+	if err != nil {
+		c.logVerboseOutput("Git stderr:", result.Stderr)
+		return StagedChanges{}, fmt.Errorf("failed to run git diff --cached: %w", err)
+	}
+	return parseStagedChanges(string(result.Stdout)), nil
+}
+
+// parseStagedChanges splits git diff --numstat --summary --patch output: the stats
+// lines, a blank line, then the patch.
+func parseStagedChanges(output string) StagedChanges {
+	stats, diff := output, ""
+	if strings.HasPrefix(output, "diff --git ") {
+		stats, diff = "", output
+	} else if i := strings.Index(output, "\n\ndiff --git "); i >= 0 {
+		stats, diff = output[:i+1], output[i+2:]
+	}
+
+	changes := StagedChanges{Diff: diff, Stats: stats}
+	for _, stat := range ParseNumstat(stats) {
+		changes.Files = append(changes.Files, stat.Path)
+	}
+	return changes
+}
+
+// FileStat is a numstat line: the lines a file gained and lost. Binary files count
+// neither.
+type FileStat struct {
+	Path    string
+	Added   int
+	Removed int
+	Binary  bool
+}
+
+// ParseNumstat returns the numstat lines of a --numstat --summary block, such as
+// StagedChanges.Stats, in order. Renamed files have their new path.
+func ParseNumstat(stats string) []FileStat {
+	var fileStats []FileStat
+	for _, line := range stringsutil.SplitNonEmpty(stats, "\n") {
+		// --summary lines start with a space; numstat lines are "added\tremoved\tpath".
+		fields := strings.SplitN(line, "\t", 3)
+		if strings.HasPrefix(line, " ") || len(fields) != 3 {
+			continue
+		}
+		stat := FileStat{Path: renamedPath(fields[2]), Binary: fields[0] == "-"}
+		stat.Added, _ = strconv.Atoi(fields[0])
+		stat.Removed, _ = strconv.Atoi(fields[1])
+		fileStats = append(fileStats, stat)
+	}
+	return fileStats
+}
+
+// renamedPath returns the new path of a numstat rename, "old => new" or
+// "dir/{old => new}/file", and any other path unchanged.
+func renamedPath(numstatPath string) string {
+	if open := strings.Index(numstatPath, "{"); open >= 0 {
+		if end := strings.Index(numstatPath[open:], "}"); end >= 0 {
+			inner := numstatPath[open+1 : open+end]
+			if _, newPart, ok := strings.Cut(inner, " => "); ok {
+				joined := numstatPath[:open] + newPart + numstatPath[open+end+1:]
+				return strings.ReplaceAll(joined, "//", "/")
+			}
+		}
+	}
+	if _, newPath, ok := strings.Cut(numstatPath, " => "); ok {
+		return newPath
+	}
+	return numstatPath
+}
+
 // GetStagedDiffStats returns staged diff stats for budgeted truncation.
 func (c *Client) GetStagedDiffStats() (string, error) {
 	if err := c.CheckGitRepository(); err != nil {
 		return "", err
 	}
+	if c.goGit != nil {
+		changes, err := c.goGitStagedChanges()
+		return changes.Stats, err
+	}
 
-	result, err := c.runner.RunLogged("diff", "--cached", "--numstat", "--summary")
+	result, err := c.runStagedDiff([]string{"diff", "--cached", "-M", "--numstat", "--summary"}, nil)
 	if err != nil {
 		c.logVerboseOutput("Git stderr:", result.Stderr)
-		return "", fmt.Errorf("failed to run git diff --cached --numstat --summary: %w", err)
+		return "", fmt.Errorf("failed to run git diff --cached -M --numstat --summary: %w", err)
 	}
 
 	return string(result.Stdout), nil
@@ -141,6 +393,10 @@ func (c *Client) ParseStagedFiles() ([]string, error) {
 	if err := c.CheckGitRepository(); err != nil {
 		return nil, err
 	}
+	if c.goGit != nil {
+		changes, err := c.goGitStagedChanges()
+		return changes.Files, err
+	}
 
 	runResult, err := c.runner.RunLogged("diff", "--cached", "--name-only")
 	if err != nil {
@@ -167,12 +423,28 @@ func (c *Client) AddAll() error {
 	return nil
 }
 
+// StagePaths stages every change to the files pathspecs match, additions and
+// deletions included, as git add --all does. Wildcards match as in ResolveFiles.
+func (c *Client) StagePaths(pathspecs []string) error {
+	if err := c.CheckGitRepository(); err != nil {
+		return err
+	}
+
+	args := append([]string{"--glob-pathspecs", "add", "--all", "--"}, pathspecs...)
+	result, err := c.runner.RunLogged(args...)
+	if err != nil {
+		return gitutil.WrapGitError("failed to stage "+strings.Join(pathspecs, " "), result, err)
+	}
+	return nil
+}
+
 func (c *Client) Commit(message string, args ...string) error {
 	if err := c.CheckGitRepository(); err != nil {
 		return err
 	}
 
-	commitArgs := append([]string{"commit", "-m", message}, args...)
+	commitArgs := append([]string{"commit"}, commitMessageArgs(message)...)
+	commitArgs = append(commitArgs, args...)
 	result, err := c.runner.RunLogged(commitArgs...)
 
 	// Always show output in verbose mode
@@ -189,6 +461,38 @@ func (c *Client) Commit(message string, args ...string) error {
 	return nil
 }
 
+// commitMessageArgs passes the subject and body as separate -m arguments, so git
+// separates them with a blank line.
+func commitMessageArgs(message string) []string {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	args := []string{"-m", strings.TrimSpace(subject)}
+	if body = strings.Trim(body, "\n"); strings.TrimSpace(body) != "" {
+		args = append(args, "-m", body)
+	}
+	return args
+}
+
+// CurrentBranch returns the short name of the checked out branch, or "" when HEAD is
+// detached.
+func (c *Client) CurrentBranch() (string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return "", err
+	}
+	return c.currentBranch()
+}
+
+func (c *Client) currentBranch() (string, error) {
+	if c.goGit != nil {
+		return c.goGitBranch()
+	}
+	// symbolic-ref exits 1 with --quiet when HEAD is detached.
+	result, err := c.runner.Run("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil && len(result.Stderr) > 0 {
+		return "", fmt.Errorf("failed to read the current branch: %s", result.StderrString(true))
+	}
+	return result.StdoutString(true), nil
+}
+
 func (c *Client) CreateAndSwitchBranch(branchName string) error {
 	if err := c.CheckGitRepository(); err != nil {
 		return err
@@ -235,30 +539,56 @@ func (c *Client) createAndSwitchBranch(branchName string) error {
 
 // GetLatestTag returns the most recently created tag in the repository.
 func (c *Client) GetLatestTag() (string, error) {
+	return c.latestTag(func(string) bool { return true })
+}
+
+// GetLatestTagWithPrefix returns the most recently created tag that is prefix followed
+// by a name without a slash: with prefix "api/", api/v1.4.0 but not api/beta/v1; with
+// prefix "", v1.4.0 but not the component tag api/v1.4.0.
+func (c *Client) GetLatestTagWithPrefix(prefix string) (string, error) {
+	return c.latestTag(func(tag string) bool {
+		name, ok := strings.CutPrefix(tag, prefix)
+		return ok && name != "" && !strings.Contains(name, "/")
+	})
+}
+
+// latestTag returns the most recently created tag that match accepts.
+func (c *Client) latestTag(match func(tag string) bool) (string, error) {
 	if err := c.CheckGitRepository(); err != nil {
 		return "", err
 	}
+	if c.goGit != nil {
+		return c.goGitLatestTag(match)
+	}
 
 	result, err := c.runner.RunLogged("tag", "--sort=-creatordate")
 	if err != nil {
 		return "", fmt.Errorf("failed to list tags: %w", err)
 	}
 
-	output := result.StdoutString(true)
-	if output == "" {
-		return "", nil
+	for _, tag := range strings.Split(result.StdoutString(true), "\n") {
+		if tag = strings.TrimSpace(tag); tag != "" && match(tag) {
+			return tag, nil
+		}
 	}
-
-	tags := strings.Split(output, "\n")
-	return strings.TrimSpace(tags[0]), nil
+	return "", nil
 }
 
 // GetCommitsSinceTag returns the commits between the given tag (exclusive) and HEAD.
 // If the tag is empty or not found, all commits up to HEAD are returned.
 func (c *Client) GetCommitsSinceTag(tag string) ([]CommitInfo, error) {
+	return c.GetCommitsSinceTagInPath(tag, "")
+}
+
+// GetCommitsSinceTagInPath is GetCommitsSinceTag, limited to the commits that change
+// files under dir when dir is not empty.
+func (c *Client) GetCommitsSinceTagInPath(tag string, dir string) ([]CommitInfo, error) {
 	if err := c.CheckGitRepository(); err != nil {
 		return nil, err
 	}
+	if c.goGit != nil && dir == "" {
+		return c.goGitCommitsSinceTag(tag)
+	}
 
 	format := "%H%x1f%an%x1f%ad%x1f%s%x1f%b%x1e"
 	args := []string{"log", "--pretty=format:" + format, "--date=short"}
@@ -272,6 +602,9 @@ func (c *Client) GetCommitsSinceTag(tag string) ([]CommitInfo, error) {
 			args = append(args, tag+"..HEAD")
 		}
 	}
+	if dir != "" {
+		args = append(args, "--", dir)
+	}
 
 	result, err := c.runner.RunLogged(args...)
 	if err != nil {
@@ -341,6 +674,68 @@ func (c *Client) CreateAnnotatedTag(tag string, message string) error {
 	return nil
 }
 
+// PushTag pushes tag, and only tag, to remote.
+func (c *Client) PushTag(remote string, tag string) error {
+	if err := c.CheckGitRepository(); err != nil {
+		return err
+	}
+
+	result, err := c.runner.RunLogged("push", remote, "refs/tags/"+tag)
+	if err != nil {
+		return gitutil.WrapGitError(fmt.Sprintf("failed to push tag '%s' to %s", tag, remote), result, err)
+	}
+	return nil
+}
+
+// AddNote attaches content as a note to object under refs/notes/<ref>, replacing any
+// existing note.
+func (c *Client) AddNote(ref string, object string, content string) error {
+	if err := c.CheckGitRepository(); err != nil {
+		return err
+	}
+
+	result, err := c.runner.RunLogged("notes", "--ref="+ref, "add", "-f", "-m", content, object)
+	if err != nil {
+		return gitutil.WrapGitError("failed to add git note", result, err)
+	}
+	return nil
+}
+
+// InterpretTrailers adds trailers, as "Key: value" lines, to the trailer block of
+// message with git interpret-trailers. A trailer already in message is not repeated,
+// and git's trailer.* config applies.
+func (c *Client) InterpretTrailers(message string, trailers []string) (string, error) {
+	if len(trailers) == 0 {
+		return message, nil
+	}
+
+	args := []string{"interpret-trailers", "--if-exists", "addIfDifferent"}
+	for _, trailer := range trailers {
+		args = append(args, "--trailer", trailer)
+	}
+	result, err := c.runner.RunInput(message+"\n", args...)
+	if err != nil {
+		return "", gitutil.WrapGitError("failed to add commit trailers", result, err)
+	}
+	return strings.TrimRight(result.StdoutString(false), "\n"), nil
+}
+
+// ShowNote returns the note attached to object under refs/notes/<ref>.
+func (c *Client) ShowNote(ref string, object string) (string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return "", err
+	}
+
+	result, err := c.runner.Run("notes", "--ref="+ref, "show", object)
+	if err != nil {
+		if strings.Contains(result.StderrString(true), "no note found") {
+			return "", fmt.Errorf("%w for %s in refs/notes/%s", ErrNoNote, object, ref)
+		}
+		return "", gitutil.WrapGitError("failed to read git note", result, err)
+	}
+	return result.StdoutString(true), nil
+}
+
 func (c *Client) tagExists(tag string) (bool, error) {
 	if tag == "" {
 		return false, nil
@@ -349,7 +744,8 @@ func (c *Client) tagExists(tag string) (bool, error) {
 	ref := "refs/tags/" + tag
 	_, err := c.runner.Run("rev-parse", "--verify", ref)
 	if err != nil {
-		if _, ok := err.(*exec.ExitError); ok {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
 			return false, nil
 		}
 		return false, fmt.Errorf("failed to verify tag %s: %w", tag, err)
@@ -364,17 +760,31 @@ func (c *Client) GetCommitHistory(limit int, teamMode bool) ([]CommitInfo, error
 		return nil, err
 	}
 
+	if c.goGit != nil {
+		author := ""
+		if !teamMode {
+			currentUser, err := c.goGitUserName()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get current git user: %w", err)
+			}
+			author = currentUser
+		}
+		return c.goGitCommitHistory(limit, author)
+	}
+
+	// .mailmap maps the names and emails an author used to one identity.
 	var args []string
 	if teamMode {
 		// Team mode: get commits from all authors
-		args = []string{"log", "--pretty=format:%h|%an|%ad|%s", "--date=short", fmt.Sprintf("-n%d", limit)}
+		args = []string{"log", "--use-mailmap", "--pretty=format:%h|%aN <%aE>|%ad|%s", "--date=short",
+			fmt.Sprintf("-n%d", limit)}
 	} else {
 		// Personal mode: get commits from current user only
 		currentUser, err := c.getCurrentGitUser()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get current git user: %w", err)
 		}
-		args = []string{"log", "--pretty=format:%h|%an|%ad|%s", "--date=short",
+		args = []string{"log", "--use-mailmap", "--pretty=format:%h|%aN <%aE>|%ad|%s", "--date=short",
 			"--author=" + currentUser, fmt.Sprintf("-n%d", limit)}
 	}
 
@@ -392,6 +802,45 @@ func (c *Client) GetCommitHistory(limit int, teamMode bool) ([]CommitInfo, error
 	return parseCommitOutput(output)
 }
 
+// GetRecentCommitFiles returns up to limit non-merge commits reachable from HEAD, newest
+// first, with the files each one changed.
+func (c *Client) GetRecentCommitFiles(limit int) ([]CommitFiles, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return nil, err
+	}
+	if c.goGit != nil {
+		return c.goGitRecentCommitFiles(limit)
+	}
+
+	result, err := c.runner.RunLogged("log", "--no-merges", "--name-only", "--pretty=format:%x1e%h%x1f%s",
+		fmt.Sprintf("-n%d", limit))
+	if err != nil {
+		return nil, gitutil.WrapGitError("failed to run git log", result, err)
+	}
+
+	var commits []CommitFiles
+	for _, record := range strings.Split(result.StdoutString(false), "\x1e") {
+		header, files, _ := strings.Cut(record, "\n")
+		hash, subject, ok := strings.Cut(header, "\x1f")
+		if !ok {
+			continue
+		}
+		commit := CommitFiles{Hash: hash, Subject: strings.TrimSpace(subject)}
+		for _, file := range strings.Split(files, "\n") {
+			if file = strings.TrimSpace(file); file != "" {
+				commit.Files = append(commit.Files, file)
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// UserName returns the user.name of the git config.
+func (c *Client) UserName() (string, error) {
+	return c.getCurrentGitUser()
+}
+
 // getCurrentGitUser gets the current git user name
 func (c *Client) getCurrentGitUser() (string, error) {
 	result, err := c.runner.Run("config", "user.name")
@@ -424,6 +873,10 @@ func parseCommitOutput(output string) ([]CommitInfo, error) {
 			Date:    strings.TrimSpace(parts[2]),
 			Message: strings.TrimSpace(parts[3]),
 		}
+		// The author is "name <email>" in GetCommitHistory.
+		if name, email, ok := strings.Cut(commit.Author, " <"); ok && strings.HasSuffix(email, ">") {
+			commit.Author, commit.Email = name, strings.TrimSuffix(email, ">")
+		}
 
 		commits = append(commits, commit)
 	}
@@ -431,18 +884,27 @@ func parseCommitOutput(output string) ([]CommitInfo, error) {
 	return commits, nil
 }
 
-// ResolveFiles expands directories to individual files and validates file paths
+// ResolveFiles expands directories to individual files and validates file paths.
+// Paths with pathspec magic or wildcards, such as 'src/**/*.go' or ':(exclude)docs/',
+// are expanded by git instead; see resolvePathspecs.
 func (c *Client) ResolveFiles(paths []string) ([]string, error) {
 	if err := c.CheckGitRepository(); err != nil {
 		return nil, err
 	}
+	if slices.ContainsFunc(paths, c.isPathspec) {
+		return c.resolvePathspecs(paths)
+	}
 
 	var resolvedFiles []string
 	for _, path := range paths {
 		// Clean path to prevent directory traversal
 		cleanPath := filepath.Clean(path)
 
-		info, err := os.Stat(cleanPath)
+		statPath, err := c.absPath(cleanPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check path: %s: %w", path, err)
+		}
+		info, err := os.Stat(statPath)
 		if err != nil {
 			if os.IsNotExist(err) {
 				inIndex, indexErr := c.isPathInStagedDiff(cleanPath)
@@ -474,6 +936,48 @@ func (c *Client) ResolveFiles(paths []string) ([]string, error) {
 	return stringsutil.UniqueStrings(resolvedFiles), nil
 }
 
+// isPathspec reports whether path is a git pathspec rather than a file or directory:
+// it starts with ':', as magic such as ':(exclude)' and ':!' does, or has a wildcard,
+// and no such file exists.
+func (c *Client) isPathspec(path string) bool {
+	if !strings.HasPrefix(path, ":") && !strings.ContainsAny(path, "*?[") {
+		return false
+	}
+	absPath, err := c.absPath(path)
+	if err != nil {
+		return true
+	}
+	_, err = os.Lstat(absPath)
+	return err != nil
+}
+
+// resolvePathspecs lists the files that match pathspecs together, the way git add
+// would: tracked and untracked files that are not ignored, plus staged deletions.
+// Wildcards match as in a shell, so '*' stays within a directory and '**/' matches
+// any number of them. Exclude pathspecs drop files from the others, or from the
+// whole directory when they are alone.
+func (c *Client) resolvePathspecs(pathspecs []string) ([]string, error) {
+	listed, err := c.runner.RunLogged(append([]string{
+		"--glob-pathspecs", "ls-files", "--cached", "--others", "--exclude-standard", "--",
+	}, pathspecs...)...)
+	if err != nil {
+		return nil, gitutil.WrapGitError("failed to expand pathspecs", listed, err)
+	}
+	deleted, err := c.runner.RunLogged(append([]string{
+		"--glob-pathspecs", "diff", "--cached", "--name-only", "--relative", "--diff-filter=D", "--",
+	}, pathspecs...)...)
+	if err != nil {
+		return nil, gitutil.WrapGitError("failed to expand pathspecs", deleted, err)
+	}
+
+	files := stringsutil.SplitNonEmpty(listed.StdoutString(true), "\n")
+	files = append(files, stringsutil.SplitNonEmpty(deleted.StdoutString(true), "\n")...)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", strings.Join(pathspecs, " "))
+	}
+	return stringsutil.UniqueStrings(files), nil
+}
+
 func (c *Client) isPathInStagedDiff(path string) (bool, error) {
 	gitPath := filepath.ToSlash(path)
 
@@ -516,77 +1020,6 @@ func (c *Client) getGitTrackedFilesInDir(dir string) ([]string, error) {
 	return stringsutil.SplitNonEmpty(output, "\n"), nil
 }
 
-// CheckFileStatus checks the git status of specified files
-// Returns: staged, modified, untracked files
-func (c *Client) CheckFileStatus(files []string) ([]string, []string, []string, error) {
-	if err := c.CheckGitRepository(); err != nil {
-		return nil, nil, nil, err
-	}
-
-	var staged, modified, untracked []string
-
-	for _, file := range files {
-		// Check if file is staged
-		isStaged, err := c.isFileStaged(file)
-		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to check staged status for %s: %w", file, err)
-		}
-
-		// Check if file is modified
-		isModified, err := c.isFileModified(file)
-		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to check modified status for %s: %w", file, err)
-		}
-
-		// Check if file is tracked
-		isTracked, err := c.isFileTracked(file)
-		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to check tracked status for %s: %w", file, err)
-		}
-
-		switch {
-		case isStaged:
-			staged = append(staged, file)
-		case isModified:
-			modified = append(modified, file)
-		case !isTracked:
-			untracked = append(untracked, file)
-		}
-	}
-
-	return staged, modified, untracked, nil
-}
-
-// isFileStaged checks if a file is staged
-func (c *Client) isFileStaged(file string) (bool, error) {
-	result, err := c.runner.Run("diff", "--cached", "--name-only", file)
-	if err != nil {
-		return false, err
-	}
-
-	return result.StdoutString(true) != "", nil
-}
-
-// isFileModified checks if a file is modified (unstaged changes)
-func (c *Client) isFileModified(file string) (bool, error) {
-	result, err := c.runner.Run("diff", "--name-only", file)
-	if err != nil {
-		return false, err
-	}
-
-	return result.StdoutString(true) != "", nil
-}
-
-// isFileTracked checks if a file is tracked by git
-func (c *Client) isFileTracked(file string) (bool, error) {
-	result, err := c.runner.Run("ls-files", file)
-	if err != nil {
-		return false, err
-	}
-
-	return result.StdoutString(true) != "", nil
-}
-
 // StageFiles stages specific files
 func (c *Client) StageFiles(files []string) error {
 	if err := c.CheckGitRepository(); err != nil {
@@ -613,11 +1046,7 @@ func (c *Client) GetFilesDiff(files []string) (string, error) {
 		return "", nil
 	}
 
-	args := []string{"diff", "--cached"}
-	args = append(args, "--")
-	args = append(args, files...)
-
-	result, err := c.runner.RunLogged(args...)
+	result, err := c.runStagedDiff([]string{"diff", "--cached", "-M"}, files)
 	if err != nil {
 		c.logVerboseOutput("Git stderr:", result.Stderr)
 		return "", fmt.Errorf("failed to get diff for files: %w", err)
@@ -632,7 +1061,7 @@ func (c *Client) CommitFiles(message string, files []string, args ...string) err
 		return err
 	}
 
-	commitArgs := []string{"commit", "-m", message}
+	commitArgs := append([]string{"commit"}, commitMessageArgs(message)...)
 	commitArgs = append(commitArgs, args...)
 	commitArgs = append(commitArgs, "--")
 	commitArgs = append(commitArgs, files...)
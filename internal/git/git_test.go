@@ -1,6 +1,7 @@
 package git
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -104,6 +105,68 @@ func TestCommitInfoFields(t *testing.T) {
 }
 
 // Test CommitInfo struct
+func TestParseCommitOutputSplitsEmail(t *testing.T) {
+	commits, err := parseCommitOutput("abc1234|Jane Doe <jane@example.com>|2024-01-15|fix: a <b> | c")
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "Jane Doe", commits[0].Author)
+	assert.Equal(t, "jane@example.com", commits[0].Email)
+	assert.Equal(t, "fix: a <b> | c", commits[0].Message)
+}
+
+func TestGetCommitHistoryUsesMailmap(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gmc_git_mailmap_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init")
+	runGitCommand(t, tempDir, "config", "user.name", "Jane Doe")
+	runGitCommand(t, tempDir, "config", "user.email", "jane@example.com")
+	runGitCommand(t, tempDir, "commit", "--allow-empty", "-m", "feat: one", "--author", "jd <jd@old.example>")
+	runGitCommand(t, tempDir, "commit", "--allow-empty", "-m", "fix: two")
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".mailmap"),
+		[]byte("Jane Doe <jane@example.com> jd <jd@old.example>\n"), 0644))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(tempDir))
+	AssertNotInRealRepo(t)
+
+	for _, backend := range []string{BackendNative, BackendGoGit} {
+		commits, err := NewClient(Options{Backend: backend}).GetCommitHistory(10, true)
+		require.NoError(t, err, backend)
+		require.Len(t, commits, 2, backend)
+		for _, commit := range commits {
+			assert.Equal(t, "Jane Doe", commit.Author, backend+": "+commit.Message)
+			assert.Equal(t, "jane@example.com", commit.Email, backend+": "+commit.Message)
+		}
+	}
+}
+
+func TestParseMailmap(t *testing.T) {
+	m := parseMailmap(`# comment
+Jane Doe <jane@example.com>
+<joe@example.com> <JOE@old.example>
+Ann Lee <ann@example.com> <ann@old.example>
+Bob Ray <bob@example.com> bobby <bob@old.example> # trailing comment
+`)
+	for _, tc := range []struct{ name, email, wantName, wantEmail string }{
+		{"jd", "Jane@Example.com", "Jane Doe", "Jane@Example.com"},
+		{"Joe", "joe@old.example", "Joe", "joe@example.com"},
+		{"ann", "ann@old.example", "Ann Lee", "ann@example.com"},
+		{"Bobby", "bob@old.example", "Bob Ray", "bob@example.com"},
+		{"Robert", "bob@old.example", "Robert", "bob@old.example"},
+		{"Sam", "sam@example.com", "Sam", "sam@example.com"},
+	} {
+		name, email := m.resolve(tc.name, tc.email)
+		assert.Equal(t, tc.wantName, name, tc.name)
+		assert.Equal(t, tc.wantEmail, email, tc.name)
+	}
+}
+
 func TestCommitInfo(t *testing.T) {
 	commit := CommitInfo{
 		Hash:    "abc123",
@@ -272,6 +335,39 @@ func TestWithTempGitRepo(t *testing.T) {
 		assert.Equal(t, "Test User", commits[0].Author)
 	})
 
+	t.Run("GetRecentCommitFiles", func(t *testing.T) {
+		commits, err := client.GetRecentCommitFiles(5)
+		assert.NoError(t, err)
+		require.Len(t, commits, 1)
+		assert.Equal(t, "test: safe commit in temp repo", commits[0].Subject)
+		assert.Equal(t, []string{"test.txt"}, commits[0].Files)
+		assert.NotEmpty(t, commits[0].Hash)
+	})
+
+	t.Run("GetGitCommonDir", func(t *testing.T) {
+		dir, err := client.GetGitCommonDir()
+		assert.NoError(t, err)
+		assert.True(t, filepath.IsAbs(dir))
+		assert.Equal(t, ".git", filepath.Base(dir))
+	})
+
+	t.Run("Notes", func(t *testing.T) {
+		_, err := client.ShowNote("gmc", "HEAD")
+		assert.ErrorIs(t, err, ErrNoNote)
+
+		require.NoError(t, client.AddNote("gmc", "HEAD", `{"model":"m"}`))
+		require.NoError(t, client.AddNote("gmc", "HEAD", `{"model":"n"}`), "existing notes are replaced")
+		note, err := client.ShowNote("gmc", "HEAD")
+		assert.NoError(t, err)
+		assert.Equal(t, `{"model":"n"}`, note)
+	})
+
+	t.Run("GetRepoRoot", func(t *testing.T) {
+		root, err := client.GetRepoRoot()
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Base(tempDir), filepath.Base(root))
+	})
+
 	t.Run("GetLatestTag_NoTags", func(t *testing.T) {
 		tag, err := client.GetLatestTag()
 		assert.NoError(t, err)
@@ -306,6 +402,19 @@ func TestWithTempGitRepo(t *testing.T) {
 		assert.Equal(t, "v0.1.0", tag)
 	})
 
+	t.Run("PushTag", func(t *testing.T) {
+		remote := t.TempDir()
+		require.NoError(t, exec.Command("git", "init", "--bare", "-q", remote).Run())
+
+		require.NoError(t, client.PushTag(remote, "v0.1.0"))
+		out, err := exec.Command("git", "-C", remote, "tag").Output()
+		require.NoError(t, err)
+		assert.Equal(t, "v0.1.0\n", string(out))
+
+		err = client.PushTag(remote, "v9.9.9")
+		assert.ErrorContains(t, err, "failed to push tag 'v9.9.9'")
+	})
+
 	t.Run("GetCommitsSinceTag_NoNewCommits", func(t *testing.T) {
 		commits, err := client.GetCommitsSinceTag("v0.1.0")
 		assert.NoError(t, err)
@@ -334,6 +443,58 @@ func TestWithTempGitRepo(t *testing.T) {
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, len(commits), 2)
 	})
+
+	t.Run("ComponentTags", func(t *testing.T) {
+		require.NoError(t, client.CreateAnnotatedTag("api/v0.1.0", "Release api/v0.1.0"))
+		require.NoError(t, os.MkdirAll("api", 0755))
+		require.NoError(t, os.WriteFile(filepath.Join("api", "handler.go"), []byte("package api"), 0644))
+		require.NoError(t, client.AddAll())
+		require.NoError(t, client.Commit("feat(api): add handler"))
+
+		tag, err := client.GetLatestTagWithPrefix("api/")
+		assert.NoError(t, err)
+		assert.Equal(t, "api/v0.1.0", tag)
+
+		tag, err = client.GetLatestTagWithPrefix("")
+		assert.NoError(t, err)
+		assert.Equal(t, "v0.1.0", tag, "the root prefix skips component tags")
+
+		commits, err := client.GetCommitsSinceTagInPath("api/v0.1.0", "api")
+		assert.NoError(t, err)
+		require.Len(t, commits, 1)
+		assert.Equal(t, "feat(api): add handler", commits[0].Message)
+
+		commits, err = client.GetCommitsSinceTagInPath("v0.1.0", "api")
+		assert.NoError(t, err)
+		assert.Len(t, commits, 1, "commits outside api are left out")
+	})
+
+	t.Run("CommitFiles_AuthorOverride", func(t *testing.T) {
+		require.NoError(t, os.WriteFile("pair.txt", []byte("pairing"), 0644))
+		require.NoError(t, client.StageFiles([]string{"pair.txt"}))
+
+		err := client.CommitFiles("chore: pair commit", []string{"pair.txt"},
+			"--author=Pair Partner <pair@example.com>", "--date=2024-01-02T03:04:05+0100")
+		require.NoError(t, err)
+
+		out, err := exec.Command("git", "log", "-1", "--format=%an <%ae> %aI").Output()
+		require.NoError(t, err)
+		assert.Equal(t, "Pair Partner <pair@example.com> 2024-01-02T03:04:05+01:00", strings.TrimSpace(string(out)))
+	})
+
+	t.Run("SigningConfig", func(t *testing.T) {
+		assert.Equal(t, SigningFormatOpenPGP, client.SigningConfig().Format)
+
+		require.NoError(t, exec.Command("git", "config", "commit.gpgsign", "yes").Run())
+		t.Cleanup(func() { _ = exec.Command("git", "config", "--unset", "commit.gpgsign").Run() })
+		require.NoError(t, exec.Command("git", "config", "gpg.format", "ssh").Run())
+		require.NoError(t, exec.Command("git", "config", "user.signingkey", "~/.ssh/id_ed25519.pub").Run())
+
+		signing := client.SigningConfig()
+		assert.True(t, signing.Enabled, "commit.gpgsign accepts any git boolean")
+		assert.Equal(t, SigningFormatSSH, signing.Format)
+		assert.Equal(t, "~/.ssh/id_ed25519.pub", signing.Key)
+	})
 }
 
 func TestResolveFilesIncludesUntracked(t *testing.T) {
@@ -374,6 +535,188 @@ func TestResolveFilesIncludesUntracked(t *testing.T) {
 	assert.Contains(t, files, "pkg/draft.txt")
 }
 
+func TestResolveFilesExpandsPathspecs(t *testing.T) {
+	client := NewClient(Options{})
+
+	tempDir, err := os.MkdirTemp("", "gmc_git_pathspec_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+	for _, file := range []string{"main.go", "src/a.go", "src/gen/b.go", "src/c.txt", "docs/guide.md", "gone.go"} {
+		path := filepath.Join(tempDir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(file), 0o644))
+	}
+	runGitCommand(t, tempDir, "add", ".")
+	runGitCommand(t, tempDir, "commit", "-m", "initial commit")
+	runGitCommand(t, tempDir, "rm", "-q", "gone.go")
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "src", "new.go"), []byte("new"), 0o644))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+
+	require.NoError(t, os.Chdir(tempDir))
+	AssertNotInRealRepo(t)
+
+	files, err := client.ResolveFiles([]string{"src/**/*.go"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"src/a.go", "src/gen/b.go", "src/new.go"}, files)
+
+	files, err = client.ResolveFiles([]string{"src", ":(exclude)src/gen"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"src/a.go", "src/c.txt", "src/new.go"}, files)
+
+	files, err = client.ResolveFiles([]string{"*.go"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main.go", "gone.go"}, files, "staged deletions match too")
+
+	files, err = client.ResolveFiles([]string{":!docs/", ":!src/"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main.go", "gone.go"}, files)
+
+	_, err = client.ResolveFiles([]string{"*.rs"})
+	assert.EqualError(t, err, "no files match *.rs")
+}
+
+func TestGetStagedDiffDetectsRenames(t *testing.T) {
+	client := NewClient(Options{})
+
+	tempDir, err := os.MkdirTemp("", "gmc_git_rename_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+	runGitCommand(t, tempDir, "config", "diff.renames", "false")
+
+	content := strings.Repeat("line of content\n", 20)
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "old.go"), []byte(content), 0644))
+	runGitCommand(t, tempDir, "add", ".")
+	runGitCommand(t, tempDir, "commit", "-m", "initial commit")
+	runGitCommand(t, tempDir, "mv", "old.go", "new.go")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+
+	require.NoError(t, os.Chdir(tempDir))
+	AssertNotInRealRepo(t)
+
+	diff, err := client.GetStagedDiff()
+	require.NoError(t, err)
+	assert.Contains(t, diff, "similarity index 100%")
+	assert.Contains(t, diff, "rename from old.go")
+	assert.Contains(t, diff, "rename to new.go")
+}
+
+func TestGetStagedChangesExcludePaths(t *testing.T) {
+	client := NewClient(Options{ExcludePaths: []string{"*.pb.go", "vendor"}})
+
+	tempDir, err := os.MkdirTemp("", "gmc_git_exclude_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init")
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "api", "v1"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "vendor", "lib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "api", "v1", "user.pb.go"), []byte("package v1\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "vendor", "lib", "lib.go"), []byte("package lib\n"), 0644))
+	runGitCommand(t, tempDir, "add", ".")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(filepath.Join(tempDir, "api")))
+	AssertNotInRealRepo(t)
+
+	// Only excluded files are staged, so the diff keeps them.
+	changes, err := client.GetStagedChanges()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"api/v1/user.pb.go", "vendor/lib/lib.go"}, changes.Files)
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644))
+	runGitCommand(t, tempDir, "add", ".")
+
+	changes, err = client.GetStagedChanges()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, changes.Files)
+	assert.NotContains(t, changes.Diff, "user.pb.go")
+
+	diff, err := client.GetFilesDiff([]string{"v1/user.pb.go", "../main.go"})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "main.go")
+	assert.NotContains(t, diff, "user.pb.go")
+}
+
+func TestStageSelection(t *testing.T) {
+	client := NewClient(Options{})
+
+	tempDir, err := os.MkdirTemp("", "gmc_git_hunks_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	file := filepath.Join(tempDir, "lines.txt")
+	write := func() {
+		require.NoError(t, os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	}
+	write()
+	runGitCommand(t, tempDir, "add", ".")
+	runGitCommand(t, tempDir, "commit", "-m", "initial commit")
+
+	lines[1] = "staged change"
+	write()
+	runGitCommand(t, tempDir, "add", ".")
+	lines[27] = "unstaged change"
+	write()
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+
+	require.NoError(t, os.Chdir(tempDir))
+	AssertNotInRealRepo(t)
+
+	staged, err := client.GetHunkDiff(true)
+	require.NoError(t, err)
+	unstaged, err := client.GetHunkDiff(false)
+	require.NoError(t, err)
+	assert.Contains(t, staged, "+staged change")
+	assert.Contains(t, unstaged, "+unstaged change")
+
+	err = client.StageSelection(unstaged, "not a patch\n")
+	assert.ErrorContains(t, err, "failed to unstage the selected hunks")
+	index, err := client.GetHunkDiff(true)
+	require.NoError(t, err)
+	assert.Equal(t, staged, index, "a failed selection restores the index")
+
+	require.NoError(t, client.StageSelection(unstaged, staged))
+	index, err = client.GetHunkDiff(true)
+	require.NoError(t, err)
+	assert.Contains(t, index, "+unstaged change")
+	assert.NotContains(t, index, "+staged change")
+}
+
 func runGitCommand(t *testing.T, dir string, args ...string) {
 	t.Helper()
 
@@ -517,3 +860,29 @@ func TestBranchValidation(t *testing.T) {
 	// These tests should ONLY run in isolated test environments
 	// See TestWithTempGitRepo for proper isolated testing of Commit function
 }
+
+func TestCommitMessageArgs(t *testing.T) {
+	assert.Equal(t, []string{"-m", "feat: x"}, commitMessageArgs("feat: x\n"))
+	assert.Equal(t, []string{"-m", "feat: x", "-m", "- a\n- b"}, commitMessageArgs("feat: x\n\n- a\n- b\n"))
+	assert.Equal(t, []string{"-m", "feat: x"}, commitMessageArgs("feat: x\n\n  \n"))
+}
+
+func TestInterpretTrailers(t *testing.T) {
+	client := NewClient(Options{})
+
+	message, err := client.InterpretTrailers("feat: add cache\n\n- warm on start", []string{
+		"Co-authored-by: Jane Doe <jane@example.com>",
+		"Refs: PROJ-12",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add cache\n\n- warm on start\n\n"+
+		"Co-authored-by: Jane Doe <jane@example.com>\nRefs: PROJ-12", message)
+
+	again, err := client.InterpretTrailers(message, []string{"Refs: PROJ-12", "Refs: PROJ-13"})
+	require.NoError(t, err)
+	assert.Equal(t, message+"\nRefs: PROJ-13", again, "trailers already present are not repeated")
+
+	unchanged, err := client.InterpretTrailers("fix: typo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: typo", unchanged)
+}
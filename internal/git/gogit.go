@@ -0,0 +1,557 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	goconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Backends for Options.Backend. BackendGoGit answers the read-only queries (where
+// the worktree is, the staged diff, file status, history and tags) with go-git, so
+// they work without a git binary. Commits, staging, notes and worktrees always run
+// git.
+const (
+	BackendNative = "native"
+	BackendGoGit  = "go-git"
+)
+
+// emptyBlob is the hash of an empty file.
+var emptyBlob = plumbing.NewHash("e69de29bb2d1d6434b8b29ae775ad8c2e48c5391")
+
+// goGitRepo is the repository around the current directory, opened on first use.
+type goGitRepo struct {
+	once sync.Once
+	repo *gogit.Repository
+	err  error
+}
+
+func (c *Client) goGitRepo() (*gogit.Repository, error) {
+	c.goGit.once.Do(func() {
+		dir := c.dir
+		if dir == "" {
+			dir = "."
+		}
+		c.goGit.repo, c.goGit.err = gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{
+			DetectDotGit:          true,
+			EnableDotGitCommonDir: true,
+		})
+	})
+	return c.goGit.repo, c.goGit.err
+}
+
+// goGitLocation finds the worktree root and the current directory relative to it, and
+// keeps them as the client's location.
+func (c *Client) goGitLocation() (*location, error) {
+	if c.location != nil {
+		return c.location, nil
+	}
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+	cwd, err := c.workingDir()
+	if err != nil {
+		return nil, err
+	}
+	if cwd, err = filepath.EvalSymlinks(cwd); err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+	if rel != "." {
+		prefix = filepath.ToSlash(rel) + "/"
+	}
+	c.location = &location{root: root, prefix: prefix}
+	return c.location, nil
+}
+
+// goGitBranch returns the short name of the branch HEAD points at, or "" when HEAD is
+// detached.
+func (c *Client) goGitBranch() (string, error) {
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the current branch: %w", err)
+	}
+	if head.Type() == plumbing.SymbolicReference && head.Target().IsBranch() {
+		return head.Target().Short(), nil
+	}
+	return "", nil
+}
+
+// goGitStagedChanges diffs the index against HEAD the way GetStagedChanges does, with
+// renames detected and one line of context. Unmerged and intent-to-add entries and
+// submodules are left out.
+func (c *Client) goGitStagedChanges() (StagedChanges, error) {
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return StagedChanges{}, err
+	}
+
+	head, err := goGitHeadEntries(repo)
+	if err != nil {
+		return StagedChanges{}, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return StagedChanges{}, fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	// Both sides of a change need a tree to load blobs through, and an empty one
+	// reads them from the object store like any other.
+	emptyTree := &plumbing.MemoryObject{}
+	emptyTree.SetType(plumbing.TreeObject)
+	blobs, err := object.DecodeTree(repo.Storer, emptyTree)
+	if err != nil {
+		return StagedChanges{}, err
+	}
+	entry := func(name string, e object.TreeEntry) object.ChangeEntry {
+		e.Name = filepath.Base(name)
+		return object.ChangeEntry{Name: name, Tree: blobs, TreeEntry: e}
+	}
+
+	var changes object.Changes
+	for _, e := range idx.Entries {
+		if e.Stage != 0 || e.IntentToAdd || e.Mode == filemode.Submodule {
+			continue
+		}
+		to := object.TreeEntry{Mode: e.Mode, Hash: e.Hash}
+		from, ok := head[e.Name]
+		switch {
+		case !ok:
+			changes = append(changes, &object.Change{To: entry(e.Name, to)})
+		case from.Mode != to.Mode || from.Hash != to.Hash:
+			changes = append(changes, &object.Change{From: entry(e.Name, from), To: entry(e.Name, to)})
+		}
+	}
+	// What is left of HEAD once every index path is taken out was deleted.
+	for _, e := range idx.Entries {
+		delete(head, e.Name)
+	}
+	for name, from := range head {
+		if from.Mode != filemode.Submodule {
+			changes = append(changes, &object.Change{From: entry(name, from)})
+		}
+	}
+
+	if changes, err = object.DetectRenames(changes, nil); err != nil {
+		return StagedChanges{}, fmt.Errorf("failed to detect renames: %w", err)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changePath(changes[i]) < changePath(changes[j])
+	})
+	patch, err := changes.Patch()
+	if err != nil {
+		return StagedChanges{}, fmt.Errorf("failed to diff the index: %w", err)
+	}
+
+	var diff bytes.Buffer
+	if err := fdiff.NewUnifiedEncoder(&diff, 1).Encode(patch); err != nil {
+		return StagedChanges{}, fmt.Errorf("failed to format the staged diff: %w", err)
+	}
+
+	var numstat, summary strings.Builder
+	var files []string
+	for i, filePatch := range patch.FilePatches() {
+		change := changes[i]
+		name := changePath(change)
+		numstatName := name
+		switch {
+		case change.From.Name == "":
+			fmt.Fprintf(&summary, " create mode %o %s\n", uint32(change.To.TreeEntry.Mode), name)
+		case change.To.Name == "":
+			fmt.Fprintf(&summary, " delete mode %o %s\n", uint32(change.From.TreeEntry.Mode), name)
+		case change.From.Name != change.To.Name:
+			numstatName = change.From.Name + " => " + change.To.Name
+			fmt.Fprintf(&summary, " rename %s\n", numstatName)
+		}
+		if change.From.Name != "" && change.To.Name != "" && change.From.TreeEntry.Mode != change.To.TreeEntry.Mode {
+			fmt.Fprintf(&summary, " mode change %o => %o %s\n",
+				uint32(change.From.TreeEntry.Mode), uint32(change.To.TreeEntry.Mode), name)
+		}
+
+		added, removed := "-", "-"
+		if !filePatch.IsBinary() || isEmptyBlob(change.From) && isEmptyBlob(change.To) {
+			lines := countPatchLines(filePatch)
+			added, removed = fmt.Sprint(lines[fdiff.Add]), fmt.Sprint(lines[fdiff.Delete])
+		}
+		fmt.Fprintf(&numstat, "%s\t%s\t%s\n", added, removed, numstatName)
+		files = append(files, name)
+	}
+
+	return StagedChanges{Diff: diff.String(), Stats: numstat.String() + summary.String(), Files: files}, nil
+}
+
+// goGitHeadEntries lists the files in the HEAD commit by path, or none on an unborn
+// branch.
+func goGitHeadEntries(repo *gogit.Repository) (map[string]object.TreeEntry, error) {
+	entries := make(map[string]object.TreeEntry)
+	ref, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.Mode != filemode.Dir {
+			entries[name] = entry
+		}
+	}
+}
+
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+// isEmptyBlob reports whether a change side is missing or an empty file, which go-git
+// reports as binary because the diff has no chunks.
+func isEmptyBlob(entry object.ChangeEntry) bool {
+	return entry.Name == "" || entry.TreeEntry.Hash == emptyBlob
+}
+
+func countPatchLines(filePatch fdiff.FilePatch) map[fdiff.Operation]int {
+	lines := make(map[fdiff.Operation]int)
+	for _, chunk := range filePatch.Chunks() {
+		content := chunk.Content()
+		if content == "" {
+			continue
+		}
+		lines[chunk.Type()] += strings.Count(content, "\n")
+		if !strings.HasSuffix(content, "\n") {
+			lines[chunk.Type()]++
+		}
+	}
+	return lines
+}
+
+// goGitFileStates reads the status of the given repository paths, the same as
+// parseStatusV2 does for git status. A path that is neither in the index nor changed
+// but exists on disk is ignored, and counts as untracked.
+func (c *Client) goGitFileStates(root string, paths []string) (map[string]fileState, error) {
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check file status: %w", err)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+	tracked := make(map[string]bool, len(idx.Entries))
+	for _, e := range idx.Entries {
+		tracked[e.Name] = true
+	}
+
+	states := make(map[string]fileState)
+	for _, name := range paths {
+		fileStatus, ok := status[name]
+		switch {
+		case ok && fileStatus.Staging == gogit.Untracked:
+			states[name] = fileState{untracked: true}
+		case ok:
+			states[name] = fileState{
+				staged:   fileStatus.Staging != gogit.Unmodified,
+				modified: fileStatus.Worktree != gogit.Unmodified,
+			}
+		case !tracked[name]:
+			if _, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name))); err == nil {
+				states[name] = fileState{untracked: true}
+			}
+		}
+	}
+	return states, nil
+}
+
+// goGitLog walks the history from HEAD newest first, as git log does, until visit
+// returns false.
+func (c *Client) goGitLog(visit func(*object.Commit) bool) error {
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return err
+	}
+	ref, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to run git log: %w", err)
+	}
+	commits, err := repo.Log(&gogit.LogOptions{From: ref.Hash(), Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return fmt.Errorf("failed to run git log: %w", err)
+	}
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if !visit(commit) {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run git log: %w", err)
+	}
+	return nil
+}
+
+// goGitCommitHistory is GetCommitHistory, limited to commits whose "name <email>"
+// matches author unless it is empty.
+func (c *Client) goGitCommitHistory(limit int, author string) ([]CommitInfo, error) {
+	var pattern *regexp.Regexp
+	if author != "" {
+		var err error
+		if pattern, err = regexp.Compile(author); err != nil {
+			return nil, fmt.Errorf("invalid author pattern %q: %w", author, err)
+		}
+	}
+
+	mailmap := c.goGitMailmap()
+	commits := []CommitInfo{}
+	err := c.goGitLog(func(commit *object.Commit) bool {
+		if len(commits) >= limit {
+			return false
+		}
+		if pattern == nil || pattern.MatchString(commit.Author.String()) {
+			subject, _ := splitCommitMessage(commit.Message)
+			name, email := mailmap.resolve(commit.Author.Name, commit.Author.Email)
+			commits = append(commits, CommitInfo{
+				Hash:    commit.Hash.String()[:7],
+				Author:  name,
+				Email:   email,
+				Date:    commit.Author.When.Format(time.DateOnly),
+				Message: subject,
+			})
+		}
+		return true
+	})
+	return commits, err
+}
+
+// goGitCommitsSinceTag is GetCommitsSinceTag.
+func (c *Client) goGitCommitsSinceTag(tag string) ([]CommitInfo, error) {
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	if tag != "" {
+		if ref, err := repo.Tag(tag); err == nil {
+			since, err := repo.ResolveRevision(plumbing.Revision(ref.Name().String() + "^{commit}"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to verify tag %s: %w", tag, err)
+			}
+			reachable, err := repo.Log(&gogit.LogOptions{From: *since})
+			if err != nil {
+				return nil, fmt.Errorf("failed to run git log: %w", err)
+			}
+			_ = reachable.ForEach(func(commit *object.Commit) error {
+				seen[commit.Hash] = true
+				return nil
+			})
+		}
+	}
+
+	commits := []CommitInfo{}
+	err = c.goGitLog(func(commit *object.Commit) bool {
+		if !seen[commit.Hash] {
+			subject, body := splitCommitMessage(commit.Message)
+			commits = append(commits, CommitInfo{
+				Hash:    commit.Hash.String(),
+				Author:  commit.Author.Name,
+				Date:    commit.Author.When.Format(time.DateOnly),
+				Message: subject,
+				Body:    strings.TrimSpace(body),
+			})
+		}
+		return true
+	})
+	return commits, err
+}
+
+// goGitRecentCommitFiles is GetRecentCommitFiles.
+func (c *Client) goGitRecentCommitFiles(limit int) ([]CommitFiles, error) {
+	var commits []CommitFiles
+	var diffErr error
+	err := c.goGitLog(func(commit *object.Commit) bool {
+		if len(commits) >= limit {
+			return false
+		}
+		if commit.NumParents() > 1 {
+			return true
+		}
+
+		files, err := commitFiles(commit)
+		if err != nil {
+			diffErr = fmt.Errorf("failed to diff commit %s: %w", commit.Hash, err)
+			return false
+		}
+		subject, _ := splitCommitMessage(commit.Message)
+		commits = append(commits, CommitFiles{Hash: commit.Hash.String()[:7], Subject: subject, Files: files})
+		return true
+	})
+	if err == nil {
+		err = diffErr
+	}
+	return commits, err
+}
+
+// commitFiles returns the paths commit changed against its parent, with renames
+// reported under the new path.
+func commitFiles(commit *object.Commit) ([]string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() == 1 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, changePath(change))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// goGitLatestTag is latestTag: the tag created last that match accepts, by the tagger
+// date of an annotated tag or the committer date of a lightweight one.
+func (c *Client) goGitLatestTag(match func(tag string) bool) (string, error) {
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return "", err
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	type createdTag struct {
+		name    string
+		created time.Time
+	}
+	var tags []createdTag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tag := createdTag{name: ref.Name().Short()}
+		if !match(tag.name) {
+			return nil
+		}
+		if annotated, err := repo.TagObject(ref.Hash()); err == nil {
+			tag.created = annotated.Tagger.When
+		} else if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+			tag.created = commit.Committer.When
+		}
+		tags = append(tags, tag)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return "", nil
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if !tags[i].created.Equal(tags[j].created) {
+			return tags[i].created.After(tags[j].created)
+		}
+		return tags[i].name < tags[j].name
+	})
+	return tags[0].name, nil
+}
+
+// goGitUserName returns user.name from the repository and global git config.
+func (c *Client) goGitUserName() (string, error) {
+	repo, err := c.goGitRepo()
+	if err != nil {
+		return "", err
+	}
+	cfg, err := repo.ConfigScoped(goconfig.GlobalScope)
+	if err != nil {
+		return "", fmt.Errorf("failed to get git user name: %w", err)
+	}
+	if cfg.User.Name == "" {
+		return "", errors.New("failed to get git user name: user.name is not set")
+	}
+	return cfg.User.Name, nil
+}
+
+// splitCommitMessage splits a commit message into git's %s and %b: the first
+// paragraph on one line, and the rest.
+func splitCommitMessage(message string) (string, string) {
+	subject, body, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n\n")
+	lines := strings.Split(strings.TrimSpace(subject), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, " "), body
+}
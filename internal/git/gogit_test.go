@@ -0,0 +1,159 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoGitBackendMatchesNative(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gmc_git_gogit_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	write := func(name, content string) {
+		file := filepath.Join(tempDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+
+	runGitCommand(t, tempDir, "init", "-b", "main")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+	runGitCommand(t, tempDir, "config", "commit.gpgsign", "false")
+	runGitCommand(t, tempDir, "config", "tag.gpgsign", "false")
+
+	write("keep.txt", "one\ntwo\nthree\nfour\nfive\n")
+	write("gone.txt", "bye\n")
+	write("pkg/old.go", "package pkg\n\nfunc Old() int {\n\treturn 1\n}\n")
+	write("run.sh", "echo hi\n")
+	write(".gitignore", "*.log\n")
+	runGitCommand(t, tempDir, "add", ".")
+	runGitCommand(t, tempDir, "commit", "-q", "-m", "feat: first commit", "-m", "With a body.")
+	runGitCommand(t, tempDir, "tag", "-a", "v0.1.0", "-m", "v0.1.0")
+
+	write("keep.txt", "one\ntwo\nTHREE\nfour\nfive\n")
+	runGitCommand(t, tempDir, "commit", "-q", "-am", "fix: shout\nthe third line")
+
+	write("keep.txt", "one\ntwo\nTHREE\nfour\nfive\nsix\n")
+	write("new.txt", "")
+	write("logo.png", "\x89PNG\x00\x01")
+	write("unstaged.txt", "untracked\n")
+	write("debug.log", "ignored\n")
+	require.NoError(t, os.Remove(filepath.Join(tempDir, "gone.txt")))
+	runGitCommand(t, tempDir, "mv", "pkg/old.go", "pkg/new.go")
+	runGitCommand(t, tempDir, "update-index", "--chmod=+x", "run.sh")
+	runGitCommand(t, tempDir, "add", "keep.txt", "new.txt", "logo.png", "gone.txt")
+	write("run.sh", "echo changed\n")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(filepath.Join(tempDir, "pkg")))
+	AssertNotInRealRepo(t)
+
+	native, err := OpenRepo(Options{})
+	require.NoError(t, err)
+	goGit, err := OpenRepo(Options{Backend: BackendGoGit})
+	require.NoError(t, err)
+	assert.Equal(t, native.location, goGit.location)
+
+	nativeBranch, err := native.CurrentBranch()
+	require.NoError(t, err)
+	goGitBranch, err := goGit.CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, nativeBranch, goGitBranch)
+
+	nativeChanges, err := native.GetStagedChanges()
+	require.NoError(t, err)
+	goGitChanges, err := goGit.GetStagedChanges()
+	require.NoError(t, err)
+	assert.Equal(t, nativeChanges.Files, goGitChanges.Files)
+	assert.Equal(t, numstatLines(nativeChanges.Stats), numstatLines(goGitChanges.Stats))
+	for _, header := range []string{
+		"diff --git a/keep.txt b/keep.txt", "new mode 100755", "rename from pkg/old.go", "Binary files",
+	} {
+		assert.Contains(t, goGitChanges.Diff, header)
+	}
+
+	files := []string{"new.go", "../run.sh", "../unstaged.txt", "../debug.log", "../keep.txt", "../.gitignore"}
+	for _, client := range []*Repo{native, goGit} {
+		staged, modified, untracked, err := client.CheckFileStatus(files)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new.go", "../run.sh", "../keep.txt"}, staged)
+		assert.Empty(t, modified)
+		assert.Equal(t, []string{"../unstaged.txt", "../debug.log"}, untracked)
+	}
+
+	nativeHistory, err := native.GetCommitHistory(10, false)
+	require.NoError(t, err)
+	goGitHistory, err := goGit.GetCommitHistory(10, false)
+	require.NoError(t, err)
+	assert.Equal(t, nativeHistory, goGitHistory)
+
+	nativeSince, err := native.GetCommitsSinceTag("v0.1.0")
+	require.NoError(t, err)
+	goGitSince, err := goGit.GetCommitsSinceTag("v0.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, nativeSince, goGitSince)
+	require.Len(t, goGitSince, 1)
+	assert.Equal(t, "fix: shout the third line", goGitSince[0].Message)
+
+	nativeAll, err := native.GetCommitsSinceTag("")
+	require.NoError(t, err)
+	goGitAll, err := goGit.GetCommitsSinceTag("")
+	require.NoError(t, err)
+	assert.Equal(t, nativeAll, goGitAll)
+
+	nativeFiles, err := native.GetRecentCommitFiles(5)
+	require.NoError(t, err)
+	goGitFiles, err := goGit.GetRecentCommitFiles(5)
+	require.NoError(t, err)
+	assert.Equal(t, nativeFiles, goGitFiles)
+
+	tag, err := goGit.GetLatestTag()
+	require.NoError(t, err)
+	assert.Equal(t, "v0.1.0", tag)
+}
+
+func TestGoGitBackendOutsideRepo(t *testing.T) {
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	client := NewClient(Options{Backend: BackendGoGit})
+	if client.IsGitRepository() {
+		t.Skip("the temporary directory is inside a git repository")
+	}
+	assert.ErrorIs(t, client.CheckGitRepository(), ErrNotGitRepo)
+	_, err = OpenRepo(Options{Backend: BackendGoGit})
+	assert.ErrorIs(t, err, ErrNotGitRepo)
+}
+
+func TestSplitCommitMessage(t *testing.T) {
+	subject, body := splitCommitMessage("\nfeat: add x\n  across lines\n\nBody line.\n\nMore.\n")
+	assert.Equal(t, "feat: add x across lines", subject)
+	assert.Equal(t, "Body line.\n\nMore.\n", body)
+}
+
+// numstatLines returns the numstat part of a --numstat --summary block with rename
+// paths reduced to the new path, since git abbreviates them with braces.
+func numstatLines(stats string) []string {
+	var lines []string
+	for _, line := range strings.Split(stats, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) == 3 {
+			lines = append(lines, fields[0]+"\t"+fields[1]+"\t"+renamedPath(fields[2]))
+		}
+	}
+	return lines
+}
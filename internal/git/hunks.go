@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// GetHunkDiff returns the unstaged diff, or the staged diff when cached is set, in a
+// form git apply accepts back: full context, binary patches and no rename detection.
+func (c *Client) GetHunkDiff(cached bool) (string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return "", err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--binary", "--no-renames"}
+	if cached {
+		args = append(args, "--cached")
+	}
+	result, err := c.runner.RunLogged(args...)
+	if err != nil {
+		return "", gitutil.WrapGitError("failed to run git diff", result, err)
+	}
+	return string(result.Stdout), nil
+}
+
+// StageSelection applies stage to the index, then unstage in reverse. Both are patches
+// built from GetHunkDiff output; either may be empty. When a patch does not apply, the
+// index is restored to its previous state.
+func (c *Client) StageSelection(stage string, unstage string) error {
+	if err := c.CheckGitRepository(); err != nil {
+		return err
+	}
+
+	result, err := c.runner.Run("write-tree")
+	if err != nil {
+		return gitutil.WrapGitError("failed to save the index", result, err)
+	}
+	tree := result.StdoutString(true)
+
+	if err := c.applyCached(stage, false); err != nil {
+		return c.restoreIndex(tree, err)
+	}
+	if err := c.applyCached(unstage, true); err != nil {
+		return c.restoreIndex(tree, err)
+	}
+	return nil
+}
+
+func (c *Client) applyCached(patch string, reverse bool) error {
+	if patch == "" {
+		return nil
+	}
+
+	file, err := os.CreateTemp("", "gmc-*.patch")
+	if err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(patch); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+
+	args := []string{"apply", "--cached"}
+	action := "stage"
+	if reverse {
+		args = append(args, "-R")
+		action = "unstage"
+	}
+	result, err := c.runner.RunLogged(append(args, file.Name())...)
+	if err != nil {
+		return gitutil.WrapGitError("failed to "+action+" the selected hunks", result, err)
+	}
+	return nil
+}
+
+func (c *Client) restoreIndex(tree string, cause error) error {
+	if result, err := c.runner.Run("read-tree", tree); err != nil {
+		return fmt.Errorf("%w; restoring the index also failed: %w",
+			cause, gitutil.WrapGitError("git read-tree", result, err))
+	}
+	return cause
+}
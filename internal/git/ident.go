@@ -0,0 +1,86 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// Ident is a git author identity with the date it applies to.
+type Ident struct {
+	Name  string
+	Email string
+	Date  time.Time
+}
+
+func (i Ident) String() string {
+	return fmt.Sprintf("%s <%s>, %s", i.Name, i.Email, i.Date.Format("2006-01-02 15:04:05 -0700"))
+}
+
+var authorPattern = regexp.MustCompile(`^\s*([^<>]*?)\s*<([^<>]*)>\s*$`)
+
+// ParseAuthor splits an "A U Thor <author@example.com>" override into name and email.
+func ParseAuthor(author string) (string, string, error) {
+	matches := authorPattern.FindStringSubmatch(author)
+	if matches == nil || matches[1] == "" || matches[2] == "" {
+		return "", "", fmt.Errorf("invalid author %q: use the form 'Name <email>'", author)
+	}
+	return matches[1], matches[2], nil
+}
+
+// AuthorIdent resolves the author identity a commit would get with the given --author
+// and --date overrides, on top of user.name, user.email and GIT_AUTHOR_*. Empty
+// overrides are left to git. It fails when git cannot work out an identity or does not
+// accept date.
+func (c *Client) AuthorIdent(author, date string) (Ident, error) {
+	runner := c.runner
+	runner.Env = append([]string(nil), runner.Env...)
+	if author != "" {
+		name, email, err := ParseAuthor(author)
+		if err != nil {
+			return Ident{}, err
+		}
+		runner.Env = append(runner.Env, "GIT_AUTHOR_NAME="+name, "GIT_AUTHOR_EMAIL="+email)
+	}
+	if date != "" {
+		runner.Env = append(runner.Env, "GIT_AUTHOR_DATE="+date)
+	}
+
+	result, err := runner.Run("var", "GIT_AUTHOR_IDENT")
+	if err != nil {
+		return Ident{}, gitutil.WrapGitError("Failed to resolve author identity", result, err)
+	}
+	return parseIdent(result.StdoutString(true))
+}
+
+// parseIdent parses git's "Name <email> 1700000000 +0100" identity line.
+func parseIdent(line string) (Ident, error) {
+	nameEmail, stamp, ok := strings.Cut(line, "> ")
+	fields := strings.Fields(stamp)
+	if !ok || len(fields) != 2 {
+		return Ident{}, fmt.Errorf("unexpected git identity %q", line)
+	}
+	name, email, err := ParseAuthor(nameEmail + ">")
+	if err != nil {
+		return Ident{}, fmt.Errorf("unexpected git identity %q", line)
+	}
+
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Ident{}, fmt.Errorf("unexpected git identity date %q", stamp)
+	}
+	offset, err := time.Parse("-0700", fields[1])
+	if err != nil {
+		return Ident{}, fmt.Errorf("unexpected git identity time zone %q", fields[1])
+	}
+	_, zone := offset.Zone()
+	return Ident{
+		Name:  name,
+		Email: email,
+		Date:  time.Unix(seconds, 0).In(time.FixedZone("", zone)),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthor(t *testing.T) {
+	name, email, err := ParseAuthor("  A U Thor <author@example.com> ")
+	require.NoError(t, err)
+	assert.Equal(t, "A U Thor", name)
+	assert.Equal(t, "author@example.com", email)
+
+	for _, author := range []string{"A U Thor", "<author@example.com>", "A <>", "A <b> <c>"} {
+		_, _, err := ParseAuthor(author)
+		assert.Error(t, err, author)
+	}
+}
+
+func TestParseIdent(t *testing.T) {
+	ident, err := parseIdent("A U Thor <author@example.com> 1704161045 +0100")
+	require.NoError(t, err)
+	assert.Equal(t, "A U Thor", ident.Name)
+	assert.Equal(t, "author@example.com", ident.Email)
+	assert.True(t, ident.Date.Equal(time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)))
+	assert.Equal(t, "A U Thor <author@example.com>, 2024-01-02 03:04:05 +0100", ident.String())
+
+	_, err = parseIdent("A U Thor <author@example.com>")
+	assert.Error(t, err)
+}
+
+func TestAuthorIdentOverrides(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Env Author")
+	t.Setenv("GIT_AUTHOR_EMAIL", "env@example.com")
+	t.Setenv("GIT_AUTHOR_DATE", "@1700000000 +0000")
+	client := NewClient(Options{})
+
+	ident, err := client.AuthorIdent("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Env Author <env@example.com>, 2023-11-14 22:13:20 +0000", ident.String())
+
+	ident, err = client.AuthorIdent("Flag Author <flag@example.com>", "2024-01-02T03:04:05+0100")
+	require.NoError(t, err)
+	assert.Equal(t, "Flag Author <flag@example.com>, 2024-01-02 03:04:05 +0100", ident.String())
+
+	_, err = client.AuthorIdent("", "next tuesday")
+	assert.ErrorContains(t, err, "invalid date format")
+	_, err = client.AuthorIdent("nobody", "")
+	assert.ErrorContains(t, err, "Name <email>")
+}
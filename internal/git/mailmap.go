@@ -0,0 +1,108 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mailmap maps the names and emails authors committed with to the ones they go by, as
+// git log --use-mailmap does. go-git does not read .mailmap, so the go-git backend
+// applies it with this.
+type mailmap struct {
+	// byEmail holds the entries that match any commit name, byNameEmail the ones that
+	// also name the commit name. Keys are lower case: git matches both without case.
+	byEmail     map[string]mailmapIdentity
+	byNameEmail map[string]mailmapIdentity
+}
+
+// mailmapIdentity is the proper name and email of an entry. Either may be empty, which
+// keeps the one of the commit.
+type mailmapIdentity struct {
+	name  string
+	email string
+}
+
+// parseMailmap reads the lines of a .mailmap file:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func parseMailmap(data string) mailmap {
+	m := mailmap{byEmail: map[string]mailmapIdentity{}, byNameEmail: map[string]mailmapIdentity{}}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		properName, properEmail, rest, ok := cutMailmapIdentity(line)
+		if !ok {
+			continue
+		}
+		commitName, commitEmail, _, ok := cutMailmapIdentity(rest)
+		if !ok {
+			// Proper Name <commit@email>
+			if properName != "" {
+				m.byEmail[strings.ToLower(properEmail)] = mailmapIdentity{name: properName}
+			}
+			continue
+		}
+		identity := mailmapIdentity{name: properName, email: properEmail}
+		if commitName == "" {
+			m.byEmail[strings.ToLower(commitEmail)] = identity
+		} else {
+			m.byNameEmail[mailmapKey(commitName, commitEmail)] = identity
+		}
+	}
+	return m
+}
+
+// cutMailmapIdentity cuts "Name <email>" from the start of s, and returns what follows.
+func cutMailmapIdentity(s string) (name, email, rest string, ok bool) {
+	before, after, found := strings.Cut(s, "<")
+	if !found {
+		return "", "", "", false
+	}
+	email, rest, found = strings.Cut(after, ">")
+	if !found {
+		return "", "", "", false
+	}
+	return strings.TrimSpace(before), strings.TrimSpace(email), rest, true
+}
+
+func mailmapKey(name, email string) string {
+	return strings.ToLower(name) + "\x00" + strings.ToLower(email)
+}
+
+// resolve returns the name and email that name and email are mapped to.
+func (m mailmap) resolve(name, email string) (string, string) {
+	identity, ok := m.byNameEmail[mailmapKey(name, email)]
+	if !ok {
+		identity, ok = m.byEmail[strings.ToLower(email)]
+	}
+	if !ok {
+		return name, email
+	}
+	if identity.name != "" {
+		name = identity.name
+	}
+	if identity.email != "" {
+		email = identity.email
+	}
+	return name, email
+}
+
+// goGitMailmap reads the .mailmap at the root of the worktree. Without one, nothing is
+// mapped.
+func (c *Client) goGitMailmap() mailmap {
+	loc, err := c.goGitLocation()
+	if err != nil {
+		return parseMailmap("")
+	}
+	data, err := os.ReadFile(filepath.Join(loc.root, ".mailmap"))
+	if err != nil {
+		return parseMailmap("")
+	}
+	return parseMailmap(string(data))
+}
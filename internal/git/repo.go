@@ -0,0 +1,106 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Repo is a Client for the worktree gmc runs in. It asks git once for what does not
+// change during a run: that the directory is a worktree and where its root is, the
+// current branch, the commit signing settings and the author identity. Its methods
+// skip the repository check that every Client call otherwise starts with.
+type Repo struct {
+	*Client
+
+	mu      sync.Mutex
+	branch  *string
+	signing *SigningConfig
+	idents  map[[2]string]Ident
+}
+
+// OpenRepo checks that the current directory is inside a git worktree and returns a
+// Repo for it.
+func OpenRepo(opts Options) (*Repo, error) {
+	client := NewClient(opts)
+	if client.goGit != nil {
+		if _, err := client.goGitLocation(); err != nil {
+			return nil, fmt.Errorf("%w: please run this command inside a git working tree", ErrNotGitRepo)
+		}
+		return &Repo{Client: client}, nil
+	}
+	result, err := client.runner.Run("rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("%w: please run this command inside a git working tree", ErrNotGitRepo)
+	}
+
+	lines := strings.Split(result.StdoutString(false), "\n")
+	if len(lines) < 2 || lines[0] == "" {
+		return nil, fmt.Errorf("%w: please run this command inside a git working tree", ErrNotGitRepo)
+	}
+	client.location = &location{root: lines[0], prefix: lines[1]}
+	return &Repo{Client: client}, nil
+}
+
+// Root returns the top-level directory of the worktree.
+func (r *Repo) Root() string {
+	return r.location.root
+}
+
+// CurrentBranch returns the short name of the checked out branch, or "" when HEAD is
+// detached.
+func (r *Repo) CurrentBranch() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.branch == nil {
+		branch, err := r.Client.currentBranch()
+		if err != nil {
+			return "", err
+		}
+		r.branch = &branch
+	}
+	return *r.branch, nil
+}
+
+// CreateAndSwitchBranch creates and checks out branchName, and remembers it as the
+// current branch.
+func (r *Repo) CreateAndSwitchBranch(branchName string) error {
+	if err := r.Client.CreateAndSwitchBranch(branchName); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.branch = &branchName
+	r.mu.Unlock()
+	return nil
+}
+
+// SigningConfig is Client.SigningConfig, read once.
+func (r *Repo) SigningConfig() SigningConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.signing == nil {
+		signing := r.Client.SigningConfig()
+		r.signing = &signing
+	}
+	return *r.signing
+}
+
+// AuthorIdent is Client.AuthorIdent, read once for each author and date.
+func (r *Repo) AuthorIdent(author, date string) (Ident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]string{author, date}
+	if ident, ok := r.idents[key]; ok {
+		return ident, nil
+	}
+
+	ident, err := r.Client.AuthorIdent(author, date)
+	if err != nil {
+		return Ident{}, err
+	}
+	if r.idents == nil {
+		r.idents = make(map[[2]string]Ident)
+	}
+	r.idents[key] = ident
+	return ident, nil
+}
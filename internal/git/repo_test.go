@@ -0,0 +1,120 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStagedChanges(t *testing.T) {
+	output := "1\t0\tn.txt\n" +
+		"0\t0\told.txt => new.txt\n" +
+		"2\t1\tpkg/{util => helpers}/x.go\n" +
+		"1\t1\tsrc/{ => nested}/y.go\n" +
+		"-\t-\tlogo.png\n" +
+		" create mode 100644 n.txt\n" +
+		" rename old.txt => new.txt (100%)\n" +
+		"\n" +
+		"diff --git a/n.txt b/n.txt\nnew file mode 100644\n"
+
+	changes := parseStagedChanges(output)
+	assert.Equal(t, "diff --git a/n.txt b/n.txt\nnew file mode 100644\n", changes.Diff)
+	assert.Equal(t, output[:len(output)-len(changes.Diff)-1], changes.Stats)
+	assert.Equal(t, []string{"n.txt", "new.txt", "pkg/helpers/x.go", "src/nested/y.go", "logo.png"}, changes.Files)
+
+	assert.Equal(t, StagedChanges{}, parseStagedChanges(""))
+
+	stats := ParseNumstat(changes.Stats)
+	require.Len(t, stats, 5)
+	assert.Equal(t, FileStat{Path: "pkg/helpers/x.go", Added: 2, Removed: 1}, stats[2])
+	assert.Equal(t, FileStat{Path: "logo.png", Binary: true}, stats[4])
+}
+
+func TestParseSigningConfig(t *testing.T) {
+	assert.Equal(t, SigningConfig{Format: SigningFormatOpenPGP}, parseSigningConfig(nil))
+
+	output := "commit.gpgsign\nfalse\x00commit.gpgsign\x00gpg.format\nssh\x00" +
+		"user.signingkey\n~/.ssh/id_ed25519.pub\x00gpg.ssh.defaultkeycommand\nssh-add -L\x00"
+	assert.Equal(t, SigningConfig{
+		Enabled:       true,
+		Format:        SigningFormatSSH,
+		Key:           "~/.ssh/id_ed25519.pub",
+		SSHKeyCommand: "ssh-add -L",
+	}, parseSigningConfig([]byte(output)), "a later entry and a key without a value win")
+
+	for value, want := range map[string]bool{"yes": true, "On": true, "1": true, "0": false, "off": false, "": false} {
+		assert.Equal(t, want, parseGitBool(value, true), value)
+	}
+}
+
+func TestOpenRepo(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gmc_git_repo_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init", "-b", "main")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+	runGitCommand(t, tempDir, "config", "commit.gpgsign", "false")
+
+	sub := filepath.Join(tempDir, "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "a.txt"), []byte("one\n"), 0o644))
+	runGitCommand(t, tempDir, "add", ".")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+
+	require.NoError(t, os.Chdir(sub))
+	AssertNotInRealRepo(t)
+
+	repo, err := OpenRepo(Options{})
+	require.NoError(t, err)
+	root, err := filepath.EvalSymlinks(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, root, repo.Root())
+	assert.True(t, repo.IsGitRepository())
+
+	branch, err := repo.CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch, "an unborn branch still has a name")
+
+	changes, err := repo.GetStagedChanges()
+	require.NoError(t, err)
+	diff, err := repo.GetStagedDiff()
+	require.NoError(t, err)
+	stats, err := repo.GetStagedDiffStats()
+	require.NoError(t, err)
+	files, err := repo.ParseStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, StagedChanges{Diff: diff, Stats: stats, Files: files}, changes)
+
+	staged, _, _, err := repo.CheckFileStatus([]string{"a.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, staged, "paths are relative to the current directory")
+
+	ident, err := repo.AuthorIdent("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "gmc tester", ident.Name)
+	runGitCommand(t, tempDir, "config", "user.name", "someone else")
+	ident, err = repo.AuthorIdent("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "gmc tester", ident.Name, "the identity is read once")
+
+	runGitCommand(t, tempDir, "commit", "-q", "-m", "initial commit")
+	require.NoError(t, repo.CreateAndSwitchBranch("feature/x"))
+	branch, err = repo.CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "feature/x", branch)
+
+	require.NoError(t, os.Chdir(os.TempDir()))
+	if _, err := OpenRepo(Options{}); err != nil {
+		assert.ErrorIs(t, err, ErrNotGitRepo)
+	}
+}
@@ -0,0 +1,246 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
+	"github.com/samzong/gmc/internal/stringsutil"
+)
+
+// RangeCommit is a commit of a revision range, with what RewriteMessages needs to
+// recreate it.
+type RangeCommit struct {
+	Hash    string
+	Tree    string
+	Parents []string
+	Author  string
+	Date    string
+	Message string
+	// env carries the author and committer identities and dates into commit-tree.
+	env []string
+}
+
+// Subject returns the first line of the commit message.
+func (c RangeCommit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return strings.TrimSpace(subject)
+}
+
+// rangeCommitFormat prints the fields of a RangeCommit, one commit per record.
+const rangeCommitFormat = "%H%x1f%T%x1f%P%x1f%an%x1f%ae%x1f%ad%x1f%cn%x1f%ce%x1f%cd%x1f%as%x1f%B%x1e"
+
+// GetRangeCommits lists the commits of revRange, such as "main..HEAD", oldest first
+// with every parent before its children.
+func (c *Client) GetRangeCommits(revRange string) ([]RangeCommit, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return nil, err
+	}
+
+	result, err := c.runner.RunLogged("log", "--reverse", "--topo-order", "--date=raw",
+		"--format="+rangeCommitFormat, revRange, "--")
+	if err != nil {
+		return nil, gitutil.WrapGitError(fmt.Sprintf("failed to list the commits of %s", revRange), result, err)
+	}
+	return parseRangeCommits(result.StdoutString(false)), nil
+}
+
+func parseRangeCommits(output string) []RangeCommit {
+	var commits []RangeCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) != 11 {
+			continue
+		}
+		commits = append(commits, RangeCommit{
+			Hash:    fields[0],
+			Tree:    fields[1],
+			Parents: strings.Fields(fields[2]),
+			Author:  fields[3],
+			Date:    fields[9],
+			Message: strings.TrimRight(fields[10], "\n"),
+			env: []string{
+				"GIT_AUTHOR_NAME=" + fields[3],
+				"GIT_AUTHOR_EMAIL=" + fields[4],
+				"GIT_AUTHOR_DATE=" + fields[5],
+				"GIT_COMMITTER_NAME=" + fields[6],
+				"GIT_COMMITTER_EMAIL=" + fields[7],
+				"GIT_COMMITTER_DATE=" + fields[8],
+			},
+		})
+	}
+	return commits
+}
+
+// GetCommitDiff returns the patch a commit introduced, in the form of GetStagedDiff.
+func (c *Client) GetCommitDiff(hash string) (string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return "", err
+	}
+
+	result, err := c.runner.RunLogged("show", "--format=", "--no-color", "-M", "-U1", hash, "--")
+	if err != nil {
+		return "", gitutil.WrapGitError(fmt.Sprintf("failed to read commit %s", hash), result, err)
+	}
+	return result.StdoutString(false), nil
+}
+
+// HasUncommittedChanges reports whether tracked files differ from HEAD, in the index
+// or in the worktree.
+func (c *Client) HasUncommittedChanges() (bool, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return false, err
+	}
+
+	result, err := c.runner.Run("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, gitutil.WrapGitError("failed to check the worktree", result, err)
+	}
+	return result.StdoutString(true) != "", nil
+}
+
+// PushedCommits returns the commits of revRange that a remote-tracking branch already
+// contains.
+func (c *Client) PushedCommits(revRange string) ([]string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return nil, err
+	}
+
+	all, err := c.runner.Run("rev-list", revRange, "--")
+	if err != nil {
+		return nil, gitutil.WrapGitError(fmt.Sprintf("failed to list the commits of %s", revRange), all, err)
+	}
+	local, err := c.runner.Run("rev-list", revRange, "--not", "--remotes", "--")
+	if err != nil {
+		return nil, gitutil.WrapGitError("failed to compare with remote branches", local, err)
+	}
+
+	unpushed := make(map[string]bool)
+	for _, hash := range stringsutil.SplitNonEmpty(local.StdoutString(true), "\n") {
+		unpushed[hash] = true
+	}
+	var pushed []string
+	for _, hash := range stringsutil.SplitNonEmpty(all.StdoutString(true), "\n") {
+		if !unpushed[hash] {
+			pushed = append(pushed, hash)
+		}
+	}
+	return pushed, nil
+}
+
+// RewriteMessages recreates commits, oldest first, with the new messages keyed by
+// hash, and points branch at the new tip. Trees, authors, committers and dates stay
+// as they were, the same as rewording each commit in git rebase -i, and the index and
+// worktree are not touched. Commits after the first rewritten one are recreated on the
+// new parents. Signatures of recreated commits are dropped.
+//
+// The last commit must be the branch tip; if the branch moved, nothing is updated.
+// It returns the new tip.
+func (c *Client) RewriteMessages(branch string, commits []RangeCommit, messages map[string]string) (string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", errors.New("no commits to rewrite")
+	}
+
+	rewritten := make(map[string]string)
+	for _, commit := range commits {
+		message, reword := messages[commit.Hash]
+		parents := make([]string, len(commit.Parents))
+		moved := false
+		for i, parent := range commit.Parents {
+			parents[i] = parent
+			if newParent, ok := rewritten[parent]; ok {
+				parents[i] = newParent
+				moved = true
+			}
+		}
+		if !reword && !moved {
+			continue
+		}
+		if !reword {
+			message = commit.Message
+		}
+
+		hash, err := c.commitTree(commit, parents, message)
+		if err != nil {
+			return "", err
+		}
+		rewritten[commit.Hash] = hash
+	}
+
+	oldTip := commits[len(commits)-1].Hash
+	newTip, ok := rewritten[oldTip]
+	if !ok {
+		return oldTip, nil
+	}
+	result, err := c.runner.RunLogged("update-ref", "-m", "gmc rewrite", "refs/heads/"+branch, newTip, oldTip)
+	if err != nil {
+		return "", gitutil.WrapGitError(fmt.Sprintf("failed to update branch %s", branch), result, err)
+	}
+	return newTip, nil
+}
+
+// commitTree writes a copy of commit with the given parents and message.
+func (c *Client) commitTree(commit RangeCommit, parents []string, message string) (string, error) {
+	file, err := os.CreateTemp("", "gmc-rewrite-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create message file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(message + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write message file: %w", err)
+	}
+
+	args := []string{"commit-tree", commit.Tree}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-F", file.Name())
+
+	runner := c.runner
+	runner.Env = append(append([]string{}, runner.Env...), commit.env...)
+	result, err := runner.RunLogged(args...)
+	if err != nil {
+		return "", gitutil.WrapGitError(fmt.Sprintf("failed to rewrite commit %s", commit.Hash), result, err)
+	}
+	return result.StdoutString(true), nil
+}
+
+// ResolveCommit returns the full hash of the commit rev names.
+func (c *Client) ResolveCommit(rev string) (string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return "", err
+	}
+
+	result, err := c.runner.Run("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a commit", rev)
+	}
+	return result.StdoutString(true), nil
+}
+
+// ResetHead moves the checked out branch to rev with git reset: with hard, the index
+// and worktree are reset too; otherwise the changes since rev stay staged.
+func (c *Client) ResetHead(rev string, hard bool) error {
+	if err := c.CheckGitRepository(); err != nil {
+		return err
+	}
+
+	mode := "--soft"
+	if hard {
+		mode = "--hard"
+	}
+	result, err := c.runner.RunLogged("reset", "--quiet", mode, rev, "--")
+	if err != nil {
+		return gitutil.WrapGitError(fmt.Sprintf("failed to reset to %s", rev), result, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRangeCommits(t *testing.T) {
+	output := "aaa\x1ftree1\x1f\x1fAnn\x1fann@example.com\x1f1700000000 +0100\x1f" +
+		"Bob\x1fbob@example.com\x1f1700000100 +0000\x1f2023-11-14\x1fwip\n\x1e\n" +
+		"bbb\x1ftree2\x1faaa\x1fAnn\x1fann@example.com\x1f1700000200 +0100\x1f" +
+		"Ann\x1fann@example.com\x1f1700000200 +0100\x1f2023-11-14\x1ffix: x\n\nBody.\n\x1e"
+
+	commits := parseRangeCommits(output)
+	require.Len(t, commits, 2)
+	assert.Empty(t, commits[0].Parents)
+	assert.Equal(t, "wip", commits[0].Message)
+	assert.Contains(t, commits[0].env, "GIT_COMMITTER_NAME=Bob")
+	assert.Equal(t, []string{"aaa"}, commits[1].Parents)
+	assert.Equal(t, "fix: x\n\nBody.", commits[1].Message)
+	assert.Equal(t, "fix: x", commits[1].Subject())
+}
+
+func TestRewriteMessages(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gmc_git_rewrite_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init", "-b", "main")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+	runGitCommand(t, tempDir, "config", "commit.gpgsign", "false")
+	for i, subject := range []string{"feat: add a", "wip", "fix: add c"} {
+		file := filepath.Join(tempDir, string(rune('a'+i))+".txt")
+		require.NoError(t, os.WriteFile(file, []byte(subject+"\n"), 0o644))
+		runGitCommand(t, tempDir, "add", ".")
+		runGitCommand(t, tempDir, "commit", "-q", "-m", subject)
+	}
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(tempDir))
+	AssertNotInRealRepo(t)
+
+	client := NewClient(Options{})
+	commits, err := client.GetRangeCommits("HEAD~2..")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "wip", commits[0].Subject())
+
+	dirty, err := client.HasUncommittedChanges()
+	require.NoError(t, err)
+	assert.False(t, dirty)
+
+	runGitCommand(t, tempDir, "update-ref", "refs/remotes/origin/main", "HEAD~1")
+	pushed, err := client.PushedCommits("HEAD~2..")
+	require.NoError(t, err)
+	assert.Equal(t, []string{commits[0].Hash}, pushed)
+
+	diff, err := client.GetCommitDiff(commits[0].Hash)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+wip")
+
+	newTip, err := client.RewriteMessages("main", commits, map[string]string{commits[0].Hash: "feat: add b"})
+	require.NoError(t, err)
+	assert.NotEqual(t, commits[1].Hash, newTip)
+
+	rewritten, err := client.GetRangeCommits("HEAD~2..")
+	require.NoError(t, err)
+	require.Len(t, rewritten, 2)
+	assert.Equal(t, newTip, rewritten[1].Hash)
+	assert.Equal(t, "feat: add b", rewritten[0].Message)
+	assert.Equal(t, "fix: add c", rewritten[1].Message)
+	assert.Equal(t, commits[0].Tree, rewritten[0].Tree)
+	assert.Equal(t, commits[1].env, rewritten[1].env, "authors, committers and dates are kept")
+	assert.Equal(t, commits[0].Parents, rewritten[0].Parents)
+
+	_, err = client.RewriteMessages("main", commits, map[string]string{commits[0].Hash: "feat: again"})
+	assert.ErrorContains(t, err, "failed to update branch main", "the branch moved since the commits were listed")
+}
+
+func TestResetHead(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gmc_git_reset_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init", "-b", "main")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+	runGitCommand(t, tempDir, "config", "commit.gpgsign", "false")
+	for i, subject := range []string{"feat: add a", "feat: add b", "feat: add c"} {
+		file := filepath.Join(tempDir, string(rune('a'+i))+".txt")
+		require.NoError(t, os.WriteFile(file, []byte(subject+"\n"), 0o644))
+		runGitCommand(t, tempDir, "add", ".")
+		runGitCommand(t, tempDir, "commit", "-q", "-m", subject)
+	}
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(tempDir))
+	AssertNotInRealRepo(t)
+
+	client := NewClient(Options{})
+	require.NoError(t, client.ResetHead("HEAD~1", false))
+	staged, err := client.ParseStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c.txt"}, staged)
+
+	require.NoError(t, client.ResetHead("HEAD~1", true))
+	staged, err = client.ParseStagedFiles()
+	require.NoError(t, err)
+	assert.Empty(t, staged)
+	assert.NoFileExists(t, filepath.Join(tempDir, "b.txt"))
+	assert.NoFileExists(t, filepath.Join(tempDir, "c.txt"))
+	head, err := client.GetRangeCommits("HEAD^!")
+	require.NoError(t, err)
+	require.Len(t, head, 1)
+	assert.Equal(t, "feat: add a", head[0].Subject())
+
+	resolved, err := client.ResolveCommit("HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head[0].Hash, resolved)
+	_, err = client.ResolveCommit("no-such-branch")
+	assert.EqualError(t, err, "no-such-branch is not a commit")
+}
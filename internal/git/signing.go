@@ -0,0 +1,80 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Signing formats accepted by gpg.format.
+const (
+	SigningFormatOpenPGP = "openpgp"
+	SigningFormatSSH     = "ssh"
+)
+
+// SigningConfig is git's commit signing configuration.
+type SigningConfig struct {
+	// Enabled is commit.gpgsign, which makes git sign every commit.
+	Enabled bool
+	// Format is gpg.format: openpgp, x509 or ssh.
+	Format string
+	// Key is user.signingkey. When empty, gpg picks a key for the committer and ssh
+	// falls back to gpg.ssh.defaultKeyCommand.
+	Key string
+	// SSHKeyCommand is gpg.ssh.defaultKeyCommand.
+	SSHKeyCommand string
+}
+
+// signingKeys are the config keys SigningConfig reads, as git config --get-regexp
+// reports them.
+var signingKeys = []string{"commit.gpgsign", "gpg.format", "user.signingkey", "gpg.ssh.defaultkeycommand"}
+
+// SigningConfig reads the commit signing settings git applies to this repository,
+// with one git config call. Unset or unreadable values keep their defaults.
+func (c *Client) SigningConfig() SigningConfig {
+	pattern := `^(` + strings.ReplaceAll(strings.Join(signingKeys, "|"), ".", `\.`) + `)$`
+	// git config exits 1 when none of the keys is set.
+	result, err := c.runner.Run("config", "-z", "--get-regexp", pattern)
+	if err != nil {
+		return SigningConfig{Format: SigningFormatOpenPGP}
+	}
+	return parseSigningConfig(result.Stdout)
+}
+
+// parseSigningConfig reads the output of git config -z --get-regexp: "key\nvalue"
+// entries ending in NUL, or "key" alone for a key without a value. A later entry
+// overrides an earlier one, as git's own lookup does.
+func parseSigningConfig(output []byte) SigningConfig {
+	signing := SigningConfig{Format: SigningFormatOpenPGP}
+	for _, entry := range strings.Split(string(output), "\x00") {
+		key, value, hasValue := strings.Cut(entry, "\n")
+		switch key {
+		case "commit.gpgsign":
+			signing.Enabled = parseGitBool(value, hasValue)
+		case "gpg.format":
+			if value != "" {
+				signing.Format = value
+			}
+		case "user.signingkey":
+			signing.Key = value
+		case "gpg.ssh.defaultkeycommand":
+			signing.SSHKeyCommand = value
+		}
+	}
+	return signing
+}
+
+// parseGitBool reads a config boolean the way git does: a key without a value is
+// true, and so are "true", "yes", "on" and non-zero numbers.
+func parseGitBool(value string, hasValue bool) bool {
+	if !hasValue {
+		return true
+	}
+	switch strings.ToLower(value) {
+	case "true", "yes", "on":
+		return true
+	case "", "false", "no", "off":
+		return false
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n != 0
+}
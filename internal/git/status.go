@@ -0,0 +1,238 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// statusBatchSize bounds the pathspecs passed to one git status so that huge
+// selections stay under the command line limit.
+const statusBatchSize = 500
+
+// fileState is what CheckFileStatus needs to know about a path.
+type fileState struct {
+	staged    bool
+	modified  bool
+	untracked bool
+	// removed marks a path deleted from the index, which git rm --cached leaves in the
+	// worktree.
+	removed bool
+}
+
+// CheckFileStatus sorts files into staged, modified (changed but not staged) and
+// untracked, keeping their order. A file with staged changes only counts as staged,
+// and unchanged tracked files are left out. It runs one git status per
+// statusBatchSize files instead of inspecting each file on its own.
+func (c *Client) CheckFileStatus(files []string) ([]string, []string, []string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	root, prefix, err := c.repoRootAndPrefix()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = repoRelativePath(root, prefix, file)
+	}
+	var states map[string]fileState
+	if c.goGit != nil {
+		states, err = c.goGitFileStates(root, paths)
+	} else {
+		states, err = c.fileStates(files)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var staged, modified, untracked []string
+	for i, file := range files {
+		state := states[paths[i]]
+		switch {
+		case state.staged:
+			staged = append(staged, file)
+		case state.modified:
+			modified = append(modified, file)
+		case state.untracked:
+			untracked = append(untracked, file)
+		}
+	}
+
+	return staged, modified, untracked, nil
+}
+
+// fileStates runs git status on files, statusBatchSize at a time.
+func (c *Client) fileStates(files []string) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	for start := 0; start < len(files); start += statusBatchSize {
+		batch := files[start:min(start+statusBatchSize, len(files))]
+		args := []string{
+			"status", "--porcelain=v2", "-z", "--untracked-files=all", "--ignored=matching", "--no-renames", "--",
+		}
+		for _, file := range batch {
+			args = append(args, filepath.ToSlash(file))
+		}
+
+		result, err := c.runner.RunLogged(args...)
+		if err != nil {
+			c.logVerboseOutput("Git stderr:", result.Stderr)
+			return nil, gitutil.WrapGitError("failed to check file status", result, err)
+		}
+		for file, state := range parseStatusV2(result.Stdout) {
+			states[file] = state
+		}
+	}
+	return states, nil
+}
+
+// StagedPaths returns the paths with staged changes, from the worktree root and
+// without rename detection, so a rename lists both its old and its new path. unstaged
+// holds those of them whose worktree content differs from the index, including
+// removed paths still in the worktree.
+func (c *Client) StagedPaths() (paths []string, unstaged []string, err error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return nil, nil, err
+	}
+	root, _, err := c.repoRootAndPrefix()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := c.runner.RunLogged("status", "--porcelain=v2", "-z", "--untracked-files=no", "--no-renames")
+	if err != nil {
+		c.logVerboseOutput("Git stderr:", result.Stderr)
+		return nil, nil, gitutil.WrapGitError("failed to list staged files", result, err)
+	}
+
+	states := parseStatusV2(result.Stdout)
+	for file, state := range states {
+		if !state.staged {
+			continue
+		}
+		paths = append(paths, file)
+		if state.modified || (state.removed && pathExists(filepath.Join(root, filepath.FromSlash(file)))) {
+			unstaged = append(unstaged, file)
+		}
+	}
+	sort.Strings(paths)
+	sort.Strings(unstaged)
+	return paths, unstaged, nil
+}
+
+func pathExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// repoRootAndPrefix returns the worktree root and the current directory relative to
+// it, with a trailing slash unless it is the root itself.
+func (c *Client) repoRootAndPrefix() (string, string, error) {
+	if c.location != nil {
+		return c.location.root, c.location.prefix, nil
+	}
+	if c.goGit != nil {
+		loc, err := c.goGitLocation()
+		if err != nil {
+			return "", "", fmt.Errorf("not in a git repository: %w", err)
+		}
+		return loc.root, loc.prefix, nil
+	}
+	result, err := c.runner.Run("rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return "", "", fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	lines := strings.Split(result.StdoutString(false), "\n")
+	if len(lines) < 2 || lines[0] == "" {
+		return "", "", errors.New("failed to determine repository root")
+	}
+	return lines[0], lines[1], nil
+}
+
+// repoRelativePath turns file, relative to the current directory or absolute, into the
+// slash-separated path from the worktree root that git status reports.
+func repoRelativePath(root, prefix, file string) string {
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(root, file); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path.Clean(prefix + filepath.ToSlash(file))
+}
+
+// parseStatusV2 reads the output of git status --porcelain=v2 -z, keyed by the paths
+// from the worktree root. Ignored files count as untracked, the same as files git has
+// never seen.
+func parseStatusV2(output []byte) map[string]fileState {
+	states := make(map[string]fileState)
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 2 {
+			continue
+		}
+
+		switch entry[0] {
+		case '1', 'u':
+			// "1 XY sub mH mI mW hH hI path" and "u XY sub m1 m2 m3 mW h1 h2 h3 path".
+			columns := 9
+			if entry[0] == 'u' {
+				columns = 11
+			}
+			parts := strings.SplitN(entry, " ", columns)
+			if len(parts) < columns || len(parts[1]) != 2 {
+				continue
+			}
+			// An unmerged path shows up in git diff --cached, so it counts as staged.
+			states[parts[columns-1]] = fileState{
+				staged:   entry[0] == 'u' || parts[1][0] != '.',
+				modified: parts[1][1] != '.',
+				removed:  parts[1][0] == 'D',
+			}
+		case '2':
+			// "2 XY sub mH mI mW hH hI Xscore path", then the original path as its own
+			// field. --no-renames keeps these out, but a copy can still be reported.
+			parts := strings.SplitN(entry, " ", 10)
+			i++
+			if len(parts) < 10 || len(parts[1]) != 2 {
+				continue
+			}
+			states[parts[9]] = fileState{staged: parts[1][0] != '.', modified: parts[1][1] != '.'}
+		case '?', '!':
+			states[entry[2:]] = fileState{untracked: true}
+		}
+	}
+	return states
+}
+
+// IgnoredDirs returns the directories under the current one that git ignores as a
+// whole, such as node_modules, relative to it and without a trailing slash.
+func (c *Client) IgnoredDirs() ([]string, error) {
+	if err := c.CheckGitRepository(); err != nil {
+		return nil, err
+	}
+
+	result, err := c.runner.RunLogged("ls-files", "--others", "--ignored", "--exclude-standard", "--directory")
+	if err != nil {
+		return nil, gitutil.WrapGitError("failed to list ignored directories", result, err)
+	}
+	var dirs []string
+	for _, line := range strings.Split(result.StdoutString(true), "\n") {
+		if dir, ok := strings.CutSuffix(line, "/"); ok && dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusV2(t *testing.T) {
+	output := "1 M. N... 100644 100644 100644 1111111 2222222 staged.go\x00" +
+		"1 .M N... 100644 100644 100644 1111111 1111111 dir/modified file.go\x00" +
+		"1 MM N... 100644 100644 100644 1111111 2222222 both.go\x00" +
+		"1 D. N... 100644 000000 000000 1111111 0000000 removed.go\x00" +
+		"2 C. N... 100644 100644 100644 1111111 1111111 C100 copy.go\x00orig.go\x00" +
+		"u UU N... 100644 100644 100644 100644 1111111 2222222 3333333 conflict.go\x00" +
+		"? new.txt\x00" +
+		"! build/out.bin\x00"
+
+	assert.Equal(t, map[string]fileState{
+		"staged.go":            {staged: true},
+		"dir/modified file.go": {modified: true},
+		"both.go":              {staged: true, modified: true},
+		"removed.go":           {staged: true, removed: true},
+		"copy.go":              {staged: true},
+		"conflict.go":          {staged: true, modified: true},
+		"new.txt":              {untracked: true},
+		"build/out.bin":        {untracked: true},
+	}, parseStatusV2([]byte(output)))
+	assert.Empty(t, parseStatusV2(nil))
+}
+
+func TestCheckFileStatus(t *testing.T) {
+	client := NewClient(Options{})
+
+	tempDir, err := os.MkdirTemp("", "gmc_git_status_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init")
+	runGitCommand(t, tempDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, tempDir, "config", "user.name", "gmc tester")
+
+	write := func(name, content string) {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	for _, name := range []string{"root.txt", "sub/staged.txt", "sub/modified.txt", "sub/clean.txt", "sub/gone.txt"} {
+		write(name, "initial\n")
+	}
+	write(".gitignore", "*.log\n")
+	runGitCommand(t, tempDir, "add", ".")
+	runGitCommand(t, tempDir, "commit", "-m", "initial commit")
+
+	write("root.txt", "changed\n")
+	write("sub/staged.txt", "staged\n")
+	runGitCommand(t, tempDir, "add", "sub/staged.txt")
+	write("sub/staged.txt", "staged, then changed\n")
+	write("sub/modified.txt", "changed\n")
+	runGitCommand(t, tempDir, "rm", "-q", "sub/gone.txt")
+	write("sub/new/untracked.txt", "new\n")
+	write("sub/debug.log", "ignored\n")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+
+	require.NoError(t, os.Chdir(filepath.Join(tempDir, "sub")))
+	AssertNotInRealRepo(t)
+
+	staged, modified, untracked, err := client.CheckFileStatus([]string{
+		"new/untracked.txt", "../root.txt", "clean.txt", "modified.txt", "gone.txt", "staged.txt", "debug.log",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gone.txt", "staged.txt"}, staged)
+	assert.Equal(t, []string{"../root.txt", "modified.txt"}, modified)
+	assert.Equal(t, []string{"new/untracked.txt", "debug.log"}, untracked)
+
+	runGitCommand(t, tempDir, "rm", "-q", "--cached", "sub/clean.txt")
+	paths, unstaged, err := client.StagedPaths()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sub/clean.txt", "sub/gone.txt", "sub/staged.txt"}, paths, "paths are from the worktree root")
+	assert.Equal(t, []string{"sub/clean.txt", "sub/staged.txt"}, unstaged, "a file git rm --cached left behind differs")
+}
+
+func TestStagePathsAndIgnoredDirs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gmc_git_stage_paths_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	runGitCommand(t, tempDir, "init")
+	write := func(name, content string) {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(".gitignore", "node_modules/\nbuild/\n")
+	write("src/a.go", "package a\n")
+	write("src/gen/b.go", "package gen\n")
+	write("docs/c.md", "# c\n")
+	write("node_modules/x/index.js", "x\n")
+	write("build/out", "out\n")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(tempDir))
+	AssertNotInRealRepo(t)
+
+	client := NewClient(Options{})
+	dirs, err := client.IgnoredDirs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build", "node_modules"}, dirs)
+
+	require.NoError(t, client.StagePaths([]string{"src/**", ":!src/gen"}))
+	staged, err := client.ParseStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/a.go"}, staged)
+}
+
+func TestHooksDir(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gmc_git_hooks_dir_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	require.NoError(t, err)
+	runGitCommand(t, tempDir, "init")
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "sub"), 0755))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	require.NoError(t, os.Chdir(filepath.Join(tempDir, "sub")))
+	AssertNotInRealRepo(t)
+
+	client := NewClient(Options{})
+	dir, hooksPath, err := client.HooksDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tempDir, ".git", "hooks"), dir)
+	assert.Empty(t, hooksPath)
+
+	runGitCommand(t, tempDir, "config", "core.hooksPath", ".husky/_")
+	dir, hooksPath, err = client.HooksDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tempDir, ".husky", "_"), dir)
+	assert.Equal(t, ".husky/_", hooksPath)
+}
@@ -7,6 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/samzong/gmc/internal/debuglog"
 )
 
 // Runner executes git commands with shared logging and output handling.
@@ -83,6 +86,21 @@ func (r Runner) RunLogged(args ...string) (Result, error) {
 	return r.run(args, true)
 }
 
+// RunInput executes a git command with input on stdin and captures stdout/stderr.
+func (r Runner) RunInput(input string, args ...string) (Result, error) {
+	cmd := r.prepare(args, false)
+	cmd.Stdin = strings.NewReader(input)
+	var outBuf bytes.Buffer
+	var errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	started := time.Now()
+	err := cmd.Run()
+	logCommand(cmd, args, started, err)
+	return Result{Stdout: outBuf.Bytes(), Stderr: errBuf.Bytes()}, err
+}
+
 // RunStreaming executes a git command with stdout/stderr streamed to the terminal.
 func (r Runner) RunStreaming(args ...string) error {
 	return r.runWithWriters(args, false, os.Stdout, os.Stderr)
@@ -105,7 +123,9 @@ func (r Runner) run(args []string, log bool) (Result, error) {
 	cmd.Stdout = &outBuf
 	cmd.Stderr = &errBuf
 
+	started := time.Now()
 	err := cmd.Run()
+	logCommand(cmd, args, started, err)
 	return Result{Stdout: outBuf.Bytes(), Stderr: errBuf.Bytes()}, err
 }
 
@@ -118,5 +138,23 @@ func (r Runner) runWithWriters(args []string, log bool, stdout io.Writer, stderr
 		cmd.Stderr = stderr
 	}
 
-	return cmd.Run()
+	started := time.Now()
+	err := cmd.Run()
+	logCommand(cmd, args, started, err)
+	return err
+}
+
+// logCommand records a finished git command in the debug log.
+func logCommand(cmd *exec.Cmd, args []string, started time.Time, err error) {
+	if !debuglog.Enabled() {
+		return
+	}
+	attrs := []any{"args", args, "latency_ms", time.Since(started).Milliseconds()}
+	if cmd.Dir != "" {
+		attrs = append(attrs, "dir", cmd.Dir)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	debuglog.Log("git", attrs...)
 }
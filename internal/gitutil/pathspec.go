@@ -0,0 +1,39 @@
+package gitutil
+
+import (
+	"path"
+	"strings"
+)
+
+// ExcludePathspecs returns the git pathspecs that leave out the repository-relative
+// patterns, after a positive pathspec for the whole tree.
+func ExcludePathspecs(patterns []string) []string {
+	specs := []string{":(top)"}
+	for _, pattern := range patterns {
+		if pattern = cleanPattern(pattern); pattern != "" {
+			specs = append(specs, ":(top,exclude)"+pattern)
+		}
+	}
+	return specs
+}
+
+// MatchPathspec reports whether the repository-relative file matches pattern the way
+// a git pathspec without magic does: wildcards match across "/", so "*.pb.go" matches
+// in every directory, and a pattern also matches the files under a directory it names.
+func MatchPathspec(pattern, file string) bool {
+	pattern = cleanPattern(pattern)
+	if pattern == "" {
+		return false
+	}
+	if file == pattern || strings.HasPrefix(file, pattern+"/") {
+		return true
+	}
+	// path.Match stops * at "/"; swap it for a byte paths do not contain instead.
+	const sep = "\x00"
+	matched, err := path.Match(strings.ReplaceAll(pattern, "/", sep), strings.ReplaceAll(file, "/", sep))
+	return err == nil && matched
+}
+
+func cleanPattern(pattern string) string {
+	return strings.Trim(strings.TrimSpace(pattern), "/")
+}
@@ -0,0 +1,37 @@
+// Package gmcerrors defines the failures that gmc reports with their own exit codes,
+// so scripts can branch on them instead of matching stderr text.
+package gmcerrors
+
+import "errors"
+
+var (
+	// ErrNotARepo is returned when gmc runs outside a git repository.
+	ErrNotARepo = errors.New("not a git repository")
+	// ErrNoChanges is returned when there is nothing staged to commit.
+	ErrNoChanges = errors.New("no changes detected in the staging area files")
+	// ErrConfigMissing is returned when a required setting, such as the API key, is not set.
+	ErrConfigMissing = errors.New("required configuration missing")
+	// ErrLLMAuth is returned when the LLM endpoint rejects the API key.
+	ErrLLMAuth = errors.New("LLM authentication failed")
+	// ErrLLMTimeout is returned when an LLM request does not finish in time.
+	ErrLLMTimeout = errors.New("LLM request timed out")
+	// ErrUserCancelled is returned when the user declines a prompt or quits a picker.
+	ErrUserCancelled = errors.New("cancelled by user")
+)
+
+// Mark returns err with kind attached: errors.Is matches both, and the message is
+// err's alone.
+func Mark(err, kind error) error {
+	if err == nil {
+		return nil
+	}
+	return &marked{err: err, kind: kind}
+}
+
+type marked struct {
+	err  error
+	kind error
+}
+
+func (m *marked) Error() string   { return m.err.Error() }
+func (m *marked) Unwrap() []error { return []error{m.err, m.kind} }
@@ -0,0 +1,20 @@
+package gmcerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMark(t *testing.T) {
+	cause := errors.New("status 401")
+	err := fmt.Errorf("failed to call LLM: %w", Mark(cause, ErrLLMAuth))
+
+	assert.Equal(t, "failed to call LLM: status 401", err.Error())
+	assert.ErrorIs(t, err, ErrLLMAuth)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrLLMTimeout)
+	assert.NoError(t, Mark(nil, ErrLLMAuth))
+}
@@ -0,0 +1,179 @@
+// Package guard finds likely secrets, such as cloud keys, private keys and tokens
+// assigned to credential-like names, in the text gmc is about to send to the LLM.
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrSecretsFound is returned when a prompt holds likely secrets and redaction is off.
+var ErrSecretsFound = errors.New("possible secrets in the changes sent to the LLM")
+
+// Finding is a likely secret.
+type Finding struct {
+	// Rule names the kind of secret, such as "aws-access-key".
+	Rule string `json:"rule"`
+	// File is the diff file the secret is in, empty outside a diff.
+	File string `json:"file,omitempty"`
+	// Preview is the start of the secret, enough to find it without repeating it.
+	Preview string `json:"preview"`
+}
+
+// String describes f as "aws-access-key in config/prod.env (AKIA...)".
+func (f Finding) String() string {
+	where := ""
+	if f.File != "" {
+		where = " in " + f.File
+	}
+	return fmt.Sprintf("%s%s (%s)", f.Rule, where, f.Preview)
+}
+
+// rule matches one kind of secret. The secret is the group named secret, or the
+// whole match without it.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	// minEntropy is the Shannon entropy, in bits per character, the secret needs. Such
+	// a secret also needs letters and digits, so that placeholders such as "changeme"
+	// and code such as cfg.GitHubToken are not reported.
+	minEntropy float64
+}
+
+// privateKey starts a PEM private key block, which is a secret up to its END line.
+var privateKey = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)
+
+var rules = []rule{
+	{name: "aws-access-key", pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{name: "github-token", pattern: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{60,}\b`)},
+	{name: "gitlab-token", pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{name: "slack-token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{name: "openai-key", pattern: regexp.MustCompile(`\bsk-(proj-)?[A-Za-z0-9_-]{32,}\b`)},
+	{name: "google-api-key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{
+		name: "high-entropy-secret",
+		pattern: regexp.MustCompile(`(?i)(secret|token|passw(or)?d|api[_-]?key|access[_-]?key|private[_-]?key|credential)` +
+			`[a-z0-9_.-]*["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9+/_.=-]{16,})`),
+		minEntropy: 3.5,
+	},
+}
+
+// Scan returns the likely secrets in text, in the order they appear.
+func Scan(text string) []Finding {
+	var findings []Finding
+	forEachSecret(text, func(f Finding) string {
+		findings = append(findings, f)
+		return ""
+	})
+	return findings
+}
+
+// Redact returns text with each likely secret replaced by a [REDACTED <rule>] marker,
+// and the findings. A private key block is replaced up to its END line.
+func Redact(text string) (string, []Finding) {
+	var findings []Finding
+	redacted := forEachSecret(text, func(f Finding) string {
+		findings = append(findings, f)
+		return "[REDACTED " + f.Rule + "]"
+	})
+	return redacted, findings
+}
+
+// forEachSecret calls fn with each secret of text and returns text with every secret
+// replaced by what fn returns. The lines of a private key block after its BEGIN line
+// are dropped, up to its END line or the next file of a diff.
+func forEachSecret(text string, fn func(Finding) string) string {
+	var out strings.Builder
+	file := ""
+	inKey := false
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			file, inKey = diffFile(line), false
+		}
+		if inKey {
+			inKey = !strings.Contains(line, "-----END ")
+			continue
+		}
+		if loc := privateKey.FindStringIndex(line); loc != nil {
+			begin := line[loc[0]:loc[1]]
+			out.WriteString(line[:loc[0]] + fn(Finding{Rule: "private-key", File: file, Preview: begin}) + "\n")
+			inKey = !strings.Contains(line[loc[1]:], "-----END ")
+			continue
+		}
+		for _, r := range rules {
+			line = replaceMatches(r, line, func(secret string) string {
+				if r.minEntropy > 0 && !looksRandom(secret, r.minEntropy) {
+					return secret
+				}
+				return fn(Finding{Rule: r.name, File: file, Preview: preview(secret)})
+			})
+		}
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// replaceMatches replaces the secret of each match of r in line with what replace
+// returns for it.
+func replaceMatches(r rule, line string, replace func(string) string) string {
+	group := r.pattern.SubexpIndex("secret")
+	var out strings.Builder
+	last := 0
+	for _, loc := range r.pattern.FindAllStringSubmatchIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		if group > 0 {
+			start, end = loc[2*group], loc[2*group+1]
+		}
+		out.WriteString(line[last:start])
+		out.WriteString(replace(line[start:end]))
+		last = end
+	}
+	out.WriteString(line[last:])
+	return out.String()
+}
+
+// looksRandom reports whether s mixes letters and digits with at least minEntropy bits
+// of entropy per character.
+func looksRandom(s string, minEntropy float64) bool {
+	return strings.ContainsAny(s, "0123456789") &&
+		strings.IndexFunc(s, unicode.IsLetter) >= 0 &&
+		entropy(s) >= minEntropy
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	total := float64(len([]rune(s)))
+	bits := 0.0
+	for _, n := range counts {
+		p := float64(n) / total
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// preview returns the first characters of secret followed by "...".
+func preview(secret string) string {
+	const shown = 4
+	if len(secret) <= shown {
+		return "..."
+	}
+	return secret[:shown] + "..."
+}
+
+// diffFile returns the new path of a "diff --git a/x b/y" line.
+func diffFile(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return ""
+	}
+	return strings.TrimPrefix(strings.Trim(fields[3], `"`), "b/")
+}
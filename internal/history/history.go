@@ -0,0 +1,178 @@
+// Package history keeps the commit messages gmc generated, with what became of them,
+// so that a message that was not committed can be committed later without another
+// LLM call.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+)
+
+// maxEntries is how many entries the history file keeps; older ones are dropped.
+const maxEntries = 500
+
+// Status is what became of a generated message.
+type Status string
+
+const (
+	StatusCommitted   Status = "committed"
+	StatusDryRun      Status = "dry-run"
+	StatusCancelled   Status = "cancelled"
+	StatusFailed      Status = "failed"
+	StatusRegenerated Status = "regenerated"
+	// StatusUndone is a committed message that gmc undo --keep-message took back.
+	StatusUndone Status = "undone"
+)
+
+// Entry is one generated message.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Repo   string    `json:"repo"`
+	Branch string    `json:"branch,omitempty"`
+	Model  string    `json:"model,omitempty"`
+	// DiffHash identifies the diff the message was generated from; see HashDiff.
+	DiffHash string   `json:"diff_hash"`
+	Files    []string `json:"files,omitempty"`
+	Message  string   `json:"message"`
+	Status   Status   `json:"status"`
+}
+
+// Redoable reports whether the message was generated but not committed: the commit
+// was cancelled, failed, was a dry run, or was undone.
+func (e Entry) Redoable() bool {
+	switch e.Status {
+	case StatusCancelled, StatusFailed, StatusDryRun, StatusUndone:
+		return true
+	}
+	return false
+}
+
+// HashDiff returns a short hash of diff, to tell whether the staged changes are still
+// the ones a message was generated from.
+func HashDiff(diff string) string {
+	sum := sha256.Sum256([]byte(diff))
+	return hex.EncodeToString(sum[:8])
+}
+
+// FilePath returns $XDG_STATE_HOME/gmc/history.jsonl.
+func FilePath() (string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// Load reads the entries at path, newest first. A missing file yields no entries.
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message history: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse message history %s, line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read message history: %w", err)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// Append adds entries, oldest first, to the history at path, dropping the oldest
+// entries beyond maxEntries.
+func Append(path string, entries ...Entry) error {
+	existing, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	// existing is newest first; the file is oldest first.
+	all := make([]Entry, 0, len(existing)+len(entries))
+	for i := len(existing) - 1; i >= 0; i-- {
+		all = append(all, existing[i])
+	}
+	all = append(all, entries...)
+	if len(all) > maxEntries {
+		all = all[len(all)-maxEntries:]
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range all {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write message history: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// ForRepo returns the entries of the repository at repo, keeping their order.
+func ForRepo(entries []Entry, repo string) []Entry {
+	var matched []Entry
+	for _, entry := range entries {
+		if entry.Repo == repo {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// Last returns the message of the newest commit attempt among entries, which are
+// newest first. Messages that were regenerated are skipped: they were never offered
+// for commit at the end.
+func Last(entries []Entry) (Entry, bool) {
+	for _, entry := range entries {
+		if entry.Status != StatusRegenerated {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Recorder appends the entries of one repository to a history file.
+type Recorder struct {
+	Path string
+	Repo string
+}
+
+// Record stamps entries with the recorder's repository and appends them.
+func (r Recorder) Record(entries ...Entry) error {
+	for i := range entries {
+		entries[i].Repo = r.Repo
+	}
+	return Append(r.Path, entries...)
+}
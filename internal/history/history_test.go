@@ -0,0 +1,78 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendLoadAndLast(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gmc", "history.jsonl")
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a missing file has no entries")
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	api := Recorder{Path: path, Repo: "/src/api"}
+	require.NoError(t, api.Record(
+		Entry{Time: at, Message: "feat: first", Status: StatusRegenerated},
+		Entry{Time: at, Message: "feat: second", Status: StatusFailed},
+	))
+	require.NoError(t, Recorder{Path: path, Repo: "/src/web"}.Record(
+		Entry{Time: at, Message: "fix: web", Status: StatusCommitted},
+	))
+
+	entries, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "fix: web", entries[0].Message, "newest first")
+
+	apiEntries := ForRepo(entries, "/src/api")
+	require.Len(t, apiEntries, 2)
+	last, ok := Last(apiEntries)
+	require.True(t, ok)
+	assert.Equal(t, "feat: second", last.Message, "regenerated messages are skipped")
+	assert.True(t, last.Redoable())
+
+	last, ok = Last(ForRepo(entries, "/src/web"))
+	require.True(t, ok)
+	assert.False(t, last.Redoable())
+	assert.True(t, Entry{Status: StatusUndone}.Redoable())
+
+	_, ok = Last(ForRepo(entries, "/src/other"))
+	assert.False(t, ok)
+}
+
+func TestAppendKeepsNewestEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	batch := make([]Entry, maxEntries)
+	for i := range batch {
+		batch[i] = Entry{Message: "old", Status: StatusCommitted}
+	}
+	require.NoError(t, Append(path, batch...))
+	require.NoError(t, Append(path, Entry{Message: "new", Status: StatusCancelled}))
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, maxEntries)
+	assert.Equal(t, "new", entries[0].Message)
+}
+
+func TestLoadReportsBadLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"message\":\"ok\"}\nnot json\n"), 0o600))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestHashDiff(t *testing.T) {
+	assert.Equal(t, HashDiff("a"), HashDiff("a"))
+	assert.NotEqual(t, HashDiff("a"), HashDiff("b"))
+	assert.Len(t, HashDiff("a"), 16)
+}
@@ -0,0 +1,190 @@
+// Package hook installs the prepare-commit-msg hook through which git commit asks gmc
+// for the message, chaining the hook it takes the place of.
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Name is the git hook gmc installs.
+const Name = "prepare-commit-msg"
+
+// ChainedSuffix is appended to the name of the hook gmc takes the place of. The gmc
+// hook runs it first.
+const ChainedSuffix = ".gmc-chained"
+
+// Hook managers Resolve recognizes.
+const (
+	ManagerHusky    = "husky"
+	ManagerLefthook = "lefthook"
+)
+
+// marker identifies a hook file gmc wrote.
+const marker = "# Installed by gmc hook install."
+
+// script runs the chained hook, then fills in the message when git commit was run
+// without one. It only looks at commits whose source ($2) is empty or "message",
+// not templates, merges, squashes or --amend, and leaves alone a file that already
+// holds a message, as -m, -F and gmc's own commits write. The generated message goes
+// above git's comments, which stay in the file, and is not recorded in the history.
+const script = `#!/bin/sh
+` + marker + ` gmc hook uninstall restores the previous hook.
+previous="$0` + ChainedSuffix + `"
+if [ -x "$previous" ]; then
+	"$previous" "$@" || exit $?
+elif [ -f "$previous" ]; then
+	sh "$previous" "$@" || exit $?
+fi
+case "$2" in
+"" | message) ;;
+*) exit 0 ;;
+esac
+grep -qv '^[[:space:]]*\(#.*\)\{0,1\}$' "$1" 2>/dev/null && exit 0
+command -v gmc >/dev/null 2>&1 || exit 0
+generated="$1.gmc"
+if gmc --dry-run --yes --all=false --no-color --no-history --out "$generated" </dev/null >/dev/null; then
+	{ cat "$generated"; cat "$1" 2>/dev/null; } >"$generated.tmp"
+	mv "$generated.tmp" "$1"
+fi
+rm -f "$generated" "$generated.tmp"
+`
+
+// ErrNotInstalled is returned by Uninstall when the hook is not gmc's.
+var ErrNotInstalled = errors.New("the gmc hook is not installed")
+
+// Target is where the gmc hook of a repository goes.
+type Target struct {
+	// Dir holds the hook file: the directory git runs hooks from, or for husky, the
+	// directory of the hooks husky calls.
+	Dir string `json:"dir"`
+	// HooksPath is core.hooksPath, "" when git runs the hooks in .git/hooks.
+	HooksPath string `json:"hooks_path,omitempty"`
+	// Manager is the hook manager that owns Dir, ManagerHusky or ManagerLefthook, or "".
+	Manager string `json:"manager,omitempty"`
+}
+
+// Path returns the path of the hook file.
+func (t Target) Path() string {
+	return filepath.Join(t.Dir, Name)
+}
+
+// ChainedPath returns where the hook gmc takes the place of is kept.
+func (t Target) ChainedPath() string {
+	return t.Path() + ChainedSuffix
+}
+
+// Resolve returns the Target for hooksDir, the directory git runs hooks from, and
+// hooksPath, the core.hooksPath setting. husky 9 points core.hooksPath at .husky/_,
+// whose generated hooks call those in .husky, so the gmc hook goes in .husky.
+func Resolve(hooksDir, hooksPath string) Target {
+	target := Target{Dir: hooksDir, HooksPath: hooksPath}
+	switch {
+	case filepath.Base(hooksDir) == "_" && filepath.Base(filepath.Dir(hooksDir)) == ".husky":
+		target.Dir = filepath.Dir(hooksDir)
+		target.Manager = ManagerHusky
+	case filepath.Base(hooksDir) == ".husky":
+		target.Manager = ManagerHusky
+	default:
+		for _, path := range []string{target.Path(), target.ChainedPath()} {
+			if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "lefthook") {
+				target.Manager = ManagerLefthook
+				break
+			}
+		}
+	}
+	return target
+}
+
+// Result describes what Install did.
+type Result struct {
+	Path string `json:"path"`
+	// Chained is the hook the gmc hook runs first, "" when there was none.
+	Chained string `json:"chained,omitempty"`
+	// Updated is set when the gmc hook was installed already and was rewritten.
+	Updated bool `json:"updated"`
+}
+
+// Install writes the gmc hook to target. A hook of another tool is moved to
+// ChainedPath, so that the gmc hook runs it first.
+func Install(target Target) (Result, error) {
+	result := Result{Path: target.Path()}
+	state := Inspect(target)
+	if state.Chained {
+		result.Chained = target.ChainedPath()
+	}
+	switch {
+	case state.Installed:
+		result.Updated = true
+	case state.Exists && state.Chained:
+		return result, fmt.Errorf("both %s and %s exist; remove one of them and run gmc hook install again",
+			target.Path(), target.ChainedPath())
+	case state.Exists:
+		if err := os.Rename(target.Path(), target.ChainedPath()); err != nil {
+			return result, fmt.Errorf("failed to keep the existing hook: %w", err)
+		}
+		result.Chained = target.ChainedPath()
+	}
+
+	if err := os.MkdirAll(target.Dir, 0o755); err != nil {
+		return result, fmt.Errorf("failed to create %s: %w", target.Dir, err)
+	}
+	if err := os.WriteFile(target.Path(), []byte(script), 0o755); err != nil {
+		return result, fmt.Errorf("failed to write %s: %w", target.Path(), err)
+	}
+	// WriteFile keeps the mode of a file that exists.
+	if err := os.Chmod(target.Path(), 0o755); err != nil {
+		return result, fmt.Errorf("failed to make %s executable: %w", target.Path(), err)
+	}
+	return result, nil
+}
+
+// Uninstall removes the gmc hook from target and moves the hook it chained back in
+// its place, returning the path of that hook, or "" when there was none.
+func Uninstall(target Target) (string, error) {
+	state := Inspect(target)
+	if !state.Installed {
+		return "", fmt.Errorf("%w at %s", ErrNotInstalled, target.Path())
+	}
+	if err := os.Remove(target.Path()); err != nil {
+		return "", fmt.Errorf("failed to remove %s: %w", target.Path(), err)
+	}
+	if !state.Chained {
+		return "", nil
+	}
+	if err := os.Rename(target.ChainedPath(), target.Path()); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", target.ChainedPath(), err)
+	}
+	return target.Path(), nil
+}
+
+// State is what Inspect found at a Target.
+type State struct {
+	// Exists is set when a hook file is at the path.
+	Exists bool `json:"exists"`
+	// Installed is set when that file is the gmc hook.
+	Installed bool `json:"installed"`
+	// Executable is set when git can run the file.
+	Executable bool `json:"executable"`
+	// Chained is set when a hook gmc took the place of is kept at ChainedPath.
+	Chained bool `json:"chained"`
+}
+
+// Inspect reports the hook files at target.
+func Inspect(target Target) State {
+	var state State
+	if info, err := os.Stat(target.Path()); err == nil && !info.IsDir() {
+		state.Exists = true
+		state.Executable = info.Mode()&0o111 != 0
+		if data, err := os.ReadFile(target.Path()); err == nil {
+			state.Installed = strings.Contains(string(data), marker)
+		}
+	}
+	if info, err := os.Stat(target.ChainedPath()); err == nil && !info.IsDir() {
+		state.Chained = true
+	}
+	return state
+}
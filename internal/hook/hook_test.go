@@ -0,0 +1,141 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+
+	target := Resolve(filepath.Join(root, ".git", "hooks"), "")
+	assert.Equal(t, Target{Dir: filepath.Join(root, ".git", "hooks")}, target)
+
+	target = Resolve(filepath.Join(root, ".husky", "_"), ".husky/_")
+	assert.Equal(t, Target{Dir: filepath.Join(root, ".husky"), HooksPath: ".husky/_", Manager: ManagerHusky}, target)
+
+	target = Resolve(filepath.Join(root, ".husky"), ".husky")
+	assert.Equal(t, ManagerHusky, target.Manager)
+	assert.Equal(t, filepath.Join(root, ".husky"), target.Dir)
+
+	hooks := filepath.Join(root, "hooks")
+	require.NoError(t, os.MkdirAll(hooks, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks, Name), []byte("#!/bin/sh\ncall_lefthook run \"$@\"\n"), 0o755))
+	assert.Equal(t, ManagerLefthook, Resolve(hooks, "").Manager)
+}
+
+func TestInstallChainsAndUninstallRestores(t *testing.T) {
+	target := Target{Dir: filepath.Join(t.TempDir(), "hooks")}
+
+	result, err := Install(target)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Path: target.Path()}, result)
+	assert.Equal(t, State{Exists: true, Installed: true, Executable: true}, Inspect(target))
+
+	restored, err := Uninstall(target)
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+	assert.Equal(t, State{}, Inspect(target))
+
+	previous := "#!/bin/sh\necho previous\n"
+	require.NoError(t, os.WriteFile(target.Path(), []byte(previous), 0o755))
+	result, err = Install(target)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Path: target.Path(), Chained: target.ChainedPath()}, result)
+	assert.Equal(t, State{Exists: true, Installed: true, Executable: true, Chained: true}, Inspect(target))
+
+	result, err = Install(target)
+	require.NoError(t, err)
+	assert.True(t, result.Updated)
+	assert.Equal(t, target.ChainedPath(), result.Chained)
+
+	restored, err = Uninstall(target)
+	require.NoError(t, err)
+	assert.Equal(t, target.Path(), restored)
+	data, err := os.ReadFile(target.Path())
+	require.NoError(t, err)
+	assert.Equal(t, previous, string(data))
+
+	_, err = Uninstall(target)
+	assert.ErrorIs(t, err, ErrNotInstalled)
+}
+
+func TestInstallRefusesTwoForeignHooks(t *testing.T) {
+	target := Target{Dir: t.TempDir()}
+	require.NoError(t, os.WriteFile(target.Path(), []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.WriteFile(target.ChainedPath(), []byte("#!/bin/sh\n"), 0o755))
+
+	_, err := Install(target)
+	assert.ErrorContains(t, err, "remove one of them")
+}
+
+func TestScriptRunsChainedHookFirst(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	target := Target{Dir: t.TempDir()}
+	log := filepath.Join(target.Dir, "log")
+	require.NoError(t, os.WriteFile(target.Path(), []byte("#!/bin/sh\necho \"previous $2\" >> "+log+"\n"), 0o755))
+	_, err := Install(target)
+	require.NoError(t, err)
+
+	// A commit with -m: the chained hook runs and gets the source.
+	cmd := exec.Command(target.Path(), filepath.Join(target.Dir, "COMMIT_EDITMSG"), "message")
+	cmd.Env = []string{"PATH=" + target.Dir}
+	require.NoError(t, cmd.Run())
+	data, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, "previous message\n", string(data))
+
+	require.NoError(t, os.WriteFile(target.ChainedPath(), []byte("#!/bin/sh\nexit 3\n"), 0o755))
+	cmd = exec.Command(target.Path(), filepath.Join(target.Dir, "COMMIT_EDITMSG"))
+	cmd.Env = []string{"PATH=" + target.Dir}
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, cmd.Run(), &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode(), "a failing chained hook stops the commit")
+}
+
+func TestScriptKeepsCommentsAndMessages(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	target := Target{Dir: t.TempDir()}
+	_, err := Install(target)
+	require.NoError(t, err)
+	bin := t.TempDir()
+	args := filepath.Join(bin, "args")
+	// The fake gmc logs its arguments and writes a message to the --out file.
+	gmc := "#!/bin/sh\necho \"$@\" > " + args + "\n" +
+		"while [ \"$1\" != --out ]; do shift; done\necho 'feat: generated' > \"$2\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "gmc"), []byte(gmc), 0o755))
+	env := []string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH")}
+	msg := filepath.Join(target.Dir, "COMMIT_EDITMSG")
+
+	run := func(content string, source ...string) string {
+		require.NoError(t, os.WriteFile(msg, []byte(content), 0o644))
+		cmd := exec.Command(target.Path(), append([]string{msg}, source...)...)
+		cmd.Env = env
+		require.NoError(t, cmd.Run())
+		data, err := os.ReadFile(msg)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// git commit without a message: the message goes above git's comments.
+	comments := "\n# Please enter the commit message for your changes.\n"
+	assert.Equal(t, "feat: generated\n"+comments, run(comments))
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "--no-history")
+
+	// A message from -m, or from gmc's own commit, is kept.
+	assert.Equal(t, "fix: mine\n"+comments, run("fix: mine\n"+comments, "message"))
+	// Templates, merges and --amend are left alone.
+	assert.Equal(t, comments, run(comments, "template"))
+	assert.Equal(t, comments, run(comments, "commit", "HEAD"))
+}
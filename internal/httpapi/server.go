@@ -0,0 +1,126 @@
+// Package httpapi serves gmc endpoints over HTTP: JSON requests and responses behind
+// bearer-token authentication.
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxBodyBytes caps request bodies; diffs are truncated for the prompt long before this.
+const maxBodyBytes = 16 << 20
+
+// Handler answers an endpoint with the JSON body of the request, which is "{}" for a
+// request without one. Its result is encoded as the JSON response.
+type Handler func(ctx context.Context, body json.RawMessage) (any, error)
+
+// Endpoint is a path the server answers, for one method.
+type Endpoint struct {
+	Method  string
+	Path    string
+	Handler Handler
+}
+
+// Error is an error with the HTTP status to answer it with. Handler errors of any
+// other type are internal server errors.
+type Error struct {
+	Status int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// BadRequest marks err as a problem with the request.
+func BadRequest(err error) error {
+	return &Error{Status: http.StatusBadRequest, Err: err}
+}
+
+// NewToken returns a random bearer token.
+func NewToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// NewHandler returns an http.Handler that serves endpoints to requests carrying
+// "Authorization: Bearer <token>". GET /healthz answers without a token.
+func NewHandler(token string, endpoints ...Endpoint) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	for _, endpoint := range endpoints {
+		mux.Handle(endpoint.Method+" "+endpoint.Path, authorize(token, serveEndpoint(endpoint.Handler)))
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no endpoint "+r.Method+" "+r.URL.Path)
+	})
+	return mux
+}
+
+func authorize(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gmc"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func serveEndpoint(handler Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		if len(strings.TrimSpace(string(body))) == 0 {
+			body = []byte("{}")
+		}
+		if !json.Valid(body) {
+			writeError(w, http.StatusBadRequest, "request body is not valid JSON")
+			return
+		}
+
+		result, err := handler(r.Context(), body)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var apiErr *Error
+			if errors.As(err, &apiErr) {
+				status = apiErr.Status
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
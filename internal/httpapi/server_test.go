@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler http.Handler, method, path, token, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+	return rec.Code, decoded
+}
+
+func TestHandlerRequiresBearerToken(t *testing.T) {
+	handler := NewHandler("secret", Endpoint{
+		Method: http.MethodGet,
+		Path:   "/ping",
+		Handler: func(context.Context, json.RawMessage) (any, error) {
+			return map[string]string{"pong": "yes"}, nil
+		},
+	})
+
+	code, body := serve(t, handler, http.MethodGet, "/ping", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "missing or invalid bearer token", body["error"])
+
+	code, _ = serve(t, handler, http.MethodGet, "/ping", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, body = serve(t, handler, http.MethodGet, "/ping", "secret", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "yes", body["pong"])
+
+	code, body = serve(t, handler, http.MethodGet, "/healthz", "", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+
+	code, body = serve(t, handler, http.MethodPost, "/ping", "secret", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "no endpoint POST /ping", body["error"])
+}
+
+func TestHandlerReportsErrors(t *testing.T) {
+	var received json.RawMessage
+	handler := NewHandler("secret", Endpoint{
+		Method: http.MethodPost,
+		Path:   "/echo",
+		Handler: func(_ context.Context, body json.RawMessage) (any, error) {
+			received = body
+			var req struct {
+				Fail string `json:"fail"`
+			}
+			_ = json.Unmarshal(body, &req)
+			switch req.Fail {
+			case "request":
+				return nil, BadRequest(errors.New("name is required"))
+			case "server":
+				return nil, errors.New("git failed")
+			}
+			return req, nil
+		},
+	})
+
+	code, _ := serve(t, handler, http.MethodPost, "/echo", "secret", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, "{}", string(received), "an empty body is an empty object")
+
+	code, body := serve(t, handler, http.MethodPost, "/echo", "secret", "{not json")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "request body is not valid JSON", body["error"])
+
+	code, body = serve(t, handler, http.MethodPost, "/echo", "secret", `{"fail": "request"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "name is required", body["error"])
+
+	code, body = serve(t, handler, http.MethodPost, "/echo", "secret", `{"fail": "server"}`)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "git failed", body["error"])
+}
+
+func TestNewToken(t *testing.T) {
+	first, err := NewToken()
+	require.NoError(t, err)
+	second, err := NewToken()
+	require.NoError(t, err)
+	assert.Len(t, first, 48)
+	assert.NotEqual(t, first, second)
+}
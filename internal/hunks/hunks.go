@@ -0,0 +1,119 @@
+// Package hunks lets gmc -i pick the staged and unstaged hunks a commit contains.
+package hunks
+
+import (
+	"strings"
+
+	"github.com/samzong/gmc/internal/formatter"
+)
+
+// Hunk is one @@ section of a file diff, or the whole diff of a file that can only be
+// staged as one unit.
+type Hunk struct {
+	Text     string
+	Selected bool
+}
+
+// Label is the hunk's @@ line.
+func (h Hunk) Label() string {
+	line, _, _ := strings.Cut(h.Text, "\n")
+	return line
+}
+
+// File is one file's staged or unstaged changes. A file with both appears twice.
+type File struct {
+	Path   string
+	Staged bool
+	Header string
+	// Kind describes a whole-file change: "new file", "deleted", "binary" or
+	// "mode change". It is empty for a file whose hunks are picked one by one.
+	Kind  string
+	Hunks []Hunk
+}
+
+// Whole reports whether the file's change is picked as one unit.
+func (f File) Whole() bool {
+	return f.Kind != ""
+}
+
+// Parse turns the staged and unstaged diffs from git.Client.GetHunkDiff into files,
+// staged first. Staged hunks start selected, unstaged ones do not, so confirming
+// without changes commits what is already staged.
+func Parse(staged string, unstaged string) []File {
+	files := parseFiles(staged, true)
+	return append(files, parseFiles(unstaged, false)...)
+}
+
+func parseFiles(diff string, staged bool) []File {
+	var files []File
+	// Without the final newline, the last hunk does not gain an empty line.
+	for _, diffFile := range formatter.ParseDiff(strings.TrimSuffix(diff, "\n")) {
+		file := File{Path: diffFile.Path, Staged: staged, Header: diffFile.Header, Kind: wholeKind(diffFile)}
+		if file.Whole() {
+			file.Hunks = []Hunk{{Text: strings.Join(diffFile.Hunks, ""), Selected: staged}}
+		} else {
+			for _, text := range diffFile.Hunks {
+				file.Hunks = append(file.Hunks, Hunk{Text: text, Selected: staged})
+			}
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func wholeKind(file formatter.DiffFile) string {
+	switch {
+	case strings.Contains(file.Header, "\nnew file mode "):
+		return "new file"
+	case strings.Contains(file.Header, "\ndeleted file mode "):
+		return "deleted"
+	case file.IsBinary:
+		return "binary"
+	case file.HasModeChange || len(file.Hunks) == 0:
+		return "mode change"
+	}
+	return ""
+}
+
+// Selection builds the patches that make the index hold exactly the selected hunks:
+// stage adds the selected unstaged hunks, and unstage, applied in reverse, removes the
+// staged hunks that were deselected. Pass them to git.Client.StageSelection.
+func Selection(files []File) (stage string, unstage string) {
+	var stageBuilder, unstageBuilder strings.Builder
+	for _, file := range files {
+		// A staged hunk leaves the index when deselected; an unstaged one enters it
+		// when selected.
+		builder := &stageBuilder
+		if file.Staged {
+			builder = &unstageBuilder
+		}
+
+		var picked []string
+		for _, hunk := range file.Hunks {
+			if hunk.Selected != file.Staged {
+				picked = append(picked, hunk.Text)
+			}
+		}
+		if len(picked) == 0 {
+			continue
+		}
+		builder.WriteString(file.Header)
+		for _, text := range picked {
+			builder.WriteString(text)
+		}
+	}
+	return stageBuilder.String(), unstageBuilder.String()
+}
+
+// Count returns the number of selected hunks and the number of hunks in all.
+func Count(files []File) (selected int, total int) {
+	for _, file := range files {
+		for _, hunk := range file.Hunks {
+			total++
+			if hunk.Selected {
+				selected++
+			}
+		}
+	}
+	return selected, total
+}
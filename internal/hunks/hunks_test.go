@@ -0,0 +1,93 @@
+package hunks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stagedDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var a = 1
++var a = 2
+ var b = 1
+@@ -20,3 +20,3 @@ func main() {
+ 	x()
+-	y()
++	z()
+ }
+diff --git a/notes.txt b/notes.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/notes.txt
+@@ -0,0 +1 @@
++todo
+`
+
+const unstagedDiff = `diff --git a/main.go b/main.go
+index 2222222..4444444 100644
+--- a/main.go
++++ b/main.go
+@@ -30,2 +30,3 @@ func helper() {
+ 	return
++	// done
+ }
+`
+
+func TestParse(t *testing.T) {
+	files := Parse(stagedDiff, unstagedDiff)
+	require.Len(t, files, 3)
+
+	assert.Equal(t, "main.go", files[0].Path)
+	assert.True(t, files[0].Staged)
+	assert.False(t, files[0].Whole())
+	require.Len(t, files[0].Hunks, 2)
+	assert.Equal(t, "@@ -20,3 +20,3 @@ func main() {", files[0].Hunks[1].Label())
+	assert.True(t, files[0].Hunks[1].Selected, "staged hunks start selected")
+
+	assert.Equal(t, "new file", files[1].Kind)
+	require.Len(t, files[1].Hunks, 1)
+	assert.Equal(t, "@@ -0,0 +1 @@\n+todo\n", files[1].Hunks[0].Text, "the last hunk gains no empty line")
+
+	assert.Equal(t, "main.go", files[2].Path)
+	assert.False(t, files[2].Staged)
+	assert.False(t, files[2].Hunks[0].Selected, "unstaged hunks start deselected")
+
+	selected, total := Count(files)
+	assert.Equal(t, 3, selected)
+	assert.Equal(t, 4, total)
+}
+
+func TestSelection(t *testing.T) {
+	files := Parse(stagedDiff, unstagedDiff)
+
+	stage, unstage := Selection(files)
+	assert.Empty(t, stage, "the default selection keeps the index as it is")
+	assert.Empty(t, unstage)
+
+	files[0].Hunks[0].Selected = false
+	files[2].Hunks[0].Selected = true
+	stage, unstage = Selection(files)
+	assert.Equal(t, unstagedDiff, stage)
+	assert.Equal(t, `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var a = 1
++var a = 2
+ var b = 1
+`, unstage)
+
+	files[1].Hunks[0].Selected = false
+	_, unstage = Selection(files)
+	assert.Contains(t, unstage, "new file mode 100644\n")
+	assert.Contains(t, unstage, "+todo\n")
+}
@@ -0,0 +1,243 @@
+package hunks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samzong/gmc/internal/gmcerrors"
+)
+
+// ErrCanceled is returned when the picker is quit without confirming.
+var ErrCanceled = gmcerrors.Mark(errors.New("hunk selection canceled"), gmcerrors.ErrUserCancelled)
+
+const (
+	previewLines      = 8
+	defaultListHeight = 15
+)
+
+// Picker lets the user toggle hunks on the terminal.
+type Picker struct {
+	// In and Out default to stdin and stdout. gmc draws the picker on stderr.
+	In  io.Reader
+	Out io.Writer
+}
+
+// PickHunks shows files and returns them with the selection the user confirmed.
+func (p Picker) PickHunks(files []File) ([]File, error) {
+	if len(files) == 0 {
+		return files, nil
+	}
+
+	var opts []tea.ProgramOption
+	if p.In != nil {
+		opts = append(opts, tea.WithInput(p.In))
+	}
+	if p.Out != nil {
+		opts = append(opts, tea.WithOutput(p.Out))
+	}
+
+	final, err := tea.NewProgram(newModel(files), opts...).Run()
+	if err != nil {
+		return nil, fmt.Errorf("hunk picker failed: %w", err)
+	}
+	m := final.(model)
+	if !m.confirmed {
+		return nil, ErrCanceled
+	}
+	return m.files, nil
+}
+
+// row is a line of the picker: a file, or one of its hunks when hunk >= 0.
+type row struct {
+	file int
+	hunk int
+}
+
+type model struct {
+	files     []File
+	rows      []row
+	cursor    int
+	offset    int
+	height    int
+	confirmed bool
+}
+
+func newModel(files []File) model {
+	m := model{files: make([]File, len(files))}
+	for i, file := range files {
+		file.Hunks = append([]Hunk(nil), file.Hunks...)
+		m.files[i] = file
+
+		m.rows = append(m.rows, row{file: i, hunk: -1})
+		if !file.Whole() {
+			for j := range file.Hunks {
+				m.rows = append(m.rows, row{file: i, hunk: j})
+			}
+		}
+	}
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return nil
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.rows)-1 {
+				m.cursor++
+			}
+		case "home", "g":
+			m.cursor = 0
+		case "end", "G":
+			m.cursor = len(m.rows) - 1
+		case " ", "x":
+			m.toggle(m.rows[m.cursor])
+		case "a":
+			m.toggleAll()
+		case "enter":
+			m.confirmed = true
+			return m, tea.Quit
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	m.scroll()
+	return m, nil
+}
+
+// toggle flips a hunk. On a file row it selects every hunk of the file, or deselects
+// them when all are selected already.
+func (m *model) toggle(r row) {
+	hunks := m.files[r.file].Hunks
+	if r.hunk >= 0 {
+		hunks[r.hunk].Selected = !hunks[r.hunk].Selected
+		return
+	}
+	selected := !allSelected(hunks)
+	for i := range hunks {
+		hunks[i].Selected = selected
+	}
+}
+
+func (m *model) toggleAll() {
+	selected, total := Count(m.files)
+	for i := range m.files {
+		for j := range m.files[i].Hunks {
+			m.files[i].Hunks[j].Selected = selected < total
+		}
+	}
+}
+
+func (m *model) scroll() {
+	height := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+}
+
+func (m model) listHeight() int {
+	if m.height == 0 {
+		return defaultListHeight
+	}
+	// Leave room for the title, the counter and the preview.
+	return max(m.height-previewLines-5, 3)
+}
+
+func (m model) View() string {
+	var b strings.Builder
+	b.WriteString("Pick the hunks to commit (↑/↓ move, space toggle, a all, enter confirm, q cancel)\n\n")
+
+	end := min(m.offset+m.listHeight(), len(m.rows))
+	for i := m.offset; i < end; i++ {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		b.WriteString(cursor + m.rowLabel(m.rows[i]) + "\n")
+	}
+
+	selected, total := Count(m.files)
+	fmt.Fprintf(&b, "\n%d of %d hunks selected\n", selected, total)
+	b.WriteString(m.preview())
+	return b.String()
+}
+
+func (m model) rowLabel(r row) string {
+	file := m.files[r.file]
+	if r.hunk >= 0 {
+		return "  " + checkbox(file.Hunks[r.hunk].Selected) + " " + file.Hunks[r.hunk].Label()
+	}
+
+	state := "unstaged"
+	if file.Staged {
+		state = "staged"
+	}
+	if file.Whole() {
+		state += ", " + file.Kind
+	}
+	mark := "[~]"
+	switch {
+	case allSelected(file.Hunks):
+		mark = "[x]"
+	case noneSelected(file.Hunks):
+		mark = "[ ]"
+	}
+	return fmt.Sprintf("%s %s (%s)", mark, file.Path, state)
+}
+
+// preview shows the start of the hunk under the cursor, or of the file's first hunk.
+func (m model) preview() string {
+	r := m.rows[m.cursor]
+	file := m.files[r.file]
+	text := file.Hunks[max(r.hunk, 0)].Text
+	if file.Whole() && text == "" {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) > previewLines {
+		lines = append(lines[:previewLines], fmt.Sprintf("... (%d more lines)", len(lines)-previewLines))
+	}
+	return "\n" + strings.Join(lines, "\n") + "\n"
+}
+
+func checkbox(selected bool) string {
+	if selected {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func allSelected(hunks []Hunk) bool {
+	for _, hunk := range hunks {
+		if !hunk.Selected {
+			return false
+		}
+	}
+	return true
+}
+
+func noneSelected(hunks []Hunk) bool {
+	for _, hunk := range hunks {
+		if hunk.Selected {
+			return false
+		}
+	}
+	return true
+}
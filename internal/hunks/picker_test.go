@@ -0,0 +1,74 @@
+package hunks
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func press(m model, keys ...string) model {
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		}
+		next, _ := m.Update(msg)
+		m = next.(model)
+	}
+	return m
+}
+
+func TestPickerToggles(t *testing.T) {
+	files := Parse(stagedDiff, unstagedDiff)
+	m := newModel(files)
+	// main.go, its two hunks, notes.txt, then unstaged main.go and its hunk.
+	assert.Len(t, m.rows, 6)
+
+	m = press(m, "down", " ")
+	assert.False(t, m.files[0].Hunks[0].Selected)
+	assert.True(t, files[0].Hunks[0].Selected, "the caller's files are not changed")
+	assert.Contains(t, m.View(), ">   [ ] @@ -1,3 +1,3 @@")
+	assert.Contains(t, m.View(), "[~] main.go (staged)")
+
+	m = press(m, "up", " ")
+	assert.True(t, allSelected(m.files[0].Hunks), "a partly selected file is selected in full")
+	m = press(m, " ")
+	assert.True(t, noneSelected(m.files[0].Hunks))
+
+	m = press(m, "down", "down", "down", " ")
+	assert.False(t, m.files[1].Hunks[0].Selected)
+	assert.Contains(t, m.View(), "> [ ] notes.txt (staged, new file)")
+
+	m = press(m, "a")
+	selected, total := Count(m.files)
+	assert.Equal(t, total, selected)
+	m = press(m, "a")
+	selected, _ = Count(m.files)
+	assert.Zero(t, selected)
+
+	assert.False(t, press(m, "q").confirmed)
+	assert.True(t, press(m, "enter").confirmed)
+}
+
+func TestPickerScrollsToCursor(t *testing.T) {
+	m := newModel(Parse(stagedDiff, unstagedDiff))
+	next, _ := m.Update(tea.WindowSizeMsg{Height: previewLines + 8})
+	m = next.(model)
+	assert.Equal(t, 3, m.listHeight())
+
+	m = press(m, "down", "down", "down", "down")
+	assert.Equal(t, 2, m.offset)
+	assert.Contains(t, m.View(), "> [ ] main.go (unstaged)")
+	assert.NotContains(t, m.View(), "main.go (staged)", "rows above the window are hidden")
+
+	m = press(m, "up", "up", "up")
+	assert.Equal(t, 1, m.offset)
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// SuggestBranchNames asks for count branch names for change, a description of the
+// work or a staged diff, as "type/short-slug". The names are returned as the LLM
+// wrote them, for the branch package to normalize.
+func (c *Client) SuggestBranchNames(change string, count int, model string) ([]string, error) {
+	change, err := c.guardPrompt(change)
+	if err != nil {
+		return nil, err
+	}
+	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: "You name git branches. You reply with branch names only, one per line.",
+		},
+		{
+			Role: openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Suggest %d different git branch names for the change below. "+
+				"Write each as <type>/<slug>, where type is feat, fix, docs, refactor, test or chore "+
+				"and slug is 2 to 5 lowercase words joined by hyphens. "+
+				"Put the best name first and write nothing else.\n\n", count) + change,
+		},
+	}
+
+	started := time.Now()
+	resp, err := client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:    chosenModel,
+			Messages: messages,
+		},
+	)
+	logCompletion("branch", chosenModel, messages[1].Content, resp, started, err)
+	if err != nil {
+		return nil, callError(err, started)
+	}
+	c.reportUsage(chosenModel, &resp.Usage)
+
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return nil, fmt.Errorf("LLM returned empty response: %w", ErrLLM)
+	}
+	names := bullets(strings.Split(stripMarkdownFence(strings.TrimSpace(resp.Choices[0].Message.Content)), "\n"))
+	if len(names) > count {
+		names = names[:count]
+	}
+	return names, nil
+}
@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// CheckStatus is the outcome of a single API key check.
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// KeyCheck is one line of the API key diagnosis.
+type KeyCheck struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+}
+
+// DiagnoseKey checks that the configured API key is accepted by the provider, can use
+// model, and has quota left, so permission problems show up before generation does.
+// Checks a provider does not support are reported as warnings.
+func (c *Client) DiagnoseKey(model string) ([]KeyCheck, error) {
+	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
+	if errors.Is(err, errMissingAPIKey) {
+		return []KeyCheck{{Name: "api key", Status: CheckFail, Detail: err.Error()}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	var checks []KeyCheck
+	models, err := client.ListModels(ctx)
+	switch {
+	case err == nil:
+		checks = append(checks, KeyCheck{Name: "api key", Status: CheckOK,
+			Detail: fmt.Sprintf("accepted, %d models visible", len(models.Models))})
+	case statusCode(err) == http.StatusNotFound || statusCode(err) == http.StatusMethodNotAllowed:
+		checks = append(checks, KeyCheck{Name: "api key", Status: CheckWarn,
+			Detail: "provider has no model list endpoint; checked with the model request only"})
+	default:
+		check := describeKeyError("api key", err)
+		checks = append(checks, check)
+		if check.Status == CheckFail {
+			return checks, nil
+		}
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       chosenModel,
+		Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Reply with OK."}},
+		MaxTokens:   1,
+		Temperature: 0,
+	})
+	if err != nil {
+		return append(checks, describeKeyError("model "+chosenModel, err)), nil
+	}
+	checks = append(checks, KeyCheck{Name: "model " + chosenModel, Status: CheckOK, Detail: "accessible"})
+
+	if quota, ok := describeRateLimits(resp.GetRateLimitHeaders()); ok {
+		checks = append(checks, quota)
+	}
+	return checks, nil
+}
+
+func statusCode(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	return 0
+}
+
+// describeKeyError turns a provider error into an actionable check result instead of
+// a bare status code.
+func describeKeyError(name string, err error) KeyCheck {
+	message := err.Error()
+	code := ""
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		message = apiErr.Message
+		if apiErr.Code != nil {
+			code = fmt.Sprint(apiErr.Code)
+		}
+		if code == "" {
+			code = apiErr.Type
+		}
+	}
+
+	switch statusCode(err) {
+	case http.StatusUnauthorized:
+		return KeyCheck{Name: name, Status: CheckFail,
+			Detail: "key rejected (invalid, revoked, or expired): " + message}
+	case http.StatusForbidden:
+		return KeyCheck{Name: name, Status: CheckFail,
+			Detail: "key lacks permission (check key scopes and organization or project restrictions): " + message}
+	case http.StatusNotFound:
+		return KeyCheck{Name: name, Status: CheckFail, Detail: "not available to this key: " + message}
+	case http.StatusTooManyRequests:
+		if code == "insufficient_quota" {
+			return KeyCheck{Name: name, Status: CheckFail, Detail: "quota exhausted (check billing): " + message}
+		}
+		return KeyCheck{Name: name, Status: CheckWarn, Detail: "rate limited: " + message}
+	}
+	if hint := networkHint(err); hint != "" {
+		message += "; " + hint
+	}
+	return KeyCheck{Name: name, Status: CheckFail, Detail: message}
+}
+
+func describeRateLimits(limits openai.RateLimitHeaders) (KeyCheck, bool) {
+	if limits.LimitRequests == 0 && limits.LimitTokens == 0 {
+		return KeyCheck{}, false
+	}
+
+	detail := fmt.Sprintf("%d/%d requests and %d/%d tokens left in the current window",
+		limits.RemainingRequests, limits.LimitRequests, limits.RemainingTokens, limits.LimitTokens)
+	if limits.RemainingRequests == 0 || limits.RemainingTokens == 0 {
+		return KeyCheck{Name: "rate limits", Status: CheckWarn,
+			Detail: fmt.Sprintf("%s; resets in %s", detail, limits.ResetRequests)}, true
+	}
+	return KeyCheck{Name: "rate limits", Status: CheckOK, Detail: detail}, true
+}
@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDoctorServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("model", "gpt-4.1-mini")
+	viper.Set("api_base", server.URL)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error":{"message":%q,"type":"invalid_request_error","code":%q}}`, message, code)
+}
+
+func TestDiagnoseKey_OK(t *testing.T) {
+	newDoctorServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/models" {
+			fmt.Fprint(w, `{"object":"list","data":[{"id":"gpt-4.1-mini"},{"id":"gpt-4o"}]}`)
+			return
+		}
+		w.Header().Set("x-ratelimit-limit-requests", "500")
+		w.Header().Set("x-ratelimit-remaining-requests", "499")
+		w.Header().Set("x-ratelimit-limit-tokens", "20000")
+		w.Header().Set("x-ratelimit-remaining-tokens", "19990")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"OK"}}]}`)
+	})
+
+	checks, err := NewClient(Options{}).DiagnoseKey("")
+	require.NoError(t, err)
+	assert.Equal(t, []KeyCheck{
+		{Name: "api key", Status: CheckOK, Detail: "accepted, 2 models visible"},
+		{Name: "model gpt-4.1-mini", Status: CheckOK, Detail: "accessible"},
+		{Name: "rate limits", Status: CheckOK, Detail: "499/500 requests and 19990/20000 tokens left in the current window"},
+	}, checks)
+}
+
+func TestDiagnoseKey_RejectedKeyStops(t *testing.T) {
+	calls := 0
+	newDoctorServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		writeAPIError(w, http.StatusUnauthorized, "invalid_api_key", "Incorrect API key provided")
+	})
+
+	checks, err := NewClient(Options{}).DiagnoseKey("")
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	assert.Equal(t, CheckFail, checks[0].Status)
+	assert.Contains(t, checks[0].Detail, "expired")
+	assert.Equal(t, 1, calls)
+}
+
+func TestDiagnoseKey_ModelAndQuotaErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		code   string
+		want   CheckStatus
+		detail string
+	}{
+		{name: "no model access", status: http.StatusNotFound, code: "model_not_found", want: CheckFail,
+			detail: "not available to this key"},
+		{name: "org restriction", status: http.StatusForbidden, code: "", want: CheckFail,
+			detail: "organization or project restrictions"},
+		{name: "quota", status: http.StatusTooManyRequests, code: "insufficient_quota", want: CheckFail,
+			detail: "quota exhausted"},
+		{name: "rate limited", status: http.StatusTooManyRequests, code: "rate_limit_exceeded", want: CheckWarn,
+			detail: "rate limited"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newDoctorServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/models" {
+					http.NotFound(w, r)
+					return
+				}
+				writeAPIError(w, tt.status, tt.code, "provider message")
+			})
+
+			checks, err := NewClient(Options{}).DiagnoseKey("")
+			require.NoError(t, err)
+			require.Len(t, checks, 2)
+			assert.Equal(t, CheckWarn, checks[0].Status, "missing model list is only a warning")
+			assert.Equal(t, tt.want, checks[1].Status)
+			assert.Contains(t, checks[1].Detail, tt.detail)
+			assert.Contains(t, checks[1].Detail, "provider message")
+		})
+	}
+}
+
+func TestDiagnoseKey_MissingAPIKey(t *testing.T) {
+	viper.Reset()
+	viper.Set("api_key", "")
+
+	checks, err := NewClient(Options{}).DiagnoseKey("")
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	assert.Equal(t, CheckFail, checks[0].Status)
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Explanation is what a set of commits changed, for a reviewer.
+type Explanation struct {
+	// Motivation is why the change was made, in a few sentences.
+	Motivation string `json:"motivation"`
+	// Changes are the key changes, one per entry.
+	Changes []string `json:"key_changes"`
+	// Risks are the areas a reviewer should check, one per entry.
+	Risks []string `json:"risk_areas"`
+}
+
+// bulletMarker matches the list marker at the start of a line.
+var bulletMarker = regexp.MustCompile(`^([-*•]|\d+[.)])\s+`)
+
+// explanationSections are the headings ExplainChanges asks for, in order.
+var explanationSections = []string{"MOTIVATION:", "KEY CHANGES:", "RISK AREAS:"}
+
+// ExplainChanges explains the commits in changes, their messages and diffs, as the
+// motivation, the key changes and the areas at risk.
+func (c *Client) ExplainChanges(changes string, model string) (Explanation, error) {
+	changes, err := c.guardPrompt(changes)
+	if err != nil {
+		return Explanation{}, err
+	}
+	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
+	if err != nil {
+		return Explanation{}, err
+	}
+	defer cancel()
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role: openai.ChatMessageRoleSystem,
+			Content: "You are a senior engineer who explains code changes to a reviewer " +
+				"who has not seen them.",
+		},
+		{
+			Role: openai.ChatMessageRoleUser,
+			Content: "Explain what these commits change. Respond exactly in this format:\n" +
+				"MOTIVATION:\n<why the change was made, in at most 3 sentences>\n" +
+				"KEY CHANGES:\n- <one change per bullet, at most 8, naming the files or functions>\n" +
+				"RISK AREAS:\n- <what could break or needs a careful review, at most 5 bullets, " +
+				"or - None>\n\n" + changes,
+		},
+	}
+
+	started := time.Now()
+	resp, err := client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:    chosenModel,
+			Messages: messages,
+		},
+	)
+	logCompletion("explain", chosenModel, messages[1].Content, resp, started, err)
+	if err != nil {
+		return Explanation{}, callError(err, started)
+	}
+	c.reportUsage(chosenModel, &resp.Usage)
+
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return Explanation{}, fmt.Errorf("LLM returned empty response: %w", ErrLLM)
+	}
+	return parseExplanation(resp.Choices[0].Message.Content)
+}
+
+// parseExplanation reads the MOTIVATION, KEY CHANGES and RISK AREAS sections of a
+// reply. A "None" risk bullet leaves Risks empty.
+func parseExplanation(response string) (Explanation, error) {
+	sections := make(map[string][]string)
+	current := ""
+	for _, line := range strings.Split(stripMarkdownFence(strings.TrimSpace(response)), "\n") {
+		line = strings.TrimSpace(line)
+		if section, rest, ok := cutSection(line); ok {
+			current, line = section, rest
+		}
+		if current == "" || line == "" {
+			continue
+		}
+		sections[current] = append(sections[current], line)
+	}
+
+	explanation := Explanation{
+		Motivation: strings.Join(sections[explanationSections[0]], " "),
+		Changes:    bullets(sections[explanationSections[1]]),
+		Risks:      bullets(sections[explanationSections[2]]),
+	}
+	if explanation.Motivation == "" && len(explanation.Changes) == 0 {
+		return Explanation{}, fmt.Errorf("LLM response missing MOTIVATION and KEY CHANGES sections: %w", ErrLLM)
+	}
+	if len(explanation.Risks) == 1 && strings.EqualFold(strings.TrimSuffix(explanation.Risks[0], "."), "none") {
+		explanation.Risks = nil
+	}
+	return explanation, nil
+}
+
+// cutSection reports whether line, in any case and with Markdown heading or bold
+// markers, starts one of the explanationSections, and returns the section and the
+// text after it on the line.
+func cutSection(line string) (string, string, bool) {
+	heading := strings.ToUpper(strings.TrimLeft(strings.ReplaceAll(line, "*", ""), "# "))
+	for _, section := range explanationSections {
+		if strings.HasPrefix(heading, section) {
+			_, rest, _ := strings.Cut(line, ":")
+			return section, strings.TrimSpace(strings.TrimLeft(rest, "* ")), true
+		}
+	}
+	return "", "", false
+}
+
+// bullets returns lines without their "- ", "* " or "1. " markers.
+func bullets(lines []string) []string {
+	var items []string
+	for _, line := range lines {
+		line = bulletMarker.ReplaceAllString(line, "")
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
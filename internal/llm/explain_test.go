@@ -0,0 +1,28 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExplanation(t *testing.T) {
+	got, err := parseExplanation("MOTIVATION:\nCommits failed without network.\nNow they do not.\n" +
+		"KEY CHANGES:\n- Add HeuristicMessage\n* Add --offline\n" +
+		"RISK AREAS:\n1. Fallback hides auth errors\n")
+	require.NoError(t, err)
+	assert.Equal(t, Explanation{
+		Motivation: "Commits failed without network. Now they do not.",
+		Changes:    []string{"Add HeuristicMessage", "Add --offline"},
+		Risks:      []string{"Fallback hides auth errors"},
+	}, got)
+
+	got, err = parseExplanation("```markdown\n## Motivation: Speed up scans.\n" +
+		"**Key changes:**\n- Cache results\n**Risk areas**:\n- None.\n```")
+	require.NoError(t, err)
+	assert.Equal(t, Explanation{Motivation: "Speed up scans.", Changes: []string{"Cache results"}}, got)
+
+	_, err = parseExplanation("This commit does things.")
+	assert.ErrorIs(t, err, ErrLLM)
+}
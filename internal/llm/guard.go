@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/guard"
+)
+
+// guardPrompt checks a prompt that carries a diff for likely secrets before it is
+// sent. With guard.redact it returns the prompt with them redacted; otherwise the
+// request fails with guard.ErrSecretsFound. AllowSecrets turns the check off.
+func (c *Client) guardPrompt(prompt string) (string, error) {
+	if c != nil && c.allowSecrets {
+		return prompt, nil
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.Guard.Redact {
+		redacted, findings := guard.Redact(prompt)
+		if len(findings) > 0 && c != nil && c.onRedact != nil {
+			c.onRedact(findings)
+		}
+		return redacted, nil
+	}
+
+	findings := guard.Scan(prompt)
+	if len(findings) == 0 {
+		return prompt, nil
+	}
+	lines := make([]string, len(findings))
+	for i, finding := range findings {
+		lines[i] = "  - " + finding.String()
+	}
+	return "", fmt.Errorf("%w:\n%s\nRemove them from the changes, set guard.redact: true to send them redacted, "+
+		"or pass --allow-secrets if they are not secrets", guard.ErrSecretsFound, strings.Join(lines, "\n"))
+}
@@ -4,67 +4,167 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/debuglog"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/gmcerrors"
+	"github.com/samzong/gmc/internal/guard"
 	"github.com/sashabaranov/go-openai"
 )
 
 var ErrLLM = errors.New("LLM error")
 
+// PartialResponseError is returned when the response stream fails (for example on
+// timeout) after some content was already received.
+type PartialResponseError struct {
+	Content string
+	Err     error
+}
+
+func (e *PartialResponseError) Error() string {
+	return fmt.Sprintf("%v (response cut off after %d bytes)", e.Err, len(e.Content))
+}
+
+func (e *PartialResponseError) Unwrap() error {
+	return e.Err
+}
+
 type Options struct {
+	// Timeout bounds each request, from connecting to the end of the reply. Zero is
+	// 30 seconds, and NoTimeout waits as long as the reply takes.
 	Timeout time.Duration
+	// ConnectTimeout bounds connecting to the endpoint, TLS handshake included. Zero is
+	// 10 seconds, and NoTimeout leaves connecting to Timeout alone.
+	ConnectTimeout time.Duration
+	// APIKey and APIBase override the configured values, for checking credentials
+	// before they are saved.
+	APIKey  string
+	APIBase string
+	// OnUsage is called with the token usage of each request the API reports usage for.
+	OnUsage func(Usage)
+	// Temperature overrides the temperature config for commit messages, as --temperature.
+	Temperature *float64
+	// AllowSecrets sends diffs without checking them for likely secrets, as --allow-secrets.
+	AllowSecrets bool
+	// OnRedact is called with the likely secrets guard.redact replaced in a prompt.
+	OnRedact func([]guard.Finding)
 }
 
 type Client struct {
-	timeout time.Duration
+	timeout        time.Duration
+	connectTimeout time.Duration
+	apiKey         string
+	apiBase        string
+	onUsage        func(Usage)
+	temperature    *float64
+	allowSecrets   bool
+	onRedact       func([]guard.Finding)
 }
 
-const defaultTimeout = 30 * time.Second
+const (
+	defaultTimeout        = 30 * time.Second
+	defaultConnectTimeout = 10 * time.Second
+	// NoTimeout is the Timeout or ConnectTimeout that sets no limit, for local models
+	// that take minutes to reply.
+	NoTimeout time.Duration = -1
+)
 
 func NewClient(opts Options) *Client {
-	timeout := opts.Timeout
-	if timeout <= 0 {
-		timeout = defaultTimeout
+	debuglog.AddSecret(opts.APIKey)
+	return &Client{
+		timeout:        opts.Timeout,
+		connectTimeout: opts.ConnectTimeout,
+		apiKey:         opts.APIKey,
+		apiBase:        opts.APIBase,
+		onUsage:        opts.OnUsage,
+		temperature:    opts.Temperature,
+		allowSecrets:   opts.AllowSecrets,
+		onRedact:       opts.OnRedact,
 	}
-	return &Client{timeout: timeout}
 }
 
 var (
 	versionPattern   = regexp.MustCompile(`(?i)version:\s*(v?\d+\.\d+\.\d+)`)
 	reasonPattern    = regexp.MustCompile(`(?is)reason:\s*(.+)$`)
-	errMissingAPIKey = errors.New(
+	errMissingAPIKey = gmcerrors.Mark(errors.New(
 		"API key not set, please set the API key first: gmc config set apikey YOUR_API_KEY",
-	)
+	), gmcerrors.ErrConfigMissing)
 )
 
+// effectiveTimeout returns the request timeout, or 0 for none.
 func (c *Client) effectiveTimeout() time.Duration {
-	if c == nil || c.timeout <= 0 {
+	if c == nil {
 		return defaultTimeout
 	}
-	return c.timeout
+	return orDefault(c.timeout, defaultTimeout)
+}
+
+// effectiveConnectTimeout returns the connect timeout, or 0 for none.
+func (c *Client) effectiveConnectTimeout() time.Duration {
+	if c == nil {
+		return defaultConnectTimeout
+	}
+	return orDefault(c.connectTimeout, defaultConnectTimeout)
+}
+
+// orDefault returns timeout, fallback when it is zero, or 0 for NoTimeout.
+func orDefault(timeout, fallback time.Duration) time.Duration {
+	switch {
+	case timeout == 0:
+		return fallback
+	case timeout < 0:
+		return 0
+	}
+	return timeout
 }
 
 func (c *Client) newOpenAIClient(model string) (*openai.Client, context.Context, context.CancelFunc, string, error) {
+	return c.newOpenAIClientContext(context.Background(), model)
+}
+
+// newOpenAIClientContext is newOpenAIClient with the request context derived from parent.
+func (c *Client) newOpenAIClientContext(
+	parent context.Context, model string,
+) (*openai.Client, context.Context, context.CancelFunc, string, error) {
 	cfg, err := config.GetConfig()
 	if err != nil {
 		return nil, nil, nil, "", err
 	}
 
-	if cfg.APIKey == "" {
+	apiKey, apiBase := cfg.APIKey, cfg.APIBase
+	if c != nil && c.apiKey != "" {
+		apiKey, apiBase = c.apiKey, c.apiBase
+	}
+
+	if apiKey == "" {
 		return nil, nil, nil, "", errMissingAPIKey
 	}
 
-	clientConfig := openai.DefaultConfig(cfg.APIKey)
+	clientConfig := openai.DefaultConfig(apiKey)
 
-	if cfg.APIBase != "" {
-		clientConfig.BaseURL = cfg.APIBase
+	if apiBase != "" {
+		clientConfig.BaseURL = apiBase
+	}
+	httpClient, err := httpClientFor(cfg, c.effectiveConnectTimeout())
+	if err != nil {
+		return nil, nil, nil, "", err
 	}
+	clientConfig.HTTPClient = withExtraHeaders(httpClient, cfg.ExtraHeaders)
 
 	client := openai.NewClientWithConfig(clientConfig)
-	ctx, cancel := context.WithTimeout(context.Background(), c.effectiveTimeout())
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := c.effectiveTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
 
 	if model == "" {
 		model = cfg.Model
@@ -74,41 +174,113 @@ func (c *Client) newOpenAIClient(model string) (*openai.Client, context.Context,
 }
 
 func (c *Client) GenerateCommitMessage(prompt string, model string) (string, error) {
+	message, _, err := c.GenerateCommitMessageWithUsage(prompt, model)
+	return message, err
+}
+
+// GenerateCommitMessageWithUsage is GenerateCommitMessage that also returns the token
+// usage the API reported for the request, zero when it reported none.
+func (c *Client) GenerateCommitMessageWithUsage(prompt string, model string) (string, Usage, error) {
+	prompt, err := c.guardPrompt(prompt)
+	if err != nil {
+		return "", Usage{}, err
+	}
 	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	defer cancel()
 
+	system, user := formatter.SplitSystemPrompt(prompt)
+	if system == "" {
+		system = defaultSystemPrompt
+	}
 	messages := []openai.ChatCompletionMessage{
 		{
-			Role: openai.ChatMessageRoleSystem,
-			Content: "You are a professional Git commit message generator, helping developers generate " +
-				"commit messages that comply with the Conventional Commits specification.",
+			Role:    openai.ChatMessageRoleSystem,
+			Content: system,
 		},
 		{
 			Role:    openai.ChatMessageRoleUser,
-			Content: prompt,
+			Content: user,
 		},
 	}
 
-	resp, err := client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model:    chosenModel,
-			Messages: messages,
-		},
-	)
+	request := openai.ChatCompletionRequest{
+		Model:         chosenModel,
+		Messages:      messages,
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	}
+	c.applySampling(&request)
 
+	started := time.Now()
+	stream, err := client.CreateChatCompletionStream(ctx, request)
 	if err != nil {
-		return "", fmt.Errorf("failed to call LLM: %w (%w)", err, ErrLLM)
+		logExchange("commit_message", chosenModel, prompt, "", nil, started, err)
+		return "", Usage{}, callError(err, started)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var usage *openai.Usage
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			logExchange("commit_message", chosenModel, prompt, content.String(), usage, started, err)
+			callErr := callError(err, started)
+			if partial := strings.TrimSpace(content.String()); partial != "" {
+				return "", Usage{}, &PartialResponseError{Content: partial, Err: callErr}
+			}
+			return "", Usage{}, callErr
+		}
+		if resp.Usage != nil {
+			usage = resp.Usage
+		}
+		if len(resp.Choices) > 0 {
+			content.WriteString(resp.Choices[0].Delta.Content)
+		}
+	}
+	logExchange("commit_message", chosenModel, prompt, content.String(), usage, started, nil)
+	c.reportUsage(chosenModel, usage)
+	var reported Usage
+	if usage != nil {
+		reported = Usage{Model: chosenModel, PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens}
 	}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("LLM returned empty response: %w", ErrLLM)
+	message := strings.TrimSpace(content.String())
+	if message == "" {
+		return "", reported, fmt.Errorf("LLM returned empty response: %w", ErrLLM)
 	}
+	return message, reported, nil
+}
 
-	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+// defaultSystemPrompt is the system message for templates without a system key.
+const defaultSystemPrompt = "You are a professional Git commit message generator, helping developers generate " +
+	"commit messages that comply with the Conventional Commits specification."
+
+// applySampling sets the temperature, top_p and max_tokens config on a commit message
+// request. Unset values keep the API's defaults. go-openai omits a zero temperature,
+// so 0 is sent as the smallest positive value instead.
+func (c *Client) applySampling(request *openai.ChatCompletionRequest) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return
+	}
+	temperature := cfg.Temperature
+	if c != nil && c.temperature != nil {
+		temperature = c.temperature
+	}
+	if temperature != nil {
+		request.Temperature = max(float32(*temperature), math.SmallestNonzeroFloat32)
+	}
+	if cfg.TopP != nil {
+		request.TopP = float32(*cfg.TopP)
+	}
+	request.MaxTokens = cfg.MaxTokens
 }
 
 func (c *Client) SuggestVersion(baseVersion string, commits []string, model string) (string, string, error) {
@@ -136,6 +308,7 @@ func (c *Client) SuggestVersion(baseVersion string, commits []string, model stri
 		},
 	}
 
+	started := time.Now()
 	resp, err := client.CreateChatCompletion(
 		ctx,
 		openai.ChatCompletionRequest{
@@ -143,10 +316,12 @@ func (c *Client) SuggestVersion(baseVersion string, commits []string, model stri
 			Messages: messages,
 		},
 	)
+	logCompletion("version", chosenModel, prompt, resp, started, err)
 
 	if err != nil {
-		return "", "", fmt.Errorf("failed to call LLM: %w (%w)", err, ErrLLM)
+		return "", "", callError(err, started)
 	}
+	c.reportUsage(chosenModel, &resp.Usage)
 
 	if len(resp.Choices) == 0 {
 		return "", "", fmt.Errorf("LLM returned empty response: %w", ErrLLM)
@@ -160,6 +335,142 @@ func (c *Client) SuggestVersion(baseVersion string, commits []string, model stri
 	return version, reason, nil
 }
 
+// SuggestCommitImprovements asks for concrete advice on improving commit hygiene,
+// given a plain-text summary of a commit quality report.
+func (c *Client) SuggestCommitImprovements(summary string, model string) (string, error) {
+	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role: openai.ChatMessageRoleSystem,
+			Content: "You review a team's git history and give short, practical advice " +
+				"for writing better Conventional Commits messages.",
+		},
+		{
+			Role: openai.ChatMessageRoleUser,
+			Content: "Commit quality report:\n" + summary + "\n\n" +
+				"Give at most 3 suggestions as \"- \" bullets, each one sentence, " +
+				"targeting the most frequent issues. Reply with the bullets only.",
+		},
+	}
+
+	started := time.Now()
+	resp, err := client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:    chosenModel,
+			Messages: messages,
+		},
+	)
+	logCompletion("commit_advice", chosenModel, messages[1].Content, resp, started, err)
+	if err != nil {
+		return "", callError(err, started)
+	}
+	c.reportUsage(chosenModel, &resp.Usage)
+
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("LLM returned empty response: %w", ErrLLM)
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// PolishReleaseNotes rewrites Markdown release notes grouped by commit type into
+// readable prose entries, keeping the headings and every change.
+func (c *Client) PolishReleaseNotes(notes string, model string) (string, error) {
+	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: "You are a release manager who writes clear release notes for a GitHub release.",
+		},
+		{
+			Role: openai.ChatMessageRoleUser,
+			Content: "Polish these release notes. Keep the Markdown headings and their order, keep one " +
+				"bullet per change with its commit hash, rewrite each commit subject as a short sentence " +
+				"for users, and do not invent changes. Reply with the Markdown only.\n\n" + notes,
+		},
+	}
+
+	started := time.Now()
+	resp, err := client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:    chosenModel,
+			Messages: messages,
+		},
+	)
+	logCompletion("release_notes", chosenModel, messages[1].Content, resp, started, err)
+	if err != nil {
+		return "", callError(err, started)
+	}
+	c.reportUsage(chosenModel, &resp.Usage)
+
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("LLM returned empty response: %w", ErrLLM)
+	}
+	return stripMarkdownFence(strings.TrimSpace(resp.Choices[0].Message.Content)) + "\n", nil
+}
+
+// SummarizeDiff condenses one chunk of a large diff into a line per file, for the
+// map step of summarizing a diff too large for a single prompt. It also returns the
+// request's token usage.
+func (c *Client) SummarizeDiff(ctx context.Context, diff string, model string) (string, Usage, error) {
+	diff, err := c.guardPrompt(diff)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	client, ctx, cancel, chosenModel, err := c.newOpenAIClientContext(ctx, model)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer cancel()
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: "You summarize git diffs for a developer writing a commit message.",
+		},
+		{
+			Role: openai.ChatMessageRoleUser,
+			Content: "Summarize each file in this diff on one line, as \"<path>: <what changed>\", " +
+				"in at most 15 words, in the order the files appear. Reply with the lines only.\n\n" + diff,
+		},
+	}
+
+	started := time.Now()
+	resp, err := client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:    chosenModel,
+			Messages: messages,
+		},
+	)
+	logCompletion("diff_summary", chosenModel, messages[1].Content, resp, started, err)
+	if err != nil {
+		return "", Usage{}, callError(err, started)
+	}
+	c.reportUsage(chosenModel, &resp.Usage)
+
+	usage := Usage{
+		Model:            chosenModel,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", usage, fmt.Errorf("LLM returned empty response: %w", ErrLLM)
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), usage, nil
+}
+
 func (c *Client) TestConnection(model string) error {
 	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
 	if err != nil {
@@ -184,7 +495,7 @@ func (c *Client) TestConnection(model string) error {
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("failed to call LLM: %w", err)
+		return fmt.Errorf("failed to call LLM: %w", explainNetworkError(err))
 	}
 
 	if len(resp.Choices) == 0 {
@@ -194,6 +505,42 @@ func (c *Client) TestConnection(model string) error {
 	return nil
 }
 
+// logExchange records an LLM request and its reply in the debug log.
+func logExchange(kind, model, prompt, reply string, usage *openai.Usage, started time.Time, err error) {
+	if !debuglog.Enabled() {
+		return
+	}
+	attrs := []any{
+		"kind", kind,
+		"model", model,
+		"prompt", prompt,
+		"response", reply,
+		"latency_ms", time.Since(started).Milliseconds(),
+	}
+	if usage != nil {
+		attrs = append(attrs,
+			"prompt_tokens", usage.PromptTokens,
+			"completion_tokens", usage.CompletionTokens,
+			"total_tokens", usage.TotalTokens)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	debuglog.Log("llm", attrs...)
+}
+
+func logCompletion(kind, model, prompt string, resp openai.ChatCompletionResponse, started time.Time, err error) {
+	reply := ""
+	if len(resp.Choices) > 0 {
+		reply = resp.Choices[0].Message.Content
+	}
+	var usage *openai.Usage
+	if err == nil {
+		usage = &resp.Usage
+	}
+	logExchange(kind, model, prompt, reply, usage, started, err)
+}
+
 func DefaultClient() *Client {
 	return NewClient(Options{})
 }
@@ -235,6 +582,18 @@ If no release should happen, repeat the current version.`,
 		strings.TrimSpace(baseVersion), builder.String())
 }
 
+// stripMarkdownFence removes a ``` fence wrapping the whole response.
+func stripMarkdownFence(response string) string {
+	if !strings.HasPrefix(response, "```") || !strings.HasSuffix(response, "```") {
+		return response
+	}
+	_, inner, found := strings.Cut(response, "\n")
+	if !found {
+		return response
+	}
+	return strings.TrimSpace(strings.TrimSuffix(inner, "```"))
+}
+
 func parseVersionSuggestion(response string) (string, string, error) {
 	trimmed := strings.TrimSpace(response)
 	if trimmed == "" {
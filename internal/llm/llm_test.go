@@ -2,14 +2,22 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
 	"github.com/sashabaranov/go-openai"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockOpenAIClient is a mock implementation of the OpenAI client
@@ -468,3 +476,196 @@ func TestGenerateCommitMessage_APIBaseConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func newStreamServer(t *testing.T, chunks []string, stall bool) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", chunk)
+			flusher.Flush()
+		}
+		if stall {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("model", "gpt-3.5-turbo")
+	viper.Set("api_base", server.URL)
+	return server
+}
+
+func TestGenerateCommitMessage_Stream(t *testing.T) {
+	newStreamServer(t, []string{"feat(parser): ", "add streaming support\n"}, false)
+
+	message, err := NewClient(Options{}).GenerateCommitMessage("prompt", "")
+	require.NoError(t, err)
+	assert.Equal(t, "feat(parser): add streaming support", message)
+}
+
+func TestGenerateCommitMessage_StalledStreamReturnsPartial(t *testing.T) {
+	newStreamServer(t, []string{"fix(llm): ", "keep partial subject\n\nThe body"}, true)
+
+	_, err := NewClient(Options{Timeout: 200 * time.Millisecond}).GenerateCommitMessage("prompt", "")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrLLM)
+
+	var partial *PartialResponseError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, "fix(llm): keep partial subject\n\nThe body", partial.Content)
+}
+
+func TestGenerateCommitMessage_StalledStreamWithoutContent(t *testing.T) {
+	newStreamServer(t, nil, true)
+
+	_, err := NewClient(Options{Timeout: 200 * time.Millisecond}).GenerateCommitMessage("prompt", "")
+	require.Error(t, err)
+
+	var partial *PartialResponseError
+	assert.False(t, errors.As(err, &partial))
+}
+
+func TestGenerateCommitMessage_SystemPromptAndSampling(t *testing.T) {
+	var request openai.ChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"feat: add login\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", server.URL)
+	viper.Set("temperature", 0.7)
+	viper.Set("top_p", 0.9)
+	viper.Set("max_tokens", 120)
+	defer viper.Reset()
+
+	prompt := formatter.JoinSystemPrompt("Write commit messages.", "Diff excerpt:\n+login")
+	_, err := NewClient(Options{}).GenerateCommitMessage(prompt, "gpt-4o")
+	require.NoError(t, err)
+	require.Len(t, request.Messages, 2)
+	assert.Equal(t, "Write commit messages.", request.Messages[0].Content)
+	assert.Equal(t, "Diff excerpt:\n+login", request.Messages[1].Content)
+	assert.InDelta(t, 0.7, request.Temperature, 0.001)
+	assert.InDelta(t, 0.9, request.TopP, 0.001)
+	assert.Equal(t, 120, request.MaxTokens)
+
+	zero := 0.0
+	_, err = NewClient(Options{Temperature: &zero}).GenerateCommitMessage("plain prompt", "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, defaultSystemPrompt, request.Messages[0].Content)
+	assert.Equal(t, "plain prompt", request.Messages[1].Content)
+	assert.Positive(t, request.Temperature, "a zero temperature is still sent")
+	assert.Less(t, request.Temperature, float32(0.001))
+}
+
+func TestSuggestCommitImprovements(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"- Name a scope\n"}}]}`)
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", server.URL)
+
+	suggestions, err := NewClient(Options{}).SuggestCommitImprovements("Commits analyzed: 3", "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, "- Name a scope", suggestions)
+	assert.Contains(t, body, "Commits analyzed: 3")
+	assert.Contains(t, body, `"model":"gpt-4o"`)
+}
+
+func TestPolishReleaseNotes(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant",`+
+			`"content":"`+"```markdown\\n## v1.5.0\\n\\n- Tags can be pushed (3333333)\\n```"+`"}}]}`)
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", server.URL)
+
+	notes, err := NewClient(Options{}).PolishReleaseNotes("## v1.5.0\n\n- feat(tag): push tags (3333333)\n", "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, "## v1.5.0\n\n- Tags can be pushed (3333333)\n", notes)
+	assert.Contains(t, body, "feat(tag): push tags (3333333)")
+}
+
+func TestSuggestBranchNames(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant",`+
+			`"content":"1. feat/oauth-login\n2. feat/add-oauth\n\n- fix/login\n- chore/extra"}}]}`)
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", server.URL)
+
+	names, err := NewClient(Options{}).SuggestBranchNames("add OAuth login", 3, "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat/oauth-login", "feat/add-oauth", "fix/login"}, names)
+	assert.Contains(t, body, "Suggest 3 different git branch names")
+	assert.Contains(t, body, "add OAuth login")
+}
+
+func TestGenerateCommitMessage_ReportsStreamUsage(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"fix: count tokens\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],"+
+			"\"usage\":{\"prompt_tokens\":812,\"completion_tokens\":24,\"total_tokens\":836}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", server.URL)
+
+	var reported []Usage
+	client := NewClient(Options{OnUsage: func(u Usage) { reported = append(reported, u) }})
+	message, err := client.GenerateCommitMessage("prompt", "gpt-4.1-mini")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: count tokens", message)
+	assert.Contains(t, body, `"stream_options":{"include_usage":true}`)
+	assert.Equal(t, []Usage{{Model: "gpt-4.1-mini", PromptTokens: 812, CompletionTokens: 24}}, reported)
+
+	message, usage, err := client.GenerateCommitMessageWithUsage("prompt", "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: count tokens", message)
+	assert.Equal(t, Usage{Model: "gpt-4o", PromptTokens: 812, CompletionTokens: 24}, usage)
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Provider is an OpenAI-compatible API offered as a preset by gmc init.
+type Provider struct {
+	Name    string
+	BaseURL string
+	// KeyOptional marks local servers that accept any API key.
+	KeyOptional bool
+}
+
+// CustomProvider is the choice for any other OpenAI-compatible endpoint.
+const CustomProvider = "Custom"
+
+// Providers lists the presets in the order gmc init offers them.
+var Providers = []Provider{
+	{Name: "OpenAI", BaseURL: "https://api.openai.com/v1"},
+	{Name: "OpenRouter", BaseURL: "https://openrouter.ai/api/v1"},
+	{Name: "DeepSeek", BaseURL: "https://api.deepseek.com/v1"},
+	{Name: "Ollama", BaseURL: "http://localhost:11434/v1", KeyOptional: true},
+	{Name: CustomProvider},
+}
+
+// ProviderForBase returns the preset whose base URL is apiBase. An empty apiBase is
+// OpenAI, and unknown URLs are the custom provider.
+func ProviderForBase(apiBase string) Provider {
+	if apiBase == "" {
+		return Providers[0]
+	}
+	trimmed := strings.TrimRight(apiBase, "/")
+	for _, provider := range Providers {
+		if provider.BaseURL != "" && provider.BaseURL == trimmed {
+			return provider
+		}
+	}
+	return Providers[len(Providers)-1]
+}
+
+// ListModels returns the IDs of the models the provider offers to the API key, sorted.
+func (c *Client) ListModels() ([]string, error) {
+	client, ctx, cancel, _, err := c.newOpenAIClient("")
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	resp, err := client.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	ids := make([]string, 0, len(resp.Models))
+	for _, model := range resp.Models {
+		ids = append(ids, model.ID)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
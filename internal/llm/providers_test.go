@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderForBase(t *testing.T) {
+	assert.Equal(t, "OpenAI", ProviderForBase("").Name)
+	assert.Equal(t, "OpenRouter", ProviderForBase("https://openrouter.ai/api/v1/").Name)
+	assert.Equal(t, "Ollama", ProviderForBase("http://localhost:11434/v1").Name)
+	assert.Equal(t, CustomProvider, ProviderForBase("https://proxy.example/v1").Name)
+}
+
+func TestListModelsUsesOverrides(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"gpt-4o"},{"id":"gpt-4.1-mini"}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	viper.Reset()
+	viper.Set("api_key", "saved-key")
+	viper.Set("api_base", "http://127.0.0.1:1")
+
+	models, err := NewClient(Options{APIKey: "new-key", APIBase: server.URL}).ListModels()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpt-4.1-mini", "gpt-4o"}, models)
+	assert.Equal(t, "Bearer new-key", gotAuth)
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ReviewChanges sends a review prompt, as formatter.BuildReviewPrompt renders it, and
+// returns the reply for the review package to parse.
+func (c *Client) ReviewChanges(prompt string, model string) (string, error) {
+	prompt, err := c.guardPrompt(prompt)
+	if err != nil {
+		return "", err
+	}
+	client, ctx, cancel, chosenModel, err := c.newOpenAIClient(model)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	system, user := formatter.SplitSystemPrompt(prompt)
+	if system == "" {
+		system = "You are a senior engineer reviewing changes before they are committed."
+	}
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: system},
+		{Role: openai.ChatMessageRoleUser, Content: user},
+	}
+
+	started := time.Now()
+	resp, err := client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:    chosenModel,
+			Messages: messages,
+		},
+	)
+	logCompletion("review", chosenModel, prompt, resp, started, err)
+	if err != nil {
+		return "", callError(err, started)
+	}
+	c.reportUsage(chosenModel, &resp.Usage)
+
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("LLM returned empty response: %w", ErrLLM)
+	}
+	return stripMarkdownFence(strings.TrimSpace(resp.Choices[0].Message.Content)), nil
+}
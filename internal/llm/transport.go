@@ -0,0 +1,232 @@
+package llm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/debuglog"
+	"github.com/samzong/gmc/internal/gmcerrors"
+	"github.com/sashabaranov/go-openai"
+)
+
+// transportKey identifies the network settings an HTTP client was built for.
+type transportKey struct {
+	proxy    string
+	caCert   string
+	insecure bool
+	// connectTimeout bounds dialing and the TLS handshake; 0 sets no limit.
+	connectTimeout time.Duration
+}
+
+// httpClients caches one client per settings, so requests reuse connections.
+var httpClients sync.Map
+
+// httpClientFor returns the HTTP client for the proxy and TLS settings of cfg, which
+// connects within connectTimeout, or without a limit when it is 0.
+func httpClientFor(cfg *config.Config, connectTimeout time.Duration) (*http.Client, error) {
+	key := transportKey{
+		proxy: cfg.HTTPProxy, caCert: cfg.CACert, insecure: cfg.TLSInsecure, connectTimeout: connectTimeout,
+	}
+	if client, ok := httpClients.Load(key); ok {
+		return client.(*http.Client), nil
+	}
+	client, err := newHTTPClient(key)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := httpClients.LoadOrStore(key, client)
+	return actual.(*http.Client), nil
+}
+
+func newHTTPClient(key transportKey) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: key.connectTimeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = key.connectTimeout
+
+	if key.proxy != "" {
+		proxyURL, err := url.Parse(key.proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid http_proxy %q: must be a URL such as http://proxy.example.com:8080", key.proxy)
+		}
+		if password, ok := proxyURL.User.Password(); ok {
+			debuglog.AddSecret(password)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if key.caCert != "" || key.insecure {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: key.insecure}
+		if key.caCert != "" {
+			pool, err := loadCertPool(key.caCert)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// secretHeaderWords mark extra header names whose values are kept out of debug logs.
+var secretHeaderWords = []string{"auth", "key", "secret", "token"}
+
+// withExtraHeaders returns client with headers set on every request, replacing
+// headers of the same name. Headers with an empty value are skipped, so a repo
+// .gmc.yaml can drop one set in the global config.
+func withExtraHeaders(client *http.Client, headers map[string]string) *http.Client {
+	set := make(http.Header, len(headers))
+	for name, value := range headers {
+		if value == "" {
+			continue
+		}
+		set.Set(name, value)
+		lower := strings.ToLower(name)
+		for _, word := range secretHeaderWords {
+			if strings.Contains(lower, word) {
+				debuglog.AddSecret(value)
+				break
+			}
+		}
+	}
+	if len(set) == 0 {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = headerTransport{base: base, headers: set}
+	return &wrapped
+}
+
+// headerTransport sets fixed headers on each request before sending it with base.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
+
+// loadCertPool returns the system roots plus the certificates of the PEM bundle at path.
+// "~/" expands to the home directory.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca_cert: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_cert %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// callError wraps a call started at started that failed in ErrLLM, marked with
+// gmcerrors.ErrLLMAuth or gmcerrors.ErrLLMTimeout when the cause is a rejected key or
+// a timeout. A timeout says how long the call ran and which limit to raise.
+func callError(err error, started time.Time) error {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	var netErr interface{ Timeout() bool }
+	switch {
+	case errors.As(err, &apiErr) && isAuthStatus(apiErr.HTTPStatusCode),
+		errors.As(err, &reqErr) && isAuthStatus(reqErr.HTTPStatusCode):
+		return gmcerrors.Mark(fmt.Errorf("failed to call LLM: %w (%w)", explainNetworkError(err), ErrLLM),
+			gmcerrors.ErrLLMAuth)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		hint := "consider raising --timeout, or --timeout 0 for no limit"
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			hint = "could not connect to api_base; check the network, or raise --connect-timeout"
+		}
+		elapsed := time.Since(started).Round(100 * time.Millisecond)
+		return gmcerrors.Mark(fmt.Errorf("LLM request timed out after %s; %s (%w: %w)", elapsed, hint, ErrLLM, err),
+			gmcerrors.ErrLLMTimeout)
+	}
+	return fmt.Errorf("failed to call LLM: %w (%w)", explainNetworkError(err), ErrLLM)
+}
+
+// IsUnreachable reports whether err is a failed LLM call that got no answer from the
+// endpoint: a timeout, or a connection, DNS or proxy failure. An endpoint that answers
+// with an error status is reachable.
+func IsUnreachable(err error) bool {
+	if !errors.Is(err, ErrLLM) {
+		return false
+	}
+	if errors.Is(err, gmcerrors.ErrLLMTimeout) {
+		return true
+	}
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	if errors.As(err, &apiErr) || errors.As(err, &reqErr) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isAuthStatus(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// explainNetworkError adds what to check to TLS and proxy failures, which otherwise
+// surface as bare handshake errors.
+func explainNetworkError(err error) error {
+	if hint := networkHint(err); hint != "" {
+		return fmt.Errorf("%w; %s", err, hint)
+	}
+	return err
+}
+
+func networkHint(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var recordHeader tls.RecordHeaderError
+	var urlErr *url.Error
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "the certificate is signed by an unknown authority: if a corporate proxy or gateway " +
+			"re-signs TLS traffic, set ca_cert to its CA bundle"
+	case errors.As(err, &hostname):
+		return "the certificate does not match the host: check api_base"
+	case errors.As(err, &invalid):
+		return "the certificate is not valid: check the system clock, or set ca_cert to the issuing CA bundle"
+	case errors.As(err, &recordHeader):
+		return "the server did not answer with TLS: use an http:// api_base, or check http_proxy"
+	case errors.As(err, &urlErr) && strings.Contains(urlErr.Err.Error(), "proxyconnect"):
+		return "could not connect through the proxy: check http_proxy, HTTPS_PROXY and NO_PROXY"
+	}
+	return ""
+}
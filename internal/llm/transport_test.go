@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/samzong/gmc/internal/gmcerrors"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTLSCompletionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"- Name a scope"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientTLSSettings(t *testing.T) {
+	server := newTLSCompletionServer(t)
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caCert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", server.URL)
+
+	_, err := NewClient(Options{}).SuggestCommitImprovements("summary", "gpt-4o")
+	require.ErrorIs(t, err, ErrLLM)
+	assert.Contains(t, err.Error(), "set ca_cert to its CA bundle")
+
+	viper.Set("ca_cert", caCert)
+	suggestions, err := NewClient(Options{}).SuggestCommitImprovements("summary", "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, "- Name a scope", suggestions)
+
+	viper.Set("ca_cert", "")
+	viper.Set("tls_insecure", true)
+	_, err = NewClient(Options{}).SuggestCommitImprovements("summary", "gpt-4o")
+	require.NoError(t, err)
+}
+
+func TestClientProxySettings(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"via proxy"}}]}`)
+	}))
+	defer proxy.Close()
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", "http://llm.invalid/v1")
+	viper.Set("http_proxy", proxy.URL)
+
+	suggestions, err := NewClient(Options{}).SuggestCommitImprovements("summary", "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, "via proxy", suggestions)
+	assert.Equal(t, "http://llm.invalid/v1/chat/completions", proxied)
+
+	viper.Set("http_proxy", "proxy.example.com:8080")
+	_, err = NewClient(Options{}).SuggestCommitImprovements("summary", "gpt-4o")
+	assert.ErrorContains(t, err, `invalid http_proxy "proxy.example.com:8080"`)
+
+	viper.Set("http_proxy", "")
+	viper.Set("ca_cert", filepath.Join(t.TempDir(), "missing.pem"))
+	_, err = NewClient(Options{}).SuggestCommitImprovements("summary", "gpt-4o")
+	assert.ErrorContains(t, err, "failed to read ca_cert")
+}
+
+func TestClientExtraHeaders(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	viper.Set("api_base", server.URL)
+	viper.Set("extra_headers", map[string]string{"x-org-id": "acme", "x-route": "", "authorization": "Gateway gw-1"})
+	defer viper.Reset()
+
+	_, err := NewClient(Options{}).SuggestCommitImprovements("summary", "gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", headers.Get("X-Org-Id"))
+	assert.NotContains(t, headers, "X-Route", "an empty value drops the header")
+	assert.Equal(t, "Gateway gw-1", headers.Get("Authorization"), "extra headers replace the ones gmc sets")
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+}
+
+func TestCallErrorMarksAuthAndTimeout(t *testing.T) {
+	auth := callError(&openai.APIError{HTTPStatusCode: http.StatusUnauthorized, Message: "bad key"}, time.Now())
+	assert.ErrorIs(t, auth, gmcerrors.ErrLLMAuth)
+	assert.ErrorIs(t, auth, ErrLLM)
+	assert.Contains(t, auth.Error(), "failed to call LLM: ")
+
+	timeout := callError(fmt.Errorf("post: %w", context.DeadlineExceeded), time.Now().Add(-30*time.Second))
+	assert.ErrorIs(t, timeout, gmcerrors.ErrLLMTimeout)
+	assert.ErrorIs(t, timeout, ErrLLM)
+	assert.NotErrorIs(t, timeout, gmcerrors.ErrLLMAuth)
+	assert.Contains(t, timeout.Error(), "LLM request timed out after 30s; consider raising --timeout")
+
+	dial := &url.Error{Op: "Post", URL: "http://10.0.0.1", Err: &net.OpError{Op: "dial", Err: timeoutErr{}}}
+	assert.Contains(t, callError(dial, time.Now()).Error(), "raise --connect-timeout")
+
+	other := callError(&openai.APIError{HTTPStatusCode: http.StatusInternalServerError}, time.Now())
+	assert.ErrorIs(t, other, ErrLLM)
+	assert.NotErrorIs(t, other, gmcerrors.ErrLLMAuth)
+}
+
+// timeoutErr is a net.Error that timed out.
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestTimeouts(t *testing.T) {
+	assert.Equal(t, defaultTimeout, NewClient(Options{}).effectiveTimeout())
+	assert.Equal(t, defaultConnectTimeout, NewClient(Options{}).effectiveConnectTimeout())
+	client := NewClient(Options{Timeout: NoTimeout, ConnectTimeout: 5 * time.Second})
+	assert.Zero(t, client.effectiveTimeout(), "NoTimeout sets no limit")
+	assert.Equal(t, 5*time.Second, client.effectiveConnectTimeout())
+
+	viper.Reset()
+	viper.Set("api_key", "test-api-key")
+	defer viper.Reset()
+	_, ctx, cancel, _, err := client.newOpenAIClient("gpt-4o")
+	require.NoError(t, err)
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	assert.False(t, hasDeadline)
+}
+
+func TestIsUnreachable(t *testing.T) {
+	refused := &url.Error{Op: "Post", URL: "http://127.0.0.1:1", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}
+	assert.True(t, IsUnreachable(callError(refused, time.Now())))
+	assert.True(t, IsUnreachable(callError(fmt.Errorf("post: %w", context.DeadlineExceeded), time.Now())))
+	assert.False(t, IsUnreachable(callError(&openai.APIError{HTTPStatusCode: http.StatusBadGateway}, time.Now())))
+	assert.False(t, IsUnreachable(refused), "only failed LLM calls count")
+}
@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Usage is the token usage the API reported for one request.
+type Usage struct {
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// Price is a model's list price in US dollars per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// modelPrices holds list prices for common models, matched by the longest prefix of
+// the model name. Costs for other models are not estimated.
+var modelPrices = map[string]Price{
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+	"gpt-4o":        {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4.1":       {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":  {Input: 0.10, Output: 0.40},
+	"o3-mini":       {Input: 1.10, Output: 4.40},
+	"o4-mini":       {Input: 1.10, Output: 4.40},
+	"deepseek-chat": {Input: 0.27, Output: 1.10},
+}
+
+// PriceFor returns the list price of model. Provider prefixes such as "openai/" are
+// ignored.
+func PriceFor(model string) (Price, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	best, found := "", false
+	for prefix := range modelPrices {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best, found = prefix, true
+		}
+	}
+	return modelPrices[best], found
+}
+
+// Cost estimates the request's cost in US dollars. ok is false for models without a
+// known price.
+func (u Usage) Cost() (cost float64, ok bool) {
+	price, ok := PriceFor(u.Model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(u.PromptTokens)*price.Input + float64(u.CompletionTokens)*price.Output) / 1e6, true
+}
+
+// EstimateTokens approximates the number of tokens in text, at four bytes a token, the
+// usual rate for English and code. It is meant for estimates before a request.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// String formats u as "prompt 812 tok, completion 24 tok, ~$0.0009".
+func (u Usage) String() string {
+	s := fmt.Sprintf("prompt %d tok, completion %d tok", u.PromptTokens, u.CompletionTokens)
+	if cost, ok := u.Cost(); ok {
+		s += fmt.Sprintf(", ~$%.4f", cost)
+	}
+	return s
+}
+
+func (c *Client) reportUsage(model string, usage *openai.Usage) {
+	if c == nil || c.onUsage == nil || usage == nil {
+		return
+	}
+	c.onUsage(Usage{Model: model, PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens})
+}
+
+// ModelUsage is the accumulated usage of one model.
+type ModelUsage struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost_usd"`
+}
+
+// UsageTotals is the usage accumulated across invocations, by model.
+type UsageTotals struct {
+	Since  time.Time             `json:"since"`
+	Models map[string]ModelUsage `json:"models"`
+}
+
+// UsageFilePath returns $XDG_STATE_HOME/gmc/usage.json.
+func UsageFilePath() (string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "usage.json"), nil
+}
+
+// LoadUsageTotals reads the totals at path. A missing file yields empty totals.
+func LoadUsageTotals(path string) (UsageTotals, error) {
+	totals := UsageTotals{Models: map[string]ModelUsage{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return totals, nil
+	}
+	if err != nil {
+		return totals, fmt.Errorf("failed to read usage stats: %w", err)
+	}
+	if err := json.Unmarshal(data, &totals); err != nil {
+		return UsageTotals{Models: map[string]ModelUsage{}}, fmt.Errorf("failed to parse usage stats %s: %w", path, err)
+	}
+	if totals.Models == nil {
+		totals.Models = map[string]ModelUsage{}
+	}
+	return totals, nil
+}
+
+// Add accumulates u into the totals.
+func (t *UsageTotals) Add(u Usage) {
+	if t.Models == nil {
+		t.Models = map[string]ModelUsage{}
+	}
+	if t.Since.IsZero() {
+		t.Since = time.Now().UTC().Truncate(time.Second)
+	}
+	model := t.Models[u.Model]
+	model.Requests++
+	model.PromptTokens += u.PromptTokens
+	model.CompletionTokens += u.CompletionTokens
+	if cost, ok := u.Cost(); ok {
+		model.Cost += cost
+	}
+	t.Models[u.Model] = model
+}
+
+// Total sums every model.
+func (t UsageTotals) Total() ModelUsage {
+	var total ModelUsage
+	for _, model := range t.Models {
+		total.Requests += model.Requests
+		total.PromptTokens += model.PromptTokens
+		total.CompletionTokens += model.CompletionTokens
+		total.Cost += model.Cost
+	}
+	return total
+}
+
+// ModelNames returns the models in the totals, sorted.
+func (t UsageTotals) ModelNames() []string {
+	names := make([]string, 0, len(t.Models))
+	for name := range t.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RecordUsage adds u to the totals stored at path.
+func RecordUsage(path string, u Usage) error {
+	totals, err := LoadUsageTotals(path)
+	if err != nil {
+		return err
+	}
+	totals.Add(u)
+
+	data, err := json.MarshalIndent(totals, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage stats: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write usage stats: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write usage stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceFor(t *testing.T) {
+	price, ok := PriceFor("gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, Price{Input: 0.15, Output: 0.60}, price, "longest prefix wins over gpt-4o")
+
+	price, ok = PriceFor("openai/gpt-4.1-mini")
+	require.True(t, ok)
+	assert.Equal(t, Price{Input: 0.40, Output: 1.60}, price)
+
+	_, ok = PriceFor("llama3.1:8b")
+	assert.False(t, ok)
+}
+
+func TestUsageString(t *testing.T) {
+	usage := Usage{Model: "gpt-4.1-mini", PromptTokens: 812, CompletionTokens: 24}
+	cost, ok := usage.Cost()
+	require.True(t, ok)
+	assert.InDelta(t, 0.0003632, cost, 1e-9)
+	assert.Equal(t, "prompt 812 tok, completion 24 tok, ~$0.0004", usage.String())
+
+	usage.Model = "llama3.1:8b"
+	assert.Equal(t, "prompt 812 tok, completion 24 tok", usage.String())
+}
+
+func TestRecordUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "usage.json")
+
+	totals, err := LoadUsageTotals(path)
+	require.NoError(t, err)
+	assert.Empty(t, totals.Models)
+
+	require.NoError(t, RecordUsage(path, Usage{Model: "gpt-4.1-mini", PromptTokens: 800, CompletionTokens: 20}))
+	require.NoError(t, RecordUsage(path, Usage{Model: "gpt-4.1-mini", PromptTokens: 200, CompletionTokens: 10}))
+	require.NoError(t, RecordUsage(path, Usage{Model: "llama3.1:8b", PromptTokens: 100, CompletionTokens: 5}))
+
+	totals, err = LoadUsageTotals(path)
+	require.NoError(t, err)
+	assert.False(t, totals.Since.IsZero())
+	assert.Equal(t, []string{"gpt-4.1-mini", "llama3.1:8b"}, totals.ModelNames())
+	mini := totals.Models["gpt-4.1-mini"]
+	assert.Equal(t, 2, mini.Requests)
+	assert.Equal(t, 1000, mini.PromptTokens)
+	assert.Equal(t, 30, mini.CompletionTokens)
+	assert.InDelta(t, 0.000448, mini.Cost, 1e-9)
+	assert.Zero(t, totals.Models["llama3.1:8b"].Cost)
+
+	total := totals.Total()
+	assert.Equal(t, 3, total.Requests)
+	assert.Equal(t, 1100, total.PromptTokens)
+	assert.Equal(t, 35, total.CompletionTokens)
+}
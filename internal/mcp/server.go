@@ -0,0 +1,228 @@
+// Package mcp serves tools over the Model Context Protocol: JSON-RPC 2.0 messages,
+// one per line, on a pair of streams such as stdin and stdout.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the MCP revision the server implements.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the revisions a client may ask for; their tool messages match.
+var supportedVersions = map[string]bool{
+	"2024-11-05":    true,
+	"2025-03-26":    true,
+	ProtocolVersion: true,
+}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Handler runs a tool with the arguments of a tools/call request and returns its text
+// result. An error is reported to the client as a failed tool call, not a protocol
+// error, so the model can read it.
+type Handler func(ctx context.Context, args json.RawMessage) (string, error)
+
+// Tool is a tool the server offers.
+type Tool struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	InputSchema Schema  `json:"inputSchema"`
+	Handler     Handler `json:"-"`
+}
+
+// Schema is the JSON Schema of a tool's arguments, always an object.
+type Schema struct {
+	Type       string              `json:"type"`
+	Properties map[string]Property `json:"properties"`
+	Required   []string            `json:"required,omitempty"`
+}
+
+// Property is one argument of a tool.
+type Property struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// ObjectSchema returns the schema of an object with properties, of which required
+// must be set.
+func ObjectSchema(properties map[string]Property, required ...string) Schema {
+	if properties == nil {
+		properties = map[string]Property{}
+	}
+	return Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// Server answers MCP requests with its tools.
+type Server struct {
+	name    string
+	version string
+	tools   []Tool
+	out     io.Writer
+}
+
+// NewServer returns a server that introduces itself as name and version.
+func NewServer(name, version string, tools ...Tool) *Server {
+	return &Server{name: name, version: version, tools: tools}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type callParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError"`
+}
+
+// Serve reads requests from in and writes responses to out until in ends or ctx is
+// done. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = out
+	reader := bufio.NewReader(in)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if writeErr := s.handleLine(ctx, line); writeErr != nil {
+				return writeErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+	}
+}
+
+func (s *Server) handleLine(ctx context.Context, line []byte) error {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil
+	}
+
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return s.writeError(json.RawMessage("null"), codeParseError, "invalid JSON: "+err.Error())
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if len(req.ID) == 0 {
+			return nil
+		}
+		return s.writeError(req.ID, codeInvalidRequest, "not a JSON-RPC 2.0 request")
+	}
+	// Notifications, such as notifications/initialized, need no response.
+	if len(req.ID) == 0 {
+		return nil
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if rpcErr != nil {
+		return s.writeError(req.ID, rpcErr.Code, rpcErr.Message)
+	}
+	return s.write(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if supportedVersions[params.ProtocolVersion] {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) callTool(ctx context.Context, raw json.RawMessage) (any, *rpcError) {
+	var params callParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params: " + err.Error()}
+	}
+	for _, tool := range s.tools {
+		if tool.Name != params.Name {
+			continue
+		}
+		args := params.Arguments
+		if len(args) == 0 || string(args) == "null" {
+			args = json.RawMessage("{}")
+		}
+		text, err := tool.Handler(ctx, args)
+		if err != nil {
+			return callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return callResult{Content: []content{{Type: "text", Text: text}}}, nil
+	}
+	return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+}
+
+func (s *Server) writeError(id json.RawMessage, code int, message string) error {
+	return s.write(response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}})
+}
+
+func (s *Server) write(resp response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
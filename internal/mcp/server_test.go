@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoTool() Tool {
+	return Tool{
+		Name:        "echo",
+		Description: "Echo the text argument",
+		InputSchema: ObjectSchema(map[string]Property{"text": {Type: "string"}}, "text"),
+		Handler: func(_ context.Context, args json.RawMessage) (string, error) {
+			var params struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", err
+			}
+			if params.Text == "" {
+				return "", errors.New("text is required")
+			}
+			return params.Text, nil
+		},
+	}
+}
+
+func serve(t *testing.T, requests ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	server := NewServer("gmc", "1.2.3", echoTool())
+	require.NoError(t, server.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out))
+
+	var responses []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &resp), line)
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServeInitializeAndList(t *testing.T) {
+	responses := serve(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":"two","method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+	)
+	require.Len(t, responses, 3, "notifications get no response")
+
+	assert.Equal(t, float64(1), responses[0]["id"])
+	result := responses[0]["result"].(map[string]any)
+	assert.Equal(t, "2024-11-05", result["protocolVersion"], "a supported client revision is kept")
+	assert.Equal(t, map[string]any{"name": "gmc", "version": "1.2.3"}, result["serverInfo"])
+	assert.Contains(t, result["capabilities"], "tools")
+
+	assert.Equal(t, "two", responses[1]["id"])
+	tools := responses[1]["result"].(map[string]any)["tools"].([]any)
+	require.Len(t, tools, 1)
+	tool := tools[0].(map[string]any)
+	assert.Equal(t, "echo", tool["name"])
+	assert.Equal(t, map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"text": map[string]any{"type": "string"}},
+		"required":   []any{"text"},
+	}, tool["inputSchema"])
+
+	assert.Equal(t, map[string]any{}, responses[2]["result"])
+}
+
+func TestServeToolsCall(t *testing.T) {
+	responses := serve(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"missing"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`,
+		`{not json`,
+	)
+	require.Len(t, responses, 6)
+
+	assert.Equal(t, ProtocolVersion, responses[0]["result"].(map[string]any)["protocolVersion"])
+	assert.Equal(t, map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": "hi"}},
+		"isError": false,
+	}, responses[1]["result"])
+	assert.Equal(t, map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": "text is required"}},
+		"isError": true,
+	}, responses[2]["result"], "a failing tool is a tool result, not a protocol error")
+
+	for i, code := range map[int]float64{3: -32602, 4: -32601, 5: -32700} {
+		rpcErr, ok := responses[i]["error"].(map[string]any)
+		require.True(t, ok, "response %d", i)
+		assert.Equal(t, code, rpcErr["code"])
+		assert.NotContains(t, responses[i], "result")
+	}
+	assert.Nil(t, responses[5]["id"])
+}
+
+func TestServeStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var out bytes.Buffer
+	err := NewServer("gmc", "dev").Serve(ctx, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`), &out)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, out.String())
+}
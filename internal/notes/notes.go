@@ -0,0 +1,48 @@
+// Package notes records how gmc generated a commit message as a git note under
+// refs/notes/gmc, keeping the provenance out of the commit message itself.
+package notes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Ref is the notes ref gmc writes to, as passed to git notes --ref.
+const Ref = "gmc"
+
+// Generation is the metadata stored for one generated commit.
+type Generation struct {
+	Model      string `json:"model"`
+	PromptHash string `json:"prompt_hash"`
+	// Candidates are the messages generated before one was accepted, oldest first.
+	Candidates []string `json:"candidates"`
+	// Edited is true when the committed message was edited after generation.
+	Edited    bool   `json:"edited,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// HashPrompt returns a short, stable hash of prompt that identifies it without storing it.
+func HashPrompt(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// Encode renders g as the note content.
+func (g Generation) Encode() (string, error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode generation note: %w", err)
+	}
+	return string(data), nil
+}
+
+// Decode parses note content written by Encode.
+func Decode(content string) (Generation, error) {
+	var g Generation
+	if err := json.Unmarshal([]byte(content), &g); err != nil {
+		return Generation{}, fmt.Errorf("note is not gmc generation metadata: %w", err)
+	}
+	return g, nil
+}
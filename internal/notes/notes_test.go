@@ -0,0 +1,33 @@
+package notes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerationRoundTrip(t *testing.T) {
+	g := Generation{
+		Model:      "gpt-4.1-mini",
+		PromptHash: HashPrompt("prompt"),
+		Candidates: []string{"chore: update", "feat(api): add retry"},
+		Edited:     true,
+		CreatedAt:  "2026-03-01T10:00:00Z",
+	}
+
+	content, err := g.Encode()
+	require.NoError(t, err)
+	decoded, err := Decode(content)
+	require.NoError(t, err)
+	assert.Equal(t, g, decoded)
+
+	_, err = Decode("written by hand")
+	assert.Error(t, err)
+}
+
+func TestHashPrompt(t *testing.T) {
+	assert.Equal(t, HashPrompt("a"), HashPrompt("a"))
+	assert.NotEqual(t, HashPrompt("a"), HashPrompt("b"))
+	assert.Len(t, HashPrompt("a"), len("sha256:")+16)
+}
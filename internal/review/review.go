@@ -0,0 +1,149 @@
+// Package review reads the findings of an LLM review of staged changes, as the review
+// templates ask for them: one "severity | category | file:line | finding" line each.
+package review
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrFindings is returned when a review has findings at or above the --fail-on severity.
+var ErrFindings = errors.New("review found problems")
+
+// Severity is how much a finding matters.
+type Severity string
+
+const (
+	High   Severity = "high"
+	Medium Severity = "medium"
+	Low    Severity = "low"
+)
+
+// Severities lists the severities from the highest.
+var Severities = []Severity{High, Medium, Low}
+
+// Categories of findings the built-in template asks for. Custom templates can use
+// others.
+const (
+	BugRisk      = "bug-risk"
+	MissingTests = "missing-tests"
+	Style        = "style"
+)
+
+var categoryOrder = map[string]int{BugRisk: 0, MissingTests: 1, Style: 2}
+
+// ParseSeverity returns the severity named s, in any case.
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if severity.rank() < 0 {
+		return "", fmt.Errorf("invalid severity %q: must be high, medium or low", s)
+	}
+	return severity, nil
+}
+
+func (s Severity) rank() int {
+	for i, severity := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// AtLeast reports whether s is threshold or higher.
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.rank() >= 0 && s.rank() <= threshold.rank()
+}
+
+// Finding is a problem the review reported.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Category string   `json:"category"`
+	// File is the file of the finding, empty when the review did not name one.
+	File string `json:"file,omitempty"`
+	// Line is the line in File, 0 when the review did not name one.
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// Location returns "file:line", "file" or "".
+func (f Finding) Location() string {
+	if f.Line > 0 {
+		return f.File + ":" + strconv.Itoa(f.Line)
+	}
+	return f.File
+}
+
+// Parse returns the findings of reply, from the highest severity down and in
+// category order within one. Lines that are not findings, such as an introduction,
+// are skipped; a reply of NONE has none. A reply with text but no finding lines is an
+// error, since a custom template may ask for another format.
+func Parse(reply string) ([]Finding, error) {
+	var findings []Finding
+	for _, line := range strings.Split(reply, "\n") {
+		if finding, ok := parseLine(line); ok {
+			findings = append(findings, finding)
+		}
+	}
+
+	trimmed := strings.Trim(strings.TrimSpace(reply), "`*.")
+	if len(findings) == 0 && trimmed != "" && !strings.EqualFold(trimmed, "none") {
+		return nil, errors.New(`the review reply has no "severity | category | file:line | finding" lines`)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity.rank() < findings[j].Severity.rank()
+		}
+		return category(findings[i].Category) < category(findings[j].Category)
+	})
+	return findings, nil
+}
+
+func parseLine(line string) (Finding, bool) {
+	line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*"))
+	fields := strings.SplitN(line, "|", 4)
+	if len(fields) != 4 {
+		return Finding{}, false
+	}
+	severity, err := ParseSeverity(fields[0])
+	if err != nil {
+		return Finding{}, false
+	}
+	finding := Finding{
+		Severity: severity,
+		Category: strings.ReplaceAll(strings.ToLower(strings.TrimSpace(fields[1])), " ", "-"),
+		File:     strings.Trim(strings.TrimSpace(fields[2]), "`"),
+		Message:  strings.TrimSpace(fields[3]),
+	}
+	if file, line, ok := strings.Cut(finding.File, ":"); ok {
+		if n, err := strconv.Atoi(line); err == nil {
+			finding.File, finding.Line = file, n
+		}
+	}
+	if finding.File == "-" {
+		finding.File = ""
+	}
+	return finding, finding.Message != ""
+}
+
+// category returns the sort order of a category: the built-in ones first, then others.
+func category(name string) int {
+	if order, ok := categoryOrder[name]; ok {
+		return order
+	}
+	return len(categoryOrder)
+}
+
+// Count returns how many findings are threshold or higher.
+func Count(findings []Finding, threshold Severity) int {
+	n := 0
+	for _, finding := range findings {
+		if finding.Severity.AtLeast(threshold) {
+			n++
+		}
+	}
+	return n
+}
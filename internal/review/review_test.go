@@ -0,0 +1,51 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	findings, err := Parse("Here is the review:\n" +
+		"low | style | cmd/review.go:12 | Name the flag variable after the flag.\n" +
+		"- high | Bug Risk | `internal/cache/cache.go:42` | The map is written without the lock.\n" +
+		"medium | missing-tests | internal/cache/cache.go | Nothing covers eviction.\n" +
+		"high | missing-tests | - | The new error path is untested.\n" +
+		"critical | bug-risk | a.go | Not a severity.\n")
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{Severity: High, Category: BugRisk, File: "internal/cache/cache.go", Line: 42,
+			Message: "The map is written without the lock."},
+		{Severity: High, Category: MissingTests, Message: "The new error path is untested."},
+		{Severity: Medium, Category: MissingTests, File: "internal/cache/cache.go", Message: "Nothing covers eviction."},
+		{Severity: Low, Category: Style, File: "cmd/review.go", Line: 12, Message: "Name the flag variable after the flag."},
+	}, findings)
+	assert.Equal(t, "internal/cache/cache.go:42", findings[0].Location())
+
+	for _, reply := range []string{"NONE", "None.", "", "`NONE`"} {
+		findings, err := Parse(reply)
+		assert.NoError(t, err, reply)
+		assert.Empty(t, findings, reply)
+	}
+
+	_, err = Parse("The code looks fine, but consider adding tests.")
+	assert.Error(t, err)
+}
+
+func TestSeverity(t *testing.T) {
+	severity, err := ParseSeverity("HIGH")
+	require.NoError(t, err)
+	assert.Equal(t, High, severity)
+	_, err = ParseSeverity("critical")
+	assert.EqualError(t, err, `invalid severity "critical": must be high, medium or low`)
+
+	assert.True(t, High.AtLeast(Medium))
+	assert.True(t, Medium.AtLeast(Medium))
+	assert.False(t, Low.AtLeast(Medium))
+
+	findings := []Finding{{Severity: High}, {Severity: Low}, {Severity: Medium}}
+	assert.Equal(t, 1, Count(findings, High))
+	assert.Equal(t, 3, Count(findings, Low))
+}
@@ -0,0 +1,102 @@
+// Package risk flags commits that the risk_policies config marks as needing a second
+// confirmation.
+package risk
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+)
+
+// Finding is a policy that a commit triggers.
+type Finding struct {
+	Policy string `json:"policy"`
+	// Files are the files that matched the policy's paths. Empty for a size limit.
+	Files []string `json:"files,omitempty"`
+	// Lines is the number of changed lines when the policy's size limit is exceeded.
+	Lines int `json:"lines,omitempty"`
+}
+
+// String describes f as "migrations: db/migrate/001.sql" or "large: 812 changed lines".
+func (f Finding) String() string {
+	if len(f.Files) == 0 {
+		return fmt.Sprintf("%s: %d changed lines", f.Policy, f.Lines)
+	}
+	return f.Policy + ": " + strings.Join(f.Files, ", ")
+}
+
+// Evaluate returns the findings of policies for a commit of files that changes lines
+// lines, in policy order.
+func Evaluate(policies []config.RiskPolicy, files []string, lines int) []Finding {
+	var findings []Finding
+	for _, policy := range policies {
+		finding := Finding{Policy: policy.Name}
+		for _, file := range files {
+			if matchesAny(policy.Paths, file) {
+				finding.Files = append(finding.Files, file)
+			}
+		}
+		if len(finding.Files) == 0 && policy.MaxLines > 0 && lines > policy.MaxLines {
+			finding.Lines = lines
+		}
+		if len(finding.Files) > 0 || finding.Lines > 0 {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// Phrase is what the user types to confirm a commit with findings, such as
+// "yes, commit migrations".
+func Phrase(findings []Finding) string {
+	names := make([]string, len(findings))
+	for i, finding := range findings {
+		names[i] = finding.Policy
+	}
+	return "yes, commit " + strings.Join(names, ", ")
+}
+
+// ChangedLines counts the added and removed lines of a diff. Any DiffStatsSeparator
+// block is ignored.
+func ChangedLines(diff string) int {
+	diff, _, _ = strings.Cut(diff, formatter.DiffStatsSeparator)
+	count := 0
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --"):
+			inHunk = false
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+			count++
+		}
+	}
+	return count
+}
+
+// matchesAny reports whether file matches one of patterns. A pattern ending in "/"
+// matches a directory at any depth, a pattern with a "/" matches the whole path, and
+// any other pattern matches the file name, so "*.pem" and ".env" match anywhere.
+func matchesAny(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		switch {
+		case strings.HasSuffix(pattern, "/"):
+			if strings.HasPrefix(file, pattern) || strings.Contains(file, "/"+pattern) {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if ok, _ := path.Match(pattern, file); ok {
+				return true
+			}
+		default:
+			if ok, _ := path.Match(pattern, path.Base(file)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
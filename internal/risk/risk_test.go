@@ -0,0 +1,51 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	policies := []config.RiskPolicy{
+		{Name: "migrations", Paths: []string{"migrations/", "db/migrate/*.rb"}},
+		{Name: "infra", Paths: []string{"infra/", "*.tf"}},
+		{Name: "secrets", Paths: []string{".env", "*.pem"}},
+		{Name: "large", MaxLines: 100},
+	}
+	files := []string{
+		"services/api/migrations/001_init.sql",
+		"db/migrate/002_add_index.rb",
+		"deploy/main.tf",
+		"config/.env",
+		"infrastructure.md",
+		"cmd/root.go",
+	}
+
+	assert.Equal(t, []Finding{
+		{Policy: "migrations", Files: []string{"services/api/migrations/001_init.sql", "db/migrate/002_add_index.rb"}},
+		{Policy: "infra", Files: []string{"deploy/main.tf"}},
+		{Policy: "secrets", Files: []string{"config/.env"}},
+		{Policy: "large", Lines: 101},
+	}, Evaluate(policies, files, 101))
+
+	assert.Empty(t, Evaluate(policies, []string{"cmd/root.go", "db/migrate/nested/x.rb"}, 100))
+	assert.Empty(t, Evaluate(nil, files, 1000))
+}
+
+func TestPhraseAndString(t *testing.T) {
+	findings := []Finding{{Policy: "migrations", Files: []string{"a.sql", "b.sql"}}, {Policy: "large", Lines: 812}}
+	assert.Equal(t, "yes, commit migrations, large", Phrase(findings))
+	assert.Equal(t, "migrations: a.sql, b.sql", findings[0].String())
+	assert.Equal(t, "large: 812 changed lines", findings[1].String())
+}
+
+func TestChangedLines(t *testing.T) {
+	diff := "diff --git a/q.sql b/q.sql\n--- a/q.sql\n+++ b/q.sql\n@@ -1,2 +1,2 @@\n" +
+		" select 1;\n--- old comment\n+-- new comment\n" +
+		"diff --git a/x b/x\nnew file mode 100644\n--- /dev/null\n+++ b/x\n@@ -0,0 +1 @@\n+x\n" +
+		formatter.DiffStatsSeparator + "\n+3 -1 q.sql\n"
+	assert.Equal(t, 3, ChangedLines(diff))
+}
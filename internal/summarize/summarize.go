@@ -0,0 +1,174 @@
+// Package summarize condenses large diffs before prompting. Diff summarizes chunks of
+// the diff concurrently with an LLM (map) and joins the summaries in diff order
+// (reduce); Local summarizes each file from its hunk headers and line counts.
+package summarize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/llm"
+)
+
+// Defaults for zero Options fields.
+const (
+	DefaultParallelism = config.DefaultSummarizeParallelism
+	DefaultTimeout     = config.DefaultSummarizeTimeout * time.Second
+	DefaultChunkLimit  = 12000
+)
+
+// Client summarizes one chunk of a diff. *llm.Client implements it.
+type Client interface {
+	SummarizeDiff(ctx context.Context, diff string, model string) (string, llm.Usage, error)
+}
+
+// Options controls Diff.
+type Options struct {
+	Model string
+	// Parallelism is the number of requests in flight at once.
+	Parallelism int
+	// Timeout bounds each request. A request that times out fails the summary.
+	Timeout time.Duration
+	// ChunkLimit is the most diff bytes sent in one request.
+	ChunkLimit int
+}
+
+// Result is a combined summary and what producing it cost.
+type Result struct {
+	Summary  string
+	Files    int
+	Requests int
+	// Usage sums the token usage of every request.
+	Usage   llm.Usage
+	Elapsed time.Duration
+}
+
+// Diff summarizes diff in chunks of whole files, at most opts.Parallelism at a time.
+// The summary lists the files in diff order, however the requests finish. The first
+// failing request cancels the others and its error is returned.
+func Diff(ctx context.Context, client Client, diff string, opts Options) (Result, error) {
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = DefaultParallelism
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.ChunkLimit <= 0 {
+		opts.ChunkLimit = DefaultChunkLimit
+	}
+
+	started := time.Now()
+	chunks := formatter.ChunkDiff(diff, opts.ChunkLimit)
+	if len(chunks) == 0 {
+		return Result{}, errors.New("no file diffs to summarize")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summaries := make([]string, len(chunks))
+	result := Result{Requests: len(chunks), Usage: llm.Usage{Model: opts.Model}}
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	jobs := make(chan int)
+	for range min(opts.Parallelism, len(chunks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				summary, usage, err := summarizeChunk(ctx, client, chunks[i], opts)
+
+				mu.Lock()
+				addUsage(&result.Usage, usage)
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				summaries[i] = summary
+				mu.Unlock()
+			}
+		}()
+	}
+
+send:
+	for i := range chunks {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return Result{}, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	for _, chunk := range chunks {
+		result.Files += len(chunk.Files)
+	}
+	result.Summary = summaryText(result.Files, summaries)
+	result.Elapsed = time.Since(started)
+	return result, nil
+}
+
+// Local summarizes diff without an LLM, a line per file with its line counts and the
+// functions its hunks touch. It makes no requests.
+func Local(diff string) (Result, error) {
+	started := time.Now()
+	lines := formatter.SummarizeFiles(diff)
+	if len(lines) == 0 {
+		return Result{}, errors.New("no file diffs to summarize")
+	}
+	return Result{
+		Summary: summaryText(len(lines), lines),
+		Files:   len(lines),
+		Elapsed: time.Since(started),
+	}, nil
+}
+
+func summaryText(files int, summaries []string) string {
+	return fmt.Sprintf("Summaries of the %d changed files, in diff order "+
+		"(the full diff is too large for one prompt):\n%s", files, strings.Join(summaries, "\n"))
+}
+
+func summarizeChunk(
+	ctx context.Context, client Client, chunk formatter.DiffChunk, opts Options,
+) (string, llm.Usage, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	summary, usage, err := client.SummarizeDiff(ctx, chunk.Diff, opts.Model)
+	if err != nil {
+		return "", usage, fmt.Errorf("failed to summarize %s: %w", describeFiles(chunk.Files), err)
+	}
+	return summary, usage, nil
+}
+
+func addUsage(total *llm.Usage, usage llm.Usage) {
+	if usage.Model != "" {
+		total.Model = usage.Model
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+}
+
+func describeFiles(files []string) string {
+	if len(files) == 1 {
+		return files[0]
+	}
+	return fmt.Sprintf("%s and %d more files", files[0], len(files)-1)
+}
@@ -0,0 +1,134 @@
+package summarize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient summarizes a chunk as its file paths, finishing later chunks first.
+type fakeClient struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	calls    int
+	fail     string
+	block    bool
+}
+
+func (c *fakeClient) SummarizeDiff(ctx context.Context, diff string, model string) (string, llm.Usage, error) {
+	c.mu.Lock()
+	c.calls++
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	var paths []string
+	for _, line := range strings.Split(diff, "\n") {
+		if rest, ok := strings.CutPrefix(line, "diff --git a/"); ok {
+			path, _, _ := strings.Cut(rest, " ")
+			paths = append(paths, path)
+		}
+	}
+	usage := llm.Usage{Model: model, PromptTokens: 100, CompletionTokens: 10}
+
+	if c.block || (c.fail != "" && strings.Contains(diff, c.fail)) {
+		if c.block {
+			<-ctx.Done()
+			return "", usage, ctx.Err()
+		}
+		return "", usage, errors.New("boom")
+	}
+	// Chunks later in the diff finish first.
+	select {
+	case <-time.After(time.Duration(100-len(paths[0])) * time.Millisecond / 10):
+	case <-ctx.Done():
+		return "", usage, ctx.Err()
+	}
+
+	lines := make([]string, len(paths))
+	for i, path := range paths {
+		lines[i] = path + ": changed"
+	}
+	return strings.Join(lines, "\n"), usage, nil
+}
+
+func largeDiff(files int) string {
+	var b strings.Builder
+	for i := 0; i < files; i++ {
+		path := fmt.Sprintf("pkg/%s/file.go", strings.Repeat("x", i+1))
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n-old\n+new\n",
+			path, path, path, path)
+	}
+	return b.String()
+}
+
+func TestDiffKeepsDiffOrderAndBoundsParallelism(t *testing.T) {
+	client := &fakeClient{}
+	result, err := Diff(context.Background(), client, largeDiff(30), Options{
+		Model:       "gpt-4o-mini",
+		Parallelism: 4,
+		ChunkLimit:  400,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 30, result.Files)
+	assert.Equal(t, client.calls, result.Requests)
+	assert.Greater(t, result.Requests, 4)
+	assert.LessOrEqual(t, client.peak, 4)
+
+	lines := strings.Split(result.Summary, "\n")
+	require.Len(t, lines, 31)
+	assert.Contains(t, lines[0], "Summaries of the 30 changed files")
+	for i, line := range lines[1:] {
+		assert.Equal(t, fmt.Sprintf("pkg/%s/file.go: changed", strings.Repeat("x", i+1)), line)
+	}
+
+	assert.Equal(t, llm.Usage{
+		Model:            "gpt-4o-mini",
+		PromptTokens:     100 * result.Requests,
+		CompletionTokens: 10 * result.Requests,
+	}, result.Usage)
+}
+
+func TestDiffFailsOnRequestError(t *testing.T) {
+	_, err := Diff(context.Background(), &fakeClient{fail: "pkg/xxx/"}, largeDiff(6), Options{ChunkLimit: 100})
+	assert.ErrorContains(t, err, "failed to summarize pkg/xxx/file.go: boom")
+
+	_, err = Diff(context.Background(), &fakeClient{block: true}, largeDiff(3), Options{
+		ChunkLimit: 100,
+		Timeout:    20 * time.Millisecond,
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "each request has its own timeout")
+
+	_, err = Diff(context.Background(), &fakeClient{}, "no diff here", Options{})
+	assert.Error(t, err)
+}
+
+func TestLocal(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n@@ -1 +1,2 @@ func A() {\n+x\n" +
+		"diff --git a/b.go b/b.go\n@@ -1 +1 @@\n-y\n+z\n"
+
+	result, err := Local(diff)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Files)
+	assert.Zero(t, result.Requests)
+	assert.Equal(t, "Summaries of the 2 changed files, in diff order (the full diff is too large for one prompt):\n"+
+		"a.go (+1/-0): func A()\nb.go (+1/-1)", result.Summary)
+
+	_, err = Local("not a diff")
+	assert.Error(t, err)
+}
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/briandowns/spinner"
-	"github.com/mattn/go-isatty"
 )
 
 // Spinner wraps briandowns/spinner with TTY awareness
@@ -14,11 +13,9 @@ type Spinner struct {
 	enabled bool
 }
 
-// NewSpinner creates a new spinner that only displays on TTY
+// NewSpinner creates a new spinner that only displays on terminals that allow ANSI
 func NewSpinner(message string) *Spinner {
-	// Only enable on TTY
-	enabled := isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd())
-	if !enabled {
+	if !Detect(os.Stderr).ANSI {
 		return &Spinner{enabled: false}
 	}
 
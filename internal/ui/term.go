@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Capabilities describe what a terminal can display.
+type Capabilities struct {
+	// TTY reports whether the writer is a terminal.
+	TTY bool
+	// ANSI reports whether escape sequences may be written: colors, hyperlinks, and the
+	// line redraws of the spinner and progress lines. NO_COLOR and --no-color turn it off.
+	ANSI bool
+	// Unicode reports whether non-ASCII symbols, such as the spinner frames, display.
+	Unicode bool
+	// Interactive reports whether full-screen prompts, such as the gmc -i hunk picker,
+	// can run. Unlike ANSI, it does not depend on NO_COLOR.
+	Interactive bool
+}
+
+var noColor bool
+
+// SetNoColor turns off escape sequences for every writer, as NO_COLOR does.
+func SetNoColor(disabled bool) {
+	noColor = disabled
+}
+
+// NoColor reports whether --no-color or a non-empty NO_COLOR is set.
+func NoColor() bool {
+	return noColor || os.Getenv("NO_COLOR") != ""
+}
+
+// Detect returns the capabilities of w. Writers that are not terminals get none. Dumb
+// terminals and Windows consoles without virtual terminal support only get TTY.
+func Detect(w io.Writer) Capabilities {
+	file, ok := w.(*os.File)
+	if !ok || !(isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())) {
+		return Capabilities{}
+	}
+	return terminalCapabilities(virtualTerminal(file))
+}
+
+func terminalCapabilities(virtualTerminal bool) Capabilities {
+	if os.Getenv("TERM") == "dumb" || !virtualTerminal {
+		return Capabilities{TTY: true}
+	}
+	return Capabilities{TTY: true, ANSI: !NoColor(), Unicode: true, Interactive: true}
+}
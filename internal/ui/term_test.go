@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectNonTerminal(t *testing.T) {
+	assert.Equal(t, Capabilities{}, Detect(&bytes.Buffer{}))
+}
+
+func TestTerminalCapabilities(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("NO_COLOR", "")
+	t.Cleanup(func() { SetNoColor(false) })
+
+	full := Capabilities{TTY: true, ANSI: true, Unicode: true, Interactive: true}
+	assert.Equal(t, full, terminalCapabilities(true))
+	assert.Equal(t, Capabilities{TTY: true}, terminalCapabilities(false), "legacy Windows console")
+
+	t.Setenv("NO_COLOR", "1")
+	assert.Equal(t, Capabilities{TTY: true, Unicode: true, Interactive: true}, terminalCapabilities(true))
+
+	t.Setenv("NO_COLOR", "")
+	SetNoColor(true)
+	assert.False(t, terminalCapabilities(true).ANSI, "--no-color")
+	SetNoColor(false)
+
+	t.Setenv("TERM", "dumb")
+	assert.Equal(t, Capabilities{TTY: true}, terminalCapabilities(true))
+}
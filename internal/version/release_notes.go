@@ -0,0 +1,28 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderReleaseNotes renders data as Markdown release notes: a heading with the
+// version and date, then a section per commit group, ready to paste into a GitHub
+// release.
+func RenderReleaseNotes(data TagMessageData) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "## %s (%s)\n", data.Version, data.Date)
+	if data.Previous != "" {
+		fmt.Fprintf(&builder, "\nChanges since %s.\n", data.Previous)
+	}
+	for _, group := range data.GroupedCommits {
+		fmt.Fprintf(&builder, "\n### %s\n\n", group.Title)
+		for _, commit := range group.Commits {
+			fmt.Fprintf(&builder, "- %s", commit.Subject)
+			if commit.ShortHash != "" {
+				fmt.Fprintf(&builder, " (%s)", commit.ShortHash)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
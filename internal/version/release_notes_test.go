@@ -0,0 +1,36 @@
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderReleaseNotes(t *testing.T) {
+	commits := []git.CommitInfo{
+		{Hash: "1111111aaaa", Message: "feat: add tag templates"},
+		{Hash: "2222222bbbb", Message: "fix: handle empty tags"},
+		{Hash: "3333333cccc", Message: "feat(tag): push tags"},
+	}
+	now := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	notes := RenderReleaseNotes(NewTagMessageData("v1.5.0", "v1.4.0", "", commits, now))
+	assert.Equal(t, `## v1.5.0 (2026-10-15)
+
+Changes since v1.4.0.
+
+### Features
+
+- feat: add tag templates (1111111)
+- feat(tag): push tags (3333333)
+
+### Fixes
+
+- fix: handle empty tags (2222222)
+`, notes)
+
+	first := RenderReleaseNotes(NewTagMessageData("v0.1.0", "", "", commits[:1], now))
+	assert.Equal(t, "## v0.1.0 (2026-10-15)\n\n### Features\n\n- feat: add tag templates (1111111)\n", first)
+}
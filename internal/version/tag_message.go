@@ -0,0 +1,150 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/samzong/gmc/internal/git"
+)
+
+// TagCommit is a commit as exposed to tag message templates.
+type TagCommit struct {
+	Hash      string
+	ShortHash string
+	Subject   string
+	Author    string
+	Date      string
+}
+
+// CommitGroup is a titled group of commits, e.g. "Features".
+type CommitGroup struct {
+	Title   string
+	Commits []TagCommit
+}
+
+// TagStats counts commits per release-note group.
+type TagStats struct {
+	Total    int
+	Breaking int
+	Features int
+	Fixes    int
+	Others   int
+}
+
+// TagMessageData is the data passed to the tag_template Go template.
+type TagMessageData struct {
+	Version  string
+	Previous string
+	Date     string
+	Reason   string
+	Stats    TagStats
+	// GroupedCommits holds only non-empty groups, ordered Breaking Changes,
+	// Features, Fixes, Other Changes.
+	GroupedCommits []CommitGroup
+}
+
+var groupTitles = map[commitCategory]string{
+	categoryBreaking: "Breaking Changes",
+	categoryFeature:  "Features",
+	categoryPatch:    "Fixes",
+	categoryOther:    "Other Changes",
+}
+
+// NewTagMessageData groups commits and fills the template data for a new tag.
+func NewTagMessageData(
+	next, previous, reason string, commits []git.CommitInfo, now time.Time,
+) TagMessageData {
+	grouped := make(map[commitCategory][]TagCommit)
+	stats := TagStats{}
+	for _, commit := range commits {
+		subject := strings.TrimSpace(commit.Message)
+		if subject == "" {
+			continue
+		}
+
+		category := classifyCommit(commit)
+		grouped[category] = append(grouped[category], TagCommit{
+			Hash:      commit.Hash,
+			ShortHash: shortHash(commit.Hash),
+			Subject:   subject,
+			Author:    commit.Author,
+			Date:      commit.Date,
+		})
+
+		stats.Total++
+		switch category {
+		case categoryBreaking:
+			stats.Breaking++
+		case categoryFeature:
+			stats.Features++
+		case categoryPatch:
+			stats.Fixes++
+		default:
+			stats.Others++
+		}
+	}
+
+	data := TagMessageData{
+		Version:  next,
+		Previous: previous,
+		Date:     now.Format("2006-01-02"),
+		Reason:   reason,
+		Stats:    stats,
+	}
+	for _, category := range []commitCategory{categoryBreaking, categoryFeature, categoryPatch, categoryOther} {
+		if commits := grouped[category]; len(commits) > 0 {
+			data.GroupedCommits = append(data.GroupedCommits, CommitGroup{Title: groupTitles[category], Commits: commits})
+		}
+	}
+	return data
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// LoadTagTemplate reads a tag message template file. "~/" expands to the home directory.
+func LoadTagTemplate(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read tag template %s: %w", path, err)
+	}
+	if _, err := template.New("tag").Parse(string(content)); err != nil {
+		return "", fmt.Errorf("invalid tag template %s: %w", path, err)
+	}
+	return string(content), nil
+}
+
+// RenderTagMessage executes a tag message template against data.
+func RenderTagMessage(templateContent string, data TagMessageData) (string, error) {
+	tmpl, err := template.New("tag").Option("missingkey=error").Parse(templateContent)
+	if err != nil {
+		return "", fmt.Errorf("tag template parsing error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("tag template rendering error: %w", err)
+	}
+
+	message := strings.TrimSpace(buf.String())
+	if message == "" {
+		return "", errors.New("tag template rendered an empty message")
+	}
+	return message, nil
+}
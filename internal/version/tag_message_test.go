@@ -0,0 +1,89 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const releaseNotesTemplate = `Release {{.Version}} ({{.Date}})
+{{- range .GroupedCommits}}
+
+{{.Title}}:
+{{- range .Commits}}
+- {{.Subject}} ({{.ShortHash}})
+{{- end}}
+{{- end}}
+
+{{.Stats.Total}} commits since {{.Previous}}`
+
+func TestNewTagMessageData(t *testing.T) {
+	commits := []git.CommitInfo{
+		{Hash: "1111111aaaa", Message: "feat: add tag templates"},
+		{Hash: "2222222bbbb", Message: "fix: handle empty tags"},
+		{Hash: "3333333cccc", Message: "docs: update README"},
+		{Hash: "4444444dddd", Message: "refactor!: drop legacy flag"},
+		{Hash: "5555555eeee", Message: "  "},
+	}
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+
+	data := NewTagMessageData("v2.0.0", "v1.4.0", "breaking change", commits, now)
+	assert.Equal(t, "2026-10-15", data.Date)
+	assert.Equal(t, TagStats{Total: 4, Breaking: 1, Features: 1, Fixes: 1, Others: 1}, data.Stats)
+
+	titles := make([]string, 0, len(data.GroupedCommits))
+	for _, group := range data.GroupedCommits {
+		titles = append(titles, group.Title)
+	}
+	assert.Equal(t, []string{"Breaking Changes", "Features", "Fixes", "Other Changes"}, titles)
+	assert.Equal(t, "4444444", data.GroupedCommits[0].Commits[0].ShortHash)
+}
+
+func TestRenderTagMessage(t *testing.T) {
+	commits := []git.CommitInfo{
+		{Hash: "1111111aaaa", Message: "feat: add tag templates"},
+		{Hash: "2222222bbbb", Message: "fix: handle empty tags"},
+	}
+	data := NewTagMessageData("v1.5.0", "v1.4.0", "", commits, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+
+	message, err := RenderTagMessage(releaseNotesTemplate, data)
+	require.NoError(t, err)
+	assert.Equal(t, `Release v1.5.0 (2026-10-15)
+
+Features:
+- feat: add tag templates (1111111)
+
+Fixes:
+- fix: handle empty tags (2222222)
+
+2 commits since v1.4.0`, message)
+
+	_, err = RenderTagMessage("{{.Missing}}", data)
+	assert.Error(t, err)
+
+	_, err = RenderTagMessage("{{if false}}x{{end}}", data)
+	assert.ErrorContains(t, err, "empty message")
+}
+
+func TestLoadTagTemplate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "tag.tmpl")
+	require.NoError(t, os.WriteFile(valid, []byte(releaseNotesTemplate), 0o600))
+
+	content, err := LoadTagTemplate(valid)
+	require.NoError(t, err)
+	assert.Equal(t, releaseNotesTemplate, content)
+
+	invalid := filepath.Join(dir, "broken.tmpl")
+	require.NoError(t, os.WriteFile(invalid, []byte("{{.Version"), 0o600))
+	_, err = LoadTagTemplate(invalid)
+	assert.ErrorContains(t, err, "invalid tag template")
+
+	_, err = LoadTagTemplate(filepath.Join(dir, "missing.tmpl"))
+	assert.ErrorContains(t, err, "unable to read tag template")
+}
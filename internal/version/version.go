@@ -18,12 +18,19 @@ const (
 	BumpMajor BumpType = "major"
 )
 
+// SemVer is a semantic version, such as v1.2.3, v1.3.0-rc.1 or v1.3.0+build.5.
 type SemVer struct {
 	Major int
 	Minor int
 	Patch int
+	// Prerelease holds the dot-separated pre-release identifiers, such as "rc.1".
+	Prerelease string
+	// Build holds the build metadata, such as "build.5". It does not affect precedence.
+	Build string
 }
 
+var identifierPattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
 func ParseSemVer(tag string) (SemVer, error) {
 	if strings.TrimSpace(tag) == "" {
 		return SemVer{}, nil
@@ -32,6 +39,19 @@ func ParseSemVer(tag string) (SemVer, error) {
 	trimmed := strings.TrimSpace(tag)
 	trimmed = strings.TrimPrefix(trimmed, "v")
 
+	trimmed, build, hasBuild := strings.Cut(trimmed, "+")
+	if hasBuild {
+		if err := checkIdentifiers(build, false); err != nil {
+			return SemVer{}, fmt.Errorf("invalid build metadata in %s: %w", tag, err)
+		}
+	}
+	trimmed, prerelease, hasPrerelease := strings.Cut(trimmed, "-")
+	if hasPrerelease {
+		if err := ValidatePrerelease(prerelease); err != nil {
+			return SemVer{}, fmt.Errorf("invalid pre-release in %s: %w", tag, err)
+		}
+	}
+
 	parts := strings.Split(trimmed, ".")
 	if len(parts) != 3 {
 		return SemVer{}, fmt.Errorf("invalid semantic version: %s", tag)
@@ -54,43 +74,177 @@ func ParseSemVer(tag string) (SemVer, error) {
 		return SemVer{}, fmt.Errorf("semantic version components must be non-negative: %s", tag)
 	}
 
-	return SemVer{Major: major, Minor: minor, Patch: patch}, nil
+	return SemVer{Major: major, Minor: minor, Patch: patch, Prerelease: prerelease, Build: build}, nil
+}
+
+// ValidatePrerelease checks dot-separated pre-release identifiers, such as "rc.1":
+// each is non-empty, uses only letters, digits and dashes, and a numeric one has no
+// leading zero.
+func ValidatePrerelease(prerelease string) error {
+	return checkIdentifiers(prerelease, true)
+}
+
+func checkIdentifiers(value string, noLeadingZero bool) error {
+	for _, identifier := range strings.Split(value, ".") {
+		if !identifierPattern.MatchString(identifier) {
+			return fmt.Errorf("identifier %q must be non-empty letters, digits and dashes", identifier)
+		}
+		if noLeadingZero && isNumeric(identifier) && len(identifier) > 1 && identifier[0] == '0' {
+			return fmt.Errorf("numeric identifier %q must not have a leading zero", identifier)
+		}
+	}
+	return nil
+}
+
+func isNumeric(identifier string) bool {
+	for _, r := range identifier {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return identifier != ""
 }
 
 func (v SemVer) String() string {
-	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
 }
 
-func (v SemVer) Equal(other SemVer) bool {
-	return v.Major == other.Major && v.Minor == other.Minor && v.Patch == other.Patch
+// IsPrerelease reports whether v has pre-release identifiers.
+func (v SemVer) IsPrerelease() bool {
+	return v.Prerelease != ""
 }
 
-func (v SemVer) LessThan(other SemVer) bool {
-	if v.Major != other.Major {
-		return v.Major < other.Major
+// Compare returns -1, 0 or 1 as v has lower, equal or higher precedence than other.
+// A pre-release ranks below its release, and build metadata is ignored.
+func (v SemVer) Compare(other SemVer) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	left, right := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := range min(len(left), len(right)) {
+		if c := compareIdentifiers(left[i], right[i]); c != 0 {
+			return c
+		}
 	}
-	if v.Minor != other.Minor {
-		return v.Minor < other.Minor
+	return sign(len(left) - len(right))
+}
+
+// compareIdentifiers orders numeric identifiers numerically and below alphanumeric
+// ones, which are ordered lexically.
+func compareIdentifiers(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		if len(a) != len(b) {
+			return sign(len(a) - len(b))
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
 	}
-	return v.Patch < other.Patch
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (v SemVer) Equal(other SemVer) bool {
+	return v.Compare(other) == 0
+}
+
+func (v SemVer) LessThan(other SemVer) bool {
+	return v.Compare(other) < 0
 }
 
 func (v SemVer) GreaterThan(other SemVer) bool {
 	return other.LessThan(v)
 }
 
+// NextMajor returns the next major release. A pre-release of a major release, such
+// as v2.0.0-rc.1, is finalized instead.
 func (v SemVer) NextMajor() SemVer {
+	if v.IsPrerelease() && v.Minor == 0 && v.Patch == 0 {
+		return v.Finalize()
+	}
 	return SemVer{Major: v.Major + 1, Minor: 0, Patch: 0}
 }
 
+// NextMinor returns the next minor release. A pre-release of a minor release, such
+// as v1.3.0-rc.1, is finalized instead.
 func (v SemVer) NextMinor() SemVer {
+	if v.IsPrerelease() && v.Patch == 0 {
+		return v.Finalize()
+	}
 	return SemVer{Major: v.Major, Minor: v.Minor + 1, Patch: 0}
 }
 
+// NextPatch returns the next patch release. A pre-release is finalized instead.
 func (v SemVer) NextPatch() SemVer {
+	if v.IsPrerelease() {
+		return v.Finalize()
+	}
 	return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
 }
 
+// Finalize returns the release v is a pre-release of, without build metadata.
+func (v SemVer) Finalize() SemVer {
+	return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// NextPrerelease returns the next pre-release of the train id toward target, the
+// release the commits since base call for. When base is a pre-release of target, the
+// train continues: v1.3.0-rc.1 becomes v1.3.0-rc.2, and v1.3.0-beta.2 becomes
+// v1.3.0-rc.1. Otherwise a train starts at target-id.1, and a target that is not
+// above base, a release, starts one for the next patch release.
+func NextPrerelease(base, target SemVer, id string) SemVer {
+	core := target.Finalize()
+	if !base.IsPrerelease() && !core.GreaterThan(base) {
+		core = base.NextPatch()
+	}
+	next := SemVer{Major: core.Major, Minor: core.Minor, Patch: core.Patch, Prerelease: id + ".1"}
+	if !base.IsPrerelease() || !base.Finalize().Equal(core) {
+		return next
+	}
+
+	if rest, ok := strings.CutPrefix(base.Prerelease, id+"."); ok && isNumeric(rest) {
+		n, err := strconv.Atoi(rest)
+		if err == nil {
+			next.Prerelease = id + "." + strconv.Itoa(n+1)
+		}
+	}
+	return next
+}
+
 type RuleStats struct {
 	Breaking []string
 	Features []string
@@ -127,21 +281,12 @@ func SuggestWithRules(base SemVer, commits []git.CommitInfo) RuleResult {
 			continue
 		}
 
-		commitType, breaking := parseCommitType(message)
-		if commitType == "" {
-			commitType = inferCommitType(message)
-		}
-
-		if !breaking && containsBreakingChange(message, commit.Body) {
-			breaking = true
-		}
-
-		switch {
-		case breaking:
+		switch classifyCommit(commit) {
+		case categoryBreaking:
 			stats.Breaking = append(stats.Breaking, message)
-		case commitType == "feat":
+		case categoryFeature:
 			stats.Features = append(stats.Features, message)
-		case isPatchType(commitType):
+		case categoryPatch:
 			stats.Patches = append(stats.Patches, message)
 		default:
 			stats.Others = append(stats.Others, message)
@@ -193,6 +338,35 @@ func SuggestWithRules(base SemVer, commits []git.CommitInfo) RuleResult {
 	return result
 }
 
+type commitCategory int
+
+const (
+	categoryBreaking commitCategory = iota
+	categoryFeature
+	categoryPatch
+	categoryOther
+)
+
+// classifyCommit maps a commit to the bump category it contributes to.
+func classifyCommit(commit git.CommitInfo) commitCategory {
+	message := strings.TrimSpace(commit.Message)
+	commitType, breaking := parseCommitType(message)
+	if commitType == "" {
+		commitType = inferCommitType(message)
+	}
+
+	switch {
+	case breaking || containsBreakingChange(message, commit.Body):
+		return categoryBreaking
+	case commitType == "feat":
+		return categoryFeature
+	case isPatchType(commitType):
+		return categoryPatch
+	default:
+		return categoryOther
+	}
+}
+
 func parseCommitType(message string) (string, bool) {
 	matches := commitTypePattern.FindStringSubmatch(message)
 	if len(matches) == 0 {
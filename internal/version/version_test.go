@@ -34,6 +34,74 @@ func TestSemVerComparison(t *testing.T) {
 	assert.True(t, base.GreaterThan(SemVer{Major: 1, Minor: 2, Patch: 2}))
 }
 
+func TestParseSemVerPrerelease(t *testing.T) {
+	v, err := ParseSemVer("v1.3.0-rc.1+build.5")
+	assert.NoError(t, err)
+	assert.Equal(t, SemVer{Major: 1, Minor: 3, Prerelease: "rc.1", Build: "build.5"}, v)
+	assert.Equal(t, "v1.3.0-rc.1+build.5", v.String())
+	assert.True(t, v.IsPrerelease())
+	assert.Equal(t, "v1.3.0", v.Finalize().String())
+
+	v, err = ParseSemVer("1.0.0-x-y.0+001")
+	assert.NoError(t, err)
+	assert.Equal(t, "x-y.0", v.Prerelease)
+	assert.Equal(t, "001", v.Build, "build identifiers may have leading zeros")
+
+	for _, invalid := range []string{"v1.2.3-", "v1.2.3-rc..1", "v1.2.3-rc.01", "v1.2.3+", "v1.2.3-rc_1"} {
+		_, err := ParseSemVer(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSemVerPrecedence(t *testing.T) {
+	ordered := []string{
+		"v1.0.0-alpha", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", "v1.0.0-beta", "v1.0.0-beta.2",
+		"v1.0.0-beta.11", "v1.0.0-rc.1", "v1.0.0", "v1.0.1-rc.1", "v1.0.1",
+	}
+	for i := 1; i < len(ordered); i++ {
+		lower, _ := ParseSemVer(ordered[i-1])
+		higher, _ := ParseSemVer(ordered[i])
+		assert.True(t, lower.LessThan(higher), "%s < %s", lower, higher)
+		assert.Equal(t, 1, higher.Compare(lower))
+	}
+
+	withBuild, _ := ParseSemVer("v1.0.0+build.1")
+	assert.True(t, withBuild.Equal(SemVer{Major: 1}), "build metadata does not affect precedence")
+}
+
+func TestSemVerBumpsFinalizePrereleases(t *testing.T) {
+	rc := func(tag string) SemVer {
+		v, err := ParseSemVer(tag)
+		assert.NoError(t, err)
+		return v
+	}
+
+	assert.Equal(t, "v1.2.3", rc("v1.2.3-rc.1").NextPatch().String())
+	assert.Equal(t, "v1.3.0", rc("v1.3.0-rc.1").NextMinor().String())
+	assert.Equal(t, "v1.3.0", rc("v1.2.3-rc.1").NextMinor().String())
+	assert.Equal(t, "v2.0.0", rc("v2.0.0-rc.1").NextMajor().String())
+	assert.Equal(t, "v2.0.0", rc("v1.3.0-rc.1").NextMajor().String())
+}
+
+func TestNextPrerelease(t *testing.T) {
+	tests := []struct {
+		base, target, id, want string
+	}{
+		{"v1.2.3", "v1.3.0", "rc", "v1.3.0-rc.1"},
+		{"v1.3.0-rc.1", "v1.3.0", "rc", "v1.3.0-rc.2"},
+		{"v1.3.0-rc.9", "v1.3.0", "rc", "v1.3.0-rc.10"},
+		{"v1.3.0-beta.2", "v1.3.0", "rc", "v1.3.0-rc.1"},
+		{"v1.3.0-rc.2", "v2.0.0", "rc", "v2.0.0-rc.1"},
+		{"v1.2.3", "v1.2.3", "rc", "v1.2.4-rc.1"},
+		{"v1.3.0-rc.1", "v1.3.0-rc.1", "rc", "v1.3.0-rc.2"},
+	}
+	for _, tt := range tests {
+		base, _ := ParseSemVer(tt.base)
+		target, _ := ParseSemVer(tt.target)
+		assert.Equal(t, tt.want, NextPrerelease(base, target, tt.id).String(), "%s toward %s", tt.base, tt.target)
+	}
+}
+
 func TestSuggestWithRulesMajor(t *testing.T) {
 	base, _ := ParseSemVer("v1.2.3")
 	commits := []git.CommitInfo{
@@ -0,0 +1,117 @@
+// Package watch reports when the files under a directory stop changing, for gmc watch
+// to commit them once an edit settles.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Options configure Run.
+type Options struct {
+	// Root is the directory watched, with its subdirectories.
+	Root string
+	// Interval is how long the files must stay unchanged before OnSettle runs.
+	Interval time.Duration
+	// Skip reports the directories, relative to Root and with forward slashes, that are
+	// not watched. .git directories never are.
+	Skip func(dir string) bool
+	// OnSettle runs once Interval passes without a change after the last one. Changes
+	// made while it runs start another interval.
+	OnSettle func()
+}
+
+// Run watches Root until ctx is done. Directories created under it are watched too.
+func Run(ctx context.Context, opts Options) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := addTree(watcher, opts.Root, opts); err != nil {
+		return err
+	}
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod || inGitDir(opts.Root, event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addTree(watcher, event.Name, opts); err != nil {
+						return err
+					}
+				}
+			}
+			settle = time.After(opts.Interval)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			// Dropped events still mean something changed.
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				settle = time.After(opts.Interval)
+				continue
+			}
+			return fmt.Errorf("failed to watch %s: %w", opts.Root, err)
+		case <-settle:
+			settle = nil
+			opts.OnSettle()
+		}
+	}
+}
+
+// addTree watches dir and the directories under it, except .git and those opts.Skip
+// reports.
+func addTree(watcher *fsnotify.Watcher, dir string, opts Options) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// A directory removed while walking needs no watch.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if rel, err := filepath.Rel(opts.Root, path); err == nil && rel != "." &&
+			opts.Skip != nil && opts.Skip(filepath.ToSlash(rel)) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// inGitDir reports whether path is a .git directory under root or inside one.
+func inGitDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(strings.Split(filepath.ToSlash(rel), "/"), ".git")
+}
@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSettlesOnceAfterABurst(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "node_modules"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0o755))
+
+	settled := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, Options{
+			Root:     root,
+			Interval: 100 * time.Millisecond,
+			Skip:     func(dir string) bool { return dir == "node_modules" },
+			OnSettle: func() { settled <- struct{}{} },
+		})
+	}()
+	// Give the watcher time to add the tree.
+	time.Sleep(100 * time.Millisecond)
+
+	write := func(name string) {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+	}
+	write("a.go")
+	write("b.go")
+	write("a.go")
+	waitSettled(t, settled)
+	assertQuiet(t, settled, "a burst of writes settles once")
+
+	write("node_modules/x.js")
+	write(".git/index")
+	assertQuiet(t, settled, "skipped and .git directories are not watched")
+
+	write("pkg/new/c.go")
+	waitSettled(t, settled)
+	write("pkg/new/c.go")
+	waitSettled(t, settled)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func waitSettled(t *testing.T, settled <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-settled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the changes never settled")
+	}
+}
+
+func assertQuiet(t *testing.T, settled <-chan struct{}, msg string) {
+	t.Helper()
+	select {
+	case <-settled:
+		assert.Fail(t, msg)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
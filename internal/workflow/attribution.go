@@ -0,0 +1,49 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GeneratedByTrailer is the trailer that marks a commit message gmc generated, with
+// the value "gmc" followed by the version and model.
+const GeneratedByTrailer = "Generated-by"
+
+// HasGeneratedByTrailer reports whether the trailer block of message, its last
+// paragraph, holds a Generated-by trailer naming gmc.
+func HasGeneratedByTrailer(message string) bool {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		return false
+	}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), GeneratedByTrailer) {
+			continue
+		}
+		if fields := strings.Fields(value); len(fields) > 0 && fields[0] == "gmc" {
+			return true
+		}
+	}
+	return false
+}
+
+// attributionTrailer returns the Generated-by trailer for message when the attribution
+// config is on, or "" when it is off, message already has one, or message came from a
+// file rather than from gmc.
+func (f *CommitFlow) attributionTrailer(message string) string {
+	if f.cfg == nil || !f.cfg.Attribution || f.opts.Message != "" || HasGeneratedByTrailer(message) {
+		return ""
+	}
+	version := f.opts.Version
+	if version == "" {
+		version = "dev"
+	} else if version[0] >= '0' && version[0] <= '9' {
+		version = "v" + version
+	}
+	model := f.cfg.Model
+	if f.historyModel != "" {
+		model = f.historyModel
+	}
+	return fmt.Sprintf("%s: gmc %s (%s)", GeneratedByTrailer, version, model)
+}
@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasGeneratedByTrailer(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"feat: add a\n\nGenerated-by: gmc v1.2.0 (gpt-4.1)", true},
+		{"feat: add a\n\nBody.\n\nSigned-off-by: A <a@example.com>\ngenerated-by: gmc", true},
+		{"feat: add a\n\nGenerated-by: other-tool v1", false},
+		{"feat: add a\n\nGenerated-by: gmc v1\n\nSigned-off-by: A <a@example.com>", false},
+		{"Generated-by: gmc", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, HasGeneratedByTrailer(tt.message), tt.message)
+	}
+}
+
+func TestApplyTrailersAddsAttribution(t *testing.T) {
+	gitClient := &trailerRecorder{}
+	cfg := &config.Config{Model: "gpt-4.1-mini", Attribution: true}
+	flow := &CommitFlow{git: gitClient, cfg: cfg, opts: CommitOptions{Version: "1.4.0"}}
+
+	message, err := flow.applyTrailers("feat: x")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: x\n\nGenerated-by: gmc v1.4.0 (gpt-4.1-mini)", message)
+	assert.True(t, HasGeneratedByTrailer(message))
+
+	gitClient.trailers = nil
+	flow.historyModel = OfflineModel
+	flow.opts.Version = "v1.4.0-3-gabc1234"
+	_, err = flow.applyTrailers("feat: x")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Generated-by: gmc v1.4.0-3-gabc1234 (offline)"}, gitClient.trailers)
+
+	gitClient.trailers = nil
+	_, err = flow.applyTrailers(message)
+	require.NoError(t, err)
+	assert.Nil(t, gitClient.trailers, "a message with the trailer keeps it")
+
+	flow.opts.Message = "feat: from a file"
+	_, err = flow.applyTrailers("feat: from a file")
+	require.NoError(t, err)
+	assert.Nil(t, gitClient.trailers, "messages from a file are not attributed")
+
+	flow.opts.Message = ""
+	cfg.Attribution = false
+	_, err = flow.applyTrailers("feat: x")
+	require.NoError(t, err)
+	assert.Nil(t, gitClient.trailers)
+}
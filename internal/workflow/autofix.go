@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"fmt"
+	"slices"
+)
+
+// commitWithAutofixRetry runs commit and, when it fails after a pre-commit hook
+// rewrote staged files, as formatters do, restages those files and runs commit once
+// more with the same message. Files that already had unstaged changes are left
+// alone: restaging them would also stage edits the user kept out of the commit.
+func (f *CommitFlow) commitWithAutofixRetry(commit func() error) error {
+	if f.opts.NoVerify || f.cfg == nil || !f.cfg.HookAutofixRetry {
+		return commit()
+	}
+
+	_, before, err := f.git.StagedPaths()
+	if err != nil {
+		return commit()
+	}
+	commitErr := commit()
+	if commitErr == nil {
+		return nil
+	}
+
+	_, after, err := f.git.StagedPaths()
+	if err != nil {
+		return commitErr
+	}
+	fixed := hookModifiedPaths(before, after)
+	if len(fixed) == 0 {
+		return commitErr
+	}
+
+	fmt.Fprintf(f.opts.ErrWriter, "The commit failed after a hook modified %d staged file(s). Restaging:\n", len(fixed))
+	for _, file := range fixed {
+		fmt.Fprintf(f.opts.ErrWriter, "  %s\n", file)
+	}
+	if err := f.git.StageFiles(topPathspecs(fixed)); err != nil {
+		return fmt.Errorf("%w; restaging the files the hook modified failed: %w", commitErr, err)
+	}
+	fmt.Fprintln(f.opts.ErrWriter, "Retrying the commit with the same message...")
+	if err := commit(); err != nil {
+		return fmt.Errorf("retry after restaging hook changes: %w", err)
+	}
+	return nil
+}
+
+// hookModifiedPaths returns the staged paths with unstaged changes after the commit
+// attempt that had none before it.
+func hookModifiedPaths(before, after []string) []string {
+	var fixed []string
+	for _, file := range after {
+		if !slices.Contains(before, file) {
+			fixed = append(fixed, file)
+		}
+	}
+	return fixed
+}
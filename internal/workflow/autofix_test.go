@@ -0,0 +1,119 @@
+package workflow
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// autofixGit fails the first commit and, like a formatter hook, leaves hookFixed with
+// unstaged changes.
+type autofixGit struct {
+	GitClient
+	unstaged  []string
+	hookFixed []string
+	failAll   bool
+	commits   int
+	staged    []string
+}
+
+func (g *autofixGit) StagedPaths() ([]string, []string, error) {
+	return nil, g.unstaged, nil
+}
+
+func (g *autofixGit) StageFiles(files []string) error {
+	g.staged = append(g.staged, files...)
+	return nil
+}
+
+func (g *autofixGit) Commit(string, ...string) error {
+	g.commits++
+	if g.commits == 1 || g.failAll {
+		g.unstaged = append(g.unstaged, g.hookFixed...)
+		g.hookFixed = nil
+		return errors.New("pre-commit hook failed")
+	}
+	return nil
+}
+
+func (g *autofixGit) CommitFiles(message string, _ []string, args ...string) error {
+	return g.Commit(message, args...)
+}
+
+func newAutofixFlow(gitClient GitClient, retry bool) (*CommitFlow, *bytes.Buffer) {
+	var errOut bytes.Buffer
+	return &CommitFlow{
+		git:  gitClient,
+		cfg:  &config.Config{HookAutofixRetry: retry},
+		opts: CommitOptions{NoSignoff: true, ErrWriter: &errOut, OutWriter: &bytes.Buffer{}},
+	}, &errOut
+}
+
+func TestPerformCommitRestagesHookFixes(t *testing.T) {
+	gitClient := &autofixGit{unstaged: []string{"partial.go"}, hookFixed: []string{"main.go", "util.go"}}
+	flow, errOut := newAutofixFlow(gitClient, true)
+
+	require.NoError(t, flow.performCommit("fix: format"))
+	assert.Equal(t, 2, gitClient.commits)
+	assert.Equal(t, []string{":(top,literal)main.go", ":(top,literal)util.go"}, gitClient.staged,
+		"files with unstaged changes before the commit are not restaged")
+	assert.Contains(t, errOut.String(), "a hook modified 2 staged file(s)")
+	assert.Contains(t, errOut.String(), "  main.go\n")
+}
+
+func TestSelectiveCommitsRestageHookFixes(t *testing.T) {
+	commits := map[string]func(*CommitFlow) error{
+		"selective": func(f *CommitFlow) error {
+			return f.performSelectiveCommit("fix: format", []string{"main.go"})
+		},
+		"per-package": func(f *CommitFlow) error {
+			return f.performPackageCommit("fix: format", []string{"main.go"}, []string{":(top,literal)main.go"})
+		},
+	}
+	for name, commit := range commits {
+		t.Run(name, func(t *testing.T) {
+			gitClient := &autofixGit{hookFixed: []string{"main.go"}}
+			flow, errOut := newAutofixFlow(gitClient, true)
+
+			require.NoError(t, commit(flow))
+			assert.Equal(t, 2, gitClient.commits)
+			assert.Equal(t, []string{":(top,literal)main.go"}, gitClient.staged)
+			assert.Contains(t, errOut.String(), "Retrying the commit with the same message")
+		})
+	}
+}
+
+func TestPerformCommitAutofixRetryOnce(t *testing.T) {
+	gitClient := &autofixGit{hookFixed: []string{"main.go"}, failAll: true}
+	flow, _ := newAutofixFlow(gitClient, true)
+
+	err := flow.performCommit("fix: format")
+	assert.ErrorContains(t, err, "retry after restaging hook changes")
+	assert.Equal(t, 2, gitClient.commits)
+}
+
+func TestPerformCommitWithoutAutofixRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		retry     bool
+		hookFixed []string
+	}{
+		{name: "disabled", retry: false, hookFixed: []string{"main.go"}},
+		{name: "hook changed nothing", retry: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitClient := &autofixGit{hookFixed: tt.hookFixed}
+			flow, _ := newAutofixFlow(gitClient, tt.retry)
+
+			err := flow.performCommit("fix: format")
+			assert.ErrorContains(t, err, "pre-commit hook failed")
+			assert.Equal(t, 1, gitClient.commits)
+			assert.Empty(t, gitClient.staged)
+		})
+	}
+}
@@ -1,19 +1,41 @@
 package workflow
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/samzong/gmc/internal/analyzer"
 	"github.com/samzong/gmc/internal/branch"
 	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/forge"
 	"github.com/samzong/gmc/internal/formatter"
-	"github.com/samzong/gmc/internal/stringsutil"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/gmcerrors"
+	"github.com/samzong/gmc/internal/history"
+	"github.com/samzong/gmc/internal/hunks"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/notes"
+	"github.com/samzong/gmc/internal/risk"
+	"github.com/samzong/gmc/internal/summarize"
 	"github.com/samzong/gmc/internal/ui"
 )
 
-var ErrNoChanges = errors.New("no changes detected in the staging area files")
+// ErrNoChanges is returned when nothing is staged; it is gmcerrors.ErrNoChanges.
+var ErrNoChanges = gmcerrors.ErrNoChanges
+
+// errCommitCancelled is returned when the user declines the generated message.
+var errCommitCancelled = gmcerrors.Mark(errors.New("commit cancelled by user"), gmcerrors.ErrUserCancelled)
+
+// ErrRiskNotAcknowledged is returned when a commit flagged by risk_policies is not confirmed.
+var ErrRiskNotAcknowledged = errors.New("risky commit not acknowledged")
+
+const issueFetchTimeout = 5 * time.Second
 
 type CommitOptions struct {
 	AddAll     bool
@@ -25,8 +47,55 @@ type CommitOptions struct {
 	Verbose    bool
 	BranchDesc string
 	UserPrompt string
-	ErrWriter  io.Writer
-	OutWriter  io.Writer
+	// Type and Scope are the type and scope the message must have. They are asked for
+	// in the prompt and forced onto a reply that ignores them.
+	Type  string
+	Scope string
+	// Author and Date override the commit's author identity and date, as git commit's
+	// --author and --date do.
+	Author string
+	Date   string
+	// SignKey is the key --gpg-sign=<keyid> signs with. Whether to sign at all is the
+	// sign_commits config, which --gpg-sign turns on.
+	SignKey string
+	// StrictContext fails the commit instead of generating from a truncated diff.
+	StrictContext bool
+	// Offline writes the message with formatter.HeuristicMessage instead of the LLM.
+	// Without it, the heuristic is used when the LLM cannot be reached, unless AutoYes
+	// would commit the message unreviewed.
+	Offline bool
+	// Interactive lets the user pick the staged and unstaged hunks to commit before
+	// generating. It needs a HunkPicker.
+	Interactive bool
+	// AcknowledgeRisk confirms a commit flagged by risk_policies without asking.
+	AcknowledgeRisk bool
+	// PerPackage commits the staged changes of each package_globs package separately.
+	PerPackage bool
+	// Trailers are added to the commit message after the trailers config, as --trailer
+	// adds them.
+	Trailers []config.Trailer
+	// IssueComment comments the commit message on the issue, and IssueTransition moves
+	// the issue through the named transition, after a commit. They need an IssueUpdater.
+	IssueComment    bool
+	IssueTransition string
+	// Message, such as a file read by ReadMessageFile, is offered instead of a generated
+	// message, without calling the LLM.
+	Message string
+	// OutFile receives the accepted message, written by WriteMessageFile.
+	OutFile string
+	// Models generates the message with each of these models at once, instead of the
+	// configured one, and lets the user pick among them.
+	Models []string
+	// Squashed are the messages of the commits the staged changes combine, oldest
+	// first, given to the prompt as context.
+	Squashed []string
+	// Version is the gmc version the Generated-by trailer of the attribution config names.
+	Version string
+	// JSON prints a CommitResult to OutWriter instead of the bare message, or a list of
+	// them with PerPackage.
+	JSON      bool
+	ErrWriter io.Writer
+	OutWriter io.Writer
 }
 
 type CommitFlow struct {
@@ -35,6 +104,61 @@ type CommitFlow struct {
 	cfg      *config.Config
 	opts     CommitOptions
 	prompter Prompter
+	issues   IssueFetcher
+	updater  IssueUpdater
+	picker   HunkPicker
+	history  HistoryRecorder
+
+	// breaking is the BREAKING CHANGE footer text once the user confirms detected breaks.
+	breaking string
+
+	// promptHash and candidates feed the generation note written after the commit.
+	promptHash string
+	candidates []string
+	// historyModel is the model recorded in the history and the generation note, when
+	// not cfg.Model (redo, or OfflineModel).
+	historyModel string
+
+	issueTimeout time.Duration
+	prefetch     *issuePrefetch
+	issue        *formatter.IssueContext
+	issueLoaded  bool
+
+	// summarizer condenses diffs too large for the prompt; summarized marks the diff
+	// passed to generation as its summary.
+	summarizer summarize.Client
+	summarized bool
+
+	// packageScope is the scope hint of the package --per-package is committing.
+	packageScope string
+
+	// ticket is the ticket ID issue_pattern found in the branch name, without --issue.
+	ticket string
+	// transitioned marks the issue moved by IssueTransition, which --per-package does once.
+	transitioned bool
+	// noTicketWarned marks the warning that there is no ticket to update as shown.
+	noTicketWarned bool
+
+	result CommitResult
+}
+
+// CommitResult is the -o json output of a commit.
+type CommitResult struct {
+	Message   string   `json:"message"`
+	Committed bool     `json:"committed"`
+	DryRun    bool     `json:"dry_run"`
+	Files     []string `json:"files"`
+	// Risks are the risk_policies the commit triggers.
+	Risks            []risk.Finding `json:"risks"`
+	RiskAcknowledged bool           `json:"risk_acknowledged"`
+}
+
+// issuePrefetch is an issue lookup running alongside diff collection.
+type issuePrefetch struct {
+	done     chan struct{}
+	deadline time.Time
+	issue    *forge.Issue
+	err      error
 }
 
 func NewCommitFlow(git GitClient, llm LLMClient, cfg *config.Config, opts CommitOptions) *CommitFlow {
@@ -44,6 +168,8 @@ func NewCommitFlow(git GitClient, llm LLMClient, cfg *config.Config, opts Commit
 		cfg:      cfg,
 		opts:     opts,
 		prompter: &InteractivePrompter{ErrWriter: opts.ErrWriter},
+
+		issueTimeout: issueFetchTimeout,
 	}
 }
 
@@ -51,11 +177,59 @@ func (f *CommitFlow) SetPrompter(p Prompter) {
 	f.prompter = p
 }
 
+// SetSummarizer enables summarizing diffs too large for the prompt, file by file,
+// instead of truncating them.
+func (f *CommitFlow) SetSummarizer(client summarize.Client) {
+	f.summarizer = client
+}
+
+// SetHunkPicker sets the picker --interactive uses.
+func (f *CommitFlow) SetHunkPicker(picker HunkPicker) {
+	f.picker = picker
+}
+
+// SetIssueFetcher enables issue metadata enrichment for --issue, or for the branch's
+// ticket.
+func (f *CommitFlow) SetIssueFetcher(fetcher IssueFetcher) {
+	f.issues = fetcher
+}
+
+// SetHistory records every generated message, with its outcome, to recorder.
+func (f *CommitFlow) SetHistory(recorder HistoryRecorder) {
+	f.history = recorder
+}
+
+// SetIssueUpdater enables IssueComment and IssueTransition.
+func (f *CommitFlow) SetIssueUpdater(updater IssueUpdater) {
+	f.updater = updater
+}
+
 func (f *CommitFlow) Run(fileArgs []string) error {
+	if f.opts.Interactive && len(fileArgs) > 0 {
+		return errors.New("--interactive cannot be combined with file paths")
+	}
+	if f.opts.PerPackage && len(fileArgs) > 0 {
+		return errors.New("--per-package cannot be combined with file paths")
+	}
+
+	f.startIssuePrefetch()
+
+	if err := f.checkAuthor(); err != nil {
+		return err
+	}
+
+	if err := f.checkSigning(); err != nil {
+		return err
+	}
+
 	if err := f.handleBranchCreation(); err != nil {
 		return err
 	}
 
+	if err := f.detectTicket(); err != nil {
+		return err
+	}
+
 	if len(fileArgs) > 0 {
 		return f.handleSelectiveCommit(fileArgs)
 	}
@@ -64,6 +238,14 @@ func (f *CommitFlow) Run(fileArgs []string) error {
 		return err
 	}
 
+	if err := f.pickHunks(); err != nil {
+		return err
+	}
+
+	if f.opts.PerPackage {
+		return f.commitPerPackage()
+	}
+
 	diff, changedFiles, err := f.getStagedChanges()
 	if err != nil {
 		return err
@@ -72,15 +254,83 @@ func (f *CommitFlow) Run(fileArgs []string) error {
 	return f.runCommitLoop(diff, changedFiles, f.performCommit)
 }
 
+// checkAuthor resolves the author identity before generating, so a bad --author or
+// --date fails without an LLM call. It is a no-op when neither the flags nor
+// GIT_AUTHOR_* override the configured identity.
+func (f *CommitFlow) checkAuthor() error {
+	if f.opts.Author == "" && f.opts.Date == "" && !authorEnvSet() {
+		return nil
+	}
+	ident, err := f.git.AuthorIdent(f.opts.Author, f.opts.Date)
+	if err != nil {
+		return fmt.Errorf("invalid author override: %w", err)
+	}
+	fmt.Fprintf(f.opts.ErrWriter, "Author: %s\n", ident)
+	return nil
+}
+
+func authorEnvSet() bool {
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_AUTHOR_DATE"} {
+		if os.Getenv(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// signCommit reports whether gmc asks git to sign the commit. git may still sign it on
+// its own when commit.gpgsign is set.
+func (f *CommitFlow) signCommit() bool {
+	return f.opts.SignKey != "" || (f.cfg != nil && f.cfg.SignCommits)
+}
+
+// checkSigning reports how the commit will be signed, so signing from --gpg-sign,
+// sign_commits or git's commit.gpgsign is visible before generating. It fails early
+// when SSH signing has no key to sign with.
+func (f *CommitFlow) checkSigning() error {
+	signing := f.git.SigningConfig()
+	if !f.signCommit() && !signing.Enabled {
+		return nil
+	}
+
+	key := f.opts.SignKey
+	if key == "" {
+		key = signing.Key
+	}
+	if key == "" && signing.Format == git.SigningFormatSSH && signing.SSHKeyCommand == "" {
+		return errors.New("SSH commit signing needs a key: set user.signingkey or pass --gpg-sign=<key>")
+	}
+	if key == "" {
+		key = "default key"
+	}
+
+	source := "commit.gpgsign"
+	if f.signCommit() {
+		source = "sign_commits"
+		if f.opts.SignKey != "" {
+			source = "--gpg-sign"
+		}
+	}
+	fmt.Fprintf(f.opts.ErrWriter, "Signing: %s, %s (%s)\n", signing.Format, key, source)
+	return nil
+}
+
 func (f *CommitFlow) handleBranchCreation() error {
 	if f.opts.BranchDesc == "" {
 		return nil
 	}
 
-	branchName := branch.GenerateName(f.opts.BranchDesc)
+	opts := branch.Options{Ticket: f.opts.IssueNum}
+	if f.cfg != nil {
+		opts.Naming = f.cfg.BranchNaming
+	}
+	branchName := branch.GenerateNameWithOptions(f.opts.BranchDesc, opts)
 	if branchName == "" {
 		return errors.New("invalid branch description: cannot generate branch name")
 	}
+	if err := branch.Check(branchName, opts.Naming); err != nil {
+		return err
+	}
 
 	fmt.Fprintf(f.opts.ErrWriter, "Creating and switching to branch: %s\n", branchName)
 	if err := f.git.CreateAndSwitchBranch(branchName); err != nil {
@@ -102,27 +352,54 @@ func (f *CommitFlow) handleStaging() error {
 	return nil
 }
 
-func (f *CommitFlow) getStagedChanges() (string, []string, error) {
-	diff, err := f.git.GetStagedDiff()
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to get git diff: %w", err)
+// pickHunks stages exactly the hunks the user picks for --interactive. Unpicked
+// changes stay in the working tree.
+func (f *CommitFlow) pickHunks() error {
+	if !f.opts.Interactive {
+		return nil
+	}
+	if f.picker == nil {
+		return errors.New("--interactive needs a terminal")
 	}
 
-	if diff == "" {
-		return "", nil, ErrNoChanges
+	staged, err := f.git.GetHunkDiff(true)
+	if err != nil {
+		return fmt.Errorf("failed to get staged hunks: %w", err)
+	}
+	unstaged, err := f.git.GetHunkDiff(false)
+	if err != nil {
+		return fmt.Errorf("failed to get unstaged hunks: %w", err)
+	}
+	files := hunks.Parse(staged, unstaged)
+	if len(files) == 0 {
+		return errors.New("no changes to pick: untracked files need git add first")
 	}
 
-	stats, err := f.git.GetStagedDiffStats()
+	picked, err := f.picker.PickHunks(files)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get git diff stats: %w", err)
+		return err
+	}
+	stage, unstage := hunks.Selection(picked)
+	if err := f.git.StageSelection(stage, unstage); err != nil {
+		return err
 	}
 
-	changedFiles, err := f.git.ParseStagedFiles()
+	selected, total := hunks.Count(picked)
+	fmt.Fprintf(f.opts.ErrWriter, "Staged %d of %d hunks.\n", selected, total)
+	return nil
+}
+
+func (f *CommitFlow) getStagedChanges() (string, []string, error) {
+	changes, err := f.git.GetStagedChanges()
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to parse staged files: %w", err)
+		return "", nil, fmt.Errorf("failed to get git diff: %w", err)
+	}
+
+	if changes.Diff == "" {
+		return "", nil, ErrNoChanges
 	}
 
-	return diff + "\n" + formatter.DiffStatsSeparator + "\n" + stats, changedFiles, nil
+	return changes.Diff + "\n" + formatter.DiffStatsSeparator + "\n" + changes.Stats, changes.Files, nil
 }
 
 func (f *CommitFlow) handleSelectiveCommit(fileArgs []string) error {
@@ -204,12 +481,38 @@ func (f *CommitFlow) commitStagedFiles(files []string) error {
 	})
 }
 
-func (f *CommitFlow) runCommitLoop(diff string, files []string, commitFn func(string) error) error {
+func (f *CommitFlow) runCommitLoop(diff string, files []string, commitFn func(string) error) (err error) {
+	f.result = CommitResult{DryRun: f.opts.DryRun, Files: files, Risks: []risk.Finding{}}
+	// finalMessage is the message passed to commitFn, once the user accepts one.
+	var finalMessage string
+	defer func() { f.recordHistory(diff, files, finalMessage, err) }()
+	if f.opts.JSON && !f.opts.PerPackage {
+		defer func() {
+			if err == nil || errors.Is(err, ErrRiskNotAcknowledged) {
+				f.printResult()
+			}
+		}()
+	}
+
+	promptDiff := diff
+	if f.opts.Message == "" {
+		if promptDiff, err = f.preparePromptDiff(diff); err != nil {
+			return err
+		}
+	}
+	if err := f.preflight(diff, promptDiff, files); err != nil {
+		return err
+	}
+	if err := f.checkRisk(diff, files); err != nil {
+		return err
+	}
+
 	for {
-		message, err := f.generateCommitMessage(files, diff)
+		message, err := f.generateCommitMessage(files, promptDiff)
 		if err != nil {
 			return err
 		}
+		f.candidates = append(f.candidates, message)
 
 		action, editedMessage, err := f.prompter.GetConfirmation(message, f.opts.AutoYes)
 		if err != nil {
@@ -218,40 +521,528 @@ func (f *CommitFlow) runCommitLoop(diff string, files []string, commitFn func(st
 
 		switch action {
 		case ActionCancel:
-			fmt.Fprintln(f.opts.ErrWriter, "Commit cancelled by user")
-			return nil
+			return errCommitCancelled
 		case ActionRegenerate:
+			if f.opts.Message != "" {
+				return errors.New("the message comes from a file; run gmc without --use-message-file to generate one")
+			}
 			fmt.Fprintln(f.opts.ErrWriter, "Regenerating commit message...")
 			continue
 		case ActionCommit:
-			finalMessage := message
+			accepted := message
 			if editedMessage != "" {
-				finalMessage = editedMessage
+				accepted = editedMessage
+			}
+			accepted = f.applyTicket(f.applyIssueSuffix(accepted))
+			if accepted, err = f.applyTrailers(accepted); err != nil {
+				return err
 			}
-			finalMessage = f.applyIssueSuffix(finalMessage)
-			return commitFn(finalMessage)
+			finalMessage = accepted
+			if err := commitFn(finalMessage); err != nil {
+				return err
+			}
+			if err := f.writeOutFile(finalMessage); err != nil {
+				return err
+			}
+			f.result.Message = finalMessage
+			f.result.Committed = !f.opts.DryRun
+			f.recordGeneration(editedMessage != "")
+			if f.result.Committed {
+				f.updateIssue(finalMessage)
+			}
+			return nil
 		}
 	}
 }
 
+// preparePromptDiff returns the diff the prompt describes: outlined, summarized when
+// too large, and checked by StrictContext. It also asks about breaking changes.
+func (f *CommitFlow) preparePromptDiff(diff string) (string, error) {
+	// Outlines of new files count toward the prompt's size; the summarizer gets the
+	// full diff.
+	promptDiff := formatter.OutlineNewFilesForConfig(f.cfg, diff)
+	if formatter.NeedsSummary(promptDiff, f.summarizeThreshold()) {
+		promptDiff, f.summarized = f.summarizeDiff(diff)
+	}
+	if f.opts.StrictContext && !f.summarized {
+		if err := formatter.CheckPromptContext(f.cfg, promptDiff); err != nil {
+			return "", err
+		}
+	}
+	if err := f.confirmBreaking(diff); err != nil {
+		return "", err
+	}
+	return promptDiff, nil
+}
+
+// writeOutFile writes message to OutFile, when set.
+func (f *CommitFlow) writeOutFile(message string) error {
+	if f.opts.OutFile == "" {
+		return nil
+	}
+	if err := WriteMessageFile(f.opts.OutFile, message); err != nil {
+		return err
+	}
+	fmt.Fprintf(f.opts.ErrWriter, "Wrote the commit message to %s\n", f.opts.OutFile)
+	return nil
+}
+
 func (f *CommitFlow) generateCommitMessage(changedFiles []string, diff string) (string, error) {
-	prompt := formatter.BuildPromptWithConfig(f.cfg, changedFiles, diff, f.opts.UserPrompt)
+	var formattedMessage string
+	heading := "Generated Commit Message:"
+	switch {
+	case f.opts.Message != "":
+		f.historyModel = MessageFileModel
+		formattedMessage = f.opts.Message
+		heading = "Commit Message:"
+	case f.opts.Offline:
+		formattedMessage = f.offlineMessage(changedFiles, diff)
+	default:
+		// A regenerate after the offline fallback is written by cfg.Model, unless
+		// compareModels picks another model or the fallback runs again.
+		f.historyModel = ""
+		var err error
+		formattedMessage, err = f.llmMessage(changedFiles, diff)
+		if err != nil {
+			if !llm.IsUnreachable(err) || f.opts.AutoYes {
+				return "", err
+			}
+			fmt.Fprintf(f.opts.ErrWriter, "Warning: %v\n", err)
+			fmt.Fprintln(f.opts.ErrWriter, "Warning: the LLM is unreachable, so the message was written offline "+
+				"from the diff; review it or choose regenerate.")
+			formattedMessage = f.offlineMessage(changedFiles, diff)
+		}
+	}
+
+	if f.breaking != "" {
+		formattedMessage = formatter.MarkBreaking(formattedMessage, f.breaking)
+	}
+	formattedMessage = f.applyTicket(f.applyIssueSuffix(formattedMessage))
 
+	fmt.Fprintln(f.opts.ErrWriter, "\n"+heading)
+	if f.opts.JSON {
+		fmt.Fprintln(f.opts.ErrWriter, formattedMessage)
+	} else {
+		fmt.Fprintln(f.opts.OutWriter, formattedMessage)
+	}
+	f.result.Message = formattedMessage
+	return formattedMessage, nil
+}
+
+// OfflineModel is the model history and generation notes record for messages written
+// by formatter.HeuristicMessage.
+const OfflineModel = "offline"
+
+// MessageFileModel is the model history and generation notes record for a Message
+// given in the options.
+const MessageFileModel = "message-file"
+
+// offlineMessage writes the message with formatter.HeuristicMessage, scoped like the
+// prompt would be.
+func (f *CommitFlow) offlineMessage(changedFiles []string, diff string) string {
+	f.historyModel = OfflineModel
+	scope := f.opts.Scope
+	if scope == "" {
+		scope = f.scopeHint(changedFiles)
+	}
+	message := formatter.HeuristicMessage(f.cfg, changedFiles, diff, scope)
+	message = formatter.FormatCommitMessageWithConfig(f.cfg, message)
+	return formatter.ConstrainMessage(message, f.opts.Type, f.opts.Scope)
+}
+
+// llmMessage asks the LLM for the message, and once more when it replies with a type
+// outside commit_types.
+func (f *CommitFlow) llmMessage(changedFiles []string, diff string) (string, error) {
+	typeHint := ""
+	if f.opts.Type == "" {
+		typeHint = formatter.TypeHintForConfig(f.cfg, changedFiles)
+	}
+	prompt := formatter.BuildPromptWithContext(f.cfg, changedFiles, diff, formatter.PromptContext{
+		UserPrompt: f.opts.UserPrompt,
+		Issue:      f.issueContext(),
+		TypeHint:   typeHint,
+		ScopeHint:  f.scopeHint(changedFiles),
+		Type:       f.opts.Type,
+		Scope:      f.opts.Scope,
+		Squashed:   f.opts.Squashed,
+		Ticket:     f.ticket,
+		Warnings:   f.opts.ErrWriter,
+		Summarized: f.summarized,
+		// preparePromptDiff outlined it.
+		Outlined: true,
+	})
+
+	if len(f.opts.Models) > 0 {
+		f.promptHash = notes.HashPrompt(prompt)
+		return f.compareModels(prompt, typeHint)
+	}
+	formattedMessage, err := f.requestMessage(prompt, typeHint)
+	if err != nil {
+		return "", err
+	}
+	if formatter.CheckCommitType(f.cfg, formattedMessage) != nil {
+		rejected := formatter.CommitTypeOf(formattedMessage)
+		fmt.Fprintf(f.opts.ErrWriter, "Type %q is not in commit_types, asking again...\n", rejected)
+		prompt = formatter.CommitTypeRetryPrompt(f.cfg, prompt, rejected)
+		formattedMessage, err = f.requestMessage(prompt, typeHint)
+		if err != nil {
+			return "", err
+		}
+		if err := formatter.CheckCommitType(f.cfg, formattedMessage); err != nil {
+			return "", err
+		}
+	}
+
+	f.promptHash = notes.HashPrompt(prompt)
+	return formattedMessage, nil
+}
+
+// checkRisk reports the risk_policies the commit triggers and asks for the typed
+// confirmation they require. --acknowledge-risk confirms up front, and a dry run needs
+// no confirmation because it commits nothing.
+func (f *CommitFlow) checkRisk(diff string, files []string) error {
+	findings := risk.Evaluate(f.cfg.RiskPolicies, files, risk.ChangedLines(diff))
+	if len(findings) == 0 {
+		return nil
+	}
+	f.result.Risks = findings
+
+	fmt.Fprintln(f.opts.ErrWriter, "Risky commit:")
+	for _, finding := range findings {
+		fmt.Fprintf(f.opts.ErrWriter, "  - %s\n", finding)
+	}
+
+	phrase := risk.Phrase(findings)
+	switch {
+	case f.opts.AcknowledgeRisk:
+		fmt.Fprintln(f.opts.ErrWriter, "Risk acknowledged (--acknowledge-risk)")
+	case f.opts.DryRun:
+		return nil
+	case f.opts.AutoYes:
+		return fmt.Errorf("%w: pass --acknowledge-risk to commit with --yes", ErrRiskNotAcknowledged)
+	default:
+		confirmed, err := f.prompter.ConfirmRisk(phrase)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("%w: type %q or pass --acknowledge-risk", ErrRiskNotAcknowledged, phrase)
+		}
+	}
+	f.result.RiskAcknowledged = true
+	return nil
+}
+
+func (f *CommitFlow) printResult() {
+	data, err := json.MarshalIndent(f.result, "", "  ")
+	if err != nil {
+		fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to encode the result: %v\n", err)
+		return
+	}
+	fmt.Fprintln(f.opts.OutWriter, string(data))
+}
+
+// summarizeDiff replaces a large diff with per-file summaries: the summarizer's when one
+// is set, else, with a summarize_threshold, local ones from hunk headers and line counts.
+// It returns diff unchanged, to be truncated as usual, when neither applies.
+func (f *CommitFlow) summarizeDiff(diff string) (string, bool) {
+	local := f.summarizeThreshold() > 0
+	if f.summarizer != nil {
+		sp := ui.NewSpinner("Summarizing large diff...")
+		sp.Start()
+		result, err := summarize.Diff(context.Background(), f.summarizer, diff, summarize.Options{
+			Model:       f.cfg.Model,
+			Parallelism: f.cfg.SummarizeParallelism,
+			Timeout:     time.Duration(f.cfg.SummarizeTimeout) * time.Second,
+		})
+		sp.Stop()
+		if err == nil {
+			fmt.Fprintf(f.opts.ErrWriter, "Summarized %d files in %d requests in %.1fs (%s)\n",
+				result.Files, result.Requests, result.Elapsed.Seconds(), result.Usage)
+			return result.Summary, true
+		}
+		if !local {
+			fmt.Fprintf(f.opts.ErrWriter, "Warning: %v; using the truncated diff\n", err)
+			return diff, false
+		}
+		fmt.Fprintf(f.opts.ErrWriter, "Warning: %v; summarizing files locally\n", err)
+	}
+	if !local {
+		return diff, false
+	}
+
+	result, err := summarize.Local(diff)
+	if err != nil {
+		return diff, false
+	}
+	fmt.Fprintf(f.opts.ErrWriter, "Summarized %d files locally\n", result.Files)
+	return result.Summary, true
+}
+
+// summarizeThreshold is the diff size above which summarizeDiff runs, 0 for the
+// prompt limit.
+func (f *CommitFlow) summarizeThreshold() int {
+	if f.cfg == nil {
+		return 0
+	}
+	return f.cfg.SummarizeThreshold
+}
+
+// recordGeneration attaches the model, prompt hash, and candidates to the new commit as a
+// git note. The commit already succeeded, so failures only warn.
+func (f *CommitFlow) recordGeneration(edited bool) {
+	if f.opts.DryRun || f.cfg == nil || !f.cfg.GenerationNotes {
+		return
+	}
+
+	model := f.cfg.Model
+	if f.historyModel != "" {
+		model = f.historyModel
+	}
+	content, err := notes.Generation{
+		Model:      model,
+		PromptHash: f.promptHash,
+		Candidates: f.candidates,
+		Edited:     edited,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}.Encode()
+	if err == nil {
+		err = f.git.AddNote(notes.Ref, "HEAD", content)
+	}
+	if err != nil {
+		fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to record generation metadata: %v\n", err)
+	}
+}
+
+// recordHistory records the candidates of one commit loop: the regenerated ones, and
+// the last one with the outcome of the commit. The history is a convenience, so
+// failures only warn.
+func (f *CommitFlow) recordHistory(diff string, files []string, finalMessage string, err error) {
+	if f.history == nil || len(f.candidates) == 0 {
+		return
+	}
+
+	branch, _ := f.git.CurrentBranch()
+	base := history.Entry{
+		Time:     time.Now().UTC().Truncate(time.Second),
+		Branch:   branch,
+		DiffHash: history.HashDiff(diff),
+		Files:    files,
+	}
+	if f.historyModel != "" {
+		base.Model = f.historyModel
+	} else if f.cfg != nil {
+		base.Model = f.cfg.Model
+	}
+
+	entries := make([]history.Entry, len(f.candidates))
+	for i, candidate := range f.candidates {
+		entries[i] = base
+		entries[i].Message = candidate
+		entries[i].Status = history.StatusRegenerated
+	}
+	last := &entries[len(entries)-1]
+	switch {
+	case finalMessage != "" && err == nil && f.opts.DryRun:
+		last.Message, last.Status = finalMessage, history.StatusDryRun
+	case finalMessage != "" && err == nil:
+		last.Message, last.Status = finalMessage, history.StatusCommitted
+	case finalMessage != "":
+		last.Message, last.Status = finalMessage, history.StatusFailed
+	default:
+		last.Status = history.StatusCancelled
+	}
+
+	if recordErr := f.history.Record(entries...); recordErr != nil {
+		fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to record message history: %v\n", recordErr)
+	}
+}
+
+// requestMessage sends prompt to the LLM and returns the formatted reply.
+func (f *CommitFlow) requestMessage(prompt string, typeHint string) (string, error) {
 	sp := ui.NewSpinner("Generating commit message...")
 	sp.Start()
 	message, err := f.llm.GenerateCommitMessage(prompt, f.cfg.Model)
 	sp.Stop()
 
 	if err != nil {
-		return "", fmt.Errorf("failed to generate commit message: %w", err)
+		salvaged, ok := f.salvagePartialMessage(err)
+		if !ok {
+			return "", fmt.Errorf("failed to generate commit message: %w", err)
+		}
+		message = salvaged
 	}
 
 	formattedMessage := formatter.FormatCommitMessageWithConfig(f.cfg, message)
-	formattedMessage = f.applyIssueSuffix(formattedMessage)
+	return f.constrain(formatter.EnforceTypeHint(f.cfg, formattedMessage, typeHint)), nil
+}
 
-	fmt.Fprintln(f.opts.ErrWriter, "\nGenerated Commit Message:")
-	fmt.Fprintln(f.opts.OutWriter, formattedMessage)
-	return formattedMessage, nil
+// constrain forces the Type and Scope options onto message, with a warning when the
+// reply ignored them.
+func (f *CommitFlow) constrain(message string) string {
+	constrained := formatter.ConstrainMessage(message, f.opts.Type, f.opts.Scope)
+	if constrained != message {
+		subject, _ := formatter.SplitCommitMessage(message)
+		fmt.Fprintf(f.opts.ErrWriter, "Warning: the reply %q ignored the required type or scope; corrected it\n",
+			subject)
+	}
+	return constrained
+}
+
+// confirmBreaking asks once per commit whether detected breaking changes should be marked,
+// so regenerated messages keep the decision.
+func (f *CommitFlow) confirmBreaking(diff string) error {
+	detected := analyzer.DetectBreakingChanges(diff)
+	if len(detected) == 0 {
+		return nil
+	}
+
+	changes := make([]string, len(detected))
+	for i, change := range detected {
+		changes[i] = change.String()
+	}
+	confirmed, err := f.prompter.ConfirmBreaking(changes, f.opts.AutoYes)
+	if err != nil {
+		return err
+	}
+	if confirmed {
+		f.breaking = strings.Join(changes, "; ")
+	}
+	return nil
+}
+
+// salvagePartialMessage keeps the subject line of a response cut off mid-stream when it
+// is already a valid Conventional Commit. The user still confirms or regenerates it, so
+// --yes discards partial responses instead of committing them unreviewed.
+func (f *CommitFlow) salvagePartialMessage(err error) (string, bool) {
+	var partial *llm.PartialResponseError
+	if !errors.As(err, &partial) || f.opts.AutoYes {
+		return "", false
+	}
+
+	subject := formatter.SalvageSubject(partial.Content)
+	if subject == "" {
+		return "", false
+	}
+
+	fmt.Fprintf(f.opts.ErrWriter, "Warning: %v\n", partial.Err)
+	fmt.Fprintln(f.opts.ErrWriter, "Warning: using the partial subject line received before the response stalled; "+
+		"review it or choose regenerate.")
+	return subject, true
+}
+
+// startIssuePrefetch starts the issue lookup in the background so it overlaps with
+// staging, diff collection and prompt building instead of adding latency.
+func (f *CommitFlow) startIssuePrefetch() {
+	if f.prefetch != nil || f.issues == nil || f.issueKey() == "" {
+		return
+	}
+
+	p := &issuePrefetch{done: make(chan struct{}), deadline: time.Now().Add(f.issueTimeout)}
+	f.prefetch = p
+	go func() {
+		defer close(p.done)
+		ctx, cancel := context.WithDeadline(context.Background(), p.deadline)
+		defer cancel()
+		p.issue, p.err = f.issues.FetchIssue(ctx, f.issueKey())
+	}()
+}
+
+// issueContext waits for the prefetched issue metadata until the prefetch deadline.
+// Lookup failures and timeouts only warn; generation proceeds without the issue.
+func (f *CommitFlow) issueContext() *formatter.IssueContext {
+	if f.issueLoaded {
+		return f.issue
+	}
+	f.issueLoaded = true
+
+	f.startIssuePrefetch()
+	p := f.prefetch
+	if p == nil {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Until(p.deadline))
+	defer timer.Stop()
+	select {
+	case <-p.done:
+	case <-timer.C:
+		f.warnIssueTimeout()
+		return nil
+	}
+
+	if p.err != nil {
+		if errors.Is(p.err, context.DeadlineExceeded) {
+			f.warnIssueTimeout()
+		} else {
+			fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to fetch issue %s: %v\n", f.issueLabel(), p.err)
+		}
+		return nil
+	}
+
+	f.issue = &formatter.IssueContext{
+		Number:      p.issue.Number,
+		Title:       p.issue.Title,
+		Labels:      p.issue.Labels,
+		Description: p.issue.Description,
+	}
+	return f.issue
+}
+
+func (f *CommitFlow) warnIssueTimeout() {
+	fmt.Fprintf(f.opts.ErrWriter, "Warning: issue %s lookup timed out after %s; generating without issue context\n",
+		f.issueLabel(), f.issueTimeout)
+}
+
+// issueKey is the issue the commit is for: --issue, or the branch's ticket.
+func (f *CommitFlow) issueKey() string {
+	if f.opts.IssueNum != "" {
+		return f.opts.IssueNum
+	}
+	return f.ticket
+}
+
+// issueLabel is issueKey as messages name it: #42 for --issue, PROJ-12 for a ticket.
+func (f *CommitFlow) issueLabel() string {
+	if f.opts.IssueNum != "" {
+		return "#" + f.opts.IssueNum
+	}
+	return f.ticket
+}
+
+// updateIssue comments message on the issue and moves it through IssueTransition, as
+// the options ask. Failures only warn: the commit is already made.
+func (f *CommitFlow) updateIssue(message string) {
+	if !f.opts.IssueComment && f.opts.IssueTransition == "" {
+		return
+	}
+	key := f.issueKey()
+	if f.updater == nil || key == "" {
+		if !f.noTicketWarned {
+			f.noTicketWarned = true
+			fmt.Fprintln(f.opts.ErrWriter,
+				"Warning: no Jira ticket in the branch name, so the issue was not commented on or moved")
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.issueTimeout)
+	defer cancel()
+	if f.opts.IssueComment {
+		if err := f.updater.AddComment(ctx, key, message); err != nil {
+			fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to comment on %s: %v\n", key, err)
+		} else {
+			fmt.Fprintf(f.opts.ErrWriter, "Commented on %s\n", key)
+		}
+	}
+	if f.opts.IssueTransition != "" && !f.transitioned {
+		f.transitioned = true
+		if err := f.updater.Transition(ctx, key, f.opts.IssueTransition); err != nil {
+			fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to move %s to %s: %v\n", key, f.opts.IssueTransition, err)
+		} else {
+			fmt.Fprintf(f.opts.ErrWriter, "Moved %s to %s\n", key, f.opts.IssueTransition)
+		}
+	}
 }
 
 func (f *CommitFlow) applyIssueSuffix(message string) string {
@@ -260,11 +1051,72 @@ func (f *CommitFlow) applyIssueSuffix(message string) string {
 	}
 
 	issueTag := fmt.Sprintf("(#%s)", f.opts.IssueNum)
-	if strings.Contains(message, issueTag) {
+	subject, body := formatter.SplitCommitMessage(message)
+	if strings.Contains(subject, issueTag) {
 		return message
 	}
 
-	return fmt.Sprintf("%s %s", message, issueTag)
+	return formatter.JoinCommitMessage(fmt.Sprintf("%s %s", subject, issueTag), body)
+}
+
+// detectTicket finds the ticket ID of the branch with issue_pattern, unless --issue
+// names the issue.
+func (f *CommitFlow) detectTicket() error {
+	if f.opts.IssueNum != "" || f.cfg == nil || f.cfg.IssuePattern == "" {
+		return nil
+	}
+	name, err := f.git.CurrentBranch()
+	if err != nil {
+		fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to read the branch name for issue_pattern: %v\n", err)
+		return nil
+	}
+	ticket, err := branch.ExtractTicket(name, f.cfg.IssuePattern)
+	if err != nil {
+		return err
+	}
+	if ticket != "" {
+		f.ticket = ticket
+		fmt.Fprintf(f.opts.ErrWriter, "Ticket: %s (from branch %s)\n", ticket, name)
+	}
+	return nil
+}
+
+// applyTicket appends the branch's ticket ID to the subject as issue_format renders
+// it. A trailer issue_format is added by applyTrailers instead.
+func (f *CommitFlow) applyTicket(message string) string {
+	if f.ticket == "" || branch.IsTrailerFormat(f.cfg.IssueFormat) {
+		return message
+	}
+
+	subject, body := formatter.SplitCommitMessage(message)
+	if strings.Contains(subject, f.ticket) {
+		return message
+	}
+	return formatter.JoinCommitMessage(subject+" "+branch.FormatTicket(f.cfg.IssueFormat, f.ticket), body)
+}
+
+// applyTrailers adds the trailers config, then --trailer, then a trailer issue_format
+// for the branch's ticket, then the Generated-by trailer of attribution, to message.
+func (f *CommitFlow) applyTrailers(message string) (string, error) {
+	var trailers []string
+	if f.cfg != nil {
+		for _, trailer := range f.cfg.Trailers {
+			trailers = append(trailers, trailer.String())
+		}
+	}
+	for _, trailer := range f.opts.Trailers {
+		trailers = append(trailers, trailer.String())
+	}
+	if f.ticket != "" && branch.IsTrailerFormat(f.cfg.IssueFormat) {
+		trailers = append(trailers, branch.FormatTicket(f.cfg.IssueFormat, f.ticket))
+	}
+	if trailer := f.attributionTrailer(message); trailer != "" {
+		trailers = append(trailers, trailer)
+	}
+	if len(trailers) == 0 {
+		return message, nil
+	}
+	return f.git.InterpretTrailers(message, trailers)
 }
 
 func (f *CommitFlow) buildCommitArgs() []string {
@@ -275,6 +1127,17 @@ func (f *CommitFlow) buildCommitArgs() []string {
 	if !f.opts.NoSignoff {
 		args = append(args, "-s")
 	}
+	if f.opts.SignKey != "" {
+		args = append(args, "--gpg-sign="+f.opts.SignKey)
+	} else if f.signCommit() {
+		args = append(args, "-S")
+	}
+	if f.opts.Author != "" {
+		args = append(args, "--author="+f.opts.Author)
+	}
+	if f.opts.Date != "" {
+		args = append(args, "--date="+f.opts.Date)
+	}
 	return args
 }
 
@@ -284,7 +1147,8 @@ func (f *CommitFlow) performCommit(message string) error {
 		return nil
 	}
 
-	if err := f.git.Commit(message, f.buildCommitArgs()...); err != nil {
+	commit := func() error { return f.git.Commit(message, f.buildCommitArgs()...) }
+	if err := f.commitWithAutofixRetry(commit); err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
@@ -299,24 +1163,11 @@ func (f *CommitFlow) performSelectiveCommit(message string, files []string) erro
 		return nil
 	}
 
-	if err := f.git.CommitFiles(message, files, f.buildCommitArgs()...); err != nil {
+	commit := func() error { return f.git.CommitFiles(message, files, f.buildCommitArgs()...) }
+	if err := f.commitWithAutofixRetry(commit); err != nil {
 		return fmt.Errorf("failed to commit files: %w", err)
 	}
 
 	fmt.Fprintf(f.opts.ErrWriter, "Successfully committed files: %v!\n", files)
 	return nil
 }
-
-func ExtractFilesFromDiff(diff string) []string {
-	var files []string
-
-	for _, line := range strings.Split(diff, "\n") {
-		if after, found := strings.CutPrefix(line, "+++ b/"); found {
-			files = append(files, after)
-		} else if after, found := strings.CutPrefix(line, "--- a/"); found {
-			files = append(files, after)
-		}
-	}
-
-	return stringsutil.UniqueStrings(files)
-}
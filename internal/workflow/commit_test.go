@@ -0,0 +1,710 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/forge"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/hunks"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSalvagePartialMessage(t *testing.T) {
+	partialErr := &llm.PartialResponseError{
+		Content: "feat(parser): add streaming\n\nThe body was cut",
+		Err:     errors.New("failed to call LLM: context deadline exceeded"),
+	}
+
+	var errOut bytes.Buffer
+	flow := &CommitFlow{opts: CommitOptions{ErrWriter: &errOut}}
+	message, ok := flow.salvagePartialMessage(partialErr)
+	assert.True(t, ok)
+	assert.Equal(t, "feat(parser): add streaming", message)
+	assert.Contains(t, errOut.String(), "partial subject line")
+
+	flow.opts.AutoYes = true
+	_, ok = flow.salvagePartialMessage(partialErr)
+	assert.False(t, ok, "--yes must not commit a partial response unreviewed")
+
+	flow.opts.AutoYes = false
+	_, ok = flow.salvagePartialMessage(&llm.PartialResponseError{Content: "feat(pa", Err: partialErr.Err})
+	assert.False(t, ok)
+
+	_, ok = flow.salvagePartialMessage(errors.New("failed to call LLM: 401"))
+	assert.False(t, ok)
+}
+
+func TestApplyIssueSuffixWithBody(t *testing.T) {
+	flow := &CommitFlow{opts: CommitOptions{IssueNum: "42"}}
+
+	assert.Equal(t, "feat: x (#42)", flow.applyIssueSuffix("feat: x"))
+	assert.Equal(t, "feat: x (#42)\n\n- why", flow.applyIssueSuffix("feat: x\n\n- why"))
+	assert.Equal(t, "feat: x (#42)\n\n- why", flow.applyIssueSuffix("feat: x (#42)\n\n- why"))
+}
+
+type trailerRecorder struct {
+	GitClient
+	trailers []string
+}
+
+func (r *trailerRecorder) InterpretTrailers(message string, trailers []string) (string, error) {
+	r.trailers = trailers
+	return message + "\n\n" + strings.Join(trailers, "\n"), nil
+}
+
+func TestApplyTrailers(t *testing.T) {
+	gitClient := &trailerRecorder{}
+	flow := &CommitFlow{git: gitClient, cfg: &config.Config{}}
+
+	message, err := flow.applyTrailers("feat: x")
+	assert.NoError(t, err)
+	assert.Equal(t, "feat: x", message)
+	assert.Nil(t, gitClient.trailers, "git is not run without trailers")
+
+	flow.cfg.Trailers = []config.Trailer{{Key: "Refs", Value: "PROJ-1"}}
+	flow.opts.Trailers = []config.Trailer{{Key: "Co-authored-by", Value: "Jane Doe <jane@example.com>"}}
+	message, err = flow.applyTrailers("feat: x")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Refs: PROJ-1", "Co-authored-by: Jane Doe <jane@example.com>"}, gitClient.trailers)
+	assert.Equal(t, "feat: x\n\nRefs: PROJ-1\nCo-authored-by: Jane Doe <jane@example.com>", message)
+}
+
+type branchStub struct {
+	trailerRecorder
+	branch string
+}
+
+func (b *branchStub) CurrentBranch() (string, error) {
+	return b.branch, nil
+}
+
+func TestTicketFromBranch(t *testing.T) {
+	var errOut bytes.Buffer
+	gitClient := &branchStub{branch: "PROJ-1234-fix-login"}
+	cfg := &config.Config{IssuePattern: `[A-Z][A-Z0-9]+-[0-9]+`}
+	flow := &CommitFlow{git: gitClient, cfg: cfg, opts: CommitOptions{ErrWriter: &errOut}}
+
+	assert.NoError(t, flow.detectTicket())
+	assert.Equal(t, "PROJ-1234", flow.ticket)
+	assert.Equal(t, "Ticket: PROJ-1234 (from branch PROJ-1234-fix-login)\n", errOut.String())
+	assert.Equal(t, "fix: handle login timeout [PROJ-1234]\n\n- why",
+		flow.applyTicket("fix: handle login timeout\n\n- why"))
+	assert.Equal(t, "fix: PROJ-1234 login", flow.applyTicket("fix: PROJ-1234 login"), "not added twice")
+
+	cfg.IssueFormat = "Refs: {id}"
+	assert.Equal(t, "fix: x", flow.applyTicket("fix: x"))
+	message, err := flow.applyTrailers("fix: x")
+	assert.NoError(t, err)
+	assert.Equal(t, "fix: x\n\nRefs: PROJ-1234", message)
+
+	flow = &CommitFlow{git: gitClient, cfg: cfg, opts: CommitOptions{IssueNum: "7", ErrWriter: &errOut}}
+	assert.NoError(t, flow.detectTicket())
+	assert.Empty(t, flow.ticket, "--issue takes precedence")
+
+	gitClient.branch = "main"
+	flow = &CommitFlow{git: gitClient, cfg: cfg, opts: CommitOptions{ErrWriter: &errOut}}
+	assert.NoError(t, flow.detectTicket())
+	assert.Empty(t, flow.ticket)
+}
+
+type stubIssueFetcher struct {
+	delay time.Duration
+	calls int
+}
+
+func (s *stubIssueFetcher) FetchIssue(ctx context.Context, number string) (*forge.Issue, error) {
+	s.calls++
+	select {
+	case <-time.After(s.delay):
+		return &forge.Issue{Number: number, Title: "Crash on empty diff", Labels: []string{"bug"}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestIssueContextUsesPrefetch(t *testing.T) {
+	fetcher := &stubIssueFetcher{delay: 10 * time.Millisecond}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{
+		opts:         CommitOptions{IssueNum: "7", ErrWriter: &errOut},
+		issues:       fetcher,
+		issueTimeout: time.Second,
+	}
+
+	flow.startIssuePrefetch()
+	issue := flow.issueContext()
+	if assert.NotNil(t, issue) {
+		assert.Equal(t, "Crash on empty diff", issue.Title)
+		assert.Equal(t, []string{"bug"}, issue.Labels)
+	}
+	assert.Same(t, issue, flow.issueContext())
+	assert.Equal(t, 1, fetcher.calls)
+	assert.Empty(t, errOut.String())
+}
+
+func TestIssueContextTimeout(t *testing.T) {
+	var errOut bytes.Buffer
+	flow := &CommitFlow{
+		opts:         CommitOptions{IssueNum: "7", ErrWriter: &errOut},
+		issues:       &stubIssueFetcher{delay: time.Second},
+		issueTimeout: 20 * time.Millisecond,
+	}
+
+	assert.Nil(t, flow.issueContext())
+	assert.Contains(t, errOut.String(), "issue #7 lookup timed out after 20ms; generating without issue context")
+}
+
+type stubPrompter struct {
+	confirmBreaking bool
+	breakingChanges []string
+	confirmRisk     bool
+	riskPhrase      string
+}
+
+func (s *stubPrompter) GetConfirmation(string, bool) (Action, string, error) {
+	return ActionCommit, "", nil
+}
+
+func (s *stubPrompter) ConfirmGenerate(bool) (bool, error) {
+	return true, nil
+}
+
+func (s *stubPrompter) PickCandidate(_ []Candidate, def int) (int, error) {
+	return def, nil
+}
+
+func (s *stubPrompter) ConfirmBreaking(changes []string, _ bool) (bool, error) {
+	s.breakingChanges = changes
+	return s.confirmBreaking, nil
+}
+
+func (s *stubPrompter) ConfirmRisk(phrase string) (bool, error) {
+	s.riskPhrase = phrase
+	return s.confirmRisk, nil
+}
+
+func TestConfirmBreaking(t *testing.T) {
+	diff := "diff --git a/api/client.go b/api/client.go\n@@ -1,2 +1,1 @@\n-func NewClient() *Client {\n"
+
+	prompter := &stubPrompter{confirmBreaking: true}
+	flow := &CommitFlow{prompter: prompter}
+	assert.NoError(t, flow.confirmBreaking(diff))
+	assert.Equal(t, []string{"removed exported func NewClient (api/client.go)"}, prompter.breakingChanges)
+	assert.Equal(t, "removed exported func NewClient (api/client.go)", flow.breaking)
+
+	prompter = &stubPrompter{}
+	flow = &CommitFlow{prompter: prompter}
+	assert.NoError(t, flow.confirmBreaking(diff))
+	assert.Empty(t, flow.breaking)
+
+	assert.NoError(t, flow.confirmBreaking("diff --git a/x.go b/x.go\n@@ -1 +1 @@\n+func New() {\n"))
+	assert.Len(t, prompter.breakingChanges, 1, "no prompt without detected changes")
+}
+
+func TestRunCommitLoopStrictContext(t *testing.T) {
+	diff := "diff --git a/big.go b/big.go\n+" + strings.Repeat("x", 5000)
+	committed := false
+	commitFn := func(string) error {
+		committed = true
+		return nil
+	}
+
+	flow := &CommitFlow{prompter: &stubPrompter{}, opts: CommitOptions{StrictContext: true}}
+	err := flow.runCommitLoop(diff, []string{"big.go"}, commitFn)
+	assert.ErrorIs(t, err, formatter.ErrContextTruncated)
+	assert.False(t, committed)
+}
+
+type stubSummarizer struct {
+	err error
+}
+
+func (s *stubSummarizer) SummarizeDiff(context.Context, string, string) (string, llm.Usage, error) {
+	return "big.go: rewrote the client", llm.Usage{Model: "gpt-4o-mini", PromptTokens: 1200, CompletionTokens: 8}, s.err
+}
+
+func TestRunCommitLoopSummarizesLargeDiff(t *testing.T) {
+	diff := "diff --git a/big.go b/big.go\n@@ -0,0 +1 @@\n+" + strings.Repeat("x", 5000) + "\n"
+	llmClient := &stubLLM{replies: []string{"feat: rewrite client"}}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{
+		llm:        llmClient,
+		cfg:        &config.Config{Model: "gpt-4o-mini"},
+		prompter:   &stubPrompter{},
+		summarizer: &stubSummarizer{},
+		opts:       CommitOptions{StrictContext: true, ErrWriter: &errOut, OutWriter: &out},
+	}
+
+	var committed string
+	err := flow.runCommitLoop(diff, []string{"big.go"}, func(message string) error {
+		committed = message
+		return nil
+	})
+	assert.NoError(t, err, "a summarized diff satisfies --strict-context")
+	assert.Equal(t, "feat: rewrite client", committed)
+	assert.Contains(t, llmClient.prompts[0], "big.go: rewrote the client")
+	assert.NotContains(t, llmClient.prompts[0], "xxxx")
+	assert.Contains(t, errOut.String(), "Summarized 1 files in 1 requests")
+	assert.Contains(t, errOut.String(), "prompt 1200 tok, completion 8 tok")
+
+	errOut.Reset()
+	flow.summarizer = &stubSummarizer{err: errors.New("timeout")}
+	summary, ok := flow.summarizeDiff(diff)
+	assert.False(t, ok)
+	assert.Equal(t, diff, summary)
+	assert.Contains(t, errOut.String(), "Warning: failed to summarize big.go: timeout; using the truncated diff")
+}
+
+func TestRunCommitLoopSummarizesLocallyAboveThreshold(t *testing.T) {
+	diff := "diff --git a/client.go b/client.go\n@@ -1,2 +1,3 @@ func Dial() error {\n+" +
+		strings.Repeat("x", 500) + "\n"
+	llmClient := &stubLLM{replies: []string{"feat: retry dial"}}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{
+		llm:      llmClient,
+		cfg:      &config.Config{Model: "gpt-4o-mini", SummarizeThreshold: 200},
+		prompter: &stubPrompter{},
+		opts:     CommitOptions{ErrWriter: &errOut, OutWriter: &bytes.Buffer{}},
+	}
+
+	err := flow.runCommitLoop(diff, []string{"client.go"}, func(string) error { return nil })
+	assert.NoError(t, err)
+	assert.Contains(t, llmClient.prompts[0], "client.go (+1/-0): func Dial() error")
+	assert.NotContains(t, llmClient.prompts[0], "xxxx")
+	assert.Contains(t, errOut.String(), "Summarized 1 files locally")
+
+	errOut.Reset()
+	flow.summarizer = &stubSummarizer{err: errors.New("timeout")}
+	summary, ok := flow.summarizeDiff(diff)
+	assert.True(t, ok)
+	assert.Contains(t, summary, "client.go (+1/-0): func Dial() error")
+	assert.Contains(t, errOut.String(), "Warning: failed to summarize client.go: timeout; summarizing files locally")
+}
+
+func TestRunCommitLoopOutlinesNewFiles(t *testing.T) {
+	var diff strings.Builder
+	diff.WriteString("diff --git a/big.go b/big.go\nnew file mode 100644\n--- /dev/null\n+++ b/big.go\n" +
+		"@@ -0,0 +1,400 @@\n+package big\n+func Run() error {\n")
+	for range 398 {
+		diff.WriteString("+\tstep()\n")
+	}
+	llmClient := &stubLLM{replies: []string{"feat: add big runner"}}
+	flow := &CommitFlow{
+		llm:      llmClient,
+		cfg:      &config.Config{OutlineNewFiles: true},
+		prompter: &stubPrompter{},
+		opts:     CommitOptions{StrictContext: true, ErrWriter: &bytes.Buffer{}, OutWriter: &bytes.Buffer{}},
+	}
+
+	err := flow.runCommitLoop(diff.String(), []string{"big.go"}, func(string) error { return nil })
+	assert.NoError(t, err, "the outline fits the prompt, so --strict-context passes")
+	assert.Contains(t, llmClient.prompts[0], "+func Run() error\n")
+	assert.NotContains(t, llmClient.prompts[0], "step()")
+}
+
+type stubIssueUpdater struct {
+	calls         []string
+	transitionErr error
+}
+
+func (s *stubIssueUpdater) AddComment(_ context.Context, key, body string) error {
+	s.calls = append(s.calls, "comment "+key+": "+body)
+	return nil
+}
+
+func (s *stubIssueUpdater) Transition(_ context.Context, key, name string) error {
+	s.calls = append(s.calls, "transition "+key+": "+name)
+	return s.transitionErr
+}
+
+func TestRunCommitLoopUpdatesIssue(t *testing.T) {
+	updater := &stubIssueUpdater{}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{
+		llm:      &stubLLM{replies: []string{"fix: retry login", "fix: retry login"}},
+		cfg:      &config.Config{},
+		prompter: &stubPrompter{},
+		updater:  updater,
+		ticket:   "PROJ-7",
+		opts: CommitOptions{IssueComment: true, IssueTransition: "In Review",
+			ErrWriter: &errOut, OutWriter: &bytes.Buffer{}},
+		issueTimeout: time.Second,
+	}
+	diff := "diff --git a/a.go b/a.go\n"
+	commit := func(string) error { return nil }
+
+	err := flow.runCommitLoop(diff, []string{"a.go"}, commit)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"comment PROJ-7: fix: retry login [PROJ-7]",
+		"transition PROJ-7: In Review",
+	}, updater.calls)
+	assert.Contains(t, errOut.String(), "Commented on PROJ-7\nMoved PROJ-7 to In Review\n")
+
+	updater.calls = nil
+	updater.transitionErr = errors.New("no transition")
+	flow.transitioned = false
+	flow.opts.IssueComment = false
+	assert.NoError(t, flow.runCommitLoop(diff, []string{"a.go"}, commit),
+		"a failed transition does not fail the commit")
+	assert.Contains(t, errOut.String(), "Warning: failed to move PROJ-7 to In Review: no transition")
+
+	updater.calls = nil
+	flow.opts.DryRun = true
+	flow.llm = &stubLLM{replies: []string{"fix: retry login"}}
+	assert.NoError(t, flow.runCommitLoop(diff, []string{"a.go"}, commit))
+	assert.Empty(t, updater.calls, "a dry run leaves the issue alone")
+
+	errOut.Reset()
+	flow.opts.DryRun = false
+	flow.ticket = ""
+	flow.llm = &stubLLM{replies: []string{"fix: retry login"}}
+	assert.NoError(t, flow.runCommitLoop(diff, []string{"a.go"}, commit))
+	assert.Empty(t, updater.calls)
+	assert.Contains(t, errOut.String(), "Warning: no Jira ticket in the branch name")
+}
+
+type stubLLM struct {
+	replies []string
+	prompts []string
+}
+
+func (s *stubLLM) GenerateCommitMessage(prompt string, _ string) (string, error) {
+	s.prompts = append(s.prompts, prompt)
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply, nil
+}
+
+func TestGenerateCommitMessageRequeriesDisallowedType(t *testing.T) {
+	cfg := &config.Config{CommitTypes: []string{"feat", "fix", "infra"}}
+	llmClient := &stubLLM{replies: []string{"chore: bump terraform provider", "infra: bump terraform provider"}}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{llm: llmClient, cfg: cfg, opts: CommitOptions{ErrWriter: &errOut, OutWriter: &out}}
+
+	message, err := flow.generateCommitMessage([]string{"main.tf"}, "diff --git a/main.tf b/main.tf\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "infra: bump terraform provider", message)
+	assert.Contains(t, llmClient.prompts[0], "only allows these commit types: feat, fix, infra")
+	assert.Contains(t, llmClient.prompts[1], `The previous reply used "chore"`)
+	assert.Contains(t, errOut.String(), `Type "chore" is not in commit_types`)
+
+	llmClient = &stubLLM{replies: []string{"chore: bump", "build: bump"}}
+	flow.llm = llmClient
+	_, err = flow.generateCommitMessage([]string{"main.tf"}, "diff --git a/main.tf b/main.tf\n")
+	assert.ErrorIs(t, err, formatter.ErrCommitTypeNotAllowed)
+	assert.Len(t, llmClient.prompts, 2, "re-queries only once")
+}
+
+func TestGenerateCommitMessageConstrainsTypeAndScope(t *testing.T) {
+	cfg := &config.Config{TypeHints: config.TypeHintsStrict}
+	llmClient := &stubLLM{replies: []string{"feat(api): handle expired tokens"}}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{llm: llmClient, cfg: cfg, opts: CommitOptions{
+		Type: "fix", Scope: "auth", ErrWriter: &errOut, OutWriter: &out,
+	}}
+
+	message, err := flow.generateCommitMessage([]string{"docs/auth.md"}, "diff --git a/docs/auth.md b/docs/auth.md\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "fix(auth): handle expired tokens", message, "--type wins over the strict docs hint")
+	assert.Contains(t, llmClient.prompts[0], "Required Type:")
+	assert.Contains(t, llmClient.prompts[0], "Required Scope:")
+	assert.Contains(t, errOut.String(), "ignored the required type or scope")
+
+	flow.opts.Offline = true
+	errOut.Reset()
+	message, err = flow.generateCommitMessage([]string{"docs/auth.md"}, "diff --git a/docs/auth.md b/docs/auth.md\n")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(message, "fix(auth): "), message)
+	assert.NotContains(t, errOut.String(), "Warning")
+}
+
+type unreachableLLM struct{}
+
+func (unreachableLLM) GenerateCommitMessage(string, string) (string, error) {
+	refused := &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}
+	dial := &url.Error{Op: "Post", URL: "https://api.example.com", Err: refused}
+	return "", fmt.Errorf("failed to call LLM: %w (%w)", dial, llm.ErrLLM)
+}
+
+func TestGenerateCommitMessageOffline(t *testing.T) {
+	diff := "diff --git a/cmd/root.go b/cmd/root.go\n--- a/cmd/root.go\n+++ b/cmd/root.go\n" +
+		"@@ -5,2 +5,2 @@ func init() {\n-\ta := 1\n+\tb := 1\n"
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{
+		llm: unreachableLLM{}, cfg: &config.Config{}, opts: CommitOptions{ErrWriter: &errOut, OutWriter: &out},
+	}
+
+	message, err := flow.generateCommitMessage([]string{"cmd/root.go"}, diff)
+	assert.NoError(t, err)
+	assert.Equal(t, "refactor(cmd): update init in root.go", message)
+	assert.Contains(t, errOut.String(), "the LLM is unreachable, so the message was written offline")
+	assert.Equal(t, OfflineModel, flow.historyModel)
+
+	flow.llm = &stubLLM{replies: []string{"fix(cmd): rename the init variable"}}
+	message, err = flow.generateCommitMessage([]string{"cmd/root.go"}, diff)
+	assert.NoError(t, err)
+	assert.Equal(t, "fix(cmd): rename the init variable", message)
+	assert.Empty(t, flow.historyModel, "a regenerated message is recorded with the configured model")
+
+	flow.llm = unreachableLLM{}
+	flow.opts.AutoYes = true
+	_, err = flow.generateCommitMessage([]string{"cmd/root.go"}, diff)
+	assert.ErrorIs(t, err, llm.ErrLLM, "--yes does not commit an offline message unasked")
+
+	flow.opts.Offline = true
+	errOut.Reset()
+	message, err = flow.generateCommitMessage([]string{"cmd/root.go"}, diff)
+	assert.NoError(t, err)
+	assert.Equal(t, "refactor(cmd): update init in root.go", message)
+	assert.NotContains(t, errOut.String(), "Warning")
+}
+
+type noteRecorder struct {
+	GitClient
+	notes map[string]string
+}
+
+func (r *noteRecorder) AddNote(ref, object, content string) error {
+	r.notes[ref+" "+object] = content
+	return nil
+}
+
+func TestRecordGeneration(t *testing.T) {
+	cfg := &config.Config{Model: "gpt-4.1-mini", GenerationNotes: true}
+	gitClient := &noteRecorder{notes: map[string]string{}}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{git: gitClient, cfg: cfg, opts: CommitOptions{ErrWriter: &errOut}}
+	flow.promptHash = notes.HashPrompt("prompt")
+	flow.candidates = []string{"feat: first", "feat: second"}
+
+	flow.recordGeneration(true)
+	gen, err := notes.Decode(gitClient.notes["gmc HEAD"])
+	assert.NoError(t, err)
+	assert.Equal(t, "gpt-4.1-mini", gen.Model)
+	assert.Equal(t, flow.promptHash, gen.PromptHash)
+	assert.Equal(t, []string{"feat: first", "feat: second"}, gen.Candidates)
+	assert.True(t, gen.Edited)
+	assert.Empty(t, errOut.String())
+
+	gitClient.notes = map[string]string{}
+	cfg.GenerationNotes = false
+	flow.recordGeneration(false)
+	assert.Empty(t, gitClient.notes, "disabled by generation_notes")
+
+	cfg.GenerationNotes = true
+	flow.opts.DryRun = true
+	flow.recordGeneration(false)
+	assert.Empty(t, gitClient.notes, "nothing to annotate on a dry run")
+}
+
+type identResolver struct {
+	GitClient
+	ident git.Ident
+	err   error
+	calls int
+}
+
+func (r *identResolver) AuthorIdent(string, string) (git.Ident, error) {
+	r.calls++
+	return r.ident, r.err
+}
+
+func TestCheckAuthor(t *testing.T) {
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_AUTHOR_DATE"} {
+		t.Setenv(key, "")
+	}
+	resolver := &identResolver{ident: git.Ident{
+		Name: "Pair Partner", Email: "pair@example.com", Date: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{git: resolver, opts: CommitOptions{ErrWriter: &errOut}}
+
+	assert.NoError(t, flow.checkAuthor())
+	assert.Zero(t, resolver.calls, "nothing to resolve without overrides")
+
+	flow.opts.Author = "Pair Partner <pair@example.com>"
+	flow.opts.Date = "2024-01-02T03:04:05+0000"
+	assert.NoError(t, flow.checkAuthor())
+	assert.Contains(t, errOut.String(), "Author: Pair Partner <pair@example.com>, 2024-01-02 03:04:05 +0000")
+	assert.Equal(t,
+		[]string{"-s", "--author=Pair Partner <pair@example.com>", "--date=2024-01-02T03:04:05+0000"},
+		flow.buildCommitArgs())
+
+	resolver.err = errors.New("fatal: invalid date format: soon")
+	assert.ErrorContains(t, flow.checkAuthor(), "invalid author override")
+
+	flow.opts = CommitOptions{ErrWriter: &errOut}
+	t.Setenv("GIT_AUTHOR_EMAIL", "env@example.com")
+	_ = flow.checkAuthor()
+	assert.Equal(t, 3, resolver.calls, "GIT_AUTHOR_* is checked as well")
+}
+
+type signingReader struct {
+	GitClient
+	signing git.SigningConfig
+}
+
+func (r *signingReader) SigningConfig() git.SigningConfig {
+	return r.signing
+}
+
+func TestCheckSigning(t *testing.T) {
+	reader := &signingReader{signing: git.SigningConfig{Format: git.SigningFormatOpenPGP}}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{git: reader, cfg: &config.Config{}, opts: CommitOptions{NoSignoff: true, ErrWriter: &errOut}}
+
+	assert.NoError(t, flow.checkSigning())
+	assert.Empty(t, errOut.String(), "nothing to report without signing")
+	assert.Empty(t, flow.buildCommitArgs())
+
+	reader.signing.Enabled = true
+	assert.NoError(t, flow.checkSigning())
+	assert.Contains(t, errOut.String(), "Signing: openpgp, default key (commit.gpgsign)")
+	assert.Empty(t, flow.buildCommitArgs(), "git applies commit.gpgsign itself")
+
+	errOut.Reset()
+	reader.signing = git.SigningConfig{Format: git.SigningFormatOpenPGP, Key: "ABCD1234"}
+	flow.cfg.SignCommits = true
+	assert.NoError(t, flow.checkSigning())
+	assert.Contains(t, errOut.String(), "Signing: openpgp, ABCD1234 (sign_commits)")
+	assert.Equal(t, []string{"-S"}, flow.buildCommitArgs())
+
+	errOut.Reset()
+	flow.opts.SignKey = "~/.ssh/id_ed25519.pub"
+	reader.signing.Format = git.SigningFormatSSH
+	assert.NoError(t, flow.checkSigning())
+	assert.Contains(t, errOut.String(), "Signing: ssh, ~/.ssh/id_ed25519.pub (--gpg-sign)")
+	assert.Equal(t, []string{"--gpg-sign=~/.ssh/id_ed25519.pub"}, flow.buildCommitArgs())
+
+	flow.opts.SignKey = ""
+	reader.signing.Key = ""
+	assert.ErrorContains(t, flow.checkSigning(), "SSH commit signing needs a key")
+	reader.signing.SSHKeyCommand = "ssh-add -L"
+	assert.NoError(t, flow.checkSigning())
+}
+
+type hunkStager struct {
+	GitClient
+	staged, unstaged string
+	stage, unstage   string
+}
+
+func (s *hunkStager) GetHunkDiff(cached bool) (string, error) {
+	if cached {
+		return s.staged, nil
+	}
+	return s.unstaged, nil
+}
+
+func (s *hunkStager) StageSelection(stage, unstage string) error {
+	s.stage, s.unstage = stage, unstage
+	return nil
+}
+
+type selectAllPicker struct{}
+
+func (selectAllPicker) PickHunks(files []hunks.File) ([]hunks.File, error) {
+	for i := range files {
+		for j := range files[i].Hunks {
+			files[i].Hunks[j].Selected = true
+		}
+	}
+	return files, nil
+}
+
+func TestPickHunks(t *testing.T) {
+	unstaged := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n"
+	stager := &hunkStager{unstaged: unstaged}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{git: stager, opts: CommitOptions{Interactive: true, ErrWriter: &errOut}}
+
+	assert.ErrorContains(t, flow.pickHunks(), "--interactive needs a terminal")
+
+	flow.SetHunkPicker(selectAllPicker{})
+	assert.NoError(t, flow.pickHunks())
+	assert.Equal(t, unstaged, stager.stage)
+	assert.Empty(t, stager.unstage)
+	assert.Contains(t, errOut.String(), "Staged 1 of 1 hunks.")
+
+	stager.unstaged = ""
+	assert.ErrorContains(t, flow.pickHunks(), "no changes to pick")
+
+	assert.ErrorContains(t, flow.Run([]string{"a.go"}), "cannot be combined with file paths")
+}
+
+func TestCheckRisk(t *testing.T) {
+	cfg := &config.Config{RiskPolicies: []config.RiskPolicy{
+		{Name: "migrations", Paths: []string{"migrations/"}},
+		{Name: "large", MaxLines: 2},
+	}}
+	diff := "diff --git a/migrations/001.sql b/migrations/001.sql\n@@ -0,0 +1,3 @@\n+a\n+b\n+c\n"
+	files := []string{"migrations/001.sql"}
+	prompter := &stubPrompter{}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{cfg: cfg, prompter: prompter, opts: CommitOptions{ErrWriter: &errOut}}
+
+	err := flow.checkRisk(diff, files)
+	assert.ErrorIs(t, err, ErrRiskNotAcknowledged)
+	assert.ErrorContains(t, err, `type "yes, commit migrations, large"`)
+	assert.Equal(t, "yes, commit migrations, large", prompter.riskPhrase)
+	assert.Contains(t, errOut.String(), "  - migrations: migrations/001.sql\n  - large: 3 changed lines\n")
+
+	prompter.confirmRisk = true
+	assert.NoError(t, flow.checkRisk(diff, files))
+	assert.True(t, flow.result.RiskAcknowledged)
+
+	prompter.riskPhrase = ""
+	flow.opts.AutoYes = true
+	assert.ErrorContains(t, flow.checkRisk(diff, files), "pass --acknowledge-risk to commit with --yes")
+
+	flow.opts.AcknowledgeRisk = true
+	assert.NoError(t, flow.checkRisk(diff, files))
+	assert.Empty(t, prompter.riskPhrase, "--acknowledge-risk does not ask")
+
+	flow.result = CommitResult{}
+	assert.NoError(t, flow.checkRisk("diff --git a/x.go b/x.go\n@@ -1 +1 @@\n-a\n", []string{"x.go"}))
+	assert.Empty(t, flow.result.Risks)
+}
+
+func TestRunCommitLoopJSONResult(t *testing.T) {
+	cfg := &config.Config{RiskPolicies: []config.RiskPolicy{{Name: "secrets", Paths: []string{".env"}}}}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{
+		llm:      &stubLLM{replies: []string{"chore: rotate keys"}},
+		cfg:      cfg,
+		prompter: &stubPrompter{},
+		opts:     CommitOptions{JSON: true, AcknowledgeRisk: true, ErrWriter: &errOut, OutWriter: &out},
+	}
+
+	diff := "diff --git a/.env b/.env\n@@ -1 +1 @@\n-KEY=a\n+KEY=b\n"
+	assert.NoError(t, flow.runCommitLoop(diff, []string{".env"}, func(string) error { return nil }))
+	assert.JSONEq(t, `{
+		"message": "chore: rotate keys",
+		"committed": true,
+		"dry_run": false,
+		"files": [".env"],
+		"risks": [{"policy": "secrets", "files": [".env"]}],
+		"risk_acknowledged": true
+	}`, out.String())
+	assert.Contains(t, errOut.String(), "chore: rotate keys", "the message is shown for confirmation")
+
+	out.Reset()
+	flow.opts.AcknowledgeRisk = false
+	err := flow.runCommitLoop(diff, []string{".env"}, func(string) error { return nil })
+	assert.ErrorIs(t, err, ErrRiskNotAcknowledged)
+	assert.Contains(t, out.String(), `"committed": false`, "a refused commit still reports its risks")
+}
@@ -0,0 +1,117 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/samzong/gmc/internal/ui"
+)
+
+// Candidate is the message one of CommitOptions.Models generated.
+type Candidate struct {
+	Model    string
+	Message  string
+	Duration time.Duration
+	// Usage is zero when the client does not report it.
+	Usage llm.Usage
+	Err   error
+}
+
+// compareModels asks every model of CommitOptions.Models for a message at once, shows
+// the candidates side by side and returns the one the user picks, or the first that
+// succeeded with AutoYes.
+func (f *CommitFlow) compareModels(prompt, typeHint string) (string, error) {
+	sp := ui.NewSpinner(fmt.Sprintf("Generating commit messages with %d models...", len(f.opts.Models)))
+	sp.Start()
+	candidates := f.generateCandidates(prompt, typeHint)
+	sp.Stop()
+
+	first := -1
+	var errs []error
+	for i, candidate := range candidates {
+		if candidate.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", candidate.Model, candidate.Err))
+		} else if first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return "", fmt.Errorf("failed to generate commit message with any model: %w", errors.Join(errs...))
+	}
+
+	RenderCandidates(f.opts.ErrWriter, candidates)
+	picked := first
+	if f.opts.AutoYes {
+		fmt.Fprintf(f.opts.ErrWriter, "Using the message of %s (-y flag is set)\n", candidates[first].Model)
+	} else {
+		var err error
+		if picked, err = f.prompter.PickCandidate(candidates, first); err != nil {
+			return "", err
+		}
+		if picked < 0 {
+			return "", errCommitCancelled
+		}
+	}
+	f.historyModel = candidates[picked].Model
+	return candidates[picked].Message, nil
+}
+
+// generateCandidates runs the requests of compareModels concurrently. Messages get the
+// Type and Scope options, and those with a type outside commit_types fail, as they
+// would after the retry of llmMessage.
+func (f *CommitFlow) generateCandidates(prompt, typeHint string) []Candidate {
+	candidates := make([]Candidate, len(f.opts.Models))
+	metered, hasUsage := f.llm.(MeteredLLMClient)
+	var wg sync.WaitGroup
+	for i, model := range f.opts.Models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			candidate := Candidate{Model: model}
+			started := time.Now()
+			var message string
+			if hasUsage {
+				message, candidate.Usage, candidate.Err = metered.GenerateCommitMessageWithUsage(prompt, model)
+			} else {
+				message, candidate.Err = f.llm.GenerateCommitMessage(prompt, model)
+			}
+			candidate.Duration = time.Since(started)
+			if candidate.Err == nil {
+				candidate.Message = formatter.ConstrainMessage(formatter.EnforceTypeHint(f.cfg,
+					formatter.FormatCommitMessageWithConfig(f.cfg, message), typeHint), f.opts.Type, f.opts.Scope)
+				candidate.Err = formatter.CheckCommitType(f.cfg, candidate.Message)
+			}
+			candidates[i] = candidate
+		}()
+	}
+	wg.Wait()
+	return candidates
+}
+
+// RenderCandidates writes candidates as a numbered table of model, time, estimated
+// cost and subject.
+func RenderCandidates(w io.Writer, candidates []Candidate) {
+	fmt.Fprintln(w, "\nCandidates:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  #\tMODEL\tTIME\tCOST\tSUBJECT")
+	for i, candidate := range candidates {
+		cost := "-"
+		if dollars, ok := candidate.Usage.Cost(); ok {
+			cost = fmt.Sprintf("~$%.4f", dollars)
+		}
+		subject, _ := formatter.SplitCommitMessage(candidate.Message)
+		if candidate.Err != nil {
+			subject = "failed: " + candidate.Err.Error()
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\t%s\n", i+1, candidate.Model,
+			candidate.Duration.Round(100*time.Millisecond), cost, strings.TrimSpace(subject))
+	}
+	_ = tw.Flush()
+}
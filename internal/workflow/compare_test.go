@@ -0,0 +1,89 @@
+package workflow
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelsLLM replies per model and reports usage, as llm.Client does.
+type modelsLLM struct {
+	mu      sync.Mutex
+	replies map[string]string
+	asked   []string
+}
+
+func (m *modelsLLM) GenerateCommitMessage(prompt, model string) (string, error) {
+	message, _, err := m.GenerateCommitMessageWithUsage(prompt, model)
+	return message, err
+}
+
+func (m *modelsLLM) GenerateCommitMessageWithUsage(_, model string) (string, llm.Usage, error) {
+	m.mu.Lock()
+	m.asked = append(m.asked, model)
+	m.mu.Unlock()
+	reply, ok := m.replies[model]
+	if !ok {
+		return "", llm.Usage{}, errors.New("model not found")
+	}
+	return reply, llm.Usage{Model: model, PromptTokens: 1000, CompletionTokens: 20}, nil
+}
+
+type pickPrompter struct {
+	stubPrompter
+	pick int
+	def  int
+}
+
+func (p *pickPrompter) PickCandidate(_ []Candidate, def int) (int, error) {
+	p.def = def
+	return p.pick, nil
+}
+
+func TestCompareModels(t *testing.T) {
+	llmClient := &modelsLLM{replies: map[string]string{
+		"gpt-4o":         "feat(auth): add OAuth login",
+		"claude-3-haiku": "fix: add oauth login",
+		"o3-mini":        "chore: add oauth login",
+	}}
+	prompter := &pickPrompter{pick: 2}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{
+		llm:      llmClient,
+		cfg:      &config.Config{CommitTypes: []string{"feat", "fix"}},
+		prompter: prompter,
+		opts: CommitOptions{
+			Models:    []string{"o9", "gpt-4o", "claude-3-haiku", "o3-mini"},
+			ErrWriter: &errOut,
+		},
+	}
+
+	message, err := flow.compareModels("prompt", "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"o9", "gpt-4o", "claude-3-haiku", "o3-mini"}, llmClient.asked)
+	assert.Equal(t, 1, prompter.def, "the first message that succeeded is suggested")
+	assert.Equal(t, "fix: add oauth login", message, "the picked candidate is used")
+	assert.Equal(t, "claude-3-haiku", flow.historyModel)
+	assert.Contains(t, errOut.String(), "failed: model not found")
+	assert.Contains(t, errOut.String(), "~$0.0027")
+	assert.Contains(t, errOut.String(), "failed: commit type not allowed: chore")
+
+	prompter.pick = -1
+	_, err = flow.compareModels("prompt", "")
+	assert.ErrorIs(t, err, errCommitCancelled)
+
+	flow.opts.AutoYes = true
+	message, err = flow.compareModels("prompt", "")
+	require.NoError(t, err)
+	assert.Equal(t, "feat(auth): add OAuth login", message)
+
+	flow.opts.Models = []string{"o9"}
+	_, err = flow.compareModels("prompt", "")
+	assert.ErrorContains(t, err, "o9: model not found")
+}
@@ -1,24 +1,69 @@
 // Package workflow provides the commit workflow orchestration logic.
 package workflow
 
+import (
+	"context"
+
+	"github.com/samzong/gmc/internal/forge"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/history"
+	"github.com/samzong/gmc/internal/hunks"
+	"github.com/samzong/gmc/internal/llm"
+)
+
 // GitClient abstracts git operations for testability.
 type GitClient interface {
 	IsGitRepository() bool
 	CheckGitRepository() error
 	AddAll() error
 	StageFiles(files []string) error
-	GetStagedDiff() (string, error)
-	GetStagedDiffStats() (string, error)
+	GetStagedChanges() (git.StagedChanges, error)
+	StagedPaths() (paths []string, unstaged []string, err error)
 	GetFilesDiff(files []string) (string, error)
-	ParseStagedFiles() ([]string, error)
 	ResolveFiles(paths []string) ([]string, error)
 	CheckFileStatus(files []string) (staged, modified, untracked []string, err error)
 	Commit(message string, args ...string) error
 	CommitFiles(message string, files []string, args ...string) error
+	InterpretTrailers(message string, trailers []string) (string, error)
 	CreateAndSwitchBranch(branchName string) error
+	CurrentBranch() (string, error)
+	AddNote(ref, object, content string) error
+	AuthorIdent(author, date string) (git.Ident, error)
+	SigningConfig() git.SigningConfig
+	GetHunkDiff(cached bool) (string, error)
+	StageSelection(stage, unstage string) error
 }
 
 // LLMClient abstracts LLM operations for testability.
 type LLMClient interface {
 	GenerateCommitMessage(prompt string, model string) (string, error)
 }
+
+// MeteredLLMClient is an LLMClient that also returns the token usage of a message, so
+// --models can show what each candidate cost.
+type MeteredLLMClient interface {
+	GenerateCommitMessageWithUsage(prompt string, model string) (string, llm.Usage, error)
+}
+
+// HunkPicker lets the user choose the hunks to commit for --interactive.
+type HunkPicker interface {
+	PickHunks(files []hunks.File) ([]hunks.File, error)
+}
+
+// IssueFetcher abstracts issue metadata lookups used to enrich the prompt.
+type IssueFetcher interface {
+	FetchIssue(ctx context.Context, number string) (*forge.Issue, error)
+}
+
+// IssueUpdater comments on and transitions the issue after a commit, for --jira-comment
+// and --jira-transition.
+type IssueUpdater interface {
+	AddComment(ctx context.Context, key, body string) error
+	Transition(ctx context.Context, key, name string) error
+}
+
+// HistoryRecorder keeps the messages a commit generated, with their outcome, for
+// gmc history and gmc redo.
+type HistoryRecorder interface {
+	Record(entries ...history.Entry) error
+}
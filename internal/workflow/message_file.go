@@ -0,0 +1,38 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadMessageFile reads a commit message saved by WriteMessageFile or by git as
+// COMMIT_EDITMSG. Like git commit, it drops the "#" comment lines and the blank lines
+// around the message.
+func ReadMessageFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the message file: %w", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+	message := strings.Trim(strings.Join(lines, "\n"), "\n")
+	if message == "" {
+		return "", errors.New("the message file " + path + " has no message")
+	}
+	return message, nil
+}
+
+// WriteMessageFile writes message to path as git writes COMMIT_EDITMSG, ending in a
+// newline, so git commit -F and ReadMessageFile can read it back.
+func WriteMessageFile(path, message string) error {
+	if err := os.WriteFile(path, []byte(message+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write the message file: %w", err)
+	}
+	return nil
+}
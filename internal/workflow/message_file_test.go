@@ -0,0 +1,72 @@
+package workflow
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "msg.txt")
+	require.NoError(t, WriteMessageFile(path, "feat: add login\n\n- why"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add login\n\n- why\n", string(data))
+
+	message, err := ReadMessageFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add login\n\n- why", message)
+}
+
+func TestReadMessageFileDropsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	editMsg := "\nfix: handle empty diff  \r\n\n# Please enter the commit message for your changes.\n#\tmodified: a.go\n"
+	require.NoError(t, os.WriteFile(path, []byte(editMsg), 0o644))
+	message, err := ReadMessageFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: handle empty diff", message)
+
+	require.NoError(t, os.WriteFile(path, []byte("# only comments\n\n"), 0o644))
+	_, err = ReadMessageFile(path)
+	assert.EqualError(t, err, "the message file "+path+" has no message")
+
+	_, err = ReadMessageFile(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRunCommitLoopMessageAndOutFile(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "msg.txt")
+	llmClient := &stubLLM{}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{
+		llm:      llmClient,
+		cfg:      &config.Config{},
+		prompter: &stubPrompter{},
+		opts: CommitOptions{
+			Message: "docs: explain setup", OutFile: outFile, DryRun: true, ErrWriter: &errOut, OutWriter: &out,
+		},
+	}
+
+	var committed string
+	diff := "diff --git a/README.md b/README.md\n@@ -1 +1 @@\n-a\n+b\n"
+	require.NoError(t, flow.runCommitLoop(diff, []string{"README.md"}, func(message string) error {
+		committed = message
+		return nil
+	}))
+	assert.Equal(t, "docs: explain setup", committed)
+	assert.Empty(t, llmClient.prompts, "a given message needs no LLM")
+	assert.Equal(t, MessageFileModel, flow.historyModel)
+	assert.Contains(t, errOut.String(), "Wrote the commit message to "+outFile)
+	saved, err := ReadMessageFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "docs: explain setup", saved)
+
+	flow.prompter = &scriptedPrompter{actions: []Action{ActionRegenerate}}
+	err = flow.runCommitLoop(diff, []string{"README.md"}, func(string) error { return nil })
+	assert.ErrorContains(t, err, "without --use-message-file")
+}
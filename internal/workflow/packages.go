@@ -0,0 +1,219 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/samzong/gmc/internal/formatter"
+)
+
+// PackageGroup is the staged files of one package that --per-package commits
+// together. The group of files outside every package has an empty Dir.
+type PackageGroup struct {
+	Dir   string
+	Files []string
+}
+
+// Name returns the package directory name, which is also its scope hint.
+func (g PackageGroup) Name() string {
+	if g.Dir == "" {
+		return ""
+	}
+	return path.Base(g.Dir)
+}
+
+// CheckPackageGlobs reports the first package_globs pattern path.Match rejects.
+func CheckPackageGlobs(globs []string) error {
+	for _, glob := range globs {
+		if _, err := path.Match(cleanPackageGlob(glob), ""); err != nil {
+			return fmt.Errorf("invalid package_globs pattern %q: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// GroupByPackage partitions repository-relative files by the package directory a glob
+// matches, such as packages/ui for "packages/*". When several globs match, the deepest
+// directory wins. Packages are sorted by directory and the files outside every package
+// come last.
+func GroupByPackage(globs []string, files []string) []PackageGroup {
+	byDir := make(map[string][]string)
+	for _, file := range files {
+		dir := packageDir(globs, file)
+		byDir[dir] = append(byDir[dir], file)
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	if _, ok := byDir[""]; ok {
+		dirs = append(dirs, "")
+	}
+
+	groups := make([]PackageGroup, 0, len(dirs))
+	for _, dir := range dirs {
+		groups = append(groups, PackageGroup{Dir: dir, Files: byDir[dir]})
+	}
+	return groups
+}
+
+// packageDir returns the directory of the package holding file, or "" when no glob
+// matches one of its parent directories.
+func packageDir(globs []string, file string) string {
+	parts := strings.Split(file, "/")
+	best := ""
+	for _, glob := range globs {
+		glob = cleanPackageGlob(glob)
+		depth := strings.Count(glob, "/") + 1
+		if glob == "" || depth >= len(parts) {
+			continue
+		}
+		dir := strings.Join(parts[:depth], "/")
+		if ok, _ := path.Match(glob, dir); ok && len(dir) > len(best) {
+			best = dir
+		}
+	}
+	return best
+}
+
+func cleanPackageGlob(glob string) string {
+	glob = strings.Trim(path.Clean(strings.TrimSpace(glob)), "/")
+	if glob == "." {
+		return ""
+	}
+	return glob
+}
+
+// commitPerPackage commits the staged changes of each package on its own, with the
+// package name as the scope hint, and the changes outside every package last.
+func (f *CommitFlow) commitPerPackage() (err error) {
+	if err := CheckPackageGlobs(f.cfg.PackageGlobs); err != nil {
+		return err
+	}
+
+	paths, unstaged, err := f.git.StagedPaths()
+	if err != nil {
+		return fmt.Errorf("failed to list staged files: %w", err)
+	}
+	if len(paths) == 0 {
+		return ErrNoChanges
+	}
+	// Each package is committed as git commit -- <paths>, which takes the worktree
+	// content of the paths, so it has to match what is staged.
+	if len(unstaged) > 0 {
+		return fmt.Errorf("--per-package commits what is staged, but these files also have unstaged changes: %s\n"+
+			"Hint: stage or stash them first", strings.Join(unstaged, " "))
+	}
+
+	groups := GroupByPackage(f.cfg.PackageGlobs, paths)
+	fmt.Fprintf(f.opts.ErrWriter, "Committing %d package group(s) one at a time.\n", len(groups))
+
+	var results []CommitResult
+	if f.opts.JSON {
+		defer func() {
+			if errors.Is(err, ErrRiskNotAcknowledged) {
+				results = append(results, f.result)
+			}
+			if err == nil || errors.Is(err, ErrRiskNotAcknowledged) {
+				f.printResults(results)
+			}
+		}()
+	}
+
+	for i, group := range groups {
+		name := group.Dir
+		if name == "" {
+			name = "files outside packages"
+		}
+		fmt.Fprintf(f.opts.ErrWriter, "\n[%d/%d] %s (%d file(s))\n", i+1, len(groups), name, len(group.Files))
+
+		pathspecs := topPathspecs(group.Files)
+		diff, err := f.git.GetFilesDiff(pathspecs)
+		if err != nil {
+			return fmt.Errorf("failed to get diff: %w", err)
+		}
+		if diff == "" {
+			continue
+		}
+
+		f.startPackage(group.Name())
+		err = f.runCommitLoop(diff, group.Files, func(msg string) error {
+			return f.performPackageCommit(msg, group.Files, pathspecs)
+		})
+		if errors.Is(err, errCommitCancelled) {
+			fmt.Fprintln(f.opts.ErrWriter, "Commit cancelled by user; skipping "+name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		results = append(results, f.result)
+	}
+	return nil
+}
+
+// startPackage clears what the previous package's commit left behind.
+func (f *CommitFlow) startPackage(scope string) {
+	f.packageScope = scope
+	f.breaking = ""
+	f.promptHash = ""
+	f.candidates = nil
+	f.summarized = false
+}
+
+// performPackageCommit is performSelectiveCommit for the files of one package, passed
+// to git as pathspecs.
+func (f *CommitFlow) performPackageCommit(message string, files, pathspecs []string) error {
+	if f.opts.DryRun {
+		fmt.Fprintln(f.opts.ErrWriter, "Dry run mode, no actual commit")
+		fmt.Fprintf(f.opts.ErrWriter, "Would commit files: %v\n", files)
+		return nil
+	}
+
+	commit := func() error { return f.git.CommitFiles(message, pathspecs, f.buildCommitArgs()...) }
+	if err := f.commitWithAutofixRetry(commit); err != nil {
+		return fmt.Errorf("failed to commit files: %w", err)
+	}
+
+	fmt.Fprintf(f.opts.ErrWriter, "Successfully committed files: %v!\n", files)
+	return nil
+}
+
+// scopeHint returns the scope the scope_rules config assigns to changedFiles, falling
+// back to the package being committed with --per-package.
+func (f *CommitFlow) scopeHint(changedFiles []string) string {
+	if scope := formatter.ScopeHintForConfig(f.cfg, changedFiles); scope != "" {
+		return scope
+	}
+	return f.packageScope
+}
+
+func (f *CommitFlow) printResults(results []CommitResult) {
+	if results == nil {
+		results = []CommitResult{}
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		fmt.Fprintf(f.opts.ErrWriter, "Warning: failed to encode the result: %v\n", err)
+		return
+	}
+	fmt.Fprintln(f.opts.OutWriter, string(data))
+}
+
+// topPathspecs makes repository-relative paths pathspecs that git resolves from the
+// worktree root whatever the current directory.
+func topPathspecs(files []string) []string {
+	pathspecs := make([]string, len(files))
+	for i, file := range files {
+		pathspecs[i] = ":(top,literal)" + file
+	}
+	return pathspecs
+}
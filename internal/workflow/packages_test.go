@@ -0,0 +1,108 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByPackage(t *testing.T) {
+	files := []string{
+		"README.md",
+		"packages/ui/src/button.tsx",
+		"packages/api/index.ts",
+		"packages/ui/package.json",
+		"apps/web/next.config.js",
+		"apps/web/plugins/auth/index.ts",
+		"packages",
+	}
+	groups := GroupByPackage([]string{"packages/*", "apps/*/", "apps/web/plugins/*"}, files)
+
+	assert.Equal(t, []PackageGroup{
+		{Dir: "apps/web", Files: []string{"apps/web/next.config.js"}},
+		{Dir: "apps/web/plugins/auth", Files: []string{"apps/web/plugins/auth/index.ts"}},
+		{Dir: "packages/api", Files: []string{"packages/api/index.ts"}},
+		{Dir: "packages/ui", Files: []string{"packages/ui/src/button.tsx", "packages/ui/package.json"}},
+		{Dir: "", Files: []string{"README.md", "packages"}},
+	}, groups)
+	assert.Equal(t, "auth", groups[1].Name())
+	assert.Equal(t, "", groups[4].Name())
+
+	assert.Equal(t, []PackageGroup{{Dir: "", Files: files}}, GroupByPackage(nil, files))
+	assert.Empty(t, GroupByPackage([]string{"packages/*"}, nil))
+}
+
+func TestCheckPackageGlobs(t *testing.T) {
+	assert.NoError(t, CheckPackageGlobs([]string{"packages/*", "apps/[a-z]*"}))
+	err := CheckPackageGlobs([]string{"packages/*", "apps/[a-"})
+	assert.ErrorContains(t, err, `invalid package_globs pattern "apps/[a-"`)
+}
+
+type packageCommitter struct {
+	GitClient
+	staged, unstaged []string
+	commits          [][]string
+}
+
+func (c *packageCommitter) StagedPaths() ([]string, []string, error) {
+	return c.staged, c.unstaged, nil
+}
+
+func (c *packageCommitter) GetFilesDiff(pathspecs []string) (string, error) {
+	var diff strings.Builder
+	for _, pathspec := range pathspecs {
+		file := strings.TrimPrefix(pathspec, ":(top,literal)")
+		diff.WriteString("diff --git a/" + file + " b/" + file + "\n@@ -1 +1 @@\n-a\n+b\n")
+	}
+	return diff.String(), nil
+}
+
+func (c *packageCommitter) CommitFiles(_ string, pathspecs []string, _ ...string) error {
+	c.commits = append(c.commits, pathspecs)
+	return nil
+}
+
+func TestCommitPerPackage(t *testing.T) {
+	committer := &packageCommitter{staged: []string{"go.mod", "packages/api/main.go", "packages/ui/app.ts"}}
+	llmClient := &stubLLM{replies: []string{"feat(api): add route", "fix(ui): align button", "chore: bump go"}}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{
+		git:      committer,
+		llm:      llmClient,
+		cfg:      &config.Config{PackageGlobs: []string{"packages/*"}},
+		prompter: &stubPrompter{},
+		opts:     CommitOptions{PerPackage: true, JSON: true, NoSignoff: true, ErrWriter: &errOut, OutWriter: &out},
+	}
+
+	require.NoError(t, flow.commitPerPackage())
+	assert.Equal(t, [][]string{
+		{":(top,literal)packages/api/main.go"},
+		{":(top,literal)packages/ui/app.ts"},
+		{":(top,literal)go.mod"},
+	}, committer.commits)
+	require.Len(t, llmClient.prompts, 3)
+	assert.Contains(t, llmClient.prompts[0], `uses the "api" scope`)
+	assert.Contains(t, llmClient.prompts[1], `uses the "ui" scope`)
+	assert.NotContains(t, llmClient.prompts[2], "Scope Hint")
+	assert.Contains(t, errOut.String(), "[3/3] files outside packages (1 file(s))")
+
+	var results []CommitResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &results), "one JSON list for every commit")
+	require.Len(t, results, 3)
+	assert.Equal(t, "fix(ui): align button", results[1].Message)
+	assert.Equal(t, []string{"packages/ui/app.ts"}, results[1].Files)
+
+	committer.unstaged = []string{"packages/ui/app.ts"}
+	assert.ErrorContains(t, flow.commitPerPackage(), "also have unstaged changes: packages/ui/app.ts")
+
+	committer.staged = nil
+	assert.ErrorIs(t, flow.commitPerPackage(), ErrNoChanges)
+
+	flow.opts.JSON = false
+	assert.ErrorContains(t, flow.Run([]string{"a.go"}), "--per-package cannot be combined with file paths")
+}
@@ -0,0 +1,152 @@
+package workflow
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/llm"
+)
+
+// preflightLargest is how many of the most changed files the preflight summary names.
+const preflightLargest = 3
+
+// preflightCompletionTokens is the reply size cost estimates assume: a subject and a
+// short body.
+const preflightCompletionTokens = 100
+
+// Preflight summarizes the staged changes before the message is generated.
+type Preflight struct {
+	Files   int
+	Added   int
+	Removed int
+	// Largest are the most changed files, most changed first.
+	Largest []git.FileStat
+	// PromptTokens estimates the size of the diff the prompt sends, or is 0 when no
+	// LLM is asked.
+	PromptTokens int
+	// Models are the models the prompt goes to.
+	Models []string
+}
+
+// Cost estimates the price of asking every model, with ok false when a model has no
+// known price.
+func (p Preflight) Cost() (cost float64, ok bool) {
+	for _, model := range p.Models {
+		dollars, known := llm.Usage{
+			Model: model, PromptTokens: p.PromptTokens, CompletionTokens: preflightCompletionTokens,
+		}.Cost()
+		if !known {
+			return 0, false
+		}
+		cost += dollars
+	}
+	return cost, len(p.Models) > 0
+}
+
+// preflight prints the Preflight summary of diff, with the prompt size estimated from
+// promptDiff, the diff preparePromptDiff outlined or summarized, and asks whether to
+// generate, unless AutoYes is set. It does nothing when the preflight config is off or
+// the message comes from a file.
+func (f *CommitFlow) preflight(diff, promptDiff string, files []string) error {
+	if f.cfg == nil || !f.cfg.Preflight || f.opts.Message != "" {
+		return nil
+	}
+
+	summary := Preflight{Files: len(files)}
+	stats := diffFileStats(diff)
+	for _, stat := range stats {
+		summary.Added += stat.Added
+		summary.Removed += stat.Removed
+	}
+	slices.SortStableFunc(stats, func(a, b git.FileStat) int {
+		return cmp.Compare(b.Added+b.Removed, a.Added+a.Removed)
+	})
+	summary.Largest = stats[:min(len(stats), preflightLargest)]
+	if !f.opts.Offline {
+		summary.PromptTokens = llm.EstimateTokens(promptDiff)
+		summary.Models = f.opts.Models
+		if len(summary.Models) == 0 {
+			summary.Models = []string{f.cfg.Model}
+		}
+	}
+	RenderPreflight(f.opts.ErrWriter, summary)
+
+	proceed, err := f.prompter.ConfirmGenerate(f.opts.AutoYes)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		return errCommitCancelled
+	}
+	return nil
+}
+
+// RenderPreflight writes summary as a few lines: files and lines, the largest files,
+// and the estimated prompt size and cost.
+func RenderPreflight(w io.Writer, summary Preflight) {
+	fmt.Fprintf(w, "Staged: %d file(s), +%d -%d lines\n", summary.Files, summary.Added, summary.Removed)
+	if len(summary.Largest) > 1 {
+		largest := make([]string, len(summary.Largest))
+		for i, stat := range summary.Largest {
+			largest[i] = fmt.Sprintf("%s (+%d -%d)", stat.Path, stat.Added, stat.Removed)
+			if stat.Binary {
+				largest[i] = stat.Path + " (binary)"
+			}
+		}
+		fmt.Fprintf(w, "Largest: %s\n", strings.Join(largest, ", "))
+	}
+	if summary.PromptTokens == 0 {
+		return
+	}
+	line := fmt.Sprintf("Prompt: ~%d tokens", summary.PromptTokens)
+	if len(summary.Models) > 1 {
+		line += fmt.Sprintf(" to %d models", len(summary.Models))
+	}
+	if cost, ok := summary.Cost(); ok {
+		line += fmt.Sprintf(", ~$%.4f", cost)
+	}
+	fmt.Fprintln(w, line)
+}
+
+// diffFileStats returns the lines each file of diff gained and lost: from its stats
+// block when it has one, or else counted from the patch.
+func diffFileStats(diff string) []git.FileStat {
+	patch, stats, found := strings.Cut(diff, formatter.DiffStatsSeparator)
+	if found && strings.TrimSpace(stats) != "" {
+		return git.ParseNumstat(stats)
+	}
+
+	var fileStats []git.FileStat
+	inHunk := false
+	for _, line := range strings.Split(patch, "\n") {
+		if header, ok := strings.CutPrefix(line, "diff --git "); ok {
+			path := header
+			if i := strings.LastIndex(header, " b/"); i >= 0 {
+				path = header[i+len(" b/"):]
+			}
+			fileStats = append(fileStats, git.FileStat{Path: path})
+			inHunk = false
+			continue
+		}
+		if len(fileStats) == 0 {
+			continue
+		}
+		stat := &fileStats[len(fileStats)-1]
+		switch {
+		case strings.HasPrefix(line, "Binary files "):
+			stat.Binary = true
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk && strings.HasPrefix(line, "+"):
+			stat.Added++
+		case inHunk && strings.HasPrefix(line, "-"):
+			stat.Removed++
+		}
+	}
+	return fileStats
+}
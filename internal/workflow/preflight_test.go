@@ -0,0 +1,83 @@
+package workflow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/formatter"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type declinePrompter struct {
+	stubPrompter
+	asked bool
+}
+
+func (p *declinePrompter) ConfirmGenerate(bool) (bool, error) {
+	p.asked = true
+	return false, nil
+}
+
+func TestDiffFileStats(t *testing.T) {
+	patch := "diff --git a/a.go b/a.go\n@@ -1,2 +1,3 @@\n-old\n+new\n+more\n ctx\n" +
+		"diff --git a/logo.png b/logo.png\nBinary files a/logo.png and b/logo.png differ\n"
+	assert.Equal(t, []git.FileStat{
+		{Path: "a.go", Added: 2, Removed: 1},
+		{Path: "logo.png", Binary: true},
+	}, diffFileStats(patch))
+
+	withStats := patch + "\n" + formatter.DiffStatsSeparator + "\n5\t0\tb.go\n"
+	assert.Equal(t, []git.FileStat{{Path: "b.go", Added: 5}}, diffFileStats(withStats),
+		"the stats block wins over the patch")
+}
+
+func TestRenderPreflight(t *testing.T) {
+	var out bytes.Buffer
+	RenderPreflight(&out, Preflight{
+		Files: 3, Added: 120, Removed: 15,
+		Largest: []git.FileStat{
+			{Path: "a.go", Added: 100, Removed: 10},
+			{Path: "b.go", Added: 20, Removed: 5},
+			{Path: "logo.png", Binary: true},
+		},
+		PromptTokens: 2000,
+		Models:       []string{"gpt-4.1-mini", "gpt-4o"},
+	})
+	assert.Equal(t, "Staged: 3 file(s), +120 -15 lines\n"+
+		"Largest: a.go (+100 -10), b.go (+20 -5), logo.png (binary)\n"+
+		"Prompt: ~2000 tokens to 2 models, ~$0.0070\n", out.String())
+
+	out.Reset()
+	RenderPreflight(&out, Preflight{Files: 1, Added: 1, Largest: []git.FileStat{{Path: "a.go", Added: 1}}})
+	assert.Equal(t, "Staged: 1 file(s), +1 -0 lines\n", out.String(), "offline runs have no prompt")
+}
+
+func TestPreflight(t *testing.T) {
+	prompter := &declinePrompter{}
+	var errOut bytes.Buffer
+	flow := &CommitFlow{
+		cfg:      &config.Config{Model: "gpt-4.1-mini", Preflight: true},
+		prompter: prompter,
+		opts:     CommitOptions{ErrWriter: &errOut},
+	}
+	diff := "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n"
+
+	err := flow.preflight(diff, diff, []string{"a.go"})
+	assert.ErrorIs(t, err, errCommitCancelled)
+	assert.True(t, prompter.asked)
+	assert.Contains(t, errOut.String(), "Staged: 1 file(s), +1 -1 lines\n")
+	assert.Contains(t, errOut.String(), "Prompt: ~")
+
+	prompter.asked = false
+	flow.opts.Message = "feat: from a file"
+	require.NoError(t, flow.preflight(diff, diff, []string{"a.go"}))
+	assert.False(t, prompter.asked, "a message file needs no generation")
+
+	flow.opts.Message = ""
+	flow.cfg.Preflight = false
+	require.NoError(t, flow.preflight(diff, diff, []string{"a.go"}))
+	assert.False(t, prompter.asked)
+}
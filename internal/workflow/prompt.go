@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/mattn/go-isatty"
@@ -24,6 +25,15 @@ const (
 
 type Prompter interface {
 	GetConfirmation(message string, autoYes bool) (Action, string, error)
+	// ConfirmBreaking asks whether detected breaking changes should mark the commit as breaking.
+	ConfirmBreaking(changes []string, autoYes bool) (bool, error)
+	// ConfirmRisk asks the user to type phrase to commit despite risk_policies findings.
+	ConfirmRisk(phrase string) (bool, error)
+	// ConfirmGenerate asks whether to generate the message after the preflight summary.
+	ConfirmGenerate(autoYes bool) (bool, error)
+	// PickCandidate asks which of the candidates --models generated to use, suggesting
+	// the one at index def. It returns -1 when the user cancels.
+	PickCandidate(candidates []Candidate, def int) (int, error)
 }
 
 type InteractivePrompter struct {
@@ -38,26 +48,13 @@ func (p *InteractivePrompter) GetConfirmation(message string, autoYes bool) (Act
 		return ActionCommit, "", nil
 	}
 
-	stdin := p.Stdin
-	if stdin == nil {
-		stdin = os.Stdin
-	}
-
-	if f, ok := stdin.(*os.File); ok {
-		if !isatty.IsTerminal(f.Fd()) && !isatty.IsCygwinTerminal(f.Fd()) {
-			return ActionCancel, "", errors.New("stdin is not a terminal, use --yes to skip interactive confirmation")
-		}
-	}
-
 	fmt.Fprint(p.ErrWriter,
 		"\nDo you want to proceed with this commit message? [y/n/r/e] (y/n/r=regenerate/e=edit): ")
-	reader := bufio.NewReader(stdin)
-	response, err := reader.ReadString('\n')
+	response, err := p.readResponse()
 	if err != nil {
-		return ActionCancel, "", fmt.Errorf("failed to read user input: %w", err)
+		return ActionCancel, "", err
 	}
 
-	response = strings.ToLower(strings.TrimSpace(response))
 	switch response {
 	case "n":
 		return ActionCancel, "", nil
@@ -77,6 +74,90 @@ func (p *InteractivePrompter) GetConfirmation(message string, autoYes bool) (Act
 	}
 }
 
+func (p *InteractivePrompter) ConfirmBreaking(changes []string, autoYes bool) (bool, error) {
+	fmt.Fprintln(p.ErrWriter, "Possible breaking changes detected:")
+	for _, change := range changes {
+		fmt.Fprintf(p.ErrWriter, "  - %s\n", change)
+	}
+	if autoYes {
+		fmt.Fprintln(p.ErrWriter, "Not marking the commit as breaking (-y flag is set)")
+		return false, nil
+	}
+
+	fmt.Fprint(p.ErrWriter, "Mark this commit as breaking (type!: and BREAKING CHANGE footer)? [y/N]: ")
+	response, err := p.readResponse()
+	if err != nil {
+		return false, err
+	}
+	return response == "y" || response == "yes", nil
+}
+
+func (p *InteractivePrompter) ConfirmGenerate(autoYes bool) (bool, error) {
+	if autoYes {
+		return true, nil
+	}
+
+	fmt.Fprint(p.ErrWriter, "Generate the commit message? [Y/n]: ")
+	response, err := p.readResponse()
+	if err != nil {
+		return false, err
+	}
+	return response != "n" && response != "no", nil
+}
+
+func (p *InteractivePrompter) ConfirmRisk(phrase string) (bool, error) {
+	fmt.Fprintf(p.ErrWriter, "Type %q to commit anyway: ", phrase)
+	response, err := p.readResponse()
+	if err != nil {
+		return false, err
+	}
+	return response == strings.ToLower(phrase), nil
+}
+
+func (p *InteractivePrompter) PickCandidate(candidates []Candidate, def int) (int, error) {
+	for {
+		fmt.Fprintf(p.ErrWriter, "Use which message? [1-%d, Enter for %d, n to cancel]: ", len(candidates), def+1)
+		response, err := p.readResponse()
+		if err != nil {
+			return -1, err
+		}
+		switch response {
+		case "":
+			return def, nil
+		case "n":
+			return -1, nil
+		}
+		if n, err := strconv.Atoi(response); err == nil && n >= 1 && n <= len(candidates) {
+			if candidates[n-1].Err == nil {
+				return n - 1, nil
+			}
+			fmt.Fprintf(p.ErrWriter, "%s failed; pick another message.\n", candidates[n-1].Model)
+			continue
+		}
+		fmt.Fprintf(p.ErrWriter, "Enter a number from 1 to %d.\n", len(candidates))
+	}
+}
+
+// readResponse reads one lower-cased line from stdin, which must be a terminal.
+func (p *InteractivePrompter) readResponse() (string, error) {
+	stdin := p.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	if f, ok := stdin.(*os.File); ok {
+		if !isatty.IsTerminal(f.Fd()) && !isatty.IsCygwinTerminal(f.Fd()) {
+			return "", errors.New("stdin is not a terminal, use --yes to skip interactive confirmation")
+		}
+	}
+
+	response, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read user input: %w", err)
+	}
+	return strings.ToLower(strings.TrimSpace(response)), nil
+}
+
 func (p *InteractivePrompter) openEditor(message string) (string, error) {
 	fmt.Fprintln(p.ErrWriter, "Opening editor to modify commit message...")
 
@@ -94,7 +175,7 @@ func (p *InteractivePrompter) openEditor(message string) (string, error) {
 	}
 	tmpFile.Close()
 
-	editor := getEditor()
+	editor := Editor()
 	cmd := exec.Command(editor, tmpFileName)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -121,7 +202,8 @@ func (p *InteractivePrompter) openEditor(message string) (string, error) {
 	return "", nil
 }
 
-func getEditor() string {
+// Editor returns the editor to open files in: $EDITOR, then $VISUAL, then vi.
+func Editor() string {
 	if editor := os.Getenv("EDITOR"); editor != "" {
 		return editor
 	}
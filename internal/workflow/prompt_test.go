@@ -19,8 +19,8 @@ func TestGetEditor(t *testing.T) {
 			t.Setenv("EDITOR", tc.editor)
 			t.Setenv("VISUAL", tc.visual)
 
-			if got := getEditor(); got != tc.want {
-				t.Fatalf("getEditor() = %q, want %q", got, tc.want)
+			if got := Editor(); got != tc.want {
+				t.Fatalf("Editor() = %q, want %q", got, tc.want)
 			}
 		})
 	}
@@ -0,0 +1,83 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/samzong/gmc/internal/history"
+	"github.com/samzong/gmc/internal/risk"
+)
+
+// ErrNothingToRedo is returned by Redo when the last generated message was committed.
+var ErrNothingToRedo = errors.New("nothing to redo: the last generated message was committed")
+
+// Redo offers entry's message for the staged changes again, and commits it, without
+// calling the LLM. The commit options apply as they do to a generated message.
+func (f *CommitFlow) Redo(entry history.Entry) (err error) {
+	if !entry.Redoable() {
+		return ErrNothingToRedo
+	}
+	if err := f.checkAuthor(); err != nil {
+		return err
+	}
+	if err := f.checkSigning(); err != nil {
+		return err
+	}
+
+	diff, files, err := f.getStagedChanges()
+	if err != nil {
+		return err
+	}
+	if history.HashDiff(diff) != entry.DiffHash {
+		fmt.Fprintln(f.opts.ErrWriter,
+			"Warning: the staged changes differ from the ones this message was generated for.")
+	}
+
+	f.result = CommitResult{DryRun: f.opts.DryRun, Files: files, Risks: []risk.Finding{}}
+	if f.opts.JSON {
+		defer func() {
+			if err == nil {
+				f.printResult()
+			}
+		}()
+	}
+
+	f.candidates = []string{entry.Message}
+	f.historyModel = entry.Model
+	var finalMessage string
+	defer func() { f.recordHistory(diff, files, finalMessage, err) }()
+
+	fmt.Fprintf(f.opts.ErrWriter, "\nCommit message generated %s (%s):\n",
+		entry.Time.Local().Format("2006-01-02 15:04"), entry.Status)
+	if f.opts.JSON {
+		fmt.Fprintln(f.opts.ErrWriter, entry.Message)
+	} else {
+		fmt.Fprintln(f.opts.OutWriter, entry.Message)
+	}
+
+	action, editedMessage, err := f.prompter.GetConfirmation(entry.Message, f.opts.AutoYes)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case ActionCancel:
+		return errCommitCancelled
+	case ActionRegenerate:
+		return errors.New("redo commits a saved message; run gmc to generate a new one")
+	}
+
+	accepted := entry.Message
+	if editedMessage != "" {
+		accepted = editedMessage
+	}
+	if accepted, err = f.applyTrailers(accepted); err != nil {
+		return err
+	}
+	finalMessage = accepted
+	if err := f.performCommit(finalMessage); err != nil {
+		return err
+	}
+	f.result.Message = finalMessage
+	f.result.Committed = !f.opts.DryRun
+	return nil
+}
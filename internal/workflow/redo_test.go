@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/samzong/gmc/internal/config"
+	"github.com/samzong/gmc/internal/git"
+	"github.com/samzong/gmc/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type redoGit struct {
+	GitClient
+	changes   git.StagedChanges
+	commitErr error
+	committed []string
+}
+
+func (g *redoGit) GetStagedChanges() (git.StagedChanges, error) { return g.changes, nil }
+func (g *redoGit) CurrentBranch() (string, error)               { return "feature/login", nil }
+func (g *redoGit) SigningConfig() git.SigningConfig             { return git.SigningConfig{} }
+
+func (g *redoGit) Commit(message string, _ ...string) error {
+	if g.commitErr != nil {
+		return g.commitErr
+	}
+	g.committed = append(g.committed, message)
+	return nil
+}
+
+type memoryHistory struct {
+	entries []history.Entry
+}
+
+func (m *memoryHistory) Record(entries ...history.Entry) error {
+	m.entries = append(m.entries, entries...)
+	return nil
+}
+
+// scriptedPrompter answers the commit confirmations with actions, in order.
+type scriptedPrompter struct {
+	stubPrompter
+	actions []Action
+}
+
+func (s *scriptedPrompter) GetConfirmation(string, bool) (Action, string, error) {
+	action := s.actions[0]
+	s.actions = s.actions[1:]
+	return action, "", nil
+}
+
+func TestRunCommitLoopRecordsHistoryAndRedo(t *testing.T) {
+	gitClient := &redoGit{
+		changes: git.StagedChanges{
+			Diff:  "diff --git a/login.go b/login.go\n+func Login() {}\n",
+			Files: []string{"login.go"},
+		},
+		commitErr: errors.New("pre-commit hook failed"),
+	}
+	recorder := &memoryHistory{}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{
+		git:      gitClient,
+		llm:      &stubLLM{replies: []string{"feat: add login", "feat(auth): add login"}},
+		cfg:      &config.Config{Model: "gpt-4o-mini"},
+		prompter: &scriptedPrompter{actions: []Action{ActionRegenerate, ActionCommit}},
+		history:  recorder,
+		opts:     CommitOptions{NoSignoff: true, ErrWriter: &errOut, OutWriter: &out},
+	}
+
+	diff, files, err := flow.getStagedChanges()
+	require.NoError(t, err)
+	err = flow.runCommitLoop(diff, files, flow.performCommit)
+	assert.ErrorContains(t, err, "pre-commit hook failed")
+
+	require.Len(t, recorder.entries, 2)
+	assert.Equal(t, history.StatusRegenerated, recorder.entries[0].Status)
+	failed := recorder.entries[1]
+	assert.Equal(t, history.StatusFailed, failed.Status)
+	assert.Equal(t, "feat(auth): add login", failed.Message)
+	assert.Equal(t, "feature/login", failed.Branch)
+	assert.Equal(t, "gpt-4o-mini", failed.Model)
+	assert.Equal(t, history.HashDiff(diff), failed.DiffHash)
+
+	gitClient.commitErr = nil
+	flow.prompter = &stubPrompter{}
+	errOut.Reset()
+	flow.cfg.Model = "gpt-4o"
+	require.NoError(t, flow.Redo(failed))
+	assert.Equal(t, []string{"feat(auth): add login"}, gitClient.committed)
+	assert.NotContains(t, errOut.String(), "staged changes differ")
+	assert.Equal(t, history.StatusCommitted, recorder.entries[2].Status)
+	assert.Equal(t, "gpt-4o-mini", recorder.entries[2].Model)
+
+	assert.ErrorIs(t, flow.Redo(recorder.entries[2]), ErrNothingToRedo)
+
+	gitClient.changes.Diff += "+func Logout() {}\n"
+	require.NoError(t, flow.Redo(failed))
+	assert.Contains(t, errOut.String(), "staged changes differ")
+}
+
+func TestRunCommitLoopRecordsCancelledMessage(t *testing.T) {
+	recorder := &memoryHistory{}
+	var errOut, out bytes.Buffer
+	flow := &CommitFlow{
+		git:      &redoGit{},
+		llm:      &stubLLM{replies: []string{"fix: handle nil user"}},
+		cfg:      &config.Config{},
+		prompter: &scriptedPrompter{actions: []Action{ActionCancel}},
+		history:  recorder,
+		opts:     CommitOptions{ErrWriter: &errOut, OutWriter: &out},
+	}
+
+	err := flow.runCommitLoop("diff --git a/user.go b/user.go\n", []string{"user.go"}, flow.performCommit)
+	assert.ErrorIs(t, err, errCommitCancelled)
+	require.Len(t, recorder.entries, 1)
+	assert.Equal(t, history.StatusCancelled, recorder.entries[0].Status)
+	assert.True(t, recorder.entries[0].Redoable())
+}
@@ -6,12 +6,18 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
 )
 
 type CloneOptions struct {
-	Name     string // Custom project name
-	Upstream string // Upstream URL for fork workflow
+	Name         string // Custom project name
+	Upstream     string // Upstream URL for fork workflow
+	Depth        int    // Fetch only this many commits per branch; 0 fetches full history
+	Filter       string // Partial clone filter, such as blob:none
+	SingleBranch bool   // Fetch only the default branch
 }
 
 func (c *Client) Clone(repoURL string, opts CloneOptions) (Report, error) {
@@ -30,22 +36,29 @@ func (c *Client) Clone(repoURL string, opts CloneOptions) (Report, error) {
 		}
 	}
 
-	if _, err := os.Stat(projectName); err == nil {
+	projectDir, err := c.absPath(projectName)
+	if err != nil {
+		return report, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if _, err := os.Stat(projectDir); err == nil {
 		return report, fmt.Errorf("directory already exists: %s", projectName)
 	}
 
 	report.Info(fmt.Sprintf("Cloning %s as bare + worktree structure...", repoURL))
 	report.Info("")
 
-	if err := os.MkdirAll(projectName, 0755); err != nil {
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
 		return report, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	bareDir := filepath.Join(projectName, ".bare")
+	bareDir := filepath.Join(projectDir, ".bare")
 
-	args := []string{"clone", "--bare", "--progress", repoURL, bareDir}
+	args := []string{"clone", "--bare", "--progress"}
+	args = append(args, opts.cloneArgs()...)
+	args = append(args, repoURL, bareDir)
 	if err := c.runner.RunStreamingLogged(args...); err != nil {
-		os.RemoveAll(projectName)
+		os.RemoveAll(projectDir)
 		return report, fmt.Errorf("failed to clone repository: %w", err)
 	}
 	report.Info("")
@@ -55,25 +68,31 @@ func (c *Client) Clone(repoURL string, opts CloneOptions) (Report, error) {
 		defaultBranch = "main"
 	}
 
-	absProjectDir, err := filepath.Abs(projectName)
-	if err != nil {
-		os.RemoveAll(projectName)
-		return report, fmt.Errorf("failed to get absolute path: %w", err)
-	}
-	mainWorktree := filepath.Join(absProjectDir, defaultBranch)
+	mainWorktree := filepath.Join(projectDir, defaultBranch)
 	args = []string{"-C", bareDir, "worktree", "add", mainWorktree, defaultBranch}
 	if err := c.runner.RunStreamingLogged(args...); err != nil {
-		os.RemoveAll(projectName)
+		os.RemoveAll(projectDir)
 		return report, fmt.Errorf("failed to create main worktree: %w", err)
 	}
 
-	configReport, err := c.configureBareRepo(bareDir, opts.Upstream)
+	configReport, err := c.configureBareRepo(bareDir, defaultBranch, opts)
 	report.Merge(configReport)
 	if err != nil {
-		os.RemoveAll(projectName)
+		os.RemoveAll(projectDir)
 		return report, err
 	}
 
+	tracking := ""
+	if opts.Upstream != "" {
+		var upstreamReport Report
+		tracking, upstreamReport, err = c.configureUpstream(bareDir, defaultBranch, opts)
+		report.Merge(upstreamReport)
+		if err != nil {
+			os.RemoveAll(projectDir)
+			return report, err
+		}
+	}
+
 	report.Info("")
 	report.Info(fmt.Sprintf("Successfully cloned to %s/", projectName))
 	report.Info("")
@@ -83,43 +102,135 @@ func (c *Client) Clone(repoURL string, opts CloneOptions) (Report, error) {
 	report.Info(fmt.Sprintf("  └── %s/           # Main worktree", defaultBranch))
 	report.Info("")
 
+	if notes := opts.notes(); len(notes) > 0 {
+		report.Info("Clone Options:")
+		for _, note := range notes {
+			report.Info("  " + note)
+		}
+		report.Info("  gmc wt add fetches base branches missing from the clone")
+		report.Info("")
+	}
+
 	if opts.Upstream != "" {
 		report.Info("Remote Configuration:")
 		report.Info("  origin   = " + repoURL + " (your fork)")
 		report.Info("  upstream = " + opts.Upstream + " (upstream)")
+		if tracking != "" {
+			report.Info(fmt.Sprintf("  %s tracks %s", defaultBranch, tracking))
+		}
 		report.Info("")
 	}
 
 	report.Info("Next steps:")
 	report.Info(fmt.Sprintf("  cd %s/%s", projectName, defaultBranch))
 	report.Info("  gmc wt add feature-name")
+	if opts.Upstream != "" {
+		report.Info(fmt.Sprintf("  gmc wt sync    # update %s from upstream and push it to your fork", defaultBranch))
+	}
 
 	return report, nil
 }
 
-func (c *Client) configureBareRepo(bareDir string, upstreamURL string) (Report, error) {
+// cloneArgs returns the git clone flags for the shallow and partial clone options.
+func (o CloneOptions) cloneArgs() []string {
+	var args []string
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	if o.Filter != "" {
+		args = append(args, "--filter", o.Filter)
+	}
+	if o.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	return args
+}
+
+// notes describes the shallow and partial clone options in the clone summary.
+func (o CloneOptions) notes() []string {
+	var notes []string
+	if o.Depth > 0 {
+		notes = append(notes, fmt.Sprintf("depth         = %d commit(s) per branch", o.Depth))
+	}
+	if o.Filter != "" {
+		notes = append(notes, "filter        = "+o.Filter+" (missing objects are fetched on demand)")
+	}
+	if o.SingleBranch {
+		notes = append(notes, "single-branch = only the default branch is fetched")
+	}
+	return notes
+}
+
+func (c *Client) configureBareRepo(bareDir string, defaultBranch string, opts CloneOptions) (Report, error) {
 	var report Report
 
-	if err := c.gitConfig(bareDir, "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+	refspec := fetchRefspec("origin", defaultBranch, opts.SingleBranch)
+	if err := c.gitConfig(bareDir, "remote.origin.fetch", refspec); err != nil {
 		return report, fmt.Errorf("failed to configure remote.origin.fetch: %w", err)
 	}
+	if opts.Depth > 0 {
+		if err := c.gitConfig(bareDir, cloneDepthKey, strconv.Itoa(opts.Depth)); err != nil {
+			return report, fmt.Errorf("failed to record the clone depth: %w", err)
+		}
+	}
 
 	if c.verbose {
 		report.Warn("Fetching remote references...")
 	}
-	_, err := c.runner.Run("-C", bareDir, "fetch", "origin")
+	_, err := c.fetch(bareDir, FetchOptions{Remote: "origin", Depth: opts.Depth})
 	if err != nil && c.verbose {
 		report.Warn(fmt.Sprintf("Warning: 'git fetch origin' failed: %v", err))
 	}
 
-	if upstreamURL != "" {
-		args := []string{"-C", bareDir, "remote", "add", "upstream", upstreamURL}
-		if _, err := c.runner.RunLogged(args...); err != nil {
-			return report, fmt.Errorf("failed to add upstream remote: %w", err)
+	return report, nil
+}
+
+// configureUpstream adds the upstream remote of a fork, fetches it and sets the local
+// default branch to track the upstream default branch, so gmc wt sync and git status
+// compare against upstream. It returns the tracked branch, such as upstream/main, or
+// "" when the fetch failed and tracking was not set.
+func (c *Client) configureUpstream(bareDir string, defaultBranch string, opts CloneOptions) (string, Report, error) {
+	var report Report
+
+	args := []string{"-C", bareDir, "remote", "add", "upstream", opts.Upstream}
+	if _, err := c.runner.RunLogged(args...); err != nil {
+		return "", report, fmt.Errorf("failed to add upstream remote: %w", err)
+	}
+	refspec := fetchRefspec("upstream", defaultBranch, opts.SingleBranch)
+	if err := c.gitConfig(bareDir, "remote.upstream.fetch", refspec); err != nil {
+		return "", report, fmt.Errorf("failed to configure remote.upstream.fetch: %w", err)
+	}
+
+	if _, err := c.fetch(bareDir, FetchOptions{Remote: "upstream", Depth: opts.Depth}); err != nil {
+		report.Warn(fmt.Sprintf("Warning: %v; %s does not track upstream yet", err, defaultBranch))
+		report.Warn(fmt.Sprintf("Run 'git branch --set-upstream-to=upstream/%s %s' after fetching upstream",
+			defaultBranch, defaultBranch))
+		return "", report, nil
+	}
+
+	upstreamBranch := defaultBranch
+	if !opts.SingleBranch {
+		// upstream's default branch can differ from the fork's, such as after a rename.
+		if _, err := c.runner.RunLogged("-C", bareDir, "remote", "set-head", "upstream", "--auto"); err == nil {
+			if head := c.gitSymbolicRef(bareDir, "refs/remotes/upstream/HEAD"); head != "" {
+				upstreamBranch = localBranchName(head)
+			}
 		}
 	}
+	tracking := "upstream/" + upstreamBranch
+	result, err := c.runner.RunLogged("-C", bareDir, "branch", "--set-upstream-to="+tracking, defaultBranch)
+	if err != nil {
+		return "", report, gitutil.WrapGitError("failed to set "+defaultBranch+" to track "+tracking, result, err)
+	}
+	return tracking, report, nil
+}
 
-	return report, nil
+// fetchRefspec returns the fetch refspec of remote: every branch, or only branch.
+func fetchRefspec(remote string, branch string, single bool) string {
+	if single {
+		return "+refs/heads/" + branch + ":refs/remotes/" + remote + "/" + branch
+	}
+	return "+refs/heads/*:refs/remotes/" + remote + "/*"
 }
 
 func (c *Client) gitConfig(repoDir string, key string, value string) error {
@@ -0,0 +1,217 @@
+package worktree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Reasons a worktree is a clean candidate.
+const (
+	// CleanMerged is a branch whose commits are all in the base branch.
+	CleanMerged = "merged"
+	// CleanUpstreamGone is a branch whose upstream was deleted, as forges do when a
+	// pull request is merged, and then pruned by git fetch --prune. It catches the
+	// squash and rebase merges that leave the branch's own commits out of the base.
+	CleanUpstreamGone = "upstream-gone"
+)
+
+// CleanOptions controls gmc wt clean.
+type CleanOptions struct {
+	// BaseBranch is the branch the merge check runs against. Empty uses the default
+	// branch of origin or upstream, or HEAD, as gmc wt prune does.
+	BaseBranch string
+	// Force includes worktrees with uncommitted changes, and upstream-gone branches
+	// with commits that are on no remote branch, and removes them anyway.
+	Force bool
+}
+
+// CleanCandidate is a worktree gmc wt clean removes together with its branch.
+type CleanCandidate struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+	// Reason is CleanMerged or CleanUpstreamGone.
+	Reason string `json:"reason"`
+	// Status is "clean", or the uncommitted changes --force removes.
+	Status string `json:"status"`
+}
+
+// CleanPlan is what PlanClean found: the candidates, and in Report, the worktrees
+// it skipped and why.
+type CleanPlan struct {
+	Report
+	Base       string
+	Candidates []CleanCandidate
+}
+
+// CleanResult is what Clean did.
+type CleanResult struct {
+	Report
+	Removed []CleanCandidate
+	// Failed maps the name of each candidate that could not be removed to the error.
+	Failed map[string]error
+}
+
+// PlanClean finds the worktrees whose branch is merged into the base branch or whose
+// upstream is gone, without removing anything. Protected, locked and detached
+// worktrees, and the base branch, are left out as gmc wt prune leaves them out; so
+// are worktrees with uncommitted changes, and upstream-gone branches with commits
+// that were never pushed, unless opts.Force is set.
+func (c *Client) PlanClean(opts CleanOptions) (CleanPlan, error) {
+	var plan CleanPlan
+
+	if err := c.ensureInit(); err != nil {
+		return plan, fmt.Errorf("failed to find worktree root: %w", err)
+	}
+	baseBranch, err := c.resolveBaseBranch(c.worktreeRoot, opts.BaseBranch)
+	if err != nil {
+		return plan, err
+	}
+	plan.Base = localBranchName(baseBranch)
+
+	candidates, repoDir, err := c.collectPruneCandidates(c.worktreeRoot, baseBranch, &plan.Report)
+	if err != nil {
+		return plan, err
+	}
+	gone := c.goneUpstreams(repoDir)
+
+	for _, cand := range candidates {
+		reason := ""
+		merged, err := c.isBranchMerged(c.worktreeRoot, cand.wt.Branch, baseBranch)
+		switch {
+		case err != nil:
+			plan.Warn(fmt.Sprintf("Skipped %s: %v", cand.name, err))
+			continue
+		case merged:
+			reason = CleanMerged
+		case gone[cand.wt.Branch]:
+			reason = CleanUpstreamGone
+		default:
+			continue
+		}
+
+		if reason == CleanUpstreamGone && !opts.Force {
+			unpushed, err := c.unpushedCommits(repoDir, cand.wt.Branch, baseBranch)
+			if err != nil {
+				plan.Warn(fmt.Sprintf("Skipped %s: %v", cand.name, err))
+				continue
+			}
+			if unpushed > 0 {
+				plan.Warn(fmt.Sprintf("Skipped %s: %s, but %d commit(s) are on no remote branch (use --force)",
+					cand.name, reason, unpushed))
+				continue
+			}
+		}
+
+		status := c.GetWorktreeStatus(cand.wt.Path)
+		if status != "clean" && !opts.Force {
+			plan.Warn(fmt.Sprintf("Skipped %s: %s, but the worktree has uncommitted changes (use --force)",
+				cand.name, reason))
+			continue
+		}
+		plan.Candidates = append(plan.Candidates, CleanCandidate{
+			Name: cand.name, Path: cand.wt.Path, Branch: cand.wt.Branch, Reason: reason, Status: status,
+		})
+	}
+	return plan, nil
+}
+
+// Clean removes the worktree and deletes the branch of every candidate, going on
+// past failures. With force, worktrees with uncommitted changes are removed too.
+func (c *Client) Clean(candidates []CleanCandidate, force bool) CleanResult {
+	result := CleanResult{Failed: make(map[string]error)}
+	if len(candidates) == 0 {
+		return result
+	}
+	if err := c.ensureInit(); err != nil {
+		for _, cand := range candidates {
+			result.Failed[cand.Name] = err
+		}
+		return result
+	}
+	defer c.InvalidateList()
+
+	for _, cand := range candidates {
+		if err := c.removeWorktreeAndBranch(c.repoDir, cand.Path, cand.Branch, force, &result.Report); err != nil {
+			result.Failed[cand.Name] = err
+			continue
+		}
+		result.Removed = append(result.Removed, cand)
+	}
+	return result
+}
+
+// goneUpstreams returns the local branches whose configured upstream no longer
+// exists. A failure to read the branches reports none gone.
+func (c *Client) goneUpstreams(repoDir string) map[string]bool {
+	gone := make(map[string]bool)
+	result, err := c.runner.Run("-C", repoDir, "for-each-ref",
+		"--format=%(refname:short)%00%(upstream:track)", "refs/heads")
+	if err != nil {
+		return gone
+	}
+	for _, line := range strings.Split(result.StdoutString(true), "\n") {
+		branch, track, ok := strings.Cut(line, "\x00")
+		if ok && track == "[gone]" {
+			gone[branch] = true
+		}
+	}
+	return gone
+}
+
+// unpushedCommits counts the commits of branch that are neither in base nor on any
+// remote-tracking branch, such as commits added after the pull request was merged.
+// Commits whose changes reached base through a rebase or squash merge do not count.
+func (c *Client) unpushedCommits(repoDir, branch, base string) (int, error) {
+	result, err := c.runner.Run("-C", repoDir, "rev-list", "--count", branch, "--not", base, "--remotes")
+	if err != nil {
+		return 0, fmt.Errorf("failed to count the unpushed commits of %s: %w", branch, err)
+	}
+	count, err := strconv.Atoi(result.StdoutString(true))
+	if err != nil || count == 0 {
+		return count, err
+	}
+	merged, err := c.changesInBase(repoDir, branch, base)
+	if err != nil || merged {
+		return 0, err
+	}
+	return count, nil
+}
+
+// changesInBase reports whether base has the changes of branch: each commit
+// rebased onto it, or all of them as one squashed commit. It compares patches the
+// way git cherry does.
+func (c *Client) changesInBase(repoDir, branch, base string) (bool, error) {
+	if picked, err := c.allCherryPicked(repoDir, base, branch); err != nil || picked {
+		return picked, err
+	}
+
+	mergeBase, err := c.mergeBase(repoDir, base, branch)
+	if err != nil {
+		return false, err
+	}
+	// Squash the branch into one dangling commit on its merge base and look for
+	// the same patch in base.
+	squashed, err := c.runner.Run("-C", repoDir, "commit-tree", branch+"^{tree}",
+		"-p", mergeBase, "-m", "squash "+branch)
+	if err != nil {
+		return false, fmt.Errorf("failed to squash %s: %w", branch, err)
+	}
+	return c.allCherryPicked(repoDir, base, squashed.StdoutString(true))
+}
+
+// allCherryPicked reports whether every commit of head since its merge base with
+// upstream has an equivalent patch in upstream.
+func (c *Client) allCherryPicked(repoDir, upstream, head string) (bool, error) {
+	result, err := c.runner.Run("-C", repoDir, "cherry", upstream, head)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare %s with %s: %w", head, upstream, err)
+	}
+	for _, line := range strings.Split(result.StdoutString(true), "\n") {
+		if strings.HasPrefix(line, "+") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
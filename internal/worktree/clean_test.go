@@ -0,0 +1,80 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanCleanAndClean(t *testing.T) {
+	repoDir := initTestRepo(t)
+	remoteDir := initBareRepo(t)
+	runGit(t, repoDir, "remote", "add", "origin", remoteDir)
+	runGit(t, repoDir, "push", "-q", "origin", "main")
+
+	root := t.TempDir()
+	for _, name := range []string{"merged", "squashed", "open", "dirty", "unpushed"} {
+		runGit(t, repoDir, "worktree", "add", "-q", "-b", name, filepath.Join(root, name))
+	}
+	for _, name := range []string{"squashed", "open", "unpushed"} {
+		writeFile(t, filepath.Join(root, name, name+".md"), name)
+		runGit(t, filepath.Join(root, name), "add", ".")
+		runGit(t, filepath.Join(root, name), "commit", "-q", "-m", name)
+		runGit(t, filepath.Join(root, name), "push", "-q", "-u", "origin", name)
+	}
+	// The pull requests of squashed and unpushed were squash merged and their
+	// branches deleted, but unpushed has a newer commit.
+	for _, name := range []string{"squashed", "unpushed"} {
+		runGit(t, repoDir, "merge", "-q", "--squash", name)
+		runGit(t, repoDir, "commit", "-q", "-m", name+" (#1)")
+		runGit(t, remoteDir, "branch", "-D", name)
+	}
+	runGit(t, repoDir, "push", "-q", "origin", "main")
+	writeFile(t, filepath.Join(root, "unpushed", "later.md"), "later")
+	runGit(t, filepath.Join(root, "unpushed"), "add", ".")
+	runGit(t, filepath.Join(root, "unpushed"), "commit", "-q", "-m", "later")
+	runGit(t, repoDir, "fetch", "-q", "--prune", "origin")
+	writeFile(t, filepath.Join(root, "dirty", "WIP.md"), "wip")
+
+	chdir(t, repoDir)
+	client := NewClient(Options{})
+	plan, err := client.PlanClean(CleanOptions{})
+	if err != nil {
+		t.Fatalf("PlanClean() error = %v", err)
+	}
+	if plan.Base != "main" {
+		t.Errorf("Base = %q, want main", plan.Base)
+	}
+	reasons := make(map[string]string)
+	for _, cand := range plan.Candidates {
+		reasons[cand.Branch] = cand.Reason
+	}
+	if len(reasons) != 2 || reasons["merged"] != CleanMerged || reasons["squashed"] != CleanUpstreamGone {
+		t.Fatalf("candidates = %+v, want merged and squashed", plan.Candidates)
+	}
+	if len(plan.Events) != 2 {
+		t.Errorf("events = %+v, want warnings for the dirty and unpushed worktrees", plan.Events)
+	}
+
+	forced, err := client.PlanClean(CleanOptions{Force: true})
+	if err != nil {
+		t.Fatalf("PlanClean(Force) error = %v", err)
+	}
+	if len(forced.Candidates) != 4 {
+		t.Errorf("PlanClean(Force) candidates = %+v, want the dirty and unpushed worktrees too", forced.Candidates)
+	}
+
+	result := client.Clean(plan.Candidates, false)
+	if len(result.Failed) != 0 || len(result.Removed) != 2 {
+		t.Fatalf("Clean() removed %+v, failed %v", result.Removed, result.Failed)
+	}
+	for _, name := range []string{"merged", "squashed"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("worktree %s still exists", name)
+		}
+	}
+	branches := runGit(t, repoDir, "branch", "--format=%(refname:short)")
+	if branches != "dirty\nmain\nopen\nunpushed\n" {
+		t.Errorf("branches after Clean() = %q, want dirty, main, open and unpushed", branches)
+	}
+}
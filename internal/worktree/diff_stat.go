@@ -74,16 +74,15 @@ func (c *Client) WorktreeDiffStat(path, base string) (DiffStat, error) {
 		return DiffStat{}, errors.New("diff base cannot be empty")
 	}
 
-	result, err := c.runner.Run("-C", path, "merge-base", base, "HEAD")
+	mergeBase, err := c.mergeBase(path, base, "HEAD")
 	if err != nil {
-		return DiffStat{}, fmt.Errorf("failed to find merge base with %s: %w", base, err)
+		return DiffStat{}, err
 	}
-	mergeBase := result.StdoutString(true)
 	if mergeBase == "" {
 		return DiffStat{}, fmt.Errorf("failed to find merge base with %s", base)
 	}
 
-	result, err = c.runner.Run("-C", path, "diff", "--numstat", "-z", mergeBase)
+	result, err := c.runner.Run("-C", path, "diff", "--numstat", "-z", mergeBase)
 	if err != nil {
 		return DiffStat{}, fmt.Errorf("failed to collect diff stat against %s: %w", mergeBase, err)
 	}
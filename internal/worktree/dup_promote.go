@@ -47,7 +47,7 @@ func (c *Client) resolveDupTaskFiles(parentRoot string, paths []string) ([]dupTa
 		absPath := path
 		if !filepath.IsAbs(absPath) {
 			var err error
-			absPath, err = filepath.Abs(absPath)
+			absPath, err = c.absPath(absPath)
 			if err != nil {
 				return nil, fmt.Errorf("failed to resolve task file %s: %w", path, err)
 			}
@@ -204,7 +204,7 @@ func (c *Client) resolvePromoteCandidate(candidate string) (string, error) {
 	absCandidate := candidate
 	if !filepath.IsAbs(absCandidate) {
 		var err error
-		absCandidate, err = filepath.Abs(candidate)
+		absCandidate, err = c.absPath(candidate)
 		if err != nil {
 			return c.resolveWorktreePath(candidate)
 		}
@@ -0,0 +1,136 @@
+package worktree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ExecOptions controls running a shell command across worktrees.
+type ExecOptions struct {
+	Command  string
+	Parallel int
+	// Only and Exclude are glob patterns matched against worktree directory names.
+	Only    []string
+	Exclude []string
+}
+
+// ExecResult is the outcome of a command in one worktree.
+type ExecResult struct {
+	Worktree   string `json:"worktree"`
+	Path       string `json:"path"`
+	Branch     string `json:"branch"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMS int64  `json:"duration_ms"`
+	Output     string `json:"output"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Passed reports whether the command exited successfully.
+func (r ExecResult) Passed() bool {
+	return r.ExitCode == 0 && r.Error == ""
+}
+
+// Exec runs opts.Command with "sh -c" in every matching worktree. Results keep the
+// worktree order; onDone, when set, is called as each worktree finishes.
+func (c *Client) Exec(opts ExecOptions, onDone func(ExecResult)) ([]ExecResult, error) {
+	if opts.Command == "" {
+		return nil, errors.New("command is required")
+	}
+	for _, pattern := range append(append([]string{}, opts.Only...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid worktree pattern %q: %w", pattern, err)
+		}
+	}
+
+	targets, err := c.managedWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	targets = filterExecTargets(targets, opts.Only, opts.Exclude)
+	if len(targets) == 0 {
+		return nil, errors.New("no worktrees match the filters")
+	}
+	return execInWorktrees(targets, opts, onDone), nil
+}
+
+func filterExecTargets(worktrees []Info, only, exclude []string) []Info {
+	var targets []Info
+	for _, wt := range worktrees {
+		name := filepath.Base(wt.Path)
+		if len(only) > 0 && !matchAny(only, name) {
+			continue
+		}
+		if matchAny(exclude, name) {
+			continue
+		}
+		targets = append(targets, wt)
+	}
+	return targets
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func execInWorktrees(targets []Info, opts ExecOptions, onDone func(ExecResult)) []ExecResult {
+	parallel := max(opts.Parallel, 1)
+	results := make([]ExecResult, len(targets))
+	slots := make(chan struct{}, parallel)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, wt := range targets {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result := runInWorktree(wt, opts.Command)
+			results[i] = result
+			if onDone != nil {
+				mu.Lock()
+				onDone(result)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func runInWorktree(wt Info, command string) ExecResult {
+	result := ExecResult{Worktree: filepath.Base(wt.Path), Path: wt.Path, Branch: wt.Branch}
+
+	var output bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = wt.Path
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err := cmd.Run()
+	result.DurationMS = time.Since(start).Milliseconds()
+	result.Output = output.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.ExitCode = -1
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterExecTargets(t *testing.T) {
+	worktrees := []Info{{Path: "/repo/main"}, {Path: "/repo/feat-a"}, {Path: "/repo/feat-b"}, {Path: "/repo/fix-c"}}
+
+	names := func(infos []Info) []string {
+		var out []string
+		for _, wt := range infos {
+			out = append(out, filepath.Base(wt.Path))
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"main", "feat-a", "feat-b", "fix-c"}, names(filterExecTargets(worktrees, nil, nil)))
+	assert.Equal(t, []string{"feat-a", "feat-b"}, names(filterExecTargets(worktrees, []string{"feat-*"}, nil)))
+	assert.Equal(t, []string{"feat-a", "fix-c"},
+		names(filterExecTargets(worktrees, []string{"feat-*", "fix-*"}, []string{"feat-b"})))
+}
+
+func TestExecInWorktrees(t *testing.T) {
+	root := t.TempDir()
+	var targets []Info
+	for _, name := range []string{"ok", "fails"} {
+		dir := filepath.Join(root, name)
+		require.NoError(t, os.Mkdir(dir, 0o755))
+		targets = append(targets, Info{Path: dir, Branch: name})
+	}
+
+	var done atomic.Int32
+	results := execInWorktrees(targets, ExecOptions{
+		Command:  `echo "in $(basename "$PWD")"; [ "$(basename "$PWD")" = ok ] || exit 3`,
+		Parallel: 2,
+	}, func(ExecResult) { done.Add(1) })
+
+	require.Len(t, results, 2)
+	assert.Equal(t, int32(2), done.Load())
+
+	assert.Equal(t, "ok", results[0].Worktree)
+	assert.True(t, results[0].Passed())
+	assert.Equal(t, "in ok\n", results[0].Output)
+
+	assert.Equal(t, "fails", results[1].Worktree)
+	assert.False(t, results[1].Passed())
+	assert.Equal(t, 3, results[1].ExitCode)
+}
+
+func TestExecInWorktreesRunsInParallel(t *testing.T) {
+	root := t.TempDir()
+	var targets []Info
+	for _, name := range []string{"a", "b", "c"} {
+		dir := filepath.Join(root, name)
+		require.NoError(t, os.Mkdir(dir, 0o755))
+		targets = append(targets, Info{Path: dir})
+	}
+	barrier := t.TempDir()
+
+	// Each command checks in, then waits for the other two. Run one at a time, the
+	// first would give up waiting and fail.
+	command := `touch "` + barrier + `/$(basename "$PWD")"
+for i in $(seq 100); do
+	[ "$(ls "` + barrier + `" | wc -l)" -ge 3 ] && exit 0
+	sleep 0.1
+done
+exit 1`
+	results := execInWorktrees(targets, ExecOptions{Command: command, Parallel: 3}, nil)
+	require.Len(t, results, 3)
+	for _, result := range results {
+		assert.True(t, result.Passed(), "%s did not meet the others", result.Worktree)
+	}
+}
@@ -0,0 +1,143 @@
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// FetchAllRemotes selects every configured remote in FetchOptions.
+const FetchAllRemotes = "all"
+
+// Ref update statuses reported by Fetch.
+const (
+	RefNew     = "new"
+	RefUpdated = "updated"
+	RefDeleted = "deleted"
+)
+
+// FetchOptions controls gmc wt fetch.
+type FetchOptions struct {
+	// Remote is the remote to fetch, or FetchAllRemotes. Empty means all remotes.
+	Remote string
+	Prune  bool
+	// Depth limits the fetch to that many commits per branch. 0 fetches as git does,
+	// which keeps the history a shallow clone has, or that it was deepened to.
+	Depth int
+	// Progress receives git's progress output. When nil, the output is captured and
+	// only shown on failure.
+	Progress io.Writer
+}
+
+// RefUpdate is a remote-tracking ref or tag that a fetch created, moved or deleted.
+type RefUpdate struct {
+	Ref    string `json:"ref"`
+	Status string `json:"status"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+// FetchResult lists the remotes fetched and the refs that changed.
+type FetchResult struct {
+	Remotes []string    `json:"remotes"`
+	Updated []RefUpdate `json:"updated"`
+}
+
+// Fetch fetches into the repository shared by all worktrees, so the result does not
+// depend on which worktree gmc runs from.
+func (c *Client) Fetch(opts FetchOptions) (FetchResult, error) {
+	if err := c.ensureInit(); err != nil {
+		return FetchResult{}, fmt.Errorf("failed to find worktree root: %w", err)
+	}
+	return c.fetch(c.repoDir, opts)
+}
+
+func (c *Client) fetch(repoDir string, opts FetchOptions) (FetchResult, error) {
+	remotes, err := c.fetchRemotes(repoDir, opts.Remote)
+	if err != nil {
+		return FetchResult{}, err
+	}
+
+	before := c.fetchedRefs(repoDir)
+	args := []string{"-C", repoDir, "fetch"}
+	if opts.Prune {
+		args = append(args, "--prune")
+	}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	target := remotes[0]
+	if len(remotes) > 1 {
+		target = "all remotes"
+		args = append(args, "--all")
+	} else {
+		args = append(args, remotes[0])
+	}
+
+	if opts.Progress != nil {
+		if err := c.runner.RunWithWriters(true, opts.Progress, opts.Progress, args...); err != nil {
+			return FetchResult{}, fmt.Errorf("failed to fetch %s: %w", target, err)
+		}
+	} else {
+		result, err := c.runner.RunLogged(args...)
+		if err != nil {
+			return FetchResult{}, gitutil.WrapGitError("failed to fetch "+target, result, err)
+		}
+	}
+
+	return FetchResult{Remotes: remotes, Updated: diffRefs(before, c.fetchedRefs(repoDir))}, nil
+}
+
+// fetchRemotes resolves the remote option to the remotes a fetch covers.
+func (c *Client) fetchRemotes(repoDir string, remote string) ([]string, error) {
+	if remote != "" && remote != FetchAllRemotes {
+		if !c.remoteExists(repoDir, remote) {
+			return nil, fmt.Errorf("remote '%s' not found", remote)
+		}
+		return []string{remote}, nil
+	}
+
+	remotes := strings.Fields(c.getGitOutput(repoDir, "remote"))
+	if len(remotes) == 0 {
+		return nil, errors.New("no remotes configured")
+	}
+	return remotes, nil
+}
+
+// fetchedRefs maps the remote-tracking refs and tags of repoDir to their object names.
+func (c *Client) fetchedRefs(repoDir string) map[string]string {
+	refs := make(map[string]string)
+	output := c.getGitOutput(repoDir, "for-each-ref", "--format=%(objectname) %(refname)", "refs/remotes", "refs/tags")
+	for _, line := range strings.Split(output, "\n") {
+		hash, ref, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok {
+			refs[ref] = hash
+		}
+	}
+	return refs
+}
+
+func diffRefs(before, after map[string]string) []RefUpdate {
+	updates := []RefUpdate{}
+	for ref, hash := range after {
+		old, existed := before[ref]
+		switch {
+		case !existed:
+			updates = append(updates, RefUpdate{Ref: ref, Status: RefNew, New: hash})
+		case old != hash:
+			updates = append(updates, RefUpdate{Ref: ref, Status: RefUpdated, Old: old, New: hash})
+		}
+	}
+	for ref, hash := range before {
+		if _, ok := after[ref]; !ok {
+			updates = append(updates, RefUpdate{Ref: ref, Status: RefDeleted, Old: hash})
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Ref < updates[j].Ref })
+	return updates
+}
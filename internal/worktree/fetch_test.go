@@ -0,0 +1,79 @@
+package worktree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFetchReportsRefUpdates(t *testing.T) {
+	originDir := initBareRepo(t)
+	sourceDir := initTestRepo(t)
+	runGit(t, sourceDir, "remote", "add", "origin", originDir)
+	runGit(t, sourceDir, "push", "origin", "main", "main:refs/heads/stale")
+
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "remote", "add", "origin", originDir)
+	runGit(t, repoDir, "fetch", "origin")
+	oldMain := strings.TrimSpace(runGit(t, repoDir, "rev-parse", "refs/remotes/origin/main"))
+
+	runGit(t, sourceDir, "commit", "--allow-empty", "-m", "advance")
+	runGit(t, sourceDir, "push", "origin", "main", "main:refs/heads/feature", ":refs/heads/stale")
+	newMain := strings.TrimSpace(runGit(t, sourceDir, "rev-parse", "main"))
+
+	client := NewClient(Options{})
+	var progress bytes.Buffer
+	result, err := client.fetch(repoDir, FetchOptions{Remote: "origin", Prune: true, Progress: &progress})
+	if err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+
+	if len(result.Remotes) != 1 || result.Remotes[0] != "origin" {
+		t.Errorf("Remotes = %v, want [origin]", result.Remotes)
+	}
+	want := []RefUpdate{
+		{Ref: "refs/remotes/origin/feature", Status: RefNew, New: newMain},
+		{Ref: "refs/remotes/origin/main", Status: RefUpdated, Old: oldMain, New: newMain},
+		{Ref: "refs/remotes/origin/stale", Status: RefDeleted, Old: oldMain},
+	}
+	if len(result.Updated) != len(want) {
+		t.Fatalf("Updated = %+v, want %+v", result.Updated, want)
+	}
+	for i := range want {
+		if result.Updated[i] != want[i] {
+			t.Errorf("Updated[%d] = %+v, want %+v", i, result.Updated[i], want[i])
+		}
+	}
+
+	result, err = client.fetch(repoDir, FetchOptions{})
+	if err != nil {
+		t.Fatalf("second fetch() error = %v", err)
+	}
+	if len(result.Updated) != 0 {
+		t.Errorf("second fetch Updated = %+v, want none", result.Updated)
+	}
+}
+
+func TestFetchRemoteValidation(t *testing.T) {
+	repoDir := initTestRepo(t)
+	client := NewClient(Options{})
+
+	if _, err := client.fetch(repoDir, FetchOptions{}); err == nil || !strings.Contains(err.Error(), "no remotes") {
+		t.Errorf("fetch() without remotes error = %v, want no remotes", err)
+	}
+
+	runGit(t, repoDir, "remote", "add", "origin", initBareRepo(t))
+	runGit(t, repoDir, "remote", "add", "upstream", initBareRepo(t))
+	if _, err := client.fetch(repoDir, FetchOptions{Remote: "fork"}); err == nil ||
+		!strings.Contains(err.Error(), "remote 'fork' not found") {
+		t.Errorf("fetch(fork) error = %v, want not found", err)
+	}
+
+	remotes, err := client.fetchRemotes(repoDir, FetchAllRemotes)
+	if err != nil {
+		t.Fatalf("fetchRemotes() error = %v", err)
+	}
+	if strings.Join(remotes, ",") != "origin,upstream" {
+		t.Errorf("fetchRemotes(all) = %v, want [origin upstream]", remotes)
+	}
+}
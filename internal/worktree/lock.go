@@ -0,0 +1,134 @@
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// noteFileName is the sidecar file, in a worktree's git directory, that holds its
+// note. git worktree remove deletes it with the rest of that directory.
+const noteFileName = "gmc-note"
+
+// Lock locks the named worktree with git worktree lock, so that git worktree remove
+// and prune leave it alone. reason is optional and shows in List as LockReason.
+func (c *Client) Lock(name, reason string) (Report, error) {
+	var report Report
+
+	path, err := c.resolveWorktreePath(name)
+	if err != nil {
+		return report, err
+	}
+	args := []string{"-C", c.repoDir, "worktree", "lock"}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		args = append(args, "--reason", reason)
+	}
+	args = append(args, path)
+	result, err := c.runner.RunLogged(args...)
+	if err != nil {
+		return report, gitutil.WrapGitError("failed to lock worktree", result, err)
+	}
+	c.InvalidateList()
+
+	report.Info(fmt.Sprintf("Locked worktree '%s'", name))
+	return report, nil
+}
+
+// Unlock unlocks the named worktree with git worktree unlock.
+func (c *Client) Unlock(name string) (Report, error) {
+	var report Report
+
+	path, err := c.resolveWorktreePath(name)
+	if err != nil {
+		return report, err
+	}
+	result, err := c.runner.RunLogged("-C", c.repoDir, "worktree", "unlock", path)
+	if err != nil {
+		return report, gitutil.WrapGitError("failed to unlock worktree", result, err)
+	}
+	c.InvalidateList()
+
+	report.Info(fmt.Sprintf("Unlocked worktree '%s'", name))
+	return report, nil
+}
+
+// SetNote saves a free-form note on the named worktree, such as what a .dup-N
+// candidate is trying. An empty note removes it.
+func (c *Client) SetNote(name, note string) (Report, error) {
+	var report Report
+
+	path, err := c.resolveWorktreePath(name)
+	if err != nil {
+		return report, err
+	}
+	gitDir, err := worktreeGitDir(path)
+	if err != nil {
+		return report, err
+	}
+	notePath := filepath.Join(gitDir, noteFileName)
+
+	if note = strings.TrimSpace(note); note == "" {
+		if err := os.Remove(notePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return report, fmt.Errorf("failed to remove note: %w", err)
+		}
+		report.Info(fmt.Sprintf("Cleared note on '%s'", name))
+		return report, nil
+	}
+	if err := os.WriteFile(notePath, []byte(note+"\n"), 0o644); err != nil {
+		return report, fmt.Errorf("failed to save note: %w", err)
+	}
+	report.Info(fmt.Sprintf("Saved note on '%s'", name))
+	return report, nil
+}
+
+// Note returns the note on the worktree at path, or "" when it has none.
+func (c *Client) Note(path string) string {
+	gitDir, err := worktreeGitDir(path)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, noteFileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// NoteFor returns the note on the named worktree.
+func (c *Client) NoteFor(name string) (string, error) {
+	path, err := c.resolveWorktreePath(name)
+	if err != nil {
+		return "", err
+	}
+	return c.Note(path), nil
+}
+
+// worktreeGitDir returns the git directory of the worktree at path: its .git
+// directory, or the directory its .git file points to for a linked worktree.
+func worktreeGitDir(path string) (string, error) {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git directory of %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", dotGit, err)
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", fmt.Errorf("unexpected contents in %s", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+	return gitDir, nil
+}
@@ -0,0 +1,92 @@
+package worktree
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseWorktreeListLocked(t *testing.T) {
+	input := `worktree /path/to/project/main
+HEAD abc123
+branch refs/heads/main
+
+worktree /path/to/project/.dup-1
+HEAD def456
+branch refs/heads/main-dup-1
+locked trying redis
+
+worktree /path/to/project/.dup-2
+HEAD 789abc
+branch refs/heads/main-dup-2
+locked
+`
+
+	worktrees, err := parseWorktreeList(input)
+	if err != nil {
+		t.Fatalf("parseWorktreeList() error = %v", err)
+	}
+	if worktrees[0].IsLocked {
+		t.Error("main worktree should not be locked")
+	}
+	if !worktrees[1].IsLocked || worktrees[1].LockReason != "trying redis" {
+		t.Errorf(".dup-1 = %+v, want locked with reason", worktrees[1])
+	}
+	if !worktrees[2].IsLocked || worktrees[2].LockReason != "" {
+		t.Errorf(".dup-2 = %+v, want locked without reason", worktrees[2])
+	}
+}
+
+func TestLockUnlockAndNote(t *testing.T) {
+	repoDir := initTestRepo(t)
+	wtDir := filepath.Join(filepath.Dir(repoDir), filepath.Base(repoDir)+"--exp")
+	runGit(t, repoDir, "worktree", "add", "-b", "exp", wtDir)
+	chdir(t, repoDir)
+
+	client := NewClient(Options{})
+	if _, err := client.Lock(wtDir, "agent running"); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	worktrees, err := client.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if wt := findWorktreeByBranch(worktrees, "exp"); !wt.IsLocked || wt.LockReason != "agent running" {
+		t.Fatalf("locked worktree = %+v, want locked with reason", wt)
+	}
+
+	if _, err := client.Unlock(wtDir); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	worktrees, _ = client.List()
+	if wt := findWorktreeByBranch(worktrees, "exp"); wt.IsLocked {
+		t.Fatalf("unlocked worktree = %+v, want unlocked", wt)
+	}
+
+	if got := client.Note(wtDir); got != "" {
+		t.Fatalf("Note() before SetNote = %q, want empty", got)
+	}
+	if _, err := client.SetNote(wtDir, "  trying a redis cache \n"); err != nil {
+		t.Fatalf("SetNote() error = %v", err)
+	}
+	if got := client.Note(wtDir); got != "trying a redis cache" {
+		t.Fatalf("Note() = %q, want the saved note", got)
+	}
+	if got := client.Note(repoDir); got != "" {
+		t.Fatalf("Note() of the main worktree = %q, want empty", got)
+	}
+	if _, err := client.SetNote(wtDir, ""); err != nil {
+		t.Fatalf("SetNote(\"\") error = %v", err)
+	}
+	if got := client.Note(wtDir); got != "" {
+		t.Fatalf("Note() after clearing = %q, want empty", got)
+	}
+}
+
+func findWorktreeByBranch(worktrees []Info, branch string) Info {
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			return wt
+		}
+	}
+	return Info{}
+}
@@ -0,0 +1,117 @@
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// MergeCheckOptions controls gmc wt mergecheck.
+type MergeCheckOptions struct {
+	// BaseBranch is the branch every worktree branch is checked against. Empty uses
+	// the base branch gmc wt sync updates.
+	BaseBranch string
+}
+
+// MergePair is the predicted outcome of merging two branches.
+type MergePair struct {
+	A         string   `json:"a"`
+	B         string   `json:"b"`
+	Conflicts []string `json:"conflicts"`
+}
+
+// Clean reports whether the two branches merge without conflicts.
+func (p MergePair) Clean() bool {
+	return len(p.Conflicts) == 0
+}
+
+// MergeCheck is the result of MergeCheck: the base, the worktree branches, and every
+// pair among them, each branch with the base first.
+type MergeCheck struct {
+	Base     string      `json:"base"`
+	Branches []string    `json:"branches"`
+	Pairs    []MergePair `json:"pairs"`
+}
+
+// Pair returns the pair of branches a and b, in either order.
+func (m MergeCheck) Pair(a, b string) (MergePair, bool) {
+	for _, pair := range m.Pairs {
+		if (pair.A == a && pair.B == b) || (pair.A == b && pair.B == a) {
+			return pair, true
+		}
+	}
+	return MergePair{}, false
+}
+
+// MergeCheck predicts which worktree branches conflict with the base branch and with
+// each other, with git merge-tree, without touching any worktree.
+func (c *Client) MergeCheck(opts MergeCheckOptions) (MergeCheck, error) {
+	var check MergeCheck
+
+	if err := c.ensureInit(); err != nil {
+		return check, fmt.Errorf("failed to find worktree root: %w", err)
+	}
+	check.Base = opts.BaseBranch
+	if check.Base == "" {
+		baseRef, err := c.resolveSyncBaseBranch(c.repoDir, "")
+		if err != nil {
+			return check, err
+		}
+		check.Base = localBranchName(baseRef)
+	}
+
+	worktrees, err := c.managedWorktrees()
+	if err != nil {
+		return check, err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == "" || wt.Branch == "(detached)" || wt.Branch == check.Base {
+			continue
+		}
+		check.Branches = append(check.Branches, wt.Branch)
+	}
+	if len(check.Branches) == 0 {
+		return check, errors.New("no worktree branches to check besides " + check.Base)
+	}
+
+	names := append([]string{check.Base}, check.Branches...)
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			conflicts, err := c.mergeConflicts(a, b)
+			if err != nil {
+				return check, err
+			}
+			check.Pairs = append(check.Pairs, MergePair{A: a, B: b, Conflicts: conflicts})
+		}
+	}
+	return check, nil
+}
+
+// mergeConflicts returns the files a merge of a and b would leave conflicted.
+func (c *Client) mergeConflicts(a, b string) ([]string, error) {
+	result, err := c.runner.Run("-C", c.repoDir, "merge-tree", "--write-tree", "--name-only", "--no-messages", a, b)
+	if err == nil {
+		return []string{}, nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		if strings.Contains(result.StderrString(true), "write-tree") {
+			return nil, errors.New("gmc wt mergecheck requires git 2.38 or later for merge-tree --write-tree")
+		}
+		return nil, gitutil.WrapGitError(fmt.Sprintf("failed to check merging %s and %s", a, b), result, err)
+	}
+
+	// The first line is the tree the merge would write; the conflicted files follow.
+	lines := strings.Split(result.StdoutString(true), "\n")
+	conflicts := []string{}
+	for _, line := range lines[1:] {
+		if line == "" {
+			break
+		}
+		conflicts = append(conflicts, line)
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,43 @@
+package worktree
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeCheckPredictsConflicts(t *testing.T) {
+	repoDir := initTestRepo(t)
+	root := t.TempDir()
+	for _, name := range []string{"edit-a", "edit-b", "new-file"} {
+		runGit(t, repoDir, "worktree", "add", "-b", name, filepath.Join(root, name))
+	}
+	writeFile(t, filepath.Join(root, "edit-a", "README.md"), "a")
+	runGit(t, filepath.Join(root, "edit-a"), "commit", "-am", "a")
+	writeFile(t, filepath.Join(root, "edit-b", "README.md"), "b")
+	runGit(t, filepath.Join(root, "edit-b"), "commit", "-am", "b")
+	writeFile(t, filepath.Join(root, "new-file", "NEW.md"), "new")
+	runGit(t, filepath.Join(root, "new-file"), "add", ".")
+	runGit(t, filepath.Join(root, "new-file"), "commit", "-m", "new")
+
+	chdir(t, repoDir)
+	check, err := NewClient(Options{}).MergeCheck(MergeCheckOptions{})
+	if err != nil {
+		t.Fatalf("MergeCheck() error = %v", err)
+	}
+	if check.Base != "main" || len(check.Branches) != 3 || len(check.Pairs) != 6 {
+		t.Fatalf("MergeCheck() = %+v, want main, 3 branches and 6 pairs", check)
+	}
+	for _, pair := range check.Pairs {
+		conflicting := (pair.A == "edit-a" && pair.B == "edit-b") || (pair.A == "edit-b" && pair.B == "edit-a")
+		if conflicting {
+			if len(pair.Conflicts) != 1 || pair.Conflicts[0] != "README.md" {
+				t.Errorf("%s <> %s conflicts = %v, want [README.md]", pair.A, pair.B, pair.Conflicts)
+			}
+		} else if !pair.Clean() {
+			t.Errorf("%s <> %s conflicts = %v, want none", pair.A, pair.B, pair.Conflicts)
+		}
+	}
+	if pair, ok := check.Pair("edit-b", "edit-a"); !ok || pair.Clean() {
+		t.Errorf("Pair(edit-b, edit-a) = %+v, %v, want the conflicting pair", pair, ok)
+	}
+}
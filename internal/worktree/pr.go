@@ -3,11 +3,18 @@ package worktree
 import (
 	"errors"
 	"fmt"
+	"os/exec"
+	"strconv"
 	"strings"
 
+	"github.com/samzong/gmc/internal/forge"
 	"github.com/samzong/gmc/internal/gitutil"
 )
 
+// prConfigKey is the branch config key under which AddPR records the PR number, as in
+// branch.pr/42.gmc-pr = 42.
+const prConfigKey = "gmc-pr"
+
 // DetectPRRemote auto-detects the best remote for fetching PRs
 func (c *Client) DetectPRRemote(repoDir string) (string, error) {
 	result, err := c.runner.Run("-C", repoDir, "remote")
@@ -36,9 +43,29 @@ func (c *Client) DetectPRRemote(repoDir string) (string, error) {
 	return "", fmt.Errorf("multiple remotes found (%v) but no 'upstream' or 'origin'", remotes)
 }
 
+// prHeadRef returns the ref the forge of remote publishes the head of a PR under:
+// refs/merge-requests/<n>/head on GitLab, refs/pull/<n>/head on GitHub and Gitea.
+// Remotes on hosts no forge is detected for are taken for GitHub.
+func (c *Client) prHeadRef(prNumber int, remote, repoDir string) string {
+	kind := strings.ToLower(strings.TrimSpace(c.forge))
+	if kind == "" {
+		// The configured URL, before any url.<base>.insteadOf rewrite.
+		result, err := c.runner.Run("-C", repoDir, "config", "--get", "remote."+remote+".url")
+		if err == nil {
+			if _, detected, err := forge.Resolve(result.StdoutString(true), ""); err == nil {
+				kind = detected
+			}
+		}
+	}
+	if kind == forge.KindGitLab {
+		return fmt.Sprintf("refs/merge-requests/%d/head", prNumber)
+	}
+	return fmt.Sprintf("refs/pull/%d/head", prNumber)
+}
+
 // PRExists checks if a PR exists on the remote
 func (c *Client) PRExists(prNumber int, remote, repoDir string) (bool, string, error) {
-	refPath := fmt.Sprintf("refs/pull/%d/head", prNumber)
+	refPath := c.prHeadRef(prNumber, remote, repoDir)
 
 	result, err := c.runner.Run("-C", repoDir, "ls-remote", remote, refPath)
 	if err != nil {
@@ -92,7 +119,7 @@ func (c *Client) AddPR(prNumber int, remote string) (Report, error) {
 		return report, err
 	}
 
-	refSpec := fmt.Sprintf("pull/%d/head:%s", prNumber, branchName)
+	refSpec := c.prHeadRef(prNumber, remote, ctx.repoDir) + ":" + branchName
 	report.Info(fmt.Sprintf("Fetching PR #%d from %s...", prNumber, remote))
 
 	result, err := c.runner.RunLogged("-C", ctx.repoDir, "fetch", remote, refSpec)
@@ -108,6 +135,9 @@ func (c *Client) AddPR(prNumber int, remote string) (Report, error) {
 	if err := c.ensureAddedWorktreeConfig(ctx.targetPath); err != nil {
 		return report, err
 	}
+	if err := c.recordPR(ctx.repoDir, branchName, prNumber); err != nil {
+		report.Warn(fmt.Sprintf("Warning: failed to record PR #%d: %v", prNumber, err))
+	}
 
 	sharedReport, err := c.syncSharedResourcesToPath(ctx.targetPath, true)
 	report.Merge(sharedReport)
@@ -123,3 +153,42 @@ func (c *Client) AddPR(prNumber int, remote string) (Report, error) {
 
 	return report, nil
 }
+
+// recordPR records prNumber in the config of branch, for RecordedPRs.
+func (c *Client) recordPR(repoDir, branch string, prNumber int) error {
+	key := "branch." + branch + "." + prConfigKey
+	result, err := c.runner.Run("-C", repoDir, "config", key, strconv.Itoa(prNumber))
+	if err != nil {
+		return gitutil.WrapGitError("failed to set "+key, result, err)
+	}
+	return nil
+}
+
+// RecordedPRs returns the PR numbers that AddPR recorded, by branch name.
+func (c *Client) RecordedPRs() (map[string]int, error) {
+	if err := c.ensureInit(); err != nil {
+		return nil, err
+	}
+	prs := make(map[string]int)
+	pattern := `^branch\..*\.` + prConfigKey + "$"
+	result, err := c.runner.Run("-C", c.repoDir, "config", "--get-regexp", pattern)
+	if err != nil {
+		// git config exits 1 when no key matches.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return prs, nil
+		}
+		return nil, gitutil.WrapGitError("failed to read recorded PRs", result, err)
+	}
+	for _, line := range strings.Split(result.StdoutString(true), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		branch := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), "."+prConfigKey)
+		if number, err := strconv.Atoi(value); err == nil && number > 0 {
+			prs[branch] = number
+		}
+	}
+	return prs, nil
+}
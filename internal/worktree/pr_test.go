@@ -41,13 +41,78 @@ func TestAddPRUsesAddWorktreeNamingInNormalRepo(t *testing.T) {
 	}
 }
 
+func TestAddPRRecordsPRNumber(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "remote", "add", "origin", initPRRemote(t, 42))
+	chdir(t, repoDir)
+
+	client := NewClient(Options{})
+	prs, err := client.RecordedPRs()
+	if err != nil || len(prs) != 0 {
+		t.Fatalf("RecordedPRs() before AddPR = %v, %v; want empty", prs, err)
+	}
+	if _, err := client.AddPR(42, ""); err != nil {
+		t.Fatalf("AddPR() error = %v", err)
+	}
+
+	prs, err = client.RecordedPRs()
+	if err != nil || prs["pr/42"] != 42 {
+		t.Fatalf("RecordedPRs() = %v, %v; want pr/42 recorded as 42", prs, err)
+	}
+	result := client.ReviewStates([]Info{{Branch: "pr/42"}})
+	if review := result.Reviews["pr/42"]; review.Number != 42 {
+		t.Fatalf("ReviewStates() review = %+v, want the recorded #42", review)
+	}
+}
+
+func TestAddPRFetchesTheForgeRef(t *testing.T) {
+	tests := []struct {
+		name  string
+		forge string
+		url   string
+		ref   string
+	}{
+		{name: "github", url: "https://github.com/acme/app.git", ref: "refs/pull/42/head"},
+		{name: "gitlab", url: "https://gitlab.com/acme/app.git", ref: "refs/merge-requests/42/head"},
+		{name: "gitea", url: "https://codeberg.org/acme/app.git", ref: "refs/pull/42/head"},
+		{name: "unknown host", url: "https://git.example.com/acme/app.git", ref: "refs/pull/42/head"},
+		{name: "forge override", forge: "gitlab", url: "https://git.example.com/acme/app.git",
+			ref: "refs/merge-requests/42/head"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoDir := initTestRepo(t)
+			// The remote has only the ref of its forge, under the URL of its host.
+			remoteDir := initPRRemoteRef(t, tt.ref)
+			runGit(t, repoDir, "config", "url."+remoteDir+".insteadOf", tt.url)
+			runGit(t, repoDir, "remote", "add", "origin", tt.url)
+			chdir(t, repoDir)
+
+			client := NewClient(Options{Forge: tt.forge})
+			if _, err := client.AddPR(42, ""); err != nil {
+				t.Fatalf("AddPR() error = %v", err)
+			}
+			head := runGit(t, repoDir, "rev-parse", "pr/42")
+			want := runGit(t, remoteDir, "rev-parse", tt.ref)
+			if head != want {
+				t.Fatalf("pr/42 = %q, want %s at %q", head, tt.ref, want)
+			}
+		})
+	}
+}
+
 func initPRRemote(t *testing.T, prNumber int) string {
+	t.Helper()
+	return initPRRemoteRef(t, fmt.Sprintf("refs/pull/%d/head", prNumber))
+}
+
+func initPRRemoteRef(t *testing.T, ref string) string {
 	t.Helper()
 	remoteDir := initTestRepo(t)
 	runGit(t, remoteDir, "checkout", "-b", "feature/review")
 	writeFile(t, filepath.Join(remoteDir, "review.txt"), "review")
 	runGit(t, remoteDir, "add", ".")
 	runGit(t, remoteDir, "commit", "-m", "review")
-	runGit(t, remoteDir, "update-ref", fmt.Sprintf("refs/pull/%d/head", prNumber), "HEAD")
+	runGit(t, remoteDir, "update-ref", ref, "HEAD")
 	return remoteDir
 }
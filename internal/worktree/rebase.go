@@ -0,0 +1,119 @@
+package worktree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
+	"github.com/samzong/gmc/internal/stringsutil"
+)
+
+// RebaseOptions controls gmc wt rebase.
+type RebaseOptions struct {
+	// BaseBranch is the branch to rebase onto. Empty uses the base branch gmc wt sync
+	// updates.
+	BaseBranch string
+	// Autostash stashes uncommitted changes before the rebase and restores them after.
+	Autostash bool
+}
+
+// RebaseConflictError reports a rebase stopped by conflicts. The rebase is left in
+// progress in Path so the conflicts can be resolved there.
+type RebaseConflictError struct {
+	Worktree string
+	Path     string
+	Base     string
+	Files    []string
+}
+
+func (e *RebaseConflictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rebasing %s onto %s stopped with conflicts in %d file(s):", e.Worktree, e.Base, len(e.Files))
+	for _, file := range e.Files {
+		b.WriteString("\n  " + file)
+	}
+	fmt.Fprintf(&b, "\nresolve them in %s, then run 'git rebase --continue', or 'git rebase --abort' to undo", e.Path)
+	return b.String()
+}
+
+// Rebase rebases the branch of the named worktree onto the base branch, the one
+// gmc wt sync updates unless opts.BaseBranch is set. A worktree with uncommitted
+// changes is refused unless opts.Autostash is set.
+func (c *Client) Rebase(name string, opts RebaseOptions) (Report, error) {
+	var report Report
+
+	if err := c.ensureInit(); err != nil {
+		return report, fmt.Errorf("failed to find worktree root: %w", err)
+	}
+	path, err := c.resolveWorktreePath(name)
+	if err != nil {
+		return report, err
+	}
+	branch := c.worktreeBranch(path)
+	if branch == "" || branch == "(detached)" {
+		return report, fmt.Errorf("worktree '%s' has no branch checked out", name)
+	}
+
+	base := opts.BaseBranch
+	if base == "" {
+		baseRef, err := c.resolveSyncBaseBranch(c.repoDir, "")
+		if err != nil {
+			return report, err
+		}
+		base = localBranchName(baseRef)
+	}
+	if base == branch {
+		return report, fmt.Errorf("worktree '%s' is on the base branch %s", name, base)
+	}
+
+	if status := c.GetWorktreeStatus(path); status != "clean" && !opts.Autostash {
+		return report, fmt.Errorf("worktree '%s' has uncommitted changes (%s); commit or stash them, "+
+			"or rerun with --autostash", name, status)
+	}
+
+	before := c.refHash(path, "HEAD")
+	args := []string{"-C", path, "rebase"}
+	if opts.Autostash {
+		args = append(args, "--autostash")
+	}
+	args = append(args, base)
+	result, err := c.runner.RunLogged(args...)
+	if err != nil {
+		if files := c.conflictedFiles(path); len(files) > 0 {
+			return report, &RebaseConflictError{Worktree: name, Path: path, Base: base, Files: files}
+		}
+		return report, gitutil.WrapGitError("failed to rebase "+branch+" onto "+base, result, err)
+	}
+
+	after := c.refHash(path, "HEAD")
+	if before == after {
+		report.Info(fmt.Sprintf("%s is already up to date with %s", branch, base))
+		return report, nil
+	}
+	report.Info(fmt.Sprintf("Rebased %s onto %s (%s..%s)", branch, base,
+		stringsutil.ShortHash(before, 7, "none"), stringsutil.ShortHash(after, 7, "none")))
+	return report, nil
+}
+
+// worktreeBranch returns the branch checked out in the worktree at path.
+func (c *Client) worktreeBranch(path string) string {
+	worktrees, err := c.ListCached()
+	if err != nil {
+		return ""
+	}
+	for _, wt := range worktrees {
+		if wt.Path == path {
+			return wt.Branch
+		}
+	}
+	return ""
+}
+
+// conflictedFiles lists the unmerged files of the worktree at path.
+func (c *Client) conflictedFiles(path string) []string {
+	result, err := c.runner.Run("-C", path, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	return stringsutil.SplitNonEmpty(result.StdoutString(true), "\n")
+}
@@ -0,0 +1,74 @@
+package worktree
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRebaseOntoBase(t *testing.T) {
+	repoDir := initTestRepo(t)
+	wtPath := filepath.Join(t.TempDir(), "feature")
+	runGit(t, repoDir, "worktree", "add", "-b", "feature", wtPath)
+	writeFile(t, filepath.Join(wtPath, "feature.txt"), "feature")
+	runGit(t, wtPath, "add", ".")
+	runGit(t, wtPath, "commit", "-m", "feature")
+	writeFile(t, filepath.Join(repoDir, "main.txt"), "main")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "main")
+
+	chdir(t, repoDir)
+	client := NewClient(Options{})
+
+	writeFile(t, filepath.Join(wtPath, "feature.txt"), "uncommitted")
+	_, err := client.Rebase("feature", RebaseOptions{})
+	if err == nil || !strings.Contains(err.Error(), "--autostash") {
+		t.Fatalf("Rebase() on a dirty worktree error = %v, want a hint to use --autostash", err)
+	}
+
+	report, err := client.Rebase("feature", RebaseOptions{Autostash: true})
+	if err != nil {
+		t.Fatalf("Rebase(autostash) error = %v", err)
+	}
+	if len(report.Events) == 0 || !strings.Contains(report.Events[0].Message, "Rebased feature onto main") {
+		t.Errorf("report = %+v, want a rebase summary", report.Events)
+	}
+	if _, err := client.runner.Run("-C", wtPath, "merge-base", "--is-ancestor", "main", "HEAD"); err != nil {
+		t.Error("feature is not based on main after the rebase")
+	}
+	if status := runGit(t, wtPath, "status", "--porcelain"); !strings.Contains(status, "feature.txt") {
+		t.Errorf("autostash did not restore the uncommitted change: %q", status)
+	}
+
+	report, err = client.Rebase("feature", RebaseOptions{Autostash: true})
+	if err != nil {
+		t.Fatalf("second Rebase() error = %v", err)
+	}
+	if !strings.Contains(report.Events[0].Message, "already up to date") {
+		t.Errorf("second rebase report = %+v, want already up to date", report.Events)
+	}
+}
+
+func TestRebaseReportsConflicts(t *testing.T) {
+	repoDir := initTestRepo(t)
+	wtPath := filepath.Join(t.TempDir(), "feature")
+	runGit(t, repoDir, "worktree", "add", "-b", "feature", wtPath)
+	writeFile(t, filepath.Join(wtPath, "README.md"), "feature")
+	runGit(t, wtPath, "commit", "-am", "feature")
+	writeFile(t, filepath.Join(repoDir, "README.md"), "main")
+	runGit(t, repoDir, "commit", "-am", "main")
+
+	chdir(t, repoDir)
+	_, err := NewClient(Options{}).Rebase("feature", RebaseOptions{})
+	var conflict *RebaseConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Rebase() error = %v, want a RebaseConflictError", err)
+	}
+	if len(conflict.Files) != 1 || conflict.Files[0] != "README.md" {
+		t.Errorf("conflict files = %v, want [README.md]", conflict.Files)
+	}
+	if !strings.Contains(err.Error(), "git rebase --continue") {
+		t.Errorf("error does not explain how to continue: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// RenameOptions options for renaming a worktree
+type RenameOptions struct {
+	WithBranch bool // Also rename the worktree's branch to the new name
+}
+
+// Rename moves the named worktree to the directory Add would use for newName, with
+// git worktree move so that git's pointers between the worktree and the repository
+// stay valid. Shared resources are synced again into the new path.
+func (c *Client) Rename(oldName, newName string, opts RenameOptions) (Report, error) {
+	var report Report
+
+	if newName == "" {
+		return report, errors.New("new worktree name cannot be empty")
+	}
+	if err := gitutil.ValidateBranchName(newName); err != nil {
+		return report, err
+	}
+	if err := c.ensureInit(); err != nil {
+		return report, fmt.Errorf("failed to find worktree root: %w", err)
+	}
+
+	wtInfo, err := c.resolveRenameSource(oldName)
+	if err != nil {
+		return report, err
+	}
+	targetPath := c.worktreeTargetPath(newName)
+	if _, err := os.Stat(targetPath); err == nil {
+		return report, fmt.Errorf("directory already exists: %s", targetPath)
+	}
+
+	renameBranch := opts.WithBranch && wtInfo.Branch != "" && wtInfo.Branch != "(detached)"
+	if opts.WithBranch && !renameBranch {
+		report.Warn(fmt.Sprintf("Warning: worktree '%s' has no branch to rename", oldName))
+	}
+	if renameBranch {
+		exists, err := c.branchExists(newName)
+		if err != nil {
+			return report, err
+		}
+		if exists {
+			return report, fmt.Errorf("branch already exists: %s", newName)
+		}
+	}
+
+	result, err := c.runner.RunLogged("-C", c.repoDir, "worktree", "move", wtInfo.Path, targetPath)
+	if err != nil {
+		return report, gitutil.WrapGitError("failed to move worktree", result, err)
+	}
+	c.InvalidateList()
+	report.Info(fmt.Sprintf("Renamed worktree '%s' to '%s' at %s", oldName, newName, targetPath))
+
+	if renameBranch {
+		result, err := c.runner.RunLogged("-C", c.repoDir, "branch", "-m", wtInfo.Branch, newName)
+		if err != nil {
+			return report, gitutil.WrapGitError("failed to rename branch", result, err)
+		}
+		report.Info(fmt.Sprintf("Renamed branch '%s' to '%s'", wtInfo.Branch, newName))
+	}
+
+	sharedReport, err := c.syncSharedResourcesToPath(targetPath, false)
+	report.Merge(sharedReport)
+	if err != nil {
+		report.Warn(fmt.Sprintf("Warning: failed to sync shared resources: %v", err))
+	}
+
+	return report, nil
+}
+
+// resolveRenameSource returns the worktree to rename, by its directory or by the
+// name it was added with, refusing the protected ones that Remove refuses too.
+func (c *Client) resolveRenameSource(name string) (Info, error) {
+	path, err := c.resolveWorktreePath(name)
+	if err != nil {
+		path = c.worktreeTargetPath(name)
+		if _, statErr := os.Stat(path); statErr != nil {
+			return Info{}, err
+		}
+	}
+	worktrees, err := c.ListCached()
+	if err != nil {
+		return Info{}, err
+	}
+	var wtInfo Info
+	for _, wt := range worktrees {
+		if samePath(wt.Path, path) {
+			wtInfo = wt
+			break
+		}
+	}
+	if wtInfo.Path == "" {
+		return Info{}, fmt.Errorf("worktree not found: %s", name)
+	}
+
+	pp, err := c.NewProtectionPolicy()
+	if err != nil {
+		return Info{}, err
+	}
+	if pp.IsProtected(wtInfo) {
+		return Info{}, fmt.Errorf("cannot rename protected worktree '%s' (%s)", name, pp.Reason(wtInfo))
+	}
+	if !pathWithin(c.searchRoot, wtInfo.Path) {
+		return Info{}, fmt.Errorf("worktree '%s' is external (not managed by gmc wt)", name)
+	}
+	return wtInfo, nil
+}
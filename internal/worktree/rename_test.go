@@ -0,0 +1,66 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameMovesWorktreeAndBranch(t *testing.T) {
+	repoDir := initTestRepo(t)
+	oldDir := filepath.Join(filepath.Dir(repoDir), filepath.Base(repoDir)+"--exp")
+	runGit(t, repoDir, "worktree", "add", "-b", "exp", oldDir)
+	chdir(t, repoDir)
+
+	client := NewClient(Options{})
+	if _, err := client.Rename("exp", "redis-cache", RenameOptions{WithBranch: true}); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	newDir := filepath.Join(filepath.Dir(repoDir), filepath.Base(repoDir)+"--redis-cache")
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Fatalf("old directory still exists: %v", err)
+	}
+	status := runGit(t, newDir, "status", "--short", "--branch")
+	if !strings.Contains(status, "## redis-cache") {
+		t.Fatalf("renamed worktree status = %q, want redis-cache branch", status)
+	}
+	if list := runGit(t, repoDir, "worktree", "list"); !strings.Contains(list, newDir) {
+		t.Fatalf("git worktree list = %q, want %s", list, newDir)
+	}
+}
+
+func TestRenameKeepsBranchByDefault(t *testing.T) {
+	repoDir := initTestRepo(t)
+	oldDir := filepath.Join(filepath.Dir(repoDir), filepath.Base(repoDir)+"--exp")
+	runGit(t, repoDir, "worktree", "add", "-b", "exp", oldDir)
+	chdir(t, repoDir)
+
+	client := NewClient(Options{})
+	if _, err := client.Rename("exp", "exp-2", RenameOptions{}); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	newDir := filepath.Join(filepath.Dir(repoDir), filepath.Base(repoDir)+"--exp-2")
+	status := runGit(t, newDir, "status", "--short", "--branch")
+	if !strings.Contains(status, "## exp") || strings.Contains(status, "## exp-2") {
+		t.Fatalf("renamed worktree status = %q, want the exp branch kept", status)
+	}
+}
+
+func TestRenameRejectsProtectedAndExisting(t *testing.T) {
+	repoDir := initTestRepo(t)
+	runGit(t, repoDir, "worktree", "add", "-b", "a", filepath.Join(filepath.Dir(repoDir), filepath.Base(repoDir)+"--a"))
+	runGit(t, repoDir, "worktree", "add", "-b", "b", filepath.Join(filepath.Dir(repoDir), filepath.Base(repoDir)+"--b"))
+	chdir(t, repoDir)
+
+	client := NewClient(Options{})
+	if _, err := client.Rename(filepath.Base(repoDir), "main-2", RenameOptions{}); err == nil ||
+		!strings.Contains(err.Error(), "cannot rename protected worktree") {
+		t.Fatalf("Rename(main) error = %v, want protected error", err)
+	}
+	if _, err := client.Rename("a", "b", RenameOptions{}); err == nil ||
+		!strings.Contains(err.Error(), "directory already exists") {
+		t.Fatalf("Rename(a, b) error = %v, want existing directory error", err)
+	}
+}
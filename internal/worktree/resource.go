@@ -8,6 +8,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -22,8 +24,30 @@ const (
 type SharedResource struct {
 	Path     string           `yaml:"path"`
 	Strategy ResourceStrategy `yaml:"strategy"`
+	// MaxSize skips copied files larger than this size (e.g. "10MB").
+	MaxSize string `yaml:"max_size,omitempty"`
+	// Render copies a file as a Go template executed with ResourceTemplateData, so
+	// each worktree can get its own values, such as ports in a .env file.
+	Render bool `yaml:"render,omitempty"`
+	// Vars are values for rendered templates, as .Vars.
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Overrides replace vars for the worktrees they name, by directory name.
+	Overrides map[string]map[string]string `yaml:"overrides,omitempty"`
+	// Overwrite is what sync does when a worktree's copy or link differs from the
+	// source. The default is never.
+	Overwrite OverwritePolicy `yaml:"overwrite,omitempty"`
 }
 
+// OverwritePolicy says whether sync replaces a resource that already exists in a
+// worktree and differs from its source.
+type OverwritePolicy string
+
+const (
+	OverwriteNever  OverwritePolicy = "never"
+	OverwriteAlways OverwritePolicy = "always"
+	OverwritePrompt OverwritePolicy = "prompt"
+)
+
 type Hook struct {
 	Cmd  string `yaml:"cmd"`
 	Desc string `yaml:"desc,omitempty"`
@@ -35,9 +59,36 @@ const (
 	legacySharedConfigYAML = ".gmc-shared.yaml"
 )
 
+// partialSuffix marks a copy in progress. Copies land under this name and are renamed
+// into place when complete, so an interrupted sync never leaves a resource that looks
+// finished. The next sync resumes a partial directory instead of starting over.
+const partialSuffix = ".gmc-partial"
+
+// CopyProgress describes one file copied while syncing a shared resource.
+type CopyProgress struct {
+	// Resource is the shared resource path from the config.
+	Resource string
+	// File is the worktree-relative path of the file just copied.
+	File  string
+	Bytes int64
+
+	Files       int
+	TotalFiles  int
+	CopiedBytes int64
+	TotalBytes  int64
+}
+
+// String renders p as a one-line progress message.
+func (p CopyProgress) String() string {
+	return fmt.Sprintf("[%d/%d] %s / %s  %s (%s)", p.Files, p.TotalFiles,
+		formatByteSize(p.CopiedBytes), formatByteSize(p.TotalBytes), p.File, formatByteSize(p.Bytes))
+}
+
 type SharedConfig struct {
 	Resources []SharedResource `yaml:"shared"`
-	Hooks     []Hook           `yaml:"hooks,omitempty"`
+	// Ignore lists glob patterns excluded when copying shared resources.
+	Ignore []string `yaml:"ignore,omitempty"`
+	Hooks  []Hook   `yaml:"hooks,omitempty"`
 }
 
 func (c *Client) SyncSharedResources(worktreeName string) (Report, error) {
@@ -67,13 +118,24 @@ func (c *Client) syncSharedResourcesToPath(targetRoot string, runHooks bool) (Re
 		return report, err
 	}
 
+	var skipped []string
 	for _, res := range cfg.Resources {
-		resourceReport, err := c.syncOneResource(c.worktreeRoot, targetRoot, res)
+		filter, err := newShareFilter(cfg, res)
+		if err != nil {
+			return report, err
+		}
+		resourceReport, err := c.syncOneResource(c.worktreeRoot, targetRoot, res, filter)
 		report.Merge(resourceReport)
+		if summary := filter.summary(); summary != "" {
+			skipped = append(skipped, summary)
+		}
 		if err != nil {
 			return report, err
 		}
 	}
+	for _, summary := range skipped {
+		report.Warn(summary)
+	}
 
 	if runHooks {
 		if err := c.runHooks(targetRoot, cfg.Hooks, &report); err != nil {
@@ -84,7 +146,9 @@ func (c *Client) syncSharedResourcesToPath(targetRoot string, runHooks bool) (Re
 	return report, nil
 }
 
-func (c *Client) syncOneResource(repoRoot, targetRoot string, res SharedResource) (Report, error) {
+func (c *Client) syncOneResource(
+	repoRoot, targetRoot string, res SharedResource, filter *shareFilter,
+) (Report, error) {
 	var report Report
 
 	if res.Path == "" {
@@ -93,6 +157,15 @@ func (c *Client) syncOneResource(repoRoot, targetRoot string, res SharedResource
 	if res.Strategy == "" {
 		return report, fmt.Errorf("shared resource '%s' missing 'strategy' field", res.Path)
 	}
+	if res.Render && res.Strategy != StrategyCopy {
+		return report, fmt.Errorf("shared resource '%s': render requires the copy strategy", res.Path)
+	}
+	switch res.Overwrite {
+	case "", OverwriteNever, OverwriteAlways, OverwritePrompt:
+	default:
+		return report, fmt.Errorf("unknown overwrite policy '%s' for resource '%s' (valid: always, never, prompt)",
+			res.Overwrite, res.Path)
+	}
 
 	srcPath, targetPath, skip, err := c.resolveSharedPaths(repoRoot, targetRoot, res)
 	if err != nil {
@@ -106,16 +179,34 @@ func (c *Client) syncOneResource(repoRoot, targetRoot string, res SharedResource
 
 	info, err := os.Stat(srcPath)
 	if os.IsNotExist(err) {
+		_ = os.RemoveAll(dstPath + partialSuffix)
 		if c.verbose {
 			report.Warn("Shared resource source not found: " + srcPath)
 		}
 		return report, nil
 	}
 
-	if _, err := os.Stat(dstPath); err == nil {
+	if res.Render && info.IsDir() {
+		return report, fmt.Errorf("shared resource '%s': render works on files, not directories", res.Path)
+	}
+
+	// A filtered file is skipped before an existing copy is moved aside, which would
+	// leave the worktree without it.
+	if res.Strategy == StrategyCopy && !info.IsDir() && filter.skip(targetPath, info) {
 		return report, nil
 	}
 
+	if _, err := os.Stat(dstPath); err == nil {
+		entry := newShareEntry(srcPath, targetRoot, targetPath, res)
+		overwrite, err := c.shouldOverwrite(entry, info, filter)
+		if err != nil || !overwrite {
+			return report, err
+		}
+		if err := moveAsideShared(entry, &report); err != nil {
+			return report, err
+		}
+	}
+
 	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
 		return report, fmt.Errorf("failed to create parent directory for %s: %w", dstPath, err)
 	}
@@ -124,6 +215,7 @@ func (c *Client) syncOneResource(repoRoot, targetRoot string, res SharedResource
 
 	switch res.Strategy {
 	case StrategySymlink:
+		_ = os.RemoveAll(dstPath + partialSuffix)
 		relSrc, err := filepath.Rel(filepath.Dir(dstPath), srcPath)
 		if err != nil {
 			return report, fmt.Errorf("failed to calculate relative path: %w", err)
@@ -133,8 +225,22 @@ func (c *Client) syncOneResource(repoRoot, targetRoot string, res SharedResource
 		}
 	case StrategyCopy:
 		if info.IsDir() {
-			if err := copyDir(srcPath, dstPath); err != nil {
-				return report, fmt.Errorf("failed to copy directory %s: %w", res.Path, err)
+			partialPath := dstPath + partialSuffix
+			if _, err := os.Stat(partialPath); err == nil {
+				report.Info("Resuming interrupted copy of " + res.Path)
+			}
+			stats, err := c.copyDir(srcPath, partialPath, targetPath, filter)
+			if err != nil {
+				return report, fmt.Errorf("failed to copy directory %s: %w (the next sync resumes from %s)",
+					res.Path, err, partialPath)
+			}
+			if err := os.Rename(partialPath, dstPath); err != nil {
+				return report, fmt.Errorf("failed to move copied directory into place: %w", err)
+			}
+			report.Info(stats.summary())
+		} else if res.Render {
+			if err := c.renderResourceFile(srcPath, dstPath, targetRoot, res); err != nil {
+				return report, fmt.Errorf("failed to render %s: %w", res.Path, err)
 			}
 		} else {
 			if err := copyFile(srcPath, dstPath); err != nil {
@@ -336,29 +442,36 @@ func (c *Client) RemoveHook(index int) (Report, error) {
 	return report, nil
 }
 
+// shouldOverwrite reports whether sync replaces the existing entry, by the resource's
+// overwrite policy or the client's Force option. An entry that matches its source is
+// left alone either way.
+func (c *Client) shouldOverwrite(entry ShareEntry, srcInfo os.FileInfo, filter *shareFilter) (bool, error) {
+	policy := entry.res.Overwrite
+	if c.force {
+		policy = OverwriteAlways
+	}
+	if policy != OverwriteAlways && policy != OverwritePrompt {
+		return false, nil
+	}
+	state, detail, err := c.compareShared(filter.clone(), entry, srcInfo)
+	if err != nil || state == ShareOK {
+		return false, err
+	}
+	entry.State, entry.Detail = state, detail
+	if policy == OverwritePrompt {
+		return c.confirmOverwrite != nil && c.confirmOverwrite(entry), nil
+	}
+	return true, nil
+}
+
 func (c *Client) SyncAllSharedResources() (Report, error) {
 	var report Report
 
-	worktrees, err := c.ListCached()
+	targets, err := c.managedWorktrees()
 	if err != nil {
 		return report, err
 	}
 
-	if err := c.ensureInit(); err != nil {
-		return report, err
-	}
-	isBare := c.repoDir != c.worktreeRoot
-	var targets []Info
-	for _, wt := range worktrees {
-		if wt.IsBare || filepath.Base(wt.Path) == ".bare" {
-			continue
-		}
-		if isBare && isExternalPath(c.worktreeRoot, wt.Path) {
-			continue
-		}
-		targets = append(targets, wt)
-	}
-
 	if len(targets) == 0 {
 		report.Info("No worktrees to sync.")
 		return report, nil
@@ -376,6 +489,31 @@ func (c *Client) SyncAllSharedResources() (Report, error) {
 	return report, nil
 }
 
+// managedWorktrees lists the worktrees gmc operates on: the bare entry and, in bare
+// layouts, worktrees outside the worktree root are skipped.
+func (c *Client) managedWorktrees() ([]Info, error) {
+	worktrees, err := c.ListCached()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.ensureInit(); err != nil {
+		return nil, err
+	}
+	isBare := c.repoDir != c.worktreeRoot
+	var targets []Info
+	for _, wt := range worktrees {
+		if wt.IsBare || filepath.Base(wt.Path) == ".bare" {
+			continue
+		}
+		if isBare && isExternalPath(c.worktreeRoot, wt.Path) {
+			continue
+		}
+		targets = append(targets, wt)
+	}
+	return targets, nil
+}
+
 func (c *Client) resolveWorktreePath(worktreeName string) (string, error) {
 	if worktreeName == "" {
 		return "", errors.New("worktree name cannot be empty")
@@ -438,7 +576,7 @@ func (c *Client) currentTopLevel() string {
 	if filepath.IsAbs(root) {
 		return filepath.Clean(root)
 	}
-	absRoot, absErr := filepath.Abs(root)
+	absRoot, absErr := c.absPath(root)
 	if absErr != nil {
 		return ""
 	}
@@ -544,6 +682,8 @@ func sanitizeTargetRelativePath(path string) (string, error) {
 	return cleaned, nil
 }
 
+// copyFile copies src to dst through a temporary file, keeping the mode and
+// modification time so a resumed copy can recognize files it already has.
 func copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
@@ -551,27 +691,70 @@ func copyFile(src, dst string) error {
 	}
 	defer sourceFile.Close()
 
-	destFile, err := os.Create(dst)
+	sourceInfo, err := sourceFile.Stat()
 	if err != nil {
 		return err
 	}
-	defer destFile.Close()
 
-	_, err = io.Copy(destFile, sourceFile)
+	tmpPath := dst + partialSuffix
+	destFile, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
 
-	sourceInfo, err := os.Stat(src)
-	if err == nil {
-		_ = os.Chmod(dst, sourceInfo.Mode())
+	if _, err := io.Copy(destFile, sourceFile); err != nil {
+		destFile.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := destFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
 	}
 
-	return nil
+	_ = os.Chmod(tmpPath, sourceInfo.Mode())
+	_ = os.Chtimes(tmpPath, sourceInfo.ModTime(), sourceInfo.ModTime())
+	return os.Rename(tmpPath, dst)
+}
+
+// sameFile reports whether dst already holds a completed copy of the file described by info.
+func sameFile(dst string, info os.FileInfo) bool {
+	existing, err := os.Stat(dst)
+	return err == nil && existing.Mode().IsRegular() &&
+		existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime())
+}
+
+type copyJob struct {
+	src  string
+	dst  string
+	rel  string
+	size int64
+}
+
+type copyStats struct {
+	files   int
+	bytes   int64
+	resumed int
+	elapsed time.Duration
+}
+
+func (s copyStats) summary() string {
+	summary := fmt.Sprintf("Copied %d files (%s) in %s", s.files, formatByteSize(s.bytes),
+		s.elapsed.Round(100*time.Millisecond))
+	if s.resumed > 0 {
+		summary += fmt.Sprintf(", %d already copied by an interrupted sync", s.resumed)
+	}
+	return summary
 }
 
-func copyDir(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+// copyDir copies src to dst, skipping entries rejected by filter and files dst already
+// holds. prefix is the worktree-relative path of src, used for pattern matching and reporting.
+func (c *Client) copyDir(src, dst, prefix string, filter *shareFilter) (copyStats, error) {
+	start := time.Now()
+	var stats copyStats
+	var jobs []copyJob
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -581,14 +764,92 @@ func copyDir(src, dst string) error {
 			return err
 		}
 
+		if relPath != "." && filter.skip(filepath.Join(prefix, relPath), info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		destPath := filepath.Join(dst, relPath)
 
 		if info.IsDir() {
 			return os.MkdirAll(destPath, info.Mode())
 		}
+		if sameFile(destPath, info) {
+			stats.resumed++
+			return nil
+		}
 
-		return copyFile(path, destPath)
+		jobs = append(jobs, copyJob{src: path, dst: destPath, rel: filepath.Join(prefix, relPath), size: info.Size()})
+		return nil
 	})
+	if err != nil {
+		return stats, err
+	}
+
+	stats.files, stats.bytes, err = c.copyFiles(prefix, jobs)
+	stats.elapsed = time.Since(start)
+	return stats, err
+}
+
+// copyFiles runs jobs on up to c.parallel workers and stops handing out work after the
+// first failure. It returns the number of files and bytes copied.
+func (c *Client) copyFiles(resource string, jobs []copyJob) (int, int64, error) {
+	if len(jobs) == 0 {
+		return 0, 0, nil
+	}
+
+	progress := CopyProgress{Resource: resource, TotalFiles: len(jobs)}
+	for _, job := range jobs {
+		progress.TotalBytes += job.size
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	queue := make(chan copyJob)
+	for range min(max(c.parallel, 1), len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				err := copyFile(job.src, job.dst)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %w", job.rel, err)
+					}
+				} else {
+					progress.File = job.rel
+					progress.Bytes = job.size
+					progress.Files++
+					progress.CopiedBytes += job.size
+					if c.onCopy != nil {
+						c.onCopy(progress)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	return progress.Files, progress.CopiedBytes, firstErr
 }
 
 func (c *Client) runHooks(worktreeRoot string, hooks []Hook, report *Report) error {
@@ -0,0 +1,156 @@
+package worktree
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// slotFileName is the file, in a worktree's git directory, that holds the slot
+// allocated to the worktree for rendered resources.
+const slotFileName = "gmc-slot"
+
+// ResourceTemplateData is what a shared resource with render: true is rendered with.
+type ResourceTemplateData struct {
+	// Name is the worktree directory name, such as .dup-1 or feature-login.
+	Name   string
+	Branch string
+	// Path is the absolute path of the worktree.
+	Path string
+	// Slot is a small number unique among the live worktrees: 0 for the main
+	// worktree of a normal repository, and from 1 for the others. It is kept for the
+	// life of the worktree, so ports derived from it do not change between syncs.
+	Slot int
+	// Vars are the resource's vars, with the overrides for this worktree applied.
+	Vars map[string]string
+}
+
+// renderFuncs are the functions available to rendered resources, beyond text/template's.
+var renderFuncs = template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+}
+
+// renderResourceFile renders the template at srcPath for the worktree at targetRoot
+// and writes the result to dstPath, with the mode of the source.
+func (c *Client) renderResourceFile(srcPath, dstPath, targetRoot string, res SharedResource) error {
+	rendered, err := c.renderResource(srcPath, targetRoot, res, true)
+	if err != nil {
+		return err
+	}
+	sourceInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := dstPath + partialSuffix
+	if err := os.WriteFile(tmpPath, rendered, sourceInfo.Mode().Perm()); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dstPath)
+}
+
+// renderResource returns the template at srcPath rendered for the worktree at
+// targetRoot. With saveSlot false, a worktree without a slot is rendered with the
+// slot it would get, which is not saved.
+func (c *Client) renderResource(srcPath, targetRoot string, res SharedResource, saveSlot bool) ([]byte, error) {
+	content, err := os.ReadFile(srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.resourceTemplateData(targetRoot, res, saveSlot)
+	if err != nil {
+		return nil, err
+	}
+	funcs := maps.Clone(renderFuncs)
+	funcs["port"] = func(base int) int { return base + data.Slot }
+	tmpl, err := template.New(res.Path).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return rendered.Bytes(), nil
+}
+
+func (c *Client) resourceTemplateData(
+	targetRoot string, res SharedResource, saveSlot bool,
+) (ResourceTemplateData, error) {
+	name := filepath.Base(targetRoot)
+	slot, err := c.worktreeSlot(targetRoot, saveSlot)
+	if err != nil {
+		return ResourceTemplateData{}, err
+	}
+
+	vars := make(map[string]string, len(res.Vars))
+	maps.Copy(vars, res.Vars)
+	maps.Copy(vars, res.Overrides[name])
+
+	return ResourceTemplateData{
+		Name:   name,
+		Branch: c.getGitOutput(targetRoot, "rev-parse", "--abbrev-ref", "HEAD"),
+		Path:   targetRoot,
+		Slot:   slot,
+		Vars:   vars,
+	}, nil
+}
+
+// worktreeSlot returns the slot of the worktree at targetRoot, allocating the lowest
+// free one on first use. The allocated slot is saved only when save is set.
+func (c *Client) worktreeSlot(targetRoot string, save bool) (int, error) {
+	if c.repoDir == c.worktreeRoot && samePath(targetRoot, c.worktreeRoot) {
+		return 0, nil
+	}
+	gitDir, err := worktreeGitDir(targetRoot)
+	if err != nil {
+		return 0, err
+	}
+	slotPath := filepath.Join(gitDir, slotFileName)
+	if slot, ok := readSlot(slotPath); ok {
+		return slot, nil
+	}
+
+	used := map[int]bool{}
+	worktrees, err := c.ListCached()
+	if err != nil {
+		return 0, err
+	}
+	for _, wt := range worktrees {
+		if wt.IsBare || samePath(wt.Path, targetRoot) {
+			continue
+		}
+		if dir, err := worktreeGitDir(wt.Path); err == nil {
+			if slot, ok := readSlot(filepath.Join(dir, slotFileName)); ok {
+				used[slot] = true
+			}
+		}
+	}
+	slot := 1
+	for used[slot] {
+		slot++
+	}
+	if !save {
+		return slot, nil
+	}
+	if err := os.WriteFile(slotPath, []byte(strconv.Itoa(slot)+"\n"), 0o644); err != nil {
+		return 0, fmt.Errorf("failed to save worktree slot: %w", err)
+	}
+	return slot, nil
+}
+
+func readSlot(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	slot, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return slot, err == nil && slot > 0
+}
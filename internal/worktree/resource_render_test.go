@@ -0,0 +1,103 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncRendersTemplatedResourcePerWorktree(t *testing.T) {
+	repoDir := initTestRepo(t)
+	parent := t.TempDir()
+	first := filepath.Join(parent, "feat-a")
+	second := filepath.Join(parent, "feat-b")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-a", first, "main")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-b", second, "main")
+
+	env := "NAME={{ .Name }}\nBRANCH={{ .Branch }}\nPORT={{ port 3000 }}\nDB={{ .Vars.db }}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".env"), []byte(env), 0o600))
+	config := []byte(`shared:
+  - path: .env
+    strategy: copy
+    render: true
+    vars:
+      db: app
+    overrides:
+      feat-b:
+        db: app_b
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "gmc-share.yml"), config, 0o644))
+	chdir(t, repoDir)
+
+	_, err := NewClient(Options{}).SyncAllSharedResources()
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(first, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, "NAME=feat-a\nBRANCH=feat-a\nPORT=3001\nDB=app\n", string(data))
+	data, err = os.ReadFile(filepath.Join(second, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, "NAME=feat-b\nBRANCH=feat-b\nPORT=3002\nDB=app_b\n", string(data))
+
+	info, err := os.Stat(filepath.Join(second, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestWorktreeSlotIsKeptAndReused(t *testing.T) {
+	repoDir := initTestRepo(t)
+	parent := t.TempDir()
+	first := filepath.Join(parent, "feat-a")
+	second := filepath.Join(parent, "feat-b")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-a", first, "main")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-b", second, "main")
+	chdir(t, repoDir)
+
+	client := NewClient(Options{})
+	require.NoError(t, client.ensureInit())
+	slot, err := client.worktreeSlot(repoDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, slot, "the main worktree of a normal repository")
+
+	a, err := client.worktreeSlot(first, true)
+	require.NoError(t, err)
+	b, err := client.worktreeSlot(second, true)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, []int{a, b})
+
+	again, err := client.worktreeSlot(first, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, again)
+
+	runGit(t, repoDir, "worktree", "remove", first)
+	third := filepath.Join(parent, "feat-c")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-c", third, "main")
+	client.InvalidateList()
+	c, err := client.worktreeSlot(third, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, c, "a removed worktree's slot is free again")
+}
+
+func TestSyncRejectsRenderWithLink(t *testing.T) {
+	repoDir := initTestRepo(t)
+	linkedWt := filepath.Join(t.TempDir(), "feat-a")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-a", linkedWt, "main")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".env"), []byte("A=1"), 0o644))
+	config := []byte("shared:\n  - path: .env\n    strategy: link\n    render: true\n")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "gmc-share.yml"), config, 0o644))
+	chdir(t, repoDir)
+
+	report, err := NewClient(Options{}).SyncAllSharedResources()
+	require.NoError(t, err)
+	var warnings []string
+	for _, event := range report.Events {
+		if event.Level == EventWarn {
+			warnings = append(warnings, event.Message)
+		}
+	}
+	require.NotEmpty(t, warnings)
+	assert.Contains(t, warnings[0], "render requires the copy strategy")
+}
@@ -3,6 +3,7 @@ package worktree
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -76,3 +77,113 @@ shared:
 	require.NoError(t, err)
 	assert.Equal(t, "model-data", string(modelContent))
 }
+
+func TestSyncSharedResourcesSkipsIgnoredAndOversized(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, ".bare"), 0755))
+
+	cacheDir := filepath.Join(tempDir, "data", ".cache")
+	require.NoError(t, os.MkdirAll(cacheDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "tmp"), []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "data", "small.txt"), []byte("ok"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "data", "debug.log"), []byte("log"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "data", "huge.bin"), make([]byte, 2048), 0644))
+
+	sharedConfig := `
+shared:
+  - path: data
+    strategy: copy
+    max_size: 1KB
+ignore:
+  - "*.log"
+  - .cache/
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".gmc-shared.yml"), []byte(sharedConfig), 0644))
+
+	wtPath := filepath.Join(tempDir, "feature")
+	require.NoError(t, os.Mkdir(wtPath, 0755))
+
+	chdir(t, tempDir)
+	client := NewClient(Options{})
+	report, err := client.SyncSharedResources("feature")
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(wtPath, "data", "small.txt"))
+	assert.NoFileExists(t, filepath.Join(wtPath, "data", "debug.log"))
+	assert.NoFileExists(t, filepath.Join(wtPath, "data", "huge.bin"))
+	assert.NoDirExists(t, filepath.Join(wtPath, "data", ".cache"))
+
+	var summary string
+	for _, event := range report.Events {
+		if event.Level == EventWarn {
+			summary = event.Message
+		}
+	}
+	assert.Contains(t, summary, "Skipped 3 shared item(s):")
+	assert.Contains(t, summary, "data/huge.bin (2.0 KB exceeds max_size 1.0 KB)")
+	assert.Contains(t, summary, "data/debug.log (ignored by *.log)")
+	assert.Contains(t, summary, "data/.cache (ignored by .cache/)")
+}
+
+func TestSyncSharedResourcesCopyProgressAndResume(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, ".bare"), 0755))
+
+	dataDir := filepath.Join(tempDir, "data", "nested")
+	require.NoError(t, os.MkdirAll(dataDir, 0755))
+	for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dataDir, name), make([]byte, 100), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".gmc-shared.yml"),
+		[]byte("shared:\n  - path: data\n    strategy: copy\n"), 0644))
+
+	wtPath := filepath.Join(tempDir, "feature")
+	require.NoError(t, os.Mkdir(wtPath, 0755))
+
+	// Simulate an interrupted sync that finished a.bin and left a torn temporary b.bin.
+	partialDir := filepath.Join(wtPath, "data"+partialSuffix, "nested")
+	require.NoError(t, os.MkdirAll(partialDir, 0755))
+	require.NoError(t, copyFile(filepath.Join(dataDir, "a.bin"), filepath.Join(partialDir, "a.bin")))
+	require.NoError(t, os.WriteFile(filepath.Join(partialDir, "b.bin"+partialSuffix), []byte("torn"), 0644))
+
+	chdir(t, tempDir)
+	var events []CopyProgress
+	client := NewClient(Options{Parallel: 4, OnCopy: func(p CopyProgress) { events = append(events, p) }})
+	report, err := client.SyncSharedResources("feature")
+	require.NoError(t, err)
+
+	for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		assert.FileExists(t, filepath.Join(wtPath, "data", "nested", name))
+	}
+	assert.NoDirExists(t, filepath.Join(wtPath, "data"+partialSuffix))
+	assert.NoFileExists(t, filepath.Join(wtPath, "data", "nested", "b.bin"+partialSuffix))
+
+	require.Len(t, events, 2, "a.bin was already copied")
+	last := events[len(events)-1]
+	assert.Equal(t, "data", last.Resource)
+	assert.Equal(t, 2, last.Files)
+	assert.Equal(t, 2, last.TotalFiles)
+	assert.Equal(t, int64(200), last.CopiedBytes)
+	assert.Equal(t, int64(200), last.TotalBytes)
+	assert.Regexp(t, `^\[2/2\] 200 B / 200 B  data/nested/[bc]\.bin \(100 B\)$`, last.String())
+
+	var messages []string
+	for _, event := range report.Events {
+		messages = append(messages, event.Message)
+	}
+	assert.Contains(t, messages, "Resuming interrupted copy of data")
+	assert.Contains(t, strings.Join(messages, "\n"), "Copied 2 files (200 B) in ")
+	assert.Contains(t, strings.Join(messages, "\n"), "1 already copied by an interrupted sync")
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{"": 0, "512": 512, "2KB": 2048, "1.5m": 3 << 19, "1G": 1 << 30}
+	for input, want := range tests {
+		got, err := parseByteSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := parseByteSize("ten megs")
+	assert.Error(t, err)
+}
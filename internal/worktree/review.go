@@ -88,7 +88,17 @@ func (c *Client) ReviewStates(worktrees []Info) ReviewLookup {
 		return result
 	}
 
+	// Branches from AddPR are someone else's PR, so the lookup of the user's own
+	// reviews would not find them; their recorded number is shown instead.
 	targets := c.reviewTargets(worktrees)
+	if recorded, err := c.RecordedPRs(); err == nil {
+		for _, wt := range worktrees {
+			if number, ok := recorded[wt.Branch]; ok {
+				result.Reviews[wt.Branch] = ReviewInfo{Number: number, HeadBranch: wt.Branch}
+				delete(targets, wt.Branch)
+			}
+		}
+	}
 	if len(targets) == 0 {
 		return result
 	}
@@ -112,7 +122,9 @@ func (c *Client) ReviewStates(worktrees []Info) ReviewLookup {
 		result.Warning = reviewLookupWarning(remote.provider, err)
 		return result
 	}
-	result.Reviews = reviews
+	for branch, review := range reviews {
+		result.Reviews[branch] = review
+	}
 	return result
 }
 
@@ -0,0 +1,102 @@
+package worktree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// cloneDepthKey records gmc wt clone --depth in the bare repository, so that the
+// base branches gmc wt add fetches later get the same depth.
+const cloneDepthKey = "gmc.cloneDepth"
+
+// defaultDeepen is how many commits mergeBase deepens a shallow repository by when
+// no clone depth is recorded.
+const defaultDeepen = 100
+
+// cloneDepth returns the recorded clone depth, or 0 for a full clone.
+func (c *Client) cloneDepth(repoDir string) int {
+	result, err := c.runner.Run("-C", repoDir, "config", "--get", cloneDepthKey)
+	if err != nil {
+		return 0
+	}
+	recorded, _ := strconv.Atoi(result.StdoutString(true))
+	return recorded
+}
+
+// isShallow reports whether repoDir is a shallow clone.
+func (c *Client) isShallow(repoDir string) bool {
+	result, err := c.runner.Run("-C", repoDir, "rev-parse", "--is-shallow-repository")
+	return err == nil && result.StdoutString(true) == "true"
+}
+
+// mergeBase returns the merge base of a and b. When the history of a shallow
+// repository stops before it, the history is deepened from origin, first by the
+// clone depth and then in full, until the merge base is found.
+func (c *Client) mergeBase(repoDir, a, b string) (string, error) {
+	result, err := c.runner.Run("-C", repoDir, "merge-base", a, b)
+	if err == nil {
+		return result.StdoutString(true), nil
+	}
+	if c.isShallow(repoDir) && c.remoteExists(repoDir, "origin") {
+		depth := c.cloneDepth(repoDir)
+		if depth <= 0 {
+			depth = defaultDeepen
+		}
+		for _, deepen := range [][]string{{"--deepen", strconv.Itoa(depth)}, {"--unshallow"}} {
+			args := append([]string{"-C", repoDir, "fetch"}, deepen...)
+			if _, fetchErr := c.runner.RunLogged(append(args, "origin")...); fetchErr != nil {
+				break
+			}
+			if result, err = c.runner.Run("-C", repoDir, "merge-base", a, b); err == nil {
+				return result.StdoutString(true), nil
+			}
+		}
+	}
+	return "", gitutil.WrapGitError(fmt.Sprintf("failed to find the merge base of %s and %s", a, b), result, err)
+}
+
+// ensureBase fetches the base branch of a new worktree from origin when the
+// repository does not have it, as after gmc wt clone --single-branch, and returns
+// the ref to create the branch from. In a shallow clone the branch is fetched at the
+// clone depth. Fetch failures are reported and leave base unchanged, so git worktree
+// add reports the missing ref.
+func (c *Client) ensureBase(repoDir string, base string, report *Report) string {
+	if c.gitRefExists(repoDir, base+"^{commit}") || !c.remoteExists(repoDir, "origin") {
+		return base
+	}
+	name := strings.TrimPrefix(base, "origin/")
+	if gitutil.ValidateBranchName(name) != nil {
+		return base
+	}
+
+	report.Info(fmt.Sprintf("Fetching '%s' from origin...", name))
+	args := []string{"-C", repoDir, "fetch"}
+	if depth := c.cloneDepth(repoDir); depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	args = append(args, "origin", "+refs/heads/"+name+":refs/remotes/origin/"+name)
+	if result, err := c.runner.RunLogged(args...); err != nil {
+		report.Warn(fmt.Sprintf("Warning: %v", gitutil.WrapGitError("failed to fetch "+name, result, err)))
+		return base
+	}
+
+	if !c.fetchesAllBranches(repoDir) {
+		// Track the branch, so later fetches update it too.
+		if _, err := c.runner.RunLogged("-C", repoDir, "remote", "set-branches", "--add", "origin", name); err != nil {
+			report.Warn(fmt.Sprintf("Warning: failed to track origin/%s: %v", name, err))
+		}
+	}
+	return "origin/" + name
+}
+
+// fetchesAllBranches reports whether origin's fetch refspecs cover every branch.
+func (c *Client) fetchesAllBranches(repoDir string) bool {
+	result, err := c.runner.Run("-C", repoDir, "config", "--get-all", "remote.origin.fetch")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(result.StdoutString(true), "refs/heads/*")
+}
@@ -0,0 +1,114 @@
+package worktree
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCloneShallowSingleBranchAndAddFetchesBase(t *testing.T) {
+	originDir := initBareRepo(t)
+	sourceDir := initTestRepo(t)
+	runGit(t, sourceDir, "commit", "--allow-empty", "-m", "second")
+	runGit(t, sourceDir, "branch", "feature")
+	runGit(t, sourceDir, "commit", "--allow-empty", "-m", "third")
+	runGit(t, sourceDir, "remote", "add", "origin", originDir)
+	runGit(t, sourceDir, "push", "origin", "main", "feature")
+	runGit(t, originDir, "symbolic-ref", "HEAD", "refs/heads/main")
+	featureTip := strings.TrimSpace(runGit(t, sourceDir, "rev-parse", "feature"))
+
+	workDir := t.TempDir()
+	chdir(t, workDir)
+	client := NewClient(Options{})
+	_, err := client.Clone("file://"+originDir, CloneOptions{Name: "proj", Depth: 1, SingleBranch: true})
+	if err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+
+	bareDir := filepath.Join(workDir, "proj", ".bare")
+	if got := strings.TrimSpace(runGit(t, bareDir, "rev-parse", "--is-shallow-repository")); got != "true" {
+		t.Errorf("is-shallow-repository = %q, want true", got)
+	}
+	if got := strings.TrimSpace(runGit(t, bareDir, "rev-list", "--count", "main")); got != "1" {
+		t.Errorf("main history = %s commit(s), want 1", got)
+	}
+	if got := strings.TrimSpace(runGit(t, bareDir, "config", "--get", cloneDepthKey)); got != "1" {
+		t.Errorf("%s = %q, want 1", cloneDepthKey, got)
+	}
+	if refs := runGit(t, bareDir, "for-each-ref", "refs/remotes/origin/feature"); refs != "" {
+		t.Errorf("single-branch clone fetched feature: %s", refs)
+	}
+
+	chdir(t, filepath.Join(workDir, "proj", "main"))
+	addClient := NewClient(Options{})
+	report, err := addClient.Add("from-feature", AddOptions{BaseBranch: "feature"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	var messages []string
+	for _, event := range report.Events {
+		messages = append(messages, event.Message)
+	}
+	if !strings.Contains(strings.Join(messages, "\n"), "Fetching 'feature' from origin") {
+		t.Errorf("report does not mention the fetch: %q", messages)
+	}
+	if got := strings.TrimSpace(runGit(t, bareDir, "rev-parse", "from-feature")); got != featureTip {
+		t.Errorf("from-feature = %s, want feature tip %s", got, featureTip)
+	}
+	if got := strings.TrimSpace(runGit(t, bareDir, "rev-list", "--count", "origin/feature")); got != "1" {
+		t.Errorf("feature history = %s commit(s), want the clone depth 1", got)
+	}
+	if fetch := runGit(t, bareDir, "config", "--get-all", "remote.origin.fetch"); !strings.Contains(fetch,
+		"refs/heads/feature:refs/remotes/origin/feature") {
+		t.Errorf("remote.origin.fetch does not track feature:\n%s", fetch)
+	}
+}
+
+func TestCloneOptionsArgs(t *testing.T) {
+	opts := CloneOptions{Depth: 50, Filter: "blob:none", SingleBranch: true}
+	got := strings.Join(opts.cloneArgs(), " ")
+	if got != "--depth 50 --filter blob:none --single-branch" {
+		t.Errorf("cloneArgs() = %q", got)
+	}
+	if args := (CloneOptions{}).cloneArgs(); len(args) != 0 {
+		t.Errorf("cloneArgs() without options = %v, want none", args)
+	}
+}
+
+func TestMergeBaseDeepensShallowClone(t *testing.T) {
+	originDir := initBareRepo(t)
+	sourceDir := initTestRepo(t)
+	base := strings.TrimSpace(runGit(t, sourceDir, "rev-parse", "HEAD"))
+	runGit(t, sourceDir, "checkout", "-q", "-b", "feature")
+	runGit(t, sourceDir, "commit", "--allow-empty", "-m", "feature")
+	runGit(t, sourceDir, "checkout", "-q", "main")
+	runGit(t, sourceDir, "commit", "--allow-empty", "-m", "second")
+	runGit(t, sourceDir, "commit", "--allow-empty", "-m", "third")
+	runGit(t, sourceDir, "remote", "add", "origin", originDir)
+	runGit(t, sourceDir, "push", "origin", "main", "feature")
+	runGit(t, originDir, "symbolic-ref", "HEAD", "refs/heads/main")
+
+	workDir := t.TempDir()
+	chdir(t, workDir)
+	client := NewClient(Options{})
+	if _, err := client.Clone("file://"+originDir, CloneOptions{Name: "proj", Depth: 1}); err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+	bareDir := filepath.Join(workDir, "proj", ".bare")
+
+	got, err := client.mergeBase(bareDir, "main", "origin/feature")
+	if err != nil {
+		t.Fatalf("mergeBase() error = %v", err)
+	}
+	if got != base {
+		t.Errorf("mergeBase() = %s, want %s", got, base)
+	}
+
+	// A later fetch keeps the history the clone was deepened to.
+	if _, err := client.fetch(bareDir, FetchOptions{}); err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+	if count := strings.TrimSpace(runGit(t, bareDir, "rev-list", "--count", "main")); count == "1" {
+		t.Error("fetch made main shallow again")
+	}
+}
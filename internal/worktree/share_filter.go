@@ -0,0 +1,148 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// skippedShare records a file or directory left out of a shared resource copy.
+type skippedShare struct {
+	Path   string
+	Reason string
+}
+
+// shareFilter applies the global ignore patterns and a per-resource size limit.
+type shareFilter struct {
+	ignore  []string
+	maxSize int64
+	skipped []skippedShare
+}
+
+func newShareFilter(cfg *SharedConfig, res SharedResource) (*shareFilter, error) {
+	maxSize, err := parseByteSize(res.MaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max_size for shared resource '%s': %w", res.Path, err)
+	}
+	for _, pattern := range cfg.Ignore {
+		if _, err := filepath.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+	}
+	return &shareFilter{ignore: cfg.Ignore, maxSize: maxSize}, nil
+}
+
+// skip reports whether relPath (relative to the worktree root) should be left out,
+// recording the reason when it is.
+// clone returns a filter with the same rules and no skipped items, for a pass that
+// must not add to the summary.
+func (f *shareFilter) clone() *shareFilter {
+	if f == nil {
+		return nil
+	}
+	return &shareFilter{ignore: f.ignore, maxSize: f.maxSize}
+}
+
+func (f *shareFilter) skip(relPath string, info os.FileInfo) bool {
+	if f == nil {
+		return false
+	}
+	if pattern, ok := f.ignored(relPath, info.IsDir()); ok {
+		f.skipped = append(f.skipped, skippedShare{Path: relPath, Reason: "ignored by " + pattern})
+		return true
+	}
+	if f.maxSize > 0 && !info.IsDir() && info.Size() > f.maxSize {
+		f.skipped = append(f.skipped, skippedShare{
+			Path:   relPath,
+			Reason: fmt.Sprintf("%s exceeds max_size %s", formatByteSize(info.Size()), formatByteSize(f.maxSize)),
+		})
+		return true
+	}
+	return false
+}
+
+// ignored matches a pattern against the basename and the full relative path.
+// A trailing "/" restricts the pattern to directories.
+func (f *shareFilter) ignored(relPath string, isDir bool) (string, bool) {
+	relPath = filepath.ToSlash(relPath)
+	base := filepath.Base(relPath)
+	for _, pattern := range f.ignore {
+		glob := pattern
+		if strings.HasSuffix(glob, "/") {
+			if !isDir {
+				continue
+			}
+			glob = strings.TrimSuffix(glob, "/")
+		}
+		if ok, _ := filepath.Match(glob, base); ok {
+			return pattern, true
+		}
+		if ok, _ := filepath.Match(glob, relPath); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+func (f *shareFilter) summary() string {
+	if f == nil || len(f.skipped) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(f.skipped)+1)
+	lines = append(lines, fmt.Sprintf("Skipped %d shared item(s):", len(f.skipped)))
+	for _, item := range f.skipped {
+		lines = append(lines, fmt.Sprintf("  - %s (%s)", item.Path, item.Reason))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses sizes like "512", "200KB", or "1.5G". Empty means no limit.
+func parseByteSize(value string) (int64, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(value))
+	if trimmed == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if number, ok := strings.CutSuffix(trimmed, unit.suffix); ok {
+			trimmed = strings.TrimSpace(number)
+			multiplier = unit.size
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("expected a size such as 500KB or 10MB, got %q", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+func formatByteSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,320 @@
+package worktree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ShareState is how a worktree's copy or link of a shared resource compares to its source.
+type ShareState string
+
+const (
+	ShareOK      ShareState = "ok"
+	ShareMissing ShareState = "missing"
+	ShareStale   ShareState = "stale"
+	ShareBroken  ShareState = "broken"
+)
+
+// ShareEntry is the state of one shared resource in one worktree.
+type ShareEntry struct {
+	Worktree string           `json:"worktree"`
+	Path     string           `json:"path"`
+	Strategy ResourceStrategy `json:"strategy"`
+	State    ShareState       `json:"state"`
+	Detail   string           `json:"detail,omitempty"`
+
+	res        SharedResource
+	srcPath    string
+	dstPath    string
+	targetPath string
+	targetRoot string
+}
+
+// SharedStatus compares every worktree's copy or link of each shared resource with
+// its source: copies by content, links by where they point. Worktrees that hold the
+// source itself, and resources whose source does not exist, are left out. Nothing is
+// written, not even the slot of a worktree that has none yet.
+func (c *Client) SharedStatus() ([]ShareEntry, error) {
+	cfg, _, err := c.LoadSharedConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Resources) == 0 {
+		return nil, nil
+	}
+	targets, err := c.managedWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ShareEntry
+	for _, wt := range targets {
+		for _, res := range cfg.Resources {
+			entry, ok, err := c.shareEntry(cfg, wt.Path, res)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (c *Client) shareEntry(cfg *SharedConfig, targetRoot string, res SharedResource) (ShareEntry, bool, error) {
+	srcPath, targetPath, skip, err := c.resolveSharedPaths(c.worktreeRoot, targetRoot, res)
+	if err != nil || skip {
+		return ShareEntry{}, false, err
+	}
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return ShareEntry{}, false, nil
+	}
+	entry := newShareEntry(srcPath, targetRoot, targetPath, res)
+	// The parents are compared so that a link to the source is not taken for it.
+	if filepath.Base(srcPath) == filepath.Base(entry.dstPath) &&
+		sameCleanPath(filepath.Dir(srcPath), filepath.Dir(entry.dstPath)) {
+		return ShareEntry{}, false, nil
+	}
+
+	filter, err := newShareFilter(cfg, res)
+	if err != nil {
+		return ShareEntry{}, false, err
+	}
+	// Sync leaves a filtered file alone, so --fix must not move it aside either.
+	if res.Strategy == StrategyCopy && !srcInfo.IsDir() && filter.skip(targetPath, srcInfo) {
+		return ShareEntry{}, false, nil
+	}
+	entry.State, entry.Detail, err = c.compareShared(filter, entry, srcInfo)
+	return entry, true, err
+}
+
+func newShareEntry(srcPath, targetRoot, targetPath string, res SharedResource) ShareEntry {
+	return ShareEntry{
+		Worktree:   filepath.Base(targetRoot),
+		Path:       targetPath,
+		Strategy:   res.Strategy,
+		res:        res,
+		srcPath:    srcPath,
+		dstPath:    filepath.Join(targetRoot, targetPath),
+		targetPath: targetPath,
+		targetRoot: targetRoot,
+	}
+}
+
+func (c *Client) compareShared(filter *shareFilter, entry ShareEntry, srcInfo os.FileInfo) (ShareState, string, error) {
+	dstInfo, err := os.Lstat(entry.dstPath)
+	if os.IsNotExist(err) {
+		return ShareMissing, "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	isLink := dstInfo.Mode()&os.ModeSymlink != 0
+
+	if entry.res.Strategy == StrategySymlink {
+		if !isLink {
+			return ShareStale, "not a link", nil
+		}
+		target, err := filepath.EvalSymlinks(entry.dstPath)
+		if err != nil {
+			return ShareBroken, "dangling link", nil
+		}
+		if !sameCleanPath(target, entry.srcPath) {
+			return ShareBroken, "links to " + target, nil
+		}
+		return ShareOK, "", nil
+	}
+
+	if isLink {
+		return ShareStale, "a link, not a copy", nil
+	}
+	if srcInfo.IsDir() {
+		changed, err := changedDirFiles(entry.srcPath, entry.dstPath, entry.targetPath, filter)
+		if err != nil || changed == 0 {
+			return ShareOK, "", err
+		}
+		return ShareStale, fmt.Sprintf("%d file(s) differ", changed), nil
+	}
+
+	if entry.res.Render {
+		want, err := c.renderResource(entry.srcPath, entry.targetRoot, entry.res, false)
+		if err != nil {
+			return "", "", err
+		}
+		got, err := os.ReadFile(entry.dstPath)
+		if err != nil {
+			return "", "", err
+		}
+		if !bytes.Equal(want, got) {
+			return ShareStale, "differs from the rendered template", nil
+		}
+		return ShareOK, "", nil
+	}
+	same, err := sameContent(entry.srcPath, entry.dstPath, srcInfo)
+	if err != nil || same {
+		return ShareOK, "", err
+	}
+	return ShareStale, "differs from the source", nil
+}
+
+// changedDirFiles counts the files under src, less the filtered ones, that are missing
+// under dst or differ from their copy.
+func changedDirFiles(src, dst, prefix string, filter *shareFilter) (int, error) {
+	changed := 0
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if relPath != "." && filter.skip(filepath.Join(prefix, relPath), info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		same, err := sameContent(path, filepath.Join(dst, relPath), info)
+		if err != nil {
+			return err
+		}
+		if !same {
+			changed++
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// sameContent reports whether dst has the content of src. A copy with the size and
+// modification time of src is taken as the same, as sync leaves it; otherwise the
+// contents are hashed.
+func sameContent(src, dst string, srcInfo os.FileInfo) (bool, error) {
+	dstInfo, err := os.Stat(dst)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if dstInfo.Size() != srcInfo.Size() {
+		return false, nil
+	}
+	if dstInfo.ModTime().Equal(srcInfo.ModTime()) {
+		return true, nil
+	}
+	srcHash, err := fileHash(src)
+	if err != nil {
+		return false, err
+	}
+	dstHash, err := fileHash(dst)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(srcHash, dstHash), nil
+}
+
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// FixShared repairs the entries that are not ok: missing ones are synced, links are
+// recreated, stale directories are copied again file by file, and a stale file is
+// moved aside to <name>.bak before it is replaced.
+func (c *Client) FixShared(entries []ShareEntry) (Report, error) {
+	var report Report
+
+	cfg, _, err := c.LoadSharedConfig()
+	if err != nil {
+		return report, err
+	}
+	for _, entry := range entries {
+		if entry.State == ShareOK {
+			continue
+		}
+		if err := c.fixShareEntry(cfg, entry, &report); err != nil {
+			return report, fmt.Errorf("failed to fix %s in %s: %w", entry.Path, entry.Worktree, err)
+		}
+	}
+	return report, nil
+}
+
+func (c *Client) fixShareEntry(cfg *SharedConfig, entry ShareEntry, report *Report) error {
+	filter, err := newShareFilter(cfg, entry.res)
+	if err != nil {
+		return err
+	}
+
+	if entry.State != ShareMissing {
+		dstInfo, err := os.Lstat(entry.dstPath)
+		if err != nil {
+			return err
+		}
+		isLink := dstInfo.Mode()&os.ModeSymlink != 0
+		if !isLink && dstInfo.IsDir() && entry.res.Strategy == StrategyCopy {
+			stats, err := c.copyDir(entry.srcPath, entry.dstPath, entry.targetPath, filter)
+			if err != nil {
+				return err
+			}
+			report.Info(fmt.Sprintf("Updated %s in %s: copied %d file(s)", entry.Path, entry.Worktree, stats.files))
+			return nil
+		}
+		if err := moveAsideShared(entry, report); err != nil {
+			return err
+		}
+	}
+
+	syncReport, err := c.syncOneResource(c.worktreeRoot, entry.targetRoot, entry.res, filter)
+	report.Merge(syncReport)
+	return err
+}
+
+// moveAsideShared clears the way for a fresh sync of entry: a link is removed, and
+// anything else is moved to a .bak backup.
+func moveAsideShared(entry ShareEntry, report *Report) error {
+	dstInfo, err := os.Lstat(entry.dstPath)
+	if err != nil {
+		return err
+	}
+	if dstInfo.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(entry.dstPath)
+	}
+	backup, err := backupSharedPath(entry.dstPath)
+	if err != nil {
+		return err
+	}
+	report.Warn(fmt.Sprintf("Moved %s in %s to %s", entry.Path, entry.Worktree, filepath.Base(backup)))
+	return nil
+}
+
+// backupSharedPath moves path to path.bak, replacing an older backup, and returns
+// the backup path.
+func backupSharedPath(path string) (string, error) {
+	backup := path + ".bak"
+	if err := os.RemoveAll(backup); err != nil {
+		return "", err
+	}
+	if err := os.Rename(path, backup); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,167 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedStatusAndFix(t *testing.T) {
+	repoDir := initTestRepo(t)
+	linkedWt := filepath.Join(t.TempDir(), "feat-a")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-a", linkedWt, "main")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".env"), []byte("A=1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "local.json"), []byte("{}"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "models"), 0o755))
+	config := []byte(`shared:
+  - path: .env
+    strategy: copy
+  - path: local.json
+    strategy: copy
+  - path: models
+    strategy: link
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "gmc-share.yml"), config, 0o644))
+	chdir(t, repoDir)
+
+	client := NewClient(Options{})
+	_, err := client.SyncAllSharedResources()
+	require.NoError(t, err)
+	entries, err := client.SharedStatus()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ShareState{".env": ShareOK, "local.json": ShareOK, "models": ShareOK},
+		shareStates(entries))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".env"), []byte("A=2\n"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(linkedWt, "local.json")))
+	require.NoError(t, os.Remove(filepath.Join(linkedWt, "models")))
+	require.NoError(t, os.Symlink(t.TempDir(), filepath.Join(linkedWt, "models")))
+
+	entries, err = client.SharedStatus()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ShareState{".env": ShareStale, "local.json": ShareMissing, "models": ShareBroken},
+		shareStates(entries))
+
+	_, err = client.FixShared(entries)
+	require.NoError(t, err)
+	entries, err = client.SharedStatus()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ShareState{".env": ShareOK, "local.json": ShareOK, "models": ShareOK},
+		shareStates(entries))
+
+	backup, err := os.ReadFile(filepath.Join(linkedWt, ".env.bak"))
+	require.NoError(t, err)
+	assert.Equal(t, "A=1\n", string(backup), "the stale copy is kept as .bak")
+}
+
+func TestSharedStatusDoesNotSaveSlots(t *testing.T) {
+	repoDir := initTestRepo(t)
+	linkedWt := filepath.Join(t.TempDir(), "feat-a")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-a", linkedWt, "main")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".env"), []byte("PORT={{ port 3000 }}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(linkedWt, ".env"), []byte("PORT=3001\n"), 0o644))
+	config := []byte("shared:\n  - path: .env\n    strategy: copy\n    render: true\n")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "gmc-share.yml"), config, 0o644))
+	chdir(t, repoDir)
+
+	entries, err := NewClient(Options{}).SharedStatus()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ShareState{".env": ShareOK}, shareStates(entries))
+	_, err = os.Stat(filepath.Join(repoDir, ".git", "worktrees", "feat-a", slotFileName))
+	assert.True(t, os.IsNotExist(err), "status saved a slot")
+}
+
+func shareStates(entries []ShareEntry) map[string]ShareState {
+	states := make(map[string]ShareState, len(entries))
+	for _, entry := range entries {
+		states[entry.Path] = entry.State
+	}
+	return states
+}
+
+func TestSyncSharedResourcesOverwritePolicy(t *testing.T) {
+	repoDir := initTestRepo(t)
+	linkedWt := filepath.Join(t.TempDir(), "feat-a")
+	runGit(t, repoDir, "worktree", "add", "-b", "feat-a", linkedWt, "main")
+
+	for _, name := range []string{"keep.env", "always.env", "ask.env"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte("v1\n"), 0o644))
+	}
+	config := []byte(`shared:
+  - path: keep.env
+    strategy: copy
+  - path: always.env
+    strategy: copy
+    overwrite: always
+  - path: ask.env
+    strategy: copy
+    overwrite: prompt
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "gmc-share.yml"), config, 0o644))
+	chdir(t, repoDir)
+
+	var asked []string
+	client := NewClient(Options{ConfirmOverwrite: func(entry ShareEntry) bool {
+		asked = append(asked, entry.Path)
+		return true
+	}})
+	_, err := client.SyncAllSharedResources()
+	require.NoError(t, err)
+	assert.Empty(t, asked, "new copies need no confirmation")
+
+	for _, name := range []string{"keep.env", "always.env", "ask.env"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte("v2\n"), 0o644))
+	}
+	_, err = client.SyncAllSharedResources()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ask.env"}, asked)
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(linkedWt, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "v1\n", read("keep.env"), "overwrite defaults to never")
+	assert.Equal(t, "v2\n", read("always.env"))
+	assert.Equal(t, "v1\n", read("always.env.bak"))
+	assert.Equal(t, "v2\n", read("ask.env"))
+
+	forced := NewClient(Options{Force: true})
+	_, err = forced.SyncAllSharedResources()
+	require.NoError(t, err)
+	assert.Equal(t, "v2\n", read("keep.env"), "--force overrides the policy")
+	assert.Equal(t, "v1\n", read("keep.env.bak"))
+
+	// A file max_size filters out is left in place, not moved aside, even with --force.
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "big.env"), []byte("v1 is too big\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(linkedWt, "big.env"), []byte("mine\n"), 0o644))
+	filtered := append(config[:len(config):len(config)], "  - path: big.env\n    strategy: copy\n    max_size: 8B\n"...)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "gmc-share.yml"), filtered, 0o644))
+	_, err = forced.SyncAllSharedResources()
+	require.NoError(t, err)
+	assert.Equal(t, "mine\n", read("big.env"))
+	_, err = os.Stat(filepath.Join(linkedWt, "big.env.bak"))
+	assert.True(t, os.IsNotExist(err), "the filtered file was moved aside")
+	entries, err := forced.SharedStatus()
+	require.NoError(t, err)
+	assert.NotContains(t, shareStates(entries), "big.env", "status --fix would move the filtered file aside")
+
+	config = append(config, "  - path: bad.env\n    strategy: copy\n    overwrite: sometimes\n"...)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "bad.env"), []byte("x\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "gmc-share.yml"), config, 0o644))
+	report, err := client.SyncAllSharedResources()
+	require.NoError(t, err)
+	var warnings []string
+	for _, event := range report.Events {
+		if event.Level == EventWarn {
+			warnings = append(warnings, event.Message)
+		}
+	}
+	assert.Contains(t, strings.Join(warnings, "\n"), "unknown overwrite policy 'sometimes'")
+}
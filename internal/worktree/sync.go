@@ -85,9 +85,8 @@ func (c *Client) Sync(opts SyncOptions) (Report, error) {
 		return c.syncDryRun(ctx)
 	}
 
-	result, err := c.runner.RunLogged("-C", repoDir, "fetch", remote)
-	if err != nil {
-		return report, gitutil.WrapGitError("failed to fetch "+remote, result, err)
+	if _, err := c.fetch(repoDir, FetchOptions{Remote: remote}); err != nil {
+		return report, err
 	}
 
 	canFF, err := c.canFastForward(repoDir, localFull, remoteFull)
@@ -108,7 +107,7 @@ func (c *Client) Sync(opts SyncOptions) (Report, error) {
 	}
 
 	if needsUpdate {
-		result, err = c.runner.RunLogged("-C", baseWorktree, "reset", "--hard", remoteRef)
+		result, err := c.runner.RunLogged("-C", baseWorktree, "reset", "--hard", remoteRef)
 		if err != nil {
 			return report, gitutil.WrapGitError("failed to update worktree", result, err)
 		}
@@ -227,7 +226,8 @@ func (c *Client) isAncestor(repoDir string, commitA, commitB string) (bool, erro
 	if err == nil {
 		return true, nil
 	}
-	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
 		return false, nil
 	}
 
@@ -0,0 +1,117 @@
+package worktree
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/samzong/gmc/internal/gitutil"
+)
+
+// Statuses of a worktree in SyncAll.
+const (
+	SyncUpdated      = "updated"
+	SyncWouldUpdate  = "would-update"
+	SyncUpToDate     = "up-to-date"
+	SyncAhead        = "ahead"
+	SyncDiverged     = "diverged"
+	SyncSkippedDirty = "skipped-dirty"
+	SyncNoUpstream   = "no-upstream"
+	SyncDetached     = "detached"
+	SyncFailed       = "failed"
+)
+
+// SyncResult is the outcome of fast-forwarding one worktree's branch.
+type SyncResult struct {
+	Worktree string `json:"worktree"`
+	Path     string `json:"path"`
+	Branch   string `json:"branch,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+	Status   string `json:"status"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SyncAll fetches every remote, then fast-forwards the branch of each worktree to
+// its upstream. Worktrees with uncommitted changes, without an upstream, or whose
+// branch has diverged are left alone and reported. With opts.DryRun nothing is
+// fetched or changed.
+func (c *Client) SyncAll(opts SyncOptions) ([]SyncResult, error) {
+	if err := c.ensureInit(); err != nil {
+		return nil, fmt.Errorf("failed to find worktree root: %w", err)
+	}
+	if !opts.DryRun {
+		if _, err := c.fetch(c.repoDir, FetchOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	worktrees, err := c.managedWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SyncResult, 0, len(worktrees))
+	for _, wt := range worktrees {
+		results = append(results, c.syncWorktree(wt, opts.DryRun))
+	}
+	return results, nil
+}
+
+func (c *Client) syncWorktree(wt Info, dryRun bool) SyncResult {
+	result := SyncResult{Worktree: filepath.Base(wt.Path), Path: wt.Path, Branch: wt.Branch}
+	if wt.Branch == "" || wt.Branch == "(detached)" {
+		result.Branch = ""
+		result.Status = SyncDetached
+		return result
+	}
+
+	upstream, err := c.runner.Run("-C", wt.Path, "rev-parse", "--abbrev-ref", "--symbolic-full-name",
+		wt.Branch+"@{upstream}")
+	if err != nil {
+		result.Status = SyncNoUpstream
+		return result
+	}
+	result.Upstream = upstream.StdoutString(true)
+
+	local := c.refHash(wt.Path, "refs/heads/"+wt.Branch)
+	remote := c.refHash(wt.Path, wt.Branch+"@{upstream}")
+	if local == remote {
+		result.Status = SyncUpToDate
+		return result
+	}
+
+	canFF, err := c.isAncestor(wt.Path, local, remote)
+	if err != nil {
+		result.Status, result.Error = SyncFailed, err.Error()
+		return result
+	}
+	if !canFF {
+		result.Status = SyncDiverged
+		if behind, _ := c.isAncestor(wt.Path, remote, local); behind {
+			result.Status = SyncAhead
+		}
+		return result
+	}
+
+	switch c.GetWorktreeStatus(wt.Path) {
+	case "clean":
+	case "unknown":
+		result.Status, result.Error = SyncFailed, "could not read the worktree status"
+		return result
+	default:
+		result.Status = SyncSkippedDirty
+		return result
+	}
+	result.Old, result.New = local, remote
+	if dryRun {
+		result.Status = SyncWouldUpdate
+		return result
+	}
+	if res, err := c.runner.RunLogged("-C", wt.Path, "merge", "--ff-only", "--quiet", result.Upstream); err != nil {
+		result.Old, result.New = "", ""
+		result.Status, result.Error = SyncFailed, gitutil.WrapGitError("failed to fast-forward", res, err).Error()
+		return result
+	}
+	result.Status = SyncUpdated
+	return result
+}
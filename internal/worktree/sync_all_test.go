@@ -0,0 +1,80 @@
+package worktree
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncAllFastForwardsCleanWorktrees(t *testing.T) {
+	repoDir := initTestRepo(t)
+	originDir := initBareRepo(t)
+	runGit(t, repoDir, "remote", "add", "origin", originDir)
+	for _, branch := range []string{"behind", "dirty", "diverged"} {
+		runGit(t, repoDir, "branch", branch)
+	}
+	runGit(t, repoDir, "push", "-u", "origin", "main", "behind", "dirty", "diverged")
+
+	advanceDir := t.TempDir()
+	runGit(t, advanceDir, "clone", originDir, ".")
+	runGit(t, advanceDir, "config", "user.name", "Test User")
+	runGit(t, advanceDir, "config", "user.email", "test@example.com")
+	for _, branch := range []string{"behind", "dirty", "diverged"} {
+		runGit(t, advanceDir, "checkout", "-B", branch, "origin/"+branch)
+		runGit(t, advanceDir, "commit", "--allow-empty", "-m", "advance "+branch)
+		runGit(t, advanceDir, "push", "origin", branch)
+	}
+	behindTip := strings.TrimSpace(runGit(t, advanceDir, "rev-parse", "behind"))
+
+	wtRoot := t.TempDir()
+	paths := map[string]string{}
+	for _, name := range []string{"behind", "dirty", "diverged", "local-only"} {
+		paths[name] = filepath.Join(wtRoot, name)
+	}
+	runGit(t, repoDir, "worktree", "add", paths["behind"], "behind")
+	runGit(t, repoDir, "worktree", "add", paths["dirty"], "dirty")
+	runGit(t, repoDir, "worktree", "add", paths["diverged"], "diverged")
+	runGit(t, repoDir, "worktree", "add", "-b", "local-only", paths["local-only"])
+	writeFile(t, filepath.Join(paths["dirty"], "README.md"), "changed")
+	runGit(t, paths["diverged"], "commit", "--allow-empty", "-m", "local work")
+
+	chdir(t, repoDir)
+	client := NewClient(Options{})
+
+	preview, err := client.SyncAll(SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("SyncAll(dry run) error = %v", err)
+	}
+	for _, result := range preview {
+		if result.Branch == "behind" && result.Status != SyncUpToDate {
+			t.Errorf("dry run without a fetch: behind status = %s, want %s", result.Status, SyncUpToDate)
+		}
+	}
+
+	results, err := client.SyncAll(SyncOptions{})
+	if err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, result := range results {
+		got[result.Branch] = result.Status
+	}
+	want := map[string]string{
+		"main":       SyncUpToDate,
+		"behind":     SyncUpdated,
+		"dirty":      SyncSkippedDirty,
+		"diverged":   SyncDiverged,
+		"local-only": SyncNoUpstream,
+	}
+	for branch, status := range want {
+		if got[branch] != status {
+			t.Errorf("%s status = %q, want %q (all: %v)", branch, got[branch], status, got)
+		}
+	}
+	if head := strings.TrimSpace(runGit(t, paths["behind"], "rev-parse", "HEAD")); head != behindTip {
+		t.Errorf("behind worktree HEAD = %s, want %s", head, behindTip)
+	}
+	if status := runGit(t, paths["dirty"], "status", "--porcelain"); !strings.Contains(status, "README.md") {
+		t.Errorf("dirty worktree lost its change: %q", status)
+	}
+}
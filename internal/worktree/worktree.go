@@ -16,11 +16,34 @@ import (
 
 type Options struct {
 	Verbose bool
+	// Dir is the directory git runs in and relative paths resolve against.
+	// When empty, the process working directory is used.
+	Dir string
+	// Forge is the forge config key: github, gitlab or gitea. When empty, the kind is
+	// detected from the remote URL; it decides the ref AddPR fetches.
+	Forge string
+	// Parallel is the number of files copied at once for copy resources. Values below 1 mean 1.
+	Parallel int
+	// OnCopy is called after each file copied for a shared resource. Calls are serialized.
+	OnCopy func(CopyProgress)
+	// Force makes sync replace shared resources that differ from their source, as
+	// overwrite: always does. The replaced files are kept as <name>.bak.
+	Force bool
+	// ConfirmOverwrite is asked before sync replaces a resource with overwrite: prompt.
+	// When it is nil, such resources are left alone.
+	ConfirmOverwrite func(ShareEntry) bool
 }
 
 type Client struct {
-	runner  gitcmd.Runner
-	verbose bool
+	runner   gitcmd.Runner
+	dir      string
+	forge    string
+	verbose  bool
+	parallel int
+	onCopy   func(CopyProgress)
+
+	force            bool
+	confirmOverwrite func(ShareEntry) bool
 
 	once         sync.Once
 	bareRoot     string
@@ -36,13 +59,20 @@ type Client struct {
 
 func NewClient(opts Options) *Client {
 	return &Client{
-		runner:  gitcmd.Runner{Verbose: opts.Verbose},
-		verbose: opts.Verbose,
+		runner:   gitcmd.Runner{Verbose: opts.Verbose, Dir: opts.Dir},
+		dir:      opts.Dir,
+		forge:    opts.Forge,
+		verbose:  opts.Verbose,
+		parallel: opts.Parallel,
+		onCopy:   opts.OnCopy,
+
+		force:            opts.Force,
+		confirmOverwrite: opts.ConfirmOverwrite,
 	}
 }
 
 func (c *Client) init() {
-	bareRoot, err := FindBareRoot("")
+	bareRoot, err := c.findBareRoot()
 	if err == nil {
 		c.bareRoot = bareRoot
 		c.worktreeRoot = bareRoot
@@ -130,6 +160,7 @@ type Info struct {
 	Commit     string // Current commit hash
 	IsPrunable bool   // Can be pruned
 	IsLocked   bool   // Is locked
+	LockReason string // Reason given to git worktree lock, if any
 	IsBare     bool   // Is the main bare worktree
 }
 
@@ -166,7 +197,7 @@ type removeContext struct {
 func (c *Client) DetectRepositoryType(dir string) (RepoType, error) {
 	if dir == "" {
 		var err error
-		dir, err = os.Getwd()
+		dir, err = c.workingDir()
 		if err != nil {
 			return RepoTypeUnknown, fmt.Errorf("failed to get current directory: %w", err)
 		}
@@ -215,6 +246,35 @@ func (c *Client) getGitOutput(dir string, args ...string) string {
 	return result.StdoutString(true)
 }
 
+// workingDir returns the directory the client runs in.
+func (c *Client) workingDir() (string, error) {
+	if c.dir != "" {
+		return c.dir, nil
+	}
+	return os.Getwd()
+}
+
+// absPath resolves path against the directory the client runs in.
+func (c *Client) absPath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	dir, err := c.workingDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path), nil
+}
+
+// findBareRoot finds the .bare root above the directory the client runs in.
+func (c *Client) findBareRoot() (string, error) {
+	dir, err := c.workingDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return FindBareRoot(dir)
+}
+
 // FindBareRoot finds the root directory containing .bare
 func FindBareRoot(startDir string) (string, error) {
 	if startDir == "" {
@@ -261,7 +321,7 @@ func (c *Client) GetGitCommonDir() (string, error) {
 		return filepath.Clean(commonDir), nil
 	}
 
-	absCommonDir, err := filepath.Abs(commonDir)
+	absCommonDir, err := c.absPath(commonDir)
 	if err != nil {
 		return "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
@@ -344,6 +404,9 @@ func parseWorktreeList(output string) ([]Info, error) {
 				current.IsPrunable = true
 			case line == "locked":
 				current.IsLocked = true
+			case strings.HasPrefix(line, "locked "):
+				current.IsLocked = true
+				current.LockReason = strings.TrimPrefix(line, "locked ")
 			case strings.HasPrefix(line, "detached"):
 				current.Branch = "(detached)"
 			}
@@ -367,6 +430,9 @@ func (c *Client) Add(name string, opts AddOptions) (Report, error) {
 	}
 
 	c.maybeFetchForAdd(ctx, opts, &report)
+	if exists, _ := c.branchExists(ctx.branchName); !exists {
+		ctx.baseBranch = c.ensureBase(ctx.repoDir, ctx.baseBranch, &report)
+	}
 	args, branchExists := c.addArgs(ctx)
 	result, err := c.runner.RunLogged(args...)
 	if err != nil {
@@ -560,15 +626,7 @@ func (c *Client) prepareAdd(name string, opts AddOptions) (addContext, error) {
 		return addContext{}, fmt.Errorf("failed to find worktree root: %w", err)
 	}
 
-	dirName := strings.ReplaceAll(name, "/", "--")
-
-	var targetPath string
-	if c.repoDir != c.worktreeRoot {
-		targetPath = filepath.Join(c.worktreeRoot, dirName)
-	} else {
-		targetPath = filepath.Join(filepath.Dir(c.worktreeRoot), filepath.Base(c.worktreeRoot)+"--"+dirName)
-	}
-
+	targetPath := c.worktreeTargetPath(name)
 	if _, err := os.Stat(targetPath); err == nil {
 		return addContext{}, fmt.Errorf("directory already exists: %s", targetPath)
 	}
@@ -587,12 +645,24 @@ func (c *Client) prepareAdd(name string, opts AddOptions) (addContext, error) {
 	}, nil
 }
 
+// worktreeTargetPath returns where the worktree called name lives: a sibling of the
+// other worktrees in the bare layout, or <repo>--<name> next to a normal repository.
+func (c *Client) worktreeTargetPath(name string) string {
+	dirName := strings.ReplaceAll(name, "/", "--")
+	if c.repoDir != c.worktreeRoot {
+		return filepath.Join(c.worktreeRoot, dirName)
+	}
+	return filepath.Join(filepath.Dir(c.worktreeRoot), filepath.Base(c.worktreeRoot)+"--"+dirName)
+}
+
 func (c *Client) maybeFetchForAdd(ctx addContext, opts AddOptions, report *Report) {
 	if !opts.Fetch {
 		return
 	}
 	report.Info("Fetching latest changes...")
-	_ = c.runner.RunStreamingLogged("-C", ctx.repoDir, "fetch", "--all")
+	if _, err := c.fetch(ctx.repoDir, FetchOptions{Progress: os.Stderr}); err != nil {
+		report.Warn(fmt.Sprintf("Warning: %v", err))
+	}
 }
 
 func (c *Client) addArgs(ctx addContext) ([]string, bool) {
@@ -806,7 +876,7 @@ func (c *Client) Dup(opts DupOptions) (*DupResult, error) {
 	targetRoot := c.dupTargetRoot()
 
 	relativeBase := targetRoot
-	if cwd, cwdErr := os.Getwd(); cwdErr == nil {
+	if cwd, cwdErr := c.workingDir(); cwdErr == nil {
 		relativeBase = cwd
 	}
 	var taskFiles []dupTaskFile
@@ -394,6 +394,45 @@ func TestCloneOptions(t *testing.T) {
 	}
 }
 
+func TestCloneForkTracksUpstreamDefaultBranch(t *testing.T) {
+	upstreamDir := initBareRepo(t)
+	sourceDir := initTestRepoWithBranch(t, "trunk")
+	runGit(t, sourceDir, "remote", "add", "upstream", upstreamDir)
+	runGit(t, sourceDir, "push", "upstream", "trunk")
+	runGit(t, upstreamDir, "symbolic-ref", "HEAD", "refs/heads/trunk")
+	forkDir := initBareRepo(t)
+	runGit(t, sourceDir, "push", forkDir, "trunk")
+	runGit(t, forkDir, "symbolic-ref", "HEAD", "refs/heads/trunk")
+
+	workDir := t.TempDir()
+	chdir(t, workDir)
+	report, err := NewClient(Options{}).Clone(forkDir, CloneOptions{Name: "fork", Upstream: upstreamDir})
+	if err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+
+	bareDir := filepath.Join(workDir, "fork", ".bare")
+	if got := strings.TrimSpace(runGit(t, bareDir, "config", "remote.upstream.fetch")); got !=
+		"+refs/heads/*:refs/remotes/upstream/*" {
+		t.Errorf("remote.upstream.fetch = %q", got)
+	}
+	if got := strings.TrimSpace(runGit(t, bareDir, "rev-parse", "--abbrev-ref", "trunk@{upstream}")); got !=
+		"upstream/trunk" {
+		t.Errorf("trunk tracks %q, want upstream/trunk", got)
+	}
+
+	var messages []string
+	for _, event := range report.Events {
+		messages = append(messages, event.Message)
+	}
+	summary := strings.Join(messages, "\n")
+	for _, want := range []string{"trunk tracks upstream/trunk", "gmc wt sync"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary does not contain %q:\n%s", want, summary)
+		}
+	}
+}
+
 func TestResolveBaseBranch_OriginPreferred(t *testing.T) {
 	repoDir := initTestRepo(t)
 